@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -15,7 +16,14 @@ func main() {
 	defer stop()
 
 	if err := app.New().Run(ctx, os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		code := 1
+		var exitErr *app.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+		}
+		if err.Error() != "" {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		os.Exit(code)
 	}
 }
@@ -6,6 +6,7 @@ require (
 	github.com/emersion/go-webdav v0.7.0
 	github.com/getsentry/sentry-go v0.40.0
 	github.com/stretchr/testify v1.8.4
+	golang.org/x/term v0.13.0
 	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/api v0.28.4
 	k8s.io/apimachinery v0.28.4
@@ -14,6 +15,7 @@ require (
 
 require (
 	github.com/evanphx/json-patch v4.12.0+incompatible // indirect
+	github.com/moby/spdystream v0.2.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 )
@@ -42,7 +44,6 @@ require (
 	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/oauth2 v0.8.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
-	golang.org/x/term v0.13.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
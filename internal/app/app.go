@@ -8,8 +8,14 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
+	// general.timezone is checked against the embedded zone database, so the
+	// machine running the tool needs no tzdata
+	_ "time/tzdata"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/i18n"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
 	"github.com/Goalt/personal-server/internal/modules"
 	"gopkg.in/yaml.v2"
@@ -30,9 +36,31 @@ type ConfigLoader func(path string) (*config.Config, error)
 type App struct {
 	registry     *modules.Registry
 	configLoader ConfigLoader
+	stdin        io.Reader
 	stdout       io.Writer
 	stderr       io.Writer
 	logger       logger.Logger
+	// clients reach the cluster; they are built from the kubernetes config
+	// section once the config is loaded
+	clients *k8s.Clients
+}
+
+// ExitError is returned by commands whose exit code carries meaning, such as
+// healthcheck. Err may be nil when the command already printed its result.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
 }
 
 // New creates a new App with default dependencies
@@ -41,6 +69,7 @@ func New(opts ...Option) *App {
 	app := &App{
 		registry:     modules.DefaultRegistry(log),
 		configLoader: config.LoadConfig,
+		stdin:        os.Stdin,
 		stdout:       os.Stdout,
 		stderr:       os.Stderr,
 		logger:       log,
@@ -68,6 +97,11 @@ func (a *App) Run(ctx context.Context, args []string) error {
 		h       = fs.Bool("h", false, "Show help information (shorthand)")
 		version = fs.Bool("version", false, "Show version information")
 		v       = fs.Bool("v", false, "Show version information (shorthand)")
+		noColor = fs.Bool("no-color", false, "Disable colored output")
+		noEmoji = fs.Bool("no-emoji", false, "Replace emoji with plain ASCII markers")
+
+		kubeconfig  = fs.String("kubeconfig", "", "Path to the kubeconfig file")
+		kubeContext = fs.String("context", "", "Kubeconfig context to use")
 	)
 
 	fs.Usage = func() { a.printUsage() }
@@ -75,6 +109,17 @@ func (a *App) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	// Messages are localized from the environment until the config is loaded
+	if lang, err := i18n.Detect(""); err == nil {
+		a.setLanguage(lang)
+	}
+	if styled, ok := a.logger.(logger.Styled); ok && (*noColor || *noEmoji) {
+		style := styled.Style()
+		style.Color = style.Color && !*noColor
+		style.Emoji = style.Emoji && !*noEmoji
+		styled.SetStyle(style)
+	}
+
 	// Handle help flags
 	if *help || *h {
 		a.printUsage()
@@ -108,6 +153,11 @@ func (a *App) Run(ctx context.Context, args []string) error {
 		return a.handleUpdateCommand(ctx)
 	}
 
+	// Handle contributor tooling (doesn't require config)
+	if cmd == "devtool" {
+		return a.handleDevtoolCommand(cmdArgs[1:])
+	}
+
 	// Handle backup decrypt command (doesn't require config)
 	if cmd == "backup" {
 		decryptCmd := flag.NewFlagSet("backup", flag.ContinueOnError)
@@ -123,19 +173,67 @@ func (a *App) Run(ctx context.Context, args []string) error {
 		}
 	}
 
+	// Handle config validate command (reports every problem, so it loads the
+	// config without stopping at the first one)
+	if cmd == "config" && len(cmdArgs) > 1 && cmdArgs[1] == "validate" {
+		return a.handleConfigValidateCommand(configFile)
+	}
+
 	cfg, err := a.configLoader(configFile)
 	if err != nil {
 		return fmt.Errorf("loading config %s: %w", configFile, err)
 	}
 
+	if problems := generalProblems(cfg.General); len(problems) > 0 {
+		return problems[0]
+	}
+	if cfg.General.Language != "" {
+		lang, _ := i18n.Parse(cfg.General.Language)
+		a.setLanguage(lang)
+	}
+
+	// All modules share one Kubernetes client built from these options
+	clientOptions, err := kubernetesClientOptions(cfg.General.Kubernetes)
+	if err != nil {
+		return err
+	}
+	if *kubeconfig != "" {
+		clientOptions.Kubeconfig = *kubeconfig
+	}
+	if *kubeContext != "" {
+		clientOptions.Context = *kubeContext
+	}
+	a.clients = k8s.NewClients(clientOptions)
+	a.registry = a.registry.WithClients(a.clients)
+
 	// Handle config command separately (not a module)
 	if cmd == "config" {
 		if len(cmdArgs) > 1 && cmdArgs[1] == "edit" {
 			return a.handleConfigEditCommand(cfg, cmdArgs[2:])
 		}
+		if len(cmdArgs) > 1 && cmdArgs[1] == "migrate" {
+			return a.handleConfigMigrateCommand(cfg)
+		}
+		if len(cmdArgs) > 1 && cmdArgs[1] == "explain" {
+			return a.handleConfigExplainCommand(cfg, cmdArgs[2:])
+		}
 		return a.handleConfigCommand(cfg)
 	}
 
+	if len(cfg.AppliedMigrations()) > 0 {
+		a.logger.Warn("Config file %s uses an older schema version; run '%s config migrate' to upgrade it\n", configFile, Name)
+	}
+
+	// Handle secrets command (generating credentials and inspecting drift)
+	if cmd == "secrets" {
+		return a.handleSecretsCommand(ctx, cfg, cmdArgs[1:])
+	}
+
+	// Handle certs command (client certificates for mTLS ingresses)
+	if cmd == "certs" {
+		return a.handleCertsCommand(ctx, cfg, cmdArgs[1:])
+	}
+
 	// Handle global backup command
 	if cmd == "backup" {
 		// potential subcommand
@@ -148,6 +246,21 @@ func (a *App) Run(ctx context.Context, args []string) error {
 			return a.handleBackupSchedule(ctx, cfg)
 		}
 
+		// Handle "backup daemon" subcommand (scheduled backups in the foreground)
+		if len(cmdArgs) > 1 && cmdArgs[1] == "daemon" {
+			return a.handleBackupDaemon(ctx, cfg)
+		}
+
+		// Handle "backup list" subcommand
+		if len(cmdArgs) > 1 && cmdArgs[1] == "list" {
+			return a.handleBackupList(ctx, cfg)
+		}
+
+		// Handle "backup restore <archive>" subcommand
+		if len(cmdArgs) > 1 && cmdArgs[1] == "restore" {
+			return a.handleBackupRestore(ctx, cfg, cmdArgs[2:])
+		}
+
 		// Handle "backup download <file>" subcommand
 		if len(cmdArgs) > 1 && cmdArgs[1] == "download" {
 			if len(cmdArgs) < 3 {
@@ -156,7 +269,54 @@ func (a *App) Run(ctx context.Context, args []string) error {
 			return a.handleBackupDownload(ctx, cfg, cmdArgs[2])
 		}
 
-		return a.handleGlobalBackupCommand(ctx, cfg)
+		return a.handleBackupCommand(ctx, cfg)
+	}
+
+	// Handle healthcheck (exit code for monitoring)
+	if cmd == "healthcheck" {
+		return a.handleHealthcheckCommand(ctx, cfg, cmdArgs[1:])
+	}
+
+	// Handle run (one-off Job from an image)
+	if cmd == "run" {
+		return a.handleRunCommand(ctx, cmdArgs[1:])
+	}
+
+	// Handle status (summary of every configured component)
+	if cmd == "status" {
+		return a.handleStatusCommand(ctx, cfg, cmdArgs[1:])
+	}
+
+	// Handle urls (public and in-cluster endpoints of every service)
+	if cmd == "urls" {
+		return a.handleURLsCommand(ctx, cfg, cmdArgs[1:])
+	}
+
+	// Handle report usage (estimated power cost per workload)
+	if cmd == "report" {
+		return a.handleReportCommand(ctx, cfg, cmdArgs[1:])
+	}
+
+	// Handle apply --all (every configured module, pet project and ingress)
+	if cmd == "apply" {
+		return a.handleApplyCommand(ctx, cfg, cmdArgs[1:])
+	}
+	// Handle clean-all (every configured component, dependents first)
+	if cmd == "clean-all" {
+		return a.handleCleanAllCommand(ctx, cfg, cmdArgs[1:])
+	}
+	if cmd == "apply-all" {
+		return a.handleApplyCommand(ctx, cfg, append([]string{"--all"}, cmdArgs[1:]...))
+	}
+
+	// Handle operator (long-running reconciler of the generated objects)
+	if cmd == "operator" {
+		return a.handleOperatorCommand(ctx, cfg, cmdArgs[1:])
+	}
+
+	// Handle crd (PersonalServerModule custom resource definition)
+	if cmd == "crd" {
+		return a.handleCRDCommand(ctx, cfg, cmdArgs[1:])
 	}
 
 	// Use registry for module commands
@@ -165,6 +325,56 @@ func (a *App) Run(ctx context.Context, args []string) error {
 		return fmt.Errorf("%s: %w", cmd, err)
 	}
 
+	// Handle "<module> status" (with the workload's warning events) and
+	// "<module> status --watch" (needs the module's namespace)
+	if len(cmdArgs) == 2 && cmdArgs[1] == "status" {
+		return a.handleModuleStatus(ctx, cfg, cmd, module)
+	}
+	if len(cmdArgs) > 2 && cmdArgs[1] == "status" {
+		return a.handleStatusWatch(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+
+	// Handle "<module> clean" (checks which components depend on the module)
+	if len(cmdArgs) > 1 && cmdArgs[1] == "clean" {
+		return a.handleCleanCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+
+	// Handle "<module> apply" and "<module> history" (change history on the
+	// module's Deployment)
+	if len(cmdArgs) > 1 && cmdArgs[1] == "apply" {
+		return a.handleModuleApply(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && cmdArgs[1] == "diff" {
+		return a.handleDiffCommand(ctx, cfg, cmd, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && cmdArgs[1] == "history" {
+		return a.handleHistoryCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	// Handle "<module> logs", "<module> upgrade", "<module> scale",
+	// "<module> restart" and "<module> shell" (the module's Deployment and the
+	// pods found by its app label)
+	if len(cmdArgs) > 1 && cmdArgs[1] == "logs" {
+		return a.handleLogsCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && cmdArgs[1] == "healthcheck" {
+		return a.handleModuleHealthcheck(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && cmdArgs[1] == "upgrade" {
+		return a.handleUpgradeCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && cmdArgs[1] == "scale" {
+		return a.handleScaleCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && cmdArgs[1] == "restart" {
+		return a.handleRestartCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && (cmdArgs[1] == "shell" || cmdArgs[1] == "exec") {
+		return a.handleShellCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+	if len(cmdArgs) > 1 && cmdArgs[1] == "run-job" {
+		return a.handleRunJobCommand(ctx, cfg, cmd, module, cmdArgs[2:])
+	}
+
 	return a.handleModuleCommand(ctx, cmdArgs[1:], module)
 }
 
@@ -208,6 +418,21 @@ func (a *App) handleModuleCommand(ctx context.Context, args []string, module mod
 			return dbManager.RemoveDB(ctx, args[1:])
 		}
 		return fmt.Errorf("module '%s' does not support remove-db", module.Name())
+	case "report":
+		if reporter, ok := module.(modules.Reporter); ok {
+			return reporter.Report(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support report", module.Name())
+	case "maintain":
+		if maintainer, ok := module.(modules.Maintainer); ok {
+			return maintainer.Maintain(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support maintain", module.Name())
+	case "promote":
+		if promoter, ok := module.(modules.Promoter); ok {
+			return promoter.Promote(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support promote", module.Name())
 	case "notify":
 		// Special case for ssh-login-notifier notify command
 		// Expected args: [user, ip, ssh_connection]
@@ -233,6 +458,36 @@ func (a *App) handleModuleCommand(ctx context.Context, args []string, module mod
 			return runner.CodeServeWeb(ctx)
 		}
 		return fmt.Errorf("module '%s' does not support code-serve-web", module.Name())
+	case "upload":
+		if uploader, ok := module.(modules.Uploader); ok {
+			return uploader.Upload(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support upload", module.Name())
+	case "create-app":
+		if creator, ok := module.(modules.AppCreator); ok {
+			return creator.CreateApp(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support create-app", module.Name())
+	case "api-key":
+		if generator, ok := module.(modules.APIKeyGenerator); ok {
+			return generator.APIKey(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support api-key", module.Name())
+	case "dkim-key":
+		if generator, ok := module.(modules.DKIMKeyGenerator); ok {
+			return generator.DKIMKey(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support dkim-key", module.Name())
+	case "create-user":
+		if creator, ok := module.(modules.UserCreator); ok {
+			return creator.CreateUser(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support create-user", module.Name())
+	case "preauth-key":
+		if generator, ok := module.(modules.PreauthKeyGenerator); ok {
+			return generator.PreauthKey(ctx, args[1:])
+		}
+		return fmt.Errorf("module '%s' does not support preauth-key", module.Name())
 	default:
 		return fmt.Errorf("unknown subcommand: %s\nAvailable subcommands: %s", subcommand, availableSubcommands)
 	}
@@ -246,16 +501,58 @@ func (a *App) printUsage() {
 
 	a.logger.Println("Options:")
 	a.logger.Println("  -c, --config   Path to configuration file (default: config.yaml)")
+	a.logger.Println("  --kubeconfig   Path to the kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	a.logger.Println("  --context      Kubeconfig context to use instead of its current-context")
 	a.logger.Println("  -h, --help     Show this help message")
 	a.logger.Println("  -v, --version  Show version information")
+	a.logger.Println("  --no-color     Disable colored output (also honours NO_COLOR)")
+	a.logger.Println("  --no-emoji     Replace emoji with plain ASCII markers")
 
 	a.logger.Println("\nCommands:")
 	a.logger.Println("  help                          Show help information")
 	a.logger.Println("  update                        Check for updates and update the CLI to the latest version")
 	a.logger.Println("  config                        Parse and print loaded configuration")
+	a.logger.Println("  devtool new-module <name>     Scaffold a new module package with tests and registry wiring")
 	a.logger.Println("  config edit <module> image <value>  Edit a module's image in the configuration file")
+	a.logger.Println("  config migrate                Upgrade the configuration file to the current schema version")
+	a.logger.Println("  config explain [module]       List supported config keys with types, defaults and required flags")
+	a.logger.Println("  config validate               Check the configuration file and list every problem found")
+	a.logger.Println("  secrets generate <module>     Write random values for a module's missing passwords and secrets to the config")
+	a.logger.Println("  secrets list                  Summarize how the Secrets modules generate differ from the cluster")
+	a.logger.Println("  secrets get <module> [--reveal]  Compare a module's Secrets key by key, showing hashes unless --reveal")
+	a.logger.Println("  apply --all [--concurrency N] Apply all configured components in dependency order, in parallel")
+	a.logger.Println("  apply-all [--continue-on-error]  Same as apply --all; --continue-on-error also applies dependents of failures")
+	a.logger.Println("  <module> apply --server-side  Create or update a module's objects in place with server-side apply")
+	a.logger.Println("  <module> apply --dry-run      Report what a server-side apply would change, changing nothing")
+	a.logger.Println("  <module> apply --wait         Wait for the Deployment to become ready, listing pod events if it does not")
+	a.logger.Println("  <module> diff                 Show field by field how a module's objects differ from the cluster")
+	a.logger.Println("  clean-all [--keep-data]       Delete the objects of every component after confirming; --keep-data keeps PVCs")
+	a.logger.Println("  status [--all]                Summarize the status of all configured components")
+	a.logger.Println("  healthcheck                   Check every workload, exiting 0 (OK), 1 (WARNING) or 2 (CRITICAL)")
+	a.logger.Println("  operator [--from-cluster]     Keep generated resources in place: recreate deleted and revert drifted objects")
+	a.logger.Println("  operator --crd                Also deploy the modules defined as PersonalServerModule resources")
+	a.logger.Println("  operator --webhook :8080      Serve POST /deploy/<component> for CI pipelines to deploy new image tags")
+	a.logger.Println("  crd install|manifest|export   Install the PersonalServerModule CRD or export config modules as resources")
+	a.logger.Println("  <module> status --watch       Stream live pod, deployment and PVC changes for a module")
+	a.logger.Println("  <module> history [--since 7d] Show who applied or deployed what and when, recorded on the Deployment")
+	a.logger.Println("  <module> logs [-f] [--since 1h]  Print or follow the logs of a module's pods; --container picks one container")
+	a.logger.Println("  <module> shell [-- command]   Open a shell in a module's pod, e.g. psql for postgres; exec is an alias")
+	a.logger.Println("  <module> run-job -- command   Run a command once as a Job with the module's image and env, e.g. migrations")
+	a.logger.Println("  <module> restart [--wait]     Roll out new pods of a module's Deployment; --wait waits for the rollout")
+	a.logger.Println("  <module> scale <replicas>     Set a module's replicas; scale 0 suspends it until scaled up again")
+	a.logger.Println("  <module> upgrade              Back up a module, move it to the configured images and wait for the rollout")
+	a.logger.Println("  <module> healthcheck          Check a module's workload, exiting 0 (OK), 1 (WARNING) or 2 (CRITICAL)")
+	a.logger.Println("  <module> clean [--cascade]    Remove a module; --cascade also removes the modules depending on it")
+	a.logger.Println("  run --image IMAGE [-- command]  Run a one-off Job, print its logs and exit with its exit code")
+	a.logger.Println("  urls                          List public URLs, cluster endpoints and credential secrets of services")
+	a.logger.Println("  report usage [--period 30d]   Estimate CPU, memory, storage and power cost per workload")
 	a.logger.Println("  backup                        Trigger a global backup including all modules")
+	a.logger.Println("  backup daemon                 Run the global backup on backup.cron in the foreground, instead of a crontab")
+	a.logger.Println("  backup list                   List local and WebDAV backups with their timestamp, size and modules")
 	a.logger.Println("  backup download <file>        Download a backup archive from WebDAV")
+	a.logger.Println("  backup restore <archive> [--modules a,b]  Download, decrypt and restore a global backup")
+	a.logger.Println("  certs issue-client <name>     Issue a client certificate for mTLS-protected ingresses")
+	a.logger.Println("  certs status [--threshold N]  Report TLS certificate issuer/expiry for public hostnames")
 	a.logger.Println("\nModules:")
 	for _, line := range a.moduleUsageLines() {
 		a.logger.Println(line)
@@ -263,7 +560,7 @@ func (a *App) printUsage() {
 }
 
 func moduleSubcommands(module modules.Module) []string {
-	subcommands := []string{"generate", "apply", "clean", "status", "doc"}
+	subcommands := []string{"generate", "apply", "diff", "clean", "status", "doc", "history", "logs", "shell", "restart", "scale", "upgrade", "healthcheck", "run-job"}
 
 	if _, ok := module.(modules.Backuper); ok {
 		subcommands = append(subcommands, "backup")
@@ -274,6 +571,15 @@ func moduleSubcommands(module modules.Module) []string {
 	if _, ok := module.(modules.DatabaseManager); ok {
 		subcommands = append(subcommands, "add-db", "remove-db")
 	}
+	if _, ok := module.(modules.Reporter); ok {
+		subcommands = append(subcommands, "report")
+	}
+	if _, ok := module.(modules.Maintainer); ok {
+		subcommands = append(subcommands, "maintain")
+	}
+	if _, ok := module.(modules.Promoter); ok {
+		subcommands = append(subcommands, "promote")
+	}
 	if _, ok := module.(modules.Notifier); ok {
 		subcommands = append(subcommands, "notify")
 	}
@@ -286,6 +592,24 @@ func moduleSubcommands(module modules.Module) []string {
 	if _, ok := module.(modules.CodeServeWebRunner); ok {
 		subcommands = append(subcommands, "code-serve-web")
 	}
+	if _, ok := module.(modules.Uploader); ok {
+		subcommands = append(subcommands, "upload")
+	}
+	if _, ok := module.(modules.AppCreator); ok {
+		subcommands = append(subcommands, "create-app")
+	}
+	if _, ok := module.(modules.APIKeyGenerator); ok {
+		subcommands = append(subcommands, "api-key")
+	}
+	if _, ok := module.(modules.DKIMKeyGenerator); ok {
+		subcommands = append(subcommands, "dkim-key")
+	}
+	if _, ok := module.(modules.UserCreator); ok {
+		subcommands = append(subcommands, "create-user")
+	}
+	if _, ok := module.(modules.PreauthKeyGenerator); ok {
+		subcommands = append(subcommands, "preauth-key")
+	}
 
 	return subcommands
 }
@@ -348,3 +672,25 @@ func (a *App) handleConfigCommand(cfg *config.Config) error {
 	a.logger.Print("%s", string(output))
 	return nil
 }
+
+// setLanguage switches the output language when the logger supports it
+func (a *App) setLanguage(lang i18n.Language) {
+	if styled, ok := a.logger.(logger.Styled); ok {
+		style := styled.Style()
+		style.Language = lang
+		styled.SetStyle(style)
+	}
+}
+
+// kubernetesClientOptions converts the general.kubernetes config section
+func kubernetesClientOptions(c config.KubernetesConfig) (k8s.ClientOptions, error) {
+	options := k8s.ClientOptions{QPS: c.QPS, Burst: c.Burst, Kubeconfig: c.Kubeconfig, Context: c.Context}
+	if c.Timeout != "" {
+		timeout, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return options, fmt.Errorf("invalid general.kubernetes.timeout %q: %w", c.Timeout, err)
+		}
+		options.Timeout = timeout
+	}
+	return options, nil
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+func TestKubernetesClientOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.KubernetesConfig
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{name: "empty", cfg: config.KubernetesConfig{}},
+		{name: "custom", cfg: config.KubernetesConfig{QPS: 50, Burst: 100, Timeout: "1m"}, wantTimeout: time.Minute},
+		{name: "cluster", cfg: config.KubernetesConfig{Kubeconfig: "/etc/personal-server/kubeconfig", Context: "vps"}},
+		{name: "invalid timeout", cfg: config.KubernetesConfig{Timeout: "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := kubernetesClientOptions(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("kubernetesClientOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if options.QPS != tt.cfg.QPS || options.Burst != tt.cfg.Burst || options.Timeout != tt.wantTimeout ||
+				options.Kubeconfig != tt.cfg.Kubeconfig || options.Context != tt.cfg.Context {
+				t.Errorf("kubernetesClientOptions() = %+v", options)
+			}
+		})
+	}
+}
+
+func TestRunRejectsUnsupportedLanguage(t *testing.T) {
+	app := New(
+		WithLogger(logger.NewNopLogger()),
+		WithConfigLoader(func(path string) (*config.Config, error) {
+			return &config.Config{General: config.GeneralConfig{Language: "klingon"}}, nil
+		}),
+	)
+
+	err := app.Run(context.Background(), []string{"status", "--all"})
+	if err == nil || !strings.Contains(err.Error(), "general.language") {
+		t.Fatalf("expected general.language error, got %v", err)
+	}
+}
+
+func TestRunRejectsUnknownTimezone(t *testing.T) {
+	app := New(
+		WithLogger(logger.NewNopLogger()),
+		WithConfigLoader(func(path string) (*config.Config, error) {
+			return &config.Config{General: config.GeneralConfig{Timezone: "Mars/Olympus_Mons"}}, nil
+		}),
+	)
+
+	err := app.Run(context.Background(), []string{"status", "--all"})
+	if err == nil || !strings.Contains(err.Error(), "general.timezone") {
+		t.Fatalf("expected general.timezone error, got %v", err)
+	}
+}
+
+func TestRunRejectsInvalidPodEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		general config.GeneralConfig
+		want    string
+	}{
+		{name: "proxy without scheme", general: config.GeneralConfig{Proxy: config.ProxyConfig{HTTPS: "proxy.corp:3128"}}, want: "general.proxy.https"},
+		{name: "relative CA bundle", general: config.GeneralConfig{CABundle: "certs/ca.crt"}, want: "general.caBundle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(
+				WithLogger(logger.NewNopLogger()),
+				WithConfigLoader(func(path string) (*config.Config, error) {
+					return &config.Config{General: tt.general}, nil
+				}),
+			)
+
+			err := app.Run(context.Background(), []string{"status", "--all"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %s error, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRunUsesConfiguredLanguage(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(
+		WithLogger(logger.NewStdLogger(&buf)),
+		WithConfigLoader(func(path string) (*config.Config, error) {
+			return &config.Config{General: config.GeneralConfig{Language: "ru"}}, nil
+		}),
+	)
+
+	if err := app.Run(context.Background(), []string{"status", "--all"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Не настроены модули") {
+		t.Errorf("expected Russian output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,356 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultApplyConcurrency = 4
+	defaultReadyTimeout     = 5 * time.Minute
+	readyPollInterval       = 2 * time.Second
+)
+
+// applyUnit is a module, pet project or ingress scheduled by apply --all
+type applyUnit struct {
+	component
+	deps []string
+	// gating is set when other units wait for this one to become ready
+	gating bool
+	out    *bytes.Buffer
+}
+
+type applyState int
+
+const (
+	applyPending applyState = iota
+	applyDone
+	applyFailed
+	applySkipped
+)
+
+// applyResult records the outcome of a single unit
+type applyResult struct {
+	name  string
+	state applyState
+	err   error
+}
+
+func (a *App) handleApplyCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	applyCmd := flag.NewFlagSet("apply", flag.ContinueOnError)
+	applyCmd.SetOutput(io.Discard)
+	all := applyCmd.Bool("all", false, "Apply every configured module, pet project and ingress")
+	concurrency := applyCmd.Int("concurrency", defaultApplyConcurrency, "Maximum number of modules applied at once")
+	timeout := applyCmd.Duration("timeout", defaultReadyTimeout, "How long to wait for a dependency to become ready")
+	serverSide := applyCmd.Bool("server-side", false, "Create or update the generated objects with server-side apply")
+	dryRun := applyCmd.Bool("dry-run", false, "Report what a server-side apply would change without changing anything")
+	continueOnError := applyCmd.Bool("continue-on-error", false, "Apply the dependents of a component that failed instead of skipping them")
+	wait := applyCmd.Bool("wait", false, "Wait for every component to become ready, not only the ones others depend on")
+	usage := fmt.Sprintf("usage: %s apply --all [--concurrency N] [--timeout 5m] [--server-side] [--dry-run] [--continue-on-error] [--wait]", Name)
+	if err := applyCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if !*all {
+		return fmt.Errorf("%s", usage)
+	}
+	if *concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	units, err := a.applyUnits(cfg)
+	if err != nil {
+		return err
+	}
+	if len(units) == 0 {
+		a.logger.Warn("Nothing to apply: no modules, pet projects or ingresses configured\n")
+		return nil
+	}
+
+	// The client waits for gating units and records each apply in the
+	// component's history
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	user := historyUser()
+
+	// Generate changes the working directory, so every unit is rendered
+	// before the units run in parallel
+	var applier *serverSideApplier
+	rendered := map[string][]*unstructured.Unstructured{}
+	renderErrs := map[string]error{}
+	if *serverSide || *dryRun {
+		if applier, err = newServerSideApplier(a.clients, *dryRun); err != nil {
+			return err
+		}
+		for _, u := range units {
+			rendered[u.name], renderErrs[u.name] = a.renderQuiet(ctx, cfg, u.name)
+		}
+	}
+
+	a.logger.Info("Applying %d components with up to %d in parallel...\n\n", len(units), *concurrency)
+	start := time.Now()
+
+	results, err := a.runApplyGraph(ctx, units, *concurrency, *continueOnError, func(ctx context.Context, u *applyUnit) error {
+		deployment := modules.DeploymentName(u.module)
+		if *dryRun {
+			// Nothing changed, so there is nothing to record or wait for
+			deployment = ""
+		}
+		if !*dryRun {
+			if err := ensureNamespace(ctx, client, cfg, u.namespace, a.bufferLogger(u.out)); err != nil {
+				return err
+			}
+		}
+		var prior []historyEntry
+		if u.kind != kindIngress && deployment != "" {
+			prior, _ = readHistory(ctx, client, u.namespace, deployment)
+		}
+		if applier != nil {
+			if renderErrs[u.name] != nil {
+				return renderErrs[u.name]
+			}
+			if err := applier.apply(ctx, rendered[u.name], a.bufferLogger(u.out)); err != nil {
+				return err
+			}
+		} else if err := u.module.Apply(ctx); err != nil {
+			return err
+		}
+		if u.kind != kindIngress && deployment != "" {
+			entry := historyEntry{Action: historyActionApply, ConfigHash: componentConfigHash(cfg, u.name), By: user}
+			if err := recordHistory(ctx, client, u.namespace, deployment, prior, entry); err != nil {
+				a.bufferLogger(u.out).Warn("Failed to record the apply in the history of %s: %v\n", u.name, err)
+			}
+		}
+		if (!u.gating && !*wait) || deployment == "" || u.kind == kindIngress {
+			return nil
+		}
+		return waitForDeployment(ctx, client, u.namespace, deployment, modules.AppLabel(u.module), *timeout)
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.printApplySummary(results, time.Since(start))
+}
+
+// applyUnits builds the units for every configured component and resolves
+// module dependencies to unit names
+func (a *App) applyUnits(cfg *config.Config) ([]*applyUnit, error) {
+	outputs := make(map[string]*bytes.Buffer)
+	components, err := a.components(cfg, func(name string) logger.Logger {
+		outputs[name] = &bytes.Buffer{}
+		return a.bufferLogger(outputs[name])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([]*applyUnit, 0, len(components))
+	for _, c := range components {
+		units = append(units, &applyUnit{component: c, out: outputs[c.name]})
+	}
+
+	byName := make(map[string]*applyUnit, len(units))
+	for _, u := range units {
+		byName[u.name] = u
+	}
+	for _, u := range units {
+		u.deps = a.dependencies(cfg, u.component)
+		for _, dep := range u.deps {
+			byName[dep].gating = true
+		}
+	}
+
+	return units, nil
+}
+
+// bufferLogger returns a logger writing to w in the same style as the app
+// logger, so buffered output looks the same once flushed
+func (a *App) bufferLogger(w io.Writer) logger.Logger {
+	if styled, ok := a.logger.(logger.Styled); ok {
+		return logger.NewStyledLogger(w, styled.Style())
+	}
+	return logger.NewStdLogger(w)
+}
+
+// runApplyGraph applies units with at most workers running at once. A unit
+// starts only after all of its dependencies were applied successfully; units
+// whose dependencies failed are skipped, or with continueOnError started once
+// their dependencies finished either way. Each unit's output is flushed to the
+// app logger as one block once the unit finishes, so logs never interleave.
+func (a *App) runApplyGraph(ctx context.Context, units []*applyUnit, workers int, continueOnError bool, apply func(context.Context, *applyUnit) error) ([]applyResult, error) {
+	byName := make(map[string]*applyUnit, len(units))
+	for _, u := range units {
+		byName[u.name] = u
+	}
+
+	pending := make(map[string]int, len(units))
+	dependents := make(map[string][]string)
+	for _, u := range units {
+		pending[u.name] = len(u.deps)
+		for _, dep := range u.deps {
+			dependents[dep] = append(dependents[dep], u.name)
+		}
+	}
+	if err := checkApplyCycles(units, pending, dependents); err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]applyState, len(units))
+	errs := make(map[string]error)
+	var ready []string
+	for _, u := range units {
+		if pending[u.name] == 0 {
+			ready = append(ready, u.name)
+		}
+	}
+
+	// skip marks name and everything that transitively depends on it
+	var skip func(name string)
+	skip = func(name string) {
+		for _, d := range dependents[name] {
+			if state[d] == applyPending {
+				state[d] = applySkipped
+				skip(d)
+			}
+		}
+	}
+
+	type finished struct {
+		name string
+		err  error
+	}
+	done := make(chan finished)
+	running, completed := 0, 0
+
+	for completed < len(units) {
+		for running < workers && len(ready) > 0 {
+			name := ready[0]
+			ready = ready[1:]
+			running++
+			a.logger.Progress("Applying %s\n", name)
+			go func(u *applyUnit) {
+				done <- finished{name: u.name, err: apply(ctx, u)}
+			}(byName[name])
+		}
+
+		if running == 0 {
+			// Everything left was skipped because a dependency failed
+			break
+		}
+
+		f := <-done
+		running--
+		completed++
+
+		u := byName[f.name]
+		if u.out.Len() > 0 {
+			a.logger.Info("\n── %s ──\n%s", u.name, u.out.String())
+		}
+		if f.err != nil {
+			state[f.name] = applyFailed
+			errs[f.name] = f.err
+			a.logger.Error("%s: %v\n", f.name, f.err)
+			if !continueOnError {
+				before := countApplyState(state, applySkipped)
+				skip(f.name)
+				completed += countApplyState(state, applySkipped) - before
+				continue
+			}
+		} else {
+			state[f.name] = applyDone
+			a.logger.Success("%s applied\n", f.name)
+		}
+		for _, d := range dependents[f.name] {
+			pending[d]--
+			if pending[d] == 0 && state[d] == applyPending {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	results := make([]applyResult, 0, len(units))
+	for _, u := range units {
+		results = append(results, applyResult{name: u.name, state: state[u.name], err: errs[u.name]})
+	}
+	return results, nil
+}
+
+func countApplyState(state map[string]applyState, want applyState) int {
+	n := 0
+	for _, s := range state {
+		if s == want {
+			n++
+		}
+	}
+	return n
+}
+
+// checkApplyCycles reports an error when the dependency graph cannot be
+// fully ordered
+func checkApplyCycles(units []*applyUnit, pending map[string]int, dependents map[string][]string) error {
+	remaining := make(map[string]int, len(pending))
+	var queue []string
+	for name, n := range pending {
+		remaining[name] = n
+		if n == 0 {
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[name] {
+			remaining[d]--
+			if remaining[d] == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	var cyclic []string
+	for _, u := range units {
+		if remaining[u.name] > 0 {
+			cyclic = append(cyclic, u.name)
+		}
+	}
+	if len(cyclic) > 0 {
+		return fmt.Errorf("dependency cycle between: %s", strings.Join(cyclic, ", "))
+	}
+	return nil
+}
+
+func (a *App) printApplySummary(results []applyResult, elapsed time.Duration) error {
+	var failed, skipped []string
+	applied := 0
+	for _, r := range results {
+		switch r.state {
+		case applyDone:
+			applied++
+		case applyFailed:
+			failed = append(failed, r.name)
+		case applySkipped:
+			skipped = append(skipped, r.name)
+		}
+	}
+
+	a.logger.Info("\nCompleted in %s: %d applied, %d failed, %d skipped\n", elapsed.Round(time.Second), applied, len(failed), len(skipped))
+	if len(skipped) > 0 {
+		a.logger.Warn("Skipped because a dependency failed: %s\n", strings.Join(skipped, ", "))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d components failed to apply: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return nil
+}
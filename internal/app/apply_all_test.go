@@ -0,0 +1,222 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+type dependentTestModule struct {
+	basicHelpTestModule
+	deps []string
+}
+
+func (m dependentTestModule) Dependencies() []string { return m.deps }
+
+func TestApplyUnits_ResolvesDependencies(t *testing.T) {
+	log := logger.NewNopLogger()
+	registry := modules.NewRegistry(log)
+	registry.Register("postgres", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return basicHelpTestModule{name: "postgres"}
+	})
+	registry.Register("postgres-exporter", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return dependentTestModule{basicHelpTestModule{name: "postgres-exporter"}, []string{"postgres"}}
+	})
+	registry.Register("gitea", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return dependentTestModule{basicHelpTestModule{name: "gitea"}, []string{"postgres", "redis"}}
+	})
+
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "gitea", Namespace: "infra"},
+		{Name: "postgres-exporter", Namespace: "infra"},
+		{Name: "postgres-infra", Namespace: "infra"},
+	}}
+
+	app := &App{logger: log, registry: registry}
+	units, err := app.applyUnits(cfg)
+	if err != nil {
+		t.Fatalf("applyUnits() error: %v", err)
+	}
+
+	deps := map[string]string{}
+	gating := map[string]bool{}
+	for _, u := range units {
+		deps[u.name] = strings.Join(u.deps, ",")
+		gating[u.name] = u.gating
+	}
+
+	want := map[string]string{"gitea": "postgres-infra", "postgres-exporter": "postgres-infra", "postgres-infra": ""}
+	for name, d := range want {
+		if deps[name] != d {
+			t.Errorf("%s deps = %q, want %q", name, deps[name], d)
+		}
+	}
+	if !gating["postgres-infra"] || gating["gitea"] || gating["postgres-exporter"] {
+		t.Errorf("unexpected gating flags: %v", gating)
+	}
+}
+
+func newTestUnits(deps map[string][]string, order ...string) []*applyUnit {
+	var units []*applyUnit
+	for _, name := range order {
+		units = append(units, &applyUnit{component: component{name: name}, deps: deps[name], out: &bytes.Buffer{}})
+	}
+	return units
+}
+
+func TestRunApplyGraph_OrderAndConcurrency(t *testing.T) {
+	units := newTestUnits(map[string][]string{
+		"gitea":   {"postgres"},
+		"drone":   {"gitea"},
+		"pgadmin": {"postgres"},
+	}, "postgres", "redis", "grafana", "gitea", "drone", "pgadmin")
+
+	var mu sync.Mutex
+	var finished []string
+	var running, maxRunning int32
+
+	app := &App{logger: logger.NewNopLogger()}
+	results, err := app.runApplyGraph(context.Background(), units, 2, false, func(ctx context.Context, u *applyUnit) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, dep := range u.deps {
+			found := false
+			for _, f := range finished {
+				found = found || f == dep
+			}
+			if !found {
+				t.Errorf("%s started before dependency %s finished", u.name, dep)
+			}
+		}
+		finished = append(finished, u.name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runApplyGraph() error: %v", err)
+	}
+
+	if maxRunning > 2 {
+		t.Errorf("max concurrent applies = %d, want <= 2", maxRunning)
+	}
+	if maxRunning < 2 {
+		t.Errorf("independent modules should be applied concurrently, max = %d", maxRunning)
+	}
+	for _, r := range results {
+		if r.state != applyDone {
+			t.Errorf("%s state = %v, want applied", r.name, r.state)
+		}
+	}
+}
+
+func TestRunApplyGraph_SkipsDependentsOfFailures(t *testing.T) {
+	units := newTestUnits(map[string][]string{
+		"gitea": {"postgres"},
+		"drone": {"gitea"},
+	}, "postgres", "gitea", "drone", "redis")
+
+	var logBuf strings.Builder
+	app := &App{logger: logger.NewStdLogger(&logBuf)}
+	results, err := app.runApplyGraph(context.Background(), units, 4, false, func(ctx context.Context, u *applyUnit) error {
+		if u.name == "postgres" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runApplyGraph() error: %v", err)
+	}
+
+	want := map[string]applyState{"postgres": applyFailed, "gitea": applySkipped, "drone": applySkipped, "redis": applyDone}
+	for _, r := range results {
+		if r.state != want[r.name] {
+			t.Errorf("%s state = %v, want %v", r.name, r.state, want[r.name])
+		}
+	}
+
+	err = app.printApplySummary(results, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "postgres") {
+		t.Errorf("expected summary error naming postgres, got %v", err)
+	}
+	if !strings.Contains(logBuf.String(), "gitea, drone") {
+		t.Errorf("expected skipped modules in output, got:\n%s", logBuf.String())
+	}
+}
+
+func TestRunApplyGraph_ContinueOnError(t *testing.T) {
+	units := newTestUnits(map[string][]string{
+		"gitea": {"postgres"},
+		"drone": {"gitea"},
+	}, "postgres", "gitea", "drone")
+
+	var mu sync.Mutex
+	var order []string
+	app := &App{logger: logger.NewNopLogger()}
+	results, err := app.runApplyGraph(context.Background(), units, 4, true, func(ctx context.Context, u *applyUnit) error {
+		mu.Lock()
+		order = append(order, u.name)
+		mu.Unlock()
+		if u.name == "gitea" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runApplyGraph() error: %v", err)
+	}
+
+	// Dependents still run after their dependency, just not only on success
+	if got := strings.Join(order, ","); got != "postgres,gitea,drone" {
+		t.Errorf("order = %s, want postgres,gitea,drone", got)
+	}
+	want := map[string]applyState{"postgres": applyDone, "gitea": applyFailed, "drone": applyDone}
+	for _, r := range results {
+		if r.state != want[r.name] {
+			t.Errorf("%s state = %v, want %v", r.name, r.state, want[r.name])
+		}
+	}
+	if err := app.printApplySummary(results, time.Second); err == nil || !strings.Contains(err.Error(), "gitea") {
+		t.Errorf("expected summary error naming gitea, got %v", err)
+	}
+}
+
+func TestRunApplyGraph_DetectsCycles(t *testing.T) {
+	units := newTestUnits(map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+	}, "a", "b", "c")
+
+	app := &App{logger: logger.NewNopLogger()}
+	_, err := app.runApplyGraph(context.Background(), units, 2, false, func(ctx context.Context, u *applyUnit) error {
+		t.Errorf("%s should not be applied", u.name)
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "a, b") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
+
+func TestHandleApplyCommand_RequiresAll(t *testing.T) {
+	app := &App{logger: logger.NewNopLogger()}
+	if err := app.handleApplyCommand(context.Background(), &config.Config{}, nil); err == nil {
+		t.Error("expected usage error without --all")
+	}
+}
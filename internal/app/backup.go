@@ -61,10 +61,10 @@ func (a *App) handleGlobalBackupCommand(ctx context.Context, cfg *config.Config)
 				if cfg.Backup.SentryDSN != "" {
 					sentry.CaptureException(err)
 				}
-				a.logger.Error("❌ Failed to backup module '%s': %v\n", name, err)
+				a.logger.Error("Failed to backup module '%s': %v\n", name, err)
 				failCount++
 			} else {
-				a.logger.Success("✅ Module '%s' backed up successfully\n", name)
+				a.logger.Success("Module '%s' backed up successfully\n", name)
 				successCount++
 			}
 			a.logger.Println()
@@ -112,7 +112,7 @@ func (a *App) handleGlobalBackupCommand(ctx context.Context, cfg *config.Config)
 					if info, err := srcFile.Stat(); err == nil {
 						os.Chmod(destPath, info.Mode())
 					}
-					a.logger.Success("✅ Binary included\n")
+					a.logger.Success("Binary included\n")
 				}
 			}
 		}
@@ -136,7 +136,7 @@ func (a *App) handleGlobalBackupCommand(ctx context.Context, cfg *config.Config)
 				if _, err := io.Copy(destFile, srcFile); err != nil {
 					a.logger.Warn("Failed to copy config file: %v\n", err)
 				} else {
-					a.logger.Success("✅ Config file included\n")
+					a.logger.Success("Config file included\n")
 				}
 			}
 		}
@@ -261,19 +261,9 @@ func (a *App) handleGlobalBackupCommand(ctx context.Context, cfg *config.Config)
 func (a *App) uploadToWebDAV(ctx context.Context, filePath, host, username, password string) error {
 	a.logger.Info("☁️ Uploading to WebDAV: %s\n", host)
 
-	// Create WebDAV client
-	transport := &http.Transport{}
-	client := &http.Client{
-		Transport: &basicAuthTransport{
-			Username:  username,
-			Password:  password,
-			Transport: transport,
-		},
-	}
-
-	wdClient, err := webdav.NewClient(client, host)
+	wdClient, err := newWebDAVClient(host, username, password)
 	if err != nil {
-		return fmt.Errorf("failed to create webdav client: %w", err)
+		return err
 	}
 
 	file, err := os.Open(filePath)
@@ -294,10 +284,27 @@ func (a *App) uploadToWebDAV(ctx context.Context, filePath, host, username, pass
 		return fmt.Errorf("failed to copy file content: %w", err)
 	}
 
-	a.logger.Success("✅ Uploaded %s to WebDAV\n", remotePath)
+	a.logger.Success("Uploaded %s to WebDAV\n", remotePath)
 	return nil
 }
 
+// newWebDAVClient creates a WebDAV client authenticating with basic auth
+func newWebDAVClient(host, username, password string) (*webdav.Client, error) {
+	client := &http.Client{
+		Transport: &basicAuthTransport{
+			Username:  username,
+			Password:  password,
+			Transport: &http.Transport{},
+		},
+	}
+
+	wdClient, err := webdav.NewClient(client, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webdav client: %w", err)
+	}
+	return wdClient, nil
+}
+
 type basicAuthTransport struct {
 	Username  string
 	Password  string
@@ -312,19 +319,9 @@ func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error
 func (a *App) downloadFromWebDAV(ctx context.Context, remotePath, localPath, host, username, password string) error {
 	a.logger.Info("☁️ Downloading from WebDAV: %s\n", host)
 
-	// Create WebDAV client
-	transport := &http.Transport{}
-	client := &http.Client{
-		Transport: &basicAuthTransport{
-			Username:  username,
-			Password:  password,
-			Transport: transport,
-		},
-	}
-
-	wdClient, err := webdav.NewClient(client, host)
+	wdClient, err := newWebDAVClient(host, username, password)
 	if err != nil {
-		return fmt.Errorf("failed to create webdav client: %w", err)
+		return err
 	}
 
 	// Open remote file for reading
@@ -346,7 +343,7 @@ func (a *App) downloadFromWebDAV(ctx context.Context, remotePath, localPath, hos
 		return fmt.Errorf("failed to copy file content: %w", err)
 	}
 
-	a.logger.Success("✅ Downloaded %s to %s\n", remotePath, localPath)
+	a.logger.Success("Downloaded %s to %s\n", remotePath, localPath)
 	return nil
 }
 
@@ -380,9 +377,20 @@ func (a *App) handleBackupDownload(ctx context.Context, cfg *config.Config, remo
 func (a *App) handleGlobalDecryptCommand(ctx context.Context, archivePath string, passphrase string) error {
 	a.logger.Info("🔓 Decrypting archive: %s\n", archivePath)
 
-	// gpg --batch --yes --passphrase-fd 0 --decrypt <archivePath> | tar -xz
+	if err := extractEncryptedArchive(ctx, archivePath, passphrase, "."); err != nil {
+		return err
+	}
+
+	a.logger.Success("Archive decrypted and extracted successfully\n")
+	return nil
+}
+
+// extractEncryptedArchive decrypts a global backup archive and unpacks it into
+// destDir
+func extractEncryptedArchive(ctx context.Context, archivePath, passphrase, destDir string) error {
+	// gpg --batch --yes --passphrase-fd 0 --decrypt <archivePath> | tar -xz -C <destDir>
 	gpgCmd := exec.CommandContext(ctx, "gpg", "--batch", "--yes", "--passphrase-fd", "0", "--decrypt", archivePath)
-	tarCmd := exec.CommandContext(ctx, "tar", "-xz")
+	tarCmd := exec.CommandContext(ctx, "tar", "-xz", "-C", destDir)
 
 	// Pipe gpg output to tar input
 	gpgStdout, err := gpgCmd.StdoutPipe()
@@ -423,8 +431,6 @@ func (a *App) handleGlobalDecryptCommand(ctx context.Context, archivePath string
 	if err := tarCmd.Wait(); err != nil {
 		return fmt.Errorf("failed to untar archive: %w", err)
 	}
-
-	a.logger.Success("✅ Archive decrypted and extracted successfully\n")
 	return nil
 }
 
@@ -509,6 +515,6 @@ func (a *App) handleBackupSchedule(ctx context.Context, cfg *config.Config) erro
 		return fmt.Errorf("failed to update crontab: %w", err)
 	}
 
-	a.logger.Success("✅ Backup schedule added to crontab\n")
+	a.logger.Success("Backup schedule added to crontab\n")
 	return nil
 }
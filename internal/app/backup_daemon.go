@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+// backupDaemon runs the global backup on a cron schedule in the foreground,
+// as an alternative to the crontab entry of backup schedule. Runs never
+// overlap: the next run is planned when the previous one finishes, and the
+// scheduled times missed meanwhile are skipped.
+type backupDaemon struct {
+	schedule string
+	// jitter is the upper bound of a random delay added to every run, so
+	// several servers do not hit the WebDAV server at once
+	jitter   time.Duration
+	location *time.Location
+	log      logger.Logger
+	backup   func(ctx context.Context) error
+	now      func() time.Time
+	// wait blocks for d, or until ctx is done
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+func (a *App) handleBackupDaemon(ctx context.Context, cfg *config.Config) error {
+	if cfg.Backup.Cron == "" {
+		return fmt.Errorf("backup daemon needs a schedule in backup.cron")
+	}
+	if err := k8s.ValidateSchedule("backup.cron", cfg.Backup.Cron); err != nil {
+		return err
+	}
+	var jitter time.Duration
+	if cfg.Backup.Jitter != "" {
+		// config validation already rejected invalid durations
+		jitter, _ = time.ParseDuration(cfg.Backup.Jitter)
+	}
+	location := time.Local
+	if cfg.General.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(cfg.General.Timezone); err != nil {
+			return fmt.Errorf("invalid general.timezone: %w", err)
+		}
+	}
+
+	daemon := &backupDaemon{
+		schedule: cfg.Backup.Cron,
+		jitter:   jitter,
+		location: location,
+		log:      a.logger,
+		backup:   func(ctx context.Context) error { return a.handleBackupCommand(ctx, cfg) },
+		now:      time.Now,
+		wait: func(ctx context.Context, d time.Duration) error {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+				return nil
+			}
+		},
+	}
+	a.logger.Info("📅 Backup daemon started with schedule %q\n", cfg.Backup.Cron)
+	return daemon.run(ctx)
+}
+
+// run plans and runs backups until ctx is done. A failed backup is logged
+// and does not stop the daemon.
+func (d *backupDaemon) run(ctx context.Context) error {
+	for {
+		now := d.now().In(d.location)
+		next, err := k8s.NextScheduleTime(d.schedule, now)
+		if err != nil {
+			return err
+		}
+		at := next
+		if d.jitter > 0 {
+			at = at.Add(time.Duration(rand.Int63n(int64(d.jitter))))
+		}
+		d.log.Info("Next backup at %s\n", at.Format("2006-01-02 15:04:05 MST"))
+
+		if err := d.wait(ctx, at.Sub(now)); err != nil {
+			d.log.Info("Backup daemon stopped\n")
+			return nil
+		}
+		if err := d.backup(ctx); err != nil {
+			if ctx.Err() != nil {
+				d.log.Info("Backup daemon stopped\n")
+				return nil
+			}
+			d.log.Error("Scheduled backup failed: %v\n", err)
+		}
+
+		if missed := d.missedRuns(next); missed > 0 {
+			d.log.Warn("Skipped %d scheduled backup(s) while the previous one was running\n", missed)
+		}
+	}
+}
+
+// missedRuns counts the scheduled times after last that have passed
+func (d *backupDaemon) missedRuns(last time.Time) int {
+	now := d.now().In(d.location)
+	missed := 0
+	for t := last; ; missed++ {
+		next, err := k8s.NextScheduleTime(d.schedule, t)
+		if err != nil || !next.Before(now) {
+			return missed
+		}
+		t = next
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+func TestBackupDaemon(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	var runs []time.Time
+	var buf strings.Builder
+	daemon := &backupDaemon{
+		schedule: "0 * * * *",
+		jitter:   time.Minute,
+		location: time.UTC,
+		log:      logger.NewStdLogger(&buf),
+		now:      func() time.Time { return clock },
+		wait: func(ctx context.Context, d time.Duration) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			clock = clock.Add(d)
+			return nil
+		},
+		backup: func(ctx context.Context) error {
+			runs = append(runs, clock)
+			switch len(runs) {
+			case 1:
+				// the first run takes 2.5 hours, past two scheduled times
+				clock = clock.Add(150 * time.Minute)
+				return errors.New("webdav unreachable")
+			case 2:
+				clock = clock.Add(time.Minute)
+			default:
+				cancel()
+			}
+			return nil
+		},
+	}
+
+	if err := daemon.run(ctx); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("runs = %v, want 3", runs)
+	}
+	for i, want := range []time.Time{
+		time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC),
+	} {
+		if runs[i].Before(want) || !runs[i].Before(want.Add(2*time.Minute)) {
+			t.Errorf("run %d at %s, want %s plus at most the jitter", i+1, runs[i], want)
+		}
+	}
+	out := buf.String()
+	for _, want := range []string{"Scheduled backup failed: webdav unreachable", "Skipped 2 scheduled backup(s)", "Backup daemon stopped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
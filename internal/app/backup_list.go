@@ -0,0 +1,301 @@
+package app
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/emersion/go-webdav"
+)
+
+// backupNamePattern matches the names backups are written under: a
+// <module>_backup_<timestamp> directory, or a global_backup_<timestamp>
+// directory with its .tar.gz and .tar.gz.gpg archives
+var backupNamePattern = regexp.MustCompile(`^([a-z0-9-]+)_backup_(\d{8}_\d{6})(\.tar\.gz(\.gpg)?)?$`)
+
+const (
+	backupLocationLocal  = "local"
+	backupLocationWebDAV = "webdav"
+)
+
+// backupEntry is a backup found locally or on WebDAV
+type backupEntry struct {
+	Location  string
+	Name      string
+	Time      time.Time
+	Size      int64
+	Modules   []string // nil when unknown, e.g. for an encrypted archive
+	Encrypted bool
+}
+
+// handleBackupList lists the backups in the local backups directory and, when
+// backup.webdav_host is set, the archives uploaded to WebDAV
+func (a *App) handleBackupList(ctx context.Context, cfg *config.Config) error {
+	entries, err := listLocalBackups("backups")
+	if err != nil {
+		return err
+	}
+
+	if cfg.Backup.WebdavHost != "" {
+		wdClient, err := newWebDAVClient(cfg.Backup.WebdavHost, cfg.Backup.WebdavUsername, cfg.Backup.WebdavPassword)
+		if err != nil {
+			return err
+		}
+		remote, err := listWebDAVBackups(ctx, wdClient)
+		if err != nil {
+			a.logger.Warn("Failed to list WebDAV backups: %v\n", err)
+		}
+		entries = append(entries, remote...)
+	}
+
+	if len(entries) == 0 {
+		a.logger.Info("No backups found\n")
+		return nil
+	}
+
+	sortBackupEntries(entries)
+	a.printBackupList(entries)
+	return nil
+}
+
+// listLocalBackups returns the backups in dir; a missing dir has none
+func listLocalBackups(dir string) ([]backupEntry, error) {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var entries []backupEntry
+	for _, file := range files {
+		m := backupNamePattern.FindStringSubmatch(file.Name())
+		if m == nil {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", file.Name(), err)
+		}
+
+		p := filepath.Join(dir, file.Name())
+		entry := backupEntry{
+			Location:  backupLocationLocal,
+			Name:      file.Name(),
+			Time:      backupTime(m[2], info.ModTime()),
+			Size:      info.Size(),
+			Encrypted: m[4] != "",
+		}
+		switch {
+		case file.IsDir():
+			entry.Size, err = dirSize(p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to size %s: %w", p, err)
+			}
+			entry.Modules, err = dirBackupModules(p, m[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", p, err)
+			}
+		case !entry.Encrypted:
+			// An unreadable archive is still listed, with its modules unknown
+			entry.Modules, _ = archiveBackupModules(p)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// listWebDAVBackups returns the backup archives in the WebDAV root, where
+// uploadToWebDAV puts them
+func listWebDAVBackups(ctx context.Context, wdClient *webdav.Client) ([]backupEntry, error) {
+	files, err := wdClient.ReadDir(ctx, "/", false)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []backupEntry
+	for _, file := range files {
+		if file.IsDir {
+			continue
+		}
+		name := path.Base(file.Path)
+		m := backupNamePattern.FindStringSubmatch(name)
+		if m == nil || m[3] == "" {
+			continue
+		}
+		entries = append(entries, backupEntry{
+			Location:  backupLocationWebDAV,
+			Name:      name,
+			Time:      backupTime(m[2], file.ModTime),
+			Size:      file.Size,
+			Encrypted: m[4] != "",
+		})
+	}
+	return entries, nil
+}
+
+// backupTime parses the timestamp in a backup name, falling back to the
+// modification time
+func backupTime(timestamp string, modTime time.Time) time.Time {
+	t, err := time.ParseInLocation("20060102_150405", timestamp, time.Local)
+	if err != nil {
+		return modTime
+	}
+	return t
+}
+
+// dirSize returns the total size of the files under dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// backupDirPrefixes maps the modules whose single-module backups are not
+// named <module>_backup_<timestamp> to the prefix they use instead
+var backupDirPrefixes = map[string]string{
+	"hobby-pod": "hobby",
+}
+
+// prefixModule returns the module a single-module backup prefix belongs to
+func prefixModule(prefix string) string {
+	for module, p := range backupDirPrefixes {
+		if p == prefix {
+			return module
+		}
+	}
+	return prefix
+}
+
+// dirBackupModules returns the modules in a backup directory: the
+// subdirectories each module writes into a global backup, or the module a
+// single-module backup is named after
+func dirBackupModules(dir, prefix string) ([]string, error) {
+	if prefix != "global" {
+		return []string{prefixModule(prefix)}, nil
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	modules := []string{}
+	for _, file := range files {
+		// The binary and config file sit next to the module directories
+		if file.IsDir() {
+			modules = append(modules, file.Name())
+		}
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// archiveBackupModules returns the modules in an unencrypted global backup
+// archive, read from the tar headers without extracting it
+func archiveBackupModules(archivePath string) ([]string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	seen := map[string]bool{}
+	modules := []string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Entries are global_backup_<timestamp>/<module>/...
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(hdr.Name, "./"), "/"), "/")
+		if len(parts) < 2 || seen[parts[1]] {
+			continue
+		}
+		if hdr.Typeflag == tar.TypeDir || len(parts) > 2 {
+			seen[parts[1]] = true
+			modules = append(modules, parts[1])
+		}
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// sortBackupEntries orders entries newest first, local before WebDAV
+func sortBackupEntries(entries []backupEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Time.After(entries[j].Time)
+		}
+		if entries[i].Location != entries[j].Location {
+			return entries[i].Location == backupLocationLocal
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+func (a *App) printBackupList(entries []backupEntry) {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "LOCATION\tNAME\tTIMESTAMP\tSIZE\tMODULES\tENCRYPTED")
+	for _, e := range entries {
+		modules := "-"
+		if len(e.Modules) > 0 {
+			modules = strings.Join(e.Modules, ",")
+		}
+		encrypted := "no"
+		if e.Encrypted {
+			encrypted = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Location, e.Name, e.Time.Format("2006-01-02 15:04:05"), formatBackupSize(e.Size), modules, encrypted)
+	}
+	w.Flush()
+	a.logger.Print("%s", sb.String())
+}
+
+// formatBackupSize renders n with a binary unit, e.g. 1.5 GiB
+func formatBackupSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
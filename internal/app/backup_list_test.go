@@ -0,0 +1,150 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/emersion/go-webdav"
+)
+
+func writeBackupFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeBackupArchive(t *testing.T, path string, names ...string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	writeBackupFile(t, path, buf.String())
+}
+
+func TestListLocalBackups(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global_backup_20260301_030000")
+	writeBackupFile(t, filepath.Join(global, "postgres", "postgres_dump_20260301_030001.sql.gz"), "12345")
+	writeBackupFile(t, filepath.Join(global, "gitea", "gitea_data_20260301_030002.tar.gz"), "123")
+	writeBackupFile(t, filepath.Join(global, "personal-server"), "bin")
+	writeBackupFile(t, filepath.Join(global, "config.yaml"), "cfg")
+	writeBackupFile(t, filepath.Join(dir, "redis_backup_20260227_120000", "dump.rdb"), "1234567")
+	writeBackupFile(t, filepath.Join(dir, "hobby_backup_20260225_120000", "hobby_data_20260225_120000.tar.gz"), "1")
+	writeBackupArchive(t, filepath.Join(dir, "global_backup_20260228_030000.tar.gz"),
+		"global_backup_20260228_030000/synapse/backup_info.txt",
+		"global_backup_20260228_030000/personal-server")
+	writeBackupFile(t, filepath.Join(dir, "global_backup_20260226_030000.tar.gz.gpg"), "encrypted")
+	writeBackupFile(t, filepath.Join(dir, "notes.txt"), "not a backup")
+
+	entries, err := listLocalBackups(dir)
+	if err != nil {
+		t.Fatalf("listLocalBackups failed: %v", err)
+	}
+	sortBackupEntries(entries)
+
+	want := []struct {
+		name      string
+		size      int64
+		modules   []string
+		encrypted bool
+	}{
+		{"global_backup_20260301_030000", 14, []string{"gitea", "postgres"}, false},
+		{"global_backup_20260228_030000.tar.gz", -1, []string{"synapse"}, false},
+		{"redis_backup_20260227_120000", 7, []string{"redis"}, false},
+		{"global_backup_20260226_030000.tar.gz.gpg", 9, nil, true},
+		{"hobby_backup_20260225_120000", 1, []string{"hobby-pod"}, false},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Name != w.name || e.Location != backupLocationLocal || e.Encrypted != w.encrypted || !reflect.DeepEqual(e.Modules, w.modules) {
+			t.Errorf("entry %d = %+v, want %+v", i, e, w)
+		}
+		if w.size >= 0 && e.Size != w.size {
+			t.Errorf("entry %d size = %d, want %d", i, e.Size, w.size)
+		}
+	}
+	if got := entries[0].Time; !got.Equal(time.Date(2026, 3, 1, 3, 0, 0, 0, time.Local)) {
+		t.Errorf("timestamp = %v", got)
+	}
+
+	missing, err := listLocalBackups(filepath.Join(dir, "missing"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("missing dir: got %v, %v", missing, err)
+	}
+}
+
+func TestListWebDAVBackups(t *testing.T) {
+	dir := t.TempDir()
+	writeBackupFile(t, filepath.Join(dir, "global_backup_20260301_030000.tar.gz.gpg"), "encrypted")
+	writeBackupFile(t, filepath.Join(dir, "readme.txt"), "other")
+	if err := os.Mkdir(filepath.Join(dir, "global_backup_20260228_030000"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(&webdav.Handler{FileSystem: webdav.LocalFileSystem(dir)})
+	defer server.Close()
+
+	wdClient, err := newWebDAVClient(server.URL, "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := listWebDAVBackups(context.Background(), wdClient)
+	if err != nil {
+		t.Fatalf("listWebDAVBackups failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1: %+v", len(entries), entries)
+	}
+	e := entries[0]
+	if e.Location != backupLocationWebDAV || e.Name != "global_backup_20260301_030000.tar.gz.gpg" || e.Size != 9 || !e.Encrypted || e.Modules != nil {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestPrintBackupList(t *testing.T) {
+	var buf bytes.Buffer
+	a := &App{logger: logger.NewStdLogger(&buf)}
+	a.printBackupList([]backupEntry{
+		{Location: backupLocationLocal, Name: "global_backup_20260301_030000", Time: time.Date(2026, 3, 1, 3, 0, 0, 0, time.Local), Size: 1536, Modules: []string{"gitea", "postgres"}},
+		{Location: backupLocationWebDAV, Name: "global_backup_20260228_030000.tar.gz.gpg", Time: time.Date(2026, 2, 28, 3, 0, 0, 0, time.Local), Size: 100, Encrypted: true},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got:\n%s", buf.String())
+	}
+	for _, want := range []string{"local", "2026-03-01 03:00:00", "1.5 KiB", "gitea,postgres", "no"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("row %q missing %q", lines[1], want)
+		}
+	}
+	for _, want := range []string{"webdav", "100 B", " - ", "yes"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("row %q missing %q", lines[2], want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+// backupFileTimestamp matches the timestamp modules put in the names of the
+// files they back up, e.g. postgres_dump_20260301_030001.sql.gz
+var backupFileTimestamp = regexp.MustCompile(`_(\d{8}_\d{6})\.`)
+
+// handleBackupRestore restores the modules in a global backup archive. The
+// archive is downloaded from WebDAV unless it exists locally, decrypted with
+// backup.passphrase and unpacked, and every module directory in it is handed
+// to that module's restore.
+func (a *App) handleBackupRestore(ctx context.Context, cfg *config.Config, args []string) error {
+	usage := fmt.Errorf("usage: %s backup restore <archive> [--modules a,b]", Name)
+	restoreCmd := flag.NewFlagSet("backup restore", flag.ContinueOnError)
+	restoreCmd.SetOutput(io.Discard)
+	only := restoreCmd.String("modules", "", "Comma-separated modules to restore (default: all in the archive)")
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		return usage
+	}
+	if err := restoreCmd.Parse(args[1:]); err != nil || restoreCmd.NArg() > 0 {
+		return usage
+	}
+
+	var selected []string
+	if *only != "" {
+		selected = strings.Split(*only, ",")
+	}
+
+	a.logger.Info("♻️ Starting restore from backup: %s\n", args[0])
+
+	archivePath, err := a.fetchBackupArchive(ctx, cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	// A local archive may be restored on a host that never ran a backup
+	if err := os.MkdirAll("backups", 0755); err != nil {
+		return fmt.Errorf("failed to create backups directory: %w", err)
+	}
+	workDir, err := os.MkdirTemp("backups", "restore_")
+	if err != nil {
+		return fmt.Errorf("failed to create restore directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if strings.HasSuffix(archivePath, ".gpg") {
+		if cfg.Backup.Passphrase == "" {
+			return fmt.Errorf("backup.passphrase is required to decrypt %s", archivePath)
+		}
+		a.logger.Info("🔓 Decrypting archive: %s\n", archivePath)
+		if err := extractEncryptedArchive(ctx, archivePath, cfg.Backup.Passphrase, workDir); err != nil {
+			return err
+		}
+	} else {
+		a.logger.Info("📦 Unpacking archive: %s\n", archivePath)
+		if out, err := exec.CommandContext(ctx, "tar", "-xzf", archivePath, "-C", workDir).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to untar archive: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	globalDir, err := findGlobalBackupDir(workDir)
+	if err != nil {
+		return err
+	}
+	return a.restoreModuleDirs(ctx, cfg, globalDir, "backups", selected)
+}
+
+// fetchBackupArchive returns the local path of a backup archive: name itself or
+// backups/<name> when either exists, otherwise backups/<name> downloaded from
+// WebDAV
+func (a *App) fetchBackupArchive(ctx context.Context, cfg *config.Config, name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	// Use only the base name to prevent path traversal, as in backup download
+	base := filepath.Base(filepath.Clean(name))
+	if base == "." || base == ".." || base == "/" {
+		return "", fmt.Errorf("invalid archive name: %s", name)
+	}
+	localPath := filepath.Join("backups", base)
+	if _, err := os.Stat(localPath); err == nil {
+		a.logger.Info("Using local archive: %s\n", localPath)
+		return localPath, nil
+	}
+
+	if cfg.Backup.WebdavHost == "" {
+		return "", fmt.Errorf("archive %s not found locally and backup.webdav_host is not set", name)
+	}
+	if err := os.MkdirAll("backups", 0755); err != nil {
+		return "", fmt.Errorf("failed to create backups directory: %w", err)
+	}
+
+	// Download next to the final path so an interrupted download is never
+	// mistaken for the archive
+	partPath := localPath + ".part"
+	if err := a.downloadFromWebDAV(ctx, filepath.Clean(name), partPath, cfg.Backup.WebdavHost, cfg.Backup.WebdavUsername, cfg.Backup.WebdavPassword); err != nil {
+		os.Remove(partPath)
+		return "", fmt.Errorf("failed to download from WebDAV: %w", err)
+	}
+	if err := os.Rename(partPath, localPath); err != nil {
+		return "", fmt.Errorf("failed to save archive: %w", err)
+	}
+	return localPath, nil
+}
+
+// findGlobalBackupDir returns the global_backup_<timestamp> directory an
+// archive was unpacked into
+func findGlobalBackupDir(dir string) (string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read unpacked archive: %w", err)
+	}
+	for _, file := range files {
+		if m := backupNamePattern.FindStringSubmatch(file.Name()); file.IsDir() && m != nil && m[1] == "global" && m[3] == "" {
+			return filepath.Join(dir, file.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("archive does not contain a global_backup_<timestamp> directory")
+}
+
+// restoreModuleDirs restores every module directory in globalDir, or only the
+// selected ones. Each directory is moved to the single-module backup
+// directory the module's restore reads, <prefix>_backup_<timestamp> in
+// backupDir, and left there.
+func (a *App) restoreModuleDirs(ctx context.Context, cfg *config.Config, globalDir, backupDir string, selected []string) error {
+	files, err := os.ReadDir(globalDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", globalDir, err)
+	}
+	var names []string
+	for _, file := range files {
+		if file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	if len(selected) > 0 {
+		for _, name := range selected {
+			if !containsString(names, name) {
+				return fmt.Errorf("module %s is not in the backup (available: %s)", name, strings.Join(names, ", "))
+			}
+		}
+		names = selected
+	}
+
+	successCount := 0
+	failCount := 0
+	for _, name := range names {
+		if err := a.restoreModuleDir(ctx, cfg, filepath.Join(globalDir, name), backupDir, name); err != nil {
+			a.logger.Error("Failed to restore module '%s': %v\n", name, err)
+			failCount++
+		} else {
+			a.logger.Success("Module '%s' restored successfully\n", name)
+			successCount++
+		}
+		a.logger.Println()
+	}
+
+	a.logger.Info("Restore summary: %d successful, %d failed\n", successCount, failCount)
+	if failCount > 0 {
+		return fmt.Errorf("%d of %d module restore(s) failed", failCount, len(names))
+	}
+	a.logger.Success("\n🎉 Restore complete!\n")
+	return nil
+}
+
+func (a *App) restoreModuleDir(ctx context.Context, cfg *config.Config, dir, backupDir, name string) error {
+	module, err := a.registry.Get(name, cfg)
+	if err != nil {
+		return err
+	}
+	restorer, ok := module.(modules.Restorer)
+	if !ok {
+		return fmt.Errorf("module '%s' does not support restore", name)
+	}
+
+	timestamp, err := moduleDirTimestamp(dir)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(backupDir, fmt.Sprintf("%s_backup_%s", moduleBackupPrefix(name), timestamp))
+	if _, err := os.Stat(target); err == nil {
+		a.logger.Info("Using existing backup directory: %s\n", target)
+	} else if err := os.Rename(dir, target); err != nil {
+		return fmt.Errorf("failed to move backup to %s: %w", target, err)
+	}
+
+	a.logger.Info("♻️ Restoring module: %s\n", name)
+	return restorer.Restore(ctx, []string{timestamp})
+}
+
+// moduleDirTimestamp returns the timestamp in the names of the files a module
+// backed up into dir
+func moduleDirTimestamp(dir string) (string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if m := backupFileTimestamp.FindStringSubmatch(file.Name()); m != nil {
+			return m[1], nil
+		}
+	}
+	return "", fmt.Errorf("no timestamped backup files in %s", dir)
+}
+
+// moduleBackupPrefix returns the prefix of a module's single-module backup
+// directories
+func moduleBackupPrefix(module string) string {
+	if prefix, ok := backupDirPrefixes[module]; ok {
+		return prefix
+	}
+	return module
+}
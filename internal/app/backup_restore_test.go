@@ -0,0 +1,213 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	"github.com/emersion/go-webdav"
+)
+
+// restoreTestModule records the backup directory each restore finds
+type restoreTestModule struct {
+	basicHelpTestModule
+	backupDir string
+	restored  *[]string
+}
+
+func (m restoreTestModule) Restore(_ context.Context, args []string) error {
+	prefix := moduleBackupPrefix(m.name)
+	if _, err := os.Stat(filepath.Join(m.backupDir, prefix+"_backup_"+args[0])); err != nil {
+		return err
+	}
+	*m.restored = append(*m.restored, m.name+"@"+args[0])
+	return nil
+}
+
+func newRestoreTestApp(t *testing.T, backupDir string, restored *[]string) (*App, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	log := logger.NewStdLogger(&buf)
+	registry := modules.NewRegistry(log)
+	for _, name := range []string{"postgres", "hobby-pod"} {
+		name := name
+		registry.RegisterSimple(name, func(config.GeneralConfig, logger.Logger) modules.Module {
+			return restoreTestModule{basicHelpTestModule{name: name}, backupDir, restored}
+		})
+	}
+	registry.RegisterSimple("ingress-nginx", func(config.GeneralConfig, logger.Logger) modules.Module {
+		return basicHelpTestModule{name: "ingress-nginx"}
+	})
+	return &App{logger: log, registry: registry}, &buf
+}
+
+func writeGlobalBackup(t *testing.T, globalDir string) {
+	t.Helper()
+	writeBackupFile(t, filepath.Join(globalDir, "postgres", "postgres_dump_20260301_030001.sql.gz"), "dump")
+	writeBackupFile(t, filepath.Join(globalDir, "postgres", "backup_info.txt"), "info")
+	writeBackupFile(t, filepath.Join(globalDir, "hobby-pod", "hobby_data_20260301_030005.tar.gz"), "data")
+	writeBackupFile(t, filepath.Join(globalDir, "personal-server"), "bin")
+}
+
+func TestRestoreModuleDirs(t *testing.T) {
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backups")
+	globalDir := filepath.Join(dir, "restore", "global_backup_20260301_030000")
+	writeGlobalBackup(t, globalDir)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var restored []string
+	a, _ := newRestoreTestApp(t, backupDir, &restored)
+	if err := a.restoreModuleDirs(context.Background(), &config.Config{}, globalDir, backupDir, nil); err != nil {
+		t.Fatalf("restoreModuleDirs failed: %v", err)
+	}
+
+	want := []string{"hobby-pod@20260301_030005", "postgres@20260301_030001"}
+	if !reflect.DeepEqual(restored, want) {
+		t.Errorf("restored = %v, want %v", restored, want)
+	}
+	if _, err := os.Stat(filepath.Join(backupDir, "hobby_backup_20260301_030005", "hobby_data_20260301_030005.tar.gz")); err != nil {
+		t.Errorf("hobby-pod backup not moved: %v", err)
+	}
+}
+
+func TestRestoreModuleDirs_Selected(t *testing.T) {
+	dir := t.TempDir()
+	globalDir := filepath.Join(dir, "global_backup_20260301_030000")
+	writeGlobalBackup(t, globalDir)
+	writeBackupFile(t, filepath.Join(globalDir, "ingress-nginx", "nginx_20260301_030002.tar.gz"), "x")
+
+	var restored []string
+	a, buf := newRestoreTestApp(t, dir, &restored)
+	ctx := context.Background()
+
+	if err := a.restoreModuleDirs(ctx, &config.Config{}, globalDir, dir, []string{"gitea"}); err == nil || !strings.Contains(err.Error(), "gitea is not in the backup") {
+		t.Errorf("expected missing module error, got %v", err)
+	}
+
+	if err := a.restoreModuleDirs(ctx, &config.Config{}, globalDir, dir, []string{"postgres"}); err != nil {
+		t.Fatalf("restoreModuleDirs failed: %v", err)
+	}
+	if !reflect.DeepEqual(restored, []string{"postgres@20260301_030001"}) {
+		t.Errorf("restored = %v", restored)
+	}
+
+	restored = nil
+	err := a.restoreModuleDirs(ctx, &config.Config{}, globalDir, dir, []string{"ingress-nginx", "hobby-pod"})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 module restore(s) failed") {
+		t.Errorf("expected a failed restore, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "module 'ingress-nginx' does not support restore") {
+		t.Errorf("missing failure in output:\n%s", buf.String())
+	}
+	if !reflect.DeepEqual(restored, []string{"hobby-pod@20260301_030005"}) {
+		t.Errorf("restored = %v", restored)
+	}
+}
+
+func TestHandleBackupRestore_WebDAV(t *testing.T) {
+	if _, err := exec.LookPath("tar"); err != nil {
+		t.Skip("tar not installed")
+	}
+	dir := t.TempDir()
+	writeGlobalBackup(t, filepath.Join(dir, "src", "global_backup_20260301_030000"))
+
+	// The fake gpg prints the archive it is given, which is not encrypted
+	remoteDir := filepath.Join(dir, "remote")
+	if err := os.MkdirAll(remoteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(remoteDir, "global_backup_20260301_030000.tar.gz.gpg")
+	if out, err := exec.Command("tar", "-czf", archive, "-C", filepath.Join(dir, "src"), "global_backup_20260301_030000").CombinedOutput(); err != nil {
+		t.Fatalf("tar failed: %v: %s", err, out)
+	}
+	binDir := t.TempDir()
+	script := "#!/bin/sh\ncat >/dev/null\nfor last; do :; done\ncat \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "gpg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	server := httptest.NewServer(&webdav.Handler{FileSystem: webdav.LocalFileSystem(remoteDir)})
+	defer server.Close()
+
+	workDir := filepath.Join(dir, "work")
+	if err := os.MkdirAll(filepath.Join(workDir, "backups"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalWd)
+
+	var restored []string
+	a, buf := newRestoreTestApp(t, "backups", &restored)
+	cfg := &config.Config{Backup: config.BackupConfig{WebdavHost: server.URL, Passphrase: "secret"}}
+	if err := a.handleBackupRestore(context.Background(), cfg, []string{"global_backup_20260301_030000.tar.gz.gpg", "--modules", "postgres"}); err != nil {
+		t.Fatalf("handleBackupRestore failed: %v\n%s", err, buf.String())
+	}
+
+	if !reflect.DeepEqual(restored, []string{"postgres@20260301_030001"}) {
+		t.Errorf("restored = %v", restored)
+	}
+	if _, err := os.Stat(filepath.Join("backups", "global_backup_20260301_030000.tar.gz.gpg")); err != nil {
+		t.Errorf("downloaded archive not kept: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join("backups", "restore_*"))
+	if len(files) != 0 {
+		t.Errorf("unpacked archive not removed: %v", files)
+	}
+
+	if err := a.handleBackupRestore(context.Background(), &config.Config{}, []string{"missing.tar.gz.gpg"}); err == nil || !strings.Contains(err.Error(), "backup.webdav_host is not set") {
+		t.Errorf("expected missing archive error, got %v", err)
+	}
+}
+
+func TestHandleBackupRestore_LocalArchiveWithoutBackupsDir(t *testing.T) {
+	if _, err := exec.LookPath("tar"); err != nil {
+		t.Skip("tar not installed")
+	}
+	dir := t.TempDir()
+	writeGlobalBackup(t, filepath.Join(dir, "src", "global_backup_20260301_030000"))
+	archive := filepath.Join(dir, "global_backup_20260301_030000.tar.gz")
+	if out, err := exec.Command("tar", "-czf", archive, "-C", filepath.Join(dir, "src"), "global_backup_20260301_030000").CombinedOutput(); err != nil {
+		t.Fatalf("tar failed: %v: %s", err, out)
+	}
+
+	workDir := filepath.Join(dir, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalWd)
+
+	var restored []string
+	a, buf := newRestoreTestApp(t, "backups", &restored)
+	if err := a.handleBackupRestore(context.Background(), &config.Config{}, []string{archive, "--modules", "postgres"}); err != nil {
+		t.Fatalf("handleBackupRestore failed: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(restored, []string{"postgres@20260301_030001"}) {
+		t.Errorf("restored = %v", restored)
+	}
+}
@@ -67,6 +67,6 @@ func (a *App) handleBackupScheduleClear(ctx context.Context) error {
 		return fmt.Errorf("failed to update crontab: %w", err)
 	}
 
-	a.logger.Success("✅ Backup schedule cleared from crontab\n")
+	a.logger.Success("Backup schedule cleared from crontab\n")
 	return nil
 }
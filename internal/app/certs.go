@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/certs"
+	"github.com/Goalt/personal-server/internal/config"
+)
+
+const (
+	defaultCertWarnDays = 14
+	certDialTimeout     = 10 * time.Second
+)
+
+func (a *App) handleCertsCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s certs <issue-client <name>|status [--threshold days]>", Name)
+	}
+
+	switch args[0] {
+	case "issue-client":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: %s certs issue-client <name>", Name)
+		}
+		return a.handleCertsIssueClient(certs.DefaultDir, args[1])
+	case "status":
+		statusCmd := flag.NewFlagSet("certs status", flag.ContinueOnError)
+		statusCmd.SetOutput(io.Discard)
+		threshold := statusCmd.Int("threshold", defaultCertWarnDays, "Warn when a certificate expires within this many days")
+		if err := statusCmd.Parse(args[1:]); err != nil {
+			return fmt.Errorf("usage: %s certs status [--threshold days]: %w", Name, err)
+		}
+		hosts := publicHostnames(cfg)
+		if len(hosts) == 0 {
+			return fmt.Errorf("no public hostnames found in ingresses configuration")
+		}
+		return a.handleCertsStatus(ctx, hosts, func(host string) string { return net.JoinHostPort(host, "443") }, time.Duration(*threshold)*24*time.Hour)
+	default:
+		return fmt.Errorf("unknown certs subcommand: %s\nAvailable subcommands: issue-client, status", args[0])
+	}
+}
+
+func (a *App) handleCertsIssueClient(dir, name string) error {
+	ca, created, err := certs.LoadOrCreateCA(dir)
+	if err != nil {
+		return fmt.Errorf("loading client CA: %w", err)
+	}
+	if created {
+		a.logger.Success("Created client CA: %s/ca.crt\n", dir)
+		a.logger.Warn("Keep %s/ca.key private; re-apply ingresses with clientCertAuth to distribute the CA\n", dir)
+	}
+
+	certPath, keyPath, err := ca.IssueClientCert(name)
+	if err != nil {
+		return fmt.Errorf("issuing client certificate: %w", err)
+	}
+
+	a.logger.Success("Issued client certificate for '%s'\n", name)
+	a.logger.Info("Certificate: %s\n", certPath)
+	a.logger.Info("Private key: %s\n", keyPath)
+	a.logger.Info("\nTo import into a browser, bundle it as PKCS#12:\n")
+	a.logger.Info("  openssl pkcs12 -export -in %s -inkey %s -out %s.p12\n", certPath, keyPath, name)
+	return nil
+}
+
+// publicHostnames returns the unique, sorted hostnames served over TLS by the
+// configured ingresses. Rules without a host fall back to the general domain.
+func publicHostnames(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, ing := range cfg.Ingresses {
+		if !ing.TLS {
+			continue
+		}
+		for _, rule := range ing.Rules {
+			host := rule.Host
+			if host == "" {
+				host = cfg.General.Domain
+			}
+			if host == "" || seen[host] {
+				continue
+			}
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// handleCertsStatus connects to each host, prints the certificate issuer and
+// expiry, and returns an error if any certificate is unreachable, expired or
+// expires within threshold.
+func (a *App) handleCertsStatus(ctx context.Context, hosts []string, addrFor func(host string) string, threshold time.Duration) error {
+	a.logger.Info("Checking TLS certificates for %d host(s)...\n\n", len(hosts))
+
+	problems := 0
+	for _, host := range hosts {
+		cert, err := fetchCertificate(ctx, addrFor(host), host)
+		if err != nil {
+			a.logger.Error("%s: %v\n", host, err)
+			problems++
+			continue
+		}
+
+		remaining := time.Until(cert.NotAfter)
+		days := int(remaining.Hours() / 24)
+		a.logger.Info("%s\n", host)
+		a.logger.Info("  Issuer:  %s\n", cert.Issuer.CommonName)
+		a.logger.Info("  Expires: %s (%d days)\n", cert.NotAfter.Format("2006-01-02 15:04:05 MST"), days)
+		if err := cert.VerifyHostname(host); err != nil {
+			a.logger.Warn("  Hostname mismatch: %v\n", err)
+			problems++
+		}
+
+		switch {
+		case remaining <= 0:
+			a.logger.Error("  Certificate has EXPIRED\n")
+			problems++
+		case remaining < threshold:
+			a.logger.Warn("  Certificate expires in less than %d days\n", int(threshold.Hours()/24))
+			problems++
+		default:
+			a.logger.Success("  OK\n")
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d certificate problem(s) found", problems)
+	}
+	a.logger.Info("\nAll certificates are valid for at least %d days\n", int(threshold.Hours()/24))
+	return nil
+}
+
+// fetchCertificate performs a TLS handshake with addr using SNI serverName
+// and returns the leaf certificate. Verification is skipped so that
+// self-signed and expired certificates can still be reported.
+func fetchCertificate(ctx context.Context, addr, serverName string) (*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: certDialTimeout},
+		Config: &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true, // inspect the certificate, do not trust it
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	peerCerts := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return nil, fmt.Errorf("no certificate presented")
+	}
+	return peerCerts[0], nil
+}
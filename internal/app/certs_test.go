@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+func TestHandleCertsIssueClient(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "certs")
+
+	var logBuf strings.Builder
+	app := &App{logger: logger.NewStdLogger(&logBuf)}
+
+	if err := app.handleCertsIssueClient(dir, "laptop"); err != nil {
+		t.Fatalf("handleCertsIssueClient failed: %v", err)
+	}
+
+	for _, file := range []string{"ca.crt", "ca.key", "clients/laptop.crt", "clients/laptop.key"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("expected %s to exist: %v", file, err)
+		}
+	}
+
+	output := logBuf.String()
+	if !strings.Contains(output, "Created client CA") {
+		t.Errorf("expected CA creation to be reported, got: %s", output)
+	}
+	if !strings.Contains(output, "openssl pkcs12 -export") {
+		t.Errorf("expected PKCS#12 hint, got: %s", output)
+	}
+}
+
+func TestHandleCertsCommand_Usage(t *testing.T) {
+	var logBuf strings.Builder
+	app := &App{logger: logger.NewStdLogger(&logBuf)}
+
+	testCases := [][]string{
+		{},
+		{"issue-client"},
+	}
+	for _, args := range testCases {
+		err := app.handleCertsCommand(context.Background(), &config.Config{}, args)
+		if err == nil || !strings.Contains(err.Error(), "usage:") {
+			t.Errorf("expected usage error for args %v, got: %v", args, err)
+		}
+	}
+
+	err := app.handleCertsCommand(context.Background(), &config.Config{}, []string{"bogus"})
+	if err == nil || !strings.Contains(err.Error(), "unknown certs subcommand") {
+		t.Errorf("expected unknown subcommand error, got: %v", err)
+	}
+}
+
+func TestPublicHostnames(t *testing.T) {
+	cfg := &config.Config{
+		General: config.GeneralConfig{Domain: "example.com"},
+		Ingresses: []config.IngressConfig{
+			{
+				Name: "web",
+				TLS:  true,
+				Rules: []config.IngressRule{
+					{Host: "gitea.example.com"},
+					{Host: ""},
+					{Host: "gitea.example.com", Path: "/api"},
+				},
+			},
+			{
+				Name:  "plain",
+				Rules: []config.IngressRule{{Host: "insecure.example.com"}},
+			},
+		},
+	}
+
+	got := publicHostnames(cfg)
+	want := []string{"example.com", "gitea.example.com"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("publicHostnames() = %v, want %v", got, want)
+	}
+}
+
+func TestHandleCertsStatus(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	addr := server.Listener.Addr().String()
+	addrFor := func(string) string { return addr }
+
+	var logBuf strings.Builder
+	app := &App{logger: logger.NewStdLogger(&logBuf)}
+
+	// httptest certificates are issued for example.com and valid for decades
+	if err := app.handleCertsStatus(context.Background(), []string{"example.com"}, addrFor, 14*24*time.Hour); err != nil {
+		t.Fatalf("handleCertsStatus failed: %v\n%s", err, logBuf.String())
+	}
+	if !strings.Contains(logBuf.String(), "Expires:") {
+		t.Errorf("expected expiry to be reported, got: %s", logBuf.String())
+	}
+
+	logBuf.Reset()
+	err := app.handleCertsStatus(context.Background(), []string{"example.com"}, addrFor, 200*365*24*time.Hour)
+	if err == nil {
+		t.Fatal("expected an error when the certificate expires within the threshold")
+	}
+	if !strings.Contains(logBuf.String(), "expires in less than") {
+		t.Errorf("expected expiry warning, got: %s", logBuf.String())
+	}
+}
+
+func TestHandleCertsStatus_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	var logBuf strings.Builder
+	app := &App{logger: logger.NewStdLogger(&logBuf)}
+
+	err = app.handleCertsStatus(context.Background(), []string{"down.example.com"}, func(string) string { return addr }, time.Hour)
+	if err == nil || !strings.Contains(err.Error(), "1 certificate problem") {
+		t.Errorf("expected one problem for unreachable host, got: %v", err)
+	}
+}
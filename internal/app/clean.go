@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+// handleCleanCommand cleans a module after checking which configured
+// components depend on it. Without --cascade it refuses to remove a module
+// others rely on (e.g. postgres used by gitea); with --cascade it cleans the
+// dependents first, most dependent first, and the module last. Namespaces
+// left empty are then deleted if general.namespaceCreation.deleteWhenEmpty is
+// set.
+func (a *App) handleCleanCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	cleanCmd := flag.NewFlagSet("clean", flag.ContinueOnError)
+	cleanCmd.SetOutput(io.Discard)
+	cascade := cleanCmd.Bool("cascade", false, "Also clean every component that depends on the module")
+	usage := fmt.Sprintf("usage: %s %s clean [--cascade]", Name, name)
+	if err := cleanCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if cleanCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+
+	components, err := a.components(cfg, func(string) logger.Logger { return a.logger })
+	if err != nil {
+		return err
+	}
+	order := a.cleanOrder(cfg, components, name)
+	dependents := order[:len(order)-1]
+	if len(dependents) == 0 {
+		if err := module.Clean(ctx); err != nil {
+			return err
+		}
+		a.deleteEmptyNamespaces(ctx, cfg, []string{name})
+		return nil
+	}
+
+	names := make([]string, 0, len(dependents))
+	for _, c := range dependents {
+		names = append(names, c.name)
+	}
+	a.logger.Warn("%s is used by: %s\n", name, strings.Join(names, ", "))
+	if !*cascade {
+		return fmt.Errorf("refusing to clean %s while other components depend on it; rerun with --cascade to clean %s first", name, strings.Join(names, ", "))
+	}
+
+	for _, c := range dependents {
+		a.logger.Progress("Cleaning %s\n", c.name)
+		if err := c.module.Clean(ctx); err != nil {
+			return fmt.Errorf("cleaning %s: %w", c.name, err)
+		}
+	}
+	a.logger.Progress("Cleaning %s\n", name)
+	if err := module.Clean(ctx); err != nil {
+		return err
+	}
+	cleaned := make([]string, 0, len(order))
+	for _, c := range order {
+		cleaned = append(cleaned, c.name)
+	}
+	a.deleteEmptyNamespaces(ctx, cfg, cleaned)
+	return nil
+}
+
+// cleanOrder returns name and every component that transitively depends on
+// it, ordered so each component comes before the ones it depends on. The
+// last element is always name itself; a name that is not configured yields a
+// single placeholder entry so callers can still clean it.
+func (a *App) cleanOrder(cfg *config.Config, components []component, name string) []component {
+	dependents := a.dependents(cfg, components)
+	var self *component
+	for i, c := range components {
+		if c.name == name {
+			self = &components[i]
+		}
+	}
+	if self == nil {
+		return []component{{name: name}}
+	}
+
+	var order []component
+	visited := make(map[string]bool)
+	var visit func(c component)
+	visit = func(c component) {
+		if visited[c.name] {
+			return
+		}
+		visited[c.name] = true
+		for _, d := range dependents[c.name] {
+			visit(d)
+		}
+		order = append(order, c)
+	}
+	visit(*self)
+	return order
+}
+
+// dependents maps each component name to the components depending on it
+func (a *App) dependents(cfg *config.Config, components []component) map[string][]component {
+	dependents := make(map[string][]component)
+	for _, c := range components {
+		for _, dep := range a.dependencies(cfg, c) {
+			dependents[dep] = append(dependents[dep], c)
+		}
+	}
+	return dependents
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// cleanTarget is a component and the objects clean-all deletes for it
+type cleanTarget struct {
+	name    string
+	objects []*unstructured.Unstructured
+}
+
+// cleanAllOptions are the flags of clean-all
+type cleanAllOptions struct {
+	yes         bool
+	keepData    bool
+	managedOnly bool
+}
+
+// handleCleanAllCommand deletes the objects every configured component
+// generates, dependents first, after the user confirms
+func (a *App) handleCleanAllCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	cleanCmd := flag.NewFlagSet("clean-all", flag.ContinueOnError)
+	cleanCmd.SetOutput(io.Discard)
+	var opts cleanAllOptions
+	cleanCmd.BoolVar(&opts.yes, "yes", false, "Do not ask for confirmation")
+	cleanCmd.BoolVar(&opts.keepData, "keep-data", false, "Keep PersistentVolumeClaims and the namespaces holding them")
+	cleanCmd.BoolVar(&opts.managedOnly, "managed-only", false, "Only delete objects labelled managed-by=personal-server")
+	usage := fmt.Sprintf("usage: %s clean-all [--yes] [--keep-data] [--managed-only]", Name)
+	if err := cleanCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if cleanCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+
+	components, err := a.components(cfg, func(string) logger.Logger { return logger.NewNopLogger() })
+	if err != nil {
+		return err
+	}
+	var targets []cleanTarget
+	for _, c := range a.cleanAllOrder(cfg, components) {
+		objects, err := a.renderModule(ctx, c.module)
+		if err != nil {
+			return fmt.Errorf("%s: failed to generate, cannot tell which objects to delete: %w", c.name, err)
+		}
+		targets = append(targets, cleanTarget{name: c.name, objects: objects})
+	}
+
+	dyn, err := a.clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	mapper, err := a.clients.RESTMapper()
+	if err != nil {
+		return err
+	}
+	return a.cleanAll(ctx, dyn, mapper, targets, opts)
+}
+
+// cleanAll deletes the objects of targets in order once the user confirmed
+func (a *App) cleanAll(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, targets []cleanTarget, opts cleanAllOptions) error {
+	total := 0
+	for i, t := range targets {
+		objects, err := a.cleanAllObjects(ctx, dyn, mapper, t.objects, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.name, err)
+		}
+		targets[i].objects = objects
+		total += len(objects)
+	}
+	if total == 0 {
+		a.logger.Info("Nothing to clean: no generated objects exist in the cluster\n")
+		return nil
+	}
+
+	a.logger.Warn("clean-all deletes %d objects of %d components:\n", total, len(targets))
+	for _, t := range targets {
+		if len(t.objects) == 0 {
+			continue
+		}
+		kinds := make([]string, 0, len(t.objects))
+		for _, object := range t.objects {
+			kinds = append(kinds, object.GetKind()+"/"+object.GetName())
+		}
+		a.logger.Info("  %-20s %s\n", t.name, strings.Join(kinds, ", "))
+	}
+	if opts.keepData {
+		a.logger.Info("PersistentVolumeClaims and namespaces are kept (--keep-data)\n")
+	} else {
+		a.logger.Warn("PersistentVolumeClaims are deleted with their data; use --keep-data to keep them\n")
+	}
+	if !opts.yes && !a.confirm("Type 'yes' to continue: ") {
+		return fmt.Errorf("clean-all aborted")
+	}
+
+	deleted := 0
+	for _, t := range targets {
+		if len(t.objects) == 0 {
+			continue
+		}
+		a.logger.Progress("Cleaning %s\n", t.name)
+		n, err := k8s.DeleteObjects(ctx, dyn, mapper, t.objects)
+		deleted += n
+		if err != nil {
+			return fmt.Errorf("cleaning %s: %w", t.name, err)
+		}
+	}
+	a.logger.Success("Deleted %d objects of %d components\n", deleted, len(targets))
+	return nil
+}
+
+// cleanAllObjects returns the objects clean-all deletes: those that exist,
+// without PVCs and namespaces with keepData, and only labelled ones with
+// managedOnly
+func (a *App) cleanAllObjects(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured, opts cleanAllOptions) ([]*unstructured.Unstructured, error) {
+	var kept []*unstructured.Unstructured
+	for _, object := range objects {
+		// Deleting a namespace deletes the claims in it
+		if opts.keepData && (object.GetKind() == "PersistentVolumeClaim" || object.GetKind() == "Namespace") {
+			continue
+		}
+		live, err := k8s.GetObject(ctx, dyn, mapper, object)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opts.managedOnly && live.GetLabels()[k8s.ManagedByLabel] != k8s.ManagedByValue {
+			continue
+		}
+		kept = append(kept, object)
+	}
+	return kept, nil
+}
+
+// cleanAllOrder orders components so each comes before the ones it depends
+// on, e.g. drone before gitea before postgres. Components outside any
+// dependency keep their config order.
+func (a *App) cleanAllOrder(cfg *config.Config, components []component) []component {
+	dependents := a.dependents(cfg, components)
+	var order []component
+	visited := make(map[string]bool)
+	var visit func(c component)
+	visit = func(c component) {
+		if visited[c.name] {
+			return
+		}
+		visited[c.name] = true
+		for _, d := range dependents[c.name] {
+			visit(d)
+		}
+		order = append(order, c)
+	}
+	for _, c := range components {
+		visit(c)
+	}
+	return order
+}
+
+// confirm prints prompt and reports whether the user answered yes. Without
+// input, e.g. from a closed stdin in CI, the answer is no.
+func (a *App) confirm(prompt string) bool {
+	a.logger.Print("%s", prompt)
+	if a.stdin == nil {
+		return false
+	}
+	answer, _ := bufio.NewReader(a.stdin).ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer)) == "yes"
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const cleanAllTestManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: infra
+  labels:
+    managed-by: personal-server
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: web-data
+  namespace: infra
+  labels:
+    managed-by: personal-server
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-secrets
+  namespace: infra
+`
+
+var (
+	cleanAllConfigMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	cleanAllPVCGVR       = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	cleanAllSecretGVR    = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+// newCleanAllTestClients returns clients holding the objects of
+// cleanAllTestManifests
+func newCleanAllTestClients(t *testing.T) (*dynamicfake.FakeDynamicClient, meta.RESTMapper, []*unstructured.Unstructured) {
+	t.Helper()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		cleanAllConfigMapGVR: "ConfigMapList",
+		cleanAllPVCGVR:       "PersistentVolumeClaimList",
+		cleanAllSecretGVR:    "SecretList",
+	})
+	objects, err := k8s.DecodeManifest([]byte(cleanAllTestManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k8s.ApplyObjects(context.Background(), dyn, mapper, objects, nil); err != nil {
+		t.Fatal(err)
+	}
+	return dyn, mapper, objects
+}
+
+func remaining(t *testing.T, dyn *dynamicfake.FakeDynamicClient) []string {
+	t.Helper()
+	var names []string
+	for _, gvr := range []schema.GroupVersionResource{cleanAllConfigMapGVR, cleanAllPVCGVR, cleanAllSecretGVR} {
+		list, err := dyn.Resource(gvr).Namespace("infra").List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range list.Items {
+			names = append(names, item.GetName())
+		}
+	}
+	return names
+}
+
+func TestCleanAll(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   cleanAllOptions
+		stdin  string
+		want   []string
+		aborts bool
+	}{
+		{name: "confirmed", stdin: "yes\n", want: nil},
+		{name: "not confirmed", stdin: "n\n", want: []string{"web-config", "web-data", "web-secrets"}, aborts: true},
+		{name: "no input", want: []string{"web-config", "web-data", "web-secrets"}, aborts: true},
+		{name: "keep data", opts: cleanAllOptions{yes: true, keepData: true}, want: []string{"web-data"}},
+		{name: "managed only", opts: cleanAllOptions{yes: true, managedOnly: true}, want: []string{"web-secrets"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dyn, mapper, objects := newCleanAllTestClients(t)
+			var out strings.Builder
+			app := &App{logger: logger.NewStdLogger(&out), stdin: strings.NewReader(tt.stdin)}
+			err := app.cleanAll(context.Background(), dyn, mapper, []cleanTarget{{name: "web", objects: objects}}, tt.opts)
+			if (err != nil) != tt.aborts {
+				t.Fatalf("cleanAll() error = %v, want aborted %v", err, tt.aborts)
+			}
+			if got := strings.Join(remaining(t, dyn), ","); got != strings.Join(tt.want, ",") {
+				t.Errorf("remaining objects = %s, want %s", got, strings.Join(tt.want, ","))
+			}
+			if !tt.opts.yes && !strings.Contains(out.String(), "PersistentVolumeClaim/web-data") {
+				t.Errorf("output does not list the objects before asking:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestCleanAllOrder(t *testing.T) {
+	registry := modules.NewRegistry(logger.NewNopLogger())
+	registry.Register("postgres", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return basicHelpTestModule{name: "postgres"}
+	})
+	registry.Register("gitea", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return dependentTestModule{basicHelpTestModule{name: "gitea"}, []string{"postgres"}}
+	})
+	registry.Register("drone", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return dependentTestModule{basicHelpTestModule{name: "drone"}, []string{"gitea"}}
+	})
+	registry.Register("redis", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return basicHelpTestModule{name: "redis"}
+	})
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "postgres", Namespace: "infra"},
+		{Name: "redis", Namespace: "infra"},
+		{Name: "gitea", Namespace: "infra"},
+		{Name: "drone", Namespace: "infra"},
+	}}
+
+	app := &App{logger: logger.NewNopLogger(), registry: registry}
+	components, err := app.components(cfg, func(string) logger.Logger { return logger.NewNopLogger() })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range app.cleanAllOrder(cfg, components) {
+		names = append(names, c.name)
+	}
+	if got := strings.Join(names, ","); got != "drone,gitea,postgres,redis" {
+		t.Errorf("cleanAllOrder() = %s, want drone,gitea,postgres,redis", got)
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+// cleanTestModule records the order in which modules are cleaned
+type cleanTestModule struct {
+	dependentTestModule
+	cleaned *[]string
+}
+
+func (m cleanTestModule) Clean(context.Context) error {
+	*m.cleaned = append(*m.cleaned, m.name)
+	return nil
+}
+
+func newCleanTestApp(cleaned *[]string) (*App, *config.Config) {
+	log := logger.NewNopLogger()
+	registry := modules.NewRegistry(log)
+	register := func(name string, deps ...string) {
+		registry.Register(name, func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+			return cleanTestModule{dependentTestModule{basicHelpTestModule{name: m.Name}, deps}, cleaned}
+		})
+	}
+	register("postgres")
+	register("gitea", "postgres")
+	register("drone", "gitea")
+	register("pgadmin", "postgres")
+	register("redis")
+
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "postgres", Namespace: "infra"},
+		{Name: "drone", Namespace: "infra"},
+		{Name: "gitea", Namespace: "infra"},
+		{Name: "pgadmin", Namespace: "infra"},
+		{Name: "redis", Namespace: "infra"},
+	}}
+	return &App{logger: log, registry: registry}, cfg
+}
+
+func TestHandleCleanCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		module      string
+		args        []string
+		wantErr     string
+		wantCleaned string
+	}{
+		{name: "no dependents", module: "redis", wantCleaned: "redis"},
+		{name: "leaf module", module: "drone", wantCleaned: "drone"},
+		{name: "refuses without cascade", module: "postgres", wantErr: "--cascade to clean drone, gitea, pgadmin first"},
+		{name: "cascade cleans dependents first", module: "postgres", args: []string{"--cascade"}, wantCleaned: "drone,gitea,pgadmin,postgres"},
+		{name: "cascade from middle of chain", module: "gitea", args: []string{"--cascade"}, wantCleaned: "drone,gitea"},
+		{name: "unknown flag", module: "redis", args: []string{"--force"}, wantErr: "usage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cleaned []string
+			app, cfg := newCleanTestApp(&cleaned)
+			module, err := app.registry.Get(tt.module, cfg)
+			if err != nil {
+				t.Fatalf("Get(%s) error: %v", tt.module, err)
+			}
+
+			err = app.handleCleanCommand(context.Background(), cfg, tt.module, module, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want error containing %q", err, tt.wantErr)
+				}
+				if len(cleaned) != 0 {
+					t.Errorf("nothing should be cleaned on error, got %v", cleaned)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Join(cleaned, ","); got != tt.wantCleaned {
+				t.Errorf("cleaned %s, want %s", got, tt.wantCleaned)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+const (
+	kindModule     = "module"
+	kindPetProject = "pet-project"
+	kindIngress    = "ingress"
+)
+
+// component is a configured module, pet project or ingress
+type component struct {
+	name      string
+	namespace string
+	kind      string
+	module    modules.Module
+}
+
+// components builds every configured component in config order: modules,
+// then pet projects, then ingresses. logFor supplies the logger each
+// component's module writes to.
+func (a *App) components(cfg *config.Config, logFor func(name string) logger.Logger) ([]component, error) {
+	var out []component
+
+	for _, m := range cfg.Modules {
+		module, err := a.registry.WithLogger(logFor(m.Name)).Get(m.Name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		out = append(out, component{name: m.Name, namespace: m.Namespace, kind: kindModule, module: module})
+	}
+	for _, p := range cfg.PetProjects {
+		module, err := a.registry.WithLogger(logFor(p.Name)).GetPetProject(p.Name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		out = append(out, component{name: p.Name, namespace: p.Namespace, kind: kindPetProject, module: module})
+	}
+	for _, ing := range cfg.Ingresses {
+		module, err := a.registry.WithLogger(logFor(ing.Name)).GetIngress(ing.Name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ing.Name, err)
+		}
+		out = append(out, component{name: ing.Name, namespace: ing.Namespace, kind: kindIngress, module: module})
+	}
+
+	return out, nil
+}
+
+// dependencies returns the names of the configured modules c depends on.
+// Dependencies on modules that are not configured are ignored, e.g. when an
+// external database is used.
+func (a *App) dependencies(cfg *config.Config, c component) []string {
+	dependent, ok := c.module.(modules.Dependent)
+	if !ok {
+		return nil
+	}
+	var deps []string
+	for _, kind := range dependent.Dependencies() {
+		for _, m := range cfg.Modules {
+			if k, ok := a.registry.Kind(m.Name); ok && k == kind && m.Name != c.name {
+				deps = append(deps, m.Name)
+			}
+		}
+	}
+	return deps
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+// handleConfigExplainCommand prints the supported configuration keys. Without
+// arguments it describes the whole file; with a module, ingress or pet
+// project name it describes that entry, including module-specific secrets.
+func (a *App) handleConfigExplainCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		a.logger.Info("Configuration file keys:\n\n")
+		a.printFields(config.Describe(config.Config{}))
+		a.logger.Info("\nRun '%s config explain <module>' for module-specific keys.\n", Name)
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: config explain [module]")
+	}
+	name := args[0]
+
+	if _, err := cfg.GetIngress(name); err == nil {
+		a.logger.Info("Configuration keys for ingress %s (ingresses[] entry):\n\n", name)
+		a.printFields(config.Describe(config.IngressConfig{}))
+		return nil
+	}
+	if _, err := cfg.GetPetProject(name); err == nil {
+		a.logger.Info("Configuration keys for pet project %s (pet-projects[] entry):\n\n", name)
+		a.printFields(config.Describe(config.PetProject{}))
+		return nil
+	}
+
+	module, err := a.registry.Get(name, explainConfig(cfg, name))
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("Configuration keys for module %s (modules[] entry):\n\n", name)
+	a.printFields(config.Describe(config.Module{}))
+
+	provider, ok := module.(modules.ConfigSchemaProvider)
+	if !ok {
+		a.logger.Info("\nModule %s reads no modules[].secrets keys. Run '%s %s doc' for details.\n", name, Name, name)
+		return nil
+	}
+	a.logger.Info("\nModule-specific keys (modules[].secrets):\n\n")
+	a.printFields(config.Describe(provider.ConfigSchema()))
+	return nil
+}
+
+// explainConfig returns cfg with a placeholder entry for name, so that modules
+// missing from the config file can still be explained.
+func explainConfig(cfg *config.Config, name string) *config.Config {
+	if _, err := cfg.GetModule(name); err == nil {
+		return cfg
+	}
+	clone := *cfg
+	clone.Modules = append(append([]config.Module{}, cfg.Modules...), config.Module{Name: name})
+	return &clone
+}
+
+func (a *App) printFields(fields []config.Field) {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tDEFAULT\tREQUIRED\tDESCRIPTION")
+	for _, f := range fields {
+		required := "no"
+		if f.Required {
+			required = "yes"
+		}
+		def := f.Default
+		if def == "" {
+			def = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Path, f.Type, def, required, f.Description)
+	}
+	w.Flush()
+	a.logger.Print("%s", sb.String())
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+func TestHandleConfigExplainCommand(t *testing.T) {
+	cfg := &config.Config{
+		Ingresses: []config.IngressConfig{{Name: "dashboard-ingress", Namespace: "infra"}},
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "top level",
+			args: nil,
+			want: []string{"KEY", "general.domain", "modules[].secrets", "ingresses[].basicAuth.users"},
+		},
+		{
+			name: "module not in config",
+			args: []string{"redis"},
+			want: []string{"modules[] entry", "namespace", "redis_password", "yes"},
+		},
+		{
+			name: "module with defaults",
+			args: []string{"webdav-infra"},
+			want: []string{"versioning_interval", "3600"},
+		},
+		{
+			name: "ingress",
+			args: []string{"dashboard-ingress"},
+			want: []string{"ingresses[] entry", "clientCertAuth", "rules[].pathType", "Prefix"},
+		},
+		{
+			name:    "unknown",
+			args:    []string{"nope"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf strings.Builder
+			log := logger.NewStdLogger(&logBuf)
+			app := &App{logger: log, registry: modules.DefaultRegistry(log)}
+
+			err := app.handleConfigExplainCommand(cfg, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleConfigExplainCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			output := logBuf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
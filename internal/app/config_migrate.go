@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Goalt/personal-server/internal/config"
+)
+
+func (a *App) handleConfigMigrateCommand(cfg *config.Config) error {
+	applied := cfg.AppliedMigrations()
+	if len(applied) == 0 {
+		a.logger.Success("Config is already at version %d, nothing to migrate\n", config.CurrentConfigVersion)
+		return nil
+	}
+
+	if cfg.Encrypted() {
+		return fmt.Errorf("config %s is encrypted with SOPS; decrypt it with 'sops --decrypt --in-place %s', migrate and encrypt it again", cfg.Path, cfg.Path)
+	}
+
+	original, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	backupPath := cfg.Path + ".bak"
+	if err := os.WriteFile(backupPath, original, 0600); err != nil {
+		return fmt.Errorf("writing config backup: %w", err)
+	}
+	a.logger.Info("Saved original config to %s\n", backupPath)
+
+	for _, step := range applied {
+		a.logger.Progress("Applied migration %s\n", step)
+	}
+
+	if err := cfg.SaveConfig(); err != nil {
+		return fmt.Errorf("saving config: %w", err)
+	}
+
+	a.logger.Success("Migrated config to version %d\n", config.CurrentConfigVersion)
+	return nil
+}
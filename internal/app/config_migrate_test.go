@@ -0,0 +1,65 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+func TestHandleConfigMigrateCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `general:
+  domain: example.com
+modules:
+  - name: redis
+    namespace: infra
+`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	var logBuf strings.Builder
+	app := &App{logger: logger.NewStdLogger(&logBuf)}
+
+	if err := app.handleConfigMigrateCommand(cfg); err != nil {
+		t.Fatalf("handleConfigMigrateCommand failed: %v", err)
+	}
+
+	backup, err := os.ReadFile(configFile + ".bak")
+	if err != nil {
+		t.Fatalf("expected backup of the original config: %v", err)
+	}
+	if string(backup) != configContent {
+		t.Errorf("backup content = %q, want original", string(backup))
+	}
+
+	reloaded, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if reloaded.ConfigVersion != config.CurrentConfigVersion {
+		t.Errorf("ConfigVersion = %d, want %d", reloaded.ConfigVersion, config.CurrentConfigVersion)
+	}
+	if len(reloaded.AppliedMigrations()) != 0 {
+		t.Errorf("expected no pending migrations after migrate, got %v", reloaded.AppliedMigrations())
+	}
+
+	logBuf.Reset()
+	if err := app.handleConfigMigrateCommand(reloaded); err != nil {
+		t.Fatalf("second migrate failed: %v", err)
+	}
+	if !strings.Contains(logBuf.String(), "nothing to migrate") {
+		t.Errorf("expected no-op message, got: %s", logBuf.String())
+	}
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/i18n"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+// handleConfigValidateCommand checks the config file without contacting the
+// cluster and prints every problem it finds, including required module
+// secrets that are otherwise only reported on apply.
+func (a *App) handleConfigValidateCommand(configFile string) error {
+	cfg, err := config.ReadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("loading config %s: %w", configFile, err)
+	}
+
+	problems := append(generalProblems(cfg.General), cfg.Problems()...)
+	problems = append(problems, a.entryProblems(cfg)...)
+	if len(problems) == 0 {
+		a.logger.Success("Config %s is valid\n", configFile)
+		return nil
+	}
+	for _, problem := range problems {
+		a.logger.Error("%v\n", problem)
+	}
+	return fmt.Errorf("config %s has %d problem(s)", configFile, len(problems))
+}
+
+// generalProblems checks the general section for values the commands reject
+// before doing any work
+func generalProblems(general config.GeneralConfig) []error {
+	var problems []error
+	if general.Language != "" {
+		if _, err := i18n.Parse(general.Language); err != nil {
+			problems = append(problems, fmt.Errorf("invalid general.language: %w", err))
+		}
+	}
+	if general.Timezone != "" {
+		if _, err := time.LoadLocation(general.Timezone); err != nil {
+			problems = append(problems, fmt.Errorf("invalid general.timezone: %w", err))
+		}
+	}
+	for _, proxy := range []struct{ name, url string }{{"http", general.Proxy.HTTP}, {"https", general.Proxy.HTTPS}} {
+		if proxy.url == "" {
+			continue
+		}
+		if u, err := url.Parse(proxy.url); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Errorf("invalid general.proxy.%s %q: must be a URL such as http://proxy.example.com:3128", proxy.name, proxy.url))
+		}
+	}
+	if general.CABundle != "" && !path.IsAbs(general.CABundle) {
+		problems = append(problems, fmt.Errorf("invalid general.caBundle %q: must be an absolute path on the node", general.CABundle))
+	}
+	if err := k8s.ValidateIPFamilyPolicy(general.IPFamilyPolicy); err != nil {
+		problems = append(problems, fmt.Errorf("invalid general.ipFamilyPolicy: %w", err))
+	}
+	if _, err := kubernetesClientOptions(general.Kubernetes); err != nil {
+		problems = append(problems, err)
+	}
+	return problems
+}
+
+// entryProblems checks what only surfaces once an entry is generated: missing
+// domain, names and namespaces, duplicate or unknown modules and the required
+// modules[].secrets keys each module documents.
+func (a *App) entryProblems(cfg *config.Config) []error {
+	var problems []error
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if cfg.General.Domain == "" {
+		add("general.domain is required")
+	}
+
+	seen := make(map[string]bool)
+	for i, m := range cfg.Modules {
+		if m.Name == "" {
+			add("modules[%d]: name is required", i)
+			continue
+		}
+		if seen[m.Name] {
+			add("module %s: defined more than once", m.Name)
+			continue
+		}
+		seen[m.Name] = true
+		if m.Namespace == "" {
+			add("module %s: namespace is required", m.Name)
+		}
+
+		module, err := a.registry.Get(m.Name, cfg)
+		if err != nil {
+			add("module %s: %v", m.Name, err)
+			continue
+		}
+		provider, ok := module.(modules.ConfigSchemaProvider)
+		if !ok {
+			continue
+		}
+		for _, field := range config.Describe(provider.ConfigSchema()) {
+			if !field.Required || strings.ContainsAny(field.Path, ".[") {
+				continue
+			}
+			if m.Secrets[field.Path] == "" {
+				add("module %s: missing required secret %s", m.Name, field.Path)
+			}
+		}
+	}
+	for i, ing := range cfg.Ingresses {
+		if ing.Name == "" {
+			add("ingresses[%d]: name is required", i)
+		} else if ing.Namespace == "" {
+			add("ingress %s: namespace is required", ing.Name)
+		}
+	}
+	for i, p := range cfg.PetProjects {
+		if p.Name == "" {
+			add("pet-projects[%d]: name is required", i)
+		} else if p.Namespace == "" {
+			add("pet project %s: namespace is required", p.Name)
+		}
+	}
+	return problems
+}
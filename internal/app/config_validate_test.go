@@ -0,0 +1,82 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+func TestHandleConfigValidateCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			config: `general:
+  domain: example.com
+modules:
+  - name: postgres
+    namespace: infra
+    secrets:
+      admin_postgres_user: postgres
+      admin_postgres_password: secret
+`,
+			want: []string{"is valid"},
+		},
+		{
+			name: "every problem",
+			config: `general:
+  timezone: Mars/Olympus
+modules:
+  - name: postgres
+    namespace: infra
+    storage: lots
+    secrets:
+      admin_postgres_password: secret
+  - name: nope
+    namespace: infra
+  - name: postgres
+    namespace: infra
+`,
+			want: []string{
+				"invalid general.timezone",
+				"module postgres: invalid storage",
+				"general.domain is required",
+				"module postgres: missing required secret admin_postgres_user",
+				"module nope: unknown module",
+				"module postgres: defined more than once",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			var logBuf strings.Builder
+			log := logger.NewStdLogger(&logBuf)
+			app := &App{logger: log, registry: modules.DefaultRegistry(log)}
+
+			err := app.handleConfigValidateCommand(configFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleConfigValidateCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			output := logBuf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,634 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	moduleGroup    = "personal-server.io"
+	moduleVersion  = "v1alpha1"
+	moduleKind     = "PersonalServerModule"
+	moduleResource = "personalservermodules"
+
+	modulePhaseReady  = "Ready"
+	modulePhaseFailed = "Failed"
+)
+
+var moduleGVR = schema.GroupVersionResource{Group: moduleGroup, Version: moduleVersion, Resource: moduleResource}
+
+// moduleCRD defines PersonalServerModule: a module configured in the cluster
+// instead of the config file. The resource name is the module name and its
+// namespace is the namespace the module is deployed to.
+const moduleCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: personalservermodules.personal-server.io
+  labels:
+    managed-by: personal-server
+spec:
+  group: personal-server.io
+  scope: Namespaced
+  names:
+    kind: PersonalServerModule
+    listKind: PersonalServerModuleList
+    plural: personalservermodules
+    singular: personalservermodule
+    shortNames:
+      - psm
+  versions:
+    - name: v1alpha1
+      served: true
+      storage: true
+      subresources:
+        status: {}
+      additionalPrinterColumns:
+        - name: Phase
+          type: string
+          jsonPath: .status.phase
+        - name: Objects
+          type: integer
+          jsonPath: .status.objects
+        - name: Age
+          type: date
+          jsonPath: .metadata.creationTimestamp
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              properties:
+                image:
+                  type: string
+                  description: Image of the module's main container, e.g. postgres:17
+                storage:
+                  type: string
+                  description: Size of the module's data volume, e.g. 20Gi
+                storageClass:
+                  type: string
+                  description: StorageClass of the module's volumes
+                envs:
+                  type: object
+                  additionalProperties:
+                    type: string
+                  description: Extra environment variables for the module container
+                labels:
+                  type: object
+                  additionalProperties:
+                    type: string
+                  description: Extra labels on the module's objects and pods
+                annotations:
+                  type: object
+                  additionalProperties:
+                    type: string
+                  description: Extra annotations on the module's objects and pods
+                ingress:
+                  type: object
+                  description: Expose the module's web interface through the ingress controller
+                  properties:
+                    host:
+                      type: string
+                    path:
+                      type: string
+                    pathType:
+                      type: string
+                    tls:
+                      type: boolean
+                    basicAuth:
+                      type: object
+                      properties:
+                        realm:
+                          type: string
+                        users:
+                          type: object
+                          additionalProperties:
+                            type: string
+                metrics:
+                  type: object
+                  description: Have Prometheus scrape the metrics the module serves
+                  properties:
+                    port:
+                      type: integer
+                    path:
+                      type: string
+                    scheme:
+                      type: string
+                    serviceMonitor:
+                      type: boolean
+                    interval:
+                      type: string
+                secrets:
+                  type: object
+                  additionalProperties:
+                    type: string
+                  description: Module-specific settings (see config explain <module>)
+                secretRef:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+                  required:
+                    - name
+                  description: Secret in the same namespace whose keys override secrets
+            status:
+              type: object
+              properties:
+                phase:
+                  type: string
+                message:
+                  type: string
+                objects:
+                  type: integer
+                observedGeneration:
+                  type: integer
+                  format: int64
+`
+
+func (a *App) handleCRDCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	usage := fmt.Sprintf("usage: %s crd install|manifest|export", Name)
+	if len(args) != 1 {
+		return fmt.Errorf("%s", usage)
+	}
+	switch args[0] {
+	case "manifest":
+		a.logger.Print("%s", moduleCRD)
+		return nil
+	case "export":
+		output, err := exportModuleResources(cfg)
+		if err != nil {
+			return err
+		}
+		a.logger.Print("%s", output)
+		return nil
+	case "install":
+		objects, err := k8s.DecodeManifest([]byte(moduleCRD))
+		if err != nil {
+			return err
+		}
+		dyn, err := a.clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+		mapper, err := a.clients.RESTMapper()
+		if err != nil {
+			return err
+		}
+		if err := k8s.ApplyObjects(ctx, dyn, mapper, objects, nil); err != nil {
+			return err
+		}
+		a.logger.Success("Installed CustomResourceDefinition %s\n", objects[0].GetName())
+		return nil
+	}
+	return fmt.Errorf("%s", usage)
+}
+
+// exportModuleResources renders the config file's modules as
+// PersonalServerModule resources, with their secrets moved to a Secret
+func exportModuleResources(cfg *config.Config) (string, error) {
+	var documents []string
+	for _, m := range cfg.Modules {
+		spec := map[string]interface{}{}
+		if m.Image != "" {
+			spec["image"] = m.Image
+		}
+		if m.Storage != "" {
+			spec["storage"] = m.Storage
+		}
+		if m.StorageClass != "" {
+			spec["storageClass"] = m.StorageClass
+		}
+		if len(m.Envs) > 0 {
+			spec["envs"] = m.Envs
+		}
+		if len(m.Labels) > 0 {
+			spec["labels"] = m.Labels
+		}
+		if len(m.Annotations) > 0 {
+			spec["annotations"] = m.Annotations
+		}
+		if m.Ingress != nil {
+			ingress := map[string]interface{}{}
+			for key, value := range map[string]string{"host": m.Ingress.Host, "path": m.Ingress.Path, "pathType": m.Ingress.PathType} {
+				if value != "" {
+					ingress[key] = value
+				}
+			}
+			if m.Ingress.TLS != nil {
+				ingress["tls"] = *m.Ingress.TLS
+			}
+			if auth := m.Ingress.BasicAuth; auth != nil {
+				basicAuth := map[string]interface{}{"users": auth.Users}
+				if auth.Realm != "" {
+					basicAuth["realm"] = auth.Realm
+				}
+				ingress["basicAuth"] = basicAuth
+			}
+			spec["ingress"] = ingress
+		}
+		if m.Metrics != nil {
+			metrics := map[string]interface{}{}
+			for key, value := range map[string]string{"path": m.Metrics.Path, "scheme": m.Metrics.Scheme, "interval": m.Metrics.Interval} {
+				if value != "" {
+					metrics[key] = value
+				}
+			}
+			if m.Metrics.Port != 0 {
+				metrics["port"] = int64(m.Metrics.Port)
+			}
+			if m.Metrics.ServiceMonitor {
+				metrics["serviceMonitor"] = true
+			}
+			spec["metrics"] = metrics
+		}
+		objects := []interface{}{}
+		if len(m.Secrets) > 0 {
+			secretName := m.Name + "-settings"
+			spec["secretRef"] = map[string]interface{}{"name": secretName}
+			objects = append(objects, map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": secretName, "namespace": m.Namespace},
+				"type":       "Opaque",
+				"stringData": m.Secrets,
+			})
+		}
+		objects = append(objects, map[string]interface{}{
+			"apiVersion": moduleGroup + "/" + moduleVersion,
+			"kind":       moduleKind,
+			"metadata":   map[string]interface{}{"name": m.Name, "namespace": m.Namespace},
+			"spec":       spec,
+		})
+		for _, object := range objects {
+			jsonBytes, err := json.Marshal(object)
+			if err != nil {
+				return "", fmt.Errorf("failed to convert %s to JSON: %w", m.Name, err)
+			}
+			yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+			if err != nil {
+				return "", fmt.Errorf("failed to convert %s to YAML: %w", m.Name, err)
+			}
+			documents = append(documents, yamlContent)
+		}
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+// moduleFromResource reads the module config of a PersonalServerModule,
+// merging the keys of its referenced Secret over spec.secrets
+func moduleFromResource(ctx context.Context, client kubernetes.Interface, resource *unstructured.Unstructured) (config.Module, error) {
+	m := config.Module{Name: resource.GetName(), Namespace: resource.GetNamespace()}
+	image, _, err := unstructured.NestedString(resource.Object, "spec", "image")
+	if err != nil {
+		return m, fmt.Errorf("invalid spec.image: %w", err)
+	}
+	m.Image = image
+	if m.Storage, _, err = unstructured.NestedString(resource.Object, "spec", "storage"); err != nil {
+		return m, fmt.Errorf("invalid spec.storage: %w", err)
+	}
+	if m.StorageClass, _, err = unstructured.NestedString(resource.Object, "spec", "storageClass"); err != nil {
+		return m, fmt.Errorf("invalid spec.storageClass: %w", err)
+	}
+	if m.Envs, _, err = unstructured.NestedStringMap(resource.Object, "spec", "envs"); err != nil {
+		return m, fmt.Errorf("invalid spec.envs: %w", err)
+	}
+	if m.Labels, _, err = unstructured.NestedStringMap(resource.Object, "spec", "labels"); err != nil {
+		return m, fmt.Errorf("invalid spec.labels: %w", err)
+	}
+	if m.Annotations, _, err = unstructured.NestedStringMap(resource.Object, "spec", "annotations"); err != nil {
+		return m, fmt.Errorf("invalid spec.annotations: %w", err)
+	}
+	if _, found, err := unstructured.NestedMap(resource.Object, "spec", "ingress"); err != nil {
+		return m, fmt.Errorf("invalid spec.ingress: %w", err)
+	} else if found {
+		m.Ingress = &config.ModuleIngress{}
+		for field, value := range map[string]*string{"host": &m.Ingress.Host, "path": &m.Ingress.Path, "pathType": &m.Ingress.PathType} {
+			if *value, _, err = unstructured.NestedString(resource.Object, "spec", "ingress", field); err != nil {
+				return m, fmt.Errorf("invalid spec.ingress.%s: %w", field, err)
+			}
+		}
+		tls, found, err := unstructured.NestedBool(resource.Object, "spec", "ingress", "tls")
+		if err != nil {
+			return m, fmt.Errorf("invalid spec.ingress.tls: %w", err)
+		}
+		if found {
+			m.Ingress.TLS = &tls
+		}
+		users, found, err := unstructured.NestedStringMap(resource.Object, "spec", "ingress", "basicAuth", "users")
+		if err != nil {
+			return m, fmt.Errorf("invalid spec.ingress.basicAuth: %w", err)
+		}
+		if found {
+			m.Ingress.BasicAuth = &config.IngressBasicAuth{Users: users}
+			if m.Ingress.BasicAuth.Realm, _, err = unstructured.NestedString(resource.Object, "spec", "ingress", "basicAuth", "realm"); err != nil {
+				return m, fmt.Errorf("invalid spec.ingress.basicAuth: %w", err)
+			}
+		}
+	}
+	if _, found, err := unstructured.NestedMap(resource.Object, "spec", "metrics"); err != nil {
+		return m, fmt.Errorf("invalid spec.metrics: %w", err)
+	} else if found {
+		m.Metrics = &config.ModuleMetrics{}
+		for field, value := range map[string]*string{"path": &m.Metrics.Path, "scheme": &m.Metrics.Scheme, "interval": &m.Metrics.Interval} {
+			if *value, _, err = unstructured.NestedString(resource.Object, "spec", "metrics", field); err != nil {
+				return m, fmt.Errorf("invalid spec.metrics.%s: %w", field, err)
+			}
+		}
+		port, _, err := unstructured.NestedInt64(resource.Object, "spec", "metrics", "port")
+		if err != nil {
+			return m, fmt.Errorf("invalid spec.metrics.port: %w", err)
+		}
+		m.Metrics.Port = int32(port)
+		if m.Metrics.ServiceMonitor, _, err = unstructured.NestedBool(resource.Object, "spec", "metrics", "serviceMonitor"); err != nil {
+			return m, fmt.Errorf("invalid spec.metrics.serviceMonitor: %w", err)
+		}
+	}
+	if m.Secrets, _, err = unstructured.NestedStringMap(resource.Object, "spec", "secrets"); err != nil {
+		return m, fmt.Errorf("invalid spec.secrets: %w", err)
+	}
+	secretName, _, err := unstructured.NestedString(resource.Object, "spec", "secretRef", "name")
+	if err != nil {
+		return m, fmt.Errorf("invalid spec.secretRef: %w", err)
+	}
+	if secretName == "" {
+		return m, nil
+	}
+	secret, err := client.CoreV1().Secrets(m.Namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return m, fmt.Errorf("failed to get Secret %s: %w", secretName, err)
+	}
+	if m.Secrets == nil {
+		m.Secrets = map[string]string{}
+	}
+	for key, value := range secret.Data {
+		m.Secrets[key] = string(value)
+	}
+	return m, nil
+}
+
+// mergeModuleResources returns cfg with the modules defined by resources
+// added, replacing config file modules of the same name. Resources that
+// cannot be used are left out and returned with the reason.
+func (a *App) mergeModuleResources(ctx context.Context, client kubernetes.Interface, cfg *config.Config, resources []*unstructured.Unstructured) (*config.Config, map[string]error) {
+	failed := map[string]error{}
+	byName := map[string][]*unstructured.Unstructured{}
+	for _, resource := range resources {
+		byName[resource.GetName()] = append(byName[resource.GetName()], resource)
+	}
+
+	defined := map[string]config.Module{}
+	for name, same := range byName {
+		if len(same) > 1 {
+			var namespaces []string
+			for _, resource := range same {
+				namespaces = append(namespaces, resource.GetNamespace())
+			}
+			sort.Strings(namespaces)
+			for _, resource := range same {
+				failed[resourceKey(resource)] = fmt.Errorf("module %s is defined in several namespaces: %s", name, strings.Join(namespaces, ", "))
+			}
+			continue
+		}
+		if !a.registry.Has(name) {
+			failed[resourceKey(same[0])] = fmt.Errorf("unknown module: %s", name)
+			continue
+		}
+		m, err := moduleFromResource(ctx, client, same[0])
+		if err != nil {
+			failed[resourceKey(same[0])] = err
+			continue
+		}
+		defined[name] = m
+	}
+
+	merged := *cfg
+	merged.Modules = nil
+	for _, m := range cfg.Modules {
+		if _, ok := defined[m.Name]; !ok {
+			merged.Modules = append(merged.Modules, m)
+		}
+	}
+	names := make([]string, 0, len(defined))
+	for name := range defined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		merged.Modules = append(merged.Modules, defined[name])
+	}
+	return &merged, failed
+}
+
+func resourceKey(resource *unstructured.Unstructured) string {
+	return resource.GetNamespace() + "/" + resource.GetName()
+}
+
+// setModuleOwners makes each PersonalServerModule the controller of the
+// objects its module generates in its namespace, so deleting the resource
+// deletes the module. PersistentVolumeClaims are left unowned to keep data.
+func setModuleOwners(state desiredState, resources []*unstructured.Unstructured) {
+	for _, resource := range resources {
+		controller := true
+		owner := metav1.OwnerReference{
+			APIVersion: moduleGroup + "/" + moduleVersion,
+			Kind:       moduleKind,
+			Name:       resource.GetName(),
+			UID:        resource.GetUID(),
+			Controller: &controller,
+		}
+		for _, object := range state[resource.GetName()] {
+			if object.GetNamespace() == resource.GetNamespace() && object.GetKind() != "PersistentVolumeClaim" {
+				object.SetOwnerReferences([]metav1.OwnerReference{owner})
+			}
+		}
+	}
+}
+
+// moduleOperator renders the config file's modules together with the
+// PersonalServerModule resources and hands the result to the reconciler
+type moduleOperator struct {
+	app       *App
+	cfg       *config.Config
+	client    kubernetes.Interface
+	dyn       dynamic.Interface
+	watchDyn  dynamic.Interface // without a request timeout, for the informer
+	mapper    meta.RESTMapper
+	namespace string
+	resync    time.Duration
+}
+
+// run renders into r and runs it, then re-renders when a
+// PersonalServerModule is added, deleted or its spec changes, and every
+// resync period to pick up edited Secrets. started is called once the first
+// state is in place. It blocks until ctx is cancelled.
+func (o *moduleOperator) run(ctx context.Context, r *reconciler, started func() error) error {
+	if _, err := o.mapper.RESTMapping(schema.GroupKind{Group: moduleGroup, Kind: moduleKind}, moduleVersion); err != nil {
+		return fmt.Errorf("%s is not installed: run '%s crd install' first: %w", moduleKind, Name, err)
+	}
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(o.watchDyn, 0)
+	defer factory.Shutdown()
+	informer := factory.ForResource(moduleGVR).Informer()
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// status updates, including our own, do not change the generation
+			oldResource, ok1 := oldObj.(*unstructured.Unstructured)
+			newResource, ok2 := newObj.(*unstructured.Unstructured)
+			if !ok1 || !ok2 || oldResource.GetGeneration() != newResource.GetGeneration() {
+				notify()
+			}
+		},
+		DeleteFunc: func(interface{}) { notify() },
+	}); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", moduleResource, err)
+	}
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return ctx.Err()
+	}
+	// the initial list is rendered below, not on its add events
+	select {
+	case <-changed:
+	default:
+	}
+
+	state, err := o.render(ctx, informer.GetStore())
+	if err != nil {
+		return err
+	}
+	r.setState(state)
+	if err := storeDesiredState(ctx, o.client, o.namespace, r.state()); err != nil {
+		return err
+	}
+	if err := started(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- r.run(ctx) }()
+
+	ticker := time.NewTicker(o.resync)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-changed:
+		case <-ticker.C:
+		}
+		state, err := o.render(ctx, informer.GetStore())
+		if err == nil {
+			err = r.update(ctx, state)
+		}
+		if err == nil {
+			// stored after the update so images deployed through the
+			// webhook are included
+			err = storeDesiredState(ctx, o.client, o.namespace, r.state())
+		}
+		if err != nil && ctx.Err() == nil {
+			o.app.logger.Error("%v\n", err)
+		}
+	}
+}
+
+// render generates the desired state of the config file's modules and the
+// PersonalServerModule resources in store and reports the outcome in each
+// resource's status
+func (o *moduleOperator) render(ctx context.Context, store cache.Store) (desiredState, error) {
+	var resources []*unstructured.Unstructured
+	for _, item := range store.List() {
+		if resource, ok := item.(*unstructured.Unstructured); ok && resource.GetDeletionTimestamp() == nil {
+			resources = append(resources, resource.DeepCopy())
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool { return resourceKey(resources[i]) < resourceKey(resources[j]) })
+
+	merged, failed := o.app.mergeModuleResources(ctx, o.client, o.cfg, resources)
+	state, generateFailed, err := o.app.renderComponents(ctx, merged)
+	if err != nil {
+		return nil, err
+	}
+	var usable []*unstructured.Unstructured
+	for _, resource := range resources {
+		if _, ok := failed[resourceKey(resource)]; ok {
+			continue
+		}
+		if err, ok := generateFailed[resource.GetName()]; ok {
+			failed[resourceKey(resource)] = err
+			delete(generateFailed, resource.GetName())
+			continue
+		}
+		usable = append(usable, resource)
+	}
+	for name, err := range generateFailed {
+		o.app.logger.Error("[%s] failed to generate: %v\n", name, err)
+	}
+	setModuleOwners(state, usable)
+	o.app.logger.Info("Rendered %d objects from %d config modules and %d %s resources\n", state.count(), len(o.cfg.Modules), len(resources), moduleKind)
+
+	for _, resource := range resources {
+		status := map[string]interface{}{
+			"phase":              modulePhaseReady,
+			"message":            "",
+			"objects":            int64(len(state[resource.GetName()])),
+			"observedGeneration": resource.GetGeneration(),
+		}
+		if err, ok := failed[resourceKey(resource)]; ok {
+			status["phase"] = modulePhaseFailed
+			status["message"] = err.Error()
+			status["objects"] = int64(0)
+			o.app.logger.Error("[%s] %v\n", resourceKey(resource), err)
+		}
+		if err := o.updateStatus(ctx, resource, status); err != nil {
+			o.app.logger.Warn("[%s] %v\n", resourceKey(resource), err)
+		}
+	}
+	return state, nil
+}
+
+// updateStatus writes status unless the resource already has it
+func (o *moduleOperator) updateStatus(ctx context.Context, resource *unstructured.Unstructured, status map[string]interface{}) error {
+	current, _, _ := unstructured.NestedMap(resource.Object, "status")
+	if current == nil {
+		current = map[string]interface{}{}
+	}
+	if current["message"] == nil {
+		current["message"] = ""
+	}
+	if equality.Semantic.DeepEqual(current, status) {
+		return nil
+	}
+	resource.Object["status"] = status
+	_, err := o.dyn.Resource(moduleGVR).Namespace(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
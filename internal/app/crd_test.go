@@ -0,0 +1,207 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func moduleResourceFixture(name, namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	resource := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": moduleGroup + "/" + moduleVersion,
+		"kind":       moduleKind,
+		"spec":       spec,
+	}}
+	resource.SetName(name)
+	resource.SetNamespace(namespace)
+	resource.SetUID(types.UID("uid-" + namespace + "-" + name))
+	return resource
+}
+
+func TestModuleCRDManifest(t *testing.T) {
+	objects, err := k8s.DecodeManifest([]byte(moduleCRD))
+	if err != nil {
+		t.Fatalf("DecodeManifest() error = %v", err)
+	}
+	if len(objects) != 1 || objects[0].GetKind() != "CustomResourceDefinition" || objects[0].GetName() != moduleResource+"."+moduleGroup {
+		t.Fatalf("manifest = %v, want the %s CRD", objects, moduleKind)
+	}
+	kind, _, _ := unstructured.NestedString(objects[0].Object, "spec", "names", "kind")
+	if kind != moduleKind {
+		t.Errorf("kind = %q, want %q", kind, moduleKind)
+	}
+}
+
+func TestMergeModuleResources(t *testing.T) {
+	log := logger.NewNopLogger()
+	registry := modules.NewRegistry(log)
+	for _, name := range []string{"postgres", "redis", "gitea"} {
+		name := name
+		registry.Register(name, func(config.GeneralConfig, config.Module, logger.Logger) modules.Module {
+			return basicHelpTestModule{name: name}
+		})
+	}
+	app := &App{logger: log, registry: registry}
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres-settings", Namespace: "db"},
+		Data:       map[string][]byte{"password": []byte("from-secret")},
+	})
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "postgres", Namespace: "infra", Image: "postgres:15"},
+		{Name: "redis", Namespace: "infra"},
+	}}
+
+	merged, failed := app.mergeModuleResources(context.Background(), client, cfg, []*unstructured.Unstructured{
+		moduleResourceFixture("postgres", "db", map[string]interface{}{
+			"image":     "postgres:16",
+			"storage":   "20Gi",
+			"labels":    map[string]interface{}{"team": "data"},
+			"ingress":   map[string]interface{}{"host": "db.example.com", "tls": true, "basicAuth": map[string]interface{}{"users": map[string]interface{}{"admin": "secret"}}},
+			"metrics":   map[string]interface{}{"port": int64(9187), "serviceMonitor": true},
+			"secrets":   map[string]interface{}{"password": "inline", "database": "app"},
+			"secretRef": map[string]interface{}{"name": "postgres-settings"},
+		}),
+		moduleResourceFixture("gitea", "dev", map[string]interface{}{}),
+		moduleResourceFixture("gitea", "prod", map[string]interface{}{}),
+		moduleResourceFixture("unknown", "infra", map[string]interface{}{}),
+	})
+
+	if len(merged.Modules) != 2 || merged.Modules[0].Name != "redis" {
+		t.Fatalf("modules = %+v, want redis from the config and postgres from the resource", merged.Modules)
+	}
+	postgres := merged.Modules[1]
+	if postgres.Namespace != "db" || postgres.Image != "postgres:16" || postgres.Storage != "20Gi" || postgres.Labels["team"] != "data" || postgres.IngressHost(cfg.General) != "db.example.com" || !postgres.IngressTLS(cfg.General) || postgres.Ingress.BasicAuth == nil || postgres.Ingress.BasicAuth.Users["admin"] != "secret" || postgres.Metrics == nil || postgres.Metrics.Port != 9187 || !postgres.Metrics.ServiceMonitor || postgres.Secrets["password"] != "from-secret" || postgres.Secrets["database"] != "app" {
+		t.Errorf("postgres = %+v, want the resource's namespace, image, storage, labels, ingress, metrics and secrets merged with its Secret", postgres)
+	}
+	if len(cfg.Modules) != 2 || cfg.Modules[0].Image != "postgres:15" {
+		t.Errorf("config modules changed to %+v", cfg.Modules)
+	}
+
+	for _, key := range []string{"dev/gitea", "prod/gitea", "infra/unknown"} {
+		if failed[key] == nil {
+			t.Errorf("%s not reported as failed", key)
+		}
+	}
+	if len(failed) != 3 {
+		t.Errorf("failed = %v, want 3 resources", failed)
+	}
+}
+
+func TestSetModuleOwners(t *testing.T) {
+	objects, err := k8s.DecodeManifest([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: postgres
+  namespace: db
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: postgres-data
+  namespace: db
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-postgres
+  namespace: apps
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setModuleOwners(desiredState{"postgres": objects}, []*unstructured.Unstructured{moduleResourceFixture("postgres", "db", nil)})
+
+	refs := objects[0].GetOwnerReferences()
+	if len(refs) != 1 || refs[0].Kind != moduleKind || refs[0].UID != "uid-db-postgres" || refs[0].Controller == nil || !*refs[0].Controller {
+		t.Errorf("Deployment owner references = %+v, want the resource as controller", refs)
+	}
+	if refs := objects[1].GetOwnerReferences(); len(refs) != 0 {
+		t.Errorf("PersistentVolumeClaim owner references = %+v, want none so data is kept", refs)
+	}
+	if refs := objects[2].GetOwnerReferences(); len(refs) != 0 {
+		t.Errorf("owner references in another namespace = %+v, want none", refs)
+	}
+}
+
+func TestExportModuleResources(t *testing.T) {
+	output, err := exportModuleResources(&config.Config{Modules: []config.Module{
+		{Name: "postgres", Namespace: "infra", Image: "postgres:16", Storage: "20Gi", Labels: map[string]string{"team": "data"}, Secrets: map[string]string{"password": "secret"}},
+		{Name: "redis", Namespace: "infra"},
+	}})
+	if err != nil {
+		t.Fatalf("exportModuleResources() error = %v", err)
+	}
+	objects, err := k8s.DecodeManifest([]byte(output))
+	if err != nil {
+		t.Fatalf("export is not a manifest: %v\n%s", err, output)
+	}
+	var kinds []string
+	for _, object := range objects {
+		kinds = append(kinds, object.GetKind()+"/"+object.GetName())
+	}
+	if got := strings.Join(kinds, ","); got != "Secret/postgres-settings,PersonalServerModule/postgres,PersonalServerModule/redis" {
+		t.Fatalf("objects = %s", got)
+	}
+	if ref, _, _ := unstructured.NestedString(objects[1].Object, "spec", "secretRef", "name"); ref != "postgres-settings" {
+		t.Errorf("secretRef = %q, want postgres-settings", ref)
+	}
+	if storage, _, _ := unstructured.NestedString(objects[1].Object, "spec", "storage"); storage != "20Gi" {
+		t.Errorf("storage = %q, want 20Gi", storage)
+	}
+	if team, _, _ := unstructured.NestedString(objects[1].Object, "spec", "labels", "team"); team != "data" {
+		t.Errorf("labels.team = %q, want data", team)
+	}
+	if password, _, _ := unstructured.NestedString(objects[0].Object, "stringData", "password"); password != "secret" {
+		t.Errorf("Secret password = %q, want secret", password)
+	}
+}
+
+func TestModuleOperatorRender(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewNopLogger()
+	registry := modules.NewRegistry(log)
+	registry.Register("web", func(config.GeneralConfig, config.Module, logger.Logger) modules.Module {
+		return generatingTestModule{basicHelpTestModule{name: "web"}, map[string]string{"objects.yaml": operatorTestManifests}}
+	})
+	web := moduleResourceFixture("web", "infra", map[string]interface{}{})
+	unknown := moduleResourceFixture("unknown", "infra", map[string]interface{}{})
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		moduleGVR: moduleKind + "List",
+	}, web.DeepCopy(), unknown.DeepCopy())
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	store.Add(web)
+	store.Add(unknown)
+
+	operator := &moduleOperator{app: &App{logger: log, registry: registry}, cfg: &config.Config{}, client: fake.NewSimpleClientset(), dyn: dyn, watchDyn: dyn, namespace: "ops"}
+	state, err := operator.render(ctx, store)
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if len(state["web"]) != 2 || len(state["web"][0].GetOwnerReferences()) != 1 {
+		t.Fatalf("state = %v, want the web objects owned by the resource", state)
+	}
+
+	for name, want := range map[string]string{"web": modulePhaseReady, "unknown": modulePhaseFailed} {
+		live, err := dyn.Resource(moduleGVR).Namespace("infra").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if phase, _, _ := unstructured.NestedString(live.Object, "status", "phase"); phase != want {
+			t.Errorf("%s phase = %q, want %q", name, phase, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Goalt/personal-server/internal/devtool"
+)
+
+// handleDevtoolCommand runs contributor tooling from the repository root
+func (a *App) handleDevtoolCommand(args []string) error {
+	usage := fmt.Sprintf("usage: %s devtool new-module <name>", Name)
+	if len(args) != 2 || args[0] != "new-module" {
+		return fmt.Errorf("%s", usage)
+	}
+
+	spec, err := devtool.NewModuleSpec(args[1])
+	if err != nil {
+		return err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	files, err := devtool.ScaffoldModule(root, spec)
+	if err != nil {
+		return err
+	}
+
+	a.logger.Success("Scaffolded module %s (package %s)\n", spec.Name, spec.Package)
+	for _, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			rel = file
+		}
+		if filepath.Base(file) == "registry_default.go" {
+			a.logger.Info("Updated %s\n", rel)
+		} else {
+			a.logger.Info("Created %s\n", rel)
+		}
+	}
+	a.logger.Info("\n💡 Next: set the image, port and data path marked TODO, then add the module to config.example.yaml and README.md\n")
+	return nil
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// handleDiffCommand prints how the objects the module generates differ from
+// the cluster, field by field
+func (a *App) handleDiffCommand(ctx context.Context, cfg *config.Config, name string, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: %s %s diff", Name, name)
+	}
+	objects, err := a.renderQuiet(ctx, cfg, name)
+	if err != nil {
+		return err
+	}
+	dyn, err := a.clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	mapper, err := a.clients.RESTMapper()
+	if err != nil {
+		return err
+	}
+	return a.printDiff(ctx, dyn, mapper, objects)
+}
+
+// printDiff prints, for every object that differs from the cluster, the
+// differing fields with their live value in red and the desired value in
+// green. Fields only the cluster sets, such as defaults, are not compared.
+func (a *App) printDiff(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured) error {
+	var style logger.Style
+	if styled, ok := a.logger.(logger.Styled); ok {
+		style = styled.Style()
+	}
+
+	missing, changed := 0, 0
+	for _, object := range objects {
+		diff, err := k8s.DiffObject(ctx, dyn, mapper, object)
+		if err != nil {
+			return err
+		}
+		header := fmt.Sprintf("%s/%s (%s)", object.GetKind(), object.GetName(), object.GetNamespace())
+		if object.GetNamespace() == "" {
+			header = fmt.Sprintf("%s/%s", object.GetKind(), object.GetName())
+		}
+		switch {
+		case diff.Missing:
+			missing++
+			a.logger.Print("%s\n", style.Paint(logger.Green, "+ "+header+": not in the cluster"))
+		case len(diff.Fields) > 0:
+			changed++
+			a.logger.Print("%s\n", style.Paint(logger.Cyan, "~ "+header))
+			for _, field := range diff.Fields {
+				secret := object.GetKind() == "Secret" && strings.HasPrefix(field.Path, "data.")
+				a.logger.Print("    %s\n", field.Path)
+				a.logger.Print("%s\n", style.Paint(logger.Red, "    - "+formatDiffValue(field.Live, secret)))
+				a.logger.Print("%s\n", style.Paint(logger.Green, "    + "+formatDiffValue(field.Desired, secret)))
+			}
+		}
+	}
+
+	if missing+changed == 0 {
+		a.logger.Success("No differences: %d objects match the cluster\n", len(objects))
+		return nil
+	}
+	a.logger.Info("\n%d of %d objects differ: %d missing, %d changed\n", missing+changed, len(objects), missing, changed)
+	return nil
+}
+
+// formatDiffValue renders a field value on one line. Secret values are
+// masked, since diff output ends up in terminals and CI logs.
+func formatDiffValue(value interface{}, secret bool) string {
+	if value == nil {
+		return "(unset)"
+	}
+	if secret {
+		return "(hidden)"
+	}
+	if s, ok := value.(string); ok {
+		return strings.ReplaceAll(s, "\n", `\n`)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestPrintDiff(t *testing.T) {
+	configMapGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secretGVR := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMapGVR: "ConfigMapList",
+		secretGVR:    "SecretList",
+	})
+
+	objects, err := k8s.DecodeManifest([]byte(operatorTestManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	app := &App{logger: logger.NewStdLogger(&buf)}
+	if err := app.printDiff(context.Background(), dyn, mapper, objects); err != nil {
+		t.Fatalf("printDiff() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "+ ConfigMap/web-config (infra): not in the cluster") || !strings.Contains(out, "2 missing, 0 changed") {
+		t.Errorf("output = %q, want both objects missing", out)
+	}
+
+	live := objects[0].DeepCopy()
+	live.Object["data"] = map[string]interface{}{"mode": "staging"}
+	if _, err := dyn.Resource(configMapGVR).Namespace("infra").Create(context.Background(), live, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	live = objects[1].DeepCopy()
+	live.Object["data"] = map[string]interface{}{"token": "b2xk"}
+	if _, err := dyn.Resource(secretGVR).Namespace("infra").Create(context.Background(), live, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	app.logger = logger.NewStyledLogger(&buf, logger.Style{Color: true, Emoji: true})
+	if err := app.printDiff(context.Background(), dyn, mapper, objects); err != nil {
+		t.Fatalf("printDiff() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"~ ConfigMap/web-config (infra)", "    data.mode\n", "\x1b[31m    - staging\x1b[0m", "\x1b[32m    + production\x1b[0m", "    - (hidden)", "0 missing, 2 changed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "c2VjcmV0") || strings.Contains(out, "b2xk") {
+		t.Errorf("output shows secret values:\n%s", out)
+	}
+}
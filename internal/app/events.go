@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/modules"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxWorkloadEvents caps the events listed for a workload
+const maxWorkloadEvents = 10
+
+// workloadEvents returns the Warning events of a Deployment, its ReplicaSets,
+// its pods and the PVCs it mounts, newest first and formatted relative to now
+func workloadEvents(ctx context.Context, client k8s.KubernetesClient, namespace, deployment, appLabel string, now time.Time) ([]string, error) {
+	pods := make(map[string]bool)
+	list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "app=" + appLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range list.Items {
+		pods[pod.Name] = true
+	}
+	claims := make(map[string]bool)
+	if live, err := client.AppsV1().Deployments(namespace).Get(ctx, deployment, metav1.GetOptions{}); err == nil {
+		for _, volume := range live.Spec.Template.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claims[volume.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+
+	// Pods that were already replaced are matched by the name prefix of
+	// their ReplicaSet
+	prefix := deployment + "-"
+	events, err := k8s.WarningEvents(ctx, client, namespace, func(ref corev1.ObjectReference) bool {
+		switch ref.Kind {
+		case "Pod":
+			return pods[ref.Name] || strings.HasPrefix(ref.Name, prefix)
+		case "ReplicaSet":
+			return strings.HasPrefix(ref.Name, prefix)
+		case "Deployment":
+			return ref.Name == deployment
+		case "PersistentVolumeClaim":
+			return claims[ref.Name]
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > maxWorkloadEvents {
+		events = events[:maxWorkloadEvents]
+	}
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, k8s.FormatEvent(event, now))
+	}
+	return lines, nil
+}
+
+// handleModuleStatus prints the module's own status followed by the recent
+// Warning events of its workload, the first thing to check when it is not
+// ready
+func (a *App) handleModuleStatus(ctx context.Context, cfg *config.Config, name string, module modules.Module) error {
+	if err := module.Status(ctx); err != nil {
+		return err
+	}
+	namespace, ok := componentNamespace(cfg, name)
+	deployment := modules.DeploymentName(module)
+	if !ok || deployment == "" {
+		return nil
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		a.logger.Warn("Failed to list warning events: %v\n", err)
+		return nil
+	}
+	a.printWorkloadEvents(ctx, client, namespace, deployment, modules.AppLabel(module))
+	return nil
+}
+
+// printWorkloadEvents prints the recent Warning events of a workload.
+// Events are only a hint, so failing to list them is not an error.
+func (a *App) printWorkloadEvents(ctx context.Context, client k8s.KubernetesClient, namespace, deployment, appLabel string) {
+	events, err := workloadEvents(ctx, client, namespace, deployment, appLabel, time.Now())
+	if err != nil {
+		a.logger.Warn("Failed to list warning events: %v\n", err)
+		return
+	}
+	if len(events) == 0 {
+		a.logger.Info("\nNo recent warning events\n")
+		return
+	}
+	a.logger.Warn("\nRecent warning events:\n")
+	for _, event := range events {
+		a.logger.Info("  %s\n", event)
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPrintWorkloadEvents(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gitea-0", Namespace: "infra", Labels: map[string]string{"app": "gitea"}}},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "a", Namespace: "infra"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "gitea-0"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Unhealthy",
+			Message:        "Readiness probe failed",
+			LastTimestamp:  metav1.NewTime(time.Now()),
+		},
+	)
+	var out strings.Builder
+	app := &App{logger: logger.NewStdLogger(&out)}
+	app.printWorkloadEvents(context.Background(), client, "infra", "gitea", "gitea")
+	if !strings.Contains(out.String(), "Pod/gitea-0  Unhealthy: Readiness probe failed") {
+		t.Errorf("output = %q, want the pod's event", out.String())
+	}
+
+	out.Reset()
+	app.printWorkloadEvents(context.Background(), client, "infra", "postgres", "postgres")
+	if !strings.Contains(out.String(), "No recent warning events") {
+		t.Errorf("output = %q, want no events", out.String())
+	}
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// health is the result of a healthcheck; its value is the exit code, as
+// Nagios plugins and monitors such as Uptime Kuma expect
+type health int
+
+const (
+	healthOK health = iota
+	healthWarning
+	healthCritical
+)
+
+func (h health) String() string {
+	switch h {
+	case healthOK:
+		return "OK"
+	case healthWarning:
+		return "WARNING"
+	default:
+		return "CRITICAL"
+	}
+}
+
+// componentHealth is the health of one component's workload
+type componentHealth struct {
+	name    string
+	health  health
+	message string
+}
+
+// handleHealthcheckCommand checks the workload of every configured module and
+// pet project and exits with the worst result
+func (a *App) handleHealthcheckCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: %s healthcheck", Name)
+	}
+	components, err := a.components(cfg, func(string) logger.Logger { return logger.NewNopLogger() })
+	if err != nil {
+		return err
+	}
+	var workloads []component
+	for _, c := range components {
+		if c.kind != kindIngress && modules.DeploymentName(c.module) != "" {
+			workloads = append(workloads, c)
+		}
+	}
+	return a.healthcheck(ctx, workloads)
+}
+
+// handleModuleHealthcheck checks the workload of a single module
+func (a *App) handleModuleHealthcheck(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: %s %s healthcheck", Name, name)
+	}
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	if modules.DeploymentName(module) == "" {
+		return fmt.Errorf("%s has no workload to check", name)
+	}
+	return a.healthcheck(ctx, []component{{name: name, namespace: namespace, kind: kindModule, module: module}})
+}
+
+// healthcheck prints the health of workloads as one summary line followed by
+// a line per component, and returns an ExitError unless all are OK
+func (a *App) healthcheck(ctx context.Context, workloads []component) error {
+	if len(workloads) == 0 {
+		fmt.Fprintln(a.stdout, "OK: no workloads configured")
+		return nil
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		fmt.Fprintf(a.stdout, "CRITICAL: failed to create Kubernetes client: %v\n", err)
+		return &ExitError{Code: int(healthCritical)}
+	}
+	results := componentHealths(workloads, collectSnapshots(ctx, client, workloads))
+	return a.printHealth(results)
+}
+
+func (a *App) printHealth(results []componentHealth) error {
+	worst := healthOK
+	counts := map[health]int{}
+	for _, r := range results {
+		counts[r.health]++
+		if r.health > worst {
+			worst = r.health
+		}
+	}
+
+	if len(results) == 1 {
+		fmt.Fprintf(a.stdout, "%s: %s %s\n", worst, results[0].name, results[0].message)
+	} else {
+		fmt.Fprintf(a.stdout, "%s: %d critical, %d warning, %d ok\n", worst, counts[healthCritical], counts[healthWarning], counts[healthOK])
+		for _, r := range results {
+			fmt.Fprintf(a.stdout, "%s %s: %s\n", r.health, r.name, r.message)
+		}
+	}
+	if worst == healthOK {
+		return nil
+	}
+	return &ExitError{Code: int(worst)}
+}
+
+func componentHealths(workloads []component, snapshots map[string]*namespaceSnapshot) []componentHealth {
+	results := make([]componentHealth, 0, len(workloads))
+	for _, c := range workloads {
+		result := componentHealth{name: c.name}
+		snapshot := snapshots[c.namespace]
+		if snapshot == nil || snapshot.err != nil {
+			result.health, result.message = healthCritical, "cluster unreachable"
+			if snapshot != nil {
+				result.message = fmt.Sprintf("cluster unreachable: %v", snapshot.err)
+			}
+		} else {
+			result.health, result.message = workloadHealth(snapshot, modules.DeploymentName(c.module), modules.AppLabel(c.module))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// workloadHealth is CRITICAL when no replica is ready, WARNING when some are
+// not, the workload is suspended, or a pod is waiting or fails its readiness
+// probe, and OK otherwise
+func workloadHealth(snapshot *namespaceSnapshot, deploymentName, appLabel string) (health, string) {
+	deployment, ok := snapshot.deployments[deploymentName]
+	if !ok {
+		return healthCritical, "not deployed"
+	}
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	if desired == 0 {
+		return healthWarning, "suspended (0 replicas)"
+	}
+	ready := deployment.Status.ReadyReplicas
+	message := fmt.Sprintf("%d/%d ready", ready, desired)
+	problem := podProblem(snapshot.pods[appLabel])
+	if problem != "" {
+		message += ", " + problem
+	}
+	switch {
+	case ready == 0:
+		return healthCritical, message
+	case ready < desired || problem != "":
+		return healthWarning, message
+	}
+	return healthOK, message
+}
+
+// podProblem describes the first container that is waiting, e.g. in
+// CrashLoopBackOff, or running without passing its readiness probe
+func podProblem(pods []corev1.Pod) string {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			switch {
+			case cs.State.Waiting != nil && cs.State.Waiting.Reason != "":
+				return fmt.Sprintf("%s in %s", cs.State.Waiting.Reason, pod.Name)
+			case cs.State.Running != nil && !cs.Ready:
+				return fmt.Sprintf("%s not ready in %s", cs.Name, pod.Name)
+			}
+		}
+	}
+	return ""
+}
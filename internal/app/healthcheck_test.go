@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWorkloadHealth(t *testing.T) {
+	deployment := func(replicas, ready int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: ready},
+		}
+	}
+	pod := func(status corev1.ContainerStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "gitea-0"},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+		}
+	}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	crashing := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		pods       []corev1.Pod
+		want       health
+		message    string
+	}{
+		{name: "ready", deployment: deployment(1, 1), pods: []corev1.Pod{pod(corev1.ContainerStatus{Name: "gitea", Ready: true, State: running})}, want: healthOK, message: "1/1 ready"},
+		{name: "not deployed", want: healthCritical, message: "not deployed"},
+		{name: "nothing ready", deployment: deployment(1, 0), pods: []corev1.Pod{pod(corev1.ContainerStatus{Name: "gitea", State: crashing})}, want: healthCritical, message: "0/1 ready, CrashLoopBackOff in gitea-0"},
+		{name: "partly ready", deployment: deployment(2, 1), want: healthWarning, message: "1/2 ready"},
+		{name: "readiness probe failing", deployment: deployment(2, 2), pods: []corev1.Pod{pod(corev1.ContainerStatus{Name: "exporter", State: running})}, want: healthWarning, message: "2/2 ready, exporter not ready in gitea-0"},
+		{name: "suspended", deployment: deployment(0, 0), want: healthWarning, message: "suspended (0 replicas)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := &namespaceSnapshot{deployments: map[string]appsv1.Deployment{}, pods: map[string][]corev1.Pod{"gitea": tt.pods}}
+			if tt.deployment != nil {
+				snapshot.deployments["gitea"] = *tt.deployment
+			}
+			got, message := workloadHealth(snapshot, "gitea", "gitea")
+			if got != tt.want || message != tt.message {
+				t.Errorf("workloadHealth() = %s %q, want %s %q", got, message, tt.want, tt.message)
+			}
+		})
+	}
+}
+
+func TestPrintHealth(t *testing.T) {
+	var out strings.Builder
+	app := &App{stdout: &out}
+	err := app.printHealth([]componentHealth{
+		{name: "postgres", health: healthOK, message: "1/1 ready"},
+		{name: "gitea", health: healthWarning, message: "1/2 ready"},
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 || err.Error() != "" {
+		t.Fatalf("printHealth() error = %v, want a silent exit code 1", err)
+	}
+	want := "WARNING: 0 critical, 1 warning, 1 ok\nOK postgres: 1/1 ready\nWARNING gitea: 1/2 ready\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := app.printHealth([]componentHealth{{name: "gitea", health: healthOK, message: "1/1 ready"}}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "OK: gitea 1/1 ready\n" {
+		t.Errorf("output = %q, want a single line", out.String())
+	}
+}
+
+// TestHandleHealthcheckCommand_SkipsCronJobs checks that modules running only
+// CronJobs are not reported as undeployed workloads
+func TestHandleHealthcheckCommand_SkipsCronJobs(t *testing.T) {
+	replicas := int32(1)
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "infra"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis-0", Namespace: "infra", Labels: map[string]string{"app": "redis"}},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "redis", Ready: true}}},
+		},
+	)
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "redis", Namespace: "infra"},
+		{Name: "cronjob-cleanup", Namespace: "infra", Secrets: map[string]string{"schedule": "0 3 * * *"}},
+		{Name: "ddns", Namespace: "infra", Secrets: map[string]string{"mode": "cronjob"}},
+	}}
+
+	var out strings.Builder
+	log := logger.NewNopLogger()
+	app := &App{logger: log, stdout: &out, registry: modules.DefaultRegistry(log), clients: k8s.NewClientsFor(client, nil)}
+	if err := app.handleHealthcheckCommand(context.Background(), cfg, nil); err != nil {
+		t.Fatalf("handleHealthcheckCommand() error = %v, output:\n%s", err, out.String())
+	}
+	if out.String() != "OK: redis 1/1 ready\n" {
+		t.Errorf("output = %q, want only the redis Deployment checked", out.String())
+	}
+}
@@ -43,10 +43,10 @@ func TestPrintUsage_ListsRegisteredModulesAndSupportedSubcommands(t *testing.T)
 	app.printUsage()
 
 	output := logBuf.String()
-	if !strings.Contains(output, "  advanced <generate|apply|clean|status|doc|backup|restore|test>") {
+	if !strings.Contains(output, "  advanced <generate|apply|diff|clean|status|doc|history|logs|shell|restart|scale|upgrade|healthcheck|run-job|backup|restore|test>") {
 		t.Fatalf("expected advanced module line in help output, got:\n%s", output)
 	}
-	if !strings.Contains(output, "  basic <generate|apply|clean|status|doc>") {
+	if !strings.Contains(output, "  basic <generate|apply|diff|clean|status|doc|history|logs|shell|restart|scale|upgrade|healthcheck|run-job>") {
 		t.Fatalf("expected basic module line in help output, got:\n%s", output)
 	}
 	if strings.Index(output, "  advanced <") > strings.Index(output, "  basic <") {
@@ -79,7 +79,7 @@ func TestRunHelp_DoesNotLoadConfig(t *testing.T) {
 	if configLoaderCalled {
 		t.Fatal("expected help command to avoid loading config")
 	}
-	if !strings.Contains(logBuf.String(), "  basic <generate|apply|clean|status|doc>") {
+	if !strings.Contains(logBuf.String(), "  basic <generate|apply|diff|clean|status|doc|history|logs|shell|restart|scale|upgrade|healthcheck|run-job>") {
 		t.Fatalf("expected help output to include module subcommands, got:\n%s", logBuf.String())
 	}
 }
@@ -95,7 +95,7 @@ func TestHandleModuleCommand_UsesModuleSupportedSubcommandsInErrors(t *testing.T
 	if !strings.Contains(err.Error(), "usage: advanced <subcommand>") {
 		t.Fatalf("expected usage prefix with module name, got: %v", err)
 	}
-	if !strings.Contains(err.Error(), "Available subcommands: generate, apply, clean, status, doc, backup, restore, test") {
+	if !strings.Contains(err.Error(), "Available subcommands: generate, apply, diff, clean, status, doc, history, logs, shell, restart, scale, upgrade, healthcheck, run-job, backup, restore, test") {
 		t.Fatalf("expected supported subcommands in error, got: %v", err)
 	}
 
@@ -103,7 +103,7 @@ func TestHandleModuleCommand_UsesModuleSupportedSubcommandsInErrors(t *testing.T
 	if err == nil {
 		t.Fatal("expected error for unknown subcommand")
 	}
-	if !strings.Contains(err.Error(), "Available subcommands: generate, apply, clean, status, doc, backup, restore, test") {
+	if !strings.Contains(err.Error(), "Available subcommands: generate, apply, diff, clean, status, doc, history, logs, shell, restart, scale, upgrade, healthcheck, run-job, backup, restore, test") {
 		t.Fatalf("expected supported subcommands in error, got: %v", err)
 	}
 }
@@ -0,0 +1,346 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// historyAnnotation holds a component's change history on its Deployment
+	// as a JSON list, oldest first
+	historyAnnotation = "personal-server.io/history"
+	// maxHistoryEntries keeps the annotation well below the size limit
+	maxHistoryEntries = 50
+
+	historyActionApply   = "apply"
+	historyActionDeploy  = "deploy"
+	historyActionRestart = "restart"
+	historyActionUpgrade = "upgrade"
+)
+
+// historyEntry records one apply, deploy, restart or upgrade of a component
+type historyEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Version is the version of this tool that made the change
+	Version string `json:"version"`
+	// ConfigHash identifies the component's config entry, so entries with
+	// different hashes mark config changes
+	ConfigHash string   `json:"configHash,omitempty"`
+	Images     []string `json:"images,omitempty"`
+	// By is who made the change, as user@host, or the operator identity
+	By string `json:"by"`
+}
+
+// handleModuleApply applies module and records the apply in its history.
+// With --server-side the generated objects are applied server-side instead
+// of through the module's Apply, updating objects that exist. --dry-run does
+// the same as a server-side dry run and records nothing. --wait waits for
+// the module's Deployment to become ready.
+func (a *App) handleModuleApply(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	applyCmd := flag.NewFlagSet("apply", flag.ContinueOnError)
+	applyCmd.SetOutput(io.Discard)
+	serverSide := applyCmd.Bool("server-side", false, "Create or update the generated objects with server-side apply")
+	dryRun := applyCmd.Bool("dry-run", false, "Report what a server-side apply would change without changing anything")
+	wait := applyCmd.Bool("wait", false, "Wait for the module's Deployment to become ready")
+	timeout := applyCmd.Duration("timeout", defaultReadyTimeout, "How long --wait waits for the Deployment")
+	usage := fmt.Sprintf("usage: %s %s apply [--server-side] [--dry-run] [--wait] [--timeout 5m]", Name, name)
+	if err := applyCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if applyCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+
+	apply := module.Apply
+	if *serverSide || *dryRun {
+		apply = func(ctx context.Context) error {
+			objects, err := a.renderQuiet(ctx, cfg, name)
+			if err != nil {
+				return err
+			}
+			applier, err := newServerSideApplier(a.clients, *dryRun)
+			if err != nil {
+				return err
+			}
+			if *dryRun {
+				a.logger.Info("Applying %s server-side as %s (dry run)...\n", name, Name)
+			} else {
+				a.logger.Info("Applying %s server-side as %s...\n", name, Name)
+			}
+			return applier.apply(ctx, objects, a.logger)
+		}
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	deployment := modules.DeploymentName(module)
+	if !ok || *dryRun {
+		return apply(ctx)
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if err := ensureNamespace(ctx, client, cfg, namespace, a.logger); err != nil {
+		return err
+	}
+	if deployment == "" {
+		return apply(ctx)
+	}
+
+	prior, err := readHistory(ctx, client, namespace, deployment)
+	if err != nil {
+		a.logger.Warn("Failed to read the history of %s: %v\n", name, err)
+	}
+	if err := apply(ctx); err != nil {
+		return err
+	}
+	entry := historyEntry{Action: historyActionApply, ConfigHash: componentConfigHash(cfg, name), By: historyUser()}
+	if err := recordHistory(ctx, client, namespace, deployment, prior, entry); err != nil {
+		a.logger.Warn("Failed to record the apply in the history of %s: %v\n", name, err)
+	}
+	if !*wait {
+		return nil
+	}
+	a.logger.Progress("Waiting for deployment %s to become ready...\n", deployment)
+	if err := waitForDeployment(ctx, client, namespace, deployment, modules.AppLabel(module), *timeout); err != nil {
+		return err
+	}
+	a.logger.Success("Deployment %s is ready\n", deployment)
+	return nil
+}
+
+func (a *App) handleHistoryCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	historyCmd := flag.NewFlagSet("history", flag.ContinueOnError)
+	historyCmd.SetOutput(io.Discard)
+	since := historyCmd.String("since", "", "Only show changes since a date (2006-01-02) or for a duration (e.g. 7d, 48h)")
+	usage := fmt.Sprintf("usage: %s %s history [--since 7d]", Name, name)
+	if err := historyCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if historyCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	var after time.Time
+	if *since != "" {
+		parsed, err := parseHistorySince(*since, time.Now())
+		if err != nil {
+			return err
+		}
+		after = parsed
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	deployment := modules.DeploymentName(module)
+	if deployment == "" {
+		return fmt.Errorf("%s has no workload to record history on", name)
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	entries, err := readHistory(ctx, client, namespace, deployment)
+	if err != nil {
+		return err
+	}
+
+	var shown []historyEntry
+	for _, entry := range entries {
+		if !entry.Time.Before(after) {
+			shown = append(shown, entry)
+		}
+	}
+	if len(shown) == 0 {
+		a.logger.Info("No recorded changes of %s\n", name)
+		return nil
+	}
+	printHistory(a.logger, shown)
+	return nil
+}
+
+// printHistory prints entries as a table, marking config changes
+func printHistory(log logger.Logger, entries []historyEntry) {
+	log.Info("%-20s %-8s %-10s %-14s %-24s %s\n", "TIME", "ACTION", "VERSION", "CONFIG", "BY", "IMAGES")
+	previous := ""
+	for _, entry := range entries {
+		hash := entry.ConfigHash
+		if hash == "" {
+			hash = "-"
+		} else if previous != "" && hash != previous {
+			hash += " *"
+		}
+		if entry.ConfigHash != "" {
+			previous = entry.ConfigHash
+		}
+		log.Info("%-20s %-8s %-10s %-14s %-24s %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Action, entry.Version, hash, entry.By, strings.Join(entry.Images, ", "))
+	}
+}
+
+// parseHistorySince accepts a date or a period such as 7d or 48h
+func parseHistorySince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	period, err := parsePeriod(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: use a date like 2006-01-02 or a period like 7d: %w", value, err)
+	}
+	return now.Add(-period), nil
+}
+
+// readHistory returns the history recorded on a Deployment, or nothing when
+// the Deployment does not exist
+func readHistory(ctx context.Context, client k8s.KubernetesClient, namespace, deployment string) ([]historyEntry, error) {
+	live, err := client.AppsV1().Deployments(namespace).Get(ctx, deployment, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Deployment %s: %w", deployment, err)
+	}
+	return parseHistory(live.Annotations[historyAnnotation])
+}
+
+func parseHistory(annotation string) ([]historyEntry, error) {
+	if annotation == "" {
+		return nil, nil
+	}
+	var entries []historyEntry
+	if err := json.Unmarshal([]byte(annotation), &entries); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", historyAnnotation, err)
+	}
+	return entries, nil
+}
+
+// recordHistory appends entry to the history of a Deployment, filling in the
+// time, tool version and the images it runs. A module's apply may replace the
+// Deployment with one without the annotation; prior, read before the apply,
+// is kept then. Nothing is recorded when the Deployment does not exist.
+func recordHistory(ctx context.Context, client k8s.KubernetesClient, namespace, deployment string, prior []historyEntry, entry historyEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	entry.Version = Version
+
+	deployments := client.AppsV1().Deployments(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		live, err := deployments.Get(ctx, deployment, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get Deployment %s: %w", deployment, err)
+		}
+		entries, err := parseHistory(live.Annotations[historyAnnotation])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			entries = prior
+		}
+
+		recorded := entry
+		if len(recorded.Images) == 0 {
+			for _, container := range live.Spec.Template.Spec.Containers {
+				recorded.Images = append(recorded.Images, container.Image)
+			}
+		}
+		entries = append(entries, recorded)
+		if len(entries) > maxHistoryEntries {
+			entries = entries[len(entries)-maxHistoryEntries:]
+		}
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+		if live.Annotations == nil {
+			live.Annotations = map[string]string{}
+		}
+		live.Annotations[historyAnnotation] = string(data)
+		_, err = deployments.Update(ctx, live, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// recordDeploy records a webhook deploy in the history of the Deployments
+// among a component's desired objects. The images are taken from the desired
+// objects, as the live ones may not have been patched yet.
+func recordDeploy(ctx context.Context, client k8s.KubernetesClient, objects []*unstructured.Unstructured, by string) error {
+	for _, object := range objects {
+		if object.GetKind() != "Deployment" {
+			continue
+		}
+		entry := historyEntry{Action: historyActionDeploy, By: by}
+		for _, container := range podContainers(object) {
+			if image, _ := container["image"].(string); image != "" {
+				entry.Images = append(entry.Images, image)
+			}
+		}
+		if err := recordHistory(ctx, client, object.GetNamespace(), object.GetName(), nil, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// componentConfigHash returns a short hash of the config entry of the
+// component named name, or "" when it is not in the config
+func componentConfigHash(cfg *config.Config, name string) string {
+	if cfg == nil {
+		return ""
+	}
+	var entry interface{}
+	for _, m := range cfg.Modules {
+		if m.Name == name {
+			entry = m
+		}
+	}
+	for _, p := range cfg.PetProjects {
+		if p.Name == name {
+			entry = p
+		}
+	}
+	if entry == nil {
+		return ""
+	}
+	// json sorts map keys, so equal entries hash alike
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// historyUser returns user@host of whoever runs the command
+func historyUser() string {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return name + "@" + hostname
+	}
+	return name
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func historyTestDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "gitea", Image: "gitea/gitea:1.21"}},
+		}}},
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(historyTestDeployment())
+
+	first := historyEntry{Action: historyActionApply, ConfigHash: "aaa", By: "me@laptop"}
+	if err := recordHistory(ctx, client, "infra", "gitea", nil, first); err != nil {
+		t.Fatalf("recordHistory() error = %v", err)
+	}
+	entries, err := readHistory(ctx, client, "infra", "gitea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Version != Version || strings.Join(entries[0].Images, ",") != "gitea/gitea:1.21" || entries[0].Time.IsZero() {
+		t.Fatalf("entries = %+v, want one entry with version, images and time", entries)
+	}
+
+	// an apply that replaces the Deployment drops the annotation; the history
+	// read before it is kept
+	prior := entries
+	if _, err := client.AppsV1().Deployments("infra").Update(ctx, historyTestDeployment(), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	second := historyEntry{Action: historyActionDeploy, Images: []string{"gitea/gitea:1.22"}, By: "operator"}
+	if err := recordHistory(ctx, client, "infra", "gitea", prior, second); err != nil {
+		t.Fatalf("recordHistory() error = %v", err)
+	}
+	entries, _ = readHistory(ctx, client, "infra", "gitea")
+	if len(entries) != 2 || entries[0].ConfigHash != "aaa" || strings.Join(entries[1].Images, ",") != "gitea/gitea:1.22" {
+		t.Fatalf("entries = %+v, want the prior entry and the deploy", entries)
+	}
+
+	for i := 0; i < maxHistoryEntries; i++ {
+		if err := recordHistory(ctx, client, "infra", "gitea", nil, first); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ = readHistory(ctx, client, "infra", "gitea")
+	if len(entries) != maxHistoryEntries || entries[0].Action != historyActionApply {
+		t.Errorf("got %d entries starting with %s, want the last %d", len(entries), entries[0].Action, maxHistoryEntries)
+	}
+
+	if err := recordHistory(ctx, client, "infra", "missing", nil, first); err != nil {
+		t.Errorf("recordHistory() of a missing Deployment error = %v", err)
+	}
+}
+
+func TestRecordDeploy(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(historyTestDeployment())
+	objects, err := k8s.DecodeManifest([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: gitea
+  namespace: infra
+spec:
+  template:
+    spec:
+      containers:
+        - name: gitea
+          image: gitea/gitea:1.22
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: gitea
+  namespace: infra
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recordDeploy(ctx, client, objects, "replica-1"); err != nil {
+		t.Fatalf("recordDeploy() error = %v", err)
+	}
+	entries, _ := readHistory(ctx, client, "infra", "gitea")
+	if len(entries) != 1 || entries[0].Action != historyActionDeploy || entries[0].By != "replica-1" || strings.Join(entries[0].Images, ",") != "gitea/gitea:1.22" {
+		t.Errorf("entries = %+v, want the deploy with the desired image", entries)
+	}
+}
+
+func TestComponentConfigHash(t *testing.T) {
+	cfg := &config.Config{
+		Modules:     []config.Module{{Name: "gitea", Namespace: "infra", Image: "gitea/gitea:1.21"}},
+		PetProjects: []config.PetProject{{Name: "bot", Namespace: "hobby", Image: "bot:1"}},
+	}
+	hash := componentConfigHash(cfg, "gitea")
+	if len(hash) != 12 || hash != componentConfigHash(cfg, "gitea") {
+		t.Fatalf("componentConfigHash() = %q, want a stable 12 character hash", hash)
+	}
+	cfg.Modules[0].Image = "gitea/gitea:1.22"
+	if componentConfigHash(cfg, "gitea") == hash {
+		t.Error("hash did not change with the config entry")
+	}
+	if componentConfigHash(cfg, "bot") == "" || componentConfigHash(cfg, "missing") != "" {
+		t.Error("want a hash for pet projects and none for unknown components")
+	}
+}
+
+func TestParseHistorySince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	got, err := parseHistorySince("7d", now)
+	if err != nil || !got.Equal(now.AddDate(0, 0, -7)) {
+		t.Errorf("parseHistorySince(7d) = %v, %v", got, err)
+	}
+	got, err = parseHistorySince("2024-05-07", now)
+	if err != nil || got.Day() != 7 || got.Hour() != 0 {
+		t.Errorf("parseHistorySince(2024-05-07) = %v, %v", got, err)
+	}
+	if _, err := parseHistorySince("last tuesday", now); err == nil {
+		t.Error("parseHistorySince() accepted an invalid value")
+	}
+}
+
+func TestPrintHistory(t *testing.T) {
+	var buf bytes.Buffer
+	at := time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC)
+	printHistory(logger.NewStdLogger(&buf), []historyEntry{
+		{Time: at, Action: historyActionApply, Version: "v1.2.0", ConfigHash: "aaa", By: "me@laptop", Images: []string{"gitea/gitea:1.21"}},
+		{Time: at.Add(time.Hour), Action: historyActionDeploy, Version: "v1.2.0", By: "operator", Images: []string{"gitea/gitea:1.22"}},
+		{Time: at.Add(2 * time.Hour), Action: historyActionApply, Version: "v1.3.0", ConfigHash: "bbb", By: "me@laptop"},
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want a header and 3 entries:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[1], "aaa ") || strings.Contains(lines[1], "*") {
+		t.Errorf("first entry = %q, want its hash unmarked", lines[1])
+	}
+	if !strings.Contains(lines[2], "gitea/gitea:1.22") {
+		t.Errorf("deploy entry = %q, want its image", lines[2])
+	}
+	if !strings.Contains(lines[3], "bbb *") {
+		t.Errorf("last entry = %q, want the config change marked", lines[3])
+	}
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+const (
+	defaultIssueThreshold         = 3
+	defaultOperatorIssueThreshold = 20
+	// maxIssueFailures is how many of the latest failures an issue lists
+	maxIssueFailures = 5
+	// maxIssueLog bounds the log kept for each failure to its last bytes
+	maxIssueLog = 8 << 10
+)
+
+// backupFailuresFile keeps the failure streak of backup runs between the
+// separate processes cron starts
+var backupFailuresFile = filepath.Join("backups", ".failures.json")
+
+// issueReporter opens an issue in a Gitea repository once a task has failed
+// threshold times in a row
+type issueReporter struct {
+	url       string
+	owner     string
+	repo      string
+	token     string
+	threshold int
+	client    *http.Client
+}
+
+// newIssueReporter returns nil when no issue repository is configured
+func newIssueReporter(cfg config.IssuesConfig, threshold int) (*issueReporter, error) {
+	if cfg.URL == "" && cfg.Repository == "" {
+		return nil, nil
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("issues.url is required when issues.repository is set")
+	}
+	owner, repo, ok := strings.Cut(cfg.Repository, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("issues.repository must be owner/name, got %q", cfg.Repository)
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("issues.token is required to open issues in %s", cfg.Repository)
+	}
+	return &issueReporter{
+		url:       strings.TrimSuffix(cfg.URL, "/"),
+		owner:     owner,
+		repo:      repo,
+		token:     cfg.Token,
+		threshold: threshold,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// open creates an issue and returns its web URL
+func (r *issueReporter) open(ctx context.Context, title, body string) (string, error) {
+	payload, err := json.Marshal(map[string]string{"title": title, "body": body})
+	if err != nil {
+		return "", err
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/repos/%s/%s/issues", r.url, url.PathEscape(r.owner), url.PathEscape(r.repo))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "token "+r.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to open issue: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to open issue in %s/%s: %s: %s", r.owner, r.repo, resp.Status, strings.TrimSpace(string(data)))
+	}
+	var issue struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(data, &issue); err != nil {
+		return "", fmt.Errorf("invalid issue response: %w", err)
+	}
+	return issue.HTMLURL, nil
+}
+
+// failureRecord is one failed run of a task
+type failureRecord struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+	Log   string    `json:"log,omitempty"`
+}
+
+// failureStreak counts the consecutive failures of a task
+type failureStreak struct {
+	Count int `json:"count"`
+	// Failures holds the latest failures, oldest first
+	Failures []failureRecord `json:"failures"`
+	// Issue is the URL of the issue opened for the streak, so one streak
+	// opens one issue
+	Issue string `json:"issue,omitempty"`
+}
+
+func (s *failureStreak) add(failure failureRecord) {
+	if failure.Time.IsZero() {
+		failure.Time = time.Now().UTC()
+	}
+	if len(failure.Log) > maxIssueLog {
+		failure.Log = "...\n" + failure.Log[len(failure.Log)-maxIssueLog:]
+	}
+	s.Count++
+	s.Failures = append(s.Failures, failure)
+	if len(s.Failures) > maxIssueFailures {
+		s.Failures = s.Failures[len(s.Failures)-maxIssueFailures:]
+	}
+}
+
+// report opens an issue for streak once it reaches the threshold. An issue
+// that could not be opened is tried again on the next failure.
+func (r *issueReporter) report(ctx context.Context, streak *failureStreak, title string) (string, error) {
+	if streak.Count < r.threshold || streak.Issue != "" {
+		return "", nil
+	}
+	issue, err := r.open(ctx, title, issueBody(title, streak))
+	if err != nil {
+		return "", err
+	}
+	streak.Issue = issue
+	return issue, nil
+}
+
+// issueBody describes a failure streak in markdown, latest failure first
+func issueBody(title string, streak *failureStreak) string {
+	var b strings.Builder
+	host, _ := os.Hostname()
+	fmt.Fprintf(&b, "%s: %d consecutive failures", title, streak.Count)
+	if host != "" {
+		fmt.Fprintf(&b, " on `%s`", host)
+	}
+	fmt.Fprintf(&b, " (%s %s).\n", Name, Version)
+	b.WriteString("\nThe streak ends with the next success; no further issue is opened for it.\n")
+	for i := len(streak.Failures) - 1; i >= 0; i-- {
+		failure := streak.Failures[i]
+		fmt.Fprintf(&b, "\n### %s\n\n```\n%s\n```\n", failure.Time.Format(time.RFC3339), strings.TrimSpace(failure.Error))
+		if failure.Log != "" {
+			fmt.Fprintf(&b, "\n<details><summary>Log</summary>\n\n```\n%s\n```\n\n</details>\n", strings.TrimRight(failure.Log, "\n"))
+		}
+	}
+	return b.String()
+}
+
+// trackFailures updates the failure streak stored at path with the outcome
+// of a run: a success clears it, and a failure extends it and opens an issue
+// once it reaches the threshold. It returns the URL of an issue it opened.
+func (r *issueReporter) trackFailures(ctx context.Context, path, title string, runErr error, log string) (string, error) {
+	if runErr == nil {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		return "", nil
+	}
+
+	var streak failureStreak
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &streak); err != nil {
+			return "", fmt.Errorf("invalid failure state %s: %w", path, err)
+		}
+	}
+	streak.add(failureRecord{Error: runErr.Error(), Log: log})
+	issue, reportErr := r.report(ctx, &streak, title)
+
+	data, err = json.MarshalIndent(streak, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return issue, reportErr
+}
+
+// handleBackupCommand runs the global backup. With an issue repository
+// configured, the run's log is captured and consecutive failures are counted
+// across runs, so a scheduled backup that keeps failing opens an issue.
+func (a *App) handleBackupCommand(ctx context.Context, cfg *config.Config) error {
+	threshold := cfg.Issues.Threshold
+	if threshold <= 0 {
+		threshold = defaultIssueThreshold
+	}
+	reporter, err := newIssueReporter(cfg.Issues, threshold)
+	if err != nil {
+		// a misconfigured issue repository must not stop the backup itself
+		a.logger.Warn("Failure issues disabled: %v\n", err)
+	}
+	if reporter == nil {
+		return a.handleGlobalBackupCommand(ctx, cfg)
+	}
+
+	var captured bytes.Buffer
+	run := *a
+	run.logger = teeLogger{a.logger, logger.NewStyledLogger(&captured, logger.Style{})}
+	run.registry = a.registry.WithLogger(run.logger)
+	backupErr := run.handleGlobalBackupCommand(ctx, cfg)
+
+	issue, err := reporter.trackFailures(ctx, backupFailuresFile, "Backup failed", backupErr, captured.String())
+	if err != nil {
+		a.logger.Warn("Failed to track the backup failure: %v\n", err)
+	}
+	if issue != "" {
+		a.logger.Info("Opened issue %s\n", issue)
+	}
+	return backupErr
+}
+
+// teeLogger writes every message to two loggers
+type teeLogger [2]logger.Logger
+
+func (t teeLogger) Info(format string, args ...interface{}) {
+	t[0].Info(format, args...)
+	t[1].Info(format, args...)
+}
+
+func (t teeLogger) Success(format string, args ...interface{}) {
+	t[0].Success(format, args...)
+	t[1].Success(format, args...)
+}
+
+func (t teeLogger) Warn(format string, args ...interface{}) {
+	t[0].Warn(format, args...)
+	t[1].Warn(format, args...)
+}
+
+func (t teeLogger) Error(format string, args ...interface{}) {
+	t[0].Error(format, args...)
+	t[1].Error(format, args...)
+}
+
+func (t teeLogger) Progress(format string, args ...interface{}) {
+	t[0].Progress(format, args...)
+	t[1].Progress(format, args...)
+}
+
+func (t teeLogger) Print(format string, args ...interface{}) {
+	t[0].Print(format, args...)
+	t[1].Print(format, args...)
+}
+
+func (t teeLogger) Println(args ...interface{}) {
+	t[0].Println(args...)
+	t[1].Println(args...)
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+// giteaIssues serves the Gitea issue API and records the issues opened
+func giteaIssues(t *testing.T, status int) (*httptest.Server, *[]map[string]string) {
+	t.Helper()
+	var opened []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/repos/me/infra/issues" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("Authorization = %q, want the token", got)
+		}
+		var issue map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&issue); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		if status != http.StatusCreated {
+			http.Error(w, "unavailable", status)
+			return
+		}
+		opened = append(opened, issue)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"number": len(opened), "html_url": "https://git.example.com/me/infra/issues/1"})
+	}))
+	t.Cleanup(server.Close)
+	return server, &opened
+}
+
+func TestNewIssueReporter(t *testing.T) {
+	if r, err := newIssueReporter(config.IssuesConfig{}, 3); r != nil || err != nil {
+		t.Errorf("newIssueReporter() of an empty config = %v, %v, want nil", r, err)
+	}
+	for _, cfg := range []config.IssuesConfig{
+		{Repository: "me/infra", Token: "secret"},
+		{URL: "https://git.example.com", Repository: "infra", Token: "secret"},
+		{URL: "https://git.example.com", Repository: "me/infra"},
+	} {
+		if _, err := newIssueReporter(cfg, 3); err == nil {
+			t.Errorf("newIssueReporter(%+v) accepted an incomplete config", cfg)
+		}
+	}
+}
+
+func TestTrackFailures(t *testing.T) {
+	ctx := context.Background()
+	server, opened := giteaIssues(t, http.StatusCreated)
+	reporter, err := newIssueReporter(config.IssuesConfig{URL: server.URL + "/", Repository: "me/infra", Token: "secret"}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "backups", ".failures.json")
+
+	for i := 1; i <= 4; i++ {
+		issue, err := reporter.trackFailures(ctx, path, "Backup failed", errors.New("webdav unreachable"), "Starting global backup...\n")
+		if err != nil {
+			t.Fatalf("trackFailures() error = %v", err)
+		}
+		if (issue != "") != (i == 3) {
+			t.Errorf("failure %d returned issue %q, want one on the third failure only", i, issue)
+		}
+	}
+	if len(*opened) != 1 {
+		t.Fatalf("opened %d issues, want 1", len(*opened))
+	}
+	body := (*opened)[0]["body"]
+	if (*opened)[0]["title"] != "Backup failed" || !strings.Contains(body, "3 consecutive failures") || !strings.Contains(body, "webdav unreachable") || !strings.Contains(body, "Starting global backup") {
+		t.Errorf("issue = %v, want the failures and their logs", (*opened)[0])
+	}
+
+	// a success ends the streak, so the next one opens a new issue
+	if _, err := reporter.trackFailures(ctx, path, "Backup failed", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failure state kept after a success: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		reporter.trackFailures(ctx, path, "Backup failed", errors.New("disk full"), "")
+	}
+	if len(*opened) != 2 {
+		t.Errorf("opened %d issues, want a second one for the new streak", len(*opened))
+	}
+}
+
+func TestTrackFailuresRetriesIssue(t *testing.T) {
+	ctx := context.Background()
+	server, _ := giteaIssues(t, http.StatusServiceUnavailable)
+	reporter, _ := newIssueReporter(config.IssuesConfig{URL: server.URL, Repository: "me/infra", Token: "secret"}, 1)
+	path := filepath.Join(t.TempDir(), ".failures.json")
+
+	if _, err := reporter.trackFailures(ctx, path, "Backup failed", errors.New("boom"), ""); err == nil {
+		t.Fatal("trackFailures() ignored the failed issue request")
+	}
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"html_url":"https://git.example.com/me/infra/issues/7"}`))
+	})
+	issue, err := reporter.trackFailures(ctx, path, "Backup failed", errors.New("boom"), "")
+	if err != nil || issue != "https://git.example.com/me/infra/issues/7" {
+		t.Errorf("trackFailures() = %q, %v, want the issue opened on the next failure", issue, err)
+	}
+}
+
+func TestFailureStreakAdd(t *testing.T) {
+	var streak failureStreak
+	for i := 0; i < maxIssueFailures+2; i++ {
+		streak.add(failureRecord{Error: "boom", Log: strings.Repeat("x", maxIssueLog+10)})
+	}
+	if streak.Count != maxIssueFailures+2 || len(streak.Failures) != maxIssueFailures {
+		t.Errorf("count %d with %d failures, want %d with the last %d", streak.Count, len(streak.Failures), maxIssueFailures+2, maxIssueFailures)
+	}
+	if log := streak.Failures[0].Log; !strings.HasPrefix(log, "...\n") || len(log) != maxIssueLog+4 {
+		t.Errorf("log of %d bytes, want the last %d", len(log), maxIssueLog)
+	}
+}
+
+func TestReconcilerRecordFailure(t *testing.T) {
+	ctx := context.Background()
+	server, opened := giteaIssues(t, http.StatusCreated)
+	r := newReconciler(nil, nil, nil, nil, time.Hour, logger.NewNopLogger())
+	r.issues, _ = newIssueReporter(config.IssuesConfig{URL: server.URL, Repository: "me/infra", Token: "secret"}, 2)
+
+	key := "Deployment.apps/infra/gitea"
+	for i := 0; i < 3; i++ {
+		r.recordFailure(ctx, key, "gitea", errors.New("admission webhook denied the request"))
+	}
+	if len(*opened) != 1 || !strings.Contains((*opened)[0]["title"], key) || !strings.Contains((*opened)[0]["title"], "gitea") {
+		t.Fatalf("opened %v, want one issue naming the object and its component", *opened)
+	}
+	if r.failures[key].Count != 3 {
+		t.Errorf("streak count = %d, want 3", r.failures[key].Count)
+	}
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/modules"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logsOptions are the flags of <module> logs
+type logsOptions struct {
+	follow    bool
+	container string
+	since     time.Duration
+}
+
+// logStream is one container of one pod
+type logStream struct {
+	pod       string
+	container string
+}
+
+// handleLogsCommand prints the logs of the module's pods, found by its app
+// label, so no pod names are needed
+func (a *App) handleLogsCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	logsCmd := flag.NewFlagSet("logs", flag.ContinueOnError)
+	logsCmd.SetOutput(io.Discard)
+	var opts logsOptions
+	logsCmd.BoolVar(&opts.follow, "follow", false, "Stream new log lines until interrupted")
+	logsCmd.BoolVar(&opts.follow, "f", false, "Stream new log lines until interrupted (shorthand)")
+	logsCmd.StringVar(&opts.container, "container", "", "Only print the logs of this container")
+	logsCmd.DurationVar(&opts.since, "since", 0, "Only print lines newer than this, e.g. 10m or 2h")
+	usage := fmt.Sprintf("usage: %s %s logs [-f] [--container NAME] [--since 1h]", Name, name)
+	if err := logsCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if logsCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	if opts.since < 0 {
+		return fmt.Errorf("--since must not be negative")
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+
+	// Log bodies stay open while they are followed, longer than the request
+	// timeout of the ordinary client allows
+	client, err := a.clients.Streaming()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return streamLogs(ctx, client, namespace, "app="+modules.AppLabel(module), opts, a.stdout)
+}
+
+// streamLogs writes the logs of every container of the pods matching
+// selector to w. With several containers each line is prefixed with
+// [pod/container]. Following streams all containers at once, otherwise they
+// are printed one after the other.
+func streamLogs(ctx context.Context, client kubernetes.Interface, namespace, selector string, opts logsOptions, w io.Writer) error {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no pods with label %s in namespace %s", selector, namespace)
+	}
+
+	var streams []logStream
+	for _, pod := range pods.Items {
+		for _, c := range pod.Spec.Containers {
+			if opts.container == "" || c.Name == opts.container {
+				streams = append(streams, logStream{pod: pod.Name, container: c.Name})
+			}
+		}
+	}
+	if len(streams) == 0 {
+		return fmt.Errorf("no container %s in the pods with label %s", opts.container, selector)
+	}
+
+	logOpts := corev1.PodLogOptions{Follow: opts.follow}
+	if opts.since > 0 {
+		seconds := int64(opts.since.Seconds())
+		logOpts.SinceSeconds = &seconds
+	}
+	prefixed := len(streams) > 1
+
+	if !opts.follow {
+		for _, s := range streams {
+			if err := copyLogs(ctx, client, namespace, s, logOpts, prefixed, w, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Lines of concurrent streams are written whole under mu
+	var mu sync.Mutex
+	errs := make(chan error, len(streams))
+	for _, s := range streams {
+		go func(s logStream) {
+			errs <- copyLogs(ctx, client, namespace, s, logOpts, prefixed, w, &mu)
+		}(s)
+	}
+	var firstErr error
+	for range streams {
+		if err := <-errs; err != nil && firstErr == nil && ctx.Err() == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// copyLogs copies the log lines of one container to w, holding mu, when set,
+// while writing each line
+func copyLogs(ctx context.Context, client kubernetes.Interface, namespace string, s logStream, opts corev1.PodLogOptions, prefixed bool, w io.Writer, mu *sync.Mutex) error {
+	opts.Container = s.container
+	body, err := client.CoreV1().Pods(namespace).GetLogs(s.pod, &opts).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get logs of %s/%s: %w", s.pod, s.container, err)
+	}
+	defer body.Close()
+
+	prefix := ""
+	if prefixed {
+		prefix = fmt.Sprintf("[%s/%s] ", s.pod, s.container)
+	}
+	reader := bufio.NewReader(body)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if line[len(line)-1] != '\n' {
+				line += "\n"
+			}
+			if mu != nil {
+				mu.Lock()
+			}
+			_, werr := io.WriteString(w, prefix+line)
+			if mu != nil {
+				mu.Unlock()
+			}
+			if werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read logs of %s/%s: %w", s.pod, s.container, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newLogsTestClient() *fake.Clientset {
+	pod := func(name, app string, containers ...string) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "infra", Labels: map[string]string{"app": app}}}
+		for _, c := range containers {
+			p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Name: c})
+		}
+		return p
+	}
+	return fake.NewSimpleClientset(
+		pod("gitea-0", "gitea", "gitea", "backup"),
+		pod("postgres-0", "postgres", "postgres"),
+	)
+}
+
+func TestStreamLogs(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		opts     logsOptions
+		want     string
+		wantErr  string
+	}{
+		{name: "single container", selector: "app=postgres", want: "fake logs\n"},
+		{name: "prefixes several containers", selector: "app=gitea", want: "[gitea-0/gitea] fake logs\n[gitea-0/backup] fake logs\n"},
+		{name: "container", selector: "app=gitea", opts: logsOptions{container: "backup"}, want: "fake logs\n"},
+		{name: "follow", selector: "app=postgres", opts: logsOptions{follow: true}, want: "fake logs\n"},
+		{name: "unknown container", selector: "app=gitea", opts: logsOptions{container: "nginx"}, wantErr: "no container nginx"},
+		{name: "no pods", selector: "app=redis", wantErr: "no pods with label app=redis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := streamLogs(context.Background(), newLogsTestClient(), "infra", tt.selector, tt.opts, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("streamLogs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleLogsCommand_Usage(t *testing.T) {
+	app := &App{}
+	module := basicHelpTestModule{name: "gitea"}
+	if err := app.handleLogsCommand(context.Background(), nil, "gitea", module, []string{"extra"}); err == nil || !strings.Contains(err.Error(), "usage:") {
+		t.Errorf("expected usage error, got %v", err)
+	}
+	if err := app.handleLogsCommand(context.Background(), nil, "gitea", module, []string{"--since", "-1m"}); err == nil {
+		t.Error("expected error for a negative --since")
+	}
+}
+
+// newSlowLogServer serves the pod web-0 in namespace apps and returns a
+// kubeconfig for it. Every pod log it serves writes a line, pauses for delay
+// and writes another one.
+func newSlowLogServer(t *testing.T, delay time.Duration) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/log"):
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "first\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(delay)
+			io.WriteString(w, "second\n")
+		case strings.HasSuffix(r.URL.Path, "/namespaces/apps/pods"):
+			pods := corev1.PodList{Items: []corev1.Pod{{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "apps", Labels: map[string]string{"app": "web"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+			}}}
+			pods.APIVersion, pods.Kind = "v1", "PodList"
+			json.NewEncoder(w).Encode(pods)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`, server.URL)
+	if err := os.WriteFile(kubeconfig, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return kubeconfig
+}
+
+func TestHandleLogsCommand_FollowOutlivesRequestTimeout(t *testing.T) {
+	kubeconfig := newSlowLogServer(t, 300*time.Millisecond)
+	var out strings.Builder
+	app := &App{
+		logger:  logger.NewNopLogger(),
+		stdout:  &out,
+		clients: k8s.NewClients(k8s.ClientOptions{Kubeconfig: kubeconfig, Timeout: 100 * time.Millisecond}),
+	}
+	cfg := &config.Config{Modules: []config.Module{{Name: "web", Namespace: "apps"}}}
+
+	if err := app.handleLogsCommand(context.Background(), cfg, "web", basicHelpTestModule{name: "web"}, []string{"-f"}); err != nil {
+		t.Fatalf("handleLogsCommand() error: %v", err)
+	}
+	if out.String() != "first\nsecond\n" {
+		t.Errorf("output = %q, want both lines", out.String())
+	}
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+// ensureNamespace creates a component's namespace before its objects are
+// applied, unless general.namespaceCreation.disabled is set
+func ensureNamespace(ctx context.Context, client k8s.KubernetesClient, cfg *config.Config, namespace string, log logger.Logger) error {
+	if namespace == "" || cfg.General.NamespaceCreation.Disabled {
+		return nil
+	}
+	created, err := k8s.EnsureNamespace(ctx, client, namespace)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Success("Created namespace: %s\n", namespace)
+	}
+	return nil
+}
+
+// deleteEmptyNamespaces deletes the namespaces of cleaned components that the
+// tool created and that are empty now, when general.namespaceCreation
+// .deleteWhenEmpty is set. Failing to delete one does not fail the clean.
+func (a *App) deleteEmptyNamespaces(ctx context.Context, cfg *config.Config, names []string) {
+	if !cfg.General.NamespaceCreation.DeleteWhenEmpty {
+		return
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		a.logger.Warn("Not deleting empty namespaces: failed to create Kubernetes client: %v\n", err)
+		return
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		namespace, ok := componentNamespace(cfg, name)
+		if !ok || namespace == "" || seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+		deleted, reason, err := k8s.DeleteEmptyNamespace(ctx, client, namespace)
+		switch {
+		case err != nil:
+			a.logger.Warn("Failed to delete namespace %s: %v\n", namespace, err)
+		case deleted:
+			a.logger.Success("Deleted empty namespace: %s\n", namespace)
+		default:
+			a.logger.Info("Keeping namespace %s: %s\n", namespace, reason)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureNamespace(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	disabled := &config.Config{General: config.GeneralConfig{NamespaceCreation: config.NamespaceCreationConfig{Disabled: true}}}
+	if err := ensureNamespace(ctx, client, disabled, "hobby", logger.NewNopLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(ctx, "hobby", metav1.GetOptions{}); err == nil {
+		t.Error("namespace created although namespaceCreation.disabled is set")
+	}
+
+	if err := ensureNamespace(ctx, client, &config.Config{}, "hobby", logger.NewNopLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(ctx, "hobby", metav1.GetOptions{}); err != nil {
+		t.Errorf("namespace not created: %v", err)
+	}
+	// A second apply finds it in place
+	if err := ensureNamespace(ctx, client, &config.Config{}, "hobby", logger.NewNopLogger()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,742 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// desiredStateName names the ConfigMap holding the generated objects and
+	// the Secret holding the generated Secrets, so credentials never land in
+	// a ConfigMap
+	desiredStateName  = "personal-server-desired-state"
+	operatorLeaseName = "personal-server-operator"
+
+	defaultOperatorNamespace = "default"
+	defaultOperatorResync    = 10 * time.Minute
+
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// desiredState maps each component name to the objects it generates
+type desiredState map[string][]*unstructured.Unstructured
+
+func (a *App) handleOperatorCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	operatorCmd := flag.NewFlagSet("operator", flag.ContinueOnError)
+	operatorCmd.SetOutput(io.Discard)
+	namespace := operatorCmd.String("namespace", defaultOperatorNamespace, "Namespace of the desired state ConfigMap and the leader election Lease")
+	resync := operatorCmd.Duration("resync", defaultOperatorResync, "How often every object is checked for drift")
+	fromCluster := operatorCmd.Bool("from-cluster", false, "Reconcile the stored desired state instead of generating it from the config")
+	identity := operatorCmd.String("identity", "", "Leader election identity (default: hostname and process ID)")
+	crd := operatorCmd.Bool("crd", false, "Also render the modules defined as "+moduleKind+" resources")
+	webhookAddr := operatorCmd.String("webhook", "", "Address to serve the deploy webhook on, e.g. :8080 (token in "+webhookTokenEnv+")")
+	usage := fmt.Sprintf("usage: %s operator [--namespace %s] [--resync 10m] [--from-cluster | --crd] [--webhook :8080]", Name, defaultOperatorNamespace)
+	if err := operatorCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if operatorCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	if *resync < time.Minute {
+		return fmt.Errorf("--resync must be at least 1m")
+	}
+	if *crd && *fromCluster {
+		return fmt.Errorf("--crd and --from-cluster cannot be combined")
+	}
+	webhookToken := os.Getenv(webhookTokenEnv)
+	if *webhookAddr != "" && webhookToken == "" {
+		return fmt.Errorf("--webhook requires a token in the %s environment variable", webhookTokenEnv)
+	}
+	if *identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get hostname for the leader election identity: %w", err)
+		}
+		*identity = fmt.Sprintf("%s_%d", hostname, os.Getpid())
+	}
+
+	var state desiredState
+	if !*fromCluster && !*crd {
+		a.logger.Info("Generating desired state from %d components...\n", len(cfg.Modules)+len(cfg.PetProjects)+len(cfg.Ingresses))
+		generated, err := a.generateDesiredState(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		state = generated
+	}
+
+	clientset, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dyn, err := a.clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	// Watches stay open longer than the request timeout of dyn allows
+	watchDyn, err := a.clients.StreamingDynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	mapper, err := a.clients.RESTMapper()
+	if err != nil {
+		return err
+	}
+
+	threshold := cfg.Issues.OperatorThreshold
+	if threshold <= 0 {
+		threshold = defaultOperatorIssueThreshold
+	}
+	issues, err := newIssueReporter(cfg.Issues, threshold)
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("Waiting for leadership as %s (Lease %s/%s)...\n", *identity, *namespace, operatorLeaseName)
+	return runWithLeaderElection(ctx, clientset, *namespace, *identity, a.logger, func(ctx context.Context) error {
+		r := newReconciler(dyn, watchDyn, mapper, nil, *resync, a.logger)
+		r.issues = issues
+		// The webhook is served by the leader only, so a Service with a
+		// readiness probe on it routes deploys to the replica that acts on them
+		startWebhook := func() error {
+			if *webhookAddr == "" {
+				return nil
+			}
+			return serveWebhook(ctx, *webhookAddr, &webhookServer{
+				token:  webhookToken,
+				log:    a.logger,
+				images: r.containerImages,
+				deploy: func(component, image string) error {
+					if err := r.setImage(component, image); err != nil {
+						return err
+					}
+					state := r.state()
+					if err := storeDesiredState(ctx, clientset, *namespace, state); err != nil {
+						return err
+					}
+					if err := r.update(ctx, state); err != nil {
+						return err
+					}
+					if err := recordDeploy(ctx, clientset, state[component], *identity); err != nil {
+						a.logger.Warn("[%s] failed to record the deploy in its history: %v\n", component, err)
+					}
+					return nil
+				},
+			}, a.logger)
+		}
+
+		if *crd {
+			operator := &moduleOperator{app: a, cfg: cfg, client: clientset, dyn: dyn, watchDyn: watchDyn, mapper: mapper, namespace: *namespace, resync: *resync}
+			return operator.run(ctx, r, startWebhook)
+		}
+		// Only the leader publishes, so replicas started from different
+		// configs do not overwrite each other
+		if state != nil {
+			if err := storeDesiredState(ctx, clientset, *namespace, state); err != nil {
+				return err
+			}
+			a.logger.Success("Stored desired state of %d objects in %s/%s\n", state.count(), *namespace, desiredStateName)
+		} else {
+			loaded, err := loadDesiredState(ctx, clientset, *namespace)
+			if err != nil {
+				return err
+			}
+			state = loaded
+			a.logger.Info("Loaded desired state of %d objects from %s/%s\n", state.count(), *namespace, desiredStateName)
+		}
+		r.setState(state)
+		if err := startWebhook(); err != nil {
+			return err
+		}
+		return r.run(ctx)
+	})
+}
+
+func (s desiredState) count() int {
+	n := 0
+	for _, objects := range s {
+		n += len(objects)
+	}
+	return n
+}
+
+// generateDesiredState runs every component's generate and collects the
+// objects it writes, failing on the first component that cannot be generated
+func (a *App) generateDesiredState(ctx context.Context, cfg *config.Config) (desiredState, error) {
+	state, failed, err := a.renderComponents(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		return nil, fmt.Errorf("%s: failed to generate: %w", names[0], failed[names[0]])
+	}
+	return state, nil
+}
+
+// renderComponents runs every component's generate and collects the objects
+// it writes. Components that fail to generate are returned separately. Jobs
+// are left out: they run once, and recreating a finished Job would run it
+// again.
+func (a *App) renderComponents(ctx context.Context, cfg *config.Config) (desiredState, map[string]error, error) {
+	components, err := a.components(cfg, func(string) logger.Logger { return logger.NewNopLogger() })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state := desiredState{}
+	failed := map[string]error{}
+	for _, c := range components {
+		objects, err := a.renderModule(ctx, c.module)
+		if err != nil {
+			failed[c.name] = err
+			continue
+		}
+		var kept []*unstructured.Unstructured
+		for _, object := range objects {
+			if object.GetKind() != "Job" {
+				kept = append(kept, object)
+			}
+		}
+		state[c.name] = kept
+	}
+	return state, failed, nil
+}
+
+// renderModule runs module's generate in a temporary directory and returns
+// the objects it writes. Generate writes to configs/ relative to the working
+// directory, so renders must not run concurrently.
+func (a *App) renderModule(ctx context.Context, module modules.Module) ([]*unstructured.Unstructured, error) {
+	dir, err := os.MkdirTemp("", "personal-server-render-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, fmt.Errorf("failed to change to temporary directory: %w", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := module.Generate(ctx); err != nil {
+		return nil, err
+	}
+	return a.readGeneratedObjects(filepath.Join(dir, "configs"))
+}
+
+// readGeneratedObjects decodes the YAML files under dir, skipping files that
+// are not Kubernetes objects
+func (a *App) readGeneratedObjects(dir string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		decoded, err := k8s.DecodeManifest(data)
+		if err != nil {
+			a.logger.Warn("Skipping %s: %v\n", filepath.Base(path), err)
+			return nil
+		}
+		objects = append(objects, decoded...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// storeDesiredState writes state to the desired state ConfigMap and Secret,
+// one multi-document YAML key per component
+func storeDesiredState(ctx context.Context, client kubernetes.Interface, namespace string, state desiredState) error {
+	configData := map[string]string{}
+	secretData := map[string][]byte{}
+	for name, objects := range state {
+		var plain, secrets []string
+		for _, object := range objects {
+			jsonBytes, err := json.Marshal(object.Object)
+			if err != nil {
+				return fmt.Errorf("failed to convert %s %s to JSON: %w", object.GetKind(), object.GetName(), err)
+			}
+			yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+			if err != nil {
+				return fmt.Errorf("failed to convert %s %s to YAML: %w", object.GetKind(), object.GetName(), err)
+			}
+			if object.GetKind() == "Secret" {
+				secrets = append(secrets, yamlContent)
+			} else {
+				plain = append(plain, yamlContent)
+			}
+		}
+		if len(plain) > 0 {
+			configData[name+".yaml"] = strings.Join(plain, "---\n")
+		}
+		if len(secrets) > 0 {
+			secretData[name+".yaml"] = []byte(strings.Join(secrets, "---\n"))
+		}
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      desiredStateName,
+		Namespace: namespace,
+		Labels:    map[string]string{"managed-by": "personal-server"},
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: meta, Data: configData}
+	if _, err := client.CoreV1().ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{}); apierrors.IsNotFound(err) {
+		_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s: %w", desiredStateName, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s: %w", desiredStateName, err)
+	}
+	secret := &corev1.Secret{ObjectMeta: meta, Type: corev1.SecretTypeOpaque, Data: secretData}
+	if _, err := client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); apierrors.IsNotFound(err) {
+		_, err = client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create Secret %s: %w", desiredStateName, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to update Secret %s: %w", desiredStateName, err)
+	}
+	return nil
+}
+
+// loadDesiredState reads the state stored by storeDesiredState
+func loadDesiredState(ctx context.Context, client kubernetes.Interface, namespace string) (desiredState, error) {
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, desiredStateName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("no desired state in namespace %s: run '%s operator' without --from-cluster first", namespace, Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConfigMap %s: %w", desiredStateName, err)
+	}
+	documents := map[string][]string{}
+	for key, content := range configMap.Data {
+		documents[key] = append(documents[key], content)
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, desiredStateName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get Secret %s: %w", desiredStateName, err)
+	}
+	if err == nil {
+		for key, content := range secret.Data {
+			documents[key] = append(documents[key], string(content))
+		}
+	}
+
+	state := desiredState{}
+	for key, contents := range documents {
+		name := strings.TrimSuffix(key, ".yaml")
+		for _, content := range contents {
+			objects, err := k8s.DecodeManifest([]byte(content))
+			if err != nil {
+				return nil, fmt.Errorf("invalid desired state of %s: %w", name, err)
+			}
+			state[name] = append(state[name], objects...)
+		}
+	}
+	return state, nil
+}
+
+// runWithLeaderElection calls run while holding the operator Lease in
+// namespace. It returns when ctx is cancelled, run fails, or the Lease is
+// lost; in the last case another replica takes over.
+func runWithLeaderElection(ctx context.Context, client kubernetes.Interface, namespace, identity string, log logger.Logger, run func(ctx context.Context) error) error {
+	electionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// OnStartedLeading hands over its context, cancelled when the Lease is
+	// lost, so run executes on this goroutine
+	leading := make(chan context.Context, 1)
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Name: operatorLeaseName, Namespace: namespace},
+			Client:     client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            operatorLeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) { leading <- leaderCtx },
+			OnStoppedLeading: func() {},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Info("Current leader: %s\n", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up leader election: %w", err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		elector.Run(electionCtx)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case leaderCtx := <-leading:
+		log.Success("Became the leader\n")
+		runErr := run(leaderCtx)
+		// release the Lease so another replica can take over
+		cancel()
+		<-stopped
+		if runErr != nil {
+			return runErr
+		}
+		if ctx.Err() == nil {
+			return fmt.Errorf("lost leadership of Lease %s/%s", namespace, operatorLeaseName)
+		}
+		return nil
+	}
+}
+
+// reconciler recreates deleted objects of the desired state and patches back
+// the ones that drifted from it
+type reconciler struct {
+	dyn     dynamic.Interface
+	mapper  meta.RESTMapper
+	log     logger.Logger
+	queue   workqueue.RateLimitingInterface
+	factory dynamicinformer.DynamicSharedInformerFactory
+
+	mu      sync.Mutex
+	desired map[string]*unstructured.Unstructured
+	owner   map[string]string
+	// versions holds the resource version each object had after its last
+	// reconcile, so the watch event for our own write is not reconciled again
+	versions map[string]string
+	// deployed maps a component and an image repository to the image
+	// deployed through the webhook, which replaces the generated one
+	deployed map[string]map[string]string
+	// issues, when set, opens an issue for an object that keeps failing to
+	// reconcile; failures holds the failure streak of each object
+	issues   *issueReporter
+	failures map[string]*failureStreak
+
+	watchMu sync.Mutex
+	watched map[schema.GroupVersionResource]bool
+}
+
+// newReconciler returns a reconciler writing through dyn and watching through
+// watchDyn, which must not have a request timeout
+func newReconciler(dyn, watchDyn dynamic.Interface, mapper meta.RESTMapper, state desiredState, resync time.Duration, log logger.Logger) *reconciler {
+	r := &reconciler{
+		dyn:      dyn,
+		mapper:   mapper,
+		log:      log,
+		queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		factory:  dynamicinformer.NewDynamicSharedInformerFactory(watchDyn, resync),
+		versions: map[string]string{},
+		deployed: map[string]map[string]string{},
+		failures: map[string]*failureStreak{},
+		watched:  map[schema.GroupVersionResource]bool{},
+	}
+	r.setState(state)
+	return r
+}
+
+func objectKey(gk schema.GroupKind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", gk.String(), namespace, name)
+}
+
+// setState replaces the desired state and returns its keys in order
+func (r *reconciler) setState(state desiredState) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.desired = map[string]*unstructured.Unstructured{}
+	r.owner = map[string]string{}
+	for name, objects := range state {
+		for _, object := range objects {
+			if images := r.deployed[name]; len(images) > 0 {
+				object = withImages(object, images)
+			}
+			key := objectKey(object.GroupVersionKind().GroupKind(), object.GetNamespace(), object.GetName())
+			r.desired[key] = object
+			r.owner[key] = name
+		}
+	}
+	return r.keys()
+}
+
+// keys returns the keys of the desired state in order; r.mu must be held
+func (r *reconciler) keys() []string {
+	keys := make([]string, 0, len(r.desired))
+	for key := range r.desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *reconciler) lookup(key string) (*unstructured.Unstructured, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.desired[key], r.owner[key]
+}
+
+// state returns the desired state, including deployed images
+func (r *reconciler) state() desiredState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := desiredState{}
+	for _, key := range r.keys() {
+		state[r.owner[key]] = append(state[r.owner[key]], r.desired[key])
+	}
+	return state
+}
+
+// containerImages returns the images of component's workload containers
+func (r *reconciler) containerImages(component string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := map[string]bool{}
+	var images []string
+	for _, key := range r.keys() {
+		if r.owner[key] != component {
+			continue
+		}
+		for _, container := range podContainers(r.desired[key]) {
+			if image, _ := container["image"].(string); image != "" && !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+// setImage makes image replace the image of the same repository in
+// component's workloads from now on, including in later desired states.
+// It takes effect with the next update.
+func (r *reconciler) setImage(component, image string) error {
+	repository := imageRepository(image)
+	found := false
+	for _, current := range r.containerImages(component) {
+		if imageRepository(current) == repository {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s runs no %s container", component, repository)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deployed[component] == nil {
+		r.deployed[component] = map[string]string{}
+	}
+	r.deployed[component][repository] = image
+	return nil
+}
+
+// run watches every resource type in the desired state and reconciles an
+// object when it changes, is deleted, or every resync period. It blocks
+// until ctx is cancelled.
+func (r *reconciler) run(ctx context.Context) error {
+	defer r.queue.ShutDown()
+	defer r.factory.Shutdown()
+
+	if err := r.update(ctx, r.state()); err != nil {
+		return err
+	}
+
+	go func() {
+		for r.processNext(ctx) {
+		}
+	}()
+	<-ctx.Done()
+	return nil
+}
+
+// update replaces the desired state, watches resource types it has not seen
+// yet and queues every object for a reconcile. Objects no longer in the state
+// are left as they are.
+func (r *reconciler) update(ctx context.Context, state desiredState) error {
+	keys := r.setState(state)
+
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+	var synced []cache.InformerSynced
+	for _, key := range keys {
+		object, _ := r.lookup(key)
+		gvk := object.GroupVersionKind()
+		mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("failed to find resource for %s: %w", gvk, err)
+		}
+		if r.watched[mapping.Resource] {
+			continue
+		}
+		gk := gvk.GroupKind()
+		enqueue := func(obj interface{}) {
+			if object, ok := deletedObject(obj).(*unstructured.Unstructured); ok {
+				key := objectKey(gk, object.GetNamespace(), object.GetName())
+				if desired, _ := r.lookup(key); desired != nil {
+					r.queue.Add(key)
+				}
+			}
+		}
+		informer := r.factory.ForResource(mapping.Resource).Informer()
+		if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    enqueue,
+			UpdateFunc: func(_, newObj interface{}) { enqueue(newObj) },
+			DeleteFunc: enqueue,
+		}); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", mapping.Resource.Resource, err)
+		}
+		r.watched[mapping.Resource] = true
+		synced = append(synced, informer.HasSynced)
+	}
+
+	r.factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) && ctx.Err() == nil {
+		return fmt.Errorf("failed to sync informer caches")
+	}
+
+	// Objects that are already missing produce no event
+	for _, key := range keys {
+		r.queue.Add(key)
+	}
+	r.log.Info("Watching %d objects of %d resource types\n", len(keys), len(r.watched))
+	return nil
+}
+
+// processNext reconciles one queued object, retrying it with backoff on
+// failure. It returns false once the queue is shut down.
+func (r *reconciler) processNext(ctx context.Context) bool {
+	item, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(item)
+
+	key := item.(string)
+	if err := r.reconcile(ctx, key); err != nil {
+		if ctx.Err() == nil {
+			_, owner := r.lookup(key)
+			r.log.Error("[%s] %v (retrying)\n", owner, err)
+			r.recordFailure(ctx, key, owner, err)
+			r.queue.AddRateLimited(key)
+		}
+		return true
+	}
+	r.mu.Lock()
+	delete(r.failures, key)
+	r.mu.Unlock()
+	r.queue.Forget(key)
+	return true
+}
+
+// recordFailure extends the failure streak of the object at key and opens
+// an issue once it reaches the threshold
+func (r *reconciler) recordFailure(ctx context.Context, key, owner string, err error) {
+	if r.issues == nil {
+		return
+	}
+	r.mu.Lock()
+	streak := r.failures[key]
+	if streak == nil {
+		streak = &failureStreak{}
+		r.failures[key] = streak
+	}
+	streak.add(failureRecord{Error: err.Error()})
+	r.mu.Unlock()
+
+	issue, err := r.issues.report(ctx, streak, fmt.Sprintf("Operator cannot reconcile %s of %s", key, owner))
+	if err != nil {
+		r.log.Warn("[%s] %v\n", owner, err)
+	}
+	if issue != "" {
+		r.log.Info("[%s] Opened issue %s\n", owner, issue)
+	}
+}
+
+func (r *reconciler) reconcile(ctx context.Context, key string) error {
+	desired, owner := r.lookup(key)
+	if desired == nil {
+		// dropped from the desired state since it was queued
+		return nil
+	}
+	r.mu.Lock()
+	ignoreVersion := r.versions[key]
+	r.mu.Unlock()
+
+	result, err := k8s.ReconcileObject(ctx, r.dyn, r.mapper, desired, ignoreVersion)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.versions[key] = result.ResourceVersion
+	r.mu.Unlock()
+
+	name := desired.GetName()
+	if desired.GetNamespace() != "" {
+		name = desired.GetNamespace() + "/" + name
+	}
+	if result.Created {
+		r.log.Warn("[%s] %s %s was missing, recreated\n", owner, desired.GetKind(), name)
+	}
+	if len(result.Drifted) > 0 {
+		r.log.Warn("[%s] %s %s drifted (%s), restored\n", owner, desired.GetKind(), name, strings.Join(result.Drifted, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const operatorTestManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: infra
+data:
+  mode: production
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-secrets
+  namespace: infra
+data:
+  token: c2VjcmV0
+`
+
+// generatingTestModule writes files to configs/<name>/ like real modules do
+type generatingTestModule struct {
+	basicHelpTestModule
+	files map[string]string
+}
+
+func (m generatingTestModule) Generate(context.Context) error {
+	dir := filepath.Join("configs", m.name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, content := range m.files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGenerateDesiredState(t *testing.T) {
+	log := logger.NewNopLogger()
+	registry := modules.NewRegistry(log)
+	registry.Register("web", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return generatingTestModule{basicHelpTestModule{name: "web"}, map[string]string{
+			"objects.yaml": operatorTestManifests,
+			"job.yaml":     "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n  namespace: infra\n",
+			"README.txt":   "not a manifest",
+			"values.yaml":  "replicas: 2\n",
+		}}
+	})
+	app := &App{logger: log, registry: registry}
+
+	wd, _ := os.Getwd()
+	state, err := app.generateDesiredState(context.Background(), &config.Config{Modules: []config.Module{{Name: "web", Namespace: "infra"}}})
+	if err != nil {
+		t.Fatalf("generateDesiredState() error = %v", err)
+	}
+	if after, _ := os.Getwd(); after != wd {
+		t.Errorf("working directory changed to %s", after)
+	}
+
+	var kinds []string
+	for _, object := range state["web"] {
+		kinds = append(kinds, object.GetKind())
+	}
+	if strings.Join(kinds, ",") != "ConfigMap,Secret" {
+		t.Errorf("objects = %v, want the ConfigMap and Secret without the Job", kinds)
+	}
+}
+
+func TestDesiredStateRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	objects, err := k8s.DecodeManifest([]byte(operatorTestManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset()
+
+	// storing twice updates the existing ConfigMap and Secret
+	for i := 0; i < 2; i++ {
+		if err := storeDesiredState(ctx, client, "ops", desiredState{"web": objects}); err != nil {
+			t.Fatalf("storeDesiredState() error = %v", err)
+		}
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps("ops").Get(ctx, desiredStateName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("ConfigMap not stored: %v", err)
+	}
+	if content := configMap.Data["web.yaml"]; !strings.Contains(content, "web-config") || strings.Contains(content, "c2VjcmV0") {
+		t.Errorf("ConfigMap web.yaml = %q, want the ConfigMap without Secret data", content)
+	}
+
+	state, err := loadDesiredState(ctx, client, "ops")
+	if err != nil {
+		t.Fatalf("loadDesiredState() error = %v", err)
+	}
+	if len(state["web"]) != 2 {
+		t.Fatalf("loaded %d objects, want 2", len(state["web"]))
+	}
+
+	if _, err := loadDesiredState(ctx, client, "elsewhere"); err == nil || !strings.Contains(err.Error(), "without --from-cluster") {
+		t.Errorf("loadDesiredState() of an empty namespace error = %v", err)
+	}
+}
+
+func TestReconcilerRecreatesDeletedObjects(t *testing.T) {
+	configMapGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMapGVR: "ConfigMapList",
+	})
+
+	objects, err := k8s.DecodeManifest([]byte(strings.SplitN(operatorTestManifests, "---", 2)[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := newReconciler(dyn, dyn, mapper, desiredState{"web": objects}, time.Hour, logger.NewNopLogger())
+	done := make(chan error, 1)
+	go func() { done <- r.run(ctx) }()
+
+	configMaps := dyn.Resource(configMapGVR).Namespace("infra")
+	waitFor := func(what string, check func(*unstructured.Unstructured) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if live, err := configMaps.Get(ctx, "web-config", metav1.GetOptions{}); err == nil && check(live) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s", what)
+	}
+	mode := func(live *unstructured.Unstructured) string {
+		value, _, _ := unstructured.NestedString(live.Object, "data", "mode")
+		return value
+	}
+
+	waitFor("initial creation", func(*unstructured.Unstructured) bool { return true })
+
+	if err := configMaps.Delete(ctx, "web-config", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("recreation", func(*unstructured.Unstructured) bool { return true })
+
+	live, err := configMaps.Get(ctx, "web-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unstructured.SetNestedField(live.Object, "debug", "data", "mode")
+	live.SetResourceVersion("edited")
+	if _, err := configMaps.Update(ctx, live, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("drift to be reverted", func(live *unstructured.Unstructured) bool { return mode(live) == "production" })
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run() error = %v", err)
+	}
+}
+
+func TestRunWithLeaderElection(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	runErr := errors.New("reconcile failed")
+
+	calls := 0
+	err := runWithLeaderElection(context.Background(), client, "ops", "replica-1", logger.NewNopLogger(), func(ctx context.Context) error {
+		calls++
+		lease, err := client.CoordinationV1().Leases("ops").Get(ctx, operatorLeaseName, metav1.GetOptions{})
+		if err != nil || lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != "replica-1" {
+			t.Errorf("Lease = %+v, %v, want held by replica-1", lease, err)
+		}
+		return runErr
+	})
+	if !errors.Is(err, runErr) || calls != 1 {
+		t.Errorf("runWithLeaderElection() = %v after %d calls, want the run error after 1 call", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := runWithLeaderElection(ctx, client, "ops", "replica-2", logger.NewNopLogger(), func(context.Context) error {
+		t.Error("run called without leadership")
+		return nil
+	}); err != nil {
+		t.Errorf("runWithLeaderElection() with a cancelled context = %v, want nil", err)
+	}
+}
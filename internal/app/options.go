@@ -32,6 +32,13 @@ func WithConfigLoader(loader ConfigLoader) Option {
 	}
 }
 
+// WithStdin sets a custom stdin reader, e.g. for confirmation prompts
+func WithStdin(r io.Reader) Option {
+	return func(a *App) {
+		a.stdin = r
+	}
+}
+
 // WithStdout sets a custom stdout writer
 func WithStdout(w io.Writer) Option {
 	return func(a *App) {
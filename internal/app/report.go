@@ -0,0 +1,476 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Power model defaults, matching the default tags of config.ReportConfig
+const (
+	defaultPricePerKWh  = 0.30
+	defaultCurrency     = "€"
+	defaultWattsPerCore = 8
+	defaultWattsPerGiB  = 0.4
+	defaultWattsPerTiB  = 1.5
+)
+
+// Usage sources of report usage
+const (
+	sourcePrometheus    = "prometheus"
+	sourceMetricsServer = "metrics-server"
+)
+
+// podUsage is the consumption of one pod over the report period
+type podUsage struct {
+	namespace string
+	pod       string
+	coreHours float64
+	gibHours  float64
+}
+
+// usageSource returns the CPU and memory consumption of the pods of the
+// given namespaces over the period
+type usageSource interface {
+	podUsage(ctx context.Context, namespaces []string, period time.Duration) ([]podUsage, error)
+}
+
+// workloadUsage is one row of the usage report: the pods of a Deployment,
+// StatefulSet or Job together with the volumes they mount
+type workloadUsage struct {
+	namespace  string
+	workload   string
+	coreHours  float64
+	gibHours   float64
+	storageGiB float64
+	kWh        float64
+	cost       float64
+}
+
+func (a *App) handleReportCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	usage := fmt.Sprintf("usage: %s report usage [--period 30d] [--price 0.30] [--source prometheus|metrics-server]", Name)
+	if len(args) == 0 || args[0] != "usage" {
+		return fmt.Errorf("%s", usage)
+	}
+
+	reportCmd := flag.NewFlagSet("report usage", flag.ContinueOnError)
+	reportCmd.SetOutput(io.Discard)
+	periodFlag := reportCmd.String("period", "30d", "Period to report, e.g. 7d or 12h")
+	price := reportCmd.Float64("price", 0, "Electricity price per kWh (default: report.pricePerKWh)")
+	sourceFlag := reportCmd.String("source", "", "Usage source: prometheus or metrics-server (default: prometheus when configured)")
+	if err := reportCmd.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	period, err := parsePeriod(*periodFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --period: %w", err)
+	}
+
+	model := reportModel(cfg.Report)
+	if *price > 0 {
+		model.PricePerKWh = *price
+	}
+
+	namespaces := configuredNamespaces(cfg)
+	if len(namespaces) == 0 {
+		a.logger.Warn("No namespaces configured\n")
+		return nil
+	}
+
+	clientset, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	// History comes from the prometheus module when there is one; metrics-server
+	// only knows current usage, which is extrapolated over the period
+	prometheusNamespace := ""
+	for _, m := range cfg.Modules {
+		if kind, ok := a.registry.Kind(m.Name); ok && kind == "prometheus" {
+			prometheusNamespace = m.Namespace
+			break
+		}
+	}
+	source := *sourceFlag
+	if source == "" {
+		source = sourceMetricsServer
+		if prometheusNamespace != "" {
+			source = sourcePrometheus
+		}
+	}
+	var usageFrom usageSource
+	switch source {
+	case sourcePrometheus:
+		if prometheusNamespace == "" {
+			return fmt.Errorf("--source prometheus needs a configured prometheus module")
+		}
+		usageFrom = prometheusUsage{client: clientset, namespace: prometheusNamespace}
+	case sourceMetricsServer:
+		usageFrom = metricsServerUsage{get: func(ctx context.Context, path string) ([]byte, error) {
+			return clientset.Discovery().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+		}}
+	default:
+		return fmt.Errorf("invalid --source %q: must be %s or %s", source, sourcePrometheus, sourceMetricsServer)
+	}
+
+	pods, err := usageFrom.podUsage(ctx, namespaces, period)
+	if err != nil {
+		return fmt.Errorf("failed to read usage from %s: %w", source, err)
+	}
+	storage, err := workloadStorage(ctx, clientset, namespaces)
+	if err != nil {
+		return err
+	}
+
+	rows := usageReport(pods, storage, period, model)
+	if len(rows) == 0 {
+		a.logger.Warn("No usage found in namespaces: %s\n", strings.Join(namespaces, ", "))
+		return nil
+	}
+	a.logger.Info("Usage over the last %s from %s", formatPeriod(period), source)
+	if source == sourceMetricsServer {
+		a.logger.Info(" (current usage extrapolated over the period)")
+	}
+	a.logger.Info("\n\n")
+	a.printUsageReport(rows, model)
+	return nil
+}
+
+// reportModel returns cfg with unset fields replaced by the defaults
+func reportModel(cfg config.ReportConfig) config.ReportConfig {
+	if cfg.PricePerKWh == 0 {
+		cfg.PricePerKWh = defaultPricePerKWh
+	}
+	if cfg.Currency == "" {
+		cfg.Currency = defaultCurrency
+	}
+	if cfg.WattsPerCore == 0 {
+		cfg.WattsPerCore = defaultWattsPerCore
+	}
+	if cfg.WattsPerGiB == 0 {
+		cfg.WattsPerGiB = defaultWattsPerGiB
+	}
+	if cfg.WattsPerTiB == 0 {
+		cfg.WattsPerTiB = defaultWattsPerTiB
+	}
+	return cfg
+}
+
+// parsePeriod parses a Go duration or a whole number of days such as 30d
+func parsePeriod(s string) (time.Duration, error) {
+	var period time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number of days", s)
+		}
+		period = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if period, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if period < time.Hour {
+		return 0, fmt.Errorf("%q is shorter than an hour", s)
+	}
+	return period, nil
+}
+
+// formatPeriod prints whole days as 30d and anything else as a duration
+func formatPeriod(period time.Duration) string {
+	if period%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", period/(24*time.Hour))
+	}
+	return period.String()
+}
+
+// usageReport groups pod usage and storage by workload and prices it with
+// model, most expensive first
+func usageReport(pods []podUsage, storage map[string]float64, period time.Duration, model config.ReportConfig) []workloadUsage {
+	byKey := make(map[string]*workloadUsage)
+	row := func(namespace, workload string) *workloadUsage {
+		key := namespace + "/" + workload
+		if byKey[key] == nil {
+			byKey[key] = &workloadUsage{namespace: namespace, workload: workload}
+		}
+		return byKey[key]
+	}
+	for _, p := range pods {
+		r := row(p.namespace, workloadName(p.pod))
+		r.coreHours += p.coreHours
+		r.gibHours += p.gibHours
+	}
+	for key, gib := range storage {
+		namespace, workload, _ := strings.Cut(key, "/")
+		row(namespace, workload).storageGiB += gib
+	}
+
+	hours := period.Hours()
+	rows := make([]workloadUsage, 0, len(byKey))
+	for _, r := range byKey {
+		wattHours := r.coreHours*model.WattsPerCore + r.gibHours*model.WattsPerGiB + r.storageGiB/1024*model.WattsPerTiB*hours
+		r.kWh = wattHours / 1000
+		r.cost = r.kWh * model.PricePerKWh
+		rows = append(rows, *r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].cost != rows[j].cost {
+			return rows[i].cost > rows[j].cost
+		}
+		if rows[i].namespace != rows[j].namespace {
+			return rows[i].namespace < rows[j].namespace
+		}
+		return rows[i].workload < rows[j].workload
+	})
+	return rows
+}
+
+// podNameAlphabet is the alphabet Kubernetes generates pod name suffixes and
+// ReplicaSet hashes from
+const podNameAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+// workloadName strips the generated suffixes from a pod name: the ReplicaSet
+// hash and random suffix of Deployment pods, the schedule time of CronJob
+// pods and the ordinal of StatefulSet pods, e.g. gitea-7d9c8f5b4-x2k7q
+// becomes gitea. Pods that are gone are only known by name, so the name is
+// all there is to go on.
+func workloadName(pod string) string {
+	generated := func(s string, minLen, maxLen int) bool {
+		if len(s) < minLen || len(s) > maxLen {
+			return false
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(podNameAlphabet, c) {
+				return false
+			}
+		}
+		return true
+	}
+	digits := func(s string) bool {
+		_, err := strconv.Atoi(s)
+		return err == nil
+	}
+
+	parts := strings.Split(pod, "-")
+	if len(parts) < 2 {
+		return pod
+	}
+	last := parts[len(parts)-1]
+	switch {
+	case generated(last, 5, 5):
+		parts = parts[:len(parts)-1]
+		if len(parts) > 1 {
+			if prev := parts[len(parts)-1]; generated(prev, 6, 10) || digits(prev) {
+				parts = parts[:len(parts)-1]
+			}
+		}
+	case digits(last):
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, "-")
+}
+
+// workloadStorage returns the requested size in GiB of the PVCs of each
+// namespace keyed by namespace/workload: the workload of the pod mounting
+// the claim, else its app label, else the claim name
+func workloadStorage(ctx context.Context, client k8s.KubernetesClient, namespaces []string) (map[string]float64, error) {
+	storage := make(map[string]float64)
+	for _, namespace := range namespaces {
+		claims, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PersistentVolumeClaims in %s: %w", namespace, err)
+		}
+		if len(claims.Items) == 0 {
+			continue
+		}
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods in %s: %w", namespace, err)
+		}
+		mountedBy := make(map[string]string)
+		for _, pod := range pods.Items {
+			for _, volume := range pod.Spec.Volumes {
+				if volume.PersistentVolumeClaim != nil {
+					mountedBy[volume.PersistentVolumeClaim.ClaimName] = workloadName(pod.Name)
+				}
+			}
+		}
+
+		for _, claim := range claims.Items {
+			workload := mountedBy[claim.Name]
+			if workload == "" {
+				workload = claim.Labels["app"]
+			}
+			if workload == "" {
+				workload = claim.Name
+			}
+			size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			storage[namespace+"/"+workload] += size.AsApproximateFloat64() / (1 << 30)
+		}
+	}
+	return storage, nil
+}
+
+func (a *App) printUsageReport(rows []workloadUsage, model config.ReportConfig) {
+	a.logger.Info("%-12s %-28s %10s %12s %12s %8s %10s\n", "NAMESPACE", "WORKLOAD", "CPU-HOURS", "MEM GIB-H", "STORAGE GIB", "KWH", "COST")
+	var total workloadUsage
+	for _, r := range rows {
+		a.logger.Info("%-12s %-28s %10.1f %12.1f %12.1f %8.2f %10s\n", r.namespace, r.workload, r.coreHours, r.gibHours, r.storageGiB, r.kWh, formatCost(r.cost, model.Currency))
+		total.coreHours += r.coreHours
+		total.gibHours += r.gibHours
+		total.storageGiB += r.storageGiB
+		total.kWh += r.kWh
+		total.cost += r.cost
+	}
+	a.logger.Info("%-12s %-28s %10.1f %12.1f %12.1f %8.2f %10s\n", "TOTAL", "", total.coreHours, total.gibHours, total.storageGiB, total.kWh, formatCost(total.cost, model.Currency))
+	a.logger.Info("\nEstimate at %s/kWh, %g W per busy core, %g W per GiB of memory and %g W per TiB of storage (report section of the config)\n",
+		formatCost(model.PricePerKWh, model.Currency), model.WattsPerCore, model.WattsPerGiB, model.WattsPerTiB)
+}
+
+func formatCost(cost float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", cost, currency)
+}
+
+// prometheusUsage reads usage history from the prometheus module's cAdvisor
+// metrics through the API server's service proxy
+type prometheusUsage struct {
+	client    k8s.KubernetesClient
+	namespace string
+}
+
+func (p prometheusUsage) podUsage(ctx context.Context, namespaces []string, period time.Duration) ([]podUsage, error) {
+	selector := fmt.Sprintf(`container!="",container!="POD",namespace=~"%s"`, strings.Join(namespaces, "|"))
+	window := fmt.Sprintf("%ds", int64(period.Seconds()))
+
+	// CPU seconds used, and memory sampled every 5 minutes as byte-seconds
+	cpu, err := p.query(ctx, fmt.Sprintf(`sum by (namespace, pod) (increase(container_cpu_usage_seconds_total{%s}[%s]))`, selector, window))
+	if err != nil {
+		return nil, err
+	}
+	memory, err := p.query(ctx, fmt.Sprintf(`sum by (namespace, pod) (sum_over_time(container_memory_working_set_bytes{%s}[%s:5m])) * 300`, selector, window))
+	if err != nil {
+		return nil, err
+	}
+
+	byPod := make(map[string]*podUsage)
+	usage := func(namespace, pod string) *podUsage {
+		key := namespace + "/" + pod
+		if byPod[key] == nil {
+			byPod[key] = &podUsage{namespace: namespace, pod: pod}
+		}
+		return byPod[key]
+	}
+	for _, s := range cpu {
+		usage(s.namespace, s.pod).coreHours += s.value / 3600
+	}
+	for _, s := range memory {
+		usage(s.namespace, s.pod).gibHours += s.value / 3600 / (1 << 30)
+	}
+
+	pods := make([]podUsage, 0, len(byPod))
+	for _, u := range byPod {
+		pods = append(pods, *u)
+	}
+	return pods, nil
+}
+
+// podSample is one series of an instant query result grouped by pod
+type podSample struct {
+	namespace string
+	pod       string
+	value     float64
+}
+
+func (p prometheusUsage) query(ctx context.Context, query string) ([]podSample, error) {
+	body, err := p.client.CoreV1().Services(p.namespace).ProxyGet("http", "prometheus", "9090", "/api/v1/query", map[string]string{"query": query}).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  []interface{}     `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode query response: %w", err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s", response.Error)
+	}
+
+	samples := make([]podSample, 0, len(response.Data.Result))
+	for _, r := range response.Data.Result {
+		if len(r.Value) != 2 {
+			continue
+		}
+		text, _ := r.Value[1].(string)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, podSample{namespace: r.Metric["namespace"], pod: r.Metric["pod"], value: value})
+	}
+	return samples, nil
+}
+
+// metricsServerUsage reads current usage from the metrics.k8s.io API and
+// assumes it held for the whole period
+type metricsServerUsage struct {
+	get func(ctx context.Context, path string) ([]byte, error)
+}
+
+func (m metricsServerUsage) podUsage(ctx context.Context, namespaces []string, period time.Duration) ([]podUsage, error) {
+	var pods []podUsage
+	for _, namespace := range namespaces {
+		body, err := m.get(ctx, fmt.Sprintf("/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods", namespace))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod metrics in %s (is metrics-server installed?): %w", namespace, err)
+		}
+		var list struct {
+			Items []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Containers []struct {
+					Usage map[string]string `json:"usage"`
+				} `json:"containers"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("failed to decode pod metrics in %s: %w", namespace, err)
+		}
+
+		for _, item := range list.Items {
+			u := podUsage{namespace: namespace, pod: item.Metadata.Name}
+			for _, c := range item.Containers {
+				if cpu, err := resource.ParseQuantity(c.Usage["cpu"]); err == nil {
+					u.coreHours += cpu.AsApproximateFloat64() * period.Hours()
+				}
+				if memory, err := resource.ParseQuantity(c.Usage["memory"]); err == nil {
+					u.gibHours += memory.AsApproximateFloat64() / (1 << 30) * period.Hours()
+				}
+			}
+			pods = append(pods, u)
+		}
+	}
+	return pods, nil
+}
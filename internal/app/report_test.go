@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWorkloadName(t *testing.T) {
+	tests := map[string]string{
+		"gitea-7d9c8f5b4-x2k7q":               "gitea",
+		"postgres-exporter-6b5d9c7f8-lq4zt":   "postgres-exporter",
+		"postgres-replica-0":                  "postgres-replica",
+		"postgres-maintenance-28912345-bv7x9": "postgres-maintenance",
+		"redis":                               "redis",
+		"ssh-login-notifier":                  "ssh-login-notifier",
+	}
+	for pod, want := range tests {
+		if got := workloadName(pod); got != want {
+			t.Errorf("workloadName(%q) = %q, want %q", pod, got, want)
+		}
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "xd", wantErr: true},
+		{in: "10m", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePeriod(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePeriod(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUsageReport(t *testing.T) {
+	model := reportModel(config.ReportConfig{PricePerKWh: 0.5})
+	pods := []podUsage{
+		{namespace: "infra", pod: "gitea-7d9c8f5b4-x2k7q", coreHours: 50, gibHours: 500},
+		{namespace: "infra", pod: "gitea-7d9c8f5b4-m8n2p", coreHours: 50, gibHours: 500},
+		{namespace: "hobby", pod: "bot-5f6d7c8b9-q2w4r", coreHours: 1},
+	}
+	storage := map[string]float64{"infra/gitea": 1024}
+
+	rows := usageReport(pods, storage, 100*time.Hour, model)
+	if len(rows) != 2 || rows[0].workload != "gitea" || rows[1].workload != "bot" {
+		t.Fatalf("rows = %+v, want gitea then bot", rows)
+	}
+	// 100 core-hours at 8 W, 1000 GiB-hours at 0.4 W, 1 TiB for 100 hours at 1.5 W
+	wantKWh := (100*8 + 1000*0.4 + 1.5*100) / 1000.0
+	if math.Abs(rows[0].kWh-wantKWh) > 1e-9 || math.Abs(rows[0].cost-wantKWh*0.5) > 1e-9 {
+		t.Errorf("gitea kWh = %v cost = %v, want %v and %v", rows[0].kWh, rows[0].cost, wantKWh, wantKWh*0.5)
+	}
+}
+
+func TestPrometheusUsage(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var queries []string
+	client.PrependProxyReactor("services", func(action k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+		proxy := action.(k8stesting.ProxyGetAction)
+		if proxy.GetNamespace() != "monitoring" || proxy.GetName() != "prometheus" || proxy.GetPath() != "/api/v1/query" {
+			t.Errorf("proxied to %s/%s%s, want monitoring/prometheus/api/v1/query", proxy.GetNamespace(), proxy.GetName(), proxy.GetPath())
+		}
+		query := proxy.GetParams()["query"]
+		queries = append(queries, query)
+		value := "7200"
+		if strings.Contains(query, "memory") {
+			value = "7730941132800" // 2 GiB for an hour
+		}
+		return true, fakeResponse(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"namespace":"infra","pod":"gitea-0"},"value":[1700000000,"` + value + `"]}]}}`), nil
+	})
+
+	pods, err := prometheusUsage{client: client, namespace: "monitoring"}.podUsage(context.Background(), []string{"hobby", "infra"}, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("podUsage() error = %v", err)
+	}
+	if len(pods) != 1 || pods[0].coreHours != 2 || math.Abs(pods[0].gibHours-2) > 1e-9 {
+		t.Errorf("pods = %+v, want gitea-0 with 2 core-hours and 2 GiB-hours", pods)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], `namespace=~"hobby|infra"`) || !strings.Contains(queries[0], "[2592000s]") {
+		t.Errorf("queries = %q, want both namespaces over 30 days", queries)
+	}
+}
+
+func TestMetricsServerUsage(t *testing.T) {
+	source := metricsServerUsage{get: func(ctx context.Context, path string) ([]byte, error) {
+		if path != "/apis/metrics.k8s.io/v1beta1/namespaces/infra/pods" {
+			t.Errorf("path = %s", path)
+		}
+		return []byte(`{"items":[{"metadata":{"name":"gitea-0"},"containers":[{"usage":{"cpu":"250m","memory":"512Mi"}},{"usage":{"cpu":"250m","memory":"512Mi"}}]}]}`), nil
+	}}
+
+	pods, err := source.podUsage(context.Background(), []string{"infra"}, 10*time.Hour)
+	if err != nil {
+		t.Fatalf("podUsage() error = %v", err)
+	}
+	if len(pods) != 1 || pods[0].coreHours != 5 || pods[0].gibHours != 10 {
+		t.Errorf("pods = %+v, want 5 core-hours and 10 GiB-hours", pods)
+	}
+}
+
+func TestWorkloadStorage(t *testing.T) {
+	claim := func(name string, labels map[string]string, size string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "infra", Labels: labels},
+			Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			}},
+		}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitea-7d9c8f5b4-x2k7q", Namespace: "infra"},
+		Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name:         "data",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "gitea-data"}},
+		}}},
+	}
+	client := fake.NewSimpleClientset(
+		claim("gitea-data", nil, "10Gi"),
+		claim("redis-data-pvc", map[string]string{"app": "redis"}, "1Gi"),
+		claim("scratch", nil, "512Mi"),
+		pod,
+	)
+
+	storage, err := workloadStorage(context.Background(), client, []string{"infra"})
+	if err != nil {
+		t.Fatalf("workloadStorage() error = %v", err)
+	}
+	want := map[string]float64{"infra/gitea": 10, "infra/redis": 1, "infra/scratch": 0.5}
+	for key, gib := range want {
+		if storage[key] != gib {
+			t.Errorf("storage[%s] = %v, want %v", key, storage[key], gib)
+		}
+	}
+}
+
+// fakeResponse is a proxied response body
+type fakeResponse string
+
+func (r fakeResponse) DoRaw(context.Context) ([]byte, error) {
+	return []byte(r), nil
+}
+
+func (r fakeResponse) Stream(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(r))), nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+// handleRestartCommand restarts the pods of the module's Deployment with a
+// rolling restart and records it in the module's history
+func (a *App) handleRestartCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	restartCmd := flag.NewFlagSet("restart", flag.ContinueOnError)
+	restartCmd.SetOutput(io.Discard)
+	wait := restartCmd.Bool("wait", false, "Wait for the rollout to complete")
+	timeout := restartCmd.Duration("timeout", defaultReadyTimeout, "How long --wait waits for the rollout")
+	usage := fmt.Sprintf("usage: %s %s restart [--wait] [--timeout 5m]", Name, name)
+	if err := restartCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if restartCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	deployment := modules.DeploymentName(module)
+	if deployment == "" {
+		return fmt.Errorf("%s has no workload to restart", name)
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if !*wait {
+		*timeout = 0
+	}
+	return a.restart(ctx, client, namespace, deployment, modules.AppLabel(module), *timeout)
+}
+
+// restart patches the Deployment to roll out new pods and, with a timeout,
+// waits up to that long for the rollout to complete
+func (a *App) restart(ctx context.Context, client k8s.KubernetesClient, namespace, deployment, appLabel string, timeout time.Duration) error {
+	prior, err := readHistory(ctx, client, namespace, deployment)
+	if err != nil {
+		a.logger.Warn("Failed to read the history of %s: %v\n", deployment, err)
+	}
+	if err := k8s.RestartDeployment(ctx, client, namespace, deployment, time.Now()); err != nil {
+		return err
+	}
+	entry := historyEntry{Action: historyActionRestart, By: historyUser()}
+	if err := recordHistory(ctx, client, namespace, deployment, prior, entry); err != nil {
+		a.logger.Warn("Failed to record the restart in the history of %s: %v\n", deployment, err)
+	}
+	if timeout <= 0 {
+		a.logger.Success("Restart of deployment %s initiated\n", deployment)
+		return nil
+	}
+
+	a.logger.Progress("Waiting for deployment %s to roll out...\n", deployment)
+	if err := waitForDeployment(ctx, client, namespace, deployment, appLabel, timeout); err != nil {
+		return err
+	}
+	a.logger.Success("Deployment %s restarted\n", deployment)
+	return nil
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestart(t *testing.T) {
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	client := fake.NewSimpleClientset(deployment)
+	var out strings.Builder
+	app := &App{logger: logger.NewStdLogger(&out)}
+
+	if err := app.restart(context.Background(), client, "infra", "gitea", "gitea", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	live, err := client.AppsV1().Deployments("infra").Get(context.Background(), "gitea", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Spec.Template.Annotations[k8s.RestartedAtAnnotation] == "" {
+		t.Error("restartedAt annotation not set")
+	}
+	entries, err := parseHistory(live.Annotations[historyAnnotation])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != historyActionRestart {
+		t.Errorf("history = %+v, want one restart entry", entries)
+	}
+	if !strings.Contains(out.String(), "Deployment gitea restarted") {
+		t.Errorf("output = %q, want the rollout to be waited for", out.String())
+	}
+
+	if err := app.restart(context.Background(), client, "infra", "redis", "redis", 0); err == nil {
+		t.Error("expected an error for a missing Deployment")
+	}
+}
@@ -0,0 +1,324 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/modules"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	runContainerName = "run"
+	// runJobTTL removes finished Jobs the command could not delete itself,
+	// e.g. after losing the connection
+	runJobTTL = int32(3600)
+)
+
+// runPollInterval is how often run checks on the Job's pod
+var runPollInterval = time.Second
+
+// runOptions are the flags shared by run and <module> run-job
+type runOptions struct {
+	image   string
+	command []string
+	env     []corev1.EnvVar
+	timeout time.Duration
+	keep    bool
+}
+
+// runFlags registers the flags of runOptions on fs. The command is --command,
+// run with sh -c, or else the arguments after the flags.
+func runFlags(fs *flag.FlagSet, opts *runOptions, script *string) {
+	fs.StringVar(&opts.image, "image", "", "Container image")
+	fs.StringVar(script, "command", "", "Shell command run with sh -c")
+	fs.Func("env", "Environment variable NAME=VALUE, repeatable", func(value string) error {
+		name, val, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("want NAME=VALUE")
+		}
+		opts.env = append(opts.env, corev1.EnvVar{Name: name, Value: val})
+		return nil
+	})
+	fs.DurationVar(&opts.timeout, "timeout", 0, "Stop the job after this long, e.g. 30m (default: no limit)")
+	fs.BoolVar(&opts.keep, "keep", false, "Keep the Job and its pod after it finishes")
+}
+
+// parseRunArgs parses the flags and command of run and <module> run-job
+func parseRunArgs(fs *flag.FlagSet, opts *runOptions, script *string, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *script != "" && fs.NArg() > 0 {
+		return fmt.Errorf("give the command either with --command or after the flags, not both")
+	}
+	if *script != "" {
+		opts.command = []string{"sh", "-c", *script}
+	} else if fs.NArg() > 0 {
+		opts.command = fs.Args()
+	}
+	if opts.timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	return nil
+}
+
+// handleRunCommand runs a one-off Job from an image, e.g.
+// personal-server run --image alpine --command 'du -sh /data'
+func (a *App) handleRunCommand(ctx context.Context, args []string) error {
+	runCmd := flag.NewFlagSet("run", flag.ContinueOnError)
+	runCmd.SetOutput(io.Discard)
+	var opts runOptions
+	var script string
+	runFlags(runCmd, &opts, &script)
+	namespace := runCmd.String("namespace", "default", "Namespace the Job runs in")
+	usage := fmt.Sprintf("usage: %s run --image IMAGE [--namespace NS] [--env NAME=VALUE]... [--timeout 30m] [--keep] [--command 'CMD' | -- command...]", Name)
+	if err := parseRunArgs(runCmd, &opts, &script, args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if opts.image == "" {
+		return fmt.Errorf("%s: --image is required", usage)
+	}
+
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	logClient, err := a.clients.Streaming()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	spec := corev1.PodSpec{Containers: []corev1.Container{{Name: runContainerName}}}
+	return a.runJob(ctx, client, logClient, *namespace, "run", spec, opts)
+}
+
+// handleRunJobCommand runs a one-off Job with the container of the module's
+// Deployment: its image, environment and volumes, so e.g. migrations see the
+// same configuration as the app
+func (a *App) handleRunJobCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	runCmd := flag.NewFlagSet("run-job", flag.ContinueOnError)
+	runCmd.SetOutput(io.Discard)
+	var opts runOptions
+	var script string
+	runFlags(runCmd, &opts, &script)
+	usage := fmt.Sprintf("usage: %s %s run-job [--image IMAGE] [--env NAME=VALUE]... [--timeout 30m] [--keep] [--command 'CMD' | -- command...]", Name, name)
+	if err := parseRunArgs(runCmd, &opts, &script, args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if opts.command == nil {
+		return fmt.Errorf("%s: a command is required", usage)
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	logClient, err := a.clients.Streaming()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	deploymentName := modules.DeploymentName(module)
+	deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("deployment '%s' not found in namespace '%s'; run '%s %s apply' first", deploymentName, namespace, Name, name)
+		}
+		return fmt.Errorf("failed to get deployment '%s': %w", deploymentName, err)
+	}
+	return a.runJob(ctx, client, logClient, namespace, name, moduleRunSpec(&deployment.Spec.Template.Spec), opts)
+}
+
+// moduleRunSpec returns a pod spec running the first container of a
+// Deployment's pod spec without what only a long-running server needs:
+// probes, ports, lifecycle hooks and sidecars
+func moduleRunSpec(template *corev1.PodSpec) corev1.PodSpec {
+	spec := *template.DeepCopy()
+	container := spec.Containers[0]
+	container.Name = runContainerName
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Lifecycle = nil
+	container.Ports = nil
+	spec.Containers = []corev1.Container{container}
+	spec.InitContainers = nil
+	return spec
+}
+
+// runJob runs spec's container as a Job named after base, with opts applied,
+// prints its logs and returns an ExitError carrying its exit code when it
+// fails. The Job is deleted afterwards, also when interrupted, unless
+// opts.keep is set. The logs are followed through logClient, which must not
+// time out before the Job finishes.
+func (a *App) runJob(ctx context.Context, client, logClient k8s.KubernetesClient, namespace, base string, spec corev1.PodSpec, opts runOptions) error {
+	container := &spec.Containers[0]
+	if opts.image != "" {
+		container.Image = opts.image
+		container.ImagePullPolicy = k8s.DefaultImagePullPolicy(opts.image)
+	}
+	if opts.command != nil {
+		container.Command = opts.command
+		container.Args = nil
+	}
+	for _, env := range opts.env {
+		setEnv(container, env)
+	}
+	spec.RestartPolicy = corev1.RestartPolicyNever
+
+	backoffLimit := int32(0)
+	ttl := runJobTTL
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-run-%s", base, utilrand.String(5)),
+			Namespace: namespace,
+			Labels:    map[string]string{k8s.ManagedByLabel: k8s.ManagedByValue},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				Spec: spec,
+			},
+		},
+	}
+	if opts.timeout > 0 {
+		seconds := int64(opts.timeout.Seconds())
+		job.Spec.ActiveDeadlineSeconds = &seconds
+	}
+
+	if _, err := client.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	a.logger.Info("Started Job %s/%s (%s)\n", namespace, job.Name, container.Image)
+	if !opts.keep {
+		defer func() {
+			// Also runs when interrupted, so the cleanup gets its own context
+			propagation := metav1.DeletePropagationBackground
+			err := client.BatchV1().Jobs(namespace).Delete(context.Background(), job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+			if err != nil && !apierrors.IsNotFound(err) {
+				a.logger.Warn("Failed to delete Job %s: %v\n", job.Name, err)
+			}
+		}()
+	}
+
+	pod, err := waitForRunPod(ctx, client, namespace, job.Name, func(pod *corev1.Pod) bool {
+		state := runContainerState(pod)
+		return state.Running != nil || state.Terminated != nil
+	})
+	if err != nil {
+		return err
+	}
+
+	stream := logStream{pod: pod.Name, container: runContainerName}
+	if err := copyLogs(ctx, logClient, namespace, stream, corev1.PodLogOptions{Follow: true}, false, a.stdout, nil); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// The log stream can end a moment before the container is reported
+	// terminated
+	pod, err = waitForRunPod(ctx, client, namespace, job.Name, func(pod *corev1.Pod) bool {
+		return runContainerState(pod).Terminated != nil
+	})
+	if err != nil {
+		return err
+	}
+	terminated := runContainerState(pod).Terminated
+	if terminated.ExitCode != 0 {
+		reason := ""
+		if terminated.Reason != "" && terminated.Reason != "Error" {
+			reason = " (" + terminated.Reason + ")"
+		}
+		return &ExitError{
+			Code: int(terminated.ExitCode),
+			Err:  fmt.Errorf("job %s failed with exit code %d%s", job.Name, terminated.ExitCode, reason),
+		}
+	}
+	a.logger.Success("Job %s completed\n", job.Name)
+	return nil
+}
+
+// setEnv sets env on container, replacing a variable of the same name
+func setEnv(container *corev1.Container, env corev1.EnvVar) {
+	for i := range container.Env {
+		if container.Env[i].Name == env.Name {
+			container.Env[i] = env
+			return
+		}
+	}
+	container.Env = append(container.Env, env)
+}
+
+// runContainerState returns the state of the run container of pod
+func runContainerState(pod *corev1.Pod) corev1.ContainerState {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == runContainerName {
+			return status.State
+		}
+	}
+	return corev1.ContainerState{}
+}
+
+// runStartFailures are the waiting reasons of a container that will not
+// start without a change, e.g. to the image name
+var runStartFailures = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// waitForRunPod waits for the pod of a run Job to satisfy done. It fails when
+// the container cannot start or the Job fails without the pod getting there,
+// e.g. on its deadline.
+func waitForRunPod(ctx context.Context, client k8s.KubernetesClient, namespace, job string, done func(*corev1.Pod) bool) (*corev1.Pod, error) {
+	for {
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "job-name=" + job})
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("failed to list pods of job %s: %w", job, err)
+		}
+		if err == nil {
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				if done(pod) {
+					return pod, nil
+				}
+				if waiting := runContainerState(pod).Waiting; waiting != nil && runStartFailures[waiting.Reason] {
+					return nil, fmt.Errorf("job %s cannot start: %s: %s", job, waiting.Reason, waiting.Message)
+				}
+			}
+		}
+
+		j, err := client.BatchV1().Jobs(namespace).Get(ctx, job, metav1.GetOptions{})
+		if err == nil {
+			for _, condition := range j.Status.Conditions {
+				if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+					return nil, &ExitError{Code: 1, Err: fmt.Errorf("job %s failed: %s %s", job, condition.Reason, condition.Message)}
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(runPollInterval):
+		}
+	}
+}
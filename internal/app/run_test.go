@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newRunTestClient returns a client on which every created Job gets a pod in
+// the given container state, as the Job controller would create it
+func newRunTestClient(t *testing.T, state corev1.ContainerState) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: job.Name + "-abcde", Namespace: job.Namespace, Labels: map[string]string{"job-name": job.Name}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: runContainerName, State: state},
+			}},
+		}
+		if err := client.Tracker().Add(pod); err != nil {
+			t.Fatal(err)
+		}
+		return false, nil, nil
+	})
+	return client
+}
+
+func TestRunJob(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    corev1.ContainerState
+		keep     bool
+		wantCode int
+		wantErr  string
+	}{
+		{name: "succeeds", state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+		{name: "keeps the job", state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}, keep: true},
+		{name: "exit code", state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 3, Reason: "Error"}}, wantCode: 3, wantErr: "failed with exit code 3"},
+		{name: "killed", state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}}, wantCode: 137, wantErr: "exit code 137 (OOMKilled)"},
+		{name: "image pull", state: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"}}, wantErr: "cannot start: ImagePullBackOff: not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRunTestClient(t, tt.state)
+			var out strings.Builder
+			app := &App{logger: logger.NewNopLogger(), stdout: &out}
+			spec := corev1.PodSpec{Containers: []corev1.Container{{Name: runContainerName}}}
+			opts := runOptions{image: "alpine:3.20", command: []string{"sh", "-c", "exit 3"}, keep: tt.keep}
+
+			err := app.runJob(context.Background(), client, client, "tools", "run", spec, opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runJob() error = %v, want %q", err, tt.wantErr)
+				}
+				var exitErr *ExitError
+				if tt.wantCode != 0 && (!errors.As(err, &exitErr) || exitErr.Code != tt.wantCode) {
+					t.Errorf("runJob() error = %#v, want exit code %d", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if tt.state.Terminated != nil && out.String() != "fake logs\n" {
+				t.Errorf("output = %q, want the job's logs", out.String())
+			}
+
+			jobs, err := client.BatchV1().Jobs("tools").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.keep {
+				if len(jobs.Items) != 0 {
+					t.Errorf("jobs = %d, want the job deleted", len(jobs.Items))
+				}
+				return
+			}
+			if len(jobs.Items) != 1 {
+				t.Fatalf("jobs = %d, want 1", len(jobs.Items))
+			}
+			job := jobs.Items[0]
+			if !strings.HasPrefix(job.Name, "run-run-") {
+				t.Errorf("job name = %q", job.Name)
+			}
+			if *job.Spec.BackoffLimit != 0 || job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+				t.Errorf("job must run once, got backoff %d, restart %s", *job.Spec.BackoffLimit, job.Spec.Template.Spec.RestartPolicy)
+			}
+			container := job.Spec.Template.Spec.Containers[0]
+			if container.Image != "alpine:3.20" || !reflect.DeepEqual(container.Command, opts.command) {
+				t.Errorf("container = %s %v", container.Image, container.Command)
+			}
+		})
+	}
+}
+
+func TestRunJob_FollowOutlivesRequestTimeout(t *testing.T) {
+	client := newRunTestClient(t, corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}})
+	kubeconfig := newSlowLogServer(t, 300*time.Millisecond)
+	logClient, err := k8s.NewClients(k8s.ClientOptions{Kubeconfig: kubeconfig, Timeout: 100 * time.Millisecond}).Streaming()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	app := &App{logger: logger.NewNopLogger(), stdout: &out}
+	spec := corev1.PodSpec{Containers: []corev1.Container{{Name: runContainerName}}}
+	opts := runOptions{image: "alpine:3.20", command: []string{"sleep", "1"}}
+
+	if err := app.runJob(context.Background(), client, logClient, "tools", "run", spec, opts); err != nil {
+		t.Fatalf("runJob() error: %v", err)
+	}
+	if out.String() != "first\nsecond\n" {
+		t.Errorf("output = %q, want both lines", out.String())
+	}
+}
+
+func TestModuleRunSpec(t *testing.T) {
+	template := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init"}},
+		Containers: []corev1.Container{
+			{
+				Name:           "gitea",
+				Image:          "gitea/gitea:1.22",
+				Env:            []corev1.EnvVar{{Name: "DB_HOST", Value: "postgres"}, {Name: "MODE", Value: "prod"}},
+				Ports:          []corev1.ContainerPort{{ContainerPort: 3000}},
+				ReadinessProbe: &corev1.Probe{},
+				VolumeMounts:   []corev1.VolumeMount{{Name: "data", MountPath: "/data"}},
+			},
+			{Name: "backup"},
+		},
+		Volumes: []corev1.Volume{{Name: "data"}},
+	}
+	spec := moduleRunSpec(template)
+	if len(spec.Containers) != 1 || spec.InitContainers != nil {
+		t.Fatalf("containers = %+v, init = %+v, want only the main container", spec.Containers, spec.InitContainers)
+	}
+	container := spec.Containers[0]
+	if container.Name != runContainerName || container.Image != "gitea/gitea:1.22" {
+		t.Errorf("container = %s %s", container.Name, container.Image)
+	}
+	if container.Ports != nil || container.ReadinessProbe != nil {
+		t.Errorf("ports and probes must be dropped, got %+v", container)
+	}
+	if len(container.VolumeMounts) != 1 || len(spec.Volumes) != 1 {
+		t.Errorf("volumes must be kept, got %+v and %+v", container.VolumeMounts, spec.Volumes)
+	}
+	if template.Containers[0].Name != "gitea" {
+		t.Error("moduleRunSpec changed the template")
+	}
+
+	setEnv(&container, corev1.EnvVar{Name: "MODE", Value: "migrate"})
+	setEnv(&container, corev1.EnvVar{Name: "DEBUG", Value: "1"})
+	want := []corev1.EnvVar{{Name: "DB_HOST", Value: "postgres"}, {Name: "MODE", Value: "migrate"}, {Name: "DEBUG", Value: "1"}}
+	if !reflect.DeepEqual(container.Env, want) {
+		t.Errorf("env = %+v, want %+v", container.Env, want)
+	}
+}
+
+func TestParseRunArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    runOptions
+		wantErr string
+	}{
+		{name: "shell command", args: []string{"--image", "alpine", "--command", "echo hi"}, want: runOptions{image: "alpine", command: []string{"sh", "-c", "echo hi"}}},
+		{name: "arguments", args: []string{"--timeout", "5m", "--", "rake", "db:migrate"}, want: runOptions{command: []string{"rake", "db:migrate"}, timeout: 5 * time.Minute}},
+		{name: "env", args: []string{"--env", "A=1", "--env", "B=x=y", "--keep"}, want: runOptions{env: []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "x=y"}}, keep: true}},
+		{name: "both commands", args: []string{"--command", "echo", "--", "ls"}, wantErr: "not both"},
+		{name: "bad env", args: []string{"--env", "A"}, wantErr: "want NAME=VALUE"},
+		{name: "negative timeout", args: []string{"--timeout", "-1s"}, wantErr: "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("run", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			var opts runOptions
+			var script string
+			runFlags(fs, &opts, &script)
+			err := parseRunArgs(fs, &opts, &script, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseRunArgs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(opts, tt.want) {
+				t.Errorf("options = %+v, want %+v", opts, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleRunCommandRequiresImage(t *testing.T) {
+	app := &App{logger: logger.NewNopLogger()}
+	err := app.handleRunCommand(context.Background(), []string{"--command", "echo hi"})
+	if err == nil || !strings.Contains(err.Error(), "--image is required") {
+		t.Fatalf("handleRunCommand() error = %v, want --image is required", err)
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/modules"
+)
+
+// handleScaleCommand sets the replicas of the module's Deployment; scale 0
+// suspends the module until it is scaled up again
+func (a *App) handleScaleCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	scaleCmd := flag.NewFlagSet("scale", flag.ContinueOnError)
+	scaleCmd.SetOutput(io.Discard)
+	wait := scaleCmd.Bool("wait", false, "Wait for the new replicas to become ready")
+	timeout := scaleCmd.Duration("timeout", defaultReadyTimeout, "How long --wait waits for the replicas")
+	usage := fmt.Sprintf("usage: %s %s scale <replicas> [--wait] [--timeout 5m]", Name, name)
+	if err := scaleCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if scaleCmd.NArg() == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	// Flags may also follow the replica count, e.g. scale 2 --wait
+	count := scaleCmd.Arg(0)
+	if err := scaleCmd.Parse(scaleCmd.Args()[1:]); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if scaleCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	replicas, err := strconv.ParseInt(count, 10, 32)
+	if err != nil || replicas < 0 {
+		return fmt.Errorf("invalid replica count %q: must be a whole number of at least 0", count)
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	deployment := modules.DeploymentName(module)
+	if deployment == "" {
+		return fmt.Errorf("%s has no workload to scale", name)
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if !*wait {
+		*timeout = 0
+	}
+	return a.scale(ctx, client, namespace, deployment, modules.AppLabel(module), int32(replicas), *timeout)
+}
+
+// scale sets the replicas of deployment and, with a timeout, waits up to
+// that long for them to become ready
+func (a *App) scale(ctx context.Context, client k8s.KubernetesClient, namespace, deployment, appLabel string, replicas int32, timeout time.Duration) error {
+	previous, err := k8s.ScaleDeployment(ctx, client, namespace, deployment, replicas)
+	if err != nil {
+		return err
+	}
+	switch {
+	case previous == replicas:
+		a.logger.Info("Deployment %s already runs %d replicas\n", deployment, replicas)
+		return nil
+	case replicas == 0:
+		a.logger.Success("Deployment %s suspended (was %d replicas); scale it up again to resume\n", deployment, previous)
+		return nil
+	default:
+		a.logger.Success("Deployment %s scaled from %d to %d replicas\n", deployment, previous, replicas)
+	}
+	if timeout <= 0 {
+		return nil
+	}
+
+	a.logger.Progress("Waiting for deployment %s to become ready...\n", deployment)
+	if err := waitForDeployment(ctx, client, namespace, deployment, appLabel, timeout); err != nil {
+		return err
+	}
+	a.logger.Success("Deployment %s is ready\n", deployment)
+	return nil
+}
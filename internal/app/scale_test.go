@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas int32
+		timeout  time.Duration
+		want     string
+	}{
+		{name: "suspend", replicas: 0, want: "Deployment hobby-pod suspended (was 1 replicas)"},
+		{name: "unchanged", replicas: 1, want: "already runs 1 replicas"},
+		{name: "scale up and wait", replicas: 2, timeout: time.Second, want: "Deployment hobby-pod is ready"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "hobby-pod", Namespace: "hobby"},
+				Spec:       appsv1.DeploymentSpec{Replicas: k8s.Int32Ptr(1)},
+				// The fake client runs no controller, so the status already
+				// shows the replicas scale up waits for
+				Status: appsv1.DeploymentStatus{UpdatedReplicas: 2, AvailableReplicas: 2},
+			})
+			var out strings.Builder
+			app := &App{logger: logger.NewStdLogger(&out)}
+			if err := app.scale(context.Background(), client, "hobby", "hobby-pod", "hobby-pod", tt.replicas, tt.timeout); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleScaleCommand_Usage(t *testing.T) {
+	app := &App{}
+	module := basicHelpTestModule{name: "hobby-pod"}
+	for _, args := range [][]string{nil, {"-1"}, {"two"}, {"1", "2"}, {"1", "--unknown"}} {
+		if err := app.handleScaleCommand(context.Background(), nil, "hobby-pod", module, args); err == nil {
+			t.Errorf("scale %q: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// generatedSecretLength is the length of the values secrets generate writes:
+// 32 letters and digits are about 190 bits
+const generatedSecretLength = 32
+
+// Drift of one Secret key between the config and the cluster
+const (
+	secretInSync        = "in sync"
+	secretDiffers       = "differs"
+	secretNotInCluster  = "not in cluster"
+	secretOnlyInCluster = "only in cluster"
+)
+
+var secretGVR = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// secretKeyDrift compares one key of a Secret. A nil value means the key is
+// absent on that side.
+type secretKeyDrift struct {
+	Key     string
+	Status  string
+	Config  []byte
+	Cluster []byte
+}
+
+// secretDrift compares a Secret a module generates from the config with the
+// live one
+type secretDrift struct {
+	Module    string
+	Name      string
+	Namespace string
+	// Missing is set when the Secret does not exist in the cluster
+	Missing bool
+	Keys    []secretKeyDrift
+}
+
+func (a *App) handleSecretsCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s secrets <generate <module>|list|get <module> [--reveal]>", Name)
+	}
+
+	switch args[0] {
+	case "generate":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s secrets generate <module>", Name)
+		}
+		return a.handleSecretsGenerate(cfg, args[1])
+	case "list":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s secrets list", Name)
+		}
+		return a.handleSecretsList(ctx, cfg)
+	case "get":
+		getCmd := flag.NewFlagSet("secrets get", flag.ContinueOnError)
+		getCmd.SetOutput(io.Discard)
+		reveal := getCmd.Bool("reveal", false, "Print secret values instead of their hashes")
+		if len(args) < 2 {
+			return fmt.Errorf("usage: %s secrets get <module> [--reveal]", Name)
+		}
+		if err := getCmd.Parse(args[2:]); err != nil || getCmd.NArg() > 0 {
+			return fmt.Errorf("usage: %s secrets get <module> [--reveal]", Name)
+		}
+		return a.handleSecretsGet(ctx, cfg, args[1], *reveal)
+	default:
+		return fmt.Errorf("unknown secrets subcommand: %s\nAvailable subcommands: generate, list, get", args[0])
+	}
+}
+
+// handleSecretsList prints a drift summary of the Secrets every configured
+// module generates
+func (a *App) handleSecretsList(ctx context.Context, cfg *config.Config) error {
+	dyn, err := a.clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	var drifts []secretDrift
+	for _, m := range cfg.Modules {
+		objects, err := a.renderQuiet(ctx, cfg, m.Name)
+		if err != nil {
+			a.logger.Warn("Skipping %v\n", err)
+			continue
+		}
+		moduleDrifts, err := compareSecrets(ctx, dyn, m.Name, objects)
+		if err != nil {
+			return err
+		}
+		drifts = append(drifts, moduleDrifts...)
+	}
+	if len(drifts) == 0 {
+		a.logger.Info("No configured module generates Secrets\n")
+		return nil
+	}
+	a.printSecretList(drifts)
+	return nil
+}
+
+// handleSecretsGet prints every key of the Secrets the module generates, with
+// hashes of the config and cluster values, or the values themselves with
+// reveal
+func (a *App) handleSecretsGet(ctx context.Context, cfg *config.Config, name string, reveal bool) error {
+	objects, err := a.renderQuiet(ctx, cfg, name)
+	if err != nil {
+		return err
+	}
+	dyn, err := a.clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	drifts, err := compareSecrets(ctx, dyn, name, objects)
+	if err != nil {
+		return err
+	}
+	if len(drifts) == 0 {
+		a.logger.Info("Module '%s' generates no Secrets\n", name)
+		return nil
+	}
+	a.printSecretKeys(drifts, reveal)
+	return nil
+}
+
+// compareSecrets compares the Secrets among objects with the cluster, key
+// by key
+func compareSecrets(ctx context.Context, dyn dynamic.Interface, module string, objects []*unstructured.Unstructured) ([]secretDrift, error) {
+	var drifts []secretDrift
+	for _, object := range objects {
+		if object.GetKind() != "Secret" {
+			continue
+		}
+		desired, err := secretValues(object)
+		if err != nil {
+			return nil, fmt.Errorf("Secret %s: %w", object.GetName(), err)
+		}
+		drift := secretDrift{Module: module, Name: object.GetName(), Namespace: object.GetNamespace()}
+
+		var live map[string][]byte
+		current, err := dyn.Resource(secretGVR).Namespace(object.GetNamespace()).Get(ctx, object.GetName(), metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			drift.Missing = true
+		case err != nil:
+			return nil, fmt.Errorf("failed to get Secret %s: %w", object.GetName(), err)
+		default:
+			if live, err = secretValues(current); err != nil {
+				return nil, fmt.Errorf("live Secret %s: %w", object.GetName(), err)
+			}
+		}
+
+		keys := make(map[string]bool)
+		for key := range desired {
+			keys[key] = true
+		}
+		for key := range live {
+			keys[key] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			want, inConfig := desired[key]
+			got, inCluster := live[key]
+			status := secretInSync
+			switch {
+			case !inCluster:
+				status = secretNotInCluster
+			case !inConfig:
+				status = secretOnlyInCluster
+			case string(want) != string(got):
+				status = secretDiffers
+			}
+			drift.Keys = append(drift.Keys, secretKeyDrift{Key: key, Status: status, Config: want, Cluster: got})
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// secretValues returns the decoded data of a Secret, with stringData merged
+// in the way the API server stores it
+func secretValues(object *unstructured.Unstructured) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	data, _, _ := unstructured.NestedStringMap(object.Object, "data")
+	for key, encoded := range data {
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not base64: %w", key, err)
+		}
+		values[key] = value
+	}
+	stringData, _, _ := unstructured.NestedStringMap(object.Object, "stringData")
+	for key, value := range stringData {
+		values[key] = []byte(value)
+	}
+	return values, nil
+}
+
+// summary describes the drift of a whole Secret in a few words
+func (d secretDrift) summary() string {
+	if d.Missing {
+		return secretNotInCluster
+	}
+	counts := make(map[string]int)
+	for _, key := range d.Keys {
+		counts[key.Status]++
+	}
+	var parts []string
+	for _, status := range []string{secretDiffers, secretNotInCluster, secretOnlyInCluster} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(parts) == 0 {
+		return secretInSync
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (a *App) printSecretList(drifts []secretDrift) {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "MODULE\tSECRET\tNAMESPACE\tKEYS\tDRIFT")
+	for _, d := range drifts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", d.Module, d.Name, d.Namespace, len(d.Keys), d.summary())
+	}
+	w.Flush()
+	a.logger.Print("%s", sb.String())
+}
+
+func (a *App) printSecretKeys(drifts []secretDrift, reveal bool) {
+	var style logger.Style
+	if styled, ok := a.logger.(logger.Styled); ok {
+		style = styled.Style()
+	}
+	for i, d := range drifts {
+		if i > 0 {
+			a.logger.Print("\n")
+		}
+		a.logger.Print("%s\n", style.Paint(logger.Cyan, fmt.Sprintf("Secret/%s (%s): %s", d.Name, d.Namespace, d.summary())))
+		var sb strings.Builder
+		w := tabwriter.NewWriter(&sb, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSTATUS\tCONFIG\tCLUSTER")
+		for _, key := range d.Keys {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", key.Key, key.Status, formatSecretValue(key.Config, reveal), formatSecretValue(key.Cluster, reveal))
+		}
+		w.Flush()
+		a.logger.Print("%s", sb.String())
+	}
+}
+
+// formatSecretValue renders a value as a short SHA-256 prefix, so equal
+// values can be recognized without printing them, or as itself with reveal
+func formatSecretValue(value []byte, reveal bool) string {
+	if value == nil {
+		return "-"
+	}
+	if reveal {
+		return strings.ReplaceAll(string(value), "\n", `\n`)
+	}
+	sum := sha256.Sum256(value)
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// handleSecretsGenerate fills in the module's missing credentials, those its
+// schema tags generate:"true", with random values and saves the config.
+// Values already set are kept, as changing them would lock the module out of
+// its data.
+func (a *App) handleSecretsGenerate(cfg *config.Config, name string) error {
+	moduleCfg, err := cfg.GetModule(name)
+	if err != nil {
+		return err
+	}
+	module, err := a.registry.Get(name, cfg)
+	if err != nil {
+		return err
+	}
+	provider, ok := module.(modules.ConfigSchemaProvider)
+	if !ok {
+		return fmt.Errorf("module %s reads no modules[].secrets keys", name)
+	}
+
+	var generated []string
+	for _, field := range config.Describe(provider.ConfigSchema()) {
+		if !field.Generate || moduleCfg.Secrets[field.Path] != "" {
+			continue
+		}
+		value, err := k8s.GeneratePassword(generatedSecretLength)
+		if err != nil {
+			return err
+		}
+		if err := cfg.SetModuleSecret(name, field.Path, value); err != nil {
+			return fmt.Errorf("editing config: %w", err)
+		}
+		generated = append(generated, field.Path)
+	}
+	if len(generated) == 0 {
+		a.logger.Success("Module '%s' has no missing secrets to generate\n", name)
+		return nil
+	}
+
+	if err := cfg.SaveConfig(); err != nil {
+		return fmt.Errorf("saving config: %w", err)
+	}
+	for _, key := range generated {
+		a.logger.Success("Generated %s for module '%s'\n", key, name)
+	}
+	return nil
+}
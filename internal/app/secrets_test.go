@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestHandleSecretsGenerate(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := `general:
+  domain: example.com
+modules:
+  - name: synapse
+    namespace: matrix
+    secrets:
+      server_name: example.com
+      synapse_db_password: kept
+  - name: webdav
+    namespace: infra
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logBuf strings.Builder
+	log := logger.NewStdLogger(&logBuf)
+	app := &App{logger: log, registry: modules.DefaultRegistry(log)}
+
+	for _, name := range []string{"synapse", "webdav"} {
+		if err := app.handleSecretsGenerate(cfg, name); err != nil {
+			t.Fatalf("handleSecretsGenerate(%s) error = %v", name, err)
+		}
+	}
+	if !strings.Contains(logBuf.String(), "Generated macaroon_secret_key for module 'synapse'") {
+		t.Errorf("output = %q, want the generated keys listed", logBuf.String())
+	}
+
+	saved, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	synapse, _ := saved.GetModule("synapse")
+	if synapse.Secrets["synapse_db_password"] != "kept" || synapse.Secrets["server_name"] != "example.com" {
+		t.Errorf("synapse secrets = %v, want the existing values kept", synapse.Secrets)
+	}
+	for _, key := range []string{"registration_shared_secret", "macaroon_secret_key"} {
+		if len(synapse.Secrets[key]) != generatedSecretLength {
+			t.Errorf("synapse %s = %q, want a generated value", key, synapse.Secrets[key])
+		}
+	}
+	if synapse.Secrets["registration_shared_secret"] == synapse.Secrets["macaroon_secret_key"] {
+		t.Error("generated values are identical")
+	}
+	webdav, _ := saved.GetModule("webdav")
+	if password := webdav.Secrets["webdav_password"]; len(password) != generatedSecretLength {
+		t.Errorf("webdav_password = %q, want a generated value instead of the default", password)
+	}
+
+	logBuf.Reset()
+	if err := app.handleSecretsGenerate(saved, "webdav"); err != nil || !strings.Contains(logBuf.String(), "no missing secrets") {
+		t.Errorf("second run = %v, %q, want nothing to generate", err, logBuf.String())
+	}
+	if err := app.handleSecretsGenerate(saved, "redis"); err == nil {
+		t.Error("handleSecretsGenerate() of a module missing from the config succeeded")
+	}
+}
+
+func TestCompareSecrets(t *testing.T) {
+	ctx := context.Background()
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		secretGVR: "SecretList",
+	})
+	objects, err := k8s.DecodeManifest([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: web-secrets
+  namespace: infra
+data:
+  token: c2VjcmV0
+stringData:
+  password: hunter2
+  user: admin
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drifts, err := compareSecrets(ctx, dyn, "web", objects)
+	if err != nil {
+		t.Fatalf("compareSecrets() error = %v", err)
+	}
+	if len(drifts) != 1 || !drifts[0].Missing || drifts[0].summary() != secretNotInCluster {
+		t.Fatalf("drifts = %+v, want the Secret missing from the cluster", drifts)
+	}
+
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "web-secrets", "namespace": "infra"},
+		"data": map[string]interface{}{
+			"token":    "c2VjcmV0",     // secret
+			"password": "aHVudGVyMw==", // hunter3
+			"extra":    "eA==",
+		},
+	}}
+	if _, err := dyn.Resource(secretGVR).Namespace("infra").Create(ctx, live, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	drifts, err = compareSecrets(ctx, dyn, "web", objects)
+	if err != nil {
+		t.Fatalf("compareSecrets() error = %v", err)
+	}
+	want := map[string]string{"extra": secretOnlyInCluster, "password": secretDiffers, "token": secretInSync, "user": secretNotInCluster}
+	for _, key := range drifts[0].Keys {
+		if key.Status != want[key.Key] {
+			t.Errorf("%s status = %q, want %q", key.Key, key.Status, want[key.Key])
+		}
+	}
+	if summary := drifts[0].summary(); summary != "1 differs, 1 not in cluster, 1 only in cluster" {
+		t.Errorf("summary() = %q", summary)
+	}
+
+	var buf strings.Builder
+	app := &App{logger: logger.NewStdLogger(&buf)}
+	app.printSecretKeys(drifts, false)
+	if out := buf.String(); strings.Contains(out, "hunter") || !strings.Contains(out, formatSecretValue([]byte("hunter2"), false)) {
+		t.Errorf("output = %q, want hashes instead of values", out)
+	}
+	buf.Reset()
+	app.printSecretKeys(drifts, true)
+	if out := buf.String(); !strings.Contains(out, "hunter2") || !strings.Contains(out, "hunter3") {
+		t.Errorf("output = %q, want the values with reveal", out)
+	}
+	buf.Reset()
+	app.printSecretList(drifts)
+	if out := buf.String(); !strings.Contains(out, "web-secrets") || !strings.Contains(out, "1 differs") {
+		t.Errorf("output = %q, want a row per Secret", out)
+	}
+
+	// Revealed values are printed as they are when output is piped, where
+	// the logger replaces its own emoji and dashes with ASCII
+	drifts[0].Keys = []secretKeyDrift{{Key: "motto", Status: secretInSync, Config: []byte("ça va — 🔑"), Cluster: []byte("ça va — 🔑")}}
+	buf.Reset()
+	(&App{logger: logger.NewStyledLogger(&buf, logger.Style{})}).printSecretKeys(drifts, true)
+	if out := buf.String(); strings.Count(out, "ça va — 🔑") != 2 {
+		t.Errorf("output = %q, want the revealed values unchanged", out)
+	}
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// applyKindOrder ranks the kinds apply --server-side creates first, so
+// workloads start with their namespace, secrets, config and volumes in place.
+// Other kinds come after these and before workloads.
+var applyKindOrder = map[string]int{
+	"Namespace":                0,
+	"CustomResourceDefinition": 1,
+	"ServiceAccount":           2,
+	"Secret":                   3,
+	"ConfigMap":                3,
+	"PersistentVolumeClaim":    4,
+	"Deployment":               6,
+	"StatefulSet":              6,
+	"DaemonSet":                6,
+	"CronJob":                  6,
+	"Job":                      7,
+}
+
+func applyRank(object *unstructured.Unstructured) int {
+	if rank, ok := applyKindOrder[object.GetKind()]; ok {
+		return rank
+	}
+	return 5
+}
+
+// serverSideApplier applies generated objects with server-side apply, as an
+// alternative to a module's Apply, which fails on objects that exist
+type serverSideApplier struct {
+	dyn    dynamic.Interface
+	mapper meta.RESTMapper
+	// dryRun reports what would change without changing anything
+	dryRun bool
+}
+
+func newServerSideApplier(clients *k8s.Clients, dryRun bool) (*serverSideApplier, error) {
+	dyn, err := clients.Dynamic()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	mapper, err := clients.RESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	return &serverSideApplier{dyn: dyn, mapper: mapper, dryRun: dryRun}, nil
+}
+
+// apply applies objects in applyKindOrder and logs what changed per object
+func (s *serverSideApplier) apply(ctx context.Context, objects []*unstructured.Unstructured, log logger.Logger) error {
+	if len(objects) == 0 {
+		log.Warn("Nothing to apply: generate writes no objects\n")
+		return nil
+	}
+	ordered := append([]*unstructured.Unstructured(nil), objects...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return applyRank(ordered[i]) < applyRank(ordered[j])
+	})
+
+	suffix := ""
+	if s.dryRun {
+		suffix = " (dry run)"
+	}
+	counts := map[k8s.ServerSideApplyResult]int{}
+	for _, object := range ordered {
+		result, err := k8s.ServerSideApply(ctx, s.dyn, s.mapper, object, k8s.ApplyOptions{FieldManager: Name, DryRun: s.dryRun})
+		if err != nil {
+			return err
+		}
+		counts[result]++
+		switch result {
+		case k8s.Created, k8s.Configured:
+			log.Success("%s/%s %s%s\n", object.GetKind(), object.GetName(), result, suffix)
+		default:
+			log.Info("  %s/%s %s%s\n", object.GetKind(), object.GetName(), result, suffix)
+		}
+	}
+	verb := "Applied"
+	if s.dryRun {
+		verb = "Dry run, nothing changed. Would apply"
+	}
+	log.Info("\n%s %d objects: %d created, %d configured, %d unchanged\n", verb, len(ordered), counts[k8s.Created], counts[k8s.Configured], counts[k8s.Unchanged]+counts[k8s.Skipped])
+	return nil
+}
+
+// renderQuiet renders the component name without its generate output
+func (a *App) renderQuiet(ctx context.Context, cfg *config.Config, name string) ([]*unstructured.Unstructured, error) {
+	module, err := a.registry.WithLogger(logger.NewNopLogger()).Get(name, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	objects, err := a.renderModule(ctx, module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s: %w", name, err)
+	}
+	return objects, nil
+}
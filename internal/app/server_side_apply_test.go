@@ -0,0 +1,121 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const serverSideTestDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: infra
+spec:
+  replicas: 1
+`
+
+func TestServerSideApplierApply(t *testing.T) {
+	registry := modules.NewRegistry(logger.NewNopLogger())
+	registry.Register("web", func(g config.GeneralConfig, m config.Module, log logger.Logger) modules.Module {
+		return generatingTestModule{basicHelpTestModule{name: "web"}, map[string]string{
+			"deployment.yaml": serverSideTestDeployment,
+			"objects.yaml":    operatorTestManifests,
+		}}
+	})
+	app := &App{logger: logger.NewNopLogger(), registry: registry}
+	cfg := &config.Config{Modules: []config.Module{{Name: "web", Namespace: "infra"}}}
+
+	objects, err := app.renderQuiet(context.Background(), cfg, "web")
+	if err != nil {
+		t.Fatalf("renderQuiet() error = %v", err)
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}:                 "ConfigMapList",
+		{Version: "v1", Resource: "secrets"}:                    "SecretList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	})
+	applier := &serverSideApplier{dyn: dyn, mapper: mapper}
+
+	var buf bytes.Buffer
+	if err := applier.apply(context.Background(), objects, logger.NewStdLogger(&buf)); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	var created []string
+	for _, action := range dyn.Actions() {
+		if action.GetVerb() == "create" {
+			created = append(created, action.(k8stesting.CreateAction).GetObject().GetObjectKind().GroupVersionKind().Kind)
+		}
+	}
+	// The Deployment is rendered first but created after what it reads
+	if got := strings.Join(created, ","); got != "ConfigMap,Secret,Deployment" {
+		t.Errorf("created %s, want ConfigMap,Secret,Deployment", got)
+	}
+	if !strings.Contains(buf.String(), "3 created, 0 configured, 0 unchanged") {
+		t.Errorf("output = %q, want a summary of 3 created objects", buf.String())
+	}
+
+	// A second apply of objects that exist leaves them alone instead of
+	// failing; the fake client answers apply patches with the live object
+	dyn.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		live, err := dyn.Tracker().Get(action.GetResource(), patch.GetNamespace(), patch.GetName())
+		return true, live, err
+	})
+	buf.Reset()
+	if err := applier.apply(context.Background(), objects, logger.NewStdLogger(&buf)); err != nil {
+		t.Fatalf("second apply() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "0 created, 0 configured, 3 unchanged") {
+		t.Errorf("output = %q, want a summary of 3 unchanged objects", buf.String())
+	}
+}
+
+func TestHandleModuleApplyUsage(t *testing.T) {
+	app := &App{logger: logger.NewNopLogger()}
+	module := basicHelpTestModule{name: "web"}
+	for _, args := range [][]string{{"--server"}, {"extra"}} {
+		if err := app.handleModuleApply(context.Background(), &config.Config{}, "web", module, args); err == nil || !strings.Contains(err.Error(), "usage:") {
+			t.Errorf("handleModuleApply(%v) error = %v, want usage", args, err)
+		}
+	}
+}
+
+func TestServerSideApplierDryRunOutput(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	})
+	objects, err := k8s.DecodeManifest([]byte(serverSideTestDeployment))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	applier := &serverSideApplier{dyn: dyn, mapper: mapper, dryRun: true}
+	if err := applier.apply(context.Background(), objects, logger.NewStdLogger(&buf)); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	for _, want := range []string{"Deployment/web created (dry run)", "Dry run, nothing changed. Would apply 1 objects: 1 created"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output = %q, want %q", buf.String(), want)
+		}
+	}
+}
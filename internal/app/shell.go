@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/modules"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const terminalResizeInterval = 500 * time.Millisecond
+
+// handleShellCommand opens an interactive shell in a running pod of the
+// module. The module picks the container and command, e.g. psql for
+// postgres; --container and a command after -- override them.
+func (a *App) handleShellCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	shellCmd := flag.NewFlagSet("shell", flag.ContinueOnError)
+	shellCmd.SetOutput(io.Discard)
+	defaultContainer, command := modules.ShellCommand(module)
+	container := shellCmd.String("container", defaultContainer, "Container to open the shell in")
+	usage := fmt.Sprintf("usage: %s %s shell [--container NAME] [-- command...]", Name, name)
+	if err := shellCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if shellCmd.NArg() > 0 {
+		command = shellCmd.Args()
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	pod, err := shellPod(ctx, client, namespace, "app="+modules.AppLabel(module))
+	if err != nil {
+		return err
+	}
+
+	req := k8s.ShellRequest{ExecRequest: k8s.ExecRequest{
+		Namespace: namespace,
+		Pod:       pod,
+		Container: *container,
+		Command:   command,
+		Stdin:     a.stdin,
+		Stdout:    a.stdout,
+		Stderr:    a.stderr,
+	}}
+	// Only a terminal can be switched to raw mode; piped input runs the
+	// command without a TTY, e.g. echo 'select 1;' | personal-server postgres shell
+	if f, ok := a.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to set the terminal to raw mode: %w", err)
+		}
+		defer term.Restore(int(f.Fd()), state)
+
+		resizeCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		req.TTY = true
+		req.Resize = newTerminalSizeQueue(resizeCtx, int(f.Fd()))
+	}
+
+	a.logger.Info("Connecting to %s/%s...\r\n", namespace, pod)
+	return a.clients.ExecShell(ctx, req)
+}
+
+// shellPod returns a running pod matching selector, preferring one whose
+// containers are all ready
+func shellPod(ctx context.Context, client kubernetes.Interface, namespace, selector string) (string, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	running := ""
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if podReady(&pod) {
+			return pod.Name, nil
+		}
+		if running == "" {
+			running = pod.Name
+		}
+	}
+	if running == "" {
+		return "", fmt.Errorf("no running pod with label %s in namespace %s", selector, namespace)
+	}
+	return running, nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// terminalSizeQueue reports the size of the local terminal whenever it
+// changes. Polling keeps it portable, unlike SIGWINCH.
+type terminalSizeQueue struct {
+	sizes chan remotecommand.TerminalSize
+}
+
+func newTerminalSizeQueue(ctx context.Context, fd int) *terminalSizeQueue {
+	q := &terminalSizeQueue{sizes: make(chan remotecommand.TerminalSize, 1)}
+	go func() {
+		defer close(q.sizes)
+		var last remotecommand.TerminalSize
+		ticker := time.NewTicker(terminalResizeInterval)
+		defer ticker.Stop()
+		for {
+			if width, height, err := term.GetSize(fd); err == nil {
+				size := remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+				if size != last {
+					last = size
+					select {
+					case q.sizes <- size:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return q
+}
+
+// Next returns the next terminal size, or nil once the session ended
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q.sizes
+	if !ok {
+		return nil
+	}
+	return &size
+}
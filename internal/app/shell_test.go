@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestShellPod(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "infra", Labels: map[string]string{"app": "postgres"}},
+			Status: corev1.PodStatus{
+				Phase:      phase,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		pods    []*corev1.Pod
+		want    string
+		wantErr bool
+	}{
+		{name: "prefers ready", pods: []*corev1.Pod{pod("a", corev1.PodRunning, corev1.ConditionFalse), pod("b", corev1.PodRunning, corev1.ConditionTrue)}, want: "b"},
+		{name: "running but not ready", pods: []*corev1.Pod{pod("a", corev1.PodPending, corev1.ConditionFalse), pod("b", corev1.PodRunning, corev1.ConditionFalse)}, want: "b"},
+		{name: "none running", pods: []*corev1.Pod{pod("a", corev1.PodPending, corev1.ConditionFalse)}, wantErr: true},
+		{name: "no pods", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for _, p := range tt.pods {
+				if _, err := client.CoreV1().Pods("infra").Create(context.Background(), p, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := shellPod(context.Background(), client, "infra", "app=postgres")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("shellPod() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("shellPod() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleShellCommand_Usage(t *testing.T) {
+	app := &App{}
+	err := app.handleShellCommand(context.Background(), nil, "postgres", basicHelpTestModule{name: "postgres"}, []string{"--unknown"})
+	if err == nil || !strings.Contains(err.Error(), "usage:") {
+		t.Errorf("expected usage error, got %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// namespaceSnapshot caches the namespace-scoped lists used by status --all
+type namespaceSnapshot struct {
+	deployments map[string]appsv1.Deployment
+	pods        map[string][]corev1.Pod // keyed by app label
+	ingresses   map[string]metav1.Time  // creation time keyed by name
+	claims      map[string]corev1.PersistentVolumeClaim
+	err         error
+}
+
+// componentStatus is one row of the status --all table
+type componentStatus struct {
+	name      string
+	namespace string
+	kind      string
+	ready     string
+	pods      string
+	claims    string
+	age       string
+	status    string
+	image     string
+}
+
+func (a *App) handleStatusCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	statusCmd := flag.NewFlagSet("status", flag.ContinueOnError)
+	statusCmd.SetOutput(io.Discard)
+	// status and status --all are the same; the flag is kept for scripts
+	statusCmd.Bool("all", true, "Show the status of every configured component")
+	usage := fmt.Sprintf("usage: %s status [--all]", Name)
+	if err := statusCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if statusCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+
+	components, err := a.components(cfg, func(string) logger.Logger { return logger.NewNopLogger() })
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		a.logger.Warn("No modules, pet projects or ingresses configured\n")
+		return nil
+	}
+
+	clientset, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	start := time.Now()
+	snapshots := collectSnapshots(ctx, clientset, components)
+	rows := componentStatuses(components, snapshots, time.Now())
+	a.printStatusTable(rows)
+	a.logger.Info("\nCollected %d components from %d namespaces in %s\n", len(rows), len(snapshots), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// collectSnapshots lists deployments, pods, ingresses and PVCs once per
+// namespace, fetching all namespaces in parallel. Pods are listed with a
+// single set-based selector covering every app label expected in the
+// namespace.
+func collectSnapshots(ctx context.Context, client k8s.KubernetesClient, components []component) map[string]*namespaceSnapshot {
+	labelsByNamespace := make(map[string]map[string]bool)
+	for _, c := range components {
+		if labelsByNamespace[c.namespace] == nil {
+			labelsByNamespace[c.namespace] = make(map[string]bool)
+		}
+		if c.kind != kindIngress {
+			if label := modules.AppLabel(c.module); label != "" {
+				labelsByNamespace[c.namespace][label] = true
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	snapshots := make(map[string]*namespaceSnapshot, len(labelsByNamespace))
+	for ns, labelSet := range labelsByNamespace {
+		wg.Add(1)
+		go func(ns string, labelSet map[string]bool) {
+			defer wg.Done()
+			snapshot := fetchSnapshot(ctx, client, ns, labelSet)
+			mu.Lock()
+			snapshots[ns] = snapshot
+			mu.Unlock()
+		}(ns, labelSet)
+	}
+	wg.Wait()
+	return snapshots
+}
+
+func fetchSnapshot(ctx context.Context, client k8s.KubernetesClient, namespace string, labelSet map[string]bool) *namespaceSnapshot {
+	snapshot := &namespaceSnapshot{
+		deployments: make(map[string]appsv1.Deployment),
+		pods:        make(map[string][]corev1.Pod),
+		ingresses:   make(map[string]metav1.Time),
+		claims:      make(map[string]corev1.PersistentVolumeClaim),
+	}
+
+	var wg sync.WaitGroup
+	var deployments *appsv1.DeploymentList
+	var pods *corev1.PodList
+	var claims *corev1.PersistentVolumeClaimList
+	var deployErr, podErr, ingressErr, claimErr error
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		deployments, deployErr = client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	}()
+	go func() {
+		defer wg.Done()
+		claims, claimErr = client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	}()
+	go func() {
+		defer wg.Done()
+		ingresses, err := client.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			ingressErr = err
+			return
+		}
+		for _, ing := range ingresses.Items {
+			snapshot.ingresses[ing.Name] = ing.CreationTimestamp
+		}
+	}()
+	if len(labelSet) > 0 {
+		labels := make([]string, 0, len(labelSet))
+		for label := range labelSet {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pods, podErr = client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("app in (%s)", strings.Join(labels, ",")),
+			})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range []error{deployErr, podErr, ingressErr, claimErr} {
+		if err != nil {
+			snapshot.err = err
+			return snapshot
+		}
+	}
+	for _, d := range deployments.Items {
+		snapshot.deployments[d.Name] = d
+	}
+	for _, c := range claims.Items {
+		snapshot.claims[c.Name] = c
+	}
+	if pods != nil {
+		for _, p := range pods.Items {
+			app := p.Labels["app"]
+			snapshot.pods[app] = append(snapshot.pods[app], p)
+		}
+	}
+	return snapshot
+}
+
+// componentStatuses derives one row per component from the cached lists,
+// with ages relative to now
+func componentStatuses(components []component, snapshots map[string]*namespaceSnapshot, now time.Time) []componentStatus {
+	rows := make([]componentStatus, 0, len(components))
+	for _, c := range components {
+		row := componentStatus{name: c.name, namespace: c.namespace, kind: c.kind, ready: "-", pods: "-", claims: "-", age: "-", image: "-"}
+		snapshot := snapshots[c.namespace]
+
+		switch {
+		case snapshot == nil || snapshot.err != nil:
+			row.status = "Unknown"
+			if snapshot != nil {
+				row.status = fmt.Sprintf("Error: %v", snapshot.err)
+			}
+		case c.kind == kindIngress:
+			row.status = "Not deployed"
+			if created, ok := snapshot.ingresses[c.name]; ok {
+				row.status = "Present"
+				row.age = k8s.FormatAge(now.Sub(created.Time))
+			}
+		case modules.DeploymentName(c.module) == "":
+			row.status = "No workload"
+		default:
+			row.ready, row.pods, row.status = workloadStatus(snapshot, modules.DeploymentName(c.module), modules.AppLabel(c.module))
+			if deployment, ok := snapshot.deployments[modules.DeploymentName(c.module)]; ok {
+				row.claims = claimStatus(snapshot, deployment)
+				row.age = k8s.FormatAge(now.Sub(deployment.CreationTimestamp.Time))
+				// The image deployed, which is the configured one once applied
+				if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+					row.image = containers[0].Image
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func workloadStatus(snapshot *namespaceSnapshot, deploymentName, appLabel string) (ready, pods, status string) {
+	deployment, ok := snapshot.deployments[deploymentName]
+	if !ok {
+		return "-", "-", "Not deployed"
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	ready = fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, desired)
+
+	running := 0
+	problem := ""
+	for _, pod := range snapshot.pods[appLabel] {
+		if pod.Status.Phase == corev1.PodRunning {
+			running++
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" && problem == "" {
+				problem = cs.State.Waiting.Reason
+			}
+		}
+	}
+	pods = fmt.Sprintf("%d/%d", running, len(snapshot.pods[appLabel]))
+
+	switch {
+	case desired == 0:
+		// Pods still terminating after scale 0 are not a problem
+		status = "Suspended"
+	case problem != "":
+		status = problem
+	case deployment.Status.ReadyReplicas >= desired:
+		status = "Ready"
+	default:
+		status = "Progressing"
+	}
+	return ready, pods, status
+}
+
+// claimStatus summarises the PVCs deployment mounts: their phase when they
+// all share one, e.g. Bound, and otherwise the first claim that is not bound
+func claimStatus(snapshot *namespaceSnapshot, deployment appsv1.Deployment) string {
+	var names []string
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			names = append(names, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	for _, name := range names {
+		claim, ok := snapshot.claims[name]
+		if !ok {
+			return fmt.Sprintf("Missing (%s)", name)
+		}
+		if claim.Status.Phase != corev1.ClaimBound {
+			return fmt.Sprintf("%s (%s)", claim.Status.Phase, name)
+		}
+	}
+	return string(corev1.ClaimBound)
+}
+
+func (a *App) printStatusTable(rows []componentStatus) {
+	a.logger.Info("%-24s %-12s %-12s %-8s %-8s %-10s %-6s %-18s %s\n", "COMPONENT", "KIND", "NAMESPACE", "READY", "PODS", "PVC", "AGE", "STATUS", "IMAGE")
+	for _, r := range rows {
+		a.logger.Info("%-24s %-12s %-12s %-8s %-8s %-10s %-6s %-18s %s\n", r.name, r.kind, r.namespace, r.ready, r.pods, r.claims, r.age, r.status, r.image)
+	}
+}
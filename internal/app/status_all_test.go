@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type workloadTestModule struct {
+	basicHelpTestModule
+	deployment, label string
+}
+
+func (m workloadTestModule) DeploymentName() string { return m.deployment }
+func (m workloadTestModule) AppLabel() string       { return m.label }
+
+func TestCollectSnapshotsAndStatuses(t *testing.T) {
+	replicas := int32(1)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-3 * 24 * time.Hour))
+	deployment := func(ns, name string, ready int32, claims ...string) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, CreationTimestamp: created},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+		}
+		d.Spec.Template.Spec.Containers = []corev1.Container{{Name: name, Image: name + ":1.0"}}
+		for _, claim := range claims {
+			d.Spec.Template.Spec.Volumes = append(d.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name:         claim,
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim}},
+			})
+		}
+		return d
+	}
+	claim := func(ns, name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	pod := func(ns, name, app string, phase corev1.PodPhase, waiting string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: map[string]string{"app": app}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		if waiting != "" {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}},
+			}}
+		}
+		return p
+	}
+
+	client := fake.NewSimpleClientset([]runtime.Object{
+		deployment("infra", "postgres", 1, "postgres-data"),
+		deployment("infra", "gitea", 0, "gitea-data", "gitea-repos"),
+		claim("infra", "postgres-data", corev1.ClaimBound),
+		claim("infra", "gitea-data", corev1.ClaimBound),
+		claim("infra", "gitea-repos", corev1.ClaimPending),
+		deployment("infra", "cloudflared-deployment", 1),
+		pod("infra", "postgres-0", "postgres", corev1.PodRunning, ""),
+		pod("infra", "gitea-0", "gitea", corev1.PodRunning, "CrashLoopBackOff"),
+		pod("infra", "cloudflared-0", "cloudflared", corev1.PodRunning, ""),
+		pod("infra", "unrelated-0", "other", corev1.PodRunning, ""),
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "hobby-pod", Namespace: "hobby", CreationTimestamp: created},
+			Spec:       appsv1.DeploymentSpec{Replicas: new(int32)},
+		},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "hobby", CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))}},
+	}...)
+
+	components := []component{
+		{name: "postgres", namespace: "infra", kind: kindModule, module: basicHelpTestModule{name: "postgres"}},
+		{name: "gitea", namespace: "infra", kind: kindModule, module: basicHelpTestModule{name: "gitea"}},
+		{name: "cloudflare", namespace: "infra", kind: kindModule, module: workloadTestModule{basicHelpTestModule{name: "cloudflare"}, "cloudflared-deployment", "cloudflared"}},
+		{name: "redis", namespace: "infra", kind: kindModule, module: basicHelpTestModule{name: "redis"}},
+		{name: "ssh-login-notifier", namespace: "infra", kind: kindModule, module: workloadTestModule{basicHelpTestModule{name: "ssh-login-notifier"}, "", ""}},
+		{name: "hobby-pod", namespace: "hobby", kind: kindPetProject, module: basicHelpTestModule{name: "hobby-pod"}},
+		{name: "web", namespace: "hobby", kind: kindIngress, module: basicHelpTestModule{name: "ingress"}},
+		{name: "api", namespace: "hobby", kind: kindIngress, module: basicHelpTestModule{name: "ingress"}},
+	}
+
+	snapshots := collectSnapshots(context.Background(), client, components)
+
+	// One list per resource type per namespace
+	if got := len(client.Actions()); got != 8 {
+		t.Errorf("expected 8 API calls, got %d: %v", got, client.Actions())
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "list" {
+			t.Errorf("unexpected %s call on %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+
+	want := map[string]componentStatus{
+		"postgres":           {ready: "1/1", pods: "1/1", claims: "Bound", age: "3d", status: "Ready", image: "postgres:1.0"},
+		"gitea":              {ready: "0/1", pods: "1/1", claims: "Pending (gitea-repos)", age: "3d", status: "CrashLoopBackOff", image: "gitea:1.0"},
+		"cloudflare":         {ready: "1/1", pods: "1/1", claims: "-", age: "3d", status: "Ready", image: "cloudflared-deployment:1.0"},
+		"redis":              {ready: "-", pods: "-", claims: "-", age: "-", status: "Not deployed", image: "-"},
+		"ssh-login-notifier": {ready: "-", pods: "-", claims: "-", age: "-", status: "No workload", image: "-"},
+		"hobby-pod":          {ready: "0/0", pods: "0/0", claims: "-", age: "3d", status: "Suspended", image: "-"},
+		"web":                {ready: "-", pods: "-", claims: "-", age: "2h", status: "Present", image: "-"},
+		"api":                {ready: "-", pods: "-", claims: "-", age: "-", status: "Not deployed", image: "-"},
+	}
+	for _, row := range componentStatuses(components, snapshots, now) {
+		w := want[row.name]
+		if row.ready != w.ready || row.pods != w.pods || row.claims != w.claims || row.age != w.age || row.status != w.status || row.image != w.image {
+			t.Errorf("%s = {%s %s %s %s %s %s}, want {%s %s %s %s %s %s}", row.name, row.ready, row.pods, row.claims, row.age, row.status, row.image, w.ready, w.pods, w.claims, w.age, w.status, w.image)
+		}
+	}
+}
+
+func TestHandleStatusCommand_WithoutAll(t *testing.T) {
+	var out strings.Builder
+	app := &App{logger: logger.NewStdLogger(&out)}
+	if err := app.handleStatusCommand(context.Background(), &config.Config{}, nil); err != nil {
+		t.Errorf("status without --all error = %v, want the summary", err)
+	}
+	if !strings.Contains(out.String(), "No modules, pet projects or ingresses configured") {
+		t.Errorf("output = %q, want the empty summary", out.String())
+	}
+	if err := app.handleStatusCommand(context.Background(), &config.Config{}, []string{"gitea"}); err == nil {
+		t.Error("expected usage error for an argument")
+	}
+}
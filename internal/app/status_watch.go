@@ -0,0 +1,258 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// handleStatusWatch prints the module status once and then streams changes to
+// its pods, Deployment and PVCs until interrupted
+func (a *App) handleStatusWatch(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	watchCmd := flag.NewFlagSet("status", flag.ContinueOnError)
+	watchCmd.SetOutput(io.Discard)
+	watch := watchCmd.Bool("watch", false, "Stream live changes")
+	w := watchCmd.Bool("w", false, "Stream live changes (shorthand)")
+	usage := fmt.Sprintf("usage: %s %s status [--watch]", Name, name)
+	if err := watchCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if !*watch && !*w {
+		return fmt.Errorf("%s", usage)
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	if modules.DeploymentName(module) == "" {
+		return fmt.Errorf("%s has no workload to watch", name)
+	}
+
+	if err := module.Status(ctx); err != nil {
+		return err
+	}
+
+	// The informers' watches stay open longer than a request timeout allows
+	clientset, err := a.clients.Streaming()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	a.logger.Info("\nWatching %s in namespace %s (Ctrl+C to stop)...\n", name, namespace)
+	return watchWorkload(ctx, clientset, namespace, modules.DeploymentName(module), modules.AppLabel(module), a.logger)
+}
+
+// componentNamespace returns the namespace of the module, pet project or
+// ingress configured under name
+func componentNamespace(cfg *config.Config, name string) (string, bool) {
+	for _, m := range cfg.Modules {
+		if m.Name == name {
+			return m.Namespace, true
+		}
+	}
+	for _, p := range cfg.PetProjects {
+		if p.Name == name {
+			return p.Namespace, true
+		}
+	}
+	for _, ing := range cfg.Ingresses {
+		if ing.Name == name {
+			return ing.Namespace, true
+		}
+	}
+	return "", false
+}
+
+// watchWorkload runs namespace-scoped shared informers for the workload's
+// pods, Deployment and PVCs and logs every change after the initial sync.
+// PVCs are matched by the app label prefix, which is how modules name them.
+// It blocks until ctx is cancelled.
+func watchWorkload(ctx context.Context, client kubernetes.Interface, namespace, deploymentName, appLabel string, log logger.Logger) error {
+	podFactory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = "app=" + appLabel
+		}))
+	deploymentFactory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", deploymentName).String()
+		}))
+	pvcFactory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace))
+
+	// Handlers of different informers run concurrently
+	var mu sync.Mutex
+	var synced atomic.Bool
+	emit := func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		log.Info("[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+	}
+
+	podInformer := podFactory.Core().V1().Pods().Informer()
+	if _, err := podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok && synced.Load() {
+				emit("pod %s created: %s", pod.Name, describePod(pod))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, ok1 := oldObj.(*corev1.Pod)
+			pod, ok2 := newObj.(*corev1.Pod)
+			if !ok1 || !ok2 {
+				return
+			}
+			if before, after := describePod(oldPod), describePod(pod); before != after {
+				emit("pod %s: %s", pod.Name, after)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if pod, ok := deletedObject(obj).(*corev1.Pod); ok {
+				emit("pod %s deleted", pod.Name)
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to watch pods: %w", err)
+	}
+
+	deploymentInformer := deploymentFactory.Apps().V1().Deployments().Informer()
+	if _, err := deploymentInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if d, ok := obj.(*appsv1.Deployment); ok && synced.Load() {
+				emit("deployment %s created: %s", d.Name, describeDeployment(d))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldD, ok1 := oldObj.(*appsv1.Deployment)
+			d, ok2 := newObj.(*appsv1.Deployment)
+			if !ok1 || !ok2 {
+				return
+			}
+			if before, after := describeDeployment(oldD), describeDeployment(d); before != after {
+				emit("deployment %s: %s", d.Name, after)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if d, ok := deletedObject(obj).(*appsv1.Deployment); ok {
+				emit("deployment %s deleted", d.Name)
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to watch deployments: %w", err)
+	}
+
+	pvcInformer := pvcFactory.Core().V1().PersistentVolumeClaims().Informer()
+	ownPVC := func(obj interface{}) (*corev1.PersistentVolumeClaim, bool) {
+		pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+		return pvc, ok && strings.HasPrefix(pvc.Name, appLabel)
+	}
+	if _, err := pvcInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pvc, ok := ownPVC(obj); ok && synced.Load() {
+				emit("pvc %s created: %s", pvc.Name, describePVC(pvc))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPVC, ok1 := ownPVC(oldObj)
+			pvc, ok2 := ownPVC(newObj)
+			if !ok1 || !ok2 {
+				return
+			}
+			if before, after := describePVC(oldPVC), describePVC(pvc); before != after {
+				emit("pvc %s: %s", pvc.Name, after)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if pvc, ok := ownPVC(deletedObject(obj)); ok {
+				emit("pvc %s deleted", pvc.Name)
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to watch persistent volume claims: %w", err)
+	}
+
+	for _, f := range []informers.SharedInformerFactory{podFactory, deploymentFactory, pvcFactory} {
+		f.Start(ctx.Done())
+		defer f.Shutdown()
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), podInformer.HasSynced, deploymentInformer.HasSynced, pvcInformer.HasSynced) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to sync informer caches")
+	}
+	synced.Store(true)
+
+	<-ctx.Done()
+	return nil
+}
+
+// deletedObject unwraps the tombstone delivered when a delete was missed
+func deletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
+
+func describePod(pod *corev1.Pod) string {
+	ready, restarts := 0, int32(0)
+	reason := ""
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Ready {
+			ready++
+		}
+		restarts += cs.RestartCount
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" && reason == "" {
+			reason = cs.State.Waiting.Reason
+		}
+	}
+	desc := fmt.Sprintf("%s, ready %d/%d, restarts %d", pod.Status.Phase, ready, len(pod.Spec.Containers), restarts)
+	if reason != "" {
+		desc += " (" + reason + ")"
+	}
+	if pod.DeletionTimestamp != nil {
+		desc += ", terminating"
+	}
+	return desc
+}
+
+func describeDeployment(d *appsv1.Deployment) string {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	desc := fmt.Sprintf("ready %d/%d, updated %d", d.Status.ReadyReplicas, desired, d.Status.UpdatedReplicas)
+	for _, c := range d.Status.Conditions {
+		desc += fmt.Sprintf(", %s=%s", c.Type, c.Status)
+		if c.Reason != "" {
+			desc += " (" + c.Reason + ")"
+		}
+	}
+	return desc
+}
+
+func describePVC(pvc *corev1.PersistentVolumeClaim) string {
+	desc := string(pvc.Status.Phase)
+	if pvc.Spec.VolumeName != "" {
+		desc += ", volume " + pvc.Spec.VolumeName
+	}
+	return desc
+}
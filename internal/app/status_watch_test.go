@@ -0,0 +1,120 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// syncBuffer lets the test read output while informer handlers write it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchWorkloadStreamsChanges(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitea-0", Namespace: "infra", Labels: map[string]string{"app": "gitea"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "gitea"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitea-data-pvc", Namespace: "infra"},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	other := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres-data-pvc", Namespace: "infra"},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	client := fake.NewSimpleClientset(pod, pvc, other)
+
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watchWorkload(ctx, client, "infra", "gitea", "gitea", logger.NewStdLogger(out))
+	}()
+
+	// Keep updating until the watch picks the change up; events sent before
+	// the informers synced are folded into the initial list
+	waitFor := func(want string, update func(attempt int32)) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for attempt := int32(1); !strings.Contains(out.String(), want); attempt++ {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q, output:\n%s", want, out.String())
+			}
+			update(attempt)
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	waitFor("pod gitea-0: Running, ready 1/1", func(attempt int32) {
+		p := pod.DeepCopy()
+		p.Status.Phase = corev1.PodRunning
+		p.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "gitea", Ready: true, RestartCount: attempt}}
+		if _, err := client.CoreV1().Pods("infra").UpdateStatus(ctx, p, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("update pod: %v", err)
+		}
+	})
+
+	for _, claim := range []*corev1.PersistentVolumeClaim{other, pvc} {
+		c := claim.DeepCopy()
+		c.Spec.VolumeName = "pv-1"
+		c.Status.Phase = corev1.ClaimBound
+		if _, err := client.CoreV1().PersistentVolumeClaims("infra").UpdateStatus(ctx, c, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("update pvc: %v", err)
+		}
+	}
+	waitFor("pvc gitea-data-pvc: Bound, volume pv-1", func(int32) {})
+
+	if err := client.CoreV1().Pods("infra").Delete(ctx, "gitea-0", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete pod: %v", err)
+	}
+	waitFor("pod gitea-0 deleted", func(int32) {})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchWorkload returned error: %v", err)
+	}
+	if strings.Contains(out.String(), "postgres-data-pvc") {
+		t.Errorf("PVC of another app should be ignored, output:\n%s", out.String())
+	}
+}
+
+func TestComponentNamespace(t *testing.T) {
+	cfg := &config.Config{
+		Modules:     []config.Module{{Name: "postgres", Namespace: "infra"}},
+		PetProjects: []config.PetProject{{Name: "blog", Namespace: "hobby"}},
+	}
+	if ns, ok := componentNamespace(cfg, "postgres"); !ok || ns != "infra" {
+		t.Errorf("componentNamespace(postgres) = %q, %v", ns, ok)
+	}
+	if ns, ok := componentNamespace(cfg, "blog"); !ok || ns != "hobby" {
+		t.Errorf("componentNamespace(blog) = %q, %v", ns, ok)
+	}
+	if _, ok := componentNamespace(cfg, "missing"); ok {
+		t.Error("expected missing component to be reported")
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/modules"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// imageChange is a container whose image an upgrade replaces
+type imageChange struct {
+	container string
+	from      string
+	to        string
+}
+
+// handleUpgradeCommand moves a running module to the images it generates:
+// the configured image, or the version pinned in the module. The module's
+// data is backed up first, then the objects are applied server-side and the
+// rollout is waited for.
+func (a *App) handleUpgradeCommand(ctx context.Context, cfg *config.Config, name string, module modules.Module, args []string) error {
+	upgradeCmd := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	upgradeCmd.SetOutput(io.Discard)
+	skipBackup := upgradeCmd.Bool("skip-backup", false, "Do not back up the module before upgrading")
+	timeout := upgradeCmd.Duration("timeout", defaultReadyTimeout, "How long to wait for the upgraded pods to become ready")
+	usage := fmt.Sprintf("usage: %s %s upgrade [--skip-backup] [--timeout 5m]", Name, name)
+	if err := upgradeCmd.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", usage, err)
+	}
+	if upgradeCmd.NArg() > 0 {
+		return fmt.Errorf("%s", usage)
+	}
+
+	namespace, ok := componentNamespace(cfg, name)
+	if !ok {
+		return fmt.Errorf("%s: no namespace configured", name)
+	}
+	deployment := modules.DeploymentName(module)
+	if deployment == "" {
+		return fmt.Errorf("%s has no workload to upgrade", name)
+	}
+	objects, err := a.renderQuiet(ctx, cfg, name)
+	if err != nil {
+		return err
+	}
+	client, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	live, err := client.AppsV1().Deployments(namespace).Get(ctx, deployment, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s is not deployed; use `%s %s apply` to install it", name, Name, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get deployment '%s': %w", deployment, err)
+	}
+
+	changes := upgradeChanges(live, objects)
+	if len(changes) == 0 {
+		a.logger.Success("%s already runs the configured images\n", name)
+		return nil
+	}
+	a.logger.Info("Upgrading %s:\n", name)
+	for _, c := range changes {
+		a.logger.Info("  %-20s %s -> %s\n", c.container, c.from, c.to)
+	}
+
+	backupDir := ""
+	if backuper, ok := module.(modules.Backuper); ok && !*skipBackup {
+		backupDir = filepath.Join("backups", fmt.Sprintf("pre_upgrade_%s_%s", name, time.Now().Format("20060102_150405")))
+		if err := os.MkdirAll(backupDir, 0755); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		a.logger.Progress("Backing up %s to %s\n", name, backupDir)
+		if err := backuper.Backup(ctx, backupDir); err != nil {
+			return fmt.Errorf("pre-upgrade backup failed, nothing was changed: %w", err)
+		}
+	}
+
+	prior, err := readHistory(ctx, client, namespace, deployment)
+	if err != nil {
+		a.logger.Warn("Failed to read the history of %s: %v\n", name, err)
+	}
+	applier, err := newServerSideApplier(a.clients, false)
+	if err != nil {
+		return err
+	}
+	if err := applier.apply(ctx, objects, a.logger); err != nil {
+		return err
+	}
+	entry := historyEntry{Action: historyActionUpgrade, ConfigHash: componentConfigHash(cfg, name), By: historyUser()}
+	if err := recordHistory(ctx, client, namespace, deployment, prior, entry); err != nil {
+		a.logger.Warn("Failed to record the upgrade in the history of %s: %v\n", name, err)
+	}
+
+	a.logger.Progress("Waiting for deployment %s to roll out...\n", deployment)
+	if err := waitForDeployment(ctx, client, namespace, deployment, modules.AppLabel(module), *timeout); err != nil {
+		if backupDir != "" {
+			return fmt.Errorf("%w; the data from before the upgrade is in %s", err, backupDir)
+		}
+		return err
+	}
+	a.logger.Success("%s upgraded\n", name)
+	return nil
+}
+
+// upgradeChanges compares the containers of the live Deployment with the
+// generated one of the same name and returns those whose image differs or
+// that are new
+func upgradeChanges(live *appsv1.Deployment, objects []*unstructured.Unstructured) []imageChange {
+	current := make(map[string]string)
+	for _, list := range [][]corev1.Container{live.Spec.Template.Spec.InitContainers, live.Spec.Template.Spec.Containers} {
+		for _, c := range list {
+			current[c.Name] = c.Image
+		}
+	}
+
+	var changes []imageChange
+	for _, object := range objects {
+		if object.GetKind() != "Deployment" || object.GetName() != live.Name {
+			continue
+		}
+		for _, container := range podContainers(object) {
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			from, ok := current[name]
+			if !ok {
+				from = "(new container)"
+			}
+			if image != "" && image != from {
+				changes = append(changes, imageChange{container: name, from: from, to: image})
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const upgradeTestManifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: gitea
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox:1.36
+      containers:
+      - name: gitea
+        image: gitea/gitea:1.22
+      - name: exporter
+        image: exporter:2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: other
+spec:
+  template:
+    spec:
+      containers:
+      - name: gitea
+        image: gitea/gitea:9
+`
+
+func TestUpgradeChanges(t *testing.T) {
+	objects, err := k8s.DecodeManifest([]byte(upgradeTestManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "gitea"}}
+	live.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "busybox:1.36"}}
+	live.Spec.Template.Spec.Containers = []corev1.Container{{Name: "gitea", Image: "gitea/gitea:1.21"}}
+
+	got := fmt.Sprintf("%+v", upgradeChanges(live, objects))
+	want := "[{container:gitea from:gitea/gitea:1.21 to:gitea/gitea:1.22} {container:exporter from:(new container) to:exporter:2}]"
+	if got != want {
+		t.Errorf("upgradeChanges() = %s, want %s", got, want)
+	}
+
+	live.Spec.Template.Spec.Containers = []corev1.Container{{Name: "gitea", Image: "gitea/gitea:1.22"}, {Name: "exporter", Image: "exporter:2"}}
+	if changes := upgradeChanges(live, objects); len(changes) != 0 {
+		t.Errorf("upgradeChanges() = %+v, want none when the images match", changes)
+	}
+}
@@ -0,0 +1,279 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// serviceURLs is one row of the urls table
+type serviceURLs struct {
+	name      string
+	namespace string
+	endpoint  string
+	public    []string
+	secrets   []string
+}
+
+func (a *App) handleURLsCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: %s urls", Name)
+	}
+
+	namespaces := configuredNamespaces(cfg)
+	if len(namespaces) == 0 {
+		a.logger.Warn("No namespaces configured\n")
+		return nil
+	}
+
+	clientset, err := a.clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	rows, err := collectServiceURLs(ctx, clientset, namespaces)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		a.logger.Warn("No services found in namespaces: %s\n", strings.Join(namespaces, ", "))
+		return nil
+	}
+	a.printURLsTable(rows)
+	return nil
+}
+
+// configuredNamespaces returns general.namespaces plus every namespace used
+// by a module, pet project or ingress, sorted and without duplicates
+func configuredNamespaces(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	add := func(ns string) {
+		if ns != "" {
+			seen[ns] = true
+		}
+	}
+	for _, ns := range cfg.General.Namespaces {
+		add(ns)
+	}
+	for _, m := range cfg.Modules {
+		add(m.Namespace)
+	}
+	for _, p := range cfg.PetProjects {
+		add(p.Namespace)
+	}
+	for _, ing := range cfg.Ingresses {
+		add(ing.Namespace)
+	}
+
+	namespaces := make([]string, 0, len(seen))
+	for ns := range seen {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// collectServiceURLs lists services, ingresses and deployments once per
+// namespace, fetching all namespaces in parallel, and returns one row per
+// service sorted by namespace and name
+func collectServiceURLs(ctx context.Context, client k8s.KubernetesClient, namespaces []string) ([]serviceURLs, error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var rows []serviceURLs
+	var errs []string
+	for _, ns := range namespaces {
+		wg.Add(1)
+		go func(ns string) {
+			defer wg.Done()
+			nsRows, err := namespaceServiceURLs(ctx, client, ns)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", ns, err))
+				return
+			}
+			rows = append(rows, nsRows...)
+		}(ns)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("failed to list resources: %s", strings.Join(errs, "; "))
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].namespace != rows[j].namespace {
+			return rows[i].namespace < rows[j].namespace
+		}
+		return rows[i].name < rows[j].name
+	})
+	return rows, nil
+}
+
+func namespaceServiceURLs(ctx context.Context, client k8s.KubernetesClient, namespace string) ([]serviceURLs, error) {
+	var wg sync.WaitGroup
+	var services *corev1.ServiceList
+	var ingresses *networkingv1.IngressList
+	var deployments *appsv1.DeploymentList
+	var serviceErr, ingressErr, deployErr error
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		services, serviceErr = client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	}()
+	go func() {
+		defer wg.Done()
+		ingresses, ingressErr = client.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	}()
+	go func() {
+		defer wg.Done()
+		deployments, deployErr = client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	}()
+	wg.Wait()
+
+	for _, err := range []error{serviceErr, ingressErr, deployErr} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	public := ingressURLs(ingresses.Items)
+	rows := make([]serviceURLs, 0, len(services.Items))
+	for _, svc := range services.Items {
+		rows = append(rows, serviceURLs{
+			name:      svc.Name,
+			namespace: namespace,
+			endpoint:  clusterEndpoint(svc),
+			public:    public[svc.Name],
+			secrets:   serviceSecrets(svc, deployments.Items),
+		})
+	}
+	return rows, nil
+}
+
+// ingressURLs maps backend service names to the public URLs routed to them.
+// A host listed in the ingress TLS section is reported as https.
+func ingressURLs(ingresses []networkingv1.Ingress) map[string][]string {
+	urls := make(map[string][]string)
+	for _, ing := range ingresses {
+		tlsHosts := make(map[string]bool)
+		for _, tls := range ing.Spec.TLS {
+			for _, host := range tls.Hosts {
+				tlsHosts[host] = true
+			}
+		}
+		for _, rule := range ing.Spec.Rules {
+			if rule.Host == "" || rule.HTTP == nil {
+				continue
+			}
+			scheme := "http"
+			if tlsHosts[rule.Host] {
+				scheme = "https"
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service == nil {
+					continue
+				}
+				p := path.Path
+				if p == "" {
+					p = "/"
+				}
+				url := fmt.Sprintf("%s://%s%s", scheme, rule.Host, p)
+				name := path.Backend.Service.Name
+				if !containsString(urls[name], url) {
+					urls[name] = append(urls[name], url)
+				}
+			}
+		}
+	}
+	for name := range urls {
+		sort.Strings(urls[name])
+	}
+	return urls
+}
+
+// clusterEndpoint formats a service's ClusterIP and ports, e.g.
+// "10.152.183.10:3000,22"; headless services report their DNS name instead
+func clusterEndpoint(svc corev1.Service) string {
+	ports := make([]string, 0, len(svc.Spec.Ports))
+	for _, p := range svc.Spec.Ports {
+		ports = append(ports, fmt.Sprintf("%d", p.Port))
+	}
+	host := svc.Spec.ClusterIP
+	if host == "" || host == corev1.ClusterIPNone {
+		host = fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
+	}
+	if len(ports) == 0 {
+		return host
+	}
+	return host + ":" + strings.Join(ports, ",")
+}
+
+// serviceSecrets returns the names of secrets that deployments selected by
+// the service read through env or envFrom, i.e. their credentials
+func serviceSecrets(svc corev1.Service, deployments []appsv1.Deployment) []string {
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	seen := make(map[string]bool)
+	for _, d := range deployments {
+		if !selector.Matches(labels.Set(d.Spec.Template.Labels)) {
+			continue
+		}
+		spec := d.Spec.Template.Spec
+		for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+			for _, env := range c.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					seen[env.ValueFrom.SecretKeyRef.Name] = true
+				}
+			}
+			for _, from := range c.EnvFrom {
+				if from.SecretRef != nil {
+					seen[from.SecretRef.Name] = true
+				}
+			}
+		}
+	}
+
+	secrets := make([]string, 0, len(seen))
+	for name := range seen {
+		secrets = append(secrets, name)
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) printURLsTable(rows []serviceURLs) {
+	orDash := func(values []string) string {
+		if len(values) == 0 {
+			return "-"
+		}
+		return strings.Join(values, ",")
+	}
+	a.logger.Info("%-24s %-12s %-28s %-40s %s\n", "SERVICE", "NAMESPACE", "CLUSTER ENDPOINT", "PUBLIC URL", "SECRETS")
+	for _, r := range rows {
+		a.logger.Info("%-24s %-12s %-28s %-40s %s\n", r.name, r.namespace, r.endpoint, orDash(r.public), orDash(r.secrets))
+	}
+}
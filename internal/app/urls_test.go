@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfiguredNamespaces(t *testing.T) {
+	cfg := &config.Config{
+		General:     config.GeneralConfig{Namespaces: []string{"infra", "hobby"}},
+		Modules:     []config.Module{{Name: "gitea", Namespace: "infra"}, {Name: "staticsite", Namespace: "web"}},
+		PetProjects: []config.PetProject{{Name: "myapp", Namespace: "hobby"}},
+	}
+	got := strings.Join(configuredNamespaces(cfg), ",")
+	if got != "hobby,infra,web" {
+		t.Errorf("configuredNamespaces() = %s, want hobby,infra,web", got)
+	}
+}
+
+func TestCollectServiceURLs(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	backend := func(name string) networkingv1.IngressBackend {
+		return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+			Name: name, Port: networkingv1.ServiceBackendPort{Number: 80},
+		}}
+	}
+
+	client := fake.NewSimpleClientset([]runtime.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.152.183.10",
+				Selector:  map[string]string{"app": "gitea"},
+				Ports:     []corev1.ServicePort{{Port: 3000}, {Port: 22}},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "infra"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: corev1.ClusterIPNone,
+				Selector:  map[string]string{"app": "postgres"},
+				Ports:     []corev1.ServicePort{{Port: 5432}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "gitea"}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "gitea",
+					Env: []corev1.EnvVar{
+						{Name: "PLAIN", Value: "x"},
+						{Name: "DB_PASSWD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "gitea-secrets"}, Key: "db_password",
+						}}},
+					},
+					EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "gitea-admin"},
+					}}},
+				}}},
+			}},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "infra"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "other"}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "other",
+					EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "other-secret"},
+					}}},
+				}}},
+			}},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "infra"},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{{Hosts: []string{"git.example.com"}}},
+				Rules: []networkingv1.IngressRule{
+					{Host: "git.example.com", IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{Path: "/", PathType: &pathType, Backend: backend("gitea")}},
+					}}},
+					{Host: "git.internal.example.com", IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{Path: "/api", PathType: &pathType, Backend: backend("gitea")}},
+					}}},
+				},
+			},
+		},
+	}...)
+
+	rows, err := collectServiceURLs(context.Background(), client, []string{"infra", "hobby"})
+	if err != nil {
+		t.Fatalf("collectServiceURLs() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d: %+v", len(rows), rows)
+	}
+
+	gitea, postgres := rows[0], rows[1]
+	if gitea.name != "gitea" || postgres.name != "postgres" {
+		t.Fatalf("rows not sorted by name: %s, %s", gitea.name, postgres.name)
+	}
+	if gitea.endpoint != "10.152.183.10:3000,22" {
+		t.Errorf("gitea endpoint = %s", gitea.endpoint)
+	}
+	if got := strings.Join(gitea.public, ","); got != "http://git.internal.example.com/api,https://git.example.com/" {
+		t.Errorf("gitea public URLs = %s", got)
+	}
+	if got := strings.Join(gitea.secrets, ","); got != "gitea-admin,gitea-secrets" {
+		t.Errorf("gitea secrets = %s", got)
+	}
+
+	if postgres.endpoint != "postgres.infra.svc:5432" {
+		t.Errorf("postgres endpoint = %s", postgres.endpoint)
+	}
+	if len(postgres.public) != 0 || len(postgres.secrets) != 0 {
+		t.Errorf("postgres should have no public URLs or secrets: %+v", postgres)
+	}
+}
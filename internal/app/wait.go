@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+)
+
+// waitForDeployment waits up to timeout for deployment to become ready. When
+// it does not, the error lists the recent Warning events of its pods, PVCs
+// and ReplicaSets, which usually tell why.
+func waitForDeployment(ctx context.Context, client k8s.KubernetesClient, namespace, deployment, appLabel string, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := k8s.WaitForDeploymentReady(waitCtx, client, namespace, deployment, readyPollInterval)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	events, eventsErr := workloadEvents(ctx, client, namespace, deployment, appLabel, time.Now())
+	if eventsErr != nil || len(events) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\nRecent warning events:\n  %s", err, strings.Join(events, "\n  "))
+}
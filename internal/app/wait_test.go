@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForDeploymentListsEvents(t *testing.T) {
+	now := time.Now()
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra"}}
+	deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{
+		Name:         "data",
+		VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "gitea-data"}},
+	}}
+	event := func(name, kind, object, reason string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "infra"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			LastTimestamp:  metav1.NewTime(now),
+		}
+	}
+	client := fake.NewSimpleClientset(
+		deployment,
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "infra", Labels: map[string]string{"app": "gitea"}}},
+		event("a", "Pod", "web-0", "FailedScheduling"),
+		event("b", "Pod", "gitea-7d9c-x2k", "BackOff"),
+		event("c", "PersistentVolumeClaim", "gitea-data", "ProvisioningFailed"),
+		event("d", "Pod", "postgres-0", "Unhealthy"),
+	)
+
+	err := waitForDeployment(context.Background(), client, "infra", "gitea", "gitea", 10*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected a timeout")
+	}
+	for _, want := range []string{"timed out", "Pod/web-0  FailedScheduling", "Pod/gitea-7d9c-x2k  BackOff", "PersistentVolumeClaim/gitea-data  ProvisioningFailed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not contain %q:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "postgres-0") {
+		t.Errorf("error lists events of other workloads:\n%v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// webhookTokenEnv holds the deploy webhook token, kept out of the
+	// command line where other users of the node could read it
+	webhookTokenEnv = "PERSONAL_SERVER_WEBHOOK_TOKEN"
+
+	webhookDeployPath = "/deploy/"
+	maxWebhookBody    = 1 << 20
+)
+
+// imageTagPattern matches a valid image tag
+var imageTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// podSpecPaths maps the workload kinds whose images can be deployed to the
+// path of their pod spec
+var podSpecPaths = map[string][]string{
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// deployRequest is the body of a deploy webhook call. Tag keeps the
+// component's image repository; image must use it.
+type deployRequest struct {
+	Image string `json:"image"`
+	Tag   string `json:"tag"`
+}
+
+// webhookServer lets a CI pipeline deploy a newly built image of a component
+// by calling POST /deploy/<component> once the build succeeded. Requests are
+// authenticated with the token, either as a bearer token or as the secret of
+// a Gitea or GitHub style HMAC-SHA256 signature of the body.
+type webhookServer struct {
+	token string
+	log   logger.Logger
+	// images returns the images a component's workloads run
+	images func(component string) []string
+	// deploy switches the component to image
+	deploy func(component, image string) error
+}
+
+// serveWebhook serves handler on addr until ctx is cancelled
+func serveWebhook(ctx context.Context, addr string, handler http.Handler, log logger.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Deploy webhook stopped: %v\n", err)
+		}
+	}()
+	log.Info("Serving the deploy webhook on %s\n", listener.Addr())
+	return nil
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/healthz" {
+		fmt.Fprintln(w, "ok")
+		return
+	}
+	component := strings.TrimPrefix(req.URL.Path, webhookDeployPath)
+	if component == req.URL.Path || component == "" || strings.Contains(component, "/") {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !s.authorized(req, body) {
+		s.log.Warn("Rejected unauthenticated deploy of %s from %s\n", component, req.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request deployRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	images := s.images(component)
+	if len(images) == 0 {
+		http.Error(w, fmt.Sprintf("unknown component: %s", component), http.StatusNotFound)
+		return
+	}
+	image, err := resolveDeployImage(request, images)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.deploy(component, image); err != nil {
+		s.log.Error("[%s] deploy of %s failed: %v\n", component, image, err)
+		http.Error(w, fmt.Sprintf("deploy failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.log.Success("[%s] deployed %s\n", component, image)
+	fmt.Fprintf(w, "deployed %s to %s\n", image, component)
+}
+
+// authorized checks the bearer token or the body signature
+func (s *webhookServer) authorized(req *http.Request, body []byte) bool {
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+	}
+	signature := req.Header.Get("X-Gitea-Signature")
+	if signature == "" {
+		signature = strings.TrimPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=")
+	}
+	if signature == "" {
+		return false
+	}
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.token))
+	mac.Write(body)
+	return hmac.Equal(given, mac.Sum(nil))
+}
+
+// resolveDeployImage returns the image a request deploys, given the images
+// the component runs
+func resolveDeployImage(request deployRequest, images []string) (string, error) {
+	switch {
+	case request.Image != "" && request.Tag != "":
+		return "", fmt.Errorf("set either image or tag, not both")
+	case request.Image != "":
+		if strings.ContainsAny(request.Image, " \t\n") {
+			return "", fmt.Errorf("invalid image: %q", request.Image)
+		}
+		return request.Image, nil
+	case request.Tag != "":
+		if !imageTagPattern.MatchString(request.Tag) {
+			return "", fmt.Errorf("invalid tag: %q", request.Tag)
+		}
+		repositories := map[string]bool{}
+		for _, image := range images {
+			repositories[imageRepository(image)] = true
+		}
+		if len(repositories) > 1 {
+			return "", fmt.Errorf("the component runs several images (%s): set image instead of tag", strings.Join(images, ", "))
+		}
+		return imageRepository(images[0]) + ":" + request.Tag, nil
+	}
+	return "", fmt.Errorf("set image or tag")
+}
+
+// imageRepository strips the tag and digest from image
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// podContainers returns the containers and init containers of a workload
+func podContainers(object *unstructured.Unstructured) []map[string]interface{} {
+	path, ok := podSpecPaths[object.GetKind()]
+	if !ok {
+		return nil
+	}
+	// not copied, so withImages can edit the containers in place
+	field, _, _ := unstructured.NestedFieldNoCopy(object.Object, path...)
+	spec, _ := field.(map[string]interface{})
+	var containers []map[string]interface{}
+	for _, field := range []string{"initContainers", "containers"} {
+		list, _ := spec[field].([]interface{})
+		for _, item := range list {
+			if container, ok := item.(map[string]interface{}); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+	return containers
+}
+
+// withImages returns a copy of object with the containers running one of the
+// repositories in images switched to the image given for it. object itself is
+// returned when nothing changes.
+func withImages(object *unstructured.Unstructured, images map[string]string) *unstructured.Unstructured {
+	changed := false
+	for _, container := range podContainers(object) {
+		current, _ := container["image"].(string)
+		if image, ok := images[imageRepository(current)]; ok && image != current {
+			changed = true
+		}
+	}
+	if !changed {
+		return object
+	}
+	object = object.DeepCopy()
+	for _, container := range podContainers(object) {
+		current, _ := container["image"].(string)
+		if image, ok := images[imageRepository(current)]; ok {
+			container["image"] = image
+			if _, ok := container["imagePullPolicy"]; ok {
+				container["imagePullPolicy"] = string(k8s.DefaultImagePullPolicy(image))
+			}
+		}
+	}
+	return object
+}
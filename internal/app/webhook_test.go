@@ -0,0 +1,172 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+const webhookTestDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: bot
+  namespace: hobby
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: git.example.com/me/bot:1.0.0
+          imagePullPolicy: IfNotPresent
+      containers:
+        - name: bot
+          image: git.example.com/me/bot:1.0.0
+          imagePullPolicy: IfNotPresent
+        - name: proxy
+          image: nginx:1.25
+`
+
+func TestImageRepository(t *testing.T) {
+	for image, want := range map[string]string{
+		"nginx":                           "nginx",
+		"nginx:1.25":                      "nginx",
+		"registry:5000/me/app":            "registry:5000/me/app",
+		"registry:5000/me/app:v2":         "registry:5000/me/app",
+		"ghcr.io/me/app@sha256:0123abcd":  "ghcr.io/me/app",
+		"ghcr.io/me/app:v1@sha256:0123ab": "ghcr.io/me/app",
+	} {
+		if got := imageRepository(image); got != want {
+			t.Errorf("imageRepository(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestWithImages(t *testing.T) {
+	objects, err := k8s.DecodeManifest([]byte(webhookTestDeployment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := objects[0]
+
+	if got := withImages(original, map[string]string{"redis": "redis:7"}); got != original {
+		t.Error("withImages() copied an object it did not change")
+	}
+	updated := withImages(original, map[string]string{"git.example.com/me/bot": "git.example.com/me/bot:latest"})
+	containers := podContainers(updated)
+	if len(containers) != 3 {
+		t.Fatalf("got %d containers, want 3", len(containers))
+	}
+	for i, want := range []string{"git.example.com/me/bot:latest", "git.example.com/me/bot:latest", "nginx:1.25"} {
+		if containers[i]["image"] != want {
+			t.Errorf("container %d image = %v, want %s", i, containers[i]["image"], want)
+		}
+	}
+	if containers[1]["imagePullPolicy"] != "Always" {
+		t.Errorf("imagePullPolicy = %v, want Always for the latest tag", containers[1]["imagePullPolicy"])
+	}
+	if _, ok := containers[2]["imagePullPolicy"]; ok {
+		t.Error("imagePullPolicy added to a container without one")
+	}
+	if podContainers(original)[1]["image"] != "git.example.com/me/bot:1.0.0" {
+		t.Error("withImages() modified the original object")
+	}
+}
+
+func TestReconcilerSetImage(t *testing.T) {
+	objects, err := k8s.DecodeManifest([]byte(webhookTestDeployment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newReconciler(nil, nil, nil, desiredState{"bot": objects}, time.Hour, logger.NewNopLogger())
+
+	if err := r.setImage("bot", "redis:7"); err == nil {
+		t.Error("setImage() of a repository the component does not run succeeded")
+	}
+	if err := r.setImage("bot", "git.example.com/me/bot:2.0.0"); err != nil {
+		t.Fatalf("setImage() error = %v", err)
+	}
+	// the deployed image replaces the generated one in later states too
+	regenerated, err := k8s.DecodeManifest([]byte(webhookTestDeployment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.setState(desiredState{"bot": regenerated})
+	images := r.containerImages("bot")
+	if strings.Join(images, ",") != "git.example.com/me/bot:2.0.0,nginx:1.25" {
+		t.Errorf("containerImages() = %v, want the deployed bot image", images)
+	}
+}
+
+func TestWebhookServer(t *testing.T) {
+	var deployed []string
+	server := &webhookServer{
+		token: "s3cret",
+		log:   logger.NewNopLogger(),
+		images: func(component string) []string {
+			switch component {
+			case "bot":
+				return []string{"git.example.com/me/bot:1.0.0"}
+			case "site":
+				return []string{"git.example.com/me/site:1", "nginx:1.25"}
+			}
+			return nil
+		},
+		deploy: func(component, image string) error {
+			deployed = append(deployed, component+"="+image)
+			return nil
+		},
+	}
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		headers map[string]string
+		want    int
+		deploy  string
+	}{
+		{name: "health", method: http.MethodGet, path: "/healthz", want: http.StatusOK},
+		{name: "no token", method: http.MethodPost, path: "/deploy/bot", body: `{"tag":"1.1.0"}`, want: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodPost, path: "/deploy/bot", body: `{"tag":"1.1.0"}`, headers: map[string]string{"Authorization": "Bearer nope"}, want: http.StatusUnauthorized},
+		{name: "bad signature", method: http.MethodPost, path: "/deploy/bot", body: `{"tag":"1.1.0"}`, headers: map[string]string{"X-Gitea-Signature": sign(`{"tag":"1.0.9"}`)}, want: http.StatusUnauthorized},
+		{name: "bearer tag", method: http.MethodPost, path: "/deploy/bot", body: `{"tag":"1.1.0"}`, headers: map[string]string{"Authorization": "Bearer s3cret"}, want: http.StatusOK, deploy: "bot=git.example.com/me/bot:1.1.0"},
+		{name: "gitea signature", method: http.MethodPost, path: "/deploy/bot", body: `{"tag":"1.2.0"}`, headers: map[string]string{"X-Gitea-Signature": sign(`{"tag":"1.2.0"}`)}, want: http.StatusOK, deploy: "bot=git.example.com/me/bot:1.2.0"},
+		{name: "github signature image", method: http.MethodPost, path: "/deploy/site", body: `{"image":"git.example.com/me/site:2"}`, headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign(`{"image":"git.example.com/me/site:2"}`)}, want: http.StatusOK, deploy: "site=git.example.com/me/site:2"},
+		{name: "ambiguous tag", method: http.MethodPost, path: "/deploy/site", body: `{"tag":"2"}`, headers: map[string]string{"Authorization": "Bearer s3cret"}, want: http.StatusBadRequest},
+		{name: "invalid tag", method: http.MethodPost, path: "/deploy/bot", body: `{"tag":"../x"}`, headers: map[string]string{"Authorization": "Bearer s3cret"}, want: http.StatusBadRequest},
+		{name: "unknown component", method: http.MethodPost, path: "/deploy/nope", body: `{"tag":"1"}`, headers: map[string]string{"Authorization": "Bearer s3cret"}, want: http.StatusNotFound},
+		{name: "get", method: http.MethodGet, path: "/deploy/bot", want: http.StatusMethodNotAllowed},
+		{name: "other path", method: http.MethodPost, path: "/other", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployed = nil
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d (%s), want %d", rec.Code, strings.TrimSpace(rec.Body.String()), tt.want)
+			}
+			if got := strings.Join(deployed, ","); got != tt.deploy {
+				t.Errorf("deployed = %q, want %q", got, tt.deploy)
+			}
+		})
+	}
+}
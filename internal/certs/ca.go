@@ -0,0 +1,272 @@
+// Package certs manages the private certificate authorities used to issue
+// client certificates for mTLS-protected ingresses and server certificates
+// for in-cluster TLS endpoints such as postgres and redis.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+const (
+	// DefaultDir is the local directory holding the CA and issued client certificates
+	DefaultDir = "certs"
+	// ServiceCADir holds the CA signing server certificates. It is kept apart
+	// from the client CA so that ingresses trusting client certificates never
+	// trust a server's.
+	ServiceCADir = "certs/service-ca"
+
+	caCertFile = "ca.crt"
+	caKeyFile  = "ca.key"
+	clientsDir = "clients"
+
+	caValidity     = 10 * 365 * 24 * time.Hour
+	clientValidity = 365 * 24 * time.Hour
+	serverValidity = 5 * 365 * 24 * time.Hour
+)
+
+var clientNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// CA is a private certificate authority stored on local disk
+type CA struct {
+	Cert    *x509.Certificate
+	Key     *ecdsa.PrivateKey
+	CertPEM []byte
+	dir     string
+}
+
+// LoadOrCreateCA loads the CA from dir, generating a new one if it does not
+// exist yet. The returned bool reports whether a new CA was created.
+func LoadOrCreateCA(dir string) (*CA, bool, error) {
+	return loadOrCreateCA(dir, "personal-server client CA")
+}
+
+// LoadOrCreateServiceCA is LoadOrCreateCA for the CA signing server
+// certificates, normally kept in ServiceCADir
+func LoadOrCreateServiceCA(dir string) (*CA, bool, error) {
+	return loadOrCreateCA(dir, "personal-server service CA")
+}
+
+func loadOrCreateCA(dir, commonName string) (*CA, bool, error) {
+	certPath := filepath.Join(dir, caCertFile)
+	if _, err := os.Stat(certPath); err == nil {
+		ca, err := LoadCA(dir)
+		return ca, false, err
+	} else if !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("failed to stat %s: %w", certPath, err)
+	}
+
+	ca, err := createCA(dir, commonName)
+	if err != nil {
+		return nil, false, err
+	}
+	return ca, true, nil
+}
+
+// LoadCA loads an existing CA certificate and key from dir
+func LoadCA(dir string) (*CA, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, caCertFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA key: %w", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("failed to decode CA certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("failed to decode CA key PEM")
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+
+	return &CA{Cert: cert, Key: key, CertPEM: certPEM, dir: dir}, nil
+}
+
+func createCA(dir, commonName string) (*CA, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create certs directory '%s': %w", dir, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	certPEM, keyPEM, err := encodePair(der, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePair(filepath.Join(dir, caCertFile), certPEM, filepath.Join(dir, caKeyFile), keyPEM); err != nil {
+		return nil, err
+	}
+
+	return &CA{Cert: cert, Key: key, CertPEM: certPEM, dir: dir}, nil
+}
+
+// IssueClientCert issues a client certificate with the given common name and
+// writes it to <dir>/clients/<name>.crt and <name>.key. Returns the paths of
+// the written certificate and key.
+func (ca *CA) IssueClientCert(name string) (string, string, error) {
+	if !clientNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid client name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate client key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(clientValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.Key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create client certificate: %w", err)
+	}
+
+	certPEM, keyPEM, err := encodePair(der, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	outDir := filepath.Join(ca.dir, clientsDir)
+	if err := os.MkdirAll(outDir, 0700); err != nil {
+		return "", "", fmt.Errorf("failed to create clients directory '%s': %w", outDir, err)
+	}
+	certPath := filepath.Join(outDir, name+".crt")
+	keyPath := filepath.Join(outDir, name+".key")
+	if err := writePair(certPath, certPEM, keyPath, keyPEM); err != nil {
+		return "", "", err
+	}
+
+	return certPath, keyPath, nil
+}
+
+// IssueServerCert issues a server certificate valid for hosts, which may be
+// DNS names or IP addresses, and returns it and its key PEM-encoded. Nothing
+// is written to disk; the caller stores the pair in a Kubernetes Secret.
+func (ca *CA) IssueServerCert(hosts []string) ([]byte, []byte, error) {
+	if len(hosts) == 0 {
+		return nil, nil, fmt.Errorf("a server certificate needs at least one host")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate server key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(serverValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create server certificate: %w", err)
+	}
+	return encodePair(der, key)
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+func encodePair(der []byte, key *ecdsa.PrivateKey) ([]byte, []byte, error) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+func writePair(certPath string, certPEM []byte, keyPath string, keyPEM []byte) error {
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", certPath, err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", keyPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package certs
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreateCA(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, created, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA() returned error: %v", err)
+	}
+	if !created {
+		t.Error("expected a new CA to be created")
+	}
+	if !ca.Cert.IsCA {
+		t.Error("CA certificate should have IsCA set")
+	}
+
+	info, err := os.Stat(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		t.Fatalf("CA key not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("CA key permissions = %v, want 0600", info.Mode().Perm())
+	}
+
+	reloaded, created, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA() reload returned error: %v", err)
+	}
+	if created {
+		t.Error("expected the existing CA to be reused")
+	}
+	if !reloaded.Cert.Equal(ca.Cert) {
+		t.Error("reloaded CA certificate differs from the original")
+	}
+}
+
+func TestIssueClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca, _, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA() returned error: %v", err)
+	}
+
+	certPath, keyPath, err := ca.IssueClientCert("laptop")
+	if err != nil {
+		t.Fatalf("IssueClientCert() returned error: %v", err)
+	}
+	if certPath != filepath.Join(dir, "clients", "laptop.crt") || keyPath != filepath.Join(dir, "clients", "laptop.key") {
+		t.Errorf("unexpected paths: %s, %s", certPath, keyPath)
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("failed to read client cert: %v", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("failed to decode client cert PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse client cert: %v", err)
+	}
+	if cert.Subject.CommonName != "laptop" {
+		t.Errorf("CommonName = %s, want laptop", cert.Subject.CommonName)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("client certificate does not verify against CA: %v", err)
+	}
+}
+
+func TestIssueClientCert_InvalidName(t *testing.T) {
+	ca, _, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA() returned error: %v", err)
+	}
+
+	for _, name := range []string{"", "../escape", "with space"} {
+		if _, _, err := ca.IssueClientCert(name); err == nil {
+			t.Errorf("IssueClientCert(%q) should fail", name)
+		}
+	}
+}
+
+func TestIssueServerCert(t *testing.T) {
+	ca, created, err := LoadOrCreateServiceCA(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadOrCreateServiceCA() returned error: %v", err)
+	}
+	if !created || ca.Cert.Subject.CommonName != "personal-server service CA" {
+		t.Errorf("service CA = %q (created %v), want a new personal-server service CA", ca.Cert.Subject.CommonName, created)
+	}
+
+	certPEM, keyPEM, err := ca.IssueServerCert([]string{"postgres.infra.svc.cluster.local", "postgres", "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("IssueServerCert() returned error: %v", err)
+	}
+	if block, _ := pem.Decode(keyPEM); block == nil || block.Type != "EC PRIVATE KEY" {
+		t.Error("IssueServerCert() did not return a PEM private key")
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("IssueServerCert() did not return a PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse server certificate: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	for _, host := range []string{"postgres", "postgres.infra.svc.cluster.local", "127.0.0.1"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+			t.Errorf("certificate does not verify for %s: %v", host, err)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err == nil {
+		t.Error("server certificate should not be usable for client authentication")
+	}
+
+	if _, _, err := ca.IssueServerCert(nil); err == nil {
+		t.Error("IssueServerCert(nil) succeeded, want error")
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ageRefPrefix = "age:"
+	// ageBinary is the age executable used to decrypt age: values
+	ageBinary = "age"
+	// ageArmorHeader starts ASCII-armored age ciphertext
+	ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+)
+
+// identityFile returns the age key file that decrypts age: values. It
+// defaults to the key sops uses, so one key serves both.
+func (a AgeConfig) identityFile() (string, error) {
+	if a.IdentityFile != "" {
+		return a.IdentityFile, nil
+	}
+	if file := os.Getenv("SOPS_AGE_KEY_FILE"); file != "" {
+		return file, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("age: values need age.identityFile or SOPS_AGE_KEY_FILE: %w", err)
+	}
+	return filepath.Join(dir, "sops", "age", "keys.txt"), nil
+}
+
+// decrypt decrypts an "age:" value: the ciphertext either base64-encoded on
+// one line or ASCII-armored. Trailing newlines of the plaintext are trimmed.
+func (a AgeConfig) decrypt(value string) (string, error) {
+	ciphertext := []byte(strings.TrimSpace(strings.TrimPrefix(value, ageRefPrefix)))
+	if !bytes.HasPrefix(ciphertext, []byte(ageArmorHeader)) {
+		decoded, err := base64.StdEncoding.DecodeString(string(ciphertext))
+		if err != nil {
+			return "", fmt.Errorf("invalid age value: must be base64 or ASCII-armored ciphertext: %v", err)
+		}
+		ciphertext = decoded
+	}
+
+	identity, err := a.identityFile()
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(ageBinary, "--decrypt", "--identity", identity)
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("decrypting age value: %s is not installed", ageBinary)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("decrypting age value: %s", msg)
+		}
+		return "", fmt.Errorf("decrypting age value: %v", err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeAge puts an age executable on PATH that "decrypts" by upper-casing
+// stdin, and fails unless given the identity file keys
+func fakeAge(t *testing.T, keys string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$3\" = \"" + keys + "\" ] || { echo \"age: error: no identity matched any of the recipients\" >&2; exit 1; }\ntr a-z A-Z\n"
+	if err := os.WriteFile(filepath.Join(dir, ageBinary), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestLoadConfig_AgeValues(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "keys.txt")
+	fakeAge(t, keys)
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("s3cret\n"))
+	path := writeTestConfig(t, `age:
+  identityFile: `+keys+`
+modules:
+  - name: postgres
+    namespace: infra
+    secrets:
+      admin_postgres_password: age:`+encoded+`
+      admin_postgres_user: |
+        age:-----BEGIN AGE ENCRYPTED FILE-----
+        postgres
+        -----END AGE ENCRYPTED FILE-----
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	secrets := cfg.Modules[0].Secrets
+	if secrets["admin_postgres_password"] != "S3CRET" {
+		t.Errorf("base64 value = %q, want S3CRET", secrets["admin_postgres_password"])
+	}
+	if !strings.Contains(secrets["admin_postgres_user"], "\nPOSTGRES\n") {
+		t.Errorf("armored value = %q, want the armored ciphertext decrypted", secrets["admin_postgres_user"])
+	}
+
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(t.TempDir(), "other.txt"))
+	cfg.Age.IdentityFile = ""
+	if _, err := cfg.Age.decrypt("age:" + encoded); err == nil || !strings.Contains(err.Error(), "no identity matched") {
+		t.Errorf("decrypt() with the wrong key error = %v, want age's error", err)
+	}
+	if _, err := cfg.Age.decrypt("age:not base64!"); err == nil || !strings.Contains(err.Error(), "invalid age value") {
+		t.Errorf("decrypt() error = %v, want invalid ciphertext", err)
+	}
+}
@@ -1,118 +1,420 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/Goalt/personal-server/internal/k8s"
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Module represents a module configuration
 type Module struct {
-	Name      string            `yaml:"name"`
-	Namespace string            `yaml:"namespace"`
-	Image     string            `yaml:"image,omitempty"`
-	Secrets   map[string]string `yaml:"secrets"`
-	Envs      map[string]string `yaml:"envs,omitempty"`
+	Name         string            `yaml:"name" required:"true" doc:"Module name; the prefix selects the implementation (e.g. postgres-infra)"`
+	Namespace    string            `yaml:"namespace" required:"true" doc:"Kubernetes namespace the module is deployed to"`
+	Image        string            `yaml:"image,omitempty" doc:"Image of the module's main container, e.g. postgres:17 (default: pinned by the module)"`
+	Storage      string            `yaml:"storage,omitempty" doc:"Size of the module's data volume, e.g. 20Gi (default: set by the module)"`
+	StorageClass string            `yaml:"storageClass,omitempty" doc:"StorageClass of the module's volumes (default: general.storageClass)"`
+	Secrets      map[string]string `yaml:"secrets" doc:"Module-specific settings and credentials (see config explain <module>)"`
+	Envs         map[string]string `yaml:"envs,omitempty" doc:"Extra environment variables for the module container"`
+	Labels       map[string]string `yaml:"labels,omitempty" doc:"Extra labels on the module's objects and pods; a key also in general.labels takes this value"`
+	Annotations  map[string]string `yaml:"annotations,omitempty" doc:"Extra annotations on the module's objects and pods; a key also in general.annotations takes this value"`
+	Ingress      *ModuleIngress    `yaml:"ingress,omitempty" doc:"Expose the module's web interface through the ingress controller; ingress: {} uses the defaults"`
+	Metrics      *ModuleMetrics    `yaml:"metrics,omitempty" doc:"Have Prometheus scrape the metrics the module serves; metrics: {} uses the defaults"`
+}
+
+// ModuleMetrics is how Prometheus finds the metrics a module serves: through
+// prometheus.io annotations on its Services, which the prometheus module
+// scrapes, or a ServiceMonitor for a Prometheus run by prometheus-operator
+type ModuleMetrics struct {
+	Port           int32  `yaml:"port,omitempty" doc:"Container port serving the metrics (default: the one behind the Service's http or first port)"`
+	Path           string `yaml:"path,omitempty" default:"/metrics" doc:"URL path of the metrics"`
+	Scheme         string `yaml:"scheme,omitempty" default:"http" doc:"http or https"`
+	ServiceMonitor bool   `yaml:"serviceMonitor,omitempty" default:"false" doc:"Create a prometheus-operator ServiceMonitor instead of the prometheus.io annotations"`
+	Interval       string `yaml:"interval,omitempty" doc:"Scrape interval of the ServiceMonitor, e.g. 30s (default: Prometheus's)"`
+}
+
+// ModuleIngress is the Ingress a module with a web interface creates for its
+// Service
+type ModuleIngress struct {
+	Host     string `yaml:"host,omitempty" doc:"Hostname routed to the module (default: <module name>.<general.domain>)"`
+	Path     string `yaml:"path,omitempty" default:"/" doc:"URL path routed to the module"`
+	PathType string `yaml:"pathType,omitempty" default:"Prefix" doc:"Prefix, Exact or ImplementationSpecific"`
+	TLS      *bool  `yaml:"tls,omitempty" doc:"Serve the module over HTTPS, or false for plain HTTP (default: general.ingressTLS.enabled)"`
+	// BasicAuth is for apps without a login of their own, such as static
+	// sites or dashboards
+	BasicAuth *IngressBasicAuth `yaml:"basicAuth,omitempty" doc:"Protect the module with HTTP basic-auth (realm, users: {name: password})"`
+}
+
+// IngressTLS reports whether the module's Ingress terminates TLS: ingress.tls
+// when set, else general.ingressTLS.enabled
+func (m Module) IngressTLS(general GeneralConfig) bool {
+	if m.Ingress != nil && m.Ingress.TLS != nil {
+		return *m.Ingress.TLS
+	}
+	return general.IngressTLS.Enabled
+}
+
+// IngressHost returns the hostname of the module's Ingress: ingress.host when
+// set, else <name>.<general.domain>
+func (m Module) IngressHost(general GeneralConfig) string {
+	if m.Ingress != nil && m.Ingress.Host != "" {
+		return m.Ingress.Host
+	}
+	return m.Name + "." + general.Domain
+}
+
+// ContainerImage returns the image of the module's main container: image
+// when set, else fallback, the version the module pins
+func (m Module) ContainerImage(fallback string) string {
+	if m.Image != "" {
+		return m.Image
+	}
+	return fallback
+}
+
+// StorageSize returns the size of the module's data volume: storage when set,
+// else fallback, the module's default
+func (m Module) StorageSize(fallback string) (resource.Quantity, error) {
+	size := m.Storage
+	if size == "" {
+		size = fallback
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil || quantity.Sign() <= 0 {
+		return resource.Quantity{}, fmt.Errorf("invalid storage %q: must be a positive quantity such as 20Gi", size)
+	}
+	return quantity, nil
+}
+
+// StorageClassName returns the StorageClass of the module's volumes: its
+// storageClass when set, else general's. Empty leaves the cluster's default.
+func (m Module) StorageClassName(general GeneralConfig) string {
+	if m.StorageClass != "" {
+		return m.StorageClass
+	}
+	return general.StorageClass
+}
+
+// ObjectMetadata returns the labels and annotations added to the module's
+// objects: general's merged with the module's own, which win on conflict.
+// With a metrics section and no ServiceMonitor it also annotates the module's
+// Services for scraping.
+func (m Module) ObjectMetadata(general GeneralConfig) k8s.ObjectMetadata {
+	metadata := k8s.ObjectMetadata{
+		Labels:      mergeStringMaps(general.Labels, m.Labels),
+		Annotations: mergeStringMaps(general.Annotations, m.Annotations),
+	}
+	if m.Metrics != nil && !m.Metrics.ServiceMonitor {
+		metadata.Scrape = &k8s.Scrape{Port: m.Metrics.Port, Path: m.Metrics.Path, Scheme: m.Metrics.Scheme}
+	}
+	return metadata
+}
+
+// mergeStringMaps returns base overlaid with override, or nil when both are
+// empty
+func mergeStringMaps(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
 }
 
 // ServicePort represents a service port configuration
 type ServicePort struct {
-	Name       string `yaml:"name"`
-	Port       int32  `yaml:"port"`
-	TargetPort int32  `yaml:"targetPort"`
+	Name       string `yaml:"name" required:"true" doc:"Port name"`
+	Port       int32  `yaml:"port" required:"true" doc:"Service port"`
+	TargetPort int32  `yaml:"targetPort" required:"true" doc:"Container port traffic is forwarded to"`
 }
 
 // ServiceConfig represents service configuration for a pet project
 type ServiceConfig struct {
-	Ports []ServicePort `yaml:"ports"`
+	Ports []ServicePort `yaml:"ports" doc:"Ports exposed by the Service"`
 }
 
 // IngressRule represents a single ingress routing rule
 type IngressRule struct {
-	Host        string `yaml:"host"`
-	Path        string `yaml:"path"`
-	PathType    string `yaml:"pathType,omitempty"` // Prefix, Exact, ImplementationSpecific
-	ServiceName string `yaml:"serviceName"`
-	ServicePort int32  `yaml:"servicePort"`
+	Host        string `yaml:"host" doc:"Hostname matched by the rule"`
+	Path        string `yaml:"path" required:"true" doc:"URL path matched by the rule"`
+	PathType    string `yaml:"pathType,omitempty" default:"Prefix" doc:"Prefix, Exact or ImplementationSpecific"`
+	ServiceName string `yaml:"serviceName" required:"true" doc:"Backend Service name"`
+	ServicePort int32  `yaml:"servicePort" required:"true" doc:"Backend Service port"`
 }
 
 // TCPService represents a TCP service exposed through the ingress controller
 type TCPService struct {
-	Port        int32  `yaml:"port"`                // External port exposed by ingress controller
-	ServiceName string `yaml:"serviceName"`         // Kubernetes service name
-	ServicePort int32  `yaml:"servicePort"`         // Service port to forward to
-	Namespace   string `yaml:"namespace,omitempty"` // Optional: service namespace (defaults to ingress namespace)
+	Port        int32  `yaml:"port" required:"true" doc:"External port exposed by ingress controller"`
+	ServiceName string `yaml:"serviceName" required:"true" doc:"Kubernetes service name"`
+	ServicePort int32  `yaml:"servicePort" required:"true" doc:"Service port to forward to"`
+	Namespace   string `yaml:"namespace,omitempty" doc:"Service namespace (defaults to ingress namespace)"`
 }
 
 // UDPService represents a UDP service exposed through the ingress controller
 type UDPService struct {
-	Port        int32  `yaml:"port"`                // External port exposed by ingress controller
-	ServiceName string `yaml:"serviceName"`         // Kubernetes service name
-	ServicePort int32  `yaml:"servicePort"`         // Service port to forward to
-	Namespace   string `yaml:"namespace,omitempty"` // Optional: service namespace (defaults to ingress namespace)
+	Port        int32  `yaml:"port" required:"true" doc:"External port exposed by ingress controller"`
+	ServiceName string `yaml:"serviceName" required:"true" doc:"Kubernetes service name"`
+	ServicePort int32  `yaml:"servicePort" required:"true" doc:"Service port to forward to"`
+	Namespace   string `yaml:"namespace,omitempty" doc:"Service namespace (defaults to ingress namespace)"`
+}
+
+// IngressBasicAuth represents HTTP basic-auth protection for an ingress
+type IngressBasicAuth struct {
+	Realm string            `yaml:"realm,omitempty" default:"Authentication Required" doc:"Realm shown in the browser prompt"`
+	Users map[string]string `yaml:"users" required:"true" doc:"Username -> plaintext password, hashed into an htpasswd Secret"`
 }
 
 // IngressConfig represents ingress configuration
 type IngressConfig struct {
-	Name        string        `yaml:"name"`
-	Namespace   string        `yaml:"namespace"`
-	Rules       []IngressRule `yaml:"rules"`
-	TCPServices []TCPService  `yaml:"tcpServices,omitempty"`
-	UDPServices []UDPService  `yaml:"udpServices,omitempty"`
-	TLS         bool          `yaml:"tls,omitempty"`
+	Name                string            `yaml:"name" required:"true" doc:"Ingress name"`
+	Namespace           string            `yaml:"namespace" required:"true" doc:"Namespace of the Ingress and its backends"`
+	Rules               []IngressRule     `yaml:"rules" doc:"HTTP routing rules"`
+	TCPServices         []TCPService      `yaml:"tcpServices,omitempty" doc:"TCP ports forwarded by the ingress controller"`
+	UDPServices         []UDPService      `yaml:"udpServices,omitempty" doc:"UDP ports forwarded by the ingress controller"`
+	TLS                 bool              `yaml:"tls,omitempty" default:"false" doc:"Terminate TLS for the rule hosts"`
+	ClusterIssuer       string            `yaml:"clusterIssuer,omitempty" doc:"cert-manager ClusterIssuer issuing the TLS certificate, e.g. letsencrypt from the certmanager module"`
+	BasicAuth           *IngressBasicAuth `yaml:"basicAuth,omitempty" doc:"HTTP basic-auth protection"`
+	AllowedSourceRanges []string          `yaml:"allowedSourceRanges,omitempty" doc:"CIDRs allowed to reach the HTTP rules"`
+	ClientCertAuth      bool              `yaml:"clientCertAuth,omitempty" default:"false" doc:"Require client certificates issued by the local CA (mTLS)"`
+	CrowdSec            bool              `yaml:"crowdsec,omitempty" default:"false" doc:"Reject requests from IPs banned by the crowdsec module"`
 }
 
 // PetProject represents a pet project configuration
 type PetProject struct {
-	Name                string               `yaml:"name"`
-	Namespace           string               `yaml:"namespace"`
-	Image               string               `yaml:"image"`
-	ImagePullSecret     string               `yaml:"imagePullSecret,omitempty"`
-	Registry            string               `yaml:"registry,omitempty"`
-	RegistryCredentials *RegistryCredentials `yaml:"registryCredentials,omitempty"`
-	Environment         map[string]string    `yaml:"environment"`
-	Service             *ServiceConfig       `yaml:"service,omitempty"`
-	PrometheusPort      int32                `yaml:"prometheusPort,omitempty"`
+	Name                string               `yaml:"name" required:"true" doc:"Pet project name"`
+	Namespace           string               `yaml:"namespace" required:"true" doc:"Kubernetes namespace the project is deployed to"`
+	Image               string               `yaml:"image" required:"true" doc:"Container image"`
+	ImagePullSecret     string               `yaml:"imagePullSecret,omitempty" doc:"Existing image pull Secret name"`
+	Registry            string               `yaml:"registry,omitempty" doc:"Key of the registries entry used to pull the image"`
+	RegistryCredentials *RegistryCredentials `yaml:"registryCredentials,omitempty" doc:"Inline registry credentials"`
+	Environment         map[string]string    `yaml:"environment" doc:"Environment variables for the container"`
+	Service             *ServiceConfig       `yaml:"service,omitempty" doc:"Service exposing the project"`
+	PrometheusPort      int32                `yaml:"prometheusPort,omitempty" doc:"Port scraped by Prometheus"`
 }
 
 type GeneralConfig struct {
-	Domain     string   `yaml:"domain"`
-	Namespaces []string `yaml:"namespaces"`
+	Domain            string                  `yaml:"domain" required:"true" doc:"Base domain used for public hostnames"`
+	Namespaces        []string                `yaml:"namespaces" doc:"Namespaces managed by the tool"`
+	NamespaceCreation NamespaceCreationConfig `yaml:"namespaceCreation,omitempty" doc:"Creation and removal of component namespaces"`
+	Kubernetes        KubernetesConfig        `yaml:"kubernetes,omitempty" doc:"Kubernetes API client settings"`
+	Language          string                  `yaml:"language,omitempty" doc:"Language of CLI output: en or ru (default: from LC_ALL, LC_MESSAGES or LANG)"`
+	Timezone          string                  `yaml:"timezone,omitempty" doc:"IANA time zone of every pod and CronJob schedule, such as Europe/Berlin (default: the image's, usually UTC)"`
+	Locale            string                  `yaml:"locale,omitempty" doc:"Locale set as LANG in every pod, such as C.UTF-8; the images must provide it (default: the image's)"`
+	Proxy             ProxyConfig             `yaml:"proxy,omitempty" doc:"Outbound HTTP(S) proxy set in every pod"`
+	CABundle          string                  `yaml:"caBundle,omitempty" doc:"Path on the node of a PEM bundle every pod trusts instead of its image's CAs; it must include the public CAs as well as your own"`
+	IPFamilyPolicy    string                  `yaml:"ipFamilyPolicy,omitempty" doc:"IP families of module Services: SingleStack, PreferDualStack or RequireDualStack (default: the cluster's, single-stack)"`
+	StorageClass      string                  `yaml:"storageClass,omitempty" doc:"StorageClass of module volumes, e.g. longhorn or local-path; modules[].storageClass overrides it (default: the cluster's default class)"`
+	Labels            map[string]string       `yaml:"labels,omitempty" doc:"Extra labels on every generated object and pod, e.g. for network policy or monitoring selectors"`
+	Annotations       map[string]string       `yaml:"annotations,omitempty" doc:"Extra annotations on every generated object and pod"`
+	IngressTLS        IngressTLSConfig        `yaml:"ingressTLS,omitempty" doc:"HTTPS with cert-manager certificates for the Ingresses of exposed modules"`
+	// Endpoints maps a module kind (e.g. "postgres") to the host:port other
+	// modules connect to. It is filled in from the configured modules when
+	// modules are created and is never read from the config file.
+	Endpoints map[string]string `yaml:"-"`
+	// TLSCASecrets maps a module kind to the Secret whose ca.crt verifies the
+	// TLS certificate served at its endpoint. Like Endpoints it is filled in
+	// from the configured modules; kinds serving plain connections are absent.
+	TLSCASecrets map[string]string `yaml:"-"`
+	// Clients are the Kubernetes clients of the run, built once by the app and
+	// handed to modules with the rest of the general config
+	Clients *k8s.Clients `yaml:"-"`
+}
+
+// Endpoint returns the endpoint of the configured module of the given kind,
+// or fallback when no such module is configured
+func (g GeneralConfig) Endpoint(kind, fallback string) string {
+	if endpoint, ok := g.Endpoints[kind]; ok && endpoint != "" {
+		return endpoint
+	}
+	return fallback
+}
+
+// ObjectMetadata returns the labels and annotations added to the objects of
+// components without module settings, such as pet projects and ingresses
+func (g GeneralConfig) ObjectMetadata() k8s.ObjectMetadata {
+	return k8s.ObjectMetadata{Labels: g.Labels, Annotations: g.Annotations}
+}
+
+// TLSCASecret returns the Secret holding the CA that verifies the configured
+// module of the given kind, or "" when it does not serve TLS
+func (g GeneralConfig) TLSCASecret(kind string) string {
+	return g.TLSCASecrets[kind]
+}
+
+// PodEnvironment returns what every module's pods inherit from the general
+// config
+func (g GeneralConfig) PodEnvironment() k8s.PodEnvironment {
+	return k8s.PodEnvironment{
+		Timezone:   g.Timezone,
+		Locale:     g.Locale,
+		HTTPProxy:  g.Proxy.HTTP,
+		HTTPSProxy: g.Proxy.HTTPS,
+		NoProxy:    g.Proxy.NoProxy,
+		CABundle:   g.CABundle,
+	}
+}
+
+// ProxyConfig is the outbound proxy pods use to reach the internet
+type ProxyConfig struct {
+	HTTP    string   `yaml:"http,omitempty" doc:"Proxy URL for plain HTTP, set as HTTP_PROXY"`
+	HTTPS   string   `yaml:"https,omitempty" doc:"Proxy URL for HTTPS, set as HTTPS_PROXY"`
+	NoProxy []string `yaml:"noProxy,omitempty" doc:"Hosts, domains (.example.com) and CIDRs reached directly; loopback and cluster-internal (.svc, .cluster.local) names are always added"`
+}
+
+// NamespaceCreationConfig controls the namespaces apply creates for
+// components. They are labelled managed-by=personal-server, which is what
+// clean checks before deleting one.
+type NamespaceCreationConfig struct {
+	Disabled        bool `yaml:"disabled,omitempty" default:"false" doc:"Do not create a missing namespace on apply; the apply then fails"`
+	DeleteWhenEmpty bool `yaml:"deleteWhenEmpty,omitempty" default:"false" doc:"Let <module> clean delete the module's namespace when it was created by the tool and nothing is left in it"`
+}
+
+// IngressTLSConfig turns on HTTPS for the modules exposed with an ingress
+// section. Their Ingresses get a TLS block and the cert-manager.io/cluster-issuer
+// annotation, so cert-manager issues each certificate into <module>-tls.
+type IngressTLSConfig struct {
+	Enabled       bool   `yaml:"enabled,omitempty" default:"false" doc:"Serve every exposed module over HTTPS; a module's ingress.tls overrides it"`
+	ClusterIssuer string `yaml:"clusterIssuer,omitempty" default:"letsencrypt" doc:"cert-manager ClusterIssuer issuing the certificates, e.g. letsencrypt-staging from the certmanager module"`
+}
+
+// Issuer returns the ClusterIssuer of module certificates, letsencrypt unless
+// clusterIssuer says otherwise
+func (t IngressTLSConfig) Issuer() string {
+	if t.ClusterIssuer != "" {
+		return t.ClusterIssuer
+	}
+	return "letsencrypt"
+}
+
+// KubernetesConfig tunes the shared Kubernetes API client
+type KubernetesConfig struct {
+	QPS     float32 `yaml:"qps,omitempty" default:"20" doc:"Client-side rate limit in requests per second"`
+	Burst   int     `yaml:"burst,omitempty" default:"40" doc:"Maximum burst above the QPS limit"`
+	Timeout string  `yaml:"timeout,omitempty" default:"30s" doc:"Per-request timeout (Go duration)"`
+	// Kubeconfig and Context select the cluster; --kubeconfig and --context override them
+	Kubeconfig string `yaml:"kubeconfig,omitempty" doc:"Path to the kubeconfig file (default: $KUBECONFIG or ~/.kube/config, else the in-cluster service account)"`
+	Context    string `yaml:"context,omitempty" doc:"Kubeconfig context to use instead of its current-context"`
 }
 
 // RegistryCredentials represents credentials for a container registry
 type RegistryCredentials struct {
-	Server    string `yaml:"server"`
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	Email     string `yaml:"email,omitempty"`
-	Namespace string `yaml:"namespace,omitempty"`
+	Server    string `yaml:"server" required:"true" doc:"Registry server address"`
+	Username  string `yaml:"username" required:"true" doc:"Registry username"`
+	Password  string `yaml:"password" required:"true" doc:"Registry password or token"`
+	Email     string `yaml:"email,omitempty" doc:"Registry account e-mail"`
+	Namespace string `yaml:"namespace,omitempty" doc:"Namespace the pull Secret is created in"`
+}
+
+// NamespaceQuota represents the resource budget of one namespace: the totals
+// its workloads may claim and the per-container defaults and bounds applied to
+// pods that do not set their own. Keys are Kubernetes resource names and
+// values are quantities, e.g. requests.cpu: "2" or memory: 512Mi.
+type NamespaceQuota struct {
+	Hard           map[string]string `yaml:"hard,omitempty" doc:"ResourceQuota totals, e.g. requests.cpu, limits.memory, requests.storage, persistentvolumeclaims, pods"`
+	Default        map[string]string `yaml:"default,omitempty" doc:"LimitRange limits given to containers that set none, e.g. cpu, memory"`
+	DefaultRequest map[string]string `yaml:"defaultRequest,omitempty" doc:"LimitRange requests given to containers that set none (default: the default limits)"`
+	Max            map[string]string `yaml:"max,omitempty" doc:"LimitRange upper bound of any one container's limits"`
 }
 
 // BackupConfig represents the backup configuration
 type BackupConfig struct {
-	WebdavHost     string `yaml:"webdav_host"`
-	WebdavUsername string `yaml:"webdav_username"`
-	WebdavPassword string `yaml:"webdav_password"`
-	SentryDSN      string `yaml:"sentry_dsn"`
-	Cron           string `yaml:"cron"`
-	Passphrase     string `yaml:"passphrase"`
+	WebdavHost     string `yaml:"webdav_host" doc:"WebDAV server backups are uploaded to"`
+	WebdavUsername string `yaml:"webdav_username" doc:"WebDAV username"`
+	WebdavPassword string `yaml:"webdav_password" doc:"WebDAV password"`
+	SentryDSN      string `yaml:"sentry_dsn" doc:"Sentry DSN for backup failure reports"`
+	Cron           string `yaml:"cron" doc:"Cron schedule used by backup schedule and backup daemon"`
+	Jitter         string `yaml:"jitter,omitempty" doc:"Random delay of up to this duration added to every backup daemon run, e.g. 10m"`
+	Passphrase     string `yaml:"passphrase" doc:"GPG passphrase used to encrypt archives"`
+}
+
+// IssuesConfig represents the Gitea repository an issue is opened in when
+// scheduled backups or the operator keep failing
+type IssuesConfig struct {
+	URL        string `yaml:"url,omitempty" doc:"Base URL of the Gitea server, e.g. https://git.example.com"`
+	Repository string `yaml:"repository,omitempty" doc:"Repository issues are opened in, as owner/name"`
+	Token      string `yaml:"token,omitempty" doc:"Gitea API token allowed to write issues"`
+	Threshold  int    `yaml:"threshold,omitempty" default:"3" doc:"Consecutive failed backup runs before an issue is opened"`
+	// reconciles are retried with exponential backoff, so the default
+	// takes about an hour of failing to reach
+	OperatorThreshold int `yaml:"operatorThreshold,omitempty" default:"20" doc:"Consecutive failed reconciles of one object before the operator opens an issue"`
+}
+
+// VaultConfig represents the HashiCorp Vault server that vault: secret
+// references are read from
+type VaultConfig struct {
+	Address   string `yaml:"address,omitempty" doc:"URL of the Vault server (default: VAULT_ADDR)"`
+	Token     string `yaml:"token,omitempty" doc:"Vault token, usually an env: or file: reference (default: VAULT_TOKEN)"`
+	Mount     string `yaml:"mount,omitempty" default:"secret" doc:"Path the KV version 2 secrets engine is mounted at"`
+	Namespace string `yaml:"namespace,omitempty" doc:"Vault Enterprise namespace (default: VAULT_NAMESPACE)"`
+}
+
+// AgeConfig represents the key that decrypts age: secret values
+type AgeConfig struct {
+	IdentityFile string `yaml:"identityFile,omitempty" doc:"age key file decrypting age: values (default: SOPS_AGE_KEY_FILE, then ~/.config/sops/age/keys.txt)"`
+}
+
+// ReportConfig represents the power model used by report usage to turn
+// resource consumption into energy and cost
+type ReportConfig struct {
+	PricePerKWh  float64 `yaml:"pricePerKWh,omitempty" default:"0.30" doc:"Electricity price per kWh"`
+	Currency     string  `yaml:"currency,omitempty" default:"€" doc:"Currency symbol printed with costs"`
+	WattsPerCore float64 `yaml:"wattsPerCore,omitempty" default:"8" doc:"Power drawn by one fully busy CPU core above idle"`
+	WattsPerGiB  float64 `yaml:"wattsPerGiB,omitempty" default:"0.4" doc:"Power drawn by one GiB of memory in use"`
+	WattsPerTiB  float64 `yaml:"wattsPerTiB,omitempty" default:"1.5" doc:"Power drawn by one TiB of provisioned storage"`
 }
 
 // Config represents the application configuration
 type Config struct {
-	Path        string                         `yaml:"-"`
-	General     GeneralConfig                  `yaml:"general"`
-	Backup      BackupConfig                   `yaml:"backup"`
-	Registries  map[string]RegistryCredentials `yaml:"registries,omitempty"`
-	Modules     []Module                       `yaml:"modules"`
-	PetProjects []PetProject                   `yaml:"pet-projects"`
-	Ingresses   []IngressConfig                `yaml:"ingresses,omitempty"`
+	Path          string                         `yaml:"-"`
+	ConfigVersion int                            `yaml:"configVersion,omitempty" default:"1" doc:"Config schema version"`
+	General       GeneralConfig                  `yaml:"general" required:"true" doc:"Settings shared by all modules"`
+	Variables     map[string]string              `yaml:"variables,omitempty" doc:"Values referenced as ${vars.<name>}"`
+	Backup        BackupConfig                   `yaml:"backup" doc:"Global backup settings"`
+	Registries    map[string]RegistryCredentials `yaml:"registries,omitempty" doc:"Named container registry credentials"`
+	Report        ReportConfig                   `yaml:"report,omitempty" doc:"Power model of report usage"`
+	Issues        IssuesConfig                   `yaml:"issues,omitempty" doc:"Gitea issues opened on repeated backup or operator failures"`
+	Vault         VaultConfig                    `yaml:"vault,omitempty" doc:"HashiCorp Vault server that vault: secret references are read from"`
+	Age           AgeConfig                      `yaml:"age,omitempty" doc:"Key that decrypts age: encrypted secret values"`
+	Quotas        map[string]NamespaceQuota      `yaml:"quotas,omitempty" doc:"Resource budgets keyed by namespace"`
+	Modules       []Module                       `yaml:"modules" doc:"Infrastructure modules"`
+	PetProjects   []PetProject                   `yaml:"pet-projects" doc:"Pet project deployments"`
+	Ingresses     []IngressConfig                `yaml:"ingresses,omitempty" doc:"Ingress definitions"`
+
+	// source is the document as written, before templates are resolved.
+	// SaveConfig writes it back so that ${...} references survive edits.
+	source *yaml.Node
+	// migrations lists the schema migrations applied while loading
+	migrations []string
+	// encrypted is set when the file was decrypted with SOPS on load
+	encrypted bool
 }
 
 // LoadConfig loads and parses the configuration file
 func LoadConfig(configFile string) (*Config, error) {
+	config, err := ReadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+	return config, nil
+}
+
+// ReadConfig loads and parses the configuration file like LoadConfig, but
+// does not validate it. Problems lists what LoadConfig would reject.
+func ReadConfig(configFile string) (*Config, error) {
 	// Check if config file exists
 	if _, err := os.Stat(configFile); os.IsNotExist(err) {
 		return nil, fmt.Errorf("config file not found: %s", configFile)
@@ -123,19 +425,217 @@ func LoadConfig(configFile string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading config file: %v", err)
 	}
+	encrypted := isSOPSEncrypted(data)
+	if encrypted {
+		if data, err = decryptSOPS(configFile); err != nil {
+			return nil, fmt.Errorf("error decrypting config file: %v", err)
+		}
+	}
 
-	// Parse YAML
+	// Upgrade documents written for older schema versions
+	var source yaml.Node
+	if err := yaml.Unmarshal(data, &source); err != nil {
+		return nil, fmt.Errorf("error parsing YAML config: %v", err)
+	}
+	applied, err := migrateDocument(&source)
+	if err != nil {
+		return nil, fmt.Errorf("error migrating config: %v", err)
+	}
+	if len(applied) > 0 {
+		if data, err = yaml.Marshal(&source); err != nil {
+			return nil, fmt.Errorf("error encoding migrated config: %v", err)
+		}
+	}
+
+	// Parse YAML and resolve ${...} references
 	var config Config
-	err = yaml.Unmarshal(data, &config)
+	err = resolveTemplates(data, &config)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing YAML config: %v", err)
 	}
 
+	if err := config.resolveSecretRefs(); err != nil {
+		return nil, fmt.Errorf("error resolving secret reference: %v", err)
+	}
+
+	if source.Kind == yaml.DocumentNode {
+		config.source = &source
+	}
+	config.migrations = applied
+	config.encrypted = encrypted
 	config.Path = configFile
 
 	return &config, nil
 }
 
+// validate checks the values modules would otherwise only reject on generate
+// or apply
+func (c *Config) validate() error {
+	if problems := c.Problems(); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// Problems returns every problem in the config, in file order. LoadConfig
+// rejects a config with the first of them.
+func (c *Config) Problems() []error {
+	var problems []error
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if domain := c.General.Domain; domain != "" {
+		if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+			add("general.domain: invalid domain %q: %s", domain, strings.Join(errs, "; "))
+		}
+	}
+	for _, ns := range c.General.Namespaces {
+		if err := validateNamespace(ns); err != nil {
+			add("general.namespaces: %v", err)
+		}
+	}
+	if err := k8s.ValidateStorageClass(c.General.StorageClass); err != nil {
+		add("general.storageClass: %v", err)
+	}
+	if err := k8s.ValidateObjectMetadata(c.General.ObjectMetadata()); err != nil {
+		add("general: %v", err)
+	}
+	if issuer := c.General.IngressTLS.ClusterIssuer; issuer != "" {
+		if errs := validation.IsDNS1123Subdomain(issuer); len(errs) > 0 {
+			add("general.ingressTLS: invalid clusterIssuer %q: %s", issuer, strings.Join(errs, "; "))
+		}
+	}
+	if c.Backup.Jitter != "" {
+		if jitter, err := time.ParseDuration(c.Backup.Jitter); err != nil || jitter < 0 {
+			add("backup.jitter: invalid duration %q: must be a duration such as 10m", c.Backup.Jitter)
+		}
+	}
+	for _, m := range c.Modules {
+		if m.Namespace != "" {
+			if err := validateNamespace(m.Namespace); err != nil {
+				add("module %s: %v", m.Name, err)
+			}
+		}
+		if err := k8s.ValidateStorageClass(m.StorageClass); err != nil {
+			add("module %s: %v", m.Name, err)
+		}
+		if err := k8s.ValidateObjectMetadata(k8s.ObjectMetadata{Labels: m.Labels, Annotations: m.Annotations}); err != nil {
+			add("module %s: %v", m.Name, err)
+		}
+		if m.Ingress != nil {
+			if err := m.Ingress.validate(m.IngressHost(c.General)); err != nil {
+				add("module %s: %v", m.Name, err)
+			}
+		}
+		if m.Metrics != nil {
+			if err := m.Metrics.validate(); err != nil {
+				add("module %s: %v", m.Name, err)
+			}
+		}
+		if m.Storage != "" {
+			if _, err := m.StorageSize(""); err != nil {
+				add("module %s: %v", m.Name, err)
+			}
+		}
+		if _, ok := m.Secrets["storage_size"]; ok {
+			add("module %s: storage_size is set as storage on the module, not in its secrets", m.Name)
+		}
+		for _, key := range imageSecrets {
+			if _, ok := m.Secrets[key]; ok {
+				add("module %s: %s is set as image on the module, not in its secrets", m.Name, key)
+			}
+		}
+	}
+	for _, ing := range c.Ingresses {
+		if ing.Namespace != "" {
+			if err := validateNamespace(ing.Namespace); err != nil {
+				add("ingress %s: %v", ing.Name, err)
+			}
+		}
+	}
+	for _, p := range c.PetProjects {
+		if p.Namespace != "" {
+			if err := validateNamespace(p.Namespace); err != nil {
+				add("pet project %s: %v", p.Name, err)
+			}
+		}
+	}
+	return problems
+}
+
+// validateNamespace checks that ns can name a Kubernetes namespace
+func validateNamespace(ns string) error {
+	if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validate checks the hostname, path, path type and basic-auth users of a
+// module's Ingress
+func (i ModuleIngress) validate(host string) error {
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return fmt.Errorf("invalid ingress host %q: %s", host, strings.Join(errs, "; "))
+	}
+	if i.Path != "" && !strings.HasPrefix(i.Path, "/") {
+		return fmt.Errorf("invalid ingress path %q: must start with /", i.Path)
+	}
+	switch i.PathType {
+	case "", "Prefix", "Exact", "ImplementationSpecific":
+	default:
+		return fmt.Errorf("invalid ingress pathType %q: want Prefix, Exact or ImplementationSpecific", i.PathType)
+	}
+	if i.BasicAuth != nil {
+		if len(i.BasicAuth.Users) == 0 {
+			return fmt.Errorf("ingress basicAuth needs at least one user")
+		}
+		for name := range i.BasicAuth.Users {
+			if name == "" || strings.Contains(name, ":") {
+				return fmt.Errorf("invalid ingress basicAuth user %q", name)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the port, path, scheme and interval Prometheus scrapes
+func (m ModuleMetrics) validate() error {
+	if m.Port < 0 || m.Port > 65535 {
+		return fmt.Errorf("invalid metrics port %d: must be between 1 and 65535", m.Port)
+	}
+	if m.Path != "" && !strings.HasPrefix(m.Path, "/") {
+		return fmt.Errorf("invalid metrics path %q: must start with /", m.Path)
+	}
+	switch m.Scheme {
+	case "", "http", "https":
+	default:
+		return fmt.Errorf("invalid metrics scheme %q: want http or https", m.Scheme)
+	}
+	if m.Interval != "" {
+		if !m.ServiceMonitor {
+			return fmt.Errorf("metrics interval is only used with serviceMonitor: true")
+		}
+		if d, err := time.ParseDuration(m.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid metrics interval %q: must be a positive duration such as 30s", m.Interval)
+		}
+	}
+	return nil
+}
+
+// AppliedMigrations returns the schema migrations applied in memory while
+// loading. A non-empty result means the file on disk is outdated and should be
+// rewritten with SaveConfig.
+func (c *Config) AppliedMigrations() []string {
+	return c.migrations
+}
+
+// Encrypted reports whether the file was decrypted with SOPS on load.
+// SaveConfig refuses to write such a config back.
+func (c *Config) Encrypted() bool {
+	return c.encrypted
+}
+
 // GetModule retrieves a module by name
 func (c *Config) GetModule(name string) (Module, error) {
 	for _, module := range c.Modules {
@@ -181,19 +681,133 @@ func (c *Config) SetModuleImage(moduleName, image string) error {
 	for i, module := range c.Modules {
 		if module.Name == moduleName {
 			c.Modules[i].Image = image
+			if c.source != nil {
+				setSourceModuleImage(c.source, moduleName, image)
+			}
 			return nil
 		}
 	}
 	return fmt.Errorf("module not found: %s", moduleName)
 }
 
+// setSourceModuleImage mirrors SetModuleImage on the unresolved document
+func setSourceModuleImage(doc *yaml.Node, moduleName, image string) {
+	modules := mappingValue(doc.Content[0], "modules")
+	if modules == nil || modules.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, module := range modules.Content {
+		name := mappingValue(module, "name")
+		if name == nil || name.Value != moduleName {
+			continue
+		}
+		if existing := mappingValue(module, "image"); existing != nil {
+			existing.Kind = yaml.ScalarNode
+			existing.Tag = "!!str"
+			existing.Style = 0
+			existing.Value = image
+			return
+		}
+		module.Content = append(module.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "image"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: image},
+		)
+		return
+	}
+}
+
+// SetModuleSecret sets a key of a module's secrets, both in the loaded config
+// and in the document SaveConfig writes
+func (c *Config) SetModuleSecret(moduleName, key, value string) error {
+	for i, module := range c.Modules {
+		if module.Name == moduleName {
+			if c.Modules[i].Secrets == nil {
+				c.Modules[i].Secrets = make(map[string]string)
+			}
+			c.Modules[i].Secrets[key] = value
+			if c.source != nil {
+				setSourceModuleSecret(c.source, moduleName, key, value)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("module not found: %s", moduleName)
+}
+
+// setSourceModuleSecret mirrors SetModuleSecret on the unresolved document
+func setSourceModuleSecret(doc *yaml.Node, moduleName, key, value string) {
+	modules := mappingValue(doc.Content[0], "modules")
+	if modules == nil || modules.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, module := range modules.Content {
+		name := mappingValue(module, "name")
+		if name == nil || name.Value != moduleName {
+			continue
+		}
+		secrets := mappingValue(module, "secrets")
+		if secrets == nil || secrets.Kind != yaml.MappingNode {
+			if secrets != nil {
+				// replace an empty "secrets:" entry
+				*secrets = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			} else {
+				secrets = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				module.Content = append(module.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "secrets"},
+					secrets,
+				)
+			}
+		}
+		if existing := mappingValue(secrets, key); existing != nil {
+			existing.Kind = yaml.ScalarNode
+			existing.Tag = "!!str"
+			existing.Style = 0
+			existing.Value = value
+			return
+		}
+		secrets.Content = append(secrets.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+		)
+		return
+	}
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // SaveConfig writes the configuration back to its file
 func (c *Config) SaveConfig() error {
 	if c.Path == "" {
 		return fmt.Errorf("config path is not set")
 	}
+	if c.encrypted {
+		return fmt.Errorf("config file %s is encrypted with SOPS; edit it with 'sops %s' instead", c.Path, c.Path)
+	}
 
-	data, err := yaml.Marshal(c)
+	var data []byte
+	var err error
+	if c.source != nil {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err = enc.Encode(c.source); err == nil {
+			err = enc.Close()
+		}
+		data = buf.Bytes()
+	} else {
+		data, err = yaml.Marshal(c)
+	}
 	if err != nil {
 		return fmt.Errorf("error marshaling config to YAML: %v", err)
 	}
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -208,6 +210,259 @@ modules:
 	}
 }
 
+func TestLoadConfig_ValidatesStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		module  string
+		wantErr string
+	}{
+		{name: "size", module: "    storage: 20Gi\n"},
+		{name: "not a quantity", module: "    storage: lots\n", wantErr: `module gitea: invalid storage "lots"`},
+		{name: "zero", module: "    storage: \"0\"\n", wantErr: `invalid storage "0"`},
+		{name: "in secrets", module: "    secrets:\n      storage_size: 20Gi\n", wantErr: "storage_size is set as storage on the module"},
+		{name: "image in secrets", module: "    storage: 20Gi\n    secrets:\n      image_tag: gitea:1.26\n", wantErr: "image_tag is set as image on the module"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, "configVersion: 3\nmodules:\n  - name: gitea\n    namespace: infra\n"+tt.module)
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			size, err := cfg.Modules[0].StorageSize("10Gi")
+			if err != nil || size.String() != "20Gi" {
+				t.Errorf("StorageSize() = %s, %v, want 20Gi", size.String(), err)
+			}
+		})
+	}
+
+	size, err := Module{}.StorageSize("10Gi")
+	if err != nil || size.String() != "10Gi" {
+		t.Errorf("StorageSize() without storage = %s, %v, want the fallback 10Gi", size.String(), err)
+	}
+}
+
+func TestLoadConfig_StorageClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		general string
+		module  string
+		want    string
+		wantErr string
+	}{
+		{name: "cluster default"},
+		{name: "general", general: "  storageClass: longhorn\n", want: "longhorn"},
+		{name: "module overrides general", general: "  storageClass: longhorn\n", module: "    storageClass: local-path\n", want: "local-path"},
+		{name: "invalid general", general: "  storageClass: Longhorn\n", wantErr: `general.storageClass: invalid storage class "Longhorn"`},
+		{name: "invalid module", module: "    storageClass: local_path\n", wantErr: `module gitea: invalid storage class "local_path"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, "configVersion: 2\ngeneral:\n  domain: example.com\n"+tt.general+"modules:\n  - name: gitea\n    namespace: infra\n"+tt.module)
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.Modules[0].StorageClassName(cfg.General); got != tt.want {
+				t.Errorf("StorageClassName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ObjectMetadata(t *testing.T) {
+	path := writeTestConfig(t, `configVersion: 3
+general:
+  domain: example.com
+  labels:
+    team: infra
+    tier: backend
+  annotations:
+    example.com/owner: ops
+modules:
+  - name: gitea
+    namespace: infra
+    labels:
+      tier: frontend
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := cfg.Modules[0].ObjectMetadata(cfg.General)
+	want := map[string]string{"team": "infra", "tier": "frontend"}
+	if !reflect.DeepEqual(metadata.Labels, want) {
+		t.Errorf("Labels = %v, want %v", metadata.Labels, want)
+	}
+	if metadata.Annotations["example.com/owner"] != "ops" {
+		t.Errorf("Annotations = %v, want the general ones", metadata.Annotations)
+	}
+
+	path = writeTestConfig(t, "configVersion: 3\ngeneral:\n  domain: example.com\nmodules:\n  - name: gitea\n    namespace: infra\n    labels:\n      app: other\n")
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "module gitea: label app is set by personal-server") {
+		t.Errorf("LoadConfig() error = %v, want the reserved label rejected", err)
+	}
+}
+
+func TestLoadConfig_ModuleIngress(t *testing.T) {
+	tests := []struct {
+		name    string
+		ingress string
+		want    string
+		wantErr string
+	}{
+		{name: "defaults", ingress: "    ingress: {}\n", want: "gitea.example.com"},
+		{name: "host", ingress: "    ingress:\n      host: git.example.org\n", want: "git.example.org"},
+		{name: "invalid host", ingress: "    ingress:\n      host: Git_Example\n", wantErr: `module gitea: invalid ingress host "Git_Example"`},
+		{name: "invalid path", ingress: "    ingress:\n      path: git\n", wantErr: `module gitea: invalid ingress path "git"`},
+		{name: "invalid path type", ingress: "    ingress:\n      pathType: Regex\n", wantErr: `module gitea: invalid ingress pathType "Regex"`},
+		{name: "basic-auth", ingress: "    ingress:\n      basicAuth:\n        users:\n          admin: secret\n", want: "gitea.example.com"},
+		{name: "basic-auth without users", ingress: "    ingress:\n      basicAuth:\n        realm: Git\n", wantErr: "module gitea: ingress basicAuth needs at least one user"},
+		{name: "invalid basic-auth user", ingress: "    ingress:\n      basicAuth:\n        users:\n          \"a:b\": secret\n", wantErr: `module gitea: invalid ingress basicAuth user "a:b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, "configVersion: 3\ngeneral:\n  domain: example.com\nmodules:\n  - name: gitea\n    namespace: infra\n"+tt.ingress)
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Modules[0].Ingress == nil {
+				t.Fatal("Ingress = nil, want the section")
+			}
+			if got := cfg.Modules[0].IngressHost(cfg.General); got != tt.want {
+				t.Errorf("IngressHost() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_IngressTLS(t *testing.T) {
+	tests := []struct {
+		name       string
+		general    string
+		ingress    string
+		wantTLS    bool
+		wantIssuer string
+		wantErr    string
+	}{
+		{name: "off by default", ingress: "    ingress: {}\n", wantIssuer: "letsencrypt"},
+		{name: "general", general: "  ingressTLS:\n    enabled: true\n", ingress: "    ingress: {}\n", wantTLS: true, wantIssuer: "letsencrypt"},
+		{name: "module opts out", general: "  ingressTLS:\n    enabled: true\n", ingress: "    ingress:\n      tls: false\n", wantIssuer: "letsencrypt"},
+		{name: "module opts in", general: "  ingressTLS:\n    clusterIssuer: letsencrypt-staging\n", ingress: "    ingress:\n      tls: true\n", wantTLS: true, wantIssuer: "letsencrypt-staging"},
+		{name: "invalid issuer", general: "  ingressTLS:\n    clusterIssuer: Lets_Encrypt\n", ingress: "    ingress: {}\n", wantErr: `general.ingressTLS: invalid clusterIssuer "Lets_Encrypt"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, "configVersion: 3\ngeneral:\n  domain: example.com\n"+tt.general+"modules:\n  - name: gitea\n    namespace: infra\n"+tt.ingress)
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.Modules[0].IngressTLS(cfg.General); got != tt.wantTLS {
+				t.Errorf("IngressTLS() = %v, want %v", got, tt.wantTLS)
+			}
+			if got := cfg.General.IngressTLS.Issuer(); got != tt.wantIssuer {
+				t.Errorf("Issuer() = %q, want %q", got, tt.wantIssuer)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ModuleMetrics(t *testing.T) {
+	tests := []struct {
+		name       string
+		metrics    string
+		wantScrape bool
+		wantErr    string
+	}{
+		{name: "annotations", metrics: "    metrics: {}\n", wantScrape: true},
+		{name: "service monitor", metrics: "    metrics:\n      serviceMonitor: true\n      interval: 30s\n"},
+		{name: "invalid port", metrics: "    metrics:\n      port: 70000\n", wantErr: "module gitea: invalid metrics port 70000"},
+		{name: "invalid path", metrics: "    metrics:\n      path: metrics\n", wantErr: `module gitea: invalid metrics path "metrics"`},
+		{name: "invalid scheme", metrics: "    metrics:\n      scheme: tcp\n", wantErr: `module gitea: invalid metrics scheme "tcp"`},
+		{name: "interval without service monitor", metrics: "    metrics:\n      interval: 30s\n", wantErr: "module gitea: metrics interval is only used with serviceMonitor"},
+		{name: "invalid interval", metrics: "    metrics:\n      serviceMonitor: true\n      interval: often\n", wantErr: `module gitea: invalid metrics interval "often"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, "configVersion: 3\ngeneral:\n  domain: example.com\nmodules:\n  - name: gitea\n    namespace: infra\n"+tt.metrics)
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scrape := cfg.Modules[0].ObjectMetadata(cfg.General).Scrape; (scrape != nil) != tt.wantScrape {
+				t.Errorf("ObjectMetadata().Scrape = %+v, want scrape annotations %v", scrape, tt.wantScrape)
+			}
+		})
+	}
+}
+
+func TestReadConfig_Problems(t *testing.T) {
+	path := writeTestConfig(t, `configVersion: 3
+general:
+  domain: -example.com
+backup:
+  jitter: soon
+modules:
+  - name: gitea
+    namespace: Infra
+    storage: lots
+  - name: redis
+    namespace: infra
+    metrics:
+      scheme: tcp
+`)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "general.domain") {
+		t.Fatalf("LoadConfig() error = %v, want the first problem", err)
+	}
+
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatalf("ReadConfig() error = %v", err)
+	}
+	problems := cfg.Problems()
+	want := []string{`general.domain: invalid domain "-example.com"`, `backup.jitter: invalid duration "soon"`, `module gitea: invalid namespace "Infra"`, "module gitea: invalid storage", `module redis: invalid metrics scheme "tcp"`}
+	if len(problems) != len(want) {
+		t.Fatalf("Problems() = %v, want %d problems", problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i].Error(), w) {
+			t.Errorf("Problems()[%d] = %v, want %q", i, problems[i], w)
+		}
+	}
+}
+
 func TestLoadConfig_UnreadableFile(t *testing.T) {
 	// Skip this test on systems where we can't change permissions
 	if os.Getuid() == 0 {
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the config schema version written by this build.
+// Files without a configVersion field are treated as version 0.
+const CurrentConfigVersion = 3
+
+// migration upgrades the raw config document from version From to From+1
+type migration struct {
+	From        int
+	Description string
+	Apply       func(root *yaml.Node) error
+}
+
+// migrations is the ordered upgrade pipeline. When the schema changes in an
+// incompatible way (renamed keys, new required fields), bump
+// CurrentConfigVersion and append a step that rewrites old documents.
+var migrations = []migration{
+	{
+		From:        0,
+		Description: "add configVersion field",
+		Apply:       func(root *yaml.Node) error { return nil },
+	},
+	{
+		From:        1,
+		Description: "move modules[].secrets.storage_size to modules[].storage",
+		Apply: func(root *yaml.Node) error {
+			return moveSecretToField(root, []string{"storage_size"}, "storage")
+		},
+	},
+	{
+		From:        2,
+		Description: "move modules[].secrets.image, image_tag and prometheus_image to modules[].image",
+		Apply: func(root *yaml.Node) error {
+			return moveSecretToField(root, imageSecrets, "image")
+		},
+	},
+}
+
+// imageSecrets are the secrets modules read their main container's image
+// from before configVersion 3
+var imageSecrets = []string{"image", "image_tag", "prometheus_image"}
+
+// moveSecretToField moves the first of keys found in each module's secrets
+// to the module field, placed after namespace, and drops the others. A field
+// already set wins.
+func moveSecretToField(root *yaml.Node, keys []string, field string) error {
+	modules := mappingValue(root, "modules")
+	if modules == nil || modules.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, module := range modules.Content {
+		if module.Kind != yaml.MappingNode {
+			continue
+		}
+		secrets := mappingValue(module, "secrets")
+		if secrets == nil || secrets.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(secrets.Content); {
+			if !containsString(keys, secrets.Content[i].Value) {
+				i += 2
+				continue
+			}
+			key, value := secrets.Content[i], secrets.Content[i+1]
+			secrets.Content = append(secrets.Content[:i], secrets.Content[i+2:]...)
+			if mappingValue(module, field) != nil {
+				continue
+			}
+			key.Value = field
+			at := len(module.Content)
+			for j := 0; j+1 < len(module.Content); j += 2 {
+				if module.Content[j].Value == "namespace" {
+					at = j + 2
+				}
+			}
+			module.Content = append(module.Content[:at], append([]*yaml.Node{key, value}, module.Content[at:]...)...)
+		}
+	}
+	return nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// configVersion reads the configVersion field of the root mapping
+func configVersion(root *yaml.Node) (int, error) {
+	node := mappingValue(root, "configVersion")
+	if node == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid configVersion %q", node.Value)
+	}
+	return version, nil
+}
+
+// setConfigVersion sets configVersion on the root mapping, placing it first
+// when the field is new
+func setConfigVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	if node := mappingValue(root, "configVersion"); node != nil {
+		node.Kind = yaml.ScalarNode
+		node.Tag = "!!int"
+		node.Style = 0
+		node.Value = value
+		return
+	}
+	root.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "configVersion"},
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: value},
+	}, root.Content...)
+}
+
+// migrateDocument upgrades doc in place to CurrentConfigVersion and returns
+// the descriptions of the applied steps.
+func migrateDocument(doc *yaml.Node) ([]string, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	root := doc.Content[0]
+
+	version, err := configVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	if version > CurrentConfigVersion {
+		return nil, fmt.Errorf("configVersion %d is newer than the supported version %d, please update personal-server", version, CurrentConfigVersion)
+	}
+
+	var applied []string
+	for _, m := range migrations {
+		if m.From < version {
+			continue
+		}
+		if err := m.Apply(root); err != nil {
+			return nil, fmt.Errorf("migrating config from version %d: %w", m.From, err)
+		}
+		setConfigVersion(root, m.From+1)
+		applied = append(applied, fmt.Sprintf("v%d -> v%d: %s", m.From, m.From+1, m.Description))
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_MigratesUnversionedConfig(t *testing.T) {
+	path := writeTestConfig(t, `general:
+  domain: example.com
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.ConfigVersion != CurrentConfigVersion {
+		t.Errorf("ConfigVersion = %d, want %d", cfg.ConfigVersion, CurrentConfigVersion)
+	}
+	applied := cfg.AppliedMigrations()
+	if len(applied) != CurrentConfigVersion || !strings.HasPrefix(applied[0], "v0 -> v1") {
+		t.Errorf("AppliedMigrations() = %v", applied)
+	}
+}
+
+func TestLoadConfig_CurrentVersionNeedsNoMigration(t *testing.T) {
+	path := writeTestConfig(t, `configVersion: 3
+general:
+  domain: example.com
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(cfg.AppliedMigrations()) != 0 {
+		t.Errorf("expected no migrations, got %v", cfg.AppliedMigrations())
+	}
+}
+
+func TestLoadConfig_MovesStorageSize(t *testing.T) {
+	path := writeTestConfig(t, `configVersion: 1
+modules:
+  - name: gitea
+    namespace: infra
+    secrets:
+      gitea_db_password: secret
+      storage_size: 50Gi
+  - name: tor
+    namespace: infra
+    storage: 1Gi
+    secrets:
+      storage_size: 100Mi
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	applied := cfg.AppliedMigrations()
+	if len(applied) != 2 || !strings.HasPrefix(applied[0], "v1 -> v2") {
+		t.Errorf("AppliedMigrations() = %v", applied)
+	}
+	for _, want := range []struct{ name, storage string }{{"gitea", "50Gi"}, {"tor", "1Gi"}} {
+		m, err := cfg.GetModule(want.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Storage != want.storage {
+			t.Errorf("%s storage = %q, want %q", want.name, m.Storage, want.storage)
+		}
+		if _, ok := m.Secrets["storage_size"]; ok {
+			t.Errorf("%s secrets = %v, want storage_size moved", want.name, m.Secrets)
+		}
+	}
+
+	if err := cfg.SaveConfig(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), "    namespace: infra\n    storage: 50Gi\n    secrets:\n      gitea_db_password: secret\n") {
+		t.Errorf("saved config:\n%s", saved)
+	}
+}
+
+func TestLoadConfig_MovesImageSecrets(t *testing.T) {
+	path := writeTestConfig(t, `configVersion: 2
+modules:
+  - name: mariadb
+    namespace: infra
+    secrets:
+      root_password: secret
+      image: mariadb:11.8
+  - name: prometheus-hobby
+    namespace: hobby
+    secrets:
+      prometheus_image: prom/prometheus:v3.5.0
+  - name: workpod
+    namespace: infra
+    image: ghcr.io/goalt/work-config:main
+    secrets:
+      image_tag: ghcr.io/goalt/work-config:old
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if applied := cfg.AppliedMigrations(); len(applied) != 1 || !strings.HasPrefix(applied[0], "v2 -> v3") {
+		t.Errorf("AppliedMigrations() = %v", applied)
+	}
+	for _, want := range []struct{ name, image string }{
+		{"mariadb", "mariadb:11.8"},
+		{"prometheus-hobby", "prom/prometheus:v3.5.0"},
+		{"workpod", "ghcr.io/goalt/work-config:main"},
+	} {
+		m, err := cfg.GetModule(want.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Image != want.image {
+			t.Errorf("%s image = %q, want %q", want.name, m.Image, want.image)
+		}
+		for _, key := range imageSecrets {
+			if _, ok := m.Secrets[key]; ok {
+				t.Errorf("%s secrets = %v, want %s moved", want.name, m.Secrets, key)
+			}
+		}
+	}
+}
+
+func TestLoadConfig_RejectsNewerOrInvalidVersion(t *testing.T) {
+	tests := []struct {
+		content string
+		wantErr string
+	}{
+		{content: "configVersion: 999\n", wantErr: "newer than the supported version"},
+		{content: "configVersion: latest\n", wantErr: "invalid configVersion"},
+	}
+
+	for _, tt := range tests {
+		_, err := LoadConfig(writeTestConfig(t, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("LoadConfig(%q) error = %v, want containing %q", tt.content, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field describes a single configuration key derived from struct tags.
+//
+// The yaml tag provides the key name, and the optional doc, default and
+// required tags provide the human-readable description, default value and
+// whether the key must be set. The generate tag marks credentials that
+// secrets generate may fill in with a random value.
+type Field struct {
+	Path        string
+	Type        string
+	Default     string
+	Required    bool
+	Generate    bool
+	Description string
+}
+
+// Describe walks the struct (or pointer to struct) v and returns one Field per
+// configuration key, depth first in declaration order. Nested structs are
+// flattened with dotted paths; elements of slices are written as "path[]" and
+// values of maps as "path.<key>". Fields tagged yaml:",inline" contribute
+// their keys at the level of the enclosing struct.
+func Describe(v interface{}) []Field {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []Field
+	describeStruct(t, "", &fields)
+	return fields
+}
+
+func describeStruct(t reflect.Type, prefix string, out *[]Field) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(sf.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if len(tag) > 1 && tag[1] == "inline" {
+			if ft := derefType(sf.Type); ft.Kind() == reflect.Struct {
+				describeStruct(ft, prefix, out)
+				continue
+			}
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		path := prefix + name
+
+		*out = append(*out, Field{
+			Path:        path,
+			Type:        typeName(sf.Type),
+			Default:     sf.Tag.Get("default"),
+			Required:    sf.Tag.Get("required") == "true",
+			Generate:    sf.Tag.Get("generate") == "true",
+			Description: sf.Tag.Get("doc"),
+		})
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			describeStruct(ft, path+".", out)
+		case reflect.Slice:
+			if elem := derefType(ft.Elem()); elem.Kind() == reflect.Struct {
+				describeStruct(elem, path+"[].", out)
+			}
+		case reflect.Map:
+			if elem := derefType(ft.Elem()); elem.Kind() == reflect.Struct {
+				describeStruct(elem, path+".<key>.", out)
+			}
+		}
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// typeName renders t the way it is written in YAML rather than in Go
+func typeName(t reflect.Type) string {
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	case reflect.Map:
+		return "map of " + typeName(t.Elem())
+	case reflect.Struct:
+		return "object"
+	}
+	return t.Kind().String()
+}
@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestDescribe(t *testing.T) {
+	type nested struct {
+		Port int32 `yaml:"port" required:"true" doc:"Listen port"`
+	}
+	type sample struct {
+		Name    string            `yaml:"name" required:"true" doc:"Entry name"`
+		Mode    string            `yaml:"mode,omitempty" default:"fast" doc:"Run mode"`
+		Enabled bool              `yaml:"enabled"`
+		Hidden  string            `yaml:"-"`
+		Items   []nested          `yaml:"items"`
+		Extra   map[string]string `yaml:"extra"`
+		Ptr     *nested           `yaml:"ptr"`
+		Inline  nested            `yaml:",inline"`
+		private string
+	}
+
+	tests := []Field{
+		{Path: "name", Type: "string", Required: true, Description: "Entry name"},
+		{Path: "mode", Type: "string", Default: "fast", Description: "Run mode"},
+		{Path: "enabled", Type: "bool"},
+		{Path: "items", Type: "list of object"},
+		{Path: "items[].port", Type: "int", Required: true, Description: "Listen port"},
+		{Path: "extra", Type: "map of string"},
+		{Path: "ptr", Type: "object"},
+		{Path: "ptr.port", Type: "int", Required: true, Description: "Listen port"},
+		{Path: "port", Type: "int", Required: true, Description: "Listen port"},
+	}
+
+	got := Describe(&sample{private: "x"})
+	if len(got) != len(tests) {
+		t.Fatalf("Describe() returned %d fields, want %d: %+v", len(got), len(tests), got)
+	}
+	for i, want := range tests {
+		if got[i] != want {
+			t.Errorf("field %d = %+v, want %+v", i, got[i], want)
+		}
+	}
+}
+
+func TestDescribe_NonStruct(t *testing.T) {
+	if got := Describe("value"); got != nil {
+		t.Errorf("Describe(string) = %+v, want nil", got)
+	}
+}
+
+func TestDescribe_ConfigDocumented(t *testing.T) {
+	for _, f := range Describe(Config{}) {
+		if f.Description == "" {
+			t.Errorf("config key %s has no doc tag", f.Path)
+		}
+	}
+}
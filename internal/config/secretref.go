@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/vault"
+)
+
+const (
+	envRefPrefix   = "env:"
+	fileRefPrefix  = "file:"
+	vaultRefPrefix = "vault:"
+)
+
+// SecretProvider fetches secrets kept outside the config file, such as in
+// HashiCorp Vault
+type SecretProvider interface {
+	// Secret returns the value of key in the secret at path
+	Secret(path, key string) (string, error)
+}
+
+// ResolveSecretRef resolves a secret value written as "env:NAME" (read from
+// the environment) or "file:/path" (read from a file, trailing newlines
+// trimmed). Any other value is returned unchanged.
+func ResolveSecretRef(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, envRefPrefix):
+		name := strings.TrimPrefix(value, envRefPrefix)
+		if name == "" {
+			return "", fmt.Errorf("empty environment variable name in %q", value)
+		}
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return resolved, nil
+	case strings.HasPrefix(value, fileRefPrefix):
+		path := strings.TrimPrefix(value, fileRefPrefix)
+		if path == "" {
+			return "", fmt.Errorf("empty file path in %q", value)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveProviderRef resolves a "vault:<path>#<key>" reference with provider
+func resolveProviderRef(provider SecretProvider, value string) (string, error) {
+	ref := strings.TrimPrefix(value, vaultRefPrefix)
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || strings.Trim(path, "/") == "" || key == "" {
+		return "", fmt.Errorf("invalid vault reference %q: must be vault:<path>#<key>", value)
+	}
+	return provider.Secret(path, key)
+}
+
+// secretProvider returns the Vault client vault: references are read from.
+// The address, token and namespace default to the standard VAULT_*
+// environment variables.
+func (v VaultConfig) secretProvider() (SecretProvider, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, fmt.Errorf("vault: references need vault.address or VAULT_ADDR")
+	}
+	token, err := ResolveSecretRef(v.Token)
+	if err != nil {
+		return nil, fmt.Errorf("vault.token: %w", err)
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("vault: references need vault.token or VAULT_TOKEN")
+	}
+	namespace := v.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	return vault.New(address, token, v.Mount, namespace), nil
+}
+
+// resolveSecretRefs replaces env:, file: and vault: references and age:
+// encrypted values in credential fields: module secrets, backup credentials
+// and registry passwords.
+func (c *Config) resolveSecretRefs() error {
+	var provider SecretProvider
+	resolve := func(field string, value *string) error {
+		var resolved string
+		var err error
+		switch {
+		case strings.HasPrefix(*value, vaultRefPrefix):
+			if provider == nil {
+				provider, err = c.Vault.secretProvider()
+			}
+			if err == nil {
+				resolved, err = resolveProviderRef(provider, *value)
+			}
+		case strings.HasPrefix(*value, ageRefPrefix):
+			resolved, err = c.Age.decrypt(*value)
+		default:
+			resolved, err = ResolveSecretRef(*value)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		*value = resolved
+		return nil
+	}
+
+	for i := range c.Modules {
+		module := &c.Modules[i]
+		keys := make([]string, 0, len(module.Secrets))
+		for key := range module.Secrets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := module.Secrets[key]
+			if err := resolve(fmt.Sprintf("modules[%s].secrets.%s", module.Name, key), &value); err != nil {
+				return err
+			}
+			module.Secrets[key] = value
+		}
+	}
+
+	backupFields := []struct {
+		name  string
+		value *string
+	}{
+		{"backup.webdav_username", &c.Backup.WebdavUsername},
+		{"backup.webdav_password", &c.Backup.WebdavPassword},
+		{"backup.sentry_dsn", &c.Backup.SentryDSN},
+		{"backup.passphrase", &c.Backup.Passphrase},
+		{"issues.token", &c.Issues.Token},
+	}
+	for _, field := range backupFields {
+		if err := resolve(field.name, field.value); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(c.Registries))
+	for name := range c.Registries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		creds := c.Registries[name]
+		if err := resolve(fmt.Sprintf("registries.%s.password", name), &creds.Password); err != nil {
+			return err
+		}
+		c.Registries[name] = creds
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecretRef(t *testing.T) {
+	t.Setenv("PS_TEST_SECRET", "from-env")
+
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "plain value", value: "plain", want: "plain"},
+		{name: "env reference", value: "env:PS_TEST_SECRET", want: "from-env"},
+		{name: "file reference", value: "file:" + secretFile, want: "from-file"},
+		{name: "missing env", value: "env:PS_TEST_MISSING", wantErr: "PS_TEST_MISSING is not set"},
+		{name: "missing file", value: "file:/nonexistent/secret", wantErr: "reading secret file"},
+		{name: "empty env name", value: "env:", wantErr: "empty environment variable name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveSecretRef(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResolveSecretRef(%q) error = %v, want containing %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveSecretRef(%q) returned error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveSecretRef(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ResolvesSecretRefs(t *testing.T) {
+	t.Setenv("PS_TEST_DB_PASSWORD", "db-pass")
+	t.Setenv("PS_TEST_PASSPHRASE", "gpg-pass")
+	t.Setenv("PS_TEST_REGISTRY", "reg-pass")
+
+	path := writeTestConfig(t, `backup:
+  passphrase: env:PS_TEST_PASSPHRASE
+registries:
+  main:
+    server: https://registry.example.com
+    password: env:PS_TEST_REGISTRY
+modules:
+  - name: postgres
+    namespace: infra
+    secrets:
+      admin_postgres_password: env:PS_TEST_DB_PASSWORD
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Modules[0].Secrets["admin_postgres_password"] != "db-pass" {
+		t.Errorf("module secret = %q, want db-pass", cfg.Modules[0].Secrets["admin_postgres_password"])
+	}
+	if cfg.Backup.Passphrase != "gpg-pass" {
+		t.Errorf("backup passphrase = %q, want gpg-pass", cfg.Backup.Passphrase)
+	}
+	if cfg.Registries["main"].Password != "reg-pass" {
+		t.Errorf("registry password = %q, want reg-pass", cfg.Registries["main"].Password)
+	}
+
+	// Saving must keep the references, not the resolved plaintext
+	if err := cfg.SaveConfig(); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if strings.Contains(string(data), "db-pass") || !strings.Contains(string(data), "env:PS_TEST_DB_PASSWORD") {
+		t.Errorf("expected references to be preserved, got:\n%s", data)
+	}
+}
+
+func TestLoadConfig_UnresolvableSecretRef(t *testing.T) {
+	path := writeTestConfig(t, `modules:
+  - name: redis
+    namespace: infra
+    secrets:
+      redis_password: env:PS_TEST_UNSET_VARIABLE
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "modules[redis].secrets.redis_password") {
+		t.Errorf("expected error naming the secret, got: %v", err)
+	}
+}
+
+func TestLoadConfig_VaultSecretRefs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" || r.URL.Path != "/v1/kv/data/infra/postgres" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"user":"postgres","password":"s3cret"}}}`))
+	}))
+	defer server.Close()
+	t.Setenv("PS_TEST_VAULT_TOKEN", "s.token")
+
+	config := `vault:
+  address: ` + server.URL + `
+  token: env:PS_TEST_VAULT_TOKEN
+  mount: kv
+modules:
+  - name: postgres
+    namespace: infra
+    secrets:
+      admin_postgres_user: vault:infra/postgres#user
+      admin_postgres_password: vault:infra/postgres#password
+`
+	cfg, err := LoadConfig(writeTestConfig(t, config))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if secrets := cfg.Modules[0].Secrets; secrets["admin_postgres_user"] != "postgres" || secrets["admin_postgres_password"] != "s3cret" {
+		t.Errorf("secrets = %v, want the values read from Vault", secrets)
+	}
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "missing key", config: strings.Replace(config, "#password", "#missing", 1), wantErr: "modules[postgres].secrets.admin_postgres_password: vault secret infra/postgres has no key missing"},
+		{name: "missing key separator", config: strings.Replace(config, "#password", "", 1), wantErr: "must be vault:<path>#<key>"},
+		{name: "no address", config: strings.Replace(config, "  address: "+server.URL+"\n", "", 1), wantErr: "need vault.address or VAULT_ADDR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VAULT_ADDR", "")
+			_, err := LoadConfig(writeTestConfig(t, tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadConfig() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
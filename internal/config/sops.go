@@ -0,0 +1,48 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sopsBinary is the SOPS executable used to decrypt encrypted config files
+const sopsBinary = "sops"
+
+// isSOPSEncrypted reports whether data is a YAML document encrypted with
+// SOPS, which records its keys and message authentication code under a
+// top-level sops key.
+func isSOPSEncrypted(data []byte) bool {
+	var doc struct {
+		SOPS *struct {
+			MAC string `yaml:"mac"`
+		} `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	return doc.SOPS != nil && doc.SOPS.MAC != ""
+}
+
+// decryptSOPS decrypts configFile with the sops binary. The age or PGP keys
+// are found by sops itself, e.g. in SOPS_AGE_KEY_FILE or the GPG agent.
+func decryptSOPS(configFile string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(sopsBinary, "--decrypt", "--input-type", "yaml", "--output-type", "yaml", configFile)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("config file is encrypted with SOPS, but %s is not installed", sopsBinary)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const encryptedConfig = `general:
+  domain: ENC[AES256_GCM,data:Zm9v,iv:YmFy,tag:YmF6,type:str]
+sops:
+  age:
+    - recipient: age1examplerecipient
+  lastmodified: "2024-01-01T00:00:00Z"
+  mac: ENC[AES256_GCM,data:bWFj,iv:aXY=,tag:dGFn,type:str]
+  version: 3.8.1
+`
+
+// fakeSOPS puts a sops executable printing output on PATH
+func fakeSOPS(t *testing.T, output string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncat <<'EOF'\n" + output + "EOF\n"
+	if err := os.WriteFile(filepath.Join(dir, sopsBinary), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestIsSOPSEncrypted(t *testing.T) {
+	if !isSOPSEncrypted([]byte(encryptedConfig)) {
+		t.Error("isSOPSEncrypted() = false for a SOPS file")
+	}
+	if isSOPSEncrypted([]byte("general:\n  domain: example.com\n")) {
+		t.Error("isSOPSEncrypted() = true for a plain file")
+	}
+}
+
+func TestLoadConfig_SOPS(t *testing.T) {
+	fakeSOPS(t, "general:\n  domain: example.com\nmodules:\n  - name: postgres\n    namespace: infra\n    secrets:\n      admin_postgres_password: s3cret\n")
+	path := writeTestConfig(t, encryptedConfig)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.General.Domain != "example.com" || cfg.Modules[0].Secrets["admin_postgres_password"] != "s3cret" {
+		t.Errorf("config = %+v, want the decrypted values", cfg)
+	}
+
+	if err := cfg.SaveConfig(); err == nil || !strings.Contains(err.Error(), "encrypted with SOPS") {
+		t.Errorf("SaveConfig() error = %v, want the decrypted config kept off disk", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != encryptedConfig {
+		t.Errorf("config file was rewritten:\n%s", data)
+	}
+}
+
+func TestLoadConfig_SOPSNotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	_, err := LoadConfig(writeTestConfig(t, encryptedConfig))
+	if err == nil || !strings.Contains(err.Error(), "sops is not installed") {
+		t.Errorf("LoadConfig() error = %v, want sops reported missing", err)
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templatePattern matches ${...} references; a leading "$" ($${...}) escapes them
+var templatePattern = regexp.MustCompile(`\$?\$\{([^}]+)\}`)
+
+// templatePrefixes are the reference roots resolved by the config templating.
+// Other ${...} expressions are left untouched.
+var templatePrefixes = []string{"general.", "vars.", "modules."}
+
+// envNamePattern matches the environment variable names that ${NAME} reads in
+// module secrets
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// templateSource holds the string values that references can point at. It is
+// decoded separately from Config so that unresolved references in non-string
+// fields (e.g. ports) do not break the first pass.
+type templateSource struct {
+	General struct {
+		Domain string `yaml:"domain"`
+	} `yaml:"general"`
+	Variables map[string]string `yaml:"variables"`
+	Modules   []struct {
+		Name      string            `yaml:"name"`
+		Namespace string            `yaml:"namespace"`
+		Image     string            `yaml:"image"`
+		Secrets   map[string]string `yaml:"secrets"`
+	} `yaml:"modules"`
+}
+
+// templateResolver expands references of the form:
+//
+//	${general.domain}
+//	${vars.<name>}                     user-defined entry of the top-level variables map
+//	${modules.<name>.name|namespace|image}
+//	${modules.<name>.service}          <name>.<namespace>.svc.cluster.local
+//	${modules.<name>.secrets.<key>}
+//
+// Within module secrets, ${NAME} also reads the environment variable NAME.
+type templateResolver struct {
+	values    map[string]string
+	resolving map[string]bool
+	resolved  map[string]string
+	// secrets holds the scalar nodes of modules[].secrets values
+	secrets map[*yaml.Node]bool
+}
+
+func newTemplateResolver(src *templateSource) *templateResolver {
+	values := map[string]string{
+		"general.domain": src.General.Domain,
+	}
+	for name, value := range src.Variables {
+		values["vars."+name] = value
+	}
+	for _, m := range src.Modules {
+		prefix := "modules." + m.Name + "."
+		values[prefix+"name"] = m.Name
+		values[prefix+"namespace"] = m.Namespace
+		values[prefix+"image"] = m.Image
+		values[prefix+"service"] = fmt.Sprintf("%s.%s.svc.cluster.local", m.Name, m.Namespace)
+		for key, value := range m.Secrets {
+			values[prefix+"secrets."+key] = value
+		}
+	}
+
+	return &templateResolver{
+		values:    values,
+		resolving: make(map[string]bool),
+		resolved:  make(map[string]string),
+		secrets:   make(map[*yaml.Node]bool),
+	}
+}
+
+func isTemplateReference(ref string) bool {
+	for _, prefix := range templatePrefixes {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the fully expanded value of a reference, detecting cycles
+func (r *templateResolver) lookup(ref string) (string, error) {
+	if value, ok := r.resolved[ref]; ok {
+		return value, nil
+	}
+	raw, ok := r.values[ref]
+	if !ok {
+		return "", fmt.Errorf("unknown config reference ${%s}", ref)
+	}
+	if r.resolving[ref] {
+		return "", fmt.Errorf("circular config reference ${%s}", ref)
+	}
+
+	r.resolving[ref] = true
+	value, err := r.expand(raw, strings.HasPrefix(ref, "modules.") && strings.Contains(ref, ".secrets."))
+	delete(r.resolving, ref)
+	if err != nil {
+		return "", err
+	}
+
+	r.resolved[ref] = value
+	return value, nil
+}
+
+// expand replaces all references in s. With env set, ${NAME} references to
+// environment variables are replaced as well.
+func (r *templateResolver) expand(s string, env bool) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var firstErr error
+	out := templatePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		ref := strings.TrimSpace(match[2 : len(match)-1])
+		if env && envNamePattern.MatchString(ref) {
+			value, ok := os.LookupEnv(ref)
+			if !ok {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("environment variable %s is not set", ref)
+				}
+				return match
+			}
+			return value
+		}
+		if !isTemplateReference(ref) {
+			return match
+		}
+		value, err := r.lookup(ref)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return match
+		}
+		return value
+	})
+	return out, firstErr
+}
+
+// expandNode expands references in every scalar of the YAML tree. Plain
+// (unquoted) scalars are re-tagged so that e.g. "${vars.port}" can still be
+// decoded into an integer field.
+func (r *templateResolver) expandNode(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, "${") {
+			return nil
+		}
+		value, err := r.expand(node.Value, r.secrets[node])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if value != node.Value && node.Style == 0 {
+			node.Tag = ""
+		}
+		node.Value = value
+		return nil
+	}
+
+	for _, child := range node.Content {
+		if err := r.expandNode(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveTemplates parses data, expands config references and decodes the
+// result into cfg.
+func resolveTemplates(data []byte, cfg *Config) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		return nil
+	}
+
+	var src templateSource
+	if err := root.Decode(&src); err != nil {
+		return err
+	}
+
+	resolver := newTemplateResolver(&src)
+	for _, node := range moduleSecretNodes(&root) {
+		resolver.secrets[node] = true
+	}
+	if err := resolver.expandNode(&root); err != nil {
+		return err
+	}
+
+	return root.Decode(cfg)
+}
+
+// moduleSecretNodes returns the value nodes of every modules[].secrets map
+func moduleSecretNodes(root *yaml.Node) []*yaml.Node {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	modules := mappingValue(root, "modules")
+	if modules == nil || modules.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var nodes []*yaml.Node
+	for _, module := range modules.Content {
+		secrets := mappingValue(module, "secrets")
+		if secrets == nil || secrets.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 1; i < len(secrets.Content); i += 2 {
+			nodes = append(nodes, secrets.Content[i])
+		}
+	}
+	return nodes
+}
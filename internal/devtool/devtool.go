@@ -0,0 +1,169 @@
+// Package devtool holds contributor tooling, such as scaffolding new modules.
+package devtool
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// modulePath is the Go module path generated code imports from
+const modulePath = "github.com/Goalt/personal-server"
+
+// registryMarker is the line in registry_default.go new Register calls are
+// inserted above
+const registryMarker = "\n\t// Register default pet project factory"
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+var moduleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// ModuleSpec names the pieces of a scaffolded module
+type ModuleSpec struct {
+	Name    string // command and config name, e.g. "uptime-kuma"
+	Package string // Go package, e.g. "uptimekuma"
+	Type    string // module type, e.g. "UptimeKumaModule"
+	Title   string // name used in log messages, e.g. "UptimeKuma"
+}
+
+// NewModuleSpec derives the package, type and title from a module name made
+// of lowercase words separated by dashes
+func NewModuleSpec(name string) (ModuleSpec, error) {
+	if !moduleNamePattern.MatchString(name) {
+		return ModuleSpec{}, fmt.Errorf("invalid module name %q: use lowercase letters, digits and dashes, e.g. uptime-kuma", name)
+	}
+
+	var title strings.Builder
+	for _, word := range strings.Split(name, "-") {
+		title.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return ModuleSpec{
+		Name:    name,
+		Package: strings.ReplaceAll(name, "-", ""),
+		Type:    title.String() + "Module",
+		Title:   title.String(),
+	}, nil
+}
+
+// ScaffoldModule writes a new module package under root/internal/modules and
+// registers it in registry_default.go. root must be the repository root. It
+// returns the files it created or changed.
+func ScaffoldModule(root string, spec ModuleSpec) ([]string, error) {
+	goMod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil || !bytes.Contains(goMod, []byte("module "+modulePath+"\n")) {
+		return nil, fmt.Errorf("%s is not the %s repository root", root, modulePath)
+	}
+
+	registryFile := filepath.Join(root, "internal", "modules", "registry_default.go")
+	registry, err := os.ReadFile(registryFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry: %w", err)
+	}
+	if bytes.Contains(registry, []byte(fmt.Sprintf("r.Register(%q,", spec.Name))) {
+		return nil, fmt.Errorf("module %q is already registered", spec.Name)
+	}
+
+	packageDir := filepath.Join(root, "internal", "modules", spec.Package)
+	if _, err := os.Stat(packageDir); err == nil {
+		return nil, fmt.Errorf("%s already exists", packageDir)
+	}
+
+	files := map[string]string{
+		spec.Package + ".go":      "templates/module.go.tmpl",
+		spec.Package + "_test.go": "templates/module_test.go.tmpl",
+	}
+	rendered := make(map[string][]byte, len(files))
+	for name, tmpl := range files {
+		source, err := render(tmpl, spec)
+		if err != nil {
+			return nil, err
+		}
+		rendered[name] = source
+	}
+
+	updatedRegistry, err := registerModule(registry, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(packageDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", packageDir, err)
+	}
+	var written []string
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(packageDir, name)
+		if err := os.WriteFile(path, rendered[name], 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	if err := os.WriteFile(registryFile, updatedRegistry, 0644); err != nil {
+		return written, fmt.Errorf("failed to update registry: %w", err)
+	}
+	return append(written, registryFile), nil
+}
+
+// render executes a template and gofmts the result
+func render(name string, spec ModuleSpec) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, spec); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", name, err)
+	}
+	return source, nil
+}
+
+// registerModule adds the package import, in sorted position among the
+// module imports, and a Register call after the last module-config factory
+func registerModule(registry []byte, spec ModuleSpec) ([]byte, error) {
+	src := string(registry)
+	importLine := fmt.Sprintf("\t%q\n", modulePath+"/internal/modules/"+spec.Package)
+
+	start := strings.Index(src, "import (\n")
+	end := strings.Index(src, "\n)\n")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("registry_default.go: import block not found")
+	}
+	lines := strings.SplitAfter(src[start+len("import (\n"):end+1], "\n")
+	insertAt := start + len("import (\n")
+	for _, line := range lines {
+		if strings.Contains(line, `"`+modulePath+`/internal/modules/`) && line > importLine {
+			break
+		}
+		insertAt += len(line)
+	}
+	src = src[:insertAt] + importLine + src[insertAt:]
+
+	marker := strings.Index(src, registryMarker)
+	if marker < 0 {
+		return nil, fmt.Errorf("registry_default.go: %q not found", strings.TrimSpace(registryMarker))
+	}
+	register := fmt.Sprintf("\tr.Register(%q, func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {\n\t\treturn %s.New(g, m, log)\n\t})\n", spec.Name, spec.Package)
+	src = src[:marker] + register + src[marker:]
+
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return nil, fmt.Errorf("registry_default.go: %w", err)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,127 @@
+package devtool
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewModuleSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ModuleSpec
+		wantErr bool
+	}{
+		{name: "redis", want: ModuleSpec{Name: "redis", Package: "redis", Type: "RedisModule", Title: "Redis"}},
+		{name: "uptime-kuma", want: ModuleSpec{Name: "uptime-kuma", Package: "uptimekuma", Type: "UptimeKumaModule", Title: "UptimeKuma"}},
+		{name: "s3-proxy2", want: ModuleSpec{Name: "s3-proxy2", Package: "s3proxy2", Type: "S3Proxy2Module", Title: "S3Proxy2"}},
+		{name: "Uptime", wantErr: true},
+		{name: "uptime_kuma", wantErr: true},
+		{name: "-kuma", wantErr: true},
+		{name: "kuma-", wantErr: true},
+		{name: "2fa", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewModuleSpec(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewModuleSpec(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NewModuleSpec(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// copyRepoFiles sets up a fake repository root with the real go.mod and
+// registry_default.go
+func copyRepoFiles(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for src, dst := range map[string]string{
+		"../../go.mod":                   "go.mod",
+		"../modules/registry_default.go": "internal/modules/registry_default.go",
+	} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(root, dst)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestScaffoldModule(t *testing.T) {
+	root := copyRepoFiles(t)
+	spec, _ := NewModuleSpec("uptime-kuma")
+
+	files, err := ScaffoldModule(root, spec)
+	if err != nil {
+		t.Fatalf("ScaffoldModule() error = %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("ScaffoldModule() returned %v, want 3 files", files)
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range []string{"uptimekuma.go", "uptimekuma_test.go"} {
+		path := filepath.Join(root, "internal", "modules", "uptimekuma", name)
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("generated %s does not parse: %v", name, err)
+		}
+		if file.Name.Name != "uptimekuma" {
+			t.Errorf("%s package = %s, want uptimekuma", name, file.Name.Name)
+		}
+	}
+	source, _ := os.ReadFile(filepath.Join(root, "internal", "modules", "uptimekuma", "uptimekuma.go"))
+	for _, want := range []string{"type UptimeKumaModule struct", `return "uptime-kuma"`, `"app":        "uptime-kuma"`} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("generated module missing %q", want)
+		}
+	}
+
+	registry, _ := os.ReadFile(filepath.Join(root, "internal", "modules", "registry_default.go"))
+	if _, err := parser.ParseFile(fset, "registry_default.go", registry, 0); err != nil {
+		t.Fatalf("updated registry does not parse: %v", err)
+	}
+	src := string(registry)
+	imp := strings.Index(src, `"github.com/Goalt/personal-server/internal/modules/uptimekuma"`)
+	before := strings.Index(src, `"github.com/Goalt/personal-server/internal/modules/synapse"`)
+	after := strings.Index(src, `"github.com/Goalt/personal-server/internal/modules/verdaccio"`)
+	if imp < before || imp > after {
+		t.Errorf("import not inserted in sorted position:\n%s", src[:after+80])
+	}
+	reg := strings.Index(src, `r.Register("uptime-kuma"`)
+	pet := strings.Index(src, "// Register default pet project factory")
+	if reg < 0 || reg > pet {
+		t.Errorf("Register call not inserted before the pet project factory")
+	}
+
+	if _, err := ScaffoldModule(root, spec); err == nil {
+		t.Error("scaffolding the same module twice should fail")
+	}
+}
+
+func TestScaffoldModuleRejectsExisting(t *testing.T) {
+	root := copyRepoFiles(t)
+	spec, _ := NewModuleSpec("redis")
+	if _, err := ScaffoldModule(root, spec); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("ScaffoldModule(redis) error = %v, want already registered", err)
+	}
+
+	if _, err := ScaffoldModule(t.TempDir(), spec); err == nil || !strings.Contains(err.Error(), "repository root") {
+		t.Errorf("ScaffoldModule outside the repository error = %v", err)
+	}
+}
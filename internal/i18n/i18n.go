@@ -0,0 +1,67 @@
+// Package i18n translates user-facing CLI messages.
+//
+// Messages are looked up by their English format string, so call sites keep
+// using plain English and the logger translates on output. A message missing
+// from a catalog is printed in English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Language is a supported output language
+type Language string
+
+const (
+	English Language = "en"
+	Russian Language = "ru"
+)
+
+// catalogs maps each non-English language to its translations
+var catalogs = map[Language]map[string]string{
+	Russian: russian,
+}
+
+// Parse returns the language for a code such as "ru", "ru_RU.UTF-8" or "en-US"
+func Parse(code string) (Language, error) {
+	lang := strings.ToLower(code)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	switch Language(lang) {
+	case English, Russian:
+		return Language(lang), nil
+	}
+	return "", fmt.Errorf("unsupported language %q (supported: %s, %s)", code, English, Russian)
+}
+
+// Detect returns the configured language, or the one from the environment
+// (LC_ALL, LC_MESSAGES, LANG in POSIX precedence) when configured is empty.
+// Unknown or unset locales fall back to English.
+func Detect(configured string) (Language, error) {
+	if configured != "" {
+		return Parse(configured)
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		if lang, err := Parse(value); err == nil {
+			return lang, nil
+		}
+		return English, nil
+	}
+	return English, nil
+}
+
+// T returns the translation of an English message or format string, or the
+// message itself when the language has no translation for it
+func (l Language) T(message string) string {
+	if translated, ok := catalogs[l][message]; ok {
+		return translated
+	}
+	return message
+}
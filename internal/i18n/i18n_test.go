@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    Language
+		wantErr bool
+	}{
+		{code: "en", want: English},
+		{code: "ru", want: Russian},
+		{code: "ru_RU.UTF-8", want: Russian},
+		{code: "en-US", want: English},
+		{code: "RU", want: Russian},
+		{code: "de", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, err := Parse(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "ru_RU.UTF-8")
+
+	if lang, err := Detect(""); err != nil || lang != Russian {
+		t.Errorf("Detect from LANG = %q, %v; want ru", lang, err)
+	}
+	if lang, err := Detect("en"); err != nil || lang != English {
+		t.Errorf("configured language should win, got %q, %v", lang, err)
+	}
+	if _, err := Detect("xx"); err == nil {
+		t.Error("expected error for unsupported configured language")
+	}
+
+	t.Setenv("LC_ALL", "C")
+	if lang, _ := Detect(""); lang != English {
+		t.Errorf("LC_ALL=C should override LANG and fall back to English, got %q", lang)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got := Russian.T("Applying %s\n"); got != "Применение %s\n" {
+		t.Errorf("Russian.T() = %q", got)
+	}
+	if got := Russian.T("not in catalog\n"); got != "not in catalog\n" {
+		t.Errorf("missing message should be returned unchanged, got %q", got)
+	}
+	if got := English.T("Applying %s\n"); got != "Applying %s\n" {
+		t.Errorf("English.T() = %q", got)
+	}
+}
+
+// TestCatalogsKeepVerbs guards against translations that would print
+// %!v(MISSING) or drop arguments
+func TestCatalogsKeepVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for lang, catalog := range catalogs {
+		for message, translated := range catalog {
+			want := strings.Join(verbs.FindAllString(message, -1), " ")
+			got := strings.Join(verbs.FindAllString(translated, -1), " ")
+			if got != want {
+				t.Errorf("%s: %q has verbs [%s], translation %q has [%s]", lang, message, want, translated, got)
+			}
+			if strings.HasSuffix(message, "\n") != strings.HasSuffix(translated, "\n") {
+				t.Errorf("%s: trailing newline differs for %q", lang, message)
+			}
+		}
+	}
+}
+
+// TestCatalogKeysUsed guards against keys that drift from the English format
+// strings in the code, which leaves the message untranslated
+func TestCatalogKeysUsed(t *testing.T) {
+	literals := map[string]bool{}
+	for _, root := range []string{"../../cmd", "../../internal"} {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// The catalogs themselves do not count as uses
+			if d.IsDir() && d.Name() == "i18n" {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+			file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if value, err := strconv.Unquote(lit.Value); err == nil {
+						literals[value] = true
+					}
+				}
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for lang, catalog := range catalogs {
+		for message := range catalog {
+			if !literals[message] {
+				t.Errorf("%s: %q is not a string in the code", lang, message)
+			}
+		}
+	}
+}
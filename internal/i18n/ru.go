@@ -0,0 +1,123 @@
+package i18n
+
+// russian holds the Russian catalog. Keys must match the English format
+// strings passed to the logger exactly, including emoji and newlines, and
+// translations must keep the same formatting verbs in the same order.
+var russian = map[string]string{
+	// Help
+	"Usage:":      "Использование:",
+	"Options:":    "Параметры:",
+	"\nCommands:": "\nКоманды:",
+	"\nModules:":  "\nМодули:",
+	"  -c, --config   Path to configuration file (default: config.yaml)":                                     "  -c, --config   Путь к файлу конфигурации (по умолчанию: config.yaml)",
+	"  -h, --help     Show this help message":                                                                "  -h, --help     Показать эту справку",
+	"  -v, --version  Show version information":                                                              "  -v, --version  Показать версию",
+	"  --no-color     Disable colored output (also honours NO_COLOR)":                                        "  --no-color     Отключить цветной вывод (также учитывается NO_COLOR)",
+	"  --no-emoji     Replace emoji with plain ASCII markers":                                                "  --no-emoji     Заменить эмодзи простыми ASCII-метками",
+	"  help                          Show help information":                                                  "  help                          Показать справку",
+	"  update                        Check for updates and update the CLI to the latest version":             "  update                        Проверить обновления и обновить CLI до последней версии",
+	"  config                        Parse and print loaded configuration":                                   "  config                        Разобрать и вывести загруженную конфигурацию",
+	"  devtool new-module <name>     Scaffold a new module package with tests and registry wiring":           "  devtool new-module <name>     Создать заготовку модуля с тестами и регистрацией",
+	"  config edit <module> image <value>  Edit a module's image in the configuration file":                  "  config edit <module> image <value>  Изменить образ модуля в файле конфигурации",
+	"  config migrate                Upgrade the configuration file to the current schema version":           "  config migrate                Обновить файл конфигурации до текущей версии схемы",
+	"  config explain [module]       List supported config keys with types, defaults and required flags":     "  config explain [module]       Показать ключи конфигурации с типами, значениями по умолчанию и обязательностью",
+	"  apply --all [--concurrency N] Apply all configured components in dependency order, in parallel":       "  apply --all [--concurrency N] Применить все компоненты параллельно с учётом зависимостей",
+	"  status [--all]                Summarize the status of all configured components":                      "  status [--all]                Сводка состояния всех настроенных компонентов",
+	"  <module> status --watch       Stream live pod, deployment and PVC changes for a module":               "  <module> status --watch       Следить за изменениями подов, deployment и PVC модуля",
+	"  <module> clean [--cascade]    Remove a module; --cascade also removes the modules depending on it":    "  <module> clean [--cascade]    Удалить модуль; --cascade также удаляет зависящие от него модули",
+	"  urls                          List public URLs, cluster endpoints and credential secrets of services": "  urls                          Показать публичные URL, адреса в кластере и секреты с учётными данными сервисов",
+	"  backup                        Trigger a global backup including all modules":                          "  backup                        Запустить общий бэкап всех модулей",
+	"  backup download <file>        Download a backup archive from WebDAV":                                  "  backup download <file>        Скачать архив бэкапа из WebDAV",
+	"  certs issue-client <name>     Issue a client certificate for mTLS-protected ingresses":                "  certs issue-client <name>     Выпустить клиентский сертификат для ingress с mTLS",
+	"  certs status [--threshold N]  Report TLS certificate issuer/expiry for public hostnames":              "  certs status [--threshold N]  Показать издателя и срок действия TLS-сертификатов",
+	"Version: %s\n\n": "Версия: %s\n\n",
+	"%s version %s\n": "%s версия %s\n",
+
+	// Config
+	"Configuration loaded from: %s\n\n": "Конфигурация загружена из: %s\n\n",
+	"Domain: %s\n":                      "Домен: %s\n",
+	"Namespaces: %v\n":                  "Пространства имён: %v\n",
+	"Config file %s uses an older schema version; run '%s config migrate' to upgrade it\n": "Файл конфигурации %s использует старую версию схемы; выполните '%s config migrate' для обновления\n",
+	"Config is already at version %d, nothing to migrate\n":                                "Конфигурация уже версии %d, миграция не нужна\n",
+	"Migrated config to version %d\n":                                                      "Конфигурация обновлена до версии %d\n",
+	"Saved original config to %s\n":                                                        "Исходная конфигурация сохранена в %s\n",
+	"Updated module '%s': set image to '%s'\n":                                             "Модуль '%s' обновлён: образ '%s'\n",
+
+	// devtool
+	"Scaffolded module %s (package %s)\n": "Создана заготовка модуля %s (пакет %s)\n",
+	"Created %s\n":                        "Создан %s\n",
+	"Updated %s\n":                        "Обновлён %s\n",
+
+	// apply --all / status --all / status --watch
+	"Applying %d components with up to %d in parallel...\n\n": "Применение %d компонентов, до %d параллельно...\n\n",
+	"Applying %s\n": "Применение %s\n",
+	"%s applied\n":  "%s применён\n",
+	"\nCompleted in %s: %d applied, %d failed, %d skipped\n":               "\nЗавершено за %s: применено %d, с ошибкой %d, пропущено %d\n",
+	"Skipped because a dependency failed: %s\n":                            "Пропущены из-за ошибки зависимости: %s\n",
+	"Nothing to apply: no modules, pet projects or ingresses configured\n": "Нечего применять: не настроены модули, pet-проекты или ingress\n",
+	"No modules, pet projects or ingresses configured\n":                   "Не настроены модули, pet-проекты или ingress\n",
+	"\nCollected %d components from %d namespaces in %s\n":                 "\nСобрано %d компонентов из %d пространств имён за %s\n",
+	"%s is used by: %s\n":                                 "%s используется: %s\n",
+	"Cleaning %s\n":                                       "Очистка %s\n",
+	"No namespaces configured\n":                          "Пространства имён не настроены\n",
+	"No services found in namespaces: %s\n":               "Сервисы не найдены в пространствах имён: %s\n",
+	"\nWatching %s in namespace %s (Ctrl+C to stop)...\n": "\nНаблюдение за %s в пространстве имён %s (Ctrl+C для выхода)...\n",
+
+	// Backup
+	"🚀 Starting global backup...\n":                       "🚀 Запуск общего бэкапа...\n",
+	"Directory: %s\n\n":                                   "Каталог: %s\n\n",
+	"📦 Backing up module: %s\n":                           "📦 Бэкап модуля: %s\n",
+	"Module '%s' backed up successfully\n":                "Бэкап модуля '%s' выполнен\n",
+	"Failed to backup module '%s': %v\n":                  "Не удалось сделать бэкап модуля '%s': %v\n",
+	"Global backup summary: %d successful, %d failed\n":   "Итог общего бэкапа: успешно %d, с ошибкой %d\n",
+	"📦 Creating archive: %s\n":                            "📦 Создание архива: %s\n",
+	"🔒 Encrypting archive: %s\n":                          "🔒 Шифрование архива: %s\n",
+	"☁️ Uploading to WebDAV: %s\n":                        "☁️ Загрузка в WebDAV: %s\n",
+	"Uploaded %s to WebDAV\n":                             "%s загружен в WebDAV\n",
+	"\n🎉 Global backup complete! Encrypted archive: %s\n": "\n🎉 Общий бэкап завершён! Зашифрованный архив: %s\n",
+	"📥 Starting backup download...\n":                     "📥 Скачивание бэкапа...\n",
+	"☁️ Downloading from WebDAV: %s\n":                    "☁️ Скачивание из WebDAV: %s\n",
+	"Downloaded %s to %s\n":                               "%s скачан в %s\n",
+	"\n🎉 Backup download complete! File saved as: %s\n":   "\n🎉 Бэкап скачан! Файл сохранён как: %s\n",
+	"🔓 Decrypting archive: %s\n":                          "🔓 Расшифровка архива: %s\n",
+	"Archive decrypted and extracted successfully\n":      "Архив расшифрован и распакован\n",
+	"📅 Scheduling backup job...\n":                        "📅 Планирование задания бэкапа...\n",
+	"Backup schedule added to crontab\n":                  "Расписание бэкапа добавлено в crontab\n",
+	"🗑️  Clearing backup schedule...\n":                   "🗑️  Удаление расписания бэкапа...\n",
+	"Backup schedule cleared from crontab\n":              "Расписание бэкапа удалено из crontab\n",
+	"No backup schedule found in crontab\n":               "Расписание бэкапа в crontab не найдено\n",
+
+	// Common module messages
+	"Target namespace: %s\n\n":                                     "Пространство имён: %s\n\n",
+	"Output directory: %s\n\n":                                     "Каталог вывода: %s\n\n",
+	"Generated: %s\n":                                              "Создан файл: %s\n",
+	"Checking for existing resources...\n":                         "Проверка существующих ресурсов...\n",
+	"No existing resources found, proceeding with creation...\n\n": "Существующих ресурсов нет, создаём...\n\n",
+	"Created Deployment: %s\n":                                     "Создан Deployment: %s\n",
+	"Created Service: %s\n":                                        "Создан Service: %s\n",
+	"Created Secret: %s\n":                                         "Создан Secret: %s\n",
+	"Created PersistentVolumeClaim: %s\n":                          "Создан PersistentVolumeClaim: %s\n",
+	"Failed to delete Deployment: %v\n":                            "Не удалось удалить Deployment: %v\n",
+	"Failed to delete Service: %v\n":                               "Не удалось удалить Service: %v\n",
+	"Failed to delete Secret: %v\n":                                "Не удалось удалить Secret: %v\n",
+	"Failed to delete PersistentVolumeClaim: %v\n":                 "Не удалось удалить PersistentVolumeClaim: %v\n",
+	"Deployment not found (already deleted or never existed)\n":    "Deployment не найден (уже удалён или не создавался)\n",
+	"Service not found (already deleted or never existed)\n":       "Service не найден (уже удалён или не создавался)\n",
+	"Secret not found (already deleted or never existed)\n":        "Secret не найден (уже удалён или не создавался)\n",
+	"Warning during clean: %v\n":                                   "Предупреждение при очистке: %v\n",
+	"\nCompleted: %d/%d resources deleted successfully\n":          "\nГотово: удалено ресурсов %d/%d\n",
+	"Error listing pods: %v\n":                                     "Ошибка получения списка подов: %v\n",
+	"📦 Using pod: %s\n":                                            "📦 Используется под: %s\n",
+	"Backup directory: %s\n":                                       "Каталог бэкапа: %s\n",
+	"Using latest backup: %s\n":                                    "Используется последний бэкап: %s\n",
+	"Data archived (%d bytes)\n":                                   "Данные заархивированы (%d байт)\n",
+	"📋 Writing metadata...\n":                                      "📋 Запись метаданных...\n",
+	"Metadata written\n":                                           "Метаданные записаны\n",
+	"💾 Data will be restored from %s\n":                            "💾 Данные будут восстановлены из %s\n",
+	"💾 Restoring data...\n":                                        "💾 Восстановление данных...\n",
+	"Data restored\n":                                              "Данные восстановлены\n",
+	"Deployment restarted successfully\n":                          "Deployment перезапущен\n",
+	"Failed to trigger rollout restart: %v\n":                      "Не удалось перезапустить rollout: %v\n",
+	"🎉 Backup complete!\n":                                         "🎉 Бэкап завершён!\n",
+	"🎉 Restore complete!\n":                                        "🎉 Восстановление завершено!\n",
+}
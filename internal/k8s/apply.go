@@ -0,0 +1,115 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// ServerSideApplyResult describes what ServerSideApply did with an object
+type ServerSideApplyResult int
+
+const (
+	// Unchanged means the live object already matched
+	Unchanged ServerSideApplyResult = iota
+	// Created means the object did not exist
+	Created
+	// Configured means the live object was changed
+	Configured
+	// Skipped means an existing Job was left alone
+	Skipped
+)
+
+func (r ServerSideApplyResult) String() string {
+	switch r {
+	case Created:
+		return "created"
+	case Configured:
+		return "configured"
+	case Skipped:
+		return "skipped"
+	default:
+		return "unchanged"
+	}
+}
+
+// ApplyOptions configures ServerSideApply
+type ApplyOptions struct {
+	// FieldManager owns the fields the apply sets
+	FieldManager string
+	// DryRun has the API server validate and admit the change without
+	// persisting it
+	DryRun bool
+}
+
+// ServerSideApply creates object or applies it server-side. Conflicts with
+// other managers are forced, so fields set in object always end up as
+// desired, while fields only others set, such as a Service's cluster IP, are
+// kept. Existing Jobs are skipped: their pod template is immutable and they
+// have already run.
+func ServerSideApply(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, object *unstructured.Unstructured, opts ApplyOptions) (ServerSideApplyResult, error) {
+	var dryRun []string
+	if opts.DryRun {
+		dryRun = []string{metav1.DryRunAll}
+	}
+	resource, err := resourceFor(dyn, mapper, object)
+	if err != nil {
+		return Unchanged, err
+	}
+	live, err := resource.Get(ctx, object.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := resource.Create(ctx, object, metav1.CreateOptions{FieldManager: opts.FieldManager, DryRun: dryRun}); err != nil {
+			return Unchanged, fmt.Errorf("failed to create %s %s: %w", object.GetKind(), object.GetName(), err)
+		}
+		return Created, nil
+	}
+	if err != nil {
+		return Unchanged, fmt.Errorf("failed to get %s %s: %w", object.GetKind(), object.GetName(), err)
+	}
+	if object.GetKind() == "Job" {
+		return Skipped, nil
+	}
+
+	// The apply patch is the whole object; a resource version would turn it
+	// into an optimistic-concurrency update
+	object = object.DeepCopy()
+	object.SetResourceVersion("")
+	patch, err := json.Marshal(object.Object)
+	if err != nil {
+		return Unchanged, fmt.Errorf("failed to encode %s %s: %w", object.GetKind(), object.GetName(), err)
+	}
+	force := true
+	applied, err := resource.Patch(ctx, object.GetName(), types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: opts.FieldManager, Force: &force, DryRun: dryRun})
+	if err != nil {
+		return Unchanged, fmt.Errorf("failed to apply %s %s: %w", object.GetKind(), object.GetName(), err)
+	}
+	if opts.DryRun {
+		// A dry run persists nothing, so the resource version says nothing
+		// about whether the object would change
+		if equality.Semantic.DeepEqual(withoutWriteMetadata(applied), withoutWriteMetadata(live)) {
+			return Unchanged, nil
+		}
+		return Configured, nil
+	}
+	if applied.GetResourceVersion() == live.GetResourceVersion() {
+		return Unchanged, nil
+	}
+	return Configured, nil
+}
+
+// withoutWriteMetadata returns object without the metadata every write changes
+func withoutWriteMetadata(object *unstructured.Unstructured) map[string]interface{} {
+	object = object.DeepCopy()
+	for _, field := range []string{"resourceVersion", "generation", "managedFields"} {
+		unstructured.RemoveNestedField(object.Object, "metadata", field)
+	}
+	return object.Object
+}
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestServerSideApply(t *testing.T) {
+	ctx := context.Background()
+	dyn, mapper := newManifestTestClients()
+	desired := decodeOne(t, desiredDeployment)
+
+	if result, err := ServerSideApply(ctx, dyn, mapper, desired, ApplyOptions{FieldManager: "personal-server"}); err != nil || result != Created {
+		t.Fatalf("ServerSideApply() of a missing object = %v, %v, want created", result, err)
+	}
+	deployments := dyn.Resource(deploymentGVR).Namespace("example")
+	live, err := deployments.Get(ctx, "example", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Deployment not created: %v", err)
+	}
+	live.SetResourceVersion("7")
+	if _, err := deployments.Update(ctx, live, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake client cannot merge apply patches; answer with the live object
+	// at the version the API server would return
+	var patchType types.PatchType
+	var patch map[string]interface{}
+	version := "8"
+	dyn.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		patchType = patchAction.GetPatchType()
+		if err := json.Unmarshal(patchAction.GetPatch(), &patch); err != nil {
+			t.Fatal(err)
+		}
+		applied := live.DeepCopy()
+		applied.SetResourceVersion(version)
+		return true, applied, nil
+	})
+
+	changed := desired.DeepCopy()
+	changed.SetResourceVersion("3")
+	unstructured.SetNestedField(changed.Object, int64(2), "spec", "replicas")
+	if result, err := ServerSideApply(ctx, dyn, mapper, changed, ApplyOptions{FieldManager: "personal-server"}); err != nil || result != Configured {
+		t.Fatalf("ServerSideApply() of a changed object = %v, %v, want configured", result, err)
+	}
+	if patchType != types.ApplyPatchType {
+		t.Errorf("patch type = %s, want %s", patchType, types.ApplyPatchType)
+	}
+	if replicas, _, _ := unstructured.NestedFieldNoCopy(patch, "spec", "replicas"); replicas != float64(2) {
+		t.Errorf("patched replicas = %v, want 2", replicas)
+	}
+	if _, found, _ := unstructured.NestedString(patch, "metadata", "resourceVersion"); found {
+		t.Error("apply patch carries a resource version")
+	}
+
+	version = "7"
+	if result, err := ServerSideApply(ctx, dyn, mapper, desired, ApplyOptions{FieldManager: "personal-server"}); err != nil || result != Unchanged {
+		t.Errorf("ServerSideApply() of an up-to-date object = %v, %v, want unchanged", result, err)
+	}
+}
+
+func TestServerSideApplyDryRun(t *testing.T) {
+	ctx := context.Background()
+	dyn, mapper := newManifestTestClients()
+	desired := decodeOne(t, desiredDeployment)
+	deployments := dyn.Resource(deploymentGVR).Namespace("example")
+	live, err := deployments.Create(ctx, desired, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run answers with the object as it would be stored, with write
+	// metadata updated but the resource version unchanged
+	var replicas int64
+	dyn.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		applied := live.DeepCopy()
+		applied.SetGeneration(live.GetGeneration() + 1)
+		applied.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "personal-server", Operation: metav1.ManagedFieldsOperationApply}})
+		if replicas != 0 {
+			unstructured.SetNestedField(applied.Object, replicas, "spec", "replicas")
+		}
+		return true, applied, nil
+	})
+
+	opts := ApplyOptions{FieldManager: "personal-server", DryRun: true}
+	if result, err := ServerSideApply(ctx, dyn, mapper, desired, opts); err != nil || result != Unchanged {
+		t.Errorf("dry run of an up-to-date object = %v, %v, want unchanged", result, err)
+	}
+	replicas = 2
+	if result, err := ServerSideApply(ctx, dyn, mapper, desired, opts); err != nil || result != Configured {
+		t.Errorf("dry run of a changed object = %v, %v, want configured", result, err)
+	}
+}
@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConfigChecksumAnnotation is the pod template annotation holding the hash of
+// the Secrets and ConfigMaps a workload reads
+const ConfigChecksumAnnotation = "checksum/config"
+
+// SetConfigChecksum annotates template with a hash of the contents of the
+// given Secrets and ConfigMaps. Pods only read them at start, so putting the
+// hash into the template makes a changed value roll the workload when it is
+// updated. Nil objects, such as optional Secrets that are not configured, are
+// skipped; any other kind of object is an error and leaves template as is.
+func SetConfigChecksum(template *corev1.PodTemplateSpec, objects ...runtime.Object) error {
+	hash := sha256.New()
+	write := func(kind, name string, data map[string][]byte) {
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(hash, "%s/%s\n", kind, name)
+		for _, key := range keys {
+			fmt.Fprintf(hash, "%s=%d:%s\n", key, len(data[key]), data[key])
+		}
+	}
+	fromStrings := func(data map[string]string) map[string][]byte {
+		converted := make(map[string][]byte, len(data))
+		for key, value := range data {
+			converted[key] = []byte(value)
+		}
+		return converted
+	}
+
+	for _, object := range objects {
+		switch object := object.(type) {
+		case *corev1.Secret:
+			if object != nil {
+				write("Secret", object.Name, object.Data)
+				write("Secret", object.Name, fromStrings(object.StringData))
+			}
+		case *corev1.ConfigMap:
+			if object != nil {
+				write("ConfigMap", object.Name, fromStrings(object.Data))
+				write("ConfigMap", object.Name, object.BinaryData)
+			}
+		case nil:
+		default:
+			return fmt.Errorf("config checksum: unsupported object %T, want a Secret or ConfigMap", object)
+		}
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[ConfigChecksumAnnotation] = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
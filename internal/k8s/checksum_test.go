@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetConfigChecksum(t *testing.T) {
+	checksum := func(secret *corev1.Secret, configMap *corev1.ConfigMap) string {
+		template := &corev1.PodTemplateSpec{}
+		if err := SetConfigChecksum(template, secret, configMap); err != nil {
+			t.Fatalf("SetConfigChecksum() error: %v", err)
+		}
+		return template.Annotations[ConfigChecksumAnnotation]
+	}
+	secret := func(data map[string]string) *corev1.Secret {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app"}, Data: map[string][]byte{}}
+		for key, value := range data {
+			s.Data[key] = []byte(value)
+		}
+		return s
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app"}, Data: map[string]string{"config.yaml": "port: 80"}}
+
+	base := checksum(secret(map[string]string{"password": "a", "user": "admin"}), configMap)
+	if len(base) != 64 {
+		t.Fatalf("checksum = %q, want a sha256 hex digest", base)
+	}
+	if got := checksum(secret(map[string]string{"user": "admin", "password": "a"}), configMap); got != base {
+		t.Error("checksum depends on map order")
+	}
+	if got := checksum(secret(map[string]string{"password": "b", "user": "admin"}), configMap); got == base {
+		t.Error("checksum unchanged after a Secret value changed")
+	}
+	// Moving a character between a key and its value must change the hash
+	if got := checksum(secret(map[string]string{"password": "a", "use": "radmin"}), configMap); got == base {
+		t.Error("checksum unchanged after keys and values were shifted")
+	}
+	changed := configMap.DeepCopy()
+	changed.Data["config.yaml"] = "port: 8080"
+	if got := checksum(secret(map[string]string{"password": "a", "user": "admin"}), changed); got == base {
+		t.Error("checksum unchanged after a ConfigMap value changed")
+	}
+	if got := checksum(nil, configMap); got == "" || got == base {
+		t.Errorf("checksum without the optional Secret = %q, want a different digest", got)
+	}
+
+	template := &corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"other": "kept"}}}
+	if err := SetConfigChecksum(template, configMap); err != nil {
+		t.Fatalf("SetConfigChecksum() error: %v", err)
+	}
+	if template.Annotations["other"] != "kept" {
+		t.Error("SetConfigChecksum() dropped existing annotations")
+	}
+
+	if err := SetConfigChecksum(template, &corev1.Service{}); err == nil {
+		t.Error("SetConfigChecksum() accepted a Service")
+	}
+	if template.Annotations[ConfigChecksumAnnotation] != checksum(nil, configMap) {
+		t.Error("SetConfigChecksum() changed the checksum on error")
+	}
+}
@@ -4,36 +4,161 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
-// CreateKubernetesClient creates a Kubernetes client using the default kubeconfig
-func CreateKubernetesClient() (*kubernetes.Clientset, error) {
-	var kubeconfig string
+const (
+	// DefaultQPS and DefaultBurst replace client-go's conservative 5/10 limits,
+	// which throttle commands that touch many modules at once.
+	DefaultQPS   = 20
+	DefaultBurst = 40
+)
+
+// ClientOptions tunes the Kubernetes clients
+type ClientOptions struct {
+	QPS     float32
+	Burst   int
+	Timeout time.Duration
+	// Kubeconfig is the kubeconfig file to load instead of ~/.kube/config
+	Kubeconfig string
+	// Context is the kubeconfig context to use instead of its current-context
+	Context string
+}
+
+// Clients builds the Kubernetes clients of one command run on first use and
+// reuses them afterwards, so the run keeps a single connection pool and rate
+// limiter. App creates it and the module registry hands it to every module
+// through config.GeneralConfig. A nil *Clients builds new clients from the
+// default kubeconfig on each call.
+type Clients struct {
+	options ClientOptions
+
+	mu               sync.Mutex
+	client           KubernetesClient
+	streaming        KubernetesClient
+	dynamic          dynamic.Interface
+	streamingDynamic dynamic.Interface
+}
 
-	// Try to get kubeconfig path
-	if home := homedir.HomeDir(); home != "" {
-		kubeconfig = filepath.Join(home, ".kube", "config")
+// NewClients returns the clients of a run, built from options when first used
+func NewClients(options ClientOptions) *Clients {
+	return &Clients{options: options}
+}
+
+// NewClientsFor wraps already built clients, such as fakes in tests. The
+// clients also serve streams; dyn may be nil when no dynamic client is used.
+func NewClientsFor(client KubernetesClient, dyn dynamic.Interface) *Clients {
+	return &Clients{client: client, streaming: client, dynamic: dyn, streamingDynamic: dyn}
+}
+
+// Kubernetes returns the typed client. Its requests time out after
+// ClientOptions.Timeout, 30 seconds by default, so it must not be used for
+// log streams or watches; use Streaming for those.
+func (c *Clients) Kubernetes() (KubernetesClient, error) {
+	if c == nil {
+		return NewKubernetesClient(ClientOptions{})
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// Check if kubeconfig file exists
-	if _, err := os.Stat(kubeconfig); os.IsNotExist(err) {
-		// Fallback to in-cluster config or default
-		kubeconfig = ""
+	if c.client != nil {
+		return c.client, nil
 	}
+	client, err := NewKubernetesClient(c.options)
+	if err != nil {
+		return nil, err
+	}
+	c.client = client
+	return client, nil
+}
+
+// Streaming returns a typed client without a request timeout, for followed
+// logs, watches and informers, whose responses stay open as long as needed
+func (c *Clients) Streaming() (KubernetesClient, error) {
+	if c == nil {
+		return newStreamingClient(ClientOptions{})
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// Build config from kubeconfig file
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if c.streaming != nil {
+		return c.streaming, nil
+	}
+	client, err := newStreamingClient(c.options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
+		return nil, err
 	}
+	c.streaming = client
+	return client, nil
+}
 
-	// Set reasonable timeout
-	config.Timeout = 30 * time.Second
+// Dynamic returns the client for resources without typed clients in
+// client-go, such as cert-manager Certificates
+func (c *Clients) Dynamic() (dynamic.Interface, error) {
+	if c == nil {
+		return newDynamicClient(ClientOptions{})
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dynamic != nil {
+		return c.dynamic, nil
+	}
+	client, err := newDynamicClient(c.options)
+	if err != nil {
+		return nil, err
+	}
+	c.dynamic = client
+	return client, nil
+}
+
+// StreamingDynamic returns a dynamic client without a request timeout, for
+// dynamic informers
+func (c *Clients) StreamingDynamic() (dynamic.Interface, error) {
+	if c == nil {
+		return newStreamingDynamicClient(ClientOptions{})
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.streamingDynamic != nil {
+		return c.streamingDynamic, nil
+	}
+	client, err := newStreamingDynamicClient(c.options)
+	if err != nil {
+		return nil, err
+	}
+	c.streamingDynamic = client
+	return client, nil
+}
+
+// Executor returns the Executor running commands in pods of this cluster
+func (c *Clients) Executor() Executor {
+	return PodExecutor{clients: c}
+}
+
+// restConfig returns the config the clients are built from
+func (c *Clients) restConfig() (*rest.Config, error) {
+	if c == nil {
+		return restConfig(ClientOptions{})
+	}
+	return restConfig(c.options)
+}
+
+// NewKubernetesClient builds a new Kubernetes client from the kubeconfig and
+// context in options
+func NewKubernetesClient(options ClientOptions) (*kubernetes.Clientset, error) {
+	config, err := restConfig(options)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create the clientset
 	clientset, err := kubernetes.NewForConfig(config)
@@ -43,3 +168,105 @@ func CreateKubernetesClient() (*kubernetes.Clientset, error) {
 
 	return clientset, nil
 }
+
+// newStreamingClient builds a Kubernetes client like NewKubernetesClient but
+// without a request timeout, which would also cut off response bodies
+func newStreamingClient(options ClientOptions) (*kubernetes.Clientset, error) {
+	config, err := restConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = 0
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return clientset, nil
+}
+
+func newDynamicClient(options ClientOptions) (dynamic.Interface, error) {
+	config, err := restConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic Kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+// newStreamingDynamicClient builds a dynamic client like newDynamicClient but
+// without a request timeout
+func newStreamingDynamicClient(options ClientOptions) (dynamic.Interface, error) {
+	config, err := restConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = 0
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic Kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+// restConfig loads the kubeconfig and context selected by options, by default
+// ~/.kube/config with its current-context, and applies the limits to it
+func restConfig(options ClientOptions) (*rest.Config, error) {
+	var rules *clientcmd.ClientConfigLoadingRules
+	switch {
+	case options.Kubeconfig != "":
+		// An explicit path that does not exist is an error rather than a
+		// silent switch to another cluster
+		rules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: options.Kubeconfig}
+	case os.Getenv(clientcmd.RecommendedConfigPathEnvVar) != "":
+		// KUBECONFIG may list several files, merged as kubectl does
+		rules = clientcmd.NewDefaultClientConfigLoadingRules()
+	default:
+		rules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: defaultKubeconfig()}
+	}
+
+	var config *rest.Config
+	var err error
+	if rules.ExplicitPath == "" && len(rules.Precedence) == 0 && options.Context == "" {
+		// Fallback to in-cluster config or default
+		config, err = clientcmd.BuildConfigFromFlags("", "")
+	} else {
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: options.Context}
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
+	}
+
+	// Set reasonable timeout and client-side rate limits
+	config.Timeout = 30 * time.Second
+	if options.Timeout > 0 {
+		config.Timeout = options.Timeout
+	}
+	config.QPS = DefaultQPS
+	if options.QPS > 0 {
+		config.QPS = options.QPS
+	}
+	config.Burst = DefaultBurst
+	if options.Burst > 0 {
+		config.Burst = options.Burst
+	}
+	return config, nil
+}
+
+// defaultKubeconfig returns ~/.kube/config if it exists
+func defaultKubeconfig() string {
+	home := homedir.HomeDir()
+	if home == "" {
+		return ""
+	}
+	kubeconfig := filepath.Join(home, ".kube", "config")
+	if _, err := os.Stat(kubeconfig); err != nil {
+		return ""
+	}
+	return kubeconfig
+}
@@ -0,0 +1,183 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: abc
+`
+
+func withTestKubeconfig(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".kube"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".kube", "config"), []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", "")
+}
+
+func TestClients_Reuse(t *testing.T) {
+	withTestKubeconfig(t)
+	clients := NewClients(ClientOptions{})
+
+	first, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("Kubernetes() error: %v", err)
+	}
+	second, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("Kubernetes() error: %v", err)
+	}
+	if first != second {
+		t.Error("Kubernetes() should reuse the client")
+	}
+
+	other, err := NewClients(ClientOptions{}).Kubernetes()
+	if err != nil {
+		t.Fatalf("Kubernetes() error: %v", err)
+	}
+	if other == first {
+		t.Error("separate Clients should not share a client")
+	}
+}
+
+func TestClients_StreamingHasNoTimeout(t *testing.T) {
+	withTestKubeconfig(t)
+	clients := NewClients(ClientOptions{Timeout: time.Minute})
+
+	client, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("Kubernetes() error: %v", err)
+	}
+	streaming, err := clients.Streaming()
+	if err != nil {
+		t.Fatalf("Streaming() error: %v", err)
+	}
+	if got := httpClientTimeout(t, client); got != time.Minute {
+		t.Errorf("Kubernetes() timeout = %v, want %v", got, time.Minute)
+	}
+	if got := httpClientTimeout(t, streaming); got != 0 {
+		t.Errorf("Streaming() timeout = %v, want none", got)
+	}
+}
+
+func httpClientTimeout(t *testing.T, client KubernetesClient) time.Duration {
+	t.Helper()
+	rest, ok := client.CoreV1().RESTClient().(*restclient.RESTClient)
+	if !ok {
+		t.Fatalf("unexpected REST client %T", client.CoreV1().RESTClient())
+	}
+	return rest.Client.Timeout
+}
+
+func TestClients_Nil(t *testing.T) {
+	withTestKubeconfig(t)
+	var clients *Clients
+	if _, err := clients.Kubernetes(); err != nil {
+		t.Errorf("Kubernetes() on nil Clients error: %v", err)
+	}
+	if _, err := clients.Streaming(); err != nil {
+		t.Errorf("Streaming() on nil Clients error: %v", err)
+	}
+}
+
+func TestNewKubernetesClient_RateLimits(t *testing.T) {
+	withTestKubeconfig(t)
+
+	tests := []struct {
+		name    string
+		options ClientOptions
+		wantQPS float32
+	}{
+		{name: "defaults", options: ClientOptions{}, wantQPS: DefaultQPS},
+		{name: "custom", options: ClientOptions{QPS: 100, Burst: 200, Timeout: time.Minute}, wantQPS: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewKubernetesClient(tt.options)
+			if err != nil {
+				t.Fatalf("NewKubernetesClient() error: %v", err)
+			}
+			if got := client.CoreV1().RESTClient().GetRateLimiter().QPS(); got != tt.wantQPS {
+				t.Errorf("QPS = %v, want %v", got, tt.wantQPS)
+			}
+		})
+	}
+}
+
+func TestRestConfig_KubeconfigAndContext(t *testing.T) {
+	withTestKubeconfig(t)
+	other := filepath.Join(t.TempDir(), "remote.yaml")
+	remote := strings.Replace(testKubeconfig, "current-context: test", `- name: remote
+  context:
+    cluster: remote
+    user: test
+current-context: test`, 1)
+	remote = strings.Replace(remote, "contexts:", `- name: remote
+  cluster:
+    server: https://remote.example.com:16443
+contexts:`, 1)
+	if err := os.WriteFile(other, []byte(remote), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		options  ClientOptions
+		env      string // KUBECONFIG
+		wantHost string
+		wantErr  string
+	}{
+		{name: "default kubeconfig", options: ClientOptions{}, wantHost: "https://127.0.0.1:6443"},
+		{name: "explicit kubeconfig", options: ClientOptions{Kubeconfig: other}, wantHost: "https://127.0.0.1:6443"},
+		{name: "context", options: ClientOptions{Kubeconfig: other, Context: "remote"}, wantHost: "https://remote.example.com:16443"},
+		{name: "unknown context", options: ClientOptions{Context: "remote"}, wantErr: `context "remote" does not exist`},
+		{name: "missing kubeconfig", options: ClientOptions{Kubeconfig: filepath.Join(t.TempDir(), "missing")}, wantErr: "missing"},
+		{name: "KUBECONFIG", options: ClientOptions{Context: "remote"}, env: other, wantHost: "https://remote.example.com:16443"},
+		{name: "flag over KUBECONFIG", options: ClientOptions{Kubeconfig: filepath.Join(t.TempDir(), "missing")}, env: other, wantErr: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", tt.env)
+			config, err := restConfig(tt.options)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("restConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("restConfig() error: %v", err)
+			}
+			if config.Host != tt.wantHost {
+				t.Errorf("Host = %q, want %q", config.Host, tt.wantHost)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// ObjectDiff is the difference between a desired object and the cluster
+type ObjectDiff struct {
+	// Missing is set when the object does not exist in the cluster
+	Missing bool
+	// Fields lists the fields that differ, see Diff
+	Fields []FieldDiff
+}
+
+// DiffObject compares desired with its live counterpart in the cluster
+func DiffObject(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, desired *unstructured.Unstructured) (ObjectDiff, error) {
+	resource, err := resourceFor(dyn, mapper, desired)
+	if err != nil {
+		return ObjectDiff{}, err
+	}
+	live, err := resource.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ObjectDiff{Missing: true}, nil
+	}
+	if err != nil {
+		return ObjectDiff{}, fmt.Errorf("failed to get %s %s: %w", desired.GetKind(), desired.GetName(), err)
+	}
+	return ObjectDiff{Fields: Diff(secretData(desired), live)}, nil
+}
+
+// secretData returns a Secret with its stringData moved to data, the way the
+// API server stores it, so the two compare equal. Other objects are returned
+// as they are.
+func secretData(object *unstructured.Unstructured) *unstructured.Unstructured {
+	stringData, found, _ := unstructured.NestedStringMap(object.Object, "stringData")
+	if object.GetKind() != "Secret" || !found {
+		return object
+	}
+	object = object.DeepCopy()
+	data, _, _ := unstructured.NestedMap(object.Object, "data")
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	unstructured.RemoveNestedField(object.Object, "stringData")
+	unstructured.SetNestedMap(object.Object, data, "data")
+	return object
+}
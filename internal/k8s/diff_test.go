@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestDiffObject(t *testing.T) {
+	ctx := context.Background()
+	dyn, mapper := newManifestTestClients()
+	desired := decodeOne(t, desiredDeployment)
+
+	if diff, err := DiffObject(ctx, dyn, mapper, desired); err != nil || !diff.Missing {
+		t.Fatalf("DiffObject() of a missing object = %+v, %v, want missing", diff, err)
+	}
+
+	live := desired.DeepCopy()
+	unstructured.SetNestedField(live.Object, int64(3), "spec", "replicas")
+	if _, err := dyn.Resource(deploymentGVR).Namespace("example").Create(ctx, live, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := DiffObject(ctx, dyn, mapper, desired)
+	if err != nil || diff.Missing {
+		t.Fatalf("DiffObject() = %+v, %v, want the live object compared", diff, err)
+	}
+	if len(diff.Fields) != 1 || fmt.Sprintf("%+v", diff.Fields[0]) != "{Path:spec.replicas Desired:1 Live:3}" {
+		t.Errorf("DiffObject() fields = %+v, want spec.replicas from 3 to 1", diff.Fields)
+	}
+}
+
+func TestDiffObjectSecretStringData(t *testing.T) {
+	ctx := context.Background()
+	secretGVR := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		secretGVR: "SecretList",
+	})
+
+	live := decodeOne(t, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: example\n  namespace: example\ndata:\n  token: c2VjcmV0\n")
+	if _, err := dyn.Resource(secretGVR).Namespace("example").Create(ctx, live, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	desired := decodeOne(t, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: example\n  namespace: example\nstringData:\n  token: secret\n")
+	if diff, err := DiffObject(ctx, dyn, mapper, desired); err != nil || len(diff.Fields) != 0 {
+		t.Errorf("DiffObject() of stringData matching data = %+v, %v, want no difference", diff, err)
+	}
+
+	unstructured.SetNestedField(desired.Object, "rotated", "stringData", "token")
+	if diff, err := DiffObject(ctx, dyn, mapper, desired); err != nil || len(diff.Fields) != 1 || diff.Fields[0].Path != "data.token" {
+		t.Errorf("DiffObject() of changed stringData = %+v, %v, want data.token", diff, err)
+	}
+}
@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// zoneinfoDir is where the IANA time zone database lives on nodes and in
+// most images
+const zoneinfoDir = "/usr/share/zoneinfo"
+
+// CABundlePath is where SetPodEnvironment mounts the CA bundle in containers
+const CABundlePath = "/etc/ssl/trusted/ca-certificates.crt"
+
+// clusterNoProxy are always reached directly: loopback and in-cluster names
+var clusterNoProxy = []string{"localhost", "127.0.0.1", ".svc", ".cluster.local"}
+
+// PodEnvironment is what every module's pods inherit from the general config:
+// local time, locale, outbound proxy and trusted CAs. Empty fields keep the
+// image defaults.
+type PodEnvironment struct {
+	Timezone   string
+	Locale     string
+	HTTPProxy  string
+	HTTPSProxy string
+	// NoProxy lists hosts, domains and CIDRs reached without the proxy, in
+	// addition to loopback and cluster-internal names
+	NoProxy []string
+	// CABundle is the path on the node of a PEM bundle replacing the CAs the
+	// images trust
+	CABundle string
+}
+
+// SetPodEnvironment applies env to every container and init container of
+// spec: TZ and LANG, HTTP_PROXY, HTTPS_PROXY and NO_PROXY in both cases, and
+// the CA bundle mounted at CABundlePath with the variables OpenSSL, Go,
+// Python requests and Node.js read it from. Variables a container already
+// sets are left alone.
+func SetPodEnvironment(spec *corev1.PodSpec, env PodEnvironment) {
+	vars := []corev1.EnvVar{
+		{Name: "TZ", Value: env.Timezone},
+		{Name: "LANG", Value: env.Locale},
+	}
+	if env.HTTPProxy != "" || env.HTTPSProxy != "" {
+		noProxy := strings.Join(append(append([]string{}, clusterNoProxy...), env.NoProxy...), ",")
+		// Many tools only read the lower-case variables, others only the
+		// upper-case ones
+		for _, v := range []corev1.EnvVar{
+			{Name: "HTTP_PROXY", Value: env.HTTPProxy},
+			{Name: "HTTPS_PROXY", Value: env.HTTPSProxy},
+			{Name: "NO_PROXY", Value: noProxy},
+		} {
+			vars = append(vars, v, corev1.EnvVar{Name: strings.ToLower(v.Name), Value: v.Value})
+		}
+	}
+	if env.CABundle != "" {
+		for _, name := range []string{"SSL_CERT_FILE", "REQUESTS_CA_BUNDLE", "NODE_EXTRA_CA_CERTS"} {
+			vars = append(vars, corev1.EnvVar{Name: name, Value: CABundlePath})
+		}
+		fileType := corev1.HostPathFile
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: "ca-bundle",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: env.CABundle, Type: &fileType},
+			},
+		})
+	}
+
+	set := func(containers []corev1.Container) {
+		for i := range containers {
+			for _, v := range vars {
+				setDefaultEnv(&containers[i], v.Name, v.Value)
+			}
+			if env.CABundle != "" {
+				containers[i].VolumeMounts = append(containers[i].VolumeMounts, corev1.VolumeMount{
+					Name:      "ca-bundle",
+					MountPath: CABundlePath,
+					ReadOnly:  true,
+				})
+			}
+		}
+	}
+	set(spec.InitContainers)
+	set(spec.Containers)
+}
+
+// MountZoneinfo mounts the node's time zone database read-only into every
+// container of spec. Images without tzdata, such as alpine ones, otherwise
+// ignore a TZ naming a zone and stay on UTC. It does nothing when timezone is
+// empty.
+func MountZoneinfo(spec *corev1.PodSpec, timezone string) {
+	if timezone == "" {
+		return
+	}
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: "zoneinfo",
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: zoneinfoDir},
+		},
+	})
+	for i := range spec.Containers {
+		spec.Containers[i].VolumeMounts = append(spec.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name:      "zoneinfo",
+			MountPath: zoneinfoDir,
+			ReadOnly:  true,
+		})
+	}
+}
+
+// setDefaultEnv adds name=value to the container unless value is empty or
+// the container already sets name
+func setDefaultEnv(container *corev1.Container, name, value string) {
+	if value == "" {
+		return
+	}
+	for _, env := range container.Env {
+		if env.Name == name {
+			return
+		}
+	}
+	container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: value})
+}
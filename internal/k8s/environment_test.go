@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetPodEnvironment(t *testing.T) {
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init"}},
+		Containers: []corev1.Container{
+			{Name: "app"},
+			{Name: "sidecar", Env: []corev1.EnvVar{{Name: "TZ", Value: "UTC"}}},
+		},
+	}
+	SetPodEnvironment(spec, PodEnvironment{Timezone: "Europe/Berlin", Locale: "C.UTF-8"})
+
+	env := func(container corev1.Container) map[string]string {
+		values := map[string]string{}
+		for _, e := range container.Env {
+			values[e.Name] = e.Value
+		}
+		return values
+	}
+	for _, container := range []corev1.Container{spec.InitContainers[0], spec.Containers[0]} {
+		if got := env(container); got["TZ"] != "Europe/Berlin" || got["LANG"] != "C.UTF-8" {
+			t.Errorf("%s env = %v, want TZ=Europe/Berlin LANG=C.UTF-8", container.Name, got)
+		}
+	}
+	if got := env(spec.Containers[1]); got["TZ"] != "UTC" || len(spec.Containers[1].Env) != 2 {
+		t.Errorf("sidecar env = %v, want its own TZ kept and LANG added", spec.Containers[1].Env)
+	}
+
+	spec = &corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}
+	SetPodEnvironment(spec, PodEnvironment{})
+	if spec.Containers[0].Env != nil || spec.Volumes != nil {
+		t.Errorf("empty environment set env %v and volumes %v, want none", spec.Containers[0].Env, spec.Volumes)
+	}
+}
+
+func TestSetPodEnvironmentProxyAndCABundle(t *testing.T) {
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init"}},
+		Containers:     []corev1.Container{{Name: "app"}},
+	}
+	SetPodEnvironment(spec, PodEnvironment{
+		HTTPSProxy: "http://proxy.corp:3128",
+		NoProxy:    []string{".corp", "10.0.0.0/8"},
+		CABundle:   "/etc/ssl/certs/ca-certificates.crt",
+	})
+
+	for _, container := range []corev1.Container{spec.InitContainers[0], spec.Containers[0]} {
+		env := map[string]string{}
+		for _, e := range container.Env {
+			env[e.Name] = e.Value
+		}
+		if env["HTTPS_PROXY"] != "http://proxy.corp:3128" || env["https_proxy"] != "http://proxy.corp:3128" {
+			t.Errorf("%s HTTPS proxy = %q/%q, want http://proxy.corp:3128", container.Name, env["HTTPS_PROXY"], env["https_proxy"])
+		}
+		if _, ok := env["HTTP_PROXY"]; ok {
+			t.Errorf("%s sets HTTP_PROXY without an HTTP proxy configured", container.Name)
+		}
+		if want := "localhost,127.0.0.1,.svc,.cluster.local,.corp,10.0.0.0/8"; env["NO_PROXY"] != want || env["no_proxy"] != want {
+			t.Errorf("%s NO_PROXY = %q, want %q", container.Name, env["NO_PROXY"], want)
+		}
+		if env["SSL_CERT_FILE"] != CABundlePath || env["NODE_EXTRA_CA_CERTS"] != CABundlePath {
+			t.Errorf("%s CA variables = %v, want %s", container.Name, env, CABundlePath)
+		}
+		if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != CABundlePath || !container.VolumeMounts[0].ReadOnly {
+			t.Errorf("%s VolumeMounts = %+v, want the bundle at %s", container.Name, container.VolumeMounts, CABundlePath)
+		}
+	}
+	if len(spec.Volumes) != 1 || spec.Volumes[0].HostPath == nil || spec.Volumes[0].HostPath.Path != "/etc/ssl/certs/ca-certificates.crt" {
+		t.Errorf("Volumes = %+v, want the node's bundle", spec.Volumes)
+	}
+}
+
+func TestMountZoneinfo(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}
+	MountZoneinfo(spec, "")
+	if len(spec.Volumes) != 0 {
+		t.Errorf("Volumes without a time zone = %v, want none", spec.Volumes)
+	}
+
+	MountZoneinfo(spec, "Europe/Berlin")
+	if len(spec.Volumes) != 1 || spec.Volumes[0].HostPath == nil || spec.Volumes[0].HostPath.Path != "/usr/share/zoneinfo" {
+		t.Fatalf("Volumes = %+v, want the node's /usr/share/zoneinfo", spec.Volumes)
+	}
+	mounts := spec.Containers[0].VolumeMounts
+	if len(mounts) != 1 || mounts[0].MountPath != "/usr/share/zoneinfo" || !mounts[0].ReadOnly {
+		t.Errorf("VolumeMounts = %+v, want /usr/share/zoneinfo read-only", mounts)
+	}
+}
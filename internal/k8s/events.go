@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// WarningEvents returns the Warning events in namespace about the objects
+// match accepts, newest first
+func WarningEvents(ctx context.Context, client KubernetesClient, namespace string, match func(corev1.ObjectReference) bool) ([]corev1.Event, error) {
+	list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	var events []corev1.Event
+	for _, event := range list.Items {
+		// Not every client honours the field selector
+		if event.Type == corev1.EventTypeWarning && match(event.InvolvedObject) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return EventTime(events[i]).After(EventTime(events[j]))
+	})
+	return events, nil
+}
+
+// EventTime returns when event last happened
+func EventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// FormatEvent formats event as one line with its age relative to now, e.g.
+// "2m  Pod/gitea-0  BackOff (x5): Back-off restarting failed container"
+func FormatEvent(event corev1.Event, now time.Time) string {
+	reason := event.Reason
+	if event.Count > 1 {
+		reason += fmt.Sprintf(" (x%d)", event.Count)
+	}
+	message := strings.Join(strings.Fields(event.Message), " ")
+	return fmt.Sprintf("%-4s %s/%s  %s: %s", FormatAge(now.Sub(EventTime(event))), event.InvolvedObject.Kind, event.InvolvedObject.Name, reason, message)
+}
@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWarningEvents(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	event := func(name, kind, object, eventType, reason string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "infra"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object},
+			Type:           eventType,
+			Reason:         reason,
+			Message:        "message of\n" + name,
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+	client := fake.NewSimpleClientset(
+		event("old", "Pod", "gitea-0", corev1.EventTypeWarning, "FailedScheduling", time.Hour),
+		event("new", "Pod", "gitea-0", corev1.EventTypeWarning, "BackOff", 2*time.Minute),
+		event("normal", "Pod", "gitea-0", corev1.EventTypeNormal, "Pulled", time.Minute),
+		event("other", "Pod", "postgres-0", corev1.EventTypeWarning, "BackOff", time.Minute),
+	)
+
+	events, err := WarningEvents(context.Background(), client, "infra", func(ref corev1.ObjectReference) bool {
+		return ref.Kind == "Pod" && ref.Name == "gitea-0"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Name != "new" || events[1].Name != "old" {
+		t.Fatalf("WarningEvents() = %v, want new then old", events)
+	}
+
+	events[0].Count = 5
+	if got, want := FormatEvent(events[0], now), "2m   Pod/gitea-0  BackOff (x5): message of new"; got != want {
+		t.Errorf("FormatEvent() = %q, want %q", got, want)
+	}
+}
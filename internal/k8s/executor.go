@@ -0,0 +1,225 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Executor runs commands inside pod containers. Backup, restore and database
+// provisioning go through it so tests can replace the cluster with a
+// ReplayExecutor.
+type Executor interface {
+	Exec(ctx context.Context, req ExecRequest) error
+}
+
+// ExecRequest describes one command run in a pod
+type ExecRequest struct {
+	Namespace string
+	Pod       string
+	Container string // empty selects the pod's default container
+	Command   []string
+	Stdin     io.Reader // nil when the command reads no input
+	Stdout    io.Writer
+	Stderr    io.Writer
+}
+
+// ExecFunc adapts a function to the Executor interface
+type ExecFunc func(ctx context.Context, req ExecRequest) error
+
+// Exec calls f(ctx, req)
+func (f ExecFunc) Exec(ctx context.Context, req ExecRequest) error {
+	return f(ctx, req)
+}
+
+// ExecCombinedOutput runs req and returns its stdout and stderr together,
+// like exec.Cmd.CombinedOutput. req.Stdout and req.Stderr are ignored.
+func ExecCombinedOutput(ctx context.Context, executor Executor, req ExecRequest) ([]byte, error) {
+	var out bytes.Buffer
+	req.Stdout = &out
+	req.Stderr = &out
+	err := executor.Exec(ctx, req)
+	return out.Bytes(), err
+}
+
+// ExecRecord is one exchange captured by RecordingExecutor and played back by
+// ReplayExecutor. Streams are kept as strings so recordings stay readable;
+// binary streams such as tar archives are better built in the test itself.
+type ExecRecord struct {
+	Namespace string   `json:"namespace"`
+	Pod       string   `json:"pod"`
+	Container string   `json:"container,omitempty"`
+	Command   []string `json:"command"`
+	Stdin     string   `json:"stdin,omitempty"`
+	Stdout    string   `json:"stdout,omitempty"`
+	Stderr    string   `json:"stderr,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// RecordingExecutor passes requests to another Executor and records each
+// exchange, so a session against a real cluster can be saved as test data
+type RecordingExecutor struct {
+	next Executor
+
+	mu      sync.Mutex
+	records []ExecRecord
+}
+
+// NewRecordingExecutor records the exchanges handled by next
+func NewRecordingExecutor(next Executor) *RecordingExecutor {
+	return &RecordingExecutor{next: next}
+}
+
+// Exec runs req on the wrapped executor, copying its streams into a record
+func (r *RecordingExecutor) Exec(ctx context.Context, req ExecRequest) error {
+	var stdin, stdout, stderr bytes.Buffer
+	if req.Stdin != nil {
+		req.Stdin = io.TeeReader(req.Stdin, &stdin)
+	}
+	req.Stdout = teeWriter(req.Stdout, &stdout)
+	req.Stderr = teeWriter(req.Stderr, &stderr)
+
+	err := r.next.Exec(ctx, req)
+
+	record := ExecRecord{
+		Namespace: req.Namespace,
+		Pod:       req.Pod,
+		Container: req.Container,
+		Command:   append([]string(nil), req.Command...),
+		Stdin:     stdin.String(),
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.records = append(r.records, record)
+	r.mu.Unlock()
+	return err
+}
+
+// Records returns the exchanges recorded so far
+func (r *RecordingExecutor) Records() []ExecRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ExecRecord(nil), r.records...)
+}
+
+func teeWriter(w io.Writer, copy *bytes.Buffer) io.Writer {
+	if w == nil {
+		return copy
+	}
+	return io.MultiWriter(w, copy)
+}
+
+// SaveExecRecords writes records to path as indented JSON
+func SaveExecRecords(path string, records []ExecRecord) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false) // keep shell operators such as && readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(records); err != nil {
+		return fmt.Errorf("failed to encode exec records: %w", err)
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write exec records: %w", err)
+	}
+	return nil
+}
+
+// LoadExecRecords reads records written by SaveExecRecords
+func LoadExecRecords(path string) ([]ExecRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exec records: %w", err)
+	}
+	var records []ExecRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode exec records %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// ReplayExecutor serves recorded exchanges in order instead of running
+// commands. Each request must match the next record's pod, container, command
+// and stdin; its stdout, stderr and error are then replayed to the caller.
+type ReplayExecutor struct {
+	mu      sync.Mutex
+	records []ExecRecord
+	next    int
+}
+
+// NewReplayExecutor replays records in the given order
+func NewReplayExecutor(records ...ExecRecord) *ReplayExecutor {
+	return &ReplayExecutor{records: records}
+}
+
+// Exec checks req against the next record and replays its result
+func (r *ReplayExecutor) Exec(ctx context.Context, req ExecRequest) error {
+	var stdin []byte
+	if req.Stdin != nil {
+		var err error
+		if stdin, err = io.ReadAll(req.Stdin); err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= len(r.records) {
+		return fmt.Errorf("unexpected exec %q in %s/%s: all %d recorded exchanges used", strings.Join(req.Command, " "), req.Namespace, req.Pod, len(r.records))
+	}
+	record := r.records[r.next]
+	r.next++
+
+	got := ExecRecord{Namespace: req.Namespace, Pod: req.Pod, Container: req.Container, Command: req.Command, Stdin: string(stdin)}
+	want := ExecRecord{Namespace: record.Namespace, Pod: record.Pod, Container: record.Container, Command: record.Command, Stdin: record.Stdin}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("exec %d does not match the recording:\n got: %s\nwant: %s", r.next, describeExec(got), describeExec(want))
+	}
+
+	if req.Stdout != nil {
+		if _, err := io.WriteString(req.Stdout, record.Stdout); err != nil {
+			return err
+		}
+	}
+	if req.Stderr != nil {
+		if _, err := io.WriteString(req.Stderr, record.Stderr); err != nil {
+			return err
+		}
+	}
+	if record.Error != "" {
+		return errors.New(record.Error)
+	}
+	return nil
+}
+
+// Verify returns an error when recorded exchanges were never requested
+func (r *ReplayExecutor) Verify() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next < len(r.records) {
+		return fmt.Errorf("%d of %d recorded exchanges not used, next: %s", len(r.records)-r.next, len(r.records), describeExec(r.records[r.next]))
+	}
+	return nil
+}
+
+func describeExec(record ExecRecord) string {
+	target := record.Namespace + "/" + record.Pod
+	if record.Container != "" {
+		target += " -c " + record.Container
+	}
+	description := fmt.Sprintf("%s %q", target, record.Command)
+	if record.Stdin != "" {
+		description += fmt.Sprintf(" stdin %q", record.Stdin)
+	}
+	return description
+}
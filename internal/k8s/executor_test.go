@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordingExecutorRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cluster := ExecFunc(func(ctx context.Context, req ExecRequest) error {
+		input, _ := io.ReadAll(req.Stdin)
+		io.WriteString(req.Stdout, "got "+string(input))
+		if req.Command[0] == "fail" {
+			io.WriteString(req.Stderr, "boom")
+			return errors.New("exit status 1")
+		}
+		return nil
+	})
+
+	recorder := NewRecordingExecutor(cluster)
+	var stdout bytes.Buffer
+	req := ExecRequest{Namespace: "infra", Pod: "postgres-0", Command: []string{"psql"}, Stdin: strings.NewReader("SELECT 1;"), Stdout: &stdout}
+	if err := recorder.Exec(ctx, req); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if stdout.String() != "got SELECT 1;" {
+		t.Errorf("stdout = %q, want the wrapped executor's output", stdout.String())
+	}
+	failing := ExecRequest{Namespace: "infra", Pod: "postgres-0", Command: []string{"fail"}, Stdin: strings.NewReader("")}
+	if _, err := ExecCombinedOutput(ctx, recorder, failing); err == nil {
+		t.Fatal("Exec() should return the wrapped executor's error")
+	}
+
+	path := filepath.Join(t.TempDir(), "exec.json")
+	if err := SaveExecRecords(path, recorder.Records()); err != nil {
+		t.Fatalf("SaveExecRecords() error = %v", err)
+	}
+	records, err := LoadExecRecords(path)
+	if err != nil {
+		t.Fatalf("LoadExecRecords() error = %v", err)
+	}
+	want := []ExecRecord{
+		{Namespace: "infra", Pod: "postgres-0", Command: []string{"psql"}, Stdin: "SELECT 1;", Stdout: "got SELECT 1;"},
+		{Namespace: "infra", Pod: "postgres-0", Command: []string{"fail"}, Stdout: "got ", Stderr: "boom", Error: "exit status 1"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("records = %+v, want %+v", records, want)
+	}
+
+	replay := NewReplayExecutor(records...)
+	stdout.Reset()
+	req.Stdin = strings.NewReader("SELECT 1;")
+	if err := replay.Exec(ctx, req); err != nil {
+		t.Fatalf("replayed Exec() error = %v", err)
+	}
+	if stdout.String() != "got SELECT 1;" {
+		t.Errorf("replayed stdout = %q, want %q", stdout.String(), "got SELECT 1;")
+	}
+	failing.Stdin = strings.NewReader("")
+	out, err := ExecCombinedOutput(ctx, replay, failing)
+	if err == nil || err.Error() != "exit status 1" {
+		t.Errorf("replayed error = %v, want exit status 1", err)
+	}
+	if string(out) != "got boom" {
+		t.Errorf("replayed output = %q, want %q", out, "got boom")
+	}
+	if err := replay.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestReplayExecutorMismatch(t *testing.T) {
+	ctx := context.Background()
+	record := ExecRecord{Namespace: "infra", Pod: "postgres-0", Command: []string{"psql"}, Stdin: "SELECT 1;"}
+
+	tests := []struct {
+		name string
+		req  ExecRequest
+	}{
+		{name: "different command", req: ExecRequest{Namespace: "infra", Pod: "postgres-0", Command: []string{"pg_dumpall"}, Stdin: strings.NewReader("SELECT 1;")}},
+		{name: "different stdin", req: ExecRequest{Namespace: "infra", Pod: "postgres-0", Command: []string{"psql"}, Stdin: strings.NewReader("SELECT 2;")}},
+		{name: "different pod", req: ExecRequest{Namespace: "infra", Pod: "postgres-1", Command: []string{"psql"}, Stdin: strings.NewReader("SELECT 1;")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay := NewReplayExecutor(record)
+			if err := replay.Exec(ctx, tt.req); err == nil {
+				t.Error("Exec() should fail when the request does not match the recording")
+			}
+		})
+	}
+
+	replay := NewReplayExecutor(record)
+	if err := replay.Verify(); err == nil {
+		t.Error("Verify() should report unused records")
+	}
+	replay = NewReplayExecutor()
+	if err := replay.Exec(ctx, ExecRequest{Command: []string{"psql"}}); err == nil {
+		t.Error("Exec() should fail once the recording is exhausted")
+	}
+}
@@ -0,0 +1,118 @@
+package k8s
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// apr1Alphabet is the custom base64 alphabet used by Apache's MD5 crypt
+const apr1Alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// GenerateHtpasswd builds htpasswd file content for the given users
+// (username -> plaintext password), one line per user sorted by username.
+// Passwords are hashed with the Apache APR1-MD5 scheme understood by
+// ingress-nginx basic-auth.
+func GenerateHtpasswd(users map[string]string) (string, error) {
+	names := make([]string, 0, len(users))
+	for name := range users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		if name == "" || strings.Contains(name, ":") {
+			return "", fmt.Errorf("invalid htpasswd username %q", name)
+		}
+		salt, err := randomAPR1Salt()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(name)
+		sb.WriteString(":")
+		sb.WriteString(APR1Hash(users[name], salt))
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func randomAPR1Salt() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	for i := range b {
+		b[i] = apr1Alphabet[int(b[i])%len(apr1Alphabet)]
+	}
+	return string(b), nil
+}
+
+// APR1Hash hashes password with the given salt using Apache's APR1-MD5
+// algorithm, returning a string of the form "$apr1$<salt>$<hash>".
+func APR1Hash(password, salt string) string {
+	const magic = "$apr1$"
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alt := md5.Sum([]byte(password + salt + password))
+
+	ctx := md5.New()
+	ctx.Write([]byte(password + magic + salt))
+	for i := len(password); i > 0; i -= 16 {
+		if i > 16 {
+			ctx.Write(alt[:])
+		} else {
+			ctx.Write(alt[:i])
+		}
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			ctx.Write([]byte{0})
+		} else {
+			ctx.Write([]byte(password[:1]))
+		}
+	}
+	sum := ctx.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		round := md5.New()
+		if i&1 == 1 {
+			round.Write([]byte(password))
+		} else {
+			round.Write(sum)
+		}
+		if i%3 != 0 {
+			round.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			round.Write([]byte(password))
+		}
+		if i&1 == 1 {
+			round.Write(sum)
+		} else {
+			round.Write([]byte(password))
+		}
+		sum = round.Sum(nil)
+	}
+
+	var out strings.Builder
+	encode := func(a, b, c byte, n int) {
+		v := uint(a)<<16 | uint(b)<<8 | uint(c)
+		for ; n > 0; n-- {
+			out.WriteByte(apr1Alphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+	encode(sum[0], sum[6], sum[12], 4)
+	encode(sum[1], sum[7], sum[13], 4)
+	encode(sum[2], sum[8], sum[14], 4)
+	encode(sum[3], sum[9], sum[15], 4)
+	encode(sum[4], sum[10], sum[5], 4)
+	encode(0, 0, sum[11], 2)
+
+	return magic + salt + "$" + out.String()
+}
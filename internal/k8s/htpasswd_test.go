@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPR1Hash(t *testing.T) {
+	tests := []struct {
+		password string
+		salt     string
+		want     string
+	}{
+		// Reference values produced by `openssl passwd -apr1 -salt <salt> <password>`
+		{password: "myPassword", salt: "r31.....", want: "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/"},
+		{password: "secret", salt: "abcdefgh", want: "$apr1$abcdefgh$h9FWgUz3n9YxylKLlR5SQ/"},
+	}
+
+	for _, tt := range tests {
+		if got := APR1Hash(tt.password, tt.salt); got != tt.want {
+			t.Errorf("APR1Hash(%q, %q) = %s, want %s", tt.password, tt.salt, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateHtpasswd(t *testing.T) {
+	content, err := GenerateHtpasswd(map[string]string{"bob": "b-pass", "alice": "a-pass"})
+	if err != nil {
+		t.Fatalf("GenerateHtpasswd() returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("GenerateHtpasswd() produced %d lines, want 2", len(lines))
+	}
+
+	for i, user := range []string{"alice", "bob"} {
+		name, hash, ok := strings.Cut(lines[i], ":")
+		if !ok || name != user {
+			t.Errorf("line %d = %q, want entry for %s", i, lines[i], user)
+			continue
+		}
+		salt := strings.Split(hash, "$")[2]
+		if APR1Hash(user[:1]+"-pass", salt) != hash {
+			t.Errorf("hash for %s does not verify", user)
+		}
+	}
+
+	if _, err := GenerateHtpasswd(map[string]string{"bad:name": "x"}); err == nil {
+		t.Error("GenerateHtpasswd() should reject usernames containing ':'")
+	}
+}
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// DecodeManifest splits a multi-document YAML or JSON manifest, such as an
+// upstream release's install manifest, into objects. Empty documents are
+// skipped.
+func DecodeManifest(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var objects []*unstructured.Unstructured
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("failed to decode manifest document %d: %w", len(objects)+1, err)
+		}
+		if len(object.Object) == 0 {
+			continue
+		}
+		if object.GetKind() == "" || object.GetName() == "" {
+			return nil, fmt.Errorf("manifest document %d has no kind or name", len(objects)+1)
+		}
+		objects = append(objects, object)
+	}
+}
+
+// FetchManifest downloads a manifest, such as an upstream release's install
+// manifest
+func FetchManifest(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// RESTMapper returns a mapper from kinds to resources that discovers the
+// cluster's API groups on first use
+func (c *Clients) RESTMapper() (meta.RESTMapper, error) {
+	config, err := c.restConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client)), nil
+}
+
+// resourceFor returns the client for object's kind, scoped to its namespace
+// when the kind is namespaced
+func resourceFor(dyn dynamic.Interface, mapper meta.RESTMapper, object *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := object.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find resource for %s: %w", gvk, err)
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return dyn.Resource(mapping.Resource).Namespace(object.GetNamespace()), nil
+	}
+	return dyn.Resource(mapping.Resource), nil
+}
+
+// GetObject returns the live copy of object. A missing object yields a
+// NotFound error.
+func GetObject(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, object *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	resource, err := resourceFor(dyn, mapper, object)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Get(ctx, object.GetName(), metav1.GetOptions{})
+}
+
+// ApplyObjects creates the objects in order, replacing those that already
+// exist. Existing Jobs are left alone: their pod template is immutable and
+// they have already run. onApplied, when set, is called after each object.
+func ApplyObjects(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured, onApplied func(*unstructured.Unstructured)) error {
+	for _, object := range objects {
+		resource, err := resourceFor(dyn, mapper, object)
+		if err != nil {
+			return err
+		}
+		existing, err := resource.Get(ctx, object.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = resource.Create(ctx, object, metav1.CreateOptions{})
+		} else if err == nil && object.GetKind() == "Job" {
+			continue
+		} else if err == nil {
+			object = object.DeepCopy()
+			object.SetResourceVersion(existing.GetResourceVersion())
+			_, err = resource.Update(ctx, object, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", object.GetKind(), object.GetName(), err)
+		}
+		if onApplied != nil {
+			onApplied(object)
+		}
+	}
+	return nil
+}
+
+// DeleteObjects deletes the objects in reverse order, so namespaces go after
+// what they contain. Objects that do not exist are skipped. It returns how
+// many objects were deleted.
+func DeleteObjects(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured) (int, error) {
+	deleted := 0
+	propagation := metav1.DeletePropagationForeground
+	for i := len(objects) - 1; i >= 0; i-- {
+		object := objects[i]
+		resource, err := resourceFor(dyn, mapper, object)
+		if err != nil {
+			return deleted, err
+		}
+		err = resource.Delete(ctx, object.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete %s %s: %w", object.GetKind(), object.GetName(), err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const testManifest = `# Source: example/templates/namespace.yaml
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: example
+---
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: example
+  namespace: example
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  namespace: example
+spec:
+  replicas: 1
+`
+
+var (
+	namespaceGVR      = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	serviceAccountGVR = schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}
+	deploymentGVR     = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+func newManifestTestClients() (*dynamicfake.FakeDynamicClient, meta.RESTMapper) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		namespaceGVR:      "NamespaceList",
+		serviceAccountGVR: "ServiceAccountList",
+		deploymentGVR:     "DeploymentList",
+	})
+	return dyn, mapper
+}
+
+func TestDecodeManifest(t *testing.T) {
+	objects, err := DecodeManifest([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("DecodeManifest() error = %v", err)
+	}
+	var kinds []string
+	for _, object := range objects {
+		kinds = append(kinds, object.GetKind())
+	}
+	if len(kinds) != 3 || kinds[0] != "Namespace" || kinds[2] != "Deployment" {
+		t.Errorf("DecodeManifest() kinds = %v, want [Namespace ServiceAccount Deployment]", kinds)
+	}
+
+	if _, err := DecodeManifest([]byte("apiVersion: v1\nkind: ConfigMap\n")); err == nil {
+		t.Error("DecodeManifest() of an object without a name succeeded, want error")
+	}
+	if _, err := DecodeManifest([]byte("kind: [")); err == nil {
+		t.Error("DecodeManifest() of invalid YAML succeeded, want error")
+	}
+}
+
+func TestApplyAndDeleteObjects(t *testing.T) {
+	ctx := context.Background()
+	dyn, mapper := newManifestTestClients()
+	objects, err := DecodeManifest([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("DecodeManifest() error = %v", err)
+	}
+
+	var applied []string
+	for i := 0; i < 2; i++ {
+		applied = nil
+		err := ApplyObjects(ctx, dyn, mapper, objects, func(object *unstructured.Unstructured) {
+			applied = append(applied, object.GetKind())
+		})
+		if err != nil {
+			t.Fatalf("ApplyObjects() #%d error = %v", i+1, err)
+		}
+	}
+	if len(applied) != 3 {
+		t.Errorf("ApplyObjects() reported %v, want all 3 objects", applied)
+	}
+	if _, err := dyn.Resource(deploymentGVR).Namespace("example").Get(ctx, "example", metav1.GetOptions{}); err != nil {
+		t.Errorf("Deployment not created: %v", err)
+	}
+	if _, err := dyn.Resource(namespaceGVR).Get(ctx, "example", metav1.GetOptions{}); err != nil {
+		t.Errorf("Namespace not created: %v", err)
+	}
+
+	deleted, err := DeleteObjects(ctx, dyn, mapper, objects)
+	if err != nil || deleted != 3 {
+		t.Fatalf("DeleteObjects() = %d, %v, want 3, nil", deleted, err)
+	}
+	if deleted, err := DeleteObjects(ctx, dyn, mapper, objects); err != nil || deleted != 0 {
+		t.Errorf("DeleteObjects() of missing objects = %d, %v, want 0, nil", deleted, err)
+	}
+}
+
+func TestApplyObjectsUnknownKind(t *testing.T) {
+	dyn, mapper := newManifestTestClients()
+	objects, err := DecodeManifest([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n"))
+	if err != nil {
+		t.Fatalf("DecodeManifest() error = %v", err)
+	}
+	if err := ApplyObjects(context.Background(), dyn, mapper, objects, nil); err == nil {
+		t.Error("ApplyObjects() of an unknown kind succeeded, want error")
+	}
+}
+
+func TestFetchManifest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/deploy.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	defer server.Close()
+
+	data, err := FetchManifest(context.Background(), server.URL+"/deploy.yaml")
+	if err != nil {
+		t.Fatalf("FetchManifest() error = %v", err)
+	}
+	if string(data) != testManifest {
+		t.Errorf("FetchManifest() = %q, want the served manifest", data)
+	}
+	if _, err := FetchManifest(context.Background(), server.URL+"/missing.yaml"); err == nil {
+		t.Error("FetchManifest() of a missing release succeeded, want error")
+	}
+}
@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// reservedLabels are set by the modules themselves: app is part of workload
+// selectors and managed-by marks what the tool may delete
+var reservedLabels = map[string]bool{"app": true, ManagedByLabel: true}
+
+// ObjectMetadata are labels and annotations from the config that are added
+// to every object a module generates, e.g. for network policy or monitoring
+// selectors. Scrape, when set, also annotates the module's Services for the
+// prometheus module.
+type ObjectMetadata struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	Scrape      *Scrape
+}
+
+// ValidateObjectMetadata checks labels and annotations from the config. Keys
+// must be qualified names such as team or example.com/team, label values
+// valid label values, and app and managed-by are left to the modules.
+func ValidateObjectMetadata(metadata ObjectMetadata) error {
+	for _, key := range sortedKeys(metadata.Labels) {
+		if reservedLabels[key] {
+			return fmt.Errorf("label %s is set by personal-server", key)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(metadata.Labels[key]); len(errs) > 0 {
+			return fmt.Errorf("invalid value %q of label %s: %s", metadata.Labels[key], key, strings.Join(errs, "; "))
+		}
+	}
+	for _, key := range sortedKeys(metadata.Annotations) {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid annotation %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// SetObjectMetadata labels objects managed-by=personal-server and adds the
+// configured labels and annotations to them and to the pod templates of
+// workloads among them, so the pods can be selected as well, and the scrape
+// annotations to Services. Keys an object
+// already sets keep the module's value. Nil objects are skipped, so a
+// prepare() can pass the objects it only builds when configured.
+func SetObjectMetadata(metadata ObjectMetadata, objects ...metav1.Object) {
+	for _, object := range objects {
+		if object == nil || reflect.ValueOf(object).IsNil() {
+			continue
+		}
+		labels := mergeMissing(object.GetLabels(), map[string]string{ManagedByLabel: ManagedByValue})
+		object.SetLabels(mergeMissing(labels, metadata.Labels))
+		object.SetAnnotations(mergeMissing(object.GetAnnotations(), metadata.Annotations))
+		if service, ok := object.(*corev1.Service); ok && metadata.Scrape != nil {
+			service.Annotations = mergeMissing(service.Annotations, metadata.Scrape.annotations(service))
+		}
+
+		var template *metav1.ObjectMeta
+		switch workload := object.(type) {
+		case *appsv1.Deployment:
+			template = &workload.Spec.Template.ObjectMeta
+		case *appsv1.StatefulSet:
+			template = &workload.Spec.Template.ObjectMeta
+		case *appsv1.DaemonSet:
+			template = &workload.Spec.Template.ObjectMeta
+		case *batchv1.Job:
+			template = &workload.Spec.Template.ObjectMeta
+		case *batchv1.CronJob:
+			template = &workload.Spec.JobTemplate.Spec.Template.ObjectMeta
+		}
+		if template != nil {
+			template.Labels = mergeMissing(template.Labels, metadata.Labels)
+			template.Annotations = mergeMissing(template.Annotations, metadata.Annotations)
+		}
+	}
+}
+
+// mergeMissing returns a copy of existing with the keys of extra it does not
+// set. Modules share one labels map between metadata and selectors, so
+// existing is never modified; it is returned as is when nothing is added.
+func mergeMissing(existing, extra map[string]string) map[string]string {
+	var merged map[string]string
+	for key, value := range extra {
+		if _, ok := existing[key]; ok {
+			continue
+		}
+		if merged == nil {
+			merged = make(map[string]string, len(existing)+len(extra))
+			for k, v := range existing {
+				merged[k] = v
+			}
+		}
+		merged[key] = value
+	}
+	if merged == nil {
+		return existing
+	}
+	return merged
+}
+
+// sortedKeys returns the keys of m in order, for stable error messages
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetObjectMetadata(t *testing.T) {
+	labels := map[string]string{"app": "web", "team": "web"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+		},
+	}
+	var missing *corev1.Service
+	SetObjectMetadata(ObjectMetadata{
+		Labels:      map[string]string{"team": "infra", "tier": "backend"},
+		Annotations: map[string]string{"example.com/owner": "ops"},
+	}, deployment, missing)
+
+	want := map[string]string{"app": "web", "team": "web", "tier": "backend", ManagedByLabel: ManagedByValue}
+	for key, value := range want {
+		if got := deployment.Labels[key]; got != value {
+			t.Errorf("label %s = %q, want %q", key, got, value)
+		}
+	}
+	if got := deployment.Spec.Template.Labels["tier"]; got != "backend" {
+		t.Errorf("pod template label tier = %q, want backend", got)
+	}
+	if _, ok := deployment.Spec.Template.Labels[ManagedByLabel]; ok {
+		t.Errorf("pod template labelled %s, want only configured labels", ManagedByLabel)
+	}
+	if got := deployment.Spec.Template.Annotations["example.com/owner"]; got != "ops" {
+		t.Errorf("pod template annotation = %q, want ops", got)
+	}
+	if len(deployment.Spec.Selector.MatchLabels) != 2 {
+		t.Errorf("selector = %v, want it unchanged", deployment.Spec.Selector.MatchLabels)
+	}
+}
+
+func TestValidateObjectMetadata(t *testing.T) {
+	valid := ObjectMetadata{
+		Labels:      map[string]string{"team": "infra", "example.com/tier": ""},
+		Annotations: map[string]string{"example.com/owner": "anything goes: here"},
+	}
+	if err := ValidateObjectMetadata(valid); err != nil {
+		t.Errorf("ValidateObjectMetadata() error = %v", err)
+	}
+	for name, metadata := range map[string]ObjectMetadata{
+		"reserved app":       {Labels: map[string]string{"app": "web"}},
+		"reserved managed":   {Labels: map[string]string{ManagedByLabel: "me"}},
+		"invalid key":        {Labels: map[string]string{"bad key": "x"}},
+		"invalid value":      {Labels: map[string]string{"team": "two words"}},
+		"invalid annotation": {Annotations: map[string]string{"-owner": "ops"}},
+	} {
+		if err := ValidateObjectMetadata(metadata); err == nil {
+			t.Errorf("%s: ValidateObjectMetadata() succeeded, want error", name)
+		}
+	}
+}
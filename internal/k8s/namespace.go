@@ -0,0 +1,171 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ManagedByLabel marks objects the tool created
+	ManagedByLabel = "managed-by"
+	// ManagedByValue is the value of ManagedByLabel
+	ManagedByValue = "personal-server"
+)
+
+// EnsureNamespace creates the namespace, labelled as managed by the tool,
+// unless it exists, and reports whether it created it
+func EnsureNamespace(ctx context.Context, client KubernetesClient, name string) (bool, error) {
+	_, err := client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to check namespace '%s': %w", name, err)
+	}
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{ManagedByLabel: ManagedByValue},
+		},
+	}
+	_, err = client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// Another component applied in parallel created it first
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create namespace '%s': %w", name, err)
+	}
+	return true, nil
+}
+
+// DeleteEmptyNamespace deletes a namespace the tool created once nothing is
+// left in it. Objects owned by others, such as the pods of a deleted
+// Deployment, and objects already being deleted do not count, nor do the
+// ConfigMap and token Secrets Kubernetes adds itself. When the namespace is
+// kept, reason says why.
+func DeleteEmptyNamespace(ctx context.Context, client KubernetesClient, name string) (deleted bool, reason string, err error) {
+	namespace, err := client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, "it does not exist", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get namespace '%s': %w", name, err)
+	}
+	if namespace.Labels[ManagedByLabel] != ManagedByValue {
+		return false, fmt.Sprintf("it is not labelled %s=%s", ManagedByLabel, ManagedByValue), nil
+	}
+
+	remaining, err := namespaceObjects(ctx, client, name)
+	if err != nil {
+		return false, "", err
+	}
+	if len(remaining) > 0 {
+		return false, "it still holds " + strings.Join(remaining, ", "), nil
+	}
+	if err := client.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return false, "", fmt.Errorf("failed to delete namespace '%s': %w", name, err)
+	}
+	return true, "", nil
+}
+
+// namespaceObjects lists the top-level objects left in a namespace as
+// kind/name
+func namespaceObjects(ctx context.Context, client KubernetesClient, namespace string) ([]string, error) {
+	var objects []string
+	add := func(kind string, meta metav1.ObjectMeta) {
+		if meta.DeletionTimestamp == nil && len(meta.OwnerReferences) == 0 {
+			objects = append(objects, kind+"/"+meta.Name)
+		}
+	}
+	list := metav1.ListOptions{}
+
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, o := range deployments.Items {
+		add("deployment", o.ObjectMeta)
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, o := range statefulSets.Items {
+		add("statefulset", o.ObjectMeta)
+	}
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for _, o := range daemonSets.Items {
+		add("daemonset", o.ObjectMeta)
+	}
+	cronJobs, err := client.BatchV1().CronJobs(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	for _, o := range cronJobs.Items {
+		add("cronjob", o.ObjectMeta)
+	}
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	for _, o := range jobs.Items {
+		add("job", o.ObjectMeta)
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, o := range pods.Items {
+		add("pod", o.ObjectMeta)
+	}
+	services, err := client.CoreV1().Services(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, o := range services.Items {
+		add("service", o.ObjectMeta)
+	}
+	claims, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistentvolumeclaims: %w", err)
+	}
+	for _, o := range claims.Items {
+		add("persistentvolumeclaim", o.ObjectMeta)
+	}
+	configMaps, err := client.CoreV1().ConfigMaps(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps: %w", err)
+	}
+	for _, o := range configMaps.Items {
+		// Published into every namespace by the control plane
+		if o.Name != "kube-root-ca.crt" {
+			add("configmap", o.ObjectMeta)
+		}
+	}
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	for _, o := range secrets.Items {
+		if o.Type != corev1.SecretTypeServiceAccountToken {
+			add("secret", o.ObjectMeta)
+		}
+	}
+	ingresses, err := client.NetworkingV1().Ingresses(namespace).List(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	for _, o := range ingresses.Items {
+		add("ingress", o.ObjectMeta)
+	}
+	return objects, nil
+}
@@ -0,0 +1,82 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureNamespace(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "infra"}})
+
+	created, err := EnsureNamespace(ctx, client, "infra")
+	if err != nil || created {
+		t.Fatalf("EnsureNamespace(infra) = %v, %v; want false, nil", created, err)
+	}
+
+	created, err = EnsureNamespace(ctx, client, "hobby")
+	if err != nil || !created {
+		t.Fatalf("EnsureNamespace(hobby) = %v, %v; want true, nil", created, err)
+	}
+	namespace, err := client.CoreV1().Namespaces().Get(ctx, "hobby", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace.Labels[ManagedByLabel] != ManagedByValue {
+		t.Errorf("labels = %v, want %s=%s", namespace.Labels, ManagedByLabel, ManagedByValue)
+	}
+}
+
+func TestDeleteEmptyNamespace(t *testing.T) {
+	ctx := context.Background()
+	managed := map[string]string{ManagedByLabel: ManagedByValue}
+	now := metav1.Now()
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "hobby", Labels: managed}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "infra", Labels: managed}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		// Left behind by the control plane or on their way out
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "hobby"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "default-token", Namespace: "hobby"}, Type: corev1.SecretTypeServiceAccountToken},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "hobby-pod-abc", Namespace: "hobby", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "hobby-pod-5d"}}}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "hobby-pod", Namespace: "hobby", DeletionTimestamp: &now, Finalizers: []string{"test"}}},
+		// Another module's data
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra"}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "gitea-data", Namespace: "infra"}},
+	)
+
+	tests := []struct {
+		namespace   string
+		wantDeleted bool
+		wantReason  string
+	}{
+		{namespace: "hobby", wantDeleted: true},
+		{namespace: "infra", wantReason: "it still holds deployment/gitea, persistentvolumeclaim/gitea-data"},
+		{namespace: "default", wantReason: "it is not labelled managed-by=personal-server"},
+		{namespace: "missing", wantReason: "it does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			deleted, reason, err := DeleteEmptyNamespace(ctx, client, tt.namespace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != tt.wantDeleted || !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("DeleteEmptyNamespace() = %v, %q; want %v, %q", deleted, reason, tt.wantDeleted, tt.wantReason)
+			}
+		})
+	}
+
+	if _, err := client.CoreV1().Namespaces().Get(ctx, "hobby", metav1.GetOptions{}); err == nil {
+		t.Error("namespace hobby should have been deleted")
+	}
+	if _, err := client.CoreV1().Namespaces().Get(ctx, "infra", metav1.GetOptions{}); err != nil {
+		t.Errorf("namespace infra should be kept: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package k8s
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ValidateIPFamilyPolicy checks a Service IP family policy from the config;
+// empty is valid and keeps the cluster default
+func ValidateIPFamilyPolicy(policy string) error {
+	switch corev1.IPFamilyPolicy(policy) {
+	case "", corev1.IPFamilyPolicySingleStack, corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack:
+		return nil
+	}
+	return fmt.Errorf("unknown IP family policy %q: must be SingleStack, PreferDualStack or RequireDualStack", policy)
+}
+
+// SetIPFamilyPolicy sets the IP family policy of service. PreferDualStack
+// gives it an IPv6 cluster IP next to the IPv4 one on dual-stack clusters
+// and is ignored on single-stack ones; RequireDualStack fails there instead.
+// An empty policy leaves the cluster default, a single IPv4 or IPv6 address.
+func SetIPFamilyPolicy(service *corev1.Service, policy string) {
+	if policy == "" {
+		return
+	}
+	p := corev1.IPFamilyPolicy(policy)
+	service.Spec.IPFamilyPolicy = &p
+}
@@ -0,0 +1,30 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetIPFamilyPolicy(t *testing.T) {
+	service := &corev1.Service{}
+	SetIPFamilyPolicy(service, "")
+	if service.Spec.IPFamilyPolicy != nil {
+		t.Errorf("IPFamilyPolicy = %v without a policy, want nil", *service.Spec.IPFamilyPolicy)
+	}
+	SetIPFamilyPolicy(service, "PreferDualStack")
+	if service.Spec.IPFamilyPolicy == nil || *service.Spec.IPFamilyPolicy != corev1.IPFamilyPolicyPreferDualStack {
+		t.Errorf("IPFamilyPolicy = %v, want PreferDualStack", service.Spec.IPFamilyPolicy)
+	}
+}
+
+func TestValidateIPFamilyPolicy(t *testing.T) {
+	for _, policy := range []string{"", "SingleStack", "PreferDualStack", "RequireDualStack"} {
+		if err := ValidateIPFamilyPolicy(policy); err != nil {
+			t.Errorf("ValidateIPFamilyPolicy(%q) error = %v", policy, err)
+		}
+	}
+	if err := ValidateIPFamilyPolicy("DualStack"); err == nil {
+		t.Error("ValidateIPFamilyPolicy(DualStack) succeeded, want error")
+	}
+}
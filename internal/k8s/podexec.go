@@ -0,0 +1,91 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// PodExecutor runs commands through the API server's exec endpoint with
+// client-go, streaming stdin and output over SPDY. The command is sent as a
+// list of arguments, so nothing is parsed by a local shell and no kubectl
+// binary is needed. Clients.Executor returns one for the cluster of a run.
+type PodExecutor struct {
+	clients *Clients
+}
+
+// Exec runs req in its pod and streams req.Stdin to the command and its
+// output to req.Stdout and req.Stderr until it exits or ctx is cancelled. A
+// command exiting non-zero returns an error with its exit code.
+func (e PodExecutor) Exec(ctx context.Context, req ExecRequest) error {
+	return e.clients.podExec(ctx, req, false, nil)
+}
+
+func (c *Clients) podExec(ctx context.Context, req ExecRequest, tty bool, resize remotecommand.TerminalSizeQueue) error {
+	config, err := c.restConfig()
+	if err != nil {
+		return err
+	}
+	// Streams such as backups last as long as they need to
+	config.Timeout = 0
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	request := client.CoreV1().RESTClient().Post().
+		Namespace(req.Namespace).
+		Resource("pods").
+		Name(req.Pod).
+		SubResource("exec").
+		VersionedParams(podExecOptions(req, tty), scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s/%s: %w", req.Namespace, req.Pod, err)
+	}
+	options := remotecommand.StreamOptions{
+		Stdin:             req.Stdin,
+		Stdout:            req.Stdout,
+		Stderr:            req.Stderr,
+		Tty:               tty,
+		TerminalSizeQueue: resize,
+	}
+	// A terminal merges stderr into stdout
+	if tty {
+		options.Stderr = nil
+	}
+	return executor.StreamWithContext(ctx, options)
+}
+
+// podExecOptions requests the streams req provides
+func podExecOptions(req ExecRequest, tty bool) *corev1.PodExecOptions {
+	return &corev1.PodExecOptions{
+		Container: req.Container,
+		Command:   req.Command,
+		Stdin:     req.Stdin != nil,
+		Stdout:    req.Stdout != nil,
+		Stderr:    req.Stderr != nil && !tty,
+		TTY:       tty,
+	}
+}
+
+// RunningPod returns the name of a running pod matching selector, the pod
+// `kubectl exec deployment/<name>` would pick
+func RunningPod(ctx context.Context, client KubernetesClient, namespace, selector string) (string, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			return pod.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no running pod found for %s", selector)
+}
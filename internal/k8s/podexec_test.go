@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodExecOptions(t *testing.T) {
+	var out bytes.Buffer
+	tests := []struct {
+		name string
+		req  ExecRequest
+		tty  bool
+		want corev1.PodExecOptions
+	}{
+		{
+			name: "output only",
+			req:  ExecRequest{Namespace: "infra", Pod: "postgres-0", Command: []string{"pg_isready"}, Stdout: &out, Stderr: &out},
+			want: corev1.PodExecOptions{Command: []string{"pg_isready"}, Stdout: true, Stderr: true},
+		},
+		{
+			name: "stdin and container",
+			req:  ExecRequest{Namespace: "hobby", Pod: "synapse-0", Container: "synapse", Command: []string{"tar", "xzf", "-"}, Stdin: strings.NewReader("")},
+			want: corev1.PodExecOptions{Container: "synapse", Command: []string{"tar", "xzf", "-"}, Stdin: true},
+		},
+		{
+			name: "terminal merges stderr",
+			req:  ExecRequest{Pod: "gitea-0", Command: []string{"sh"}, Stdin: strings.NewReader(""), Stdout: &out, Stderr: &out},
+			tty:  true,
+			want: corev1.PodExecOptions{Command: []string{"sh"}, Stdin: true, Stdout: true, TTY: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podExecOptions(tt.req, tt.tty); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("podExecOptions() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunningPod(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "hobby", Labels: map[string]string{"app": "hobby-pod"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	client := fake.NewSimpleClientset(pod("hobby-pod-old", corev1.PodPending), pod("hobby-pod-new", corev1.PodRunning))
+
+	name, err := RunningPod(context.Background(), client, "hobby", "app=hobby-pod")
+	if err != nil {
+		t.Fatalf("RunningPod() error = %v", err)
+	}
+	if name != "hobby-pod-new" {
+		t.Errorf("RunningPod() = %q, want hobby-pod-new", name)
+	}
+
+	if _, err := RunningPod(context.Background(), client, "hobby", "app=work-pod"); err == nil || !strings.Contains(err.Error(), "no running pod found for app=work-pod") {
+		t.Errorf("RunningPod() error = %v, want no running pod", err)
+	}
+}
@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ProbeSettings documents the modules[].secrets keys that override the probes
+// of a module's main container. Embed it with yaml:",inline" in a module's
+// settings so config explain lists the keys. Unset keys keep the module's
+// defaults.
+type ProbeSettings struct {
+	LivenessPath              string `yaml:"liveness_path" doc:"HTTP path of the liveness probe"`
+	LivenessPort              string `yaml:"liveness_port" doc:"Port number or name of the liveness probe"`
+	LivenessInitialDelay      string `yaml:"liveness_initial_delay" doc:"Seconds before the first liveness probe"`
+	LivenessPeriod            string `yaml:"liveness_period" doc:"Seconds between liveness probes"`
+	LivenessTimeout           string `yaml:"liveness_timeout" doc:"Seconds before a liveness probe times out"`
+	LivenessFailureThreshold  string `yaml:"liveness_failure_threshold" doc:"Failed liveness probes before the container is restarted"`
+	ReadinessPath             string `yaml:"readiness_path" doc:"HTTP path of the readiness probe"`
+	ReadinessPort             string `yaml:"readiness_port" doc:"Port number or name of the readiness probe"`
+	ReadinessInitialDelay     string `yaml:"readiness_initial_delay" doc:"Seconds before the first readiness probe"`
+	ReadinessPeriod           string `yaml:"readiness_period" doc:"Seconds between readiness probes"`
+	ReadinessTimeout          string `yaml:"readiness_timeout" doc:"Seconds before a readiness probe times out"`
+	ReadinessFailureThreshold string `yaml:"readiness_failure_threshold" doc:"Failed readiness probes before the pod stops receiving traffic"`
+	StartupInitialDelay       string `yaml:"startup_initial_delay" doc:"Seconds before the first startup probe"`
+	StartupPeriod             string `yaml:"startup_period" doc:"Seconds between startup probes"`
+	StartupTimeout            string `yaml:"startup_timeout" doc:"Seconds before a startup probe times out"`
+	StartupFailureThreshold   string `yaml:"startup_failure_threshold" doc:"Failed startup probes before the container is restarted; period times threshold is the time allowed to start"`
+}
+
+// Startup probe defaults: SetStartupProbe allows 5 minutes unless told
+// otherwise
+const (
+	defaultStartupPeriod           = 10
+	DefaultStartupFailureThreshold = 30
+)
+
+// SetStartupProbe gives the container a startup probe checking what its
+// liveness probe checks, allowing failureThreshold periods of 10 seconds to
+// start. Liveness and readiness probes only run once it succeeds, so slow
+// first starts, migrations or crash recovery are not mistaken for a hung
+// container.
+func SetStartupProbe(container *corev1.Container, failureThreshold int32) {
+	container.StartupProbe = &corev1.Probe{
+		PeriodSeconds:    defaultStartupPeriod,
+		FailureThreshold: failureThreshold,
+	}
+	if container.LivenessProbe != nil {
+		container.StartupProbe.ProbeHandler = *container.LivenessProbe.ProbeHandler.DeepCopy()
+	}
+}
+
+// ApplyProbeOverrides applies the liveness_*, readiness_* and startup_* keys
+// of secrets to the container's probes. Paths only apply to HTTP probes and
+// ports to HTTP and TCP probes; setting a key the probe cannot use is an
+// error rather than being ignored. The startup probe follows the liveness
+// probe's handler; a startup_* key adds one to a container that has only a
+// liveness probe.
+func ApplyProbeOverrides(container *corev1.Container, secrets map[string]string) error {
+	probes := []struct {
+		prefix string
+		probe  *corev1.Probe
+	}{
+		{"liveness", container.LivenessProbe},
+		{"readiness", container.ReadinessProbe},
+	}
+
+	for _, p := range probes {
+		key := func(name string) string { return p.prefix + "_" + name }
+		if p.probe == nil {
+			for _, name := range append([]string{"path", "port"}, probeTimingKeys...) {
+				if _, ok := secrets[key(name)]; ok {
+					return fmt.Errorf("%s is set but container %s has no %s probe", key(name), container.Name, p.prefix)
+				}
+			}
+			continue
+		}
+
+		if path, ok := secrets[key("path")]; ok {
+			if p.probe.HTTPGet == nil {
+				return fmt.Errorf("%s is set but the %s probe of container %s is not an HTTP probe", key("path"), p.prefix, container.Name)
+			}
+			p.probe.HTTPGet.Path = path
+		}
+		if value, ok := secrets[key("port")]; ok {
+			port := intstr.Parse(value)
+			if port.Type == intstr.Int && (port.IntVal < 1 || port.IntVal > 65535) {
+				return fmt.Errorf("invalid %s %q: must be between 1 and 65535", key("port"), value)
+			}
+			switch {
+			case p.probe.HTTPGet != nil:
+				p.probe.HTTPGet.Port = port
+			case p.probe.TCPSocket != nil:
+				p.probe.TCPSocket.Port = port
+			default:
+				return fmt.Errorf("%s is set but the %s probe of container %s has no port", key("port"), p.prefix, container.Name)
+			}
+		}
+
+		if err := applyProbeTimings(p.probe, p.prefix, secrets); err != nil {
+			return err
+		}
+	}
+
+	startupKeys := false
+	for _, name := range probeTimingKeys {
+		if _, ok := secrets["startup_"+name]; ok {
+			startupKeys = true
+		}
+	}
+	if container.StartupProbe == nil && !startupKeys {
+		return nil
+	}
+	if container.LivenessProbe == nil {
+		return fmt.Errorf("startup probe of container %s needs a liveness probe to follow", container.Name)
+	}
+	if container.StartupProbe == nil {
+		SetStartupProbe(container, DefaultStartupFailureThreshold)
+	}
+	container.StartupProbe.ProbeHandler = *container.LivenessProbe.ProbeHandler.DeepCopy()
+	return applyProbeTimings(container.StartupProbe, "startup", secrets)
+}
+
+// probeTimingKeys are the key suffixes of a probe's timings
+var probeTimingKeys = []string{"initial_delay", "period", "timeout", "failure_threshold"}
+
+// applyProbeTimings applies the <prefix>_* timing keys of secrets to probe
+func applyProbeTimings(probe *corev1.Probe, prefix string, secrets map[string]string) error {
+	fields := []struct {
+		min   int32
+		field *int32
+	}{
+		{0, &probe.InitialDelaySeconds},
+		{1, &probe.PeriodSeconds},
+		{1, &probe.TimeoutSeconds},
+		{1, &probe.FailureThreshold},
+	}
+	for i, f := range fields {
+		key := prefix + "_" + probeTimingKeys[i]
+		value, ok := secrets[key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || int32(n) < f.min {
+			return fmt.Errorf("invalid %s %q: must be a whole number of at least %d", key, value, f.min)
+		}
+		*f.field = int32(n)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newProbeContainer() *corev1.Container {
+	return &corev1.Container{
+		Name: "app",
+		LivenessProbe: &corev1.Probe{
+			ProbeHandler:        corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: intstr.FromInt(80)}},
+			InitialDelaySeconds: 30,
+			PeriodSeconds:       10,
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler:  corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"true"}}},
+			PeriodSeconds: 5,
+		},
+	}
+}
+
+func TestApplyProbeOverrides(t *testing.T) {
+	container := newProbeContainer()
+	err := ApplyProbeOverrides(container, map[string]string{
+		"liveness_path":              "/healthz",
+		"liveness_port":              "http",
+		"liveness_initial_delay":     "0",
+		"liveness_failure_threshold": "6",
+		"readiness_period":           "20",
+		"readiness_timeout":          "4",
+		"image":                      "example/app:1.0",
+	})
+	if err != nil {
+		t.Fatalf("ApplyProbeOverrides() error = %v", err)
+	}
+
+	liveness := container.LivenessProbe
+	if liveness.HTTPGet.Path != "/healthz" || liveness.HTTPGet.Port != intstr.FromString("http") {
+		t.Errorf("liveness HTTPGet = %+v, want /healthz on port http", liveness.HTTPGet)
+	}
+	if liveness.InitialDelaySeconds != 0 || liveness.PeriodSeconds != 10 || liveness.FailureThreshold != 6 {
+		t.Errorf("liveness timings = %d/%d/%d, want 0/10/6", liveness.InitialDelaySeconds, liveness.PeriodSeconds, liveness.FailureThreshold)
+	}
+	if readiness := container.ReadinessProbe; readiness.PeriodSeconds != 20 || readiness.TimeoutSeconds != 4 {
+		t.Errorf("readiness period/timeout = %d/%d, want 20/4", readiness.PeriodSeconds, readiness.TimeoutSeconds)
+	}
+}
+
+func TestApplyProbeOverridesStartup(t *testing.T) {
+	container := newProbeContainer()
+	SetStartupProbe(container, 60)
+	err := ApplyProbeOverrides(container, map[string]string{
+		"liveness_path":   "/healthz",
+		"startup_period":  "5",
+		"startup_timeout": "3",
+	})
+	if err != nil {
+		t.Fatalf("ApplyProbeOverrides() error = %v", err)
+	}
+
+	startup := container.StartupProbe
+	if startup.HTTPGet == nil || startup.HTTPGet.Path != "/healthz" {
+		t.Errorf("startup HTTPGet = %+v, want the liveness handler on /healthz", startup.HTTPGet)
+	}
+	if startup.HTTPGet == container.LivenessProbe.HTTPGet {
+		t.Error("startup probe shares the liveness HTTPGet, want a copy")
+	}
+	if startup.PeriodSeconds != 5 || startup.TimeoutSeconds != 3 || startup.FailureThreshold != 60 {
+		t.Errorf("startup period/timeout/threshold = %d/%d/%d, want 5/3/60", startup.PeriodSeconds, startup.TimeoutSeconds, startup.FailureThreshold)
+	}
+
+	// A startup_* key adds a startup probe to a container without one
+	container = newProbeContainer()
+	if err := ApplyProbeOverrides(container, map[string]string{"startup_failure_threshold": "90"}); err != nil {
+		t.Fatalf("ApplyProbeOverrides() error = %v", err)
+	}
+	if startup := container.StartupProbe; startup == nil || startup.HTTPGet == nil || startup.PeriodSeconds != 10 || startup.FailureThreshold != 90 {
+		t.Errorf("StartupProbe = %+v, want an HTTP probe every 10s with threshold 90", startup)
+	}
+
+	container = newProbeContainer()
+	if err := ApplyProbeOverrides(container, nil); err != nil || container.StartupProbe != nil {
+		t.Errorf("ApplyProbeOverrides() without startup keys = %+v, %v, want no startup probe", container.StartupProbe, err)
+	}
+}
+
+func TestApplyProbeOverridesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]string
+	}{
+		{name: "path on exec probe", secrets: map[string]string{"readiness_path": "/"}},
+		{name: "port on exec probe", secrets: map[string]string{"readiness_port": "80"}},
+		{name: "port out of range", secrets: map[string]string{"liveness_port": "70000"}},
+		{name: "negative delay", secrets: map[string]string{"liveness_initial_delay": "-1"}},
+		{name: "zero period", secrets: map[string]string{"liveness_period": "0"}},
+		{name: "not a number", secrets: map[string]string{"readiness_timeout": "5s"}},
+		{name: "zero startup threshold", secrets: map[string]string{"startup_failure_threshold": "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ApplyProbeOverrides(newProbeContainer(), tt.secrets); err == nil {
+				t.Error("ApplyProbeOverrides() succeeded, want error")
+			}
+		})
+	}
+
+	container := &corev1.Container{Name: "worker"}
+	if err := ApplyProbeOverrides(container, map[string]string{"liveness_period": "5"}); err == nil {
+		t.Error("ApplyProbeOverrides() on a container without probes succeeded, want error")
+	}
+	if err := ApplyProbeOverrides(container, map[string]string{"startup_period": "5"}); err == nil {
+		t.Error("ApplyProbeOverrides() startup key without a liveness probe succeeded, want error")
+	}
+	if err := ApplyProbeOverrides(container, nil); err != nil {
+		t.Errorf("ApplyProbeOverrides() without overrides error = %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// ReconcileResult describes what ReconcileObject changed
+type ReconcileResult struct {
+	// Created is set when the object was missing and has been created
+	Created bool
+	// Drifted lists the fields that differed from the desired object and
+	// were patched back
+	Drifted []string
+	// ResourceVersion is the object's version after reconciling
+	ResourceVersion string
+}
+
+// ReconcileObject creates desired when it does not exist and merge-patches
+// the fields that drifted from it otherwise. Fields the desired object leaves
+// unset, such as a Service's cluster IP or a PVC's volume, are not touched.
+// When the live object is at ignoreVersion, typically the version written by
+// the previous call, it is left alone: the API server may normalise a value,
+// such as a quantity, and patching it again would loop.
+func ReconcileObject(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, desired *unstructured.Unstructured, ignoreVersion string) (ReconcileResult, error) {
+	resource, err := resourceFor(dyn, mapper, desired)
+	if err != nil {
+		return ReconcileResult{}, err
+	}
+	live, err := resource.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		created, err := resource.Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return ReconcileResult{}, fmt.Errorf("failed to create %s %s: %w", desired.GetKind(), desired.GetName(), err)
+		}
+		return ReconcileResult{Created: true, ResourceVersion: created.GetResourceVersion()}, nil
+	}
+	if err != nil {
+		return ReconcileResult{}, fmt.Errorf("failed to get %s %s: %w", desired.GetKind(), desired.GetName(), err)
+	}
+	if live.GetDeletionTimestamp() != nil || (ignoreVersion != "" && live.GetResourceVersion() == ignoreVersion) {
+		return ReconcileResult{ResourceVersion: live.GetResourceVersion()}, nil
+	}
+
+	drifted := Drift(desired, live)
+	if len(drifted) == 0 {
+		return ReconcileResult{ResourceVersion: live.GetResourceVersion()}, nil
+	}
+	patch, err := json.Marshal(ownedFields(desired))
+	if err != nil {
+		return ReconcileResult{}, fmt.Errorf("failed to encode %s %s: %w", desired.GetKind(), desired.GetName(), err)
+	}
+	patched, err := resource.Patch(ctx, desired.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return ReconcileResult{}, fmt.Errorf("failed to patch %s %s: %w", desired.GetKind(), desired.GetName(), err)
+	}
+	return ReconcileResult{Drifted: drifted, ResourceVersion: patched.GetResourceVersion()}, nil
+}
+
+// Drift returns the paths of the fields set in desired whose value differs
+// in live, e.g. "spec.replicas". Only labels, annotations and owner
+// references are compared in metadata, and status is ignored. Fields the API
+// server defaults are not drift, and neither is a zero value that the server
+// omits.
+func Drift(desired, live *unstructured.Unstructured) []string {
+	var drifted []string
+	for _, field := range Diff(desired, live) {
+		drifted = append(drifted, field.Path)
+	}
+	return drifted
+}
+
+// FieldDiff is a field whose live value differs from the desired one. A nil
+// value means the field is unset.
+type FieldDiff struct {
+	Path    string
+	Desired interface{}
+	Live    interface{}
+}
+
+// Diff returns the fields Drift reports together with their desired and live
+// values
+func Diff(desired, live *unstructured.Unstructured) []FieldDiff {
+	var fields []FieldDiff
+	diffFields("", ownedFields(desired), live.Object, &fields)
+	return fields
+}
+
+// ownedFields returns the parts of object a reconcile owns: everything but
+// status, and only labels, annotations and owner references of metadata
+func ownedFields(object *unstructured.Unstructured) map[string]interface{} {
+	owned := map[string]interface{}{}
+	for key, value := range object.Object {
+		switch key {
+		case "status":
+		case "metadata":
+			metadata := map[string]interface{}{}
+			if labels := object.GetLabels(); len(labels) > 0 {
+				metadata["labels"] = labels
+			}
+			if annotations := object.GetAnnotations(); len(annotations) > 0 {
+				metadata["annotations"] = annotations
+			}
+			if refs, ok := value.(map[string]interface{})["ownerReferences"]; ok {
+				metadata["ownerReferences"] = refs
+			}
+			owned[key] = metadata
+		default:
+			owned[key] = value
+		}
+	}
+	return owned
+}
+
+func diffFields(path string, desired, live interface{}, drifted *[]FieldDiff) {
+	if isZeroValue(desired) && isZeroValue(live) {
+		return
+	}
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, _ := live.(map[string]interface{})
+		keys := make([]string, 0, len(d))
+		for key := range d {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			diffFields(child, d[key], l[key], drifted)
+		}
+	case map[string]string:
+		converted := make(map[string]interface{}, len(d))
+		for key, value := range d {
+			converted[key] = value
+		}
+		diffFields(path, converted, live, drifted)
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			*drifted = append(*drifted, FieldDiff{Path: path, Desired: desired, Live: live})
+			return
+		}
+		for i := range d {
+			diffFields(fmt.Sprintf("%s[%d]", path, i), d[i], l[i], drifted)
+		}
+	default:
+		if !equalScalars(desired, live) {
+			*drifted = append(*drifted, FieldDiff{Path: path, Desired: desired, Live: live})
+		}
+	}
+}
+
+// equalScalars compares JSON scalars, treating numbers of any type and
+// equivalent quantities such as "0.5" and "500m" as equal
+func equalScalars(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	sa, ok1 := a.(string)
+	sb, ok2 := b.(string)
+	if ok1 && ok2 {
+		if sa == sb {
+			return true
+		}
+		qa, err1 := resource.ParseQuantity(sa)
+		qb, err2 := resource.ParseQuantity(sb)
+		return err1 == nil && err2 == nil && qa.Cmp(qb) == 0
+	}
+	return a == b
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func isZeroValue(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case bool:
+		return !x
+	case map[string]interface{}:
+		return len(x) == 0
+	case map[string]string:
+		return len(x) == 0
+	case []interface{}:
+		return len(x) == 0
+	}
+	if n, ok := toFloat(v); ok {
+		return n == 0
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const desiredDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  namespace: example
+  creationTimestamp: null
+  labels:
+    app: example
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: example
+          image: example:1.0
+          resources:
+            limits:
+              cpu: "0.5"
+status: {}
+`
+
+func decodeOne(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+	objects, err := DecodeManifest([]byte(manifest))
+	if err != nil || len(objects) != 1 {
+		t.Fatalf("DecodeManifest() = %v, %v", objects, err)
+	}
+	return objects[0]
+}
+
+func TestDrift(t *testing.T) {
+	desired := decodeOne(t, desiredDeployment)
+
+	live := desired.DeepCopy()
+	live.SetResourceVersion("7")
+	live.SetUID("uid")
+	live.SetLabels(map[string]string{"app": "example", "extra": "added"})
+	unstructured.SetNestedField(live.Object, int64(3), "status", "replicas")
+	unstructured.SetNestedField(live.Object, "RollingUpdate", "spec", "strategy", "type")
+	containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+	containers[0].(map[string]interface{})["imagePullPolicy"] = "IfNotPresent"
+	containers[0].(map[string]interface{})["resources"] = map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m"}}
+	unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers")
+	if drifted := Drift(desired, live); len(drifted) != 0 {
+		t.Errorf("Drift() of a defaulted copy = %v, want none", drifted)
+	}
+
+	unstructured.SetNestedField(live.Object, int64(0), "spec", "replicas")
+	containers[0].(map[string]interface{})["image"] = "example:2.0"
+	unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers")
+	live.SetLabels(map[string]string{"app": "other"})
+	want := []string{"metadata.labels.app", "spec.replicas", "spec.template.spec.containers[0].image"}
+	if drifted := Drift(desired, live); !reflect.DeepEqual(drifted, want) {
+		t.Errorf("Drift() = %v, want %v", drifted, want)
+	}
+}
+
+func TestReconcileObject(t *testing.T) {
+	ctx := context.Background()
+	dyn, mapper := newManifestTestClients()
+	desired := decodeOne(t, desiredDeployment)
+
+	result, err := ReconcileObject(ctx, dyn, mapper, desired, "")
+	if err != nil || !result.Created {
+		t.Fatalf("ReconcileObject() of a missing object = %+v, %v, want created", result, err)
+	}
+
+	deployments := dyn.Resource(deploymentGVR).Namespace("example")
+	live, err := deployments.Get(ctx, "example", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Deployment not created: %v", err)
+	}
+	unstructured.SetNestedField(live.Object, int64(0), "spec", "replicas")
+	unstructured.SetNestedField(live.Object, "manual", "spec", "paused")
+	if _, err := deployments.Update(ctx, live, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = ReconcileObject(ctx, dyn, mapper, desired, "")
+	if err != nil || result.Created || !reflect.DeepEqual(result.Drifted, []string{"spec.replicas"}) {
+		t.Fatalf("ReconcileObject() of a scaled object = %+v, %v, want spec.replicas drifted", result, err)
+	}
+	live, _ = deployments.Get(ctx, "example", metav1.GetOptions{})
+	if replicas, _, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); replicas != 1 {
+		t.Errorf("replicas = %d after reconcile, want 1", replicas)
+	}
+	if paused, _, _ := unstructured.NestedString(live.Object, "spec", "paused"); paused != "manual" {
+		t.Errorf("field outside the desired object was changed to %q", paused)
+	}
+
+	if result, err := ReconcileObject(ctx, dyn, mapper, desired, ""); err != nil || result.Created || len(result.Drifted) != 0 {
+		t.Errorf("ReconcileObject() of an up-to-date object = %+v, %v, want no change", result, err)
+	}
+}
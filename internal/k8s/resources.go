@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourceSettings documents the modules[].secrets keys that override the CPU
+// and memory requests and limits of a module's main container. Embed it with
+// yaml:",inline" in a module's settings next to ShutdownSettings. Unset keys
+// keep the module's defaults.
+type ResourceSettings struct {
+	CPURequest    string `yaml:"cpu_request" doc:"CPU reserved for the main container, e.g. 100m; empty removes the default"`
+	CPULimit      string `yaml:"cpu_limit" doc:"CPU the main container is throttled to, e.g. 500m or 2 (default: no limit)"`
+	MemoryRequest string `yaml:"memory_request" doc:"Memory reserved for the main container, e.g. 256Mi; empty removes the default"`
+	MemoryLimit   string `yaml:"memory_limit" doc:"Memory the main container is killed above, e.g. 1Gi; empty removes the default"`
+}
+
+// Resources are CPU and memory requests and limits as quantities such as
+// 100m or 256Mi. Empty fields are left unset.
+type Resources struct {
+	CPURequest    string
+	CPULimit      string
+	MemoryRequest string
+	MemoryLimit   string
+}
+
+// resourceKeys maps the ResourceSettings keys to what they set, in the order
+// of the Resources fields
+var resourceKeys = []struct {
+	key   string
+	name  corev1.ResourceName
+	limit bool
+}{
+	{"cpu_request", corev1.ResourceCPU, false},
+	{"cpu_limit", corev1.ResourceCPU, true},
+	{"memory_request", corev1.ResourceMemory, false},
+	{"memory_limit", corev1.ResourceMemory, true},
+}
+
+// SetResources sets the container's CPU and memory requests and limits. The
+// module defaults leave CPU unlimited, since a CPU limit throttles a container
+// even on an idle node, while a memory limit keeps one module from starving
+// the others on a small server.
+func SetResources(container *corev1.Container, r Resources) {
+	values := []string{r.CPURequest, r.CPULimit, r.MemoryRequest, r.MemoryLimit}
+	for i, k := range resourceKeys {
+		if values[i] == "" {
+			setResource(&container.Resources, k.name, k.limit, resource.Quantity{}, false)
+			continue
+		}
+		setResource(&container.Resources, k.name, k.limit, resource.MustParse(values[i]), true)
+	}
+}
+
+// ApplyResourceOverrides applies the cpu_request, cpu_limit, memory_request
+// and memory_limit keys of secrets to the container. An empty value removes
+// the module's default. A request above its limit is an error, since the API
+// server would reject the pod.
+func ApplyResourceOverrides(container *corev1.Container, secrets map[string]string) error {
+	for _, k := range resourceKeys {
+		value, ok := secrets[k.key]
+		if !ok {
+			continue
+		}
+		quantity := resource.Quantity{}
+		if value != "" {
+			q, err := resource.ParseQuantity(value)
+			if err != nil || q.Sign() <= 0 {
+				return fmt.Errorf("invalid %s %q: must be a positive quantity such as 500m or 512Mi", k.key, value)
+			}
+			quantity = q
+		}
+		setResource(&container.Resources, k.name, k.limit, quantity, value != "")
+	}
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, hasRequest := container.Resources.Requests[name]
+		limit, hasLimit := container.Resources.Limits[name]
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s request %s is above its limit %s", name, request.String(), limit.String())
+		}
+	}
+	return nil
+}
+
+// setResource sets or, when set is false, removes one request or limit
+func setResource(requirements *corev1.ResourceRequirements, name corev1.ResourceName, limit bool, quantity resource.Quantity, set bool) {
+	list := &requirements.Requests
+	if limit {
+		list = &requirements.Limits
+	}
+	if !set {
+		delete(*list, name)
+		if len(*list) == 0 {
+			*list = nil
+		}
+		return
+	}
+	if *list == nil {
+		*list = corev1.ResourceList{}
+	}
+	(*list)[name] = quantity
+}
@@ -0,0 +1,67 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetResources(t *testing.T) {
+	container := &corev1.Container{}
+	SetResources(container, Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "1Gi"})
+
+	if got := container.Resources.Requests.Cpu().String(); got != "100m" {
+		t.Errorf("cpu request = %s, want 100m", got)
+	}
+	if got := container.Resources.Requests.Memory().String(); got != "256Mi" {
+		t.Errorf("memory request = %s, want 256Mi", got)
+	}
+	if got := container.Resources.Limits.Memory().String(); got != "1Gi" {
+		t.Errorf("memory limit = %s, want 1Gi", got)
+	}
+	if _, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+		t.Errorf("cpu limit = %v, want none", container.Resources.Limits)
+	}
+}
+
+func TestApplyResourceOverrides(t *testing.T) {
+	container := &corev1.Container{}
+	SetResources(container, Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "1Gi"})
+
+	err := ApplyResourceOverrides(container, map[string]string{"cpu_limit": "2", "memory_limit": "512Mi", "cpu_request": ""})
+	if err != nil {
+		t.Fatalf("ApplyResourceOverrides() error = %v", err)
+	}
+	if got := container.Resources.Limits.Cpu().String(); got != "2" {
+		t.Errorf("cpu limit = %s, want 2", got)
+	}
+	if got := container.Resources.Limits.Memory().String(); got != "512Mi" {
+		t.Errorf("memory limit = %s, want 512Mi", got)
+	}
+	if _, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+		t.Errorf("requests = %v, want the cpu request removed", container.Resources.Requests)
+	}
+
+	if err := ApplyResourceOverrides(container, map[string]string{"memory_request": "", "memory_limit": ""}); err != nil {
+		t.Fatalf("ApplyResourceOverrides() error = %v", err)
+	}
+	if container.Resources.Requests != nil {
+		t.Errorf("requests = %v, want nil", container.Resources.Requests)
+	}
+
+	tests := []struct {
+		secrets map[string]string
+		wantErr string
+	}{
+		{map[string]string{"memory_limit": "1GB-ish"}, "invalid memory_limit"},
+		{map[string]string{"cpu_request": "-1"}, "invalid cpu_request"},
+		{map[string]string{"memory_request": "2Gi", "memory_limit": "1Gi"}, "memory request 2Gi is above its limit 1Gi"},
+	}
+	for _, tt := range tests {
+		err := ApplyResourceOverrides(&corev1.Container{}, tt.secrets)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ApplyResourceOverrides(%v) error = %v, want %q", tt.secrets, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RestartedAtAnnotation is the pod template annotation `kubectl rollout
+// restart` sets; changing it rolls out new pods
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// RestartDeployment starts a rolling restart of a Deployment like `kubectl
+// rollout restart`, by patching RestartedAtAnnotation to at. Only the
+// annotation is sent, so concurrent changes to the Deployment are kept.
+func RestartDeployment(ctx context.Context, client KubernetesClient, namespace, name string, at time.Time) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{RestartedAtAnnotation: at.Format(time.RFC3339)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("deployment '%s' not found in namespace '%s'", name, namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restart deployment '%s': %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestartDeployment(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra"}}
+	deployment.Spec.Template.Annotations = map[string]string{"checksum/config": "abc"}
+	client := fake.NewSimpleClientset(deployment)
+	at := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	if err := RestartDeployment(context.Background(), client, "infra", "gitea", at); err != nil {
+		t.Fatal(err)
+	}
+	live, err := client.AppsV1().Deployments("infra").Get(context.Background(), "gitea", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := live.Spec.Template.Annotations[RestartedAtAnnotation]; got != "2024-05-10T12:00:00Z" {
+		t.Errorf("restartedAt = %q, want 2024-05-10T12:00:00Z", got)
+	}
+	if live.Spec.Template.Annotations["checksum/config"] != "abc" {
+		t.Errorf("other annotations were not kept: %v", live.Spec.Template.Annotations)
+	}
+
+	if err := RestartDeployment(context.Background(), client, "infra", "redis", at); err == nil {
+		t.Error("expected an error for a missing Deployment")
+	}
+}
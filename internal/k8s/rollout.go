@@ -0,0 +1,117 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RevisionAnnotation is the annotation the Deployment controller stamps on
+	// a Deployment and its ReplicaSets with their rollout revision
+	RevisionAnnotation = "deployment.kubernetes.io/revision"
+	// ChangeCauseAnnotation records why a revision was rolled out
+	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+// DeploymentRevision is one entry of a Deployment's rollout history.
+type DeploymentRevision struct {
+	Revision    int64
+	ChangeCause string
+	Images      []string
+	replicaSet  *appsv1.ReplicaSet
+}
+
+// DeploymentHistory returns the rollout history of a Deployment like `kubectl
+// rollout history`, built from the ReplicaSets it owns and ordered from the
+// oldest to the newest revision.
+func DeploymentHistory(ctx context.Context, client KubernetesClient, namespace, name string) ([]DeploymentRevision, error) {
+	deployment, err := getDeployment(ctx, client, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return deploymentHistory(ctx, client, deployment)
+}
+
+// UndoDeployment rolls a Deployment back to the revision before its current
+// one like `kubectl rollout undo`, by copying that ReplicaSet's pod template
+// into the Deployment. It returns the revision rolled back to.
+func UndoDeployment(ctx context.Context, client KubernetesClient, namespace, name string) (int64, error) {
+	deployment, err := getDeployment(ctx, client, namespace, name)
+	if err != nil {
+		return 0, err
+	}
+	history, err := deploymentHistory(ctx, client, deployment)
+	if err != nil {
+		return 0, err
+	}
+	current, _ := strconv.ParseInt(deployment.Annotations[RevisionAnnotation], 10, 64)
+	var previous *DeploymentRevision
+	for i := range history {
+		if history[i].Revision != current {
+			previous = &history[i]
+		}
+	}
+	if previous == nil {
+		return 0, fmt.Errorf("no previous revision found for deployment '%s'", name)
+	}
+
+	template := previous.replicaSet.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	deployment.Spec.Template = *template
+	if _, err := client.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		return 0, fmt.Errorf("failed to roll back deployment '%s': %w", name, err)
+	}
+	return previous.Revision, nil
+}
+
+func getDeployment(ctx context.Context, client KubernetesClient, namespace, name string) (*appsv1.Deployment, error) {
+	deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("deployment '%s' not found in namespace '%s'", name, namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment '%s': %w", name, err)
+	}
+	return deployment, nil
+}
+
+func deploymentHistory(ctx context.Context, client KubernetesClient, deployment *appsv1.Deployment) ([]DeploymentRevision, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector on deployment '%s': %w", deployment.Name, err)
+	}
+	replicaSets, err := client.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replica sets for deployment '%s': %w", deployment.Name, err)
+	}
+
+	var history []DeploymentRevision
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		revision, err := strconv.ParseInt(rs.Annotations[RevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		var images []string
+		for _, container := range rs.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+		history = append(history, DeploymentRevision{
+			Revision:    revision,
+			ChangeCause: rs.Annotations[ChangeCauseAnnotation],
+			Images:      images,
+			replicaSet:  rs,
+		})
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Revision < history[j].Revision })
+	return history, nil
+}
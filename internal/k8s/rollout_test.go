@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func rolloutFixture() (*appsv1.Deployment, []*appsv1.ReplicaSet) {
+	labels := map[string]string{"app": "blog"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "blog", Namespace: "hobby", UID: types.UID("blog-uid"),
+			Annotations: map[string]string{RevisionAnnotation: "2"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "blog", Image: "blog:2"}}},
+			},
+		},
+	}
+	owner := *metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	replicaSet := func(name, revision, image string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "hobby",
+				Labels:          map[string]string{"app": "blog", appsv1.DefaultDeploymentUniqueLabelKey: name},
+				Annotations:     map[string]string{RevisionAnnotation: revision, ChangeCauseAnnotation: "deploy " + image},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "blog", appsv1.DefaultDeploymentUniqueLabelKey: name}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "blog", Image: image}}},
+			}},
+		}
+	}
+	return deployment, []*appsv1.ReplicaSet{replicaSet("blog-2", "2", "blog:2"), replicaSet("blog-1", "1", "blog:1")}
+}
+
+func TestDeploymentHistory(t *testing.T) {
+	deployment, replicaSets := rolloutFixture()
+	orphan := replicaSets[0].DeepCopy()
+	orphan.Name, orphan.OwnerReferences = "orphan", nil
+	client := fake.NewSimpleClientset(deployment, replicaSets[0], replicaSets[1], orphan)
+
+	history, err := DeploymentHistory(context.Background(), client, "hobby", "blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d revisions, want 2: %+v", len(history), history)
+	}
+	if history[0].Revision != 1 || history[1].Revision != 2 {
+		t.Errorf("revisions = %d, %d, want 1, 2", history[0].Revision, history[1].Revision)
+	}
+	if history[0].ChangeCause != "deploy blog:1" || history[0].Images[0] != "blog:1" {
+		t.Errorf("revision 1 = %+v", history[0])
+	}
+
+	if _, err := DeploymentHistory(context.Background(), client, "hobby", "missing"); err == nil {
+		t.Error("expected an error for a missing Deployment")
+	}
+}
+
+func TestUndoDeployment(t *testing.T) {
+	deployment, replicaSets := rolloutFixture()
+	client := fake.NewSimpleClientset(deployment, replicaSets[0], replicaSets[1])
+
+	revision, err := UndoDeployment(context.Background(), client, "hobby", "blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision != 1 {
+		t.Errorf("rolled back to revision %d, want 1", revision)
+	}
+	live, err := client.AppsV1().Deployments("hobby").Get(context.Background(), "blog", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := live.Spec.Template.Spec.Containers[0].Image; got != "blog:1" {
+		t.Errorf("image = %q, want blog:1", got)
+	}
+	if _, ok := live.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+		t.Errorf("pod-template-hash label was copied into the Deployment: %v", live.Spec.Template.Labels)
+	}
+
+	single := fake.NewSimpleClientset(deployment, replicaSets[0])
+	if _, err := UndoDeployment(context.Background(), single, "hobby", "blog"); err == nil {
+		t.Error("expected an error without a previous revision")
+	}
+}
@@ -0,0 +1,36 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ScaleDeployment sets the replicas of a Deployment and returns the previous
+// count. Zero suspends the workload and keeps everything else in place.
+func ScaleDeployment(ctx context.Context, client KubernetesClient, namespace, name string, replicas int32) (int32, error) {
+	if replicas < 0 {
+		return 0, fmt.Errorf("replicas must not be negative, got %d", replicas)
+	}
+	deployments := client.AppsV1().Deployments(namespace)
+	live, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return 0, fmt.Errorf("deployment '%s' not found in namespace '%s'", name, namespace)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deployment '%s': %w", name, err)
+	}
+	previous := int32(1)
+	if live.Spec.Replicas != nil {
+		previous = *live.Spec.Replicas
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	if _, err := deployments.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return previous, fmt.Errorf("failed to scale deployment '%s': %w", name, err)
+	}
+	return previous, nil
+}
@@ -0,0 +1,39 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestScaleDeployment(t *testing.T) {
+	client := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "hobby-pod", Namespace: "hobby"},
+		Spec:       appsv1.DeploymentSpec{Replicas: Int32Ptr(2)},
+	})
+
+	previous, err := ScaleDeployment(context.Background(), client, "hobby", "hobby-pod", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous != 2 {
+		t.Errorf("previous = %d, want 2", previous)
+	}
+	live, err := client.AppsV1().Deployments("hobby").Get(context.Background(), "hobby-pod", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Spec.Replicas == nil || *live.Spec.Replicas != 0 {
+		t.Errorf("replicas = %v, want 0", live.Spec.Replicas)
+	}
+
+	if _, err := ScaleDeployment(context.Background(), client, "hobby", "hobby-pod", -1); err == nil {
+		t.Error("expected an error for negative replicas")
+	}
+	if _, err := ScaleDeployment(context.Background(), client, "hobby", "redis", 1); err == nil {
+		t.Error("expected an error for a missing Deployment")
+	}
+}
@@ -0,0 +1,170 @@
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleField matches one field of a standard five-field cron schedule
+var scheduleField = regexp.MustCompile(`^[0-9A-Za-z*/,\-?]+$`)
+
+// ValidateSchedule checks that schedule, read from the config key key, is a
+// five-field cron expression or one of the @-macros Kubernetes CronJobs accept
+func ValidateSchedule(key, schedule string) error {
+	switch schedule {
+	case "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly":
+		return nil
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return fmt.Errorf("invalid %s %q: want five cron fields, e.g. \"30 3 * * 0\"", key, schedule)
+	}
+	for _, field := range fields {
+		if !scheduleField.MatchString(field) {
+			return fmt.Errorf("invalid %s %q: unexpected field %q", key, schedule, field)
+		}
+	}
+	return nil
+}
+
+// scheduleMacros expands the @-macros to their five-field form
+var scheduleMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// scheduleBounds are the value ranges and names of the five cron fields
+var scheduleBounds = []struct {
+	name     string
+	min, max int
+	names    []string
+}{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// NextScheduleTime returns the first time after after at which a CronJob
+// with schedule runs, in after's location. As in cron, a day matches either
+// the day of month or the day of week when both are restricted.
+func NextScheduleTime(schedule string, after time.Time) (time.Time, error) {
+	if expanded, ok := scheduleMacros[schedule]; ok {
+		schedule = expanded
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return time.Time{}, fmt.Errorf("invalid schedule %q: want five cron fields", schedule)
+	}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseScheduleField(field, i)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+		}
+		sets[i] = set
+	}
+	// Sunday may be written as 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	anyDay := func(field string) bool { return field[0] == '*' || field[0] == '?' }
+	dayMatches := func(t time.Time) bool {
+		dom := sets[2]&(1<<uint(t.Day())) != 0
+		dow := sets[4]&(1<<uint(t.Weekday())) != 0
+		if anyDay(fields[2]) || anyDay(fields[4]) {
+			return dom && dow
+		}
+		return dom || dow
+	}
+
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Every valid schedule matches within the 28-year calendar cycle
+	limit := t.AddDate(28, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case sets[3]&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case sets[1]&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case sets[0]&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("schedule %q never runs", schedule)
+}
+
+// parseScheduleField parses the cron field at index into a bit set of the
+// values it matches
+func parseScheduleField(field string, index int) (uint64, error) {
+	bounds := scheduleBounds[index]
+	value := func(s string) (int, error) {
+		for i, name := range bounds.names {
+			if strings.EqualFold(s, name) {
+				return i + bounds.min, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < bounds.min || n > bounds.max {
+			return 0, fmt.Errorf("%s %q out of range %d-%d", bounds.name, s, bounds.min, bounds.max)
+		}
+		return n, nil
+	}
+
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepPart, bounds.name)
+			}
+			step = n
+		}
+		var low, high int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			low, high = bounds.min, bounds.max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = value(from); err != nil {
+				return 0, err
+			}
+			if high, err = value(to); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid %s range %q", bounds.name, rangePart)
+			}
+		default:
+			var err error
+			if low, err = value(rangePart); err != nil {
+				return 0, err
+			}
+			high = low
+			if hasStep {
+				high = bounds.max
+			}
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,67 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		wantErr  bool
+	}{
+		{schedule: "30 3 * * 0"},
+		{schedule: "*/5 * * * *"},
+		{schedule: "@hourly"},
+		{schedule: "30 3 *", wantErr: true},
+		{schedule: "30 3 * * ;rm", wantErr: true},
+		{schedule: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSchedule("schedule", tt.schedule); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSchedule(%q) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNextScheduleTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// A Wednesday
+	after := time.Date(2026, time.October, 14, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		after    time.Time
+		want     time.Time
+		wantErr  bool
+	}{
+		{schedule: "*/5 * * * *", after: after, want: time.Date(2026, time.October, 14, 10, 20, 0, 0, time.UTC)},
+		{schedule: "30 3 * * *", after: after, want: time.Date(2026, time.October, 15, 3, 30, 0, 0, time.UTC)},
+		{schedule: "@hourly", after: after, want: time.Date(2026, time.October, 14, 11, 0, 0, 0, time.UTC)},
+		{schedule: "@monthly", after: after, want: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 9 * * mon-fri", after: time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC), want: time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 * * 7", after: after, want: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		// Either the 1st or a Friday
+		{schedule: "0 0 1 * fri", after: after, want: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 29 feb *", after: after, want: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{schedule: "30 3 * * *", after: after.In(berlin), want: time.Date(2026, time.October, 15, 3, 30, 0, 0, berlin)},
+		{schedule: "0 0 30 feb *", after: after, wantErr: true},
+		{schedule: "61 * * * *", after: after, wantErr: true},
+		{schedule: "*/0 * * * *", after: after, wantErr: true},
+		{schedule: "5-1 * * * *", after: after, wantErr: true},
+		{schedule: "* * *", after: after, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NextScheduleTime(tt.schedule, tt.after)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NextScheduleTime(%q) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("NextScheduleTime(%q) = %v, want %v", tt.schedule, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Scrape has the prometheus module scrape the metrics a module's Services
+// serve. Its kubernetes-service-endpoints job keeps Services annotated
+// prometheus.io/scrape and reads the port, path and scheme from the other
+// prometheus.io annotations.
+type Scrape struct {
+	Port   int32  // container port serving the metrics; 0 for the Service's web port
+	Path   string // default /metrics
+	Scheme string // http or https, default http
+}
+
+// annotations returns the prometheus.io annotations of service
+func (s Scrape) annotations(service *corev1.Service) map[string]string {
+	annotations := map[string]string{"prometheus.io/scrape": "true"}
+	if port := s.Port; port != 0 {
+		annotations["prometheus.io/port"] = strconv.Itoa(int(port))
+	} else if port := MetricsPort(service); port != 0 {
+		annotations["prometheus.io/port"] = strconv.Itoa(int(port))
+	}
+	annotations["prometheus.io/path"] = "/metrics"
+	if s.Path != "" {
+		annotations["prometheus.io/path"] = s.Path
+	}
+	if s.Scheme != "" {
+		annotations["prometheus.io/scheme"] = s.Scheme
+	}
+	return annotations
+}
+
+// WebPort returns the port of service named http, else its first port, or
+// nil when it has none
+func WebPort(service *corev1.Service) *corev1.ServicePort {
+	if len(service.Spec.Ports) == 0 {
+		return nil
+	}
+	for i := range service.Spec.Ports {
+		if service.Spec.Ports[i].Name == "http" {
+			return &service.Spec.Ports[i]
+		}
+	}
+	return &service.Spec.Ports[0]
+}
+
+// MetricsPort returns the container port behind the web port of service,
+// which Prometheus scrapes on the pods, or 0 when the Service has no ports
+// or targets a named container port
+func MetricsPort(service *corev1.Service) int32 {
+	port := WebPort(service)
+	switch {
+	case port == nil:
+		return 0
+	case port.TargetPort.IntVal != 0:
+		return port.TargetPort.IntVal
+	case port.TargetPort.StrVal != "":
+		return 0
+	}
+	return port.Port
+}
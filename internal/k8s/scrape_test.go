@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSetObjectMetadata_Scrape(t *testing.T) {
+	service := &corev1.Service{Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{
+		{Name: "ssh", Port: 22},
+		{Name: "http", Port: 80, TargetPort: intstr.FromInt(3000)},
+	}}}
+	deployment := &appsv1.Deployment{}
+	SetObjectMetadata(ObjectMetadata{Scrape: &Scrape{Scheme: "https"}}, service, deployment)
+
+	want := map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   "3000",
+		"prometheus.io/path":   "/metrics",
+		"prometheus.io/scheme": "https",
+	}
+	for key, value := range want {
+		if got := service.Annotations[key]; got != value {
+			t.Errorf("annotation %s = %q, want %q", key, got, value)
+		}
+	}
+	if len(deployment.Annotations) > 0 || len(deployment.Spec.Template.Annotations) > 0 {
+		t.Errorf("Deployment annotated %v, want only Services", deployment.Annotations)
+	}
+
+	service = &corev1.Service{Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}}}
+	SetObjectMetadata(ObjectMetadata{Scrape: &Scrape{Port: 9090, Path: "/stats"}}, service)
+	if service.Annotations["prometheus.io/port"] != "9090" || service.Annotations["prometheus.io/path"] != "/stats" {
+		t.Errorf("annotations = %v, want port 9090 and path /stats", service.Annotations)
+	}
+}
+
+func TestMetricsPort(t *testing.T) {
+	tests := []struct {
+		name  string
+		ports []corev1.ServicePort
+		want  int32
+	}{
+		{name: "no ports"},
+		{name: "first port", ports: []corev1.ServicePort{{Port: 8080}, {Port: 9000}}, want: 8080},
+		{name: "http target port", ports: []corev1.ServicePort{{Port: 22}, {Name: "http", Port: 80, TargetPort: intstr.FromInt(3000)}}, want: 3000},
+		{name: "named target port", ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromString("web")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetricsPort(&corev1.Service{Spec: corev1.ServiceSpec{Ports: tt.ports}}); got != tt.want {
+				t.Errorf("MetricsPort() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
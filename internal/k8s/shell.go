@@ -0,0 +1,22 @@
+package k8s
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// ShellRequest is an ExecRequest attached to the local terminal
+type ShellRequest struct {
+	ExecRequest
+	// TTY allocates a terminal in the container; its output then arrives on
+	// Stdout only
+	TTY bool
+	// Resize delivers the size of the local terminal, nil without TTY
+	Resize remotecommand.TerminalSizeQueue
+}
+
+// ExecShell runs req like PodExecutor, with a terminal when req.TTY is set
+func (c *Clients) ExecShell(ctx context.Context, req ShellRequest) error {
+	return c.podExec(ctx, req.ExecRequest, req.TTY, req.Resize)
+}
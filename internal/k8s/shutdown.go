@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ShutdownSettings documents the modules[].secrets keys that override how a
+// module's pods are stopped. Embed it with yaml:",inline" in a module's
+// settings next to ProbeSettings. Unset keys keep the module's defaults.
+type ShutdownSettings struct {
+	TerminationGracePeriod string `yaml:"termination_grace_period" doc:"Seconds a pod gets to stop, pre-stop hook included, before it is killed"`
+	PreStop                string `yaml:"pre_stop" doc:"Shell command run in the main container before it is stopped; empty removes the module's default hook"`
+}
+
+// SetGracefulShutdown gives the pod gracePeriod seconds to stop and, unless
+// preStop is empty, runs preStop with sh -c in its main container before the
+// container is sent SIGTERM. The hook counts against the grace period, so it
+// must finish well within it; a pod still running at the end is killed, which
+// is what leaves data files needing recovery.
+func SetGracefulShutdown(spec *corev1.PodSpec, gracePeriod int64, preStop string) {
+	spec.TerminationGracePeriodSeconds = &gracePeriod
+	setPreStop(&spec.Containers[0], preStop)
+}
+
+// ApplyShutdownOverrides applies the termination_grace_period and pre_stop
+// keys of secrets to the pod spec and its main container
+func ApplyShutdownOverrides(spec *corev1.PodSpec, secrets map[string]string) error {
+	if value, ok := secrets["termination_grace_period"]; ok {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid termination_grace_period %q: must be a whole number of at least 0", value)
+		}
+		spec.TerminationGracePeriodSeconds = &n
+	}
+	if preStop, ok := secrets["pre_stop"]; ok {
+		setPreStop(&spec.Containers[0], preStop)
+	}
+	return nil
+}
+
+// setPreStop sets or, for an empty command, removes the container's pre-stop
+// hook
+func setPreStop(container *corev1.Container, command string) {
+	if command == "" {
+		if container.Lifecycle != nil {
+			container.Lifecycle.PreStop = nil
+			if container.Lifecycle.PostStart == nil {
+				container.Lifecycle = nil
+			}
+		}
+		return
+	}
+	if container.Lifecycle == nil {
+		container.Lifecycle = &corev1.Lifecycle{}
+	}
+	container.Lifecycle.PreStop = &corev1.LifecycleHandler{
+		Exec: &corev1.ExecAction{Command: []string{"sh", "-c", command}},
+	}
+}
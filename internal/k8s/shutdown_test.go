@@ -0,0 +1,57 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetGracefulShutdown(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "db"}, {Name: "exporter"}}}
+	SetGracefulShutdown(spec, 60, "db-ctl stop")
+
+	if spec.TerminationGracePeriodSeconds == nil || *spec.TerminationGracePeriodSeconds != 60 {
+		t.Errorf("TerminationGracePeriodSeconds = %v, want 60", spec.TerminationGracePeriodSeconds)
+	}
+	hook := spec.Containers[0].Lifecycle.PreStop
+	if hook == nil || len(hook.Exec.Command) != 3 || hook.Exec.Command[2] != "db-ctl stop" {
+		t.Errorf("PreStop = %+v, want sh -c db-ctl stop", hook)
+	}
+	if spec.Containers[1].Lifecycle != nil {
+		t.Errorf("sidecar Lifecycle = %+v, want nil", spec.Containers[1].Lifecycle)
+	}
+
+	SetGracefulShutdown(spec, 30, "")
+	if spec.Containers[0].Lifecycle != nil {
+		t.Errorf("Lifecycle after an empty command = %+v, want nil", spec.Containers[0].Lifecycle)
+	}
+}
+
+func TestApplyShutdownOverrides(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "db"}}}
+	SetGracefulShutdown(spec, 60, "db-ctl stop")
+
+	err := ApplyShutdownOverrides(spec, map[string]string{"termination_grace_period": "120", "pre_stop": "db-ctl stop --fast"})
+	if err != nil {
+		t.Fatalf("ApplyShutdownOverrides() error = %v", err)
+	}
+	if *spec.TerminationGracePeriodSeconds != 120 {
+		t.Errorf("TerminationGracePeriodSeconds = %d, want 120", *spec.TerminationGracePeriodSeconds)
+	}
+	if got := spec.Containers[0].Lifecycle.PreStop.Exec.Command[2]; got != "db-ctl stop --fast" {
+		t.Errorf("PreStop command = %q, want db-ctl stop --fast", got)
+	}
+
+	if err := ApplyShutdownOverrides(spec, map[string]string{"pre_stop": ""}); err != nil {
+		t.Fatalf("ApplyShutdownOverrides() error = %v", err)
+	}
+	if spec.Containers[0].Lifecycle != nil {
+		t.Errorf("Lifecycle after an empty pre_stop = %+v, want nil", spec.Containers[0].Lifecycle)
+	}
+
+	for _, value := range []string{"-1", "30s"} {
+		if err := ApplyShutdownOverrides(spec, map[string]string{"termination_grace_period": value}); err == nil {
+			t.Errorf("ApplyShutdownOverrides(termination_grace_period=%q) succeeded, want error", value)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ValidateStorageClass checks a StorageClass name from the config; empty is
+// valid and keeps the cluster's default class
+func ValidateStorageClass(class string) error {
+	if class == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(class); len(errs) > 0 {
+		return fmt.Errorf("invalid storage class %q: %s", class, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// SetStorageClass sets the StorageClass a PersistentVolumeClaim is provisioned
+// from. An empty class leaves the field unset, so the cluster's default class
+// is used, e.g. microk8s-hostpath on MicroK8s or local-path on k3s.
+func SetStorageClass(spec *corev1.PersistentVolumeClaimSpec, class string) {
+	if class == "" {
+		return
+	}
+	spec.StorageClassName = &class
+}
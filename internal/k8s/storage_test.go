@@ -0,0 +1,32 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetStorageClass(t *testing.T) {
+	spec := corev1.PersistentVolumeClaimSpec{}
+	SetStorageClass(&spec, "")
+	if spec.StorageClassName != nil {
+		t.Errorf("StorageClassName = %q without a class, want nil", *spec.StorageClassName)
+	}
+	SetStorageClass(&spec, "longhorn")
+	if spec.StorageClassName == nil || *spec.StorageClassName != "longhorn" {
+		t.Errorf("StorageClassName = %v, want longhorn", spec.StorageClassName)
+	}
+}
+
+func TestValidateStorageClass(t *testing.T) {
+	for _, class := range []string{"", "longhorn", "local-path", "ceph-rbd.example.com"} {
+		if err := ValidateStorageClass(class); err != nil {
+			t.Errorf("ValidateStorageClass(%q) error = %v", class, err)
+		}
+	}
+	for _, class := range []string{"Longhorn", "local_path", "-ceph"} {
+		if err := ValidateStorageClass(class); err == nil {
+			t.Errorf("ValidateStorageClass(%q) succeeded, want error", class)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -67,6 +68,22 @@ func GenerateConnectionToken() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
+// GeneratePassword returns a random string of length letters and digits,
+// which needs no escaping in DSNs, shells or YAML
+func GeneratePassword(length int) (string, error) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	size := big.NewInt(int64(len(alphabet)))
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i] = alphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
 // BoolPtr returns a pointer to a bool value
 func BoolPtr(b bool) *bool {
 	return &b
@@ -0,0 +1,43 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WaitForDeploymentReady polls the named Deployment until all of its replicas
+// are updated and available, or ctx is done. A Deployment that does not exist
+// is treated as ready so callers can gate on modules that do not run one.
+func WaitForDeploymentReady(ctx context.Context, client KubernetesClient, namespace, name string, interval time.Duration) error {
+	for {
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("failed to get deployment '%s': %w", name, err)
+		}
+		if err == nil && deploymentReady(deployment.Generation, deployment.Status.ObservedGeneration,
+			deployment.Spec.Replicas, deployment.Status.UpdatedReplicas, deployment.Status.AvailableReplicas) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for deployment '%s' in namespace '%s' to become ready", name, namespace)
+		case <-time.After(interval):
+		}
+	}
+}
+
+func deploymentReady(generation, observed int64, replicas *int32, updated, available int32) bool {
+	want := int32(1)
+	if replicas != nil {
+		want = *replicas
+	}
+	return observed >= generation && updated >= want && available >= want
+}
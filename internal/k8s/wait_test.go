@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForDeploymentReady(t *testing.T) {
+	replicas := int32(1)
+	deployment := func(available int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "infra"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{
+				UpdatedReplicas:   available,
+				AvailableReplicas: available,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		objects []*appsv1.Deployment
+		wantErr bool
+	}{
+		{name: "ready", objects: []*appsv1.Deployment{deployment(1)}},
+		{name: "missing deployment", objects: nil},
+		{name: "never ready", objects: []*appsv1.Deployment{deployment(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for _, d := range tt.objects {
+				if _, err := client.AppsV1().Deployments(d.Namespace).Create(context.Background(), d, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("failed to seed deployment: %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := WaitForDeploymentReady(ctx, client, "infra", "postgres", 10*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WaitForDeploymentReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
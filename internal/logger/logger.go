@@ -32,52 +32,88 @@ type Logger interface {
 
 // StdLogger is the default logger implementation that writes to stdout
 type StdLogger struct {
-	out io.Writer
+	out   io.Writer
+	style Style
 }
 
-// NewStdLogger creates a new StdLogger that writes to the provided writer
+// NewStdLogger creates a new StdLogger that writes to the provided writer.
+// Emoji are kept and colors are off; use NewStyledLogger to choose.
 func NewStdLogger(out io.Writer) *StdLogger {
-	return &StdLogger{out: out}
+	return &StdLogger{out: out, style: Style{Emoji: true}}
 }
 
-// Default returns a StdLogger that writes to os.Stdout
+// NewStyledLogger creates a new StdLogger with an explicit output style
+func NewStyledLogger(out io.Writer, style Style) *StdLogger {
+	return &StdLogger{out: out, style: style}
+}
+
+// Default returns a StdLogger that writes to os.Stdout, styled for the
+// terminal it is attached to
 func Default() *StdLogger {
-	return NewStdLogger(os.Stdout)
+	return NewStyledLogger(os.Stdout, DetectStyle(os.Stdout))
+}
+
+// Style returns the logger's output style
+func (l *StdLogger) Style() Style {
+	return l.style
+}
+
+// SetStyle changes the logger's output style
+func (l *StdLogger) SetStyle(style Style) {
+	l.style = style
 }
 
 // Info logs informational messages
 func (l *StdLogger) Info(format string, args ...interface{}) {
-	fmt.Fprintf(l.out, format, args...)
+	l.write("", "", format, args...)
 }
 
 // Success logs success messages with ✅ prefix
 func (l *StdLogger) Success(format string, args ...interface{}) {
-	fmt.Fprintf(l.out, "✅ "+format, args...)
+	l.write("✅ ", colorGreen, format, args...)
 }
 
 // Warn logs warning messages with ⚠️ prefix
 func (l *StdLogger) Warn(format string, args ...interface{}) {
-	fmt.Fprintf(l.out, "⚠️  "+format, args...)
+	l.write("⚠️  ", colorYellow, format, args...)
 }
 
 // Error logs error messages with ❌ prefix
 func (l *StdLogger) Error(format string, args ...interface{}) {
-	fmt.Fprintf(l.out, "❌ "+format, args...)
+	l.write("❌ ", colorRed, format, args...)
 }
 
 // Progress logs progress/action messages with 📦 prefix
 func (l *StdLogger) Progress(format string, args ...interface{}) {
-	fmt.Fprintf(l.out, "📦 "+format, args...)
+	l.write("📦 ", colorCyan, format, args...)
 }
 
 // Print logs plain messages without any prefix
 func (l *StdLogger) Print(format string, args ...interface{}) {
-	fmt.Fprintf(l.out, format, args...)
+	l.write("", "", format, args...)
 }
 
 // Println logs plain messages with a newline
 func (l *StdLogger) Println(args ...interface{}) {
-	fmt.Fprintln(l.out, args...)
+	// Arguments are printed as values; only translated messages come from
+	// the catalog and are rendered like a format string
+	for i, arg := range args {
+		if message, ok := arg.(string); ok {
+			if translated := l.style.Language.T(message); translated != message {
+				args[i] = l.style.render(translated)
+			}
+		}
+	}
+	fmt.Fprint(l.out, validUTF8(fmt.Sprintln(args...)))
+}
+
+func (l *StdLogger) write(prefix, color, format string, args ...interface{}) {
+	prefix = l.style.render(prefix)
+	if prefix != "" && l.style.Color {
+		prefix = color + prefix + colorReset
+	}
+	message := fmt.Sprintf(l.style.render(l.style.Language.T(format)), args...)
+	fmt.Fprint(l.out, prefix+validUTF8(message))
 }
 
 // NopLogger is a logger that discards all output (useful for testing)
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Goalt/personal-server/internal/i18n"
+)
+
+// Style controls how a StdLogger decorates its output
+type Style struct {
+	// Color wraps the Success/Warn/Error/Progress prefixes in ANSI colors
+	Color bool
+	// Emoji keeps emoji; when false they are replaced with ASCII markers
+	Emoji bool
+	// Language translates messages found in its catalog; empty means English
+	Language i18n.Language
+}
+
+// Styled is implemented by loggers whose output style can be changed after
+// construction, e.g. from command-line flags
+type Styled interface {
+	Style() Style
+	SetStyle(Style)
+}
+
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+// Color is an ANSI color Paint can wrap text in
+type Color string
+
+// Colors for Paint
+const (
+	Red    Color = colorRed
+	Green  Color = colorGreen
+	Yellow Color = colorYellow
+	Cyan   Color = colorCyan
+)
+
+// Paint wraps text in color when the style uses colors, for output whose
+// color carries meaning beyond a message prefix, such as diff lines
+func (s Style) Paint(color Color, text string) string {
+	if !s.Color {
+		return text
+	}
+	return string(color) + text + colorReset
+}
+
+// asciiReplacer maps the emoji and box-drawing characters used in messages
+// to plain ASCII. Variation selectors are listed first so "⚠️" and "⚠" both
+// map to the same marker.
+var asciiReplacer = strings.NewReplacer(
+	"✅", "[OK]",
+	"⚠️", "[WARN]",
+	"⚠", "[WARN]",
+	"❌", "[ERROR]",
+	"📦", "[*]",
+	"🗑️", "[DEL]",
+	"🗑", "[DEL]",
+	"💾", "[SAVE]",
+	"🔄", "[..]",
+	"🎉", "[DONE]",
+	"💡", "[TIP]",
+	"📋", "[*]",
+	"🔍", "[?]",
+	"📥", "[GET]",
+	"☁️", "[CLOUD]",
+	"☁", "[CLOUD]",
+	"🚀", "[RUN]",
+	"🔒", "[LOCK]",
+	"🔓", "[UNLOCK]",
+	"📅", "[DATE]",
+	"⏭️", "[SKIP]",
+	"⏭", "[SKIP]",
+	"—", "-",
+	"–", "-",
+	"─", "-",
+	"…", "...",
+)
+
+// DetectStyle picks a style for output written to f. Colors are used only on
+// an interactive terminal and honour NO_COLOR and TERM=dumb. Emoji are kept
+// on terminals but replaced with ASCII when output goes to a file or pipe
+// (cron logs, CI) and on legacy Windows consoles that cannot render them.
+func DetectStyle(f *os.File) Style {
+	tty := isTerminal(f)
+	legacyConsole := runtime.GOOS == "windows" && os.Getenv("WT_SESSION") == "" && os.Getenv("TERM_PROGRAM") == ""
+
+	return Style{
+		Color: tty && !legacyConsole && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb",
+		Emoji: tty && !legacyConsole,
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// render makes text valid UTF-8 and, without emoji, strips it down to text
+// that any console can show. Letters of every script are kept. Only the
+// logger's own prefixes and format strings are rendered; values interpolated
+// into them, such as revealed secrets, are printed unchanged.
+func (s Style) render(text string) string {
+	text = validUTF8(text)
+	if s.Emoji {
+		return text
+	}
+
+	text = asciiReplacer.Replace(text)
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf {
+			return r
+		}
+		// Drop remaining pictographs, variation selectors and joiners
+		if unicode.Is(unicode.So, r) || unicode.Is(unicode.Variation_Selector, r) || r == '\u200d' {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// validUTF8 replaces invalid UTF-8 in text with "?"
+func validUTF8(text string) string {
+	if !utf8.ValidString(text) {
+		return strings.ToValidUTF8(text, "?")
+	}
+	return text
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/i18n"
+)
+
+func TestStdLoggerStyles(t *testing.T) {
+	tests := []struct {
+		name  string
+		style Style
+		log   func(Logger)
+		want  string
+	}{
+		{
+			name:  "emoji kept",
+			style: Style{Emoji: true},
+			log:   func(l Logger) { l.Success("done\n") },
+			want:  "✅ done\n",
+		},
+		{
+			name:  "ascii prefix",
+			style: Style{},
+			log:   func(l Logger) { l.Warn("careful\n") },
+			want:  "[WARN]  careful\n",
+		},
+		{
+			name:  "ascii message body",
+			style: Style{},
+			log:   func(l Logger) { l.Info("🗑️  Deleting Service — %s\n", "gitea") },
+			want:  "[DEL]  Deleting Service - gitea\n",
+		},
+		{
+			name:  "unknown pictographs dropped, letters kept",
+			style: Style{},
+			log:   func(l Logger) { l.Info("🧪 Проверка ok\n") },
+			want:  " Проверка ok\n",
+		},
+		{
+			name:  "interpolated values kept",
+			style: Style{},
+			log:   func(l Logger) { l.Warn("⚠️ value — %s\n", "a—b ✓ 🔑") },
+			want:  "[WARN]  [WARN] value - a—b ✓ 🔑\n",
+		},
+		{
+			name:  "println values kept",
+			style: Style{},
+			log:   func(l Logger) { l.Println("a — b 🔑") },
+			want:  "a — b 🔑\n",
+		},
+		{
+			name:  "colored prefix",
+			style: Style{Color: true},
+			log:   func(l Logger) { l.Error("boom\n") },
+			want:  colorRed + "[ERROR] " + colorReset + "boom\n",
+		},
+		{
+			name:  "no color on plain messages",
+			style: Style{Color: true, Emoji: true},
+			log:   func(l Logger) { l.Println("plain") },
+			want:  "plain\n",
+		},
+		{
+			name:  "invalid utf-8 replaced",
+			style: Style{Emoji: true},
+			log:   func(l Logger) { l.Print("bad \xff byte\n") },
+			want:  "bad ? byte\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(NewStyledLogger(&buf, tt.style))
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStdLoggerTranslates(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStyledLogger(&buf, Style{Emoji: true, Language: i18n.Russian})
+	l.Success("%s applied\n", "gitea")
+	l.Println("Usage:")
+	l.Info("untranslated %d\n", 1)
+
+	want := "✅ gitea применён\nИспользование:\nuntranslated 1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSetStyle(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(&buf)
+	l.SetStyle(Style{})
+	l.Progress("working\n")
+	if got := buf.String(); got != "[*] working\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDetectStyleNonTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if style := DetectStyle(f); style.Color || style.Emoji {
+		t.Errorf("expected plain style for a regular file, got %+v", style)
+	}
+}
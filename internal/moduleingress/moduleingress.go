@@ -0,0 +1,309 @@
+// Package moduleingress builds the Ingress that exposes a module's web
+// interface through the ingress controller, at <module>.<domain> unless the
+// module's ingress section says otherwise, and applies and deletes it along
+// with the module's other objects. With general.ingressTLS enabled the
+// Ingress also requests a certificate from cert-manager.
+package moduleingress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// clusterIssuerAnnotation makes cert-manager's ingress-shim issue the
+// certificate of an Ingress's TLS block into the Secret it names
+const clusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+
+// defaultRealm is shown in the browser's basic-auth prompt unless the
+// module's basicAuth sets a realm
+const defaultRealm = "Authentication Required"
+
+// webSocketTimeout is how long, in seconds, ingress-nginx keeps an idle
+// WebSocket open instead of its 60 second default
+const webSocketTimeout = "3600"
+
+// Route is the Ingress of one module
+type Route struct {
+	Name      string
+	Namespace string
+	Host      string
+	Path      string
+	PathType  networkingv1.PathType
+	Service   string
+	Port      networkingv1.ServiceBackendPort
+	WebSocket bool // the app keeps WebSockets open, e.g. for terminals or live updates
+	// TLSSecret holds the certificate for Host; empty serves plain HTTP.
+	// ClusterIssuer, when set, has cert-manager issue it.
+	TLSSecret     string
+	ClusterIssuer string
+	// BasicAuth, when set, has ingress-nginx ask for one of its users,
+	// checked against the htpasswd Secret <name>-basic-auth
+	BasicAuth *config.IngressBasicAuth
+	Labels    map[string]string
+	Metadata  k8s.ObjectMetadata
+}
+
+// Object is one object of a Route and the file Generate writes it to
+type Object struct {
+	File   string
+	Object interface{}
+}
+
+// Config returns the Route to the port named http of service, else its first
+// port, or nil when the module has no ingress section. The Ingress is named
+// after the module and carries the Service's labels. With TLS, cert-manager
+// issues its certificate into <module>-tls.
+func Config(module config.Module, general config.GeneralConfig, service *corev1.Service, webSocket bool) *Route {
+	if module.Ingress == nil || service == nil {
+		return nil
+	}
+	port := k8s.WebPort(service)
+	if port == nil {
+		return nil
+	}
+	route := &Route{
+		Name:      module.Name,
+		Namespace: module.Namespace,
+		Host:      module.IngressHost(general),
+		Path:      module.Ingress.Path,
+		PathType:  networkingv1.PathType(module.Ingress.PathType),
+		Service:   service.Name,
+		Port:      networkingv1.ServiceBackendPort{Number: port.Port},
+		WebSocket: webSocket,
+		BasicAuth: module.Ingress.BasicAuth,
+		Labels:    service.Labels,
+		Metadata:  module.ObjectMetadata(general),
+	}
+	if route.Path == "" {
+		route.Path = "/"
+	}
+	if route.PathType == "" {
+		route.PathType = networkingv1.PathTypePrefix
+	}
+	if module.IngressTLS(general) {
+		route.TLSSecret = module.Name + "-tls"
+		route.ClusterIssuer = general.IngressTLS.Issuer()
+	}
+	return route
+}
+
+// Ingress returns the Ingress routing Host and Path to the Service, over
+// HTTPS when TLSSecret is set
+func (r *Route) Ingress() *networkingv1.Ingress {
+	pathType := r.PathType
+	ingress := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "Ingress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.Name,
+			Namespace: r.Namespace,
+			Labels:    r.Labels,
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: r.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     r.Path,
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: r.Service,
+											Port: r.Port,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if r.WebSocket {
+		// ingress-nginx upgrades WebSocket connections by itself but closes
+		// them after a minute without traffic
+		ingress.Annotations = map[string]string{
+			"nginx.ingress.kubernetes.io/proxy-read-timeout": webSocketTimeout,
+			"nginx.ingress.kubernetes.io/proxy-send-timeout": webSocketTimeout,
+		}
+	}
+	if r.TLSSecret != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      []string{r.Host},
+				SecretName: r.TLSSecret,
+			},
+		}
+		if r.ClusterIssuer != "" {
+			if ingress.Annotations == nil {
+				ingress.Annotations = map[string]string{}
+			}
+			ingress.Annotations[clusterIssuerAnnotation] = r.ClusterIssuer
+		}
+	}
+	if r.BasicAuth != nil {
+		if ingress.Annotations == nil {
+			ingress.Annotations = map[string]string{}
+		}
+		realm := r.BasicAuth.Realm
+		if realm == "" {
+			realm = defaultRealm
+		}
+		ingress.Annotations["nginx.ingress.kubernetes.io/auth-type"] = "basic"
+		ingress.Annotations["nginx.ingress.kubernetes.io/auth-secret"] = authSecretName(r.Name)
+		ingress.Annotations["nginx.ingress.kubernetes.io/auth-realm"] = realm
+	}
+	k8s.SetObjectMetadata(r.Metadata, ingress)
+	return ingress
+}
+
+// authSecretName is the htpasswd Secret of the module's basic-auth users
+func authSecretName(module string) string {
+	return module + "-basic-auth"
+}
+
+// AuthSecret returns the htpasswd Secret of the basic-auth users, or nil
+// without basic-auth. Passwords are hashed with a new salt on every call.
+func (r *Route) AuthSecret() (*corev1.Secret, error) {
+	if r.BasicAuth == nil {
+		return nil, nil
+	}
+	htpasswd, err := k8s.GenerateHtpasswd(r.BasicAuth.Users)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate htpasswd for %s: %w", r.Name, err)
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      authSecretName(r.Name),
+			Namespace: r.Namespace,
+			Labels:    r.Labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"auth": htpasswd,
+		},
+	}
+	k8s.SetObjectMetadata(r.Metadata, secret)
+	return secret, nil
+}
+
+// Objects returns what Generate writes for the Route: the basic-auth Secret,
+// if any, and the Ingress
+func (r *Route) Objects() ([]Object, error) {
+	secret, err := r.AuthSecret()
+	if err != nil {
+		return nil, err
+	}
+	var objects []Object
+	if secret != nil {
+		objects = append(objects, Object{File: "basic-auth-secret", Object: secret})
+	}
+	return append(objects, Object{File: "ingress", Object: r.Ingress()}), nil
+}
+
+// owned reports whether an Ingress or basic-auth Secret is a module's rather
+// than one of the ingresses section, which may have the same name: only the
+// former carry the app label of the module's Service
+func owned(object metav1.Object) bool {
+	return object.GetLabels()["app"] != ""
+}
+
+// Apply creates the basic-auth Secret and the Ingress, replacing existing
+// ones of the module. It fails when an Ingress or Secret of the ingresses
+// section has the name of the module's.
+func (r *Route) Apply(ctx context.Context, client kubernetes.Interface) error {
+	secret, err := r.AuthSecret()
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		if err := r.applySecret(ctx, client, secret); err != nil {
+			return err
+		}
+	}
+
+	ingress := r.Ingress()
+	ingresses := client.NetworkingV1().Ingresses(r.Namespace)
+	existing, err := ingresses.Get(ctx, r.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = ingresses.Create(ctx, ingress, metav1.CreateOptions{})
+	} else if err == nil {
+		if !owned(existing) {
+			return fmt.Errorf("ingress %s in namespace %s is not the module's; rename the ingresses entry", r.Name, r.Namespace)
+		}
+		ingress.ResourceVersion = existing.ResourceVersion
+		_, err = ingresses.Update(ctx, ingress, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply Ingress %s: %w", r.Name, err)
+	}
+	return nil
+}
+
+// applySecret creates or replaces the basic-auth Secret ahead of the Ingress
+// that references it
+func (r *Route) applySecret(ctx context.Context, client kubernetes.Interface, secret *corev1.Secret) error {
+	secrets := client.CoreV1().Secrets(r.Namespace)
+	existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	} else if err == nil {
+		if !owned(existing) {
+			return fmt.Errorf("secret %s in namespace %s is not the module's; rename the ingresses entry", secret.Name, r.Namespace)
+		}
+		secret.ResourceVersion = existing.ResourceVersion
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply basic-auth Secret %s: %w", secret.Name, err)
+	}
+	return nil
+}
+
+// Delete removes the module's Ingress and basic-auth Secret and reports
+// whether there was an Ingress, so Clean stays quiet about modules that were
+// never exposed. Objects of the ingresses section with the names of the
+// module's are left alone.
+func Delete(ctx context.Context, client kubernetes.Interface, module config.Module) (bool, error) {
+	secretName := authSecretName(module.Name)
+	secrets := client.CoreV1().Secrets(module.Namespace)
+	if secret, err := secrets.Get(ctx, secretName, metav1.GetOptions{}); err == nil && owned(secret) {
+		if err := secrets.Delete(ctx, secretName, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete basic-auth Secret %s: %w", secretName, err)
+		}
+	}
+
+	ingresses := client.NetworkingV1().Ingresses(module.Namespace)
+	existing, err := ingresses.Get(ctx, module.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err == nil && !owned(existing) {
+		return false, nil
+	}
+	if err == nil {
+		err = ingresses.Delete(ctx, module.Name, metav1.DeleteOptions{})
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete Ingress %s: %w", module.Name, err)
+	}
+	return true, nil
+}
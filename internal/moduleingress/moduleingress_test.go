@@ -0,0 +1,173 @@
+package moduleingress
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra", Labels: map[string]string{"app": "gitea"}},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{
+			{Name: "ssh", Port: 22},
+			{Name: "http", Port: 3000},
+		}},
+	}
+}
+
+func TestConfig(t *testing.T) {
+	general := config.GeneralConfig{Domain: "example.com"}
+	module := config.Module{Name: "gitea", Namespace: "infra"}
+	if route := Config(module, general, testService(), false); route != nil {
+		t.Fatalf("Config() = %+v without an ingress section, want nil", route)
+	}
+
+	module.Ingress = &config.ModuleIngress{}
+	route := Config(module, general, testService(), false)
+	if route == nil {
+		t.Fatal("Config() = nil, want a route")
+	}
+	if route.Host != "gitea.example.com" || route.Path != "/" || route.PathType != networkingv1.PathTypePrefix {
+		t.Errorf("route = %+v, want gitea.example.com, / and Prefix", route)
+	}
+	if route.Service != "gitea" || route.Port.Number != 3000 {
+		t.Errorf("backend = %s:%d, want the http port gitea:3000", route.Service, route.Port.Number)
+	}
+
+	module.Ingress = &config.ModuleIngress{Host: "git.example.org", Path: "/git", PathType: "Exact"}
+	route = Config(module, general, testService(), false)
+	if route.Host != "git.example.org" || route.Path != "/git" || route.PathType != networkingv1.PathTypeExact {
+		t.Errorf("route = %+v, want the configured host, path and path type", route)
+	}
+	if route.TLSSecret != "" {
+		t.Errorf("TLSSecret = %q without ingressTLS, want plain HTTP", route.TLSSecret)
+	}
+}
+
+func TestRoute_IngressTLS(t *testing.T) {
+	general := config.GeneralConfig{Domain: "example.com", IngressTLS: config.IngressTLSConfig{Enabled: true}}
+	module := config.Module{Name: "gitea", Namespace: "infra", Ingress: &config.ModuleIngress{}}
+	ingress := Config(module, general, testService(), false).Ingress()
+
+	if len(ingress.Spec.TLS) != 1 || ingress.Spec.TLS[0].SecretName != "gitea-tls" || ingress.Spec.TLS[0].Hosts[0] != "gitea.example.com" {
+		t.Errorf("TLS = %+v, want gitea.example.com in gitea-tls", ingress.Spec.TLS)
+	}
+	if ingress.Annotations[clusterIssuerAnnotation] != "letsencrypt" {
+		t.Errorf("annotations = %v, want the letsencrypt ClusterIssuer", ingress.Annotations)
+	}
+
+	plain := false
+	module.Ingress.TLS = &plain
+	if ingress := Config(module, general, testService(), false).Ingress(); len(ingress.Spec.TLS) > 0 || ingress.Annotations[clusterIssuerAnnotation] != "" {
+		t.Errorf("Ingress = %+v with ingress.tls false, want plain HTTP", ingress)
+	}
+}
+
+func TestRoute_Ingress(t *testing.T) {
+	module := config.Module{Name: "jupyter", Namespace: "dev", Ingress: &config.ModuleIngress{}}
+	ingress := Config(module, config.GeneralConfig{Domain: "example.com"}, testService(), true).Ingress()
+
+	if ingress.Name != "jupyter" || ingress.Namespace != "dev" {
+		t.Errorf("Ingress = %s/%s, want dev/jupyter", ingress.Namespace, ingress.Name)
+	}
+	if ingress.Labels["app"] != "gitea" || ingress.Labels["managed-by"] != "personal-server" {
+		t.Errorf("labels = %v, want the Service's and managed-by", ingress.Labels)
+	}
+	if ingress.Annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] != webSocketTimeout {
+		t.Errorf("annotations = %v, want WebSocket timeouts", ingress.Annotations)
+	}
+	rule := ingress.Spec.Rules[0]
+	if rule.Host != "jupyter.example.com" || rule.HTTP.Paths[0].Backend.Service.Port.Number != 3000 {
+		t.Errorf("rule = %+v, want jupyter.example.com to port 3000", rule)
+	}
+}
+
+func TestRoute_ApplyAndDelete(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	module := config.Module{Name: "gitea", Namespace: "infra", Ingress: &config.ModuleIngress{}}
+	route := Config(module, config.GeneralConfig{Domain: "example.com"}, testService(), false)
+
+	for i := 0; i < 2; i++ {
+		if err := route.Apply(ctx, client); err != nil {
+			t.Fatalf("Apply() #%d error = %v", i+1, err)
+		}
+	}
+	if _, err := client.NetworkingV1().Ingresses("infra").Get(ctx, "gitea", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Ingress not found: %v", err)
+	}
+
+	if deleted, err := Delete(ctx, client, module); err != nil || !deleted {
+		t.Fatalf("Delete() = %v, %v, want true", deleted, err)
+	}
+	if deleted, err := Delete(ctx, client, module); err != nil || deleted {
+		t.Errorf("Delete() of a missing Ingress = %v, %v, want false", deleted, err)
+	}
+}
+
+func TestRoute_LeavesIngressesSection(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+		Name: "gitea", Namespace: "infra", Labels: map[string]string{"managed-by": "personal-server"},
+	}})
+	module := config.Module{Name: "gitea", Namespace: "infra", Ingress: &config.ModuleIngress{}}
+	route := Config(module, config.GeneralConfig{Domain: "example.com"}, testService(), false)
+
+	if err := route.Apply(ctx, client); err == nil {
+		t.Error("Apply() replaced an Ingress of the ingresses section")
+	}
+	if deleted, err := Delete(ctx, client, module); err != nil || deleted {
+		t.Errorf("Delete() = %v, %v, want the Ingress kept", deleted, err)
+	}
+	if _, err := client.NetworkingV1().Ingresses("infra").Get(ctx, "gitea", metav1.GetOptions{}); err != nil {
+		t.Errorf("Ingress of the ingresses section is gone: %v", err)
+	}
+}
+
+func TestRoute_BasicAuth(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	module := config.Module{Name: "staticsite", Namespace: "web", Ingress: &config.ModuleIngress{
+		BasicAuth: &config.IngressBasicAuth{Users: map[string]string{"admin": "secret"}},
+	}}
+	route := Config(module, config.GeneralConfig{Domain: "example.com"}, testService(), false)
+
+	objects, err := route.Objects()
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+	if len(objects) != 2 || objects[0].File != "basic-auth-secret" || objects[1].File != "ingress" {
+		t.Fatalf("Objects() = %+v, want the basic-auth Secret and the Ingress", objects)
+	}
+	annotations := objects[1].Object.(*networkingv1.Ingress).Annotations
+	if annotations["nginx.ingress.kubernetes.io/auth-secret"] != "staticsite-basic-auth" || annotations["nginx.ingress.kubernetes.io/auth-realm"] != defaultRealm {
+		t.Errorf("annotations = %v, want basic-auth with staticsite-basic-auth", annotations)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := route.Apply(ctx, client); err != nil {
+			t.Fatalf("Apply() #%d error = %v", i+1, err)
+		}
+	}
+	secret, err := client.CoreV1().Secrets("web").Get(ctx, "staticsite-basic-auth", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("basic-auth Secret not found: %v", err)
+	}
+	if !strings.HasPrefix(secret.StringData["auth"], "admin:$apr1$") {
+		t.Errorf("auth = %q, want an htpasswd line for admin", secret.StringData["auth"])
+	}
+
+	if deleted, err := Delete(ctx, client, module); err != nil || !deleted {
+		t.Fatalf("Delete() = %v, %v, want true", deleted, err)
+	}
+	if _, err := client.CoreV1().Secrets("web").Get(ctx, "staticsite-basic-auth", metav1.GetOptions{}); err == nil {
+		t.Error("basic-auth Secret still exists after Delete()")
+	}
+}
@@ -0,0 +1,791 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage          = "prom/alertmanager:v0.27.0"
+	defaultStorageSize    = "1Gi"
+	defaultRepeatInterval = "4h"
+	containerPort         = 9093
+	// secretsPath is where alertmanager-secrets is mounted; the receivers
+	// read the bot token and SMTP password from files there
+	secretsPath = "/etc/alertmanager/secrets"
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "128Mi"}
+
+type AlertmanagerModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *AlertmanagerModule {
+	return &AlertmanagerModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *AlertmanagerModule) Name() string {
+	return "alertmanager"
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *AlertmanagerModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// Endpoint returns the host:port Prometheus sends alerts to
+func (m *AlertmanagerModule) Endpoint() string {
+	return fmt.Sprintf("alertmanager.%s.svc.cluster.local:%d", m.ModuleConfig.Namespace, containerPort)
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	TelegramBotToken string `yaml:"telegram_bot_token" doc:"Telegram bot token; with telegram_chat_id enables the Telegram receiver"`
+	TelegramChatID   string `yaml:"telegram_chat_id" doc:"Numeric Telegram chat ID alerts are sent to"`
+	EmailTo          string `yaml:"email_to" doc:"Address alerts are mailed to; enables the email receiver"`
+	EmailFrom        string `yaml:"email_from" doc:"Sender address of alert emails"`
+	SMTPSmarthost    string `yaml:"smtp_smarthost" doc:"SMTP server as host:port"`
+	SMTPUsername     string `yaml:"smtp_username" doc:"SMTP login (default: email_from)"`
+	SMTPPassword     string `yaml:"smtp_password" doc:"SMTP password"`
+	RepeatInterval   string `yaml:"repeat_interval" default:"4h" doc:"How often a still-firing alert is sent again"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *AlertmanagerModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *AlertmanagerModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: alertmanager\n\n")
+	m.log.Info("Description:\n  Deploys Alertmanager, which routes the prometheus module's alerts to\n  Telegram and/or email. Manages a Secret, ConfigMap, PersistentVolumeClaim,\n  Service, and Deployment. Prometheus sends alerts here automatically when\n  both modules are configured.\n\n")
+	m.log.Info("Receivers (at least one is required, modules[].secrets):\n  telegram_bot_token, telegram_chat_id   Telegram bot and numeric chat ID\n  email_to, email_from, smtp_smarthost   Email receiver (smtp_smarthost as host:port)\n  smtp_username, smtp_password           SMTP login (username defaults to email_from)\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  repeat_interval   Resend interval of firing alerts (default: %s)\n  image             Container image (modules[].image, default: %s)\n  ingress           Expose the web interface at <name>.<general.domain> (modules[].ingress)\n  storage           Size of the data volume (modules[].storage, default: %s)\n\n", defaultRepeatInterval, defaultImage, defaultStorageSize)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/alertmanager/\n  apply      Create/update resources in the cluster\n  clean      Delete all Alertmanager resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
+	return nil
+}
+
+func (m *AlertmanagerModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "alertmanager")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating Alertmanager Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, configMap, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(configMap, "configmap"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+	total := 5
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Alertmanager configurations generated successfully\n", total, total)
+	return nil
+}
+
+func (m *AlertmanagerModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying Alertmanager Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	secret, configMap, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'", secret.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, configMap.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("ConfigMap '%s' already exists in namespace '%s'", configMap.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check ConfigMap existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", pvc.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", service.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: %s\n", secret.Name)
+
+	if _, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create ConfigMap: %w", err)
+	}
+	m.log.Success("Created ConfigMap: %s\n", configMap.Name)
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: %s\n", pvc.Name)
+
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", service.Name)
+
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", deployment.Name)
+
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: Alertmanager configurations applied successfully\n")
+	return nil
+}
+
+// alertmanagerConfig builds alertmanager.yml with a single "default" receiver
+// holding the configured Telegram and email integrations. Credentials are
+// referenced as files under secretsPath so the ConfigMap holds no secrets
+func (m *AlertmanagerModule) alertmanagerConfig() (string, map[string][]byte, error) {
+	secrets := m.ModuleConfig.Secrets
+	secretData := map[string][]byte{}
+	global := map[string]interface{}{}
+	receiver := map[string]interface{}{"name": "default"}
+
+	botToken := k8s.GetSecretOrDefault(secrets, "telegram_bot_token", "")
+	chatIDValue := k8s.GetSecretOrDefault(secrets, "telegram_chat_id", "")
+	if (botToken == "") != (chatIDValue == "") {
+		return "", nil, fmt.Errorf("telegram_bot_token and telegram_chat_id must be set together")
+	}
+	if botToken != "" {
+		chatID, err := strconv.ParseInt(chatIDValue, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid telegram_chat_id %q: must be a numeric chat ID", chatIDValue)
+		}
+		secretData["telegram-bot-token"] = []byte(botToken)
+		receiver["telegram_configs"] = []map[string]interface{}{{
+			"bot_token_file": secretsPath + "/telegram-bot-token",
+			"chat_id":        chatID,
+			"parse_mode":     "HTML",
+			"send_resolved":  true,
+		}}
+	}
+
+	if emailTo := k8s.GetSecretOrDefault(secrets, "email_to", ""); emailTo != "" {
+		emailFrom := k8s.GetSecretOrDefault(secrets, "email_from", "")
+		smarthost := k8s.GetSecretOrDefault(secrets, "smtp_smarthost", "")
+		if emailFrom == "" || smarthost == "" {
+			return "", nil, fmt.Errorf("email_to requires email_from and smtp_smarthost")
+		}
+		global["smtp_from"] = emailFrom
+		global["smtp_smarthost"] = smarthost
+		global["smtp_require_tls"] = true
+		if password := k8s.GetSecretOrDefault(secrets, "smtp_password", ""); password != "" {
+			secretData["smtp-password"] = []byte(password)
+			global["smtp_auth_username"] = k8s.GetSecretOrDefault(secrets, "smtp_username", emailFrom)
+			global["smtp_auth_password_file"] = secretsPath + "/smtp-password"
+		}
+		receiver["email_configs"] = []map[string]interface{}{{
+			"to":            emailTo,
+			"send_resolved": true,
+		}}
+	}
+
+	if len(secretData) == 0 {
+		return "", nil, fmt.Errorf("no receiver configured: set telegram_bot_token and telegram_chat_id, or email_to")
+	}
+
+	alertmanagerConfig := map[string]interface{}{
+		"global": global,
+		"route": map[string]interface{}{
+			"receiver":        "default",
+			"group_by":        []string{"alertname", "namespace"},
+			"group_wait":      "30s",
+			"group_interval":  "5m",
+			"repeat_interval": k8s.GetSecretOrDefault(secrets, "repeat_interval", defaultRepeatInterval),
+		},
+		// a critical alert silences the warnings for the same problem
+		"inhibit_rules": []map[string]interface{}{{
+			"source_matchers": []string{`severity="critical"`},
+			"target_matchers": []string{`severity="warning"`},
+			"equal":           []string{"alertname", "namespace"},
+		}},
+		"receivers": []map[string]interface{}{receiver},
+	}
+
+	// marshalled directly: a JSON round trip would turn large chat IDs into floats
+	yamlContent, err := yaml.Marshal(alertmanagerConfig)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert alertmanager.yml to YAML: %w", err)
+	}
+	return string(yamlContent), secretData, nil
+}
+
+// prepare creates and returns the Kubernetes objects for the alertmanager module
+func (m *AlertmanagerModule) prepare() (*corev1.Secret, *corev1.ConfigMap, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	alertmanagerConfig, secretData, err := m.alertmanagerConfig()
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	labels := map[string]string{
+		"app":        "alertmanager",
+		"managed-by": "personal-server",
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alertmanager-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: secretData,
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alertmanager-config",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"alertmanager.yml": alertmanagerConfig,
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alertmanager-data-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alertmanager",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       containerPort,
+					TargetPort: intstr.FromInt(containerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "alertmanager",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	replicas := int32(1)
+	// the image runs as nobody, which must be able to write the data volume
+	fsGroup := int64(65534)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alertmanager",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "alertmanager",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "alertmanager",
+					},
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						FSGroup: &fsGroup,
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            "alertmanager",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Args: []string{
+								"--config.file=/etc/alertmanager/alertmanager.yml",
+								"--storage.path=/alertmanager",
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: containerPort,
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/-/healthy",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 15,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/-/ready",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "config",
+									MountPath: "/etc/alertmanager/alertmanager.yml",
+									SubPath:   "alertmanager.yml",
+								},
+								{
+									Name:      "secrets",
+									MountPath: secretsPath,
+									ReadOnly:  true,
+								},
+								{
+									Name:      "data",
+									MountPath: "/alertmanager",
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "alertmanager-config"},
+								},
+							},
+						},
+						{
+							Name: "secrets",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: "alertmanager-secrets",
+								},
+							},
+						},
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "alertmanager-data-pvc",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret, configMap); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, configMap, pvc, service, deployment)
+	return secret, configMap, pvc, service, deployment, nil
+}
+
+func (m *AlertmanagerModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning Alertmanager Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Processing Deployment: alertmanager\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "alertmanager", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'alertmanager' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: alertmanager\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Service: alertmanager\n")
+	if err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Delete(ctx, "alertmanager", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service 'alertmanager' not found\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: alertmanager\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: alertmanager-data-pvc\n")
+	if err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Delete(ctx, "alertmanager-data-pvc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'alertmanager-data-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: alertmanager-data-pvc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing ConfigMap: alertmanager-config\n")
+	if err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Delete(ctx, "alertmanager-config", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("ConfigMap 'alertmanager-config' not found\n")
+		} else {
+			m.log.Error("Failed to delete ConfigMap: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted ConfigMap: alertmanager-config\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Secret: alertmanager-secrets\n")
+	if err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Delete(ctx, "alertmanager-secrets", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'alertmanager-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: alertmanager-secrets\n")
+		successCount++
+	}
+
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
+	m.log.Info("\nCompleted: %d Alertmanager resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *AlertmanagerModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking Alertmanager resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "alertmanager", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'alertmanager' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	service, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "alertmanager", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service 'alertmanager' not found\n")
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, "alertmanager-data-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'alertmanager-data-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, "alertmanager-config", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("ConfigMap 'alertmanager-config' not found\n")
+		} else {
+			m.log.Error("Error getting ConfigMap: %v\n", err)
+		}
+	} else {
+		age := time.Since(configMap.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("CONFIGMAP:\n")
+		m.log.Info("  Name:            %s\n", configMap.Name)
+		m.log.Info("  Data keys:       %d\n", len(configMap.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	secret, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "alertmanager-secrets", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Secret 'alertmanager-secrets' not found\n")
+		} else {
+			m.log.Error("Error getting Secret: %v\n", err)
+		}
+	} else {
+		age := time.Since(secret.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SECRET:\n")
+		m.log.Info("  Name:            %s\n", secret.Name)
+		m.log.Info("  Type:            %s\n", secret.Type)
+		m.log.Info("  Data keys:       %d\n", len(secret.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=alertmanager",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No Alertmanager pods found")
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package alertmanager
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+func TestAlertmanagerModule_Name(t *testing.T) {
+	module := &AlertmanagerModule{}
+	if module.Name() != "alertmanager" {
+		t.Errorf("Name() = %s, want alertmanager", module.Name())
+	}
+}
+
+func TestAlertmanagerModule_Doc(t *testing.T) {
+	module := &AlertmanagerModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestAlertmanagerModule_Endpoint(t *testing.T) {
+	module := &AlertmanagerModule{ModuleConfig: config.Module{Namespace: "monitoring"}}
+	if got := module.Endpoint(); got != "alertmanager.monitoring.svc.cluster.local:9093" {
+		t.Errorf("Endpoint() = %s", got)
+	}
+}
+
+func TestAlertmanagerModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name        string
+		secrets     map[string]string
+		wantKeys    []string
+		wantConfig  []string
+		wantMissing []string
+		wantErr     string
+	}{
+		{
+			name:        "telegram receiver",
+			secrets:     map[string]string{"telegram_bot_token": "123:abc", "telegram_chat_id": "-100200300"},
+			wantKeys:    []string{"telegram-bot-token"},
+			wantConfig:  []string{"bot_token_file: /etc/alertmanager/secrets/telegram-bot-token", "chat_id: -100200300", "repeat_interval: 4h"},
+			wantMissing: []string{"email_configs", "123:abc"},
+		},
+		{
+			name: "email receiver with login",
+			secrets: map[string]string{
+				"email_to": "me@example.com", "email_from": "alerts@example.com", "smtp_smarthost": "smtp.example.com:587",
+				"smtp_password": "hunter2", "repeat_interval": "12h",
+			},
+			wantKeys:    []string{"smtp-password"},
+			wantConfig:  []string{"to: me@example.com", "smtp_auth_username: alerts@example.com", "smtp_auth_password_file: /etc/alertmanager/secrets/smtp-password", "repeat_interval: 12h"},
+			wantMissing: []string{"telegram_configs", "hunter2"},
+		},
+		{
+			name:    "no receiver",
+			wantErr: "no receiver configured",
+		},
+		{
+			name:    "token without chat",
+			secrets: map[string]string{"telegram_bot_token": "123:abc"},
+			wantErr: "must be set together",
+		},
+		{
+			name:    "chat username",
+			secrets: map[string]string{"telegram_bot_token": "123:abc", "telegram_chat_id": "@me"},
+			wantErr: "numeric chat ID",
+		},
+		{
+			name:    "email without smarthost",
+			secrets: map[string]string{"email_to": "me@example.com", "email_from": "alerts@example.com"},
+			wantErr: "smtp_smarthost",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &AlertmanagerModule{
+				ModuleConfig: config.Module{Name: "alertmanager", Namespace: "monitoring", Secrets: tt.secrets},
+				log:          logger.NewNopLogger(),
+			}
+
+			secret, configMap, _, _, deployment, err := module.prepare()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("prepare() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
+
+			if len(secret.Data) != len(tt.wantKeys) {
+				t.Errorf("secret keys = %d, want %v", len(secret.Data), tt.wantKeys)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := secret.Data[key]; !ok {
+					t.Errorf("secret missing key %s", key)
+				}
+			}
+			alertmanagerConfig := configMap.Data["alertmanager.yml"]
+			for _, want := range tt.wantConfig {
+				if !strings.Contains(alertmanagerConfig, want) {
+					t.Errorf("alertmanager.yml missing %q:\n%s", want, alertmanagerConfig)
+				}
+			}
+			for _, unwanted := range tt.wantMissing {
+				if strings.Contains(alertmanagerConfig, unwanted) {
+					t.Errorf("alertmanager.yml contains %q:\n%s", unwanted, alertmanagerConfig)
+				}
+			}
+			if deployment.Spec.Template.Annotations[k8s.ConfigChecksumAnnotation] == "" {
+				t.Error("pod template has no config checksum")
+			}
+		})
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/configmap.yaml
+var expectedConfigMapYAML string
+
+//go:embed testdata/pvc.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := &AlertmanagerModule{
+		ModuleConfig: config.Module{
+			Name:      "alertmanager",
+			Namespace: "monitoring",
+			Secrets: map[string]string{
+				"telegram_bot_token": "123456:token",
+				"telegram_chat_id":   "42",
+				"email_to":           "admin@example.com",
+				"email_from":         "alerts@example.com",
+				"smtp_smarthost":     "smtp.example.com:587",
+				"smtp_password":      "password",
+			},
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/alertmanager/secret.yaml", expectedSecretYAML},
+		{"configmap", "configs/alertmanager/configmap.yaml", expectedConfigMapYAML},
+		{"pvc", "configs/alertmanager/pvc.yaml", expectedPvcYAML},
+		{"service", "configs/alertmanager/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/alertmanager/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
@@ -4,24 +4,37 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
 
-	"os/exec"
 	"strings"
 
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "64Mi", MemoryLimit: "256Mi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "100Mi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "vaultwarden/server:1.32.0"
+
 type BitwardenModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -40,14 +53,38 @@ func (m *BitwardenModule) Name() string {
 	return "bitwarden"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *BitwardenModule) SupportsServiceMonitor() bool {
+	return true
+}
+
 func (m *BitwardenModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: bitwarden\n\n")
 	m.log.Info("Description:\n  Deploys Vaultwarden (Bitwarden-compatible) password manager.\n  Manages a Deployment, Service, and PersistentVolumeClaim.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  (none — no secrets required)\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  mail_from   Sender of emails sent through the smtp module (default: vaultwarden@<general.domain>)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/bitwarden/\n  apply      Create/update resources in the cluster\n  clean      Delete all Bitwarden resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive /data volume to the destination directory\n  restore    Restore /data volume from a backup archive\n")
 	return nil
 }
 
+// smtpEnv points Vaultwarden at the smtp module's relay when one is
+// configured, enabling invitations and password hints by email. The relay
+// accepts cluster traffic without TLS or a login.
+func (m *BitwardenModule) smtpEnv() []corev1.EnvVar {
+	host, port, err := net.SplitHostPort(m.GeneralConfig.Endpoint("smtp", ""))
+	if err != nil {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "SMTP_HOST", Value: host},
+		{Name: "SMTP_PORT", Value: port},
+		{Name: "SMTP_SECURITY", Value: "off"},
+		{Name: "SMTP_FROM", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "mail_from", "vaultwarden@"+m.GeneralConfig.Domain)},
+	}
+}
+
 func (m *BitwardenModule) Generate(ctx context.Context) error {
 	// Define output directory
 	outputDir := filepath.Join("configs", "bitwarden")
@@ -61,7 +98,10 @@ func (m *BitwardenModule) Generate(ctx context.Context) error {
 	m.log.Info("Output directory: %s\n\n", outputDir)
 
 	// Prepare Kubernetes objects
-	pvc, service, deployment := m.prepare()
+	pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -95,14 +135,37 @@ func (m *BitwardenModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 3
 
-	m.log.Info("\nCompleted: 3/3 Bitwarden configurations generated successfully\n")
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Bitwarden configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *BitwardenModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -136,7 +199,10 @@ func (m *BitwardenModule) Apply(ctx context.Context) error {
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
-	pvc, service, deployment := m.prepare()
+	pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Apply PersistentVolumeClaim
 	m.log.Progress("Applying PersistentVolumeClaim: bitwarden-claim0\n")
@@ -162,14 +228,39 @@ func (m *BitwardenModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: %s\n", createdDeployment.Name)
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: Bitwarden configurations applied successfully\n")
 	return nil
 }
 
 // prepare creates and returns the Kubernetes objects for bitwarden module
-func (m *BitwardenModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment) {
+func (m *BitwardenModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
 	// Prepare PersistentVolumeClaim
-	storageQuantity := resource.MustParse("100Mi")
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "bitwarden-claim0",
@@ -185,11 +276,12 @@ func (m *BitwardenModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Serv
 			},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: storageQuantity,
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -223,8 +315,10 @@ func (m *BitwardenModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Serv
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
+	image := m.ModuleConfig.ContainerImage(defaultImage)
 	replicas := int32(1)
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -254,14 +348,14 @@ func (m *BitwardenModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Serv
 					Containers: []corev1.Container{
 						{
 							Name:            "bitwarden",
-							Image:           "vaultwarden/server:1.32.0",
-							ImagePullPolicy: k8s.DefaultImagePullPolicy("vaultwarden/server:1.32.0"),
-							Env: []corev1.EnvVar{
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
+							Env: append([]corev1.EnvVar{
 								{
 									Name:  "WEBSOCKET_ENABLED",
 									Value: "true",
 								},
-							},
+							}, m.smtpEnv()...),
 							Ports: []corev1.ContainerPort{
 								{
 									ContainerPort: 80,
@@ -270,6 +364,26 @@ func (m *BitwardenModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Serv
 									ContainerPort: 3012,
 								},
 							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/alive",
+										Port: intstr.FromInt(80),
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/alive",
+										Port: intstr.FromInt(80),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "bitwarden-claim0",
@@ -293,12 +407,27 @@ func (m *BitwardenModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Serv
 		},
 	}
 
-	return pvc, service, deployment
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), pvc, service, deployment)
+	return pvc, service, deployment, nil
 }
 
 func (m *BitwardenModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -355,6 +484,22 @@ func (m *BitwardenModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d/3 bitwarden resources deleted successfully\n", successCount)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -365,7 +510,7 @@ func (m *BitwardenModule) Clean(ctx context.Context) error {
 
 func (m *BitwardenModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -475,13 +620,30 @@ func (m *BitwardenModule) Status(ctx context.Context) error {
 	return nil
 }
 
+// findPod returns the name of the bitwarden pod exec commands run in
+func (m *BitwardenModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=bitwarden",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=bitwarden")
+	}
+	return pods.Items[0].Name, nil
+}
+
 func (m *BitwardenModule) Backup(ctx context.Context, destDir string) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
 
+func (m *BitwardenModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
 	var backupDir string
 	if destDir != "" {
@@ -501,46 +663,28 @@ func (m *BitwardenModule) Backup(ctx context.Context, destDir string) error {
 	// 1. Backup data volume
 	m.log.Info("💾 Backing up Bitwarden data (/data)...\n")
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=bitwarden",
-	})
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=bitwarden")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("bitwarden_data_%s.tar.gz", timestamp))
 
-	// Execute tar command in pod and stream to file
-	// We need to use kubectl exec logic here.
-
-	// Let's try to find kubectl or microk8s kubectl
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
-	}
-
-	// Construct command: kubectl exec -n <ns> <pod> -- tar czf - /data
-	cmdStr := fmt.Sprintf("%s exec -n %s %s -- tar czf - /data", kubectlCmd, m.ModuleConfig.Namespace, podName)
-
-	cmdParts := strings.Fields(cmdStr)
-	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
-
 	outFile, err := os.Create(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to create data backup file: %w", err)
 	}
 	defer outFile.Close()
 
-	cmd.Stdout = outFile
-	cmd.Stderr = os.Stderr // pipe stderr to see errors
-
-	if err := cmd.Run(); err != nil {
+	// Execute tar command in pod and stream to file
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "/data"},
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to archive data: %w", err)
 	}
 
@@ -548,7 +692,7 @@ func (m *BitwardenModule) Backup(ctx context.Context, destDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to stat data backup file: %w", err)
 	}
-	m.log.Success("✅ Data archived (%d bytes)\n", fileInfo.Size())
+	m.log.Success("Data archived (%d bytes)\n", fileInfo.Size())
 
 	// 2. Metadata
 	m.log.Info("📋 Writing metadata...\n")
@@ -571,7 +715,7 @@ personal-server bitwarden restore %s
 	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
-	m.log.Success("✅ Metadata written\n")
+	m.log.Success("Metadata written\n")
 
 	m.log.Success("🎉 Backup complete!\n")
 	m.log.Info("💡 To restore: personal-server bitwarden restore %s\n", timestamp)
@@ -631,48 +775,49 @@ func (m *BitwardenModule) Restore(ctx context.Context, args []string) error {
 	m.log.Info("💾 Data will be restored from %s\n", dataBackupFile)
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	if err := m.restoreDataWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dataBackupFile); err != nil {
+		return err
+	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=bitwarden",
-	})
-	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+	// Restart deployment
+	m.log.Info("🔄 Restarting deployment 'bitwarden'...\n")
+	if err := k8s.RestartDeployment(ctx, clientset, m.ModuleConfig.Namespace, "bitwarden", time.Now()); err != nil {
+		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
+	} else {
+		m.log.Success("Deployment restarted successfully\n")
 	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=bitwarden")
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreDataWithClient replaces /data in the bitwarden pod with the contents
+// of a tar archive written by Backup
+func (m *BitwardenModule) restoreDataWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dataBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	// Restore data
 	m.log.Info("💾 Restoring data...\n")
 
-	// Let's try to find kubectl or microk8s kubectl
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
-	}
-
 	// 1. Clean existing data
-	// kubectl exec -n <ns> <pod> -- rm -rf /data/*
-	cleanCmdStr := fmt.Sprintf("%s exec -n %s %s -- rm -rf /data/*", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	cleanCmdParts := strings.Fields(cleanCmdStr)
-	cleanCmd := exec.CommandContext(ctx, cleanCmdParts[0], cleanCmdParts[1:]...)
-	if err := cleanCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", "rm -rf /data/*"},
+	}); err != nil {
 		// Ignore error if directory is already empty or other minor issues, but log it
 		m.log.Warn("Warning during clean: %v\n", err)
 	}
 
 	// 2. Restore from tar
-	// cat <file> | kubectl exec -i -n <ns> <pod> -- tar xzf - -C /
-	restoreCmdStr := fmt.Sprintf("%s exec -i -n %s %s -- tar xzf - -C /", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	restoreCmdParts := strings.Fields(restoreCmdStr)
-	restoreCmd := exec.CommandContext(ctx, restoreCmdParts[0], restoreCmdParts[1:]...)
 
 	inFile, err := os.Open(dataBackupFile)
 	if err != nil {
@@ -680,32 +825,16 @@ func (m *BitwardenModule) Restore(ctx context.Context, args []string) error {
 	}
 	defer inFile.Close()
 
-	restoreCmd.Stdin = inFile
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", "/"},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore data: %w", err)
 	}
-	m.log.Success("✅ Data restored\n")
-
-	// Restart deployment
-	m.log.Info("🔄 Restarting deployment 'bitwarden'...\n")
-
-	// We can use rollout restart via kubectl or just delete the pod to force restart if replicas=1
-	// Or use scale down/up.
-	// The script used `rollout restart`.
-	// Let's use `kubectl rollout restart` for simplicity and consistency with script logic
-	restartCmdStr := fmt.Sprintf("%s rollout restart deployment/bitwarden -n %s", kubectlCmd, m.ModuleConfig.Namespace)
-	restartCmdParts := strings.Fields(restartCmdStr)
-	restartCmd := exec.CommandContext(ctx, restartCmdParts[0], restartCmdParts[1:]...)
-
-	if err := restartCmd.Run(); err != nil {
-		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
-	} else {
-		m.log.Success("✅ Deployment restarted successfully\n")
-	}
-
-	m.log.Success("🎉 Restore complete!\n")
+	m.log.Success("Data restored\n")
 	return nil
 }
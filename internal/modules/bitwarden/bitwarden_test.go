@@ -13,6 +13,8 @@ import (
 	"github.com/Goalt/personal-server/internal/logger"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestBitwardenModule_Name(t *testing.T) {
@@ -53,7 +55,10 @@ func TestBitwardenModule_Prepare(t *testing.T) {
 				},
 			}
 
-			pvc, service, deployment := module.prepare()
+			pvc, service, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			// Verify all objects are not nil
 			if pvc == nil {
@@ -91,7 +96,10 @@ func TestBitwardenModule_PreparePVC(t *testing.T) {
 		},
 	}
 
-	pvc, _, _ := module.prepare()
+	pvc, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test PVC name
 	if pvc.Name != "bitwarden-claim0" {
@@ -138,7 +146,10 @@ func TestBitwardenModule_PrepareService(t *testing.T) {
 		},
 	}
 
-	_, service, _ := module.prepare()
+	_, service, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Service name
 	if service.Name != "bitwarden" {
@@ -227,7 +238,10 @@ func TestBitwardenModule_PrepareDeployment(t *testing.T) {
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Deployment name
 	if deployment.Name != "bitwarden" {
@@ -293,7 +307,10 @@ func TestBitwardenModule_PrepareDeploymentContainer(t *testing.T) {
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify container count
 	if len(deployment.Spec.Template.Spec.Containers) != 1 {
@@ -371,6 +388,55 @@ func TestBitwardenModule_PrepareDeploymentContainer(t *testing.T) {
 	}
 }
 
+func TestBitwardenModule_SMTP(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints map[string]string
+		secrets   map[string]string
+		want      map[string]string
+	}{
+		{name: "no smtp module", want: map[string]string{}},
+		{
+			name:      "smtp module relay",
+			endpoints: map[string]string{"smtp": "smtp.infra.svc.cluster.local:587"},
+			want:      map[string]string{"SMTP_HOST": "smtp.infra.svc.cluster.local", "SMTP_PORT": "587", "SMTP_SECURITY": "off", "SMTP_FROM": "vaultwarden@example.com"},
+		},
+		{
+			name:      "mail_from overrides the sender",
+			endpoints: map[string]string{"smtp": "smtp.infra.svc.cluster.local:587"},
+			secrets:   map[string]string{"mail_from": "vault@example.org"},
+			want:      map[string]string{"SMTP_HOST": "smtp.infra.svc.cluster.local", "SMTP_PORT": "587", "SMTP_SECURITY": "off", "SMTP_FROM": "vault@example.org"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &BitwardenModule{
+				GeneralConfig: config.GeneralConfig{Domain: "example.com", Endpoints: tt.endpoints},
+				ModuleConfig:  config.Module{Name: "bitwarden", Namespace: "infra", Secrets: tt.secrets},
+			}
+			_, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
+			got := map[string]string{}
+			for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if e.Name != "WEBSOCKET_ENABLED" {
+					got[e.Name] = e.Value
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("SMTP env = %v, want %v", got, tt.want)
+			}
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("%s = %q, want %q", name, got[name], value)
+				}
+			}
+		})
+	}
+}
+
 func TestBitwardenModule_PrepareDeploymentVolumes(t *testing.T) {
 	module := &BitwardenModule{
 		GeneralConfig: config.GeneralConfig{
@@ -382,7 +448,10 @@ func TestBitwardenModule_PrepareDeploymentVolumes(t *testing.T) {
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test volumes
 	if len(deployment.Spec.Template.Spec.Volumes) != 1 {
@@ -526,3 +595,51 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestBitwardenModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bitwarden-7d9f", Namespace: "infra", Labels: map[string]string{"app": "bitwarden"}}}
+	module := &BitwardenModule{ModuleConfig: config.Module{Name: "bitwarden", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "bitwarden-7d9f", Command: []string{"tar", "czf", "-", "/data"}, Stdout: "archive"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(destDir, "bitwarden", "bitwarden_data_*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("expected one archive, got %v", archives)
+	}
+	if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+		t.Errorf("archive = %q, want the tar output", data)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "bitwarden", "backup_info.txt")); err != nil {
+		t.Errorf("metadata not written: %v", err)
+	}
+}
+
+func TestBitwardenModule_RestoreDataWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bitwarden-7d9f", Namespace: "infra", Labels: map[string]string{"app": "bitwarden"}}}
+	archive := filepath.Join(t.TempDir(), "bitwarden_data.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &BitwardenModule{ModuleConfig: config.Module{Name: "bitwarden", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "bitwarden-7d9f", Command: []string{"sh", "-c", "rm -rf /data/*"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "bitwarden-7d9f", Command: []string{"tar", "xzf", "-", "-C", "/"}, Stdin: "archive"},
+	)
+
+	if err := module.restoreDataWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreDataWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,575 @@
+package certmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// namespace is where the upstream manifest installs cert-manager; its
+	// webhook certificate names the Service in this namespace, so it cannot
+	// be moved
+	namespace = "cert-manager"
+
+	defaultVersion      = "v1.15.3"
+	defaultIngressClass = "public"
+	manifestURL         = "https://github.com/cert-manager/cert-manager/releases/download/%s/cert-manager.yaml"
+
+	productionServer = "https://acme-v02.api.letsencrypt.org/directory"
+	stagingServer    = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+	cloudflareSecretName = "cloudflare-api-token"
+	cloudflareSecretKey  = "api-token"
+
+	// webhookTimeout bounds the wait for cert-manager's Deployments and for
+	// its webhook to accept the ClusterIssuer after a fresh install
+	webhookTimeout = 3 * time.Minute
+)
+
+// deployments are the Deployments of the upstream manifest, all of which must
+// be available before cert-manager admits resources
+var deployments = []string{"cert-manager", "cert-manager-cainjector", "cert-manager-webhook"}
+
+// ClusterIssuerGVR identifies cert-manager ClusterIssuers for the dynamic client
+var ClusterIssuerGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}
+
+// CertificateGVR identifies cert-manager Certificates for the dynamic client
+var CertificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+type CertManagerModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *CertManagerModule {
+	return &CertManagerModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *CertManagerModule) Name() string {
+	return "certmanager"
+}
+
+// DeploymentName returns the name of the module's Deployment, or "" when
+// cert-manager is installed by something else
+func (m *CertManagerModule) DeploymentName() string {
+	if !m.install() {
+		return ""
+	}
+	return "cert-manager"
+}
+
+// AppLabel returns the app label of the module's pods
+func (m *CertManagerModule) AppLabel() string {
+	return "cert-manager"
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	Email              string `yaml:"email" required:"true" doc:"Contact email of the Let's Encrypt account, which receives expiry warnings"`
+	Version            string `yaml:"version" default:"v1.15.3" doc:"cert-manager release installed from GitHub"`
+	Install            string `yaml:"install" default:"true" doc:"\"false\" to only create the ClusterIssuer when cert-manager is already installed, e.g. by microk8s enable cert-manager"`
+	Staging            string `yaml:"staging" default:"false" doc:"\"true\" to use the Let's Encrypt staging server, whose certificates are not trusted but whose rate limits are higher"`
+	HTTP01IngressClass string `yaml:"http01_ingress_class" default:"public" doc:"Ingress class of the controller that serves HTTP-01 challenges"`
+	DNS01Provider      string `yaml:"dns01_provider" doc:"DNS provider solving DNS-01 challenges, needed for wildcard certificates: cloudflare (default: none)"`
+	CloudflareAPIToken string `yaml:"cloudflare_api_token" doc:"Cloudflare API token with Zone.DNS edit permission, required with dns01_provider: cloudflare"`
+	DNS01Zones         string `yaml:"dns01_zones" doc:"Comma-separated DNS zones solved with DNS-01 (default: general.domain)"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *CertManagerModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *CertManagerModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: certmanager\n\n")
+	m.log.Info("Description:\n  Installs cert-manager into the cert-manager namespace and creates a Let's Encrypt ClusterIssuer.\n  Ingresses with tls: true and clusterIssuer: letsencrypt, and exposed modules with\n  general.ingressTLS enabled, then get certificates automatically.\n  The issuer solves HTTP-01 challenges through the ingress controller, and DNS-01 challenges\n  (needed for wildcard certificates) through Cloudflare when dns01_provider is set.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  email                  Contact email of the Let's Encrypt account\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  version                cert-manager release to install (default: %s)\n  install                \"false\" when cert-manager is already installed (default: true)\n  staging                \"true\" to use the Let's Encrypt staging server; the issuer is then letsencrypt-staging\n  http01_ingress_class   Ingress class serving HTTP-01 challenges (default: %s)\n  dns01_provider         cloudflare to solve DNS-01 challenges (default: none)\n  cloudflare_api_token   Cloudflare API token with Zone.DNS edit permission\n  dns01_zones            Comma-separated zones solved with DNS-01 (default: general.domain)\n\n", defaultVersion, defaultIngressClass)
+	m.log.Info("Subcommands:\n  generate   Write the ClusterIssuer YAML to configs/certmanager/\n  apply      Install cert-manager and create the ClusterIssuer\n  clean      Delete the ClusterIssuer and cert-manager, keeping its CRDs and certificates\n  status     Print cert-manager, ClusterIssuer and Certificate status\n  doc        Show this documentation\n")
+	return nil
+}
+
+// issuerName is the ClusterIssuer ingresses reference, kept apart for the
+// staging server so switching servers does not reuse the wrong account
+func (m *CertManagerModule) issuerName() string {
+	if m.staging() {
+		return "letsencrypt-staging"
+	}
+	return "letsencrypt"
+}
+
+func (m *CertManagerModule) staging() bool {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "staging", "false") == "true"
+}
+
+func (m *CertManagerModule) install() bool {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "install", "true") != "false"
+}
+
+func (m *CertManagerModule) version() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "version", defaultVersion)
+}
+
+// prepare validates the configuration and returns the Cloudflare token
+// Secret, or nil without DNS-01, and the ClusterIssuer
+func (m *CertManagerModule) prepare() (*corev1.Secret, *unstructured.Unstructured, error) {
+	if m.ModuleConfig.Namespace != "" && m.ModuleConfig.Namespace != namespace {
+		return nil, nil, fmt.Errorf("certmanager must use namespace %s, where the upstream manifest installs it, not %s", namespace, m.ModuleConfig.Namespace)
+	}
+	email := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "email", "")
+	if email == "" {
+		return nil, nil, fmt.Errorf("email is required in certmanager secrets")
+	}
+
+	server := productionServer
+	if m.staging() {
+		server = stagingServer
+	}
+
+	// cert-manager prefers the solver whose selector matches a domain and
+	// falls back to the one without a selector
+	var solvers []interface{}
+	var secret *corev1.Secret
+	switch provider := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "dns01_provider", ""); provider {
+	case "":
+	case "cloudflare":
+		token := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "cloudflare_api_token", "")
+		if token == "" {
+			return nil, nil, fmt.Errorf("cloudflare_api_token is required with dns01_provider: cloudflare")
+		}
+		secret = &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cloudflareSecretName,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"managed-by": "personal-server",
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				cloudflareSecretKey: []byte(token),
+			},
+		}
+
+		var zones []interface{}
+		for _, zone := range strings.Split(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "dns01_zones", m.GeneralConfig.Domain), ",") {
+			if zone = strings.TrimSpace(zone); zone != "" {
+				zones = append(zones, zone)
+			}
+		}
+		if len(zones) == 0 {
+			return nil, nil, fmt.Errorf("dns01_zones or general.domain is required with dns01_provider: cloudflare")
+		}
+		solvers = append(solvers, map[string]interface{}{
+			"selector": map[string]interface{}{
+				"dnsZones": zones,
+			},
+			"dns01": map[string]interface{}{
+				"cloudflare": map[string]interface{}{
+					"apiTokenSecretRef": map[string]interface{}{
+						"name": cloudflareSecretName,
+						"key":  cloudflareSecretKey,
+					},
+				},
+			},
+		})
+	default:
+		return nil, nil, fmt.Errorf("unsupported dns01_provider %q: want cloudflare", provider)
+	}
+	solvers = append(solvers, map[string]interface{}{
+		"http01": map[string]interface{}{
+			"ingress": map[string]interface{}{
+				"ingressClassName": k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "http01_ingress_class", defaultIngressClass),
+			},
+		},
+	})
+
+	issuer := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "ClusterIssuer",
+		"metadata": map[string]interface{}{
+			"name": m.issuerName(),
+			"labels": map[string]interface{}{
+				"managed-by": "personal-server",
+			},
+		},
+		"spec": map[string]interface{}{
+			"acme": map[string]interface{}{
+				"server": server,
+				"email":  email,
+				"privateKeySecretRef": map[string]interface{}{
+					"name": m.issuerName() + "-account-key",
+				},
+				"solvers": solvers,
+			},
+		},
+	}}
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, issuer)
+	return secret, issuer, nil
+}
+
+func (m *CertManagerModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "certmanager")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating cert-manager Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, issuer, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	total := 1
+	if secret != nil {
+		if err := writeYAML(secret, "secret"); err != nil {
+			return err
+		}
+		total++
+	}
+	if err := writeYAML(issuer, "cluster-issuer"); err != nil {
+		return err
+	}
+
+	m.log.Info("\nCompleted: %d/%d cert-manager configurations generated successfully\n", total, total)
+	if m.install() {
+		m.log.Info("Install cert-manager itself first with: kubectl apply -f %s\n", fmt.Sprintf(manifestURL, m.version()))
+	}
+	return nil
+}
+
+// manifestObjects downloads and decodes the manifest of the configured
+// release, or returns nil when install is off
+func (m *CertManagerModule) manifestObjects(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	if !m.install() {
+		return nil, nil
+	}
+	url := fmt.Sprintf(manifestURL, m.version())
+	m.log.Progress("Downloading cert-manager %s manifest...\n", m.version())
+	data, err := k8s.FetchManifest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return k8s.DecodeManifest(data)
+}
+
+func (m *CertManagerModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dyn, err := m.GeneralConfig.Clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	mapper, err := m.GeneralConfig.Clients.RESTMapper()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying cert-manager Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", namespace)
+
+	// Validate before downloading anything
+	if _, _, err := m.prepare(); err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+	objects, err := m.manifestObjects(ctx)
+	if err != nil {
+		return err
+	}
+	return m.applyWithClient(ctx, clientset, dyn, mapper, objects, 5*time.Second)
+}
+
+func (m *CertManagerModule) applyWithClient(ctx context.Context, client k8s.KubernetesClient, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured, interval time.Duration) error {
+	secret, issuer, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	if len(objects) > 0 {
+		m.log.Progress("Applying cert-manager %s (%d objects)\n", m.version(), len(objects))
+		if err := k8s.ApplyObjects(ctx, dyn, mapper, objects, nil); err != nil {
+			return err
+		}
+		m.log.Success("Applied cert-manager %s\n", m.version())
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	for _, name := range deployments {
+		m.log.Progress("Waiting for Deployment: %s\n", name)
+		if err := k8s.WaitForDeploymentReady(waitCtx, client, namespace, name, interval); err != nil {
+			return err
+		}
+	}
+
+	if secret != nil {
+		m.log.Progress("Applying Secret: %s\n", secret.Name)
+		_, err := client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			_, err = client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply secret: %w", err)
+		}
+		m.log.Success("Applied Secret: %s\n", secret.Name)
+	}
+
+	// The webhook admits ClusterIssuers only once its serving certificate has
+	// been injected, which can lag behind the Deployment becoming available
+	m.log.Progress("Applying ClusterIssuer: %s\n", issuer.GetName())
+	issuers := dyn.Resource(ClusterIssuerGVR)
+	for {
+		existing, err := issuers.Get(waitCtx, issuer.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err = issuers.Create(waitCtx, issuer, metav1.CreateOptions{})
+		} else if err == nil {
+			issuer.SetResourceVersion(existing.GetResourceVersion())
+			_, err = issuers.Update(waitCtx, issuer, metav1.UpdateOptions{})
+		}
+		if err == nil {
+			break
+		}
+		if !errors.IsInternalError(err) || waitCtx.Err() != nil {
+			return fmt.Errorf("failed to apply ClusterIssuer %s: %w", issuer.GetName(), err)
+		}
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("failed to apply ClusterIssuer %s: %w", issuer.GetName(), err)
+		case <-time.After(interval):
+		}
+	}
+	m.log.Success("Applied ClusterIssuer: %s\n", issuer.GetName())
+
+	m.log.Info("\nCompleted: cert-manager configurations applied successfully\n")
+	m.log.Info("Request certificates with tls: true and clusterIssuer: %s on an ingresses[] entry\n", issuer.GetName())
+	return nil
+}
+
+func (m *CertManagerModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dyn, err := m.GeneralConfig.Clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	mapper, err := m.GeneralConfig.Clients.RESTMapper()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning cert-manager Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", namespace)
+
+	objects, err := m.manifestObjects(ctx)
+	if err != nil {
+		return err
+	}
+	return m.cleanWithClient(ctx, clientset, dyn, mapper, objects)
+}
+
+func (m *CertManagerModule) cleanWithClient(ctx context.Context, client k8s.KubernetesClient, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured) error {
+	successCount := 0
+
+	// Delete ClusterIssuer
+	m.log.Info("🗑️  Processing ClusterIssuer: %s\n", m.issuerName())
+	err := dyn.Resource(ClusterIssuerGVR).Delete(ctx, m.issuerName(), metav1.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			m.log.Warn("ClusterIssuer '%s' not found\n", m.issuerName())
+		} else {
+			m.log.Error("Failed to delete ClusterIssuer: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted ClusterIssuer: %s\n", m.issuerName())
+		successCount++
+	}
+
+	// Delete the Cloudflare token Secret when DNS-01 is configured
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "dns01_provider", "") != "" {
+		m.log.Info("🗑️  Processing Secret: %s\n", cloudflareSecretName)
+		err := client.CoreV1().Secrets(namespace).Delete(ctx, cloudflareSecretName, metav1.DeleteOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("Secret '%s' not found\n", cloudflareSecretName)
+			} else {
+				m.log.Error("Failed to delete Secret: %v\n", err)
+			}
+		} else {
+			m.log.Success("Deleted Secret: %s\n", cloudflareSecretName)
+			successCount++
+		}
+	}
+
+	// Uninstall cert-manager. Deleting its CRDs would delete every
+	// Certificate in the cluster together with the Secrets they manage, so
+	// they stay.
+	if len(objects) > 0 {
+		var components []*unstructured.Unstructured
+		for _, object := range objects {
+			if object.GetKind() != "CustomResourceDefinition" {
+				components = append(components, object)
+			}
+		}
+		m.log.Info("🗑️  Processing cert-manager %s (%d objects)\n", m.version(), len(components))
+		deleted, err := k8s.DeleteObjects(ctx, dyn, mapper, components)
+		if err != nil {
+			m.log.Error("Failed to delete cert-manager: %v\n", err)
+		} else {
+			m.log.Success("Deleted cert-manager (%d objects)\n", deleted)
+		}
+		successCount += deleted
+		m.log.Println("\nNote: cert-manager's CustomResourceDefinitions and the certificates they hold were kept.")
+	}
+
+	m.log.Info("\nCompleted: %d cert-manager resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *CertManagerModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dyn, err := m.GeneralConfig.Clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.statusWithClient(ctx, clientset, dyn)
+}
+
+// readyCondition returns the status and message of a cert-manager
+// resource's Ready condition
+func readyCondition(object unstructured.Unstructured) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		message, _ := condition["message"].(string)
+		return status, message
+	}
+	return "Unknown", ""
+}
+
+func (m *CertManagerModule) statusWithClient(ctx context.Context, client k8s.KubernetesClient, dyn dynamic.Interface) error {
+	m.log.Info("Checking cert-manager resources in namespace '%s'...\n\n", namespace)
+
+	// Check Deployments
+	m.log.Info("DEPLOYMENTS:\n")
+	m.log.Info("%-30s %-10s %-10s\n", "NAME", "READY", "AGE")
+	for _, name := range deployments {
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("%-30s %-10s\n", name, "Not Found")
+			} else {
+				m.log.Error("Error getting Deployment %s: %v\n", name, err)
+			}
+			continue
+		}
+		m.log.Info("%-30s %-10s %-10s\n", name,
+			fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, deployment.Status.Replicas),
+			k8s.FormatAge(time.Since(deployment.CreationTimestamp.Time).Round(time.Second)))
+	}
+	m.log.Println()
+
+	// Check ClusterIssuer
+	issuer, err := dyn.Resource(ClusterIssuerGVR).Get(ctx, m.issuerName(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			m.log.Error("ClusterIssuer '%s' not found\n\n", m.issuerName())
+		} else {
+			m.log.Error("Error getting ClusterIssuer: %v\n\n", err)
+		}
+	} else {
+		status, message := readyCondition(*issuer)
+		server, _, _ := unstructured.NestedString(issuer.Object, "spec", "acme", "server")
+		m.log.Info("CLUSTER ISSUER:\n")
+		m.log.Info("  Name:            %s\n", issuer.GetName())
+		m.log.Info("  Server:          %s\n", server)
+		m.log.Info("  Ready:           %s\n", status)
+		if status != "True" && message != "" {
+			m.log.Warn("  Message:         %s\n", message)
+		}
+		m.log.Println()
+	}
+
+	// Check Certificates in all namespaces
+	certificates, err := dyn.Resource(CertificateGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if !meta.IsNoMatchError(err) && !errors.IsNotFound(err) {
+			m.log.Error("Error listing Certificates: %v\n", err)
+		}
+		return nil
+	}
+	if len(certificates.Items) == 0 {
+		m.log.Println("No Certificates found")
+		return nil
+	}
+	m.log.Info("CERTIFICATES:\n")
+	m.log.Info("%-20s %-30s %-8s %-12s\n", "NAMESPACE", "NAME", "READY", "EXPIRES")
+	for _, certificate := range certificates.Items {
+		status, _ := readyCondition(certificate)
+		expires := "-"
+		if notAfter, _, _ := unstructured.NestedString(certificate.Object, "status", "notAfter"); notAfter != "" {
+			if t, err := time.Parse(time.RFC3339, notAfter); err == nil {
+				expires = t.Format("2006-01-02")
+			}
+		}
+		m.log.Info("%-20s %-30s %-8s %-12s\n", certificate.GetNamespace(), certificate.GetName(), status, expires)
+	}
+	return nil
+}
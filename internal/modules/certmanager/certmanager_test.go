@@ -0,0 +1,329 @@
+package certmanager
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCertManagerModule_Name(t *testing.T) {
+	module := &CertManagerModule{}
+	if module.Name() != "certmanager" {
+		t.Errorf("Name() = %s, want certmanager", module.Name())
+	}
+}
+
+func TestCertManagerModule_Doc(t *testing.T) {
+	module := &CertManagerModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestCertManagerModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name        string
+		namespace   string
+		secrets     map[string]string
+		wantIssuer  string
+		wantServer  string
+		wantSolvers int
+		wantZones   []string
+		wantErr     bool
+	}{
+		{
+			name:        "HTTP-01 only",
+			secrets:     map[string]string{"email": "admin@example.com"},
+			wantIssuer:  "letsencrypt",
+			wantServer:  productionServer,
+			wantSolvers: 1,
+		},
+		{
+			name:        "staging",
+			secrets:     map[string]string{"email": "admin@example.com", "staging": "true"},
+			wantIssuer:  "letsencrypt-staging",
+			wantServer:  stagingServer,
+			wantSolvers: 1,
+		},
+		{
+			name:        "DNS-01 defaults to the general domain",
+			secrets:     map[string]string{"email": "admin@example.com", "dns01_provider": "cloudflare", "cloudflare_api_token": "token"},
+			wantIssuer:  "letsencrypt",
+			wantServer:  productionServer,
+			wantSolvers: 2,
+			wantZones:   []string{"example.com"},
+		},
+		{
+			name:        "DNS-01 with zones",
+			secrets:     map[string]string{"email": "admin@example.com", "dns01_provider": "cloudflare", "cloudflare_api_token": "token", "dns01_zones": "example.com, example.org"},
+			wantIssuer:  "letsencrypt",
+			wantServer:  productionServer,
+			wantSolvers: 2,
+			wantZones:   []string{"example.com", "example.org"},
+		},
+		{
+			name:    "DNS-01 without token",
+			secrets: map[string]string{"email": "admin@example.com", "dns01_provider": "cloudflare"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported DNS provider",
+			secrets: map[string]string{"email": "admin@example.com", "dns01_provider": "route53"},
+			wantErr: true,
+		},
+		{
+			name:    "missing email",
+			wantErr: true,
+		},
+		{
+			name:      "wrong namespace",
+			namespace: "infra",
+			secrets:   map[string]string{"email": "admin@example.com"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := tt.namespace
+			if ns == "" {
+				ns = namespace
+			}
+			module := &CertManagerModule{
+				GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+				ModuleConfig:  config.Module{Name: "certmanager", Namespace: ns, Secrets: tt.secrets},
+				log:           logger.NewNopLogger(),
+			}
+
+			secret, issuer, err := module.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if issuer.GetName() != tt.wantIssuer {
+				t.Errorf("ClusterIssuer name = %s, want %s", issuer.GetName(), tt.wantIssuer)
+			}
+			if got, _, _ := unstructured.NestedString(issuer.Object, "spec", "acme", "server"); got != tt.wantServer {
+				t.Errorf("server = %s, want %s", got, tt.wantServer)
+			}
+			solvers, _, _ := unstructured.NestedSlice(issuer.Object, "spec", "acme", "solvers")
+			if len(solvers) != tt.wantSolvers {
+				t.Fatalf("solvers = %d, want %d", len(solvers), tt.wantSolvers)
+			}
+			// The HTTP-01 solver comes last as the fallback for other domains
+			if got, _, _ := unstructured.NestedString(solvers[len(solvers)-1].(map[string]interface{}), "http01", "ingress", "ingressClassName"); got != defaultIngressClass {
+				t.Errorf("HTTP-01 ingressClassName = %s, want %s", got, defaultIngressClass)
+			}
+
+			if tt.wantZones == nil {
+				if secret != nil {
+					t.Errorf("prepare() returned Secret %s without DNS-01", secret.Name)
+				}
+				return
+			}
+			if secret == nil || string(secret.Data[cloudflareSecretKey]) != "token" || secret.Namespace != namespace {
+				t.Fatalf("Secret = %+v, want the Cloudflare token in %s", secret, namespace)
+			}
+			zones, _, _ := unstructured.NestedStringSlice(solvers[0].(map[string]interface{}), "selector", "dnsZones")
+			if len(zones) != len(tt.wantZones) {
+				t.Fatalf("dnsZones = %v, want %v", zones, tt.wantZones)
+			}
+			for i := range zones {
+				if zones[i] != tt.wantZones[i] {
+					t.Errorf("dnsZones = %v, want %v", zones, tt.wantZones)
+				}
+			}
+		})
+	}
+}
+
+const testManifest = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusterissuers.cert-manager.io
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: cert-manager
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cert-manager
+  namespace: cert-manager
+`
+
+var (
+	crdGVR        = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	namespaceGVR  = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+func newTestClients(t *testing.T) (*dynamicfake.FakeDynamicClient, meta.RESTMapper, []*unstructured.Unstructured) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdGVR:           "CustomResourceDefinitionList",
+		namespaceGVR:     "NamespaceList",
+		deploymentGVR:    "DeploymentList",
+		ClusterIssuerGVR: "ClusterIssuerList",
+		CertificateGVR:   "CertificateList",
+	})
+	objects, err := k8s.DecodeManifest([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("DecodeManifest() error = %v", err)
+	}
+	return dyn, mapper, objects
+}
+
+func TestApplyAndClean(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	dyn, mapper, objects := newTestClients(t)
+	module := &CertManagerModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig: config.Module{
+			Name:      "certmanager",
+			Namespace: namespace,
+			Secrets:   map[string]string{"email": "admin@example.com", "dns01_provider": "cloudflare", "cloudflare_api_token": "token"},
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	// The webhook rejects the first attempt while its certificate is injected
+	rejected := false
+	dyn.PrependReactor("create", "clusterissuers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if rejected {
+			return false, nil, nil
+		}
+		rejected = true
+		return true, nil, errors.NewInternalError(os.ErrDeadlineExceeded)
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := module.applyWithClient(ctx, client, dyn, mapper, objects, time.Millisecond); err != nil {
+			t.Fatalf("applyWithClient() #%d error = %v", i+1, err)
+		}
+	}
+	if !rejected {
+		t.Error("ClusterIssuer create was not retried")
+	}
+	if _, err := dyn.Resource(ClusterIssuerGVR).Get(ctx, "letsencrypt", metav1.GetOptions{}); err != nil {
+		t.Errorf("ClusterIssuer not created: %v", err)
+	}
+	if _, err := dyn.Resource(deploymentGVR).Namespace(namespace).Get(ctx, "cert-manager", metav1.GetOptions{}); err != nil {
+		t.Errorf("manifest Deployment not created: %v", err)
+	}
+	if _, err := client.CoreV1().Secrets(namespace).Get(ctx, cloudflareSecretName, metav1.GetOptions{}); err != nil {
+		t.Errorf("Cloudflare Secret not created: %v", err)
+	}
+
+	if err := module.cleanWithClient(ctx, client, dyn, mapper, objects); err != nil {
+		t.Fatalf("cleanWithClient() error = %v", err)
+	}
+	if _, err := dyn.Resource(ClusterIssuerGVR).Get(ctx, "letsencrypt", metav1.GetOptions{}); err == nil {
+		t.Error("ClusterIssuer still exists after clean")
+	}
+	if _, err := dyn.Resource(deploymentGVR).Namespace(namespace).Get(ctx, "cert-manager", metav1.GetOptions{}); err == nil {
+		t.Error("manifest Deployment still exists after clean")
+	}
+	if _, err := dyn.Resource(crdGVR).Get(ctx, "clusterissuers.cert-manager.io", metav1.GetOptions{}); err != nil {
+		t.Errorf("CustomResourceDefinition deleted by clean: %v", err)
+	}
+}
+
+func TestApplyRejected(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	dyn, mapper, _ := newTestClients(t)
+	dyn.PrependReactor("create", "clusterissuers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewBadRequest("invalid email")
+	})
+	module := &CertManagerModule{
+		ModuleConfig: config.Module{Name: "certmanager", Namespace: namespace, Secrets: map[string]string{"email": "admin@example.com"}},
+		log:          logger.NewNopLogger(),
+	}
+
+	if err := module.applyWithClient(context.Background(), client, dyn, mapper, nil, time.Millisecond); err == nil {
+		t.Error("applyWithClient() succeeded although the ClusterIssuer was rejected")
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/cluster-issuer.yaml
+var expectedClusterIssuerYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := &CertManagerModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig: config.Module{
+			Name:      "certmanager",
+			Namespace: namespace,
+			Secrets: map[string]string{
+				"email":                "admin@example.com",
+				"dns01_provider":       "cloudflare",
+				"cloudflare_api_token": "token",
+			},
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/certmanager/secret.yaml", expectedSecretYAML},
+		{"cluster-issuer", "configs/certmanager/cluster-issuer.yaml", expectedClusterIssuerYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
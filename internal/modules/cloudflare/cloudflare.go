@@ -18,6 +18,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "128Mi"}
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "cloudflare/cloudflared:2025.11.1"
+
 type CloudflareModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -36,10 +44,35 @@ func (m *CloudflareModule) Name() string {
 	return "cloudflare"
 }
 
+// DeploymentName returns the name of the module's Deployment
+func (m *CloudflareModule) DeploymentName() string {
+	return "cloudflared-deployment"
+}
+
+// AppLabel returns the app label of the module's pods
+func (m *CloudflareModule) AppLabel() string {
+	return "cloudflared"
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	CloudflareAPIToken string `yaml:"cloudflare_api_token" required:"true" doc:"Cloudflare API token used to authenticate the tunnel agent"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *CloudflareModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *CloudflareModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: cloudflare\n\n")
 	m.log.Info("Description:\n  Deploys a Cloudflare tunnel agent (cloudflared) as a Kubernetes Deployment.\n  Exposes internal services to the internet via a Cloudflare Zero Trust tunnel.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  cloudflare_api_token   Cloudflare API token used to authenticate the tunnel agent\n\n")
+	m.log.Info("Optional module fields:\n  image   Container image (default: %s)\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/cloudflare/\n  apply      Create/update resources in the cluster\n  clean      Delete all Cloudflare resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
 	return nil
 }
@@ -63,7 +96,10 @@ func (m *CloudflareModule) Generate(ctx context.Context) error {
 	m.log.Info("Output directory: %s\n\n", outputDir)
 
 	// Prepare Kubernetes objects
-	secret, deployment := m.prepare(apiToken)
+	secret, deployment, err := m.prepare(apiToken)
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -105,7 +141,7 @@ func (m *CloudflareModule) Apply(ctx context.Context) error {
 	}
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -132,7 +168,10 @@ func (m *CloudflareModule) Apply(ctx context.Context) error {
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
-	secret, deployment := m.prepare(apiToken)
+	secret, deployment, err := m.prepare(apiToken)
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Apply Secret
 	m.log.Progress("Applying Secret: tunnel-token\n")
@@ -155,7 +194,7 @@ func (m *CloudflareModule) Apply(ctx context.Context) error {
 }
 
 // prepare creates and returns the Kubernetes objects for cloudflare module
-func (m *CloudflareModule) prepare(apiToken string) (*corev1.Secret, *appsv1.Deployment) {
+func (m *CloudflareModule) prepare(apiToken string) (*corev1.Secret, *appsv1.Deployment, error) {
 	// Prepare Secret
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -208,7 +247,7 @@ func (m *CloudflareModule) prepare(apiToken string) (*corev1.Secret, *appsv1.Dep
 					Containers: []corev1.Container{
 						{
 							Name:  "cloudflared",
-							Image: "cloudflare/cloudflared:2025.11.1",
+							Image: m.ModuleConfig.ContainerImage(defaultImage),
 							Env: []corev1.EnvVar{
 								{
 									Name: "TUNNEL_TOKEN",
@@ -243,6 +282,16 @@ func (m *CloudflareModule) prepare(apiToken string) (*corev1.Secret, *appsv1.Dep
 								InitialDelaySeconds: 10,
 								PeriodSeconds:       10,
 							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/ready",
+										Port: intstr.FromInt(2000),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 						},
 					},
 				},
@@ -250,12 +299,29 @@ func (m *CloudflareModule) prepare(apiToken string) (*corev1.Secret, *appsv1.Dep
 		},
 	}
 
-	return secret, deployment
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, deployment)
+	return secret, deployment, nil
 }
 
 func (m *CloudflareModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -307,7 +373,7 @@ func (m *CloudflareModule) Clean(ctx context.Context) error {
 
 func (m *CloudflareModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
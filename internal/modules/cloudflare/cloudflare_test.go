@@ -51,7 +51,10 @@ func TestCloudflareModule_Prepare(t *testing.T) {
 				},
 			}
 
-			secret, deployment := module.prepare(tt.apiToken)
+			secret, deployment, err := module.prepare(tt.apiToken)
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			// Verify all objects are not nil
 			if secret == nil {
@@ -84,7 +87,10 @@ func TestCloudflareModule_PrepareSecret(t *testing.T) {
 		},
 	}
 
-	secret, _ := module.prepare(apiToken)
+	secret, _, err := module.prepare(apiToken)
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Secret name
 	if secret.Name != "tunnel-token" {
@@ -126,7 +132,10 @@ func TestCloudflareModule_PrepareDeployment(t *testing.T) {
 		},
 	}
 
-	_, deployment := module.prepare("test-token")
+	_, deployment, err := module.prepare("test-token")
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Deployment name
 	if deployment.Name != "cloudflared-deployment" {
@@ -176,7 +185,10 @@ func TestCloudflareModule_PrepareDeploymentPodSecurityContext(t *testing.T) {
 		},
 	}
 
-	_, deployment := module.prepare("test-token")
+	_, deployment, err := module.prepare("test-token")
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test PodSecurityContext
 	podSecurityContext := deployment.Spec.Template.Spec.SecurityContext
@@ -209,7 +221,10 @@ func TestCloudflareModule_PrepareDeploymentContainer(t *testing.T) {
 		},
 	}
 
-	_, deployment := module.prepare("test-token")
+	_, deployment, err := module.prepare("test-token")
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify container count
 	if len(deployment.Spec.Template.Spec.Containers) != 1 {
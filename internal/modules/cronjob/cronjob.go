@@ -0,0 +1,547 @@
+package cronjob
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	defaultConcurrencyPolicy = "Forbid"
+	defaultBackoffLimit      = "0"
+	historyLimit             = 3
+
+	// secretEnvPrefix marks the modules[].secrets keys passed to the job
+	// from the module's Secret rather than read as settings
+	secretEnvPrefix = "secret_"
+
+	// recentJobs is how many of the retained Jobs status lists
+	recentJobs = 5
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "64Mi", MemoryLimit: "512Mi"}
+
+type CronJobModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *CronJobModule {
+	return &CronJobModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+// Name returns the configured module name so several jobs can be deployed
+// as cronjob-<suffix>
+func (m *CronJobModule) Name() string {
+	if m.ModuleConfig.Name == "" {
+		return "cronjob"
+	}
+	return m.ModuleConfig.Name
+}
+
+// DeploymentName is empty: the job's pods only exist while a run lasts
+func (m *CronJobModule) DeploymentName() string {
+	return ""
+}
+
+// AppLabel returns the app label of the job's pods
+func (m *CronJobModule) AppLabel() string {
+	return m.Name()
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	Schedule          string `yaml:"schedule" required:"true" doc:"Cron schedule, e.g. \"30 3 * * *\" or @daily, read in general.timezone"`
+	Command           string `yaml:"command" doc:"Shell command run with sh -c (default: the image's entrypoint)"`
+	ConcurrencyPolicy string `yaml:"concurrency_policy" default:"Forbid" doc:"Forbid skips a run while the previous one is active, Replace stops it, Allow runs both"`
+	BackoffLimit      string `yaml:"backoff_limit" default:"0" doc:"Retries of a failed run"`
+	Timeout           string `yaml:"timeout" doc:"Duration after which a run is stopped and marked failed, e.g. 30m (default: none)"`
+	SecretEnv         string `yaml:"secret_<NAME>" doc:"Stored in the <module>-secrets Secret and passed to the job as the environment variable NAME"`
+
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *CronJobModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *CronJobModule) secretName() string { return m.Name() + "-secrets" }
+
+func (m *CronJobModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: %s (cronjob)\n\n", m.Name())
+	m.log.Info("Description:\n  Runs a container image on a schedule, e.g. for cleanup scripts or certificate renewal.\n  Manages a CronJob and, when secret_* keys are set, a Secret.\n  Multiple jobs can be deployed using the 'cronjob-<suffix>' naming convention.\n\n")
+	m.log.Info("Required module fields:\n  image   Container image\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  schedule             Cron schedule, e.g. \"30 3 * * *\" or @daily, read in general.timezone\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  command              Shell command run with sh -c (default: the image's entrypoint)\n  concurrency_policy   Forbid, Replace or Allow (default: %s)\n  backoff_limit        Retries of a failed run (default: %s)\n  timeout              Duration after which a run is stopped, e.g. 30m (default: none)\n  secret_<NAME>        Stored in the %s Secret and passed to the job as NAME\n\n", defaultConcurrencyPolicy, defaultBackoffLimit, m.secretName())
+	m.log.Info("Optional module fields:\n  envs    Plain environment variables for the job\n\n")
+	m.log.Info("Subcommands:\n  generate     Write Kubernetes YAML to configs/%s/\n  apply        Create/update resources in the cluster\n  clean        Delete the CronJob, its Jobs and the Secret from the cluster\n  status       Print the schedule, last and next run, and the results of recent Jobs\n  doc          Show this documentation\n", m.Name())
+	return nil
+}
+
+func (m *CronJobModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", m.Name())
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating %s Kubernetes configurations...\n", m.Name())
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, cronJob, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if secret != nil {
+		if err := writeYAML(secret, "secret"); err != nil {
+			return err
+		}
+	}
+	if err := writeYAML(cronJob, "cronjob"); err != nil {
+		return err
+	}
+
+	m.log.Info("\nCompleted: %s configurations generated successfully\n", m.Name())
+	return nil
+}
+
+func (m *CronJobModule) Apply(ctx context.Context) error {
+	// Prepare Kubernetes objects first so invalid settings fail fast
+	secret, cronJob, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Applying %s Kubernetes configurations...\n", m.Name())
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	m.log.Info("Checking for existing resources...\n")
+	if secret != nil {
+		if _, err := clientset.CoreV1().Secrets(ns).Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("secret '%s' already exists in namespace '%s'", secret.Name, ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check secret existence: %w", err)
+		}
+	}
+	if _, err := clientset.BatchV1().CronJobs(ns).Get(ctx, cronJob.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("CronJob '%s' already exists in namespace '%s'", cronJob.Name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check CronJob existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if secret != nil {
+		if _, err := clientset.CoreV1().Secrets(ns).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create secret: %w", err)
+		}
+		m.log.Success("Created Secret: %s\n", secret.Name)
+	}
+	if _, err := clientset.BatchV1().CronJobs(ns).Create(ctx, cronJob, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create CronJob: %w", err)
+	}
+	m.log.Success("Created CronJob: %s\n", cronJob.Name)
+
+	m.log.Info("\nCompleted: %s configurations applied successfully\n", m.Name())
+	return nil
+}
+
+// secretEnv returns the secret_<NAME> keys as NAME -> value
+func (m *CronJobModule) secretEnv() (map[string]string, error) {
+	env := make(map[string]string)
+	for key, value := range m.ModuleConfig.Secrets {
+		name, ok := strings.CutPrefix(key, secretEnvPrefix)
+		if !ok {
+			continue
+		}
+		if errs := validation.IsEnvVarName(name); name == "" || len(errs) > 0 {
+			return nil, fmt.Errorf("invalid key %s: %s must be a valid environment variable name", key, name)
+		}
+		if _, ok := m.ModuleConfig.Envs[name]; ok {
+			return nil, fmt.Errorf("%s is set both in envs and as %s", name, key)
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// prepare creates and returns the Kubernetes objects for the job. The Secret
+// is nil when no secret_* key is configured.
+func (m *CronJobModule) prepare() (*corev1.Secret, *batchv1.CronJob, error) {
+	name := m.Name()
+	ns := m.ModuleConfig.Namespace
+	labels := map[string]string{
+		"app":        name,
+		"managed-by": "personal-server",
+	}
+
+	image := m.ModuleConfig.Image
+	if image == "" {
+		return nil, nil, fmt.Errorf("image not found in configuration")
+	}
+	schedule := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "schedule", "")
+	if schedule == "" {
+		return nil, nil, fmt.Errorf("schedule not found in configuration")
+	}
+	if err := k8s.ValidateSchedule("schedule", schedule); err != nil {
+		return nil, nil, err
+	}
+
+	policy := batchv1.ConcurrencyPolicy(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "concurrency_policy", defaultConcurrencyPolicy))
+	switch policy {
+	case batchv1.ForbidConcurrent, batchv1.ReplaceConcurrent, batchv1.AllowConcurrent:
+	default:
+		return nil, nil, fmt.Errorf("invalid concurrency_policy %q: must be Forbid, Replace or Allow", policy)
+	}
+
+	backoffValue := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "backoff_limit", defaultBackoffLimit)
+	n, err := strconv.ParseInt(backoffValue, 10, 32)
+	if err != nil || n < 0 {
+		return nil, nil, fmt.Errorf("invalid backoff_limit %q: must be a whole number of at least 0", backoffValue)
+	}
+	backoffLimit := int32(n)
+
+	var activeDeadline *int64
+	if value := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "timeout", ""); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < time.Second {
+			return nil, nil, fmt.Errorf("invalid timeout %q: want a duration of at least 1s, e.g. 30m", value)
+		}
+		seconds := int64(timeout.Seconds())
+		activeDeadline = &seconds
+	}
+
+	secretEnv, err := m.secretEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Plain variables first, then the secret ones, each sorted for stable output
+	var env []corev1.EnvVar
+	for _, key := range sortedKeys(m.ModuleConfig.Envs) {
+		env = append(env, corev1.EnvVar{Name: key, Value: m.ModuleConfig.Envs[key]})
+	}
+	var secret *corev1.Secret
+	if len(secretEnv) > 0 {
+		secret = &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.secretName(),
+				Namespace: ns,
+				Labels:    labels,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: make(map[string][]byte),
+		}
+		for _, key := range sortedKeys(secretEnv) {
+			secret.Data[key] = []byte(secretEnv[key])
+			env = append(env, corev1.EnvVar{
+				Name: key,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: m.secretName()},
+						Key:                  key,
+					},
+				},
+			})
+		}
+	}
+
+	var command []string
+	if script := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "command", ""); script != "" {
+		command = []string{"sh", "-c", script}
+	}
+
+	limit := int32(historyLimit)
+	cronJob := &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          policy,
+			SuccessfulJobsHistoryLimit: &limit,
+			FailedJobsHistoryLimit:     &limit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				// Lets status find the Jobs by label
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit:          &backoffLimit,
+					ActiveDeadlineSeconds: activeDeadline,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app": name,
+							},
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{
+								{
+									Name:            name,
+									Image:           image,
+									ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
+									Command:         command,
+									Env:             env,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if m.GeneralConfig.Timezone != "" {
+		// Without a time zone the schedule is read in the controller's zone,
+		// usually UTC
+		cronJob.Spec.TimeZone = &m.GeneralConfig.Timezone
+	}
+	k8s.SetResources(&cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, err
+	}
+	k8s.SetPodEnvironment(&cronJob.Spec.JobTemplate.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, cronJob)
+	return secret, cronJob, nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *CronJobModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	name := m.Name()
+	m.log.Info("Cleaning %s Kubernetes resources...\n", name)
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	successCount := 0
+	// Foreground propagation takes the CronJob's Jobs and their pods along
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Processing CronJob: %s\n", name)
+	if err := clientset.BatchV1().CronJobs(ns).Delete(ctx, name, deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("CronJob '%s' not found\n", name)
+		} else {
+			m.log.Error("Failed to delete CronJob: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted CronJob: %s\n", name)
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Secret: %s\n", m.secretName())
+	if err := clientset.CoreV1().Secrets(ns).Delete(ctx, m.secretName(), deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret '%s' not found\n", m.secretName())
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: %s\n", m.secretName())
+		successCount++
+	}
+
+	m.log.Info("\nCompleted: %d %s resources deleted successfully\n", successCount, name)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *CronJobModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.statusWithClient(ctx, clientset, time.Now())
+}
+
+func (m *CronJobModule) statusWithClient(ctx context.Context, client k8s.KubernetesClient, now time.Time) error {
+	ns := m.ModuleConfig.Namespace
+	name := m.Name()
+	m.log.Info("Checking %s resources in namespace '%s'...\n\n", name, ns)
+
+	cronJob, err := client.BatchV1().CronJobs(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("CronJob '%s' not found\n", name)
+		} else {
+			m.log.Error("Error getting CronJob: %v\n", err)
+		}
+	} else {
+		m.log.Info("CRONJOB:\n")
+		m.log.Info("  Name:            %s\n", cronJob.Name)
+		m.log.Info("  Schedule:        %s\n", cronJob.Spec.Schedule)
+		if cronJob.Spec.TimeZone != nil {
+			m.log.Info("  Time zone:       %s\n", *cronJob.Spec.TimeZone)
+		}
+		m.log.Info("  Image:           %s\n", cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image)
+		m.log.Info("  Active jobs:     %d\n", len(cronJob.Status.Active))
+		if t := cronJob.Status.LastScheduleTime; t != nil {
+			m.log.Info("  Last run:        %s ago\n", k8s.FormatAge(now.Sub(t.Time).Round(time.Second)))
+		} else {
+			m.log.Info("  Last run:        never\n")
+		}
+		if t := cronJob.Status.LastSuccessfulTime; t != nil {
+			m.log.Info("  Last success:    %s ago\n", k8s.FormatAge(now.Sub(t.Time).Round(time.Second)))
+		}
+		switch next, err := m.nextRun(cronJob, now); {
+		case cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend:
+			m.log.Info("  Next run:        suspended\n")
+		case err != nil:
+			m.log.Warn("  Next run:        unknown (%v)\n", err)
+		default:
+			m.log.Info("  Next run:        in %s (%s)\n", k8s.FormatAge(next.Sub(now).Round(time.Second)), next.Format("2006-01-02 15:04 MST"))
+		}
+		m.log.Println()
+	}
+
+	jobs, err := client.BatchV1().Jobs(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + name,
+	})
+	if err != nil {
+		m.log.Error("Error listing Jobs: %v\n", err)
+		return nil
+	}
+	if len(jobs.Items) == 0 {
+		m.log.Println("No " + name + " jobs found")
+		return nil
+	}
+	sort.Slice(jobs.Items, func(i, j int) bool {
+		return jobs.Items[j].CreationTimestamp.Before(&jobs.Items[i].CreationTimestamp)
+	})
+	if len(jobs.Items) > recentJobs {
+		jobs.Items = jobs.Items[:recentJobs]
+	}
+
+	last := jobs.Items[0]
+	result, reason := jobResult(&last)
+	if result == "Failed" {
+		m.log.Error("Last job %s failed: %s\n\n", last.Name, reason)
+	}
+	m.log.Info("JOBS:\n")
+	m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "RESULT", "DURATION", "AGE")
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		result, _ := jobResult(job)
+		duration := "-"
+		if start := job.Status.StartTime; start != nil {
+			end := now
+			if job.Status.CompletionTime != nil {
+				end = job.Status.CompletionTime.Time
+			}
+			duration = k8s.FormatAge(end.Sub(start.Time).Round(time.Second))
+		}
+		m.log.Info("%-40s %-10s %-10s %-10s\n",
+			job.Name,
+			result,
+			duration,
+			k8s.FormatAge(now.Sub(job.CreationTimestamp.Time).Round(time.Second)))
+	}
+	return nil
+}
+
+// nextRun returns when the CronJob runs next, in its time zone
+func (m *CronJobModule) nextRun(cronJob *batchv1.CronJob, now time.Time) (time.Time, error) {
+	loc := time.UTC
+	if cronJob.Spec.TimeZone != nil {
+		var err error
+		if loc, err = time.LoadLocation(*cronJob.Spec.TimeZone); err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %s", *cronJob.Spec.TimeZone)
+		}
+	}
+	return k8s.NextScheduleTime(cronJob.Spec.Schedule, now.In(loc))
+}
+
+// jobResult reports whether a Job is Running, Succeeded or Failed, and why it
+// failed
+func jobResult(job *batchv1.Job) (result, reason string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return "Succeeded", ""
+		case batchv1.JobFailed:
+			reason = condition.Reason
+			if condition.Message != "" {
+				reason += ": " + condition.Message
+			}
+			return "Failed", reason
+		}
+	}
+	return "Running", ""
+}
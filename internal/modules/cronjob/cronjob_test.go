@@ -0,0 +1,281 @@
+package cronjob
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCronJobModule_Name(t *testing.T) {
+	tests := []struct {
+		configName string
+		want       string
+	}{
+		{configName: "", want: "cronjob"},
+		{configName: "cronjob-cleanup", want: "cronjob-cleanup"},
+	}
+	for _, tt := range tests {
+		module := &CronJobModule{ModuleConfig: config.Module{Name: tt.configName}}
+		if got := module.Name(); got != tt.want {
+			t.Errorf("Name() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestCronJobModule_Doc(t *testing.T) {
+	module := &CronJobModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func newTestModule(secrets, envs map[string]string) *CronJobModule {
+	return &CronJobModule{
+		GeneralConfig: config.GeneralConfig{Timezone: "Europe/Berlin"},
+		ModuleConfig: config.Module{
+			Name:      "cronjob-cleanup",
+			Namespace: "infra",
+			Image:     "alpine:3.20",
+			Secrets:   secrets,
+			Envs:      envs,
+		},
+		log: logger.NewNopLogger(),
+	}
+}
+
+func TestCronJobModule_Prepare(t *testing.T) {
+	module := newTestModule(map[string]string{
+		"schedule":           "30 3 * * *",
+		"command":            "find /tmp -mtime +7 -delete",
+		"concurrency_policy": "Replace",
+		"backoff_limit":      "2",
+		"timeout":            "30m",
+		"secret_API_TOKEN":   "s3cret",
+	}, map[string]string{"DRY_RUN": "false"})
+
+	secret, cronJob, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+
+	if got := string(secret.Data["API_TOKEN"]); got != "s3cret" {
+		t.Errorf("API_TOKEN = %q, want s3cret", got)
+	}
+	if cronJob.Spec.Schedule != "30 3 * * *" || cronJob.Spec.ConcurrencyPolicy != batchv1.ReplaceConcurrent {
+		t.Errorf("schedule, policy = %s, %s; want 30 3 * * *, Replace", cronJob.Spec.Schedule, cronJob.Spec.ConcurrencyPolicy)
+	}
+	if tz := cronJob.Spec.TimeZone; tz == nil || *tz != "Europe/Berlin" {
+		t.Errorf("TimeZone = %v, want Europe/Berlin", tz)
+	}
+	if cronJob.Spec.JobTemplate.Labels["app"] != "cronjob-cleanup" {
+		t.Errorf("Job labels = %v, want app=cronjob-cleanup for status", cronJob.Spec.JobTemplate.Labels)
+	}
+	jobSpec := cronJob.Spec.JobTemplate.Spec
+	if *jobSpec.BackoffLimit != 2 || jobSpec.ActiveDeadlineSeconds == nil || *jobSpec.ActiveDeadlineSeconds != 1800 {
+		t.Errorf("backoff, deadline = %d, %v; want 2, 1800", *jobSpec.BackoffLimit, jobSpec.ActiveDeadlineSeconds)
+	}
+	container := jobSpec.Template.Spec.Containers[0]
+	if strings.Join(container.Command, " ") != "sh -c find /tmp -mtime +7 -delete" {
+		t.Errorf("Command = %q", container.Command)
+	}
+	if container.Env[0].Name != "DRY_RUN" || container.Env[1].ValueFrom.SecretKeyRef.Name != "cronjob-cleanup-secrets" {
+		t.Errorf("Env = %+v, want DRY_RUN then API_TOKEN from cronjob-cleanup-secrets", container.Env)
+	}
+}
+
+func TestCronJobModule_PrepareDefaults(t *testing.T) {
+	module := newTestModule(map[string]string{"schedule": "@daily"}, nil)
+	module.GeneralConfig.Timezone = ""
+
+	secret, cronJob, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	if secret != nil {
+		t.Errorf("secret = %v, want none without secret_* keys", secret)
+	}
+	if cronJob.Spec.TimeZone != nil {
+		t.Errorf("TimeZone = %s, want unset", *cronJob.Spec.TimeZone)
+	}
+	if cronJob.Spec.ConcurrencyPolicy != batchv1.ForbidConcurrent {
+		t.Errorf("ConcurrencyPolicy = %s, want Forbid", cronJob.Spec.ConcurrencyPolicy)
+	}
+	jobSpec := cronJob.Spec.JobTemplate.Spec
+	if *jobSpec.BackoffLimit != 0 || jobSpec.ActiveDeadlineSeconds != nil {
+		t.Errorf("backoff, deadline = %d, %v; want 0, none", *jobSpec.BackoffLimit, jobSpec.ActiveDeadlineSeconds)
+	}
+	if command := jobSpec.Template.Spec.Containers[0].Command; command != nil {
+		t.Errorf("Command = %q, want the image's entrypoint", command)
+	}
+}
+
+func TestCronJobModule_PrepareErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		noImage bool
+		secrets map[string]string
+		envs    map[string]string
+		want    string
+	}{
+		{name: "missing image", noImage: true, secrets: map[string]string{"schedule": "@daily"}, want: "image not found"},
+		{name: "missing schedule", want: "schedule not found"},
+		{name: "invalid schedule", secrets: map[string]string{"schedule": "daily"}, want: "invalid schedule"},
+		{name: "invalid concurrency policy", secrets: map[string]string{"schedule": "@daily", "concurrency_policy": "Never"}, want: "invalid concurrency_policy"},
+		{name: "invalid backoff limit", secrets: map[string]string{"schedule": "@daily", "backoff_limit": "-1"}, want: "invalid backoff_limit"},
+		{name: "invalid timeout", secrets: map[string]string{"schedule": "@daily", "timeout": "soon"}, want: "invalid timeout"},
+		{name: "secret env also in envs", secrets: map[string]string{"schedule": "@daily", "secret_TOKEN": "x"}, envs: map[string]string{"TOKEN": "y"}, want: "both in envs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets, tt.envs)
+			if tt.noImage {
+				module.ModuleConfig.Image = ""
+			}
+			_, _, err := module.prepare()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("prepare() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []batchv1.JobCondition
+		want       string
+		wantReason string
+	}{
+		{name: "running", want: "Running"},
+		{name: "succeeded", conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}, want: "Succeeded"},
+		{
+			name:       "failed",
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}},
+			want:       "Failed",
+			wantReason: "BackoffLimitExceeded: Job has reached the specified backoff limit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, reason := jobResult(&batchv1.Job{Status: batchv1.JobStatus{Conditions: tt.conditions}})
+			if result != tt.want || reason != tt.wantReason {
+				t.Errorf("jobResult() = %s, %q; want %s, %q", result, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCronJobModule_StatusWithClient(t *testing.T) {
+	now := time.Date(2026, time.October, 14, 10, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) metav1.Time { return metav1.NewTime(now.Add(-ago)) }
+	timeZone := "Europe/Berlin"
+	lastRun := at(6*time.Hour + 30*time.Minute)
+	labels := map[string]string{"app": "cronjob-cleanup"}
+	job := func(name string, ago time.Duration, condition batchv1.JobConditionType, reason string) *batchv1.Job {
+		created, finished := at(ago), at(ago-time.Minute)
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "infra", Labels: labels, CreationTimestamp: created},
+			Status: batchv1.JobStatus{
+				StartTime:      &created,
+				CompletionTime: &finished,
+				Conditions:     []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue, Reason: reason}},
+			},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "cronjob-cleanup", Namespace: "infra"},
+			Spec: batchv1.CronJobSpec{
+				Schedule: "30 3 * * *",
+				TimeZone: &timeZone,
+				JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Image: "alpine:3.20"}},
+				}}}},
+			},
+			Status: batchv1.CronJobStatus{LastScheduleTime: &lastRun},
+		},
+		job("cronjob-cleanup-29001", 30*time.Hour+30*time.Minute, batchv1.JobComplete, ""),
+		job("cronjob-cleanup-29002", 6*time.Hour+30*time.Minute, batchv1.JobFailed, "DeadlineExceeded"),
+	)
+
+	var out bytes.Buffer
+	module := newTestModule(nil, nil)
+	module.log = logger.NewStdLogger(&out)
+	if err := module.statusWithClient(context.Background(), client, now); err != nil {
+		t.Fatalf("statusWithClient() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"Last run:        6h ago",
+		// 03:30 in Berlin is 01:30 UTC the next day
+		"Next run:        in 15h (2026-10-15 03:30 CEST)",
+		"Last job cronjob-cleanup-29002 failed: DeadlineExceeded",
+		"cronjob-cleanup-29001                    Succeeded",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("status output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/cronjob.yaml
+var expectedCronJobYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := newTestModule(map[string]string{
+		"schedule":     "30 3 * * *",
+		"command":      "find /data/tmp -mtime +7 -delete",
+		"timeout":      "10m",
+		"secret_TOKEN": "token",
+	}, map[string]string{"DRY_RUN": "false"})
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/cronjob-cleanup/secret.yaml", expectedSecretYAML},
+		{"cronjob", "configs/cronjob-cleanup/cronjob.yaml", expectedCronJobYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,866 @@
+package crowdsec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage        = "crowdsecurity/crowdsec:v1.6.3"
+	defaultBouncerImage = "fbonalair/traefik-crowdsec-bouncer:0.5.0"
+	defaultStorageSize  = "1Gi"
+	// defaultCollections detect scans, CVE probes and brute-force logins in
+	// the ingress controller's access log
+	defaultCollections  = "crowdsecurity/nginx,crowdsecurity/base-http-scenarios,crowdsecurity/http-cve"
+	defaultLogPods      = "ingress-nginx-controller-*"
+	defaultLogNamespace = "ingress-nginx"
+	// lapiPort serves the local API the bouncer queries for decisions
+	lapiPort = 8080
+	// bouncerPort serves the forward-auth endpoint the ingress asks
+	bouncerPort = 8080
+	// bouncerName is the name the bouncer is registered under in the local API
+	bouncerName = "ingress"
+	// containerLogsPath is where the kubelet links the log file of every
+	// container on the node
+	containerLogsPath = "/var/log/containers"
+)
+
+var (
+	collectionPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	logGlobPattern    = regexp.MustCompile(`^[a-z0-9*.-]+$`)
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "128Mi", MemoryLimit: "512Mi"}
+
+type CrowdSecModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *CrowdSecModule {
+	return &CrowdSecModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *CrowdSecModule) Name() string {
+	return "crowdsec"
+}
+
+// Endpoint returns the host:port of the bouncer ingresses with crowdsec: true
+// send their requests to for a verdict
+func (m *CrowdSecModule) Endpoint() string {
+	return fmt.Sprintf("crowdsec-bouncer.%s.svc.cluster.local:%d", m.ModuleConfig.Namespace, bouncerPort)
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	BouncerAPIKey string `yaml:"bouncer_api_key" required:"true" generate:"true" doc:"Key the bouncer authenticates to the local API with, e.g. from openssl rand -hex 32"`
+	Collections   string `yaml:"collections" default:"crowdsecurity/nginx,crowdsecurity/base-http-scenarios,crowdsecurity/http-cve" doc:"Comma-separated hub collections installed in the agent"`
+	LogPods       string `yaml:"log_pods" default:"ingress-nginx-controller-*" doc:"Name glob of the ingress controller pods whose access logs are read"`
+	LogNamespace  string `yaml:"log_namespace" default:"ingress-nginx" doc:"Namespace of the ingress controller pods"`
+	BouncerImage  string `yaml:"bouncer_image" default:"fbonalair/traefik-crowdsec-bouncer:0.5.0" doc:"Bouncer container image"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *CrowdSecModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *CrowdSecModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: crowdsec\n\n")
+	m.log.Info("Description:\n  Deploys the CrowdSec agent and a forward-auth bouncer for the ingress controller.\n  Manages a Secret, ConfigMap (acquisition), PersistentVolumeClaim, two Services, and two Deployments.\n  The agent reads the ingress controller's access logs from the node and bans IPs\n  behind scans and brute-force logins; ingresses with crowdsec: true ask the bouncer\n  about every request and answer banned IPs with 403. Requests fail while the bouncer is down.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  bouncer_api_key   Key the bouncer authenticates with, e.g. from openssl rand -hex 32\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  collections       Comma-separated hub collections (default: %s)\n  log_pods          Name glob of the ingress controller pods (default: %s;\n                    nginx-ingress-microk8s-controller-* on microk8s)\n  log_namespace     Namespace of the ingress controller (default: %s; ingress on microk8s)\n  image             Agent container image (modules[].image, default: %s)\n  bouncer_image     Bouncer container image (default: %s)\n  storage           Size of the decisions volume (modules[].storage, default: %s)\n\n", defaultCollections, defaultLogPods, defaultLogNamespace, defaultImage, defaultBouncerImage, defaultStorageSize)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/crowdsec/\n  apply      Create/update resources in the cluster\n  clean      Delete all CrowdSec resources from the cluster, including the decisions\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
+	return nil
+}
+
+// collections parses the collections key
+func (m *CrowdSecModule) collections() ([]string, error) {
+	var collections []string
+	for _, collection := range strings.Split(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "collections", defaultCollections), ",") {
+		collection = strings.TrimSpace(collection)
+		if collection == "" {
+			continue
+		}
+		if !collectionPattern.MatchString(collection) {
+			return nil, fmt.Errorf("invalid collection %q: use author/name", collection)
+		}
+		collections = append(collections, collection)
+	}
+	if len(collections) == 0 {
+		return nil, fmt.Errorf("collections must name at least one hub collection")
+	}
+	return collections, nil
+}
+
+// acquis renders the agent's acquisition config reading the access logs of
+// the ingress controller. The cri-logs parser unwraps the container runtime's
+// log format before the nginx parsers see the lines.
+func (m *CrowdSecModule) acquis() (string, error) {
+	pods := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "log_pods", defaultLogPods)
+	namespace := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "log_namespace", defaultLogNamespace)
+	if !logGlobPattern.MatchString(pods) {
+		return "", fmt.Errorf("invalid log_pods %q", pods)
+	}
+	if !logGlobPattern.MatchString(namespace) {
+		return "", fmt.Errorf("invalid log_namespace %q", namespace)
+	}
+
+	var b strings.Builder
+	b.WriteString("filenames:\n")
+	fmt.Fprintf(&b, "  - %s/%s_%s_*.log\n", containerLogsPath, pods, namespace)
+	// The files are symlinks replaced on every pod restart
+	b.WriteString("force_inotify: true\n")
+	b.WriteString("poll_without_inotify: true\n")
+	b.WriteString("labels:\n")
+	b.WriteString("  type: containerd\n")
+	b.WriteString("  program: nginx\n")
+	return b.String(), nil
+}
+
+func (m *CrowdSecModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "crowdsec")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating CrowdSec Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	objs, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(objs.secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(objs.configMap, "configmap"); err != nil {
+		return err
+	}
+	if err := writeYAML(objs.pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(objs.agentService, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(objs.agent, "deployment"); err != nil {
+		return err
+	}
+	if err := writeYAML(objs.bouncerService, "bouncer-service"); err != nil {
+		return err
+	}
+	if err := writeYAML(objs.bouncer, "bouncer-deployment"); err != nil {
+		return err
+	}
+
+	m.log.Info("\nCompleted: 7/7 CrowdSec configurations generated successfully\n")
+	return nil
+}
+
+func (m *CrowdSecModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying CrowdSec Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	objs, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+	ns := m.ModuleConfig.Namespace
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().Secrets(ns).Get(ctx, objs.secret.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'", objs.secret.Name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().ConfigMaps(ns).Get(ctx, objs.configMap.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("configmap '%s' already exists in namespace '%s'", objs.configMap.Name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check configmap existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, objs.pvc.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", objs.pvc.Name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+	for _, service := range []*corev1.Service{objs.agentService, objs.bouncerService} {
+		if _, err := clientset.CoreV1().Services(ns).Get(ctx, service.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("service '%s' already exists in namespace '%s'", service.Name, ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check service existence: %w", err)
+		}
+	}
+	for _, deployment := range []*appsv1.Deployment{objs.agent, objs.bouncer} {
+		if _, err := clientset.AppsV1().Deployments(ns).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check deployment existence: %w", err)
+		}
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().Secrets(ns).Create(ctx, objs.secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: %s\n", objs.secret.Name)
+
+	if _, err := clientset.CoreV1().ConfigMaps(ns).Create(ctx, objs.configMap, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create configmap: %w", err)
+	}
+	m.log.Success("Created ConfigMap: %s\n", objs.configMap.Name)
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, objs.pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: %s\n", objs.pvc.Name)
+
+	// The agent registers the bouncer's key on start, so it goes first
+	for _, pair := range []struct {
+		service    *corev1.Service
+		deployment *appsv1.Deployment
+	}{{objs.agentService, objs.agent}, {objs.bouncerService, objs.bouncer}} {
+		if _, err := clientset.CoreV1().Services(ns).Create(ctx, pair.service, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create service: %w", err)
+		}
+		m.log.Success("Created Service: %s\n", pair.service.Name)
+
+		if _, err := clientset.AppsV1().Deployments(ns).Create(ctx, pair.deployment, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create deployment: %w", err)
+		}
+		m.log.Success("Created Deployment: %s\n", pair.deployment.Name)
+	}
+
+	m.log.Info("\nCompleted: CrowdSec configurations applied successfully\n")
+	m.log.Info("💡 Set crowdsec: true on the ingresses to protect and apply them again\n")
+	return nil
+}
+
+// objects holds the Kubernetes objects of the crowdsec module
+type objects struct {
+	secret         *corev1.Secret
+	configMap      *corev1.ConfigMap
+	pvc            *corev1.PersistentVolumeClaim
+	agentService   *corev1.Service
+	agent          *appsv1.Deployment
+	bouncerService *corev1.Service
+	bouncer        *appsv1.Deployment
+}
+
+// prepare creates and returns the Kubernetes objects for the crowdsec module
+func (m *CrowdSecModule) prepare() (*objects, error) {
+	apiKey := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "bouncer_api_key", "")
+	if apiKey == "" {
+		return nil, fmt.Errorf("bouncer_api_key not found in configuration")
+	}
+	collections, err := m.collections()
+	if err != nil {
+		return nil, err
+	}
+	acquis, err := m.acquis()
+	if err != nil {
+		return nil, err
+	}
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"app":        "crowdsec",
+		"managed-by": "personal-server",
+	}
+	bouncerLabels := map[string]string{
+		"app":        "crowdsec-bouncer",
+		"managed-by": "personal-server",
+	}
+	apiKeyRef := &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "crowdsec-secrets"},
+			Key:                  "BOUNCER_API_KEY",
+		},
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crowdsec-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"BOUNCER_API_KEY": []byte(apiKey),
+		},
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crowdsec-acquis",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"acquis.yaml": acquis,
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crowdsec-data-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	agentService := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crowdsec",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "lapi",
+					Port:       lapiPort,
+					TargetPort: intstr.FromInt(lapiPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "crowdsec",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(agentService, m.GeneralConfig.IPFamilyPolicy)
+
+	replicas := int32(1)
+	hostPathDirectory := corev1.HostPathDirectory
+	agent := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crowdsec",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			// The decisions database is a single SQLite file
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "crowdsec",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "crowdsec",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "crowdsec",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "lapi",
+									ContainerPort: lapiPort,
+								},
+							},
+							// The image installs the hub items and registers
+							// the bouncer key on every start
+							Env: []corev1.EnvVar{
+								{Name: "COLLECTIONS", Value: strings.Join(collections, " ")},
+								{Name: "PARSERS", Value: "crowdsecurity/cri-logs"},
+								{Name: "BOUNCER_KEY_" + bouncerName, ValueFrom: apiKeyRef},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromInt(lapiPort),
+									},
+								},
+								InitialDelaySeconds: 60,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromInt(lapiPort),
+									},
+								},
+								InitialDelaySeconds: 15,
+								PeriodSeconds:       10,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/var/lib/crowdsec/data",
+								},
+								{
+									Name:      "acquis",
+									MountPath: "/etc/crowdsec/acquis.yaml",
+									SubPath:   "acquis.yaml",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "logs",
+									MountPath: "/var/log",
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "crowdsec-data-pvc",
+								},
+							},
+						},
+						{
+							Name: "acquis",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "crowdsec-acquis"},
+								},
+							},
+						},
+						{
+							// /var/log/containers links into /var/log/pods
+							Name: "logs",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: "/var/log",
+									Type: &hostPathDirectory,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	bouncerService := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crowdsec-bouncer",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    bouncerLabels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       bouncerPort,
+					TargetPort: intstr.FromInt(bouncerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "crowdsec-bouncer",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(bouncerService, m.GeneralConfig.IPFamilyPolicy)
+
+	bouncer := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crowdsec-bouncer",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    bouncerLabels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "crowdsec-bouncer",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "crowdsec-bouncer",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "bouncer",
+							Image:           k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "bouncer_image", defaultBouncerImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: bouncerPort,
+								},
+							},
+							Env: []corev1.EnvVar{
+								{Name: "CROWDSEC_BOUNCER_API_KEY", ValueFrom: apiKeyRef},
+								{Name: "CROWDSEC_AGENT_HOST", Value: fmt.Sprintf("crowdsec:%d", lapiPort)},
+								{Name: "PORT", Value: fmt.Sprintf("%d", bouncerPort)},
+								{Name: "GIN_MODE", Value: "release"},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/api/v1/ping",
+										Port: intstr.FromInt(bouncerPort),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/api/v1/ping",
+										Port: intstr.FromInt(bouncerPort),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, deployment := range []*appsv1.Deployment{agent, bouncer} {
+		if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+			return nil, err
+		}
+		if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+			return nil, err
+		}
+		k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+		if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+			return nil, err
+		}
+		k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	}
+	if err := k8s.SetConfigChecksum(&agent.Spec.Template, secret, configMap); err != nil {
+		return nil, err
+	}
+	if err := k8s.SetConfigChecksum(&bouncer.Spec.Template, secret); err != nil {
+		return nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, configMap, pvc, agentService, agent, bouncerService, bouncer)
+	return &objects{
+		secret:         secret,
+		configMap:      configMap,
+		pvc:            pvc,
+		agentService:   agentService,
+		agent:          agent,
+		bouncerService: bouncerService,
+		bouncer:        bouncer,
+	}, nil
+}
+
+func (m *CrowdSecModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning CrowdSec Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+	ns := m.ModuleConfig.Namespace
+
+	for _, name := range []string{"crowdsec-bouncer", "crowdsec"} {
+		m.log.Info("🗑️  Processing Deployment: %s\n", name)
+		if err := clientset.AppsV1().Deployments(ns).Delete(ctx, name, deleteOptions); err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("Deployment '%s' not found\n", name)
+			} else {
+				m.log.Error("Failed to delete Deployment: %v\n", err)
+			}
+		} else {
+			m.log.Success("Deleted Deployment: %s\n", name)
+			successCount++
+		}
+
+		m.log.Info("🗑️  Processing Service: %s\n", name)
+		if err := clientset.CoreV1().Services(ns).Delete(ctx, name, deleteOptions); err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("Service '%s' not found\n", name)
+			} else {
+				m.log.Error("Failed to delete Service: %v\n", err)
+			}
+		} else {
+			m.log.Success("Deleted Service: %s\n", name)
+			successCount++
+		}
+	}
+
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: crowdsec-data-pvc\n")
+	if err := clientset.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, "crowdsec-data-pvc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'crowdsec-data-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: crowdsec-data-pvc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing ConfigMap: crowdsec-acquis\n")
+	if err := clientset.CoreV1().ConfigMaps(ns).Delete(ctx, "crowdsec-acquis", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("ConfigMap 'crowdsec-acquis' not found\n")
+		} else {
+			m.log.Error("Failed to delete ConfigMap: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted ConfigMap: crowdsec-acquis\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Secret: crowdsec-secrets\n")
+	if err := clientset.CoreV1().Secrets(ns).Delete(ctx, "crowdsec-secrets", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'crowdsec-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: crowdsec-secrets\n")
+		successCount++
+	}
+
+	m.log.Info("\nCompleted: %d CrowdSec resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+		m.log.Println("Ingresses with crowdsec: true reject requests until they are applied without it.")
+	}
+	return nil
+}
+
+func (m *CrowdSecModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking CrowdSec resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+	ns := m.ModuleConfig.Namespace
+
+	for _, name := range []string{"crowdsec", "crowdsec-bouncer"} {
+		deployment, err := clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("Deployment '%s' not found\n", name)
+			} else {
+				m.log.Error("Error getting Deployment: %v\n", err)
+			}
+		} else {
+			age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("DEPLOYMENT:\n")
+			m.log.Info("  Name:            %s\n", deployment.Name)
+			m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+			m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+			m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+			m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+			m.log.Println()
+		}
+
+		service, err := clientset.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("Service '%s' not found\n", name)
+			} else {
+				m.log.Error("Error getting Service: %v\n", err)
+			}
+		} else {
+			age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("SERVICE:\n")
+			m.log.Info("  Name:            %s\n", service.Name)
+			m.log.Info("  Type:            %s\n", service.Spec.Type)
+			m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+			m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+			m.log.Println()
+		}
+	}
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, "crowdsec-data-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'crowdsec-data-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps(ns).Get(ctx, "crowdsec-acquis", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("ConfigMap 'crowdsec-acquis' not found\n")
+		} else {
+			m.log.Error("Error getting ConfigMap: %v\n", err)
+		}
+	} else {
+		age := time.Since(configMap.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("CONFIGMAP:\n")
+		m.log.Info("  Name:            %s\n", configMap.Name)
+		m.log.Info("  Data keys:       %d\n", len(configMap.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	secret, err := clientset.CoreV1().Secrets(ns).Get(ctx, "crowdsec-secrets", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Secret 'crowdsec-secrets' not found\n")
+		} else {
+			m.log.Error("Error getting Secret: %v\n", err)
+		}
+	} else {
+		age := time.Since(secret.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SECRET:\n")
+		m.log.Info("  Name:            %s\n", secret.Name)
+		m.log.Info("  Type:            %s\n", secret.Type)
+		m.log.Info("  Data keys:       %d\n", len(secret.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app in (crowdsec,crowdsec-bouncer)",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No CrowdSec pods found")
+	}
+	return nil
+}
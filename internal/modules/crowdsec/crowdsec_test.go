@@ -0,0 +1,175 @@
+package crowdsec
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newTestModule(secrets map[string]string) *CrowdSecModule {
+	return &CrowdSecModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig:  config.Module{Name: "crowdsec", Namespace: "security", Secrets: secrets},
+		log:           logger.NewNopLogger(),
+	}
+}
+
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestCrowdSecModule_Name(t *testing.T) {
+	module := &CrowdSecModule{}
+	if module.Name() != "crowdsec" {
+		t.Errorf("Name() = %s, want crowdsec", module.Name())
+	}
+}
+
+func TestCrowdSecModule_Endpoint(t *testing.T) {
+	if got := newTestModule(nil).Endpoint(); got != "crowdsec-bouncer.security.svc.cluster.local:8080" {
+		t.Errorf("Endpoint() = %s, want crowdsec-bouncer.security.svc.cluster.local:8080", got)
+	}
+}
+
+func TestCrowdSecModule_Doc(t *testing.T) {
+	module := &CrowdSecModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestCrowdSecModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name            string
+		secrets         map[string]string
+		storage         string
+		wantCollections string
+		wantLogFile     string
+		wantErr         bool
+	}{
+		{
+			name:            "defaults",
+			secrets:         map[string]string{"bouncer_api_key": "key"},
+			wantCollections: "crowdsecurity/nginx crowdsecurity/base-http-scenarios crowdsecurity/http-cve",
+			wantLogFile:     "/var/log/containers/ingress-nginx-controller-*_ingress-nginx_*.log",
+		},
+		{
+			name:            "microk8s controller and extra collections",
+			secrets:         map[string]string{"bouncer_api_key": "key", "collections": "crowdsecurity/nginx, LePresidente/gitea", "log_pods": "nginx-ingress-microk8s-controller-*", "log_namespace": "ingress"},
+			wantCollections: "crowdsecurity/nginx LePresidente/gitea",
+			wantLogFile:     "/var/log/containers/nginx-ingress-microk8s-controller-*_ingress_*.log",
+		},
+		{name: "missing bouncer key", secrets: map[string]string{}, wantErr: true},
+		{name: "collection without author", secrets: map[string]string{"bouncer_api_key": "key", "collections": "nginx"}, wantErr: true},
+		{name: "no collections", secrets: map[string]string{"bouncer_api_key": "key", "collections": " , "}, wantErr: true},
+		{name: "log glob with a path", secrets: map[string]string{"bouncer_api_key": "key", "log_pods": "../*"}, wantErr: true},
+		{name: "bad storage size", secrets: map[string]string{"bouncer_api_key": "key"}, storage: "big", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets)
+			module.ModuleConfig.Storage = tt.storage
+			objs, err := module.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			env := objs.agent.Spec.Template.Spec.Containers[0].Env
+			if got := envValue(env, "COLLECTIONS"); got != tt.wantCollections {
+				t.Errorf("COLLECTIONS = %q, want %q", got, tt.wantCollections)
+			}
+			if want := "  - " + tt.wantLogFile + "\n"; !strings.Contains(objs.configMap.Data["acquis.yaml"], want) {
+				t.Errorf("acquis.yaml = %q, want file %s", objs.configMap.Data["acquis.yaml"], tt.wantLogFile)
+			}
+
+			// Agent and bouncer share the key the agent registers
+			var agentKey, bouncerKey *corev1.SecretKeySelector
+			for _, e := range env {
+				if e.Name == "BOUNCER_KEY_ingress" {
+					agentKey = e.ValueFrom.SecretKeyRef
+				}
+			}
+			for _, e := range objs.bouncer.Spec.Template.Spec.Containers[0].Env {
+				if e.Name == "CROWDSEC_BOUNCER_API_KEY" {
+					bouncerKey = e.ValueFrom.SecretKeyRef
+				}
+			}
+			if agentKey == nil || bouncerKey == nil || *agentKey != *bouncerKey {
+				t.Fatalf("bouncer key refs = %v and %v, want the same Secret key", agentKey, bouncerKey)
+			}
+			if string(objs.secret.Data[agentKey.Key]) != "key" {
+				t.Errorf("Secret %s = %q, want key", agentKey.Key, objs.secret.Data[agentKey.Key])
+			}
+		})
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/configmap.yaml
+var expectedConfigMapYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+//go:embed testdata/bouncer-deployment.yaml
+var expectedBouncerDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := newTestModule(map[string]string{
+		"bouncer_api_key": "key",
+	})
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/crowdsec/secret.yaml", expectedSecretYAML},
+		{"configmap", "configs/crowdsec/configmap.yaml", expectedConfigMapYAML},
+		{"deployment", "configs/crowdsec/deployment.yaml", expectedDeploymentYAML},
+		{"bouncer-deployment", "configs/crowdsec/bouncer-deployment.yaml", expectedBouncerDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
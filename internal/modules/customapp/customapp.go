@@ -0,0 +1,860 @@
+package customapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	defaultVolumeSize = "1Gi"
+
+	// secretEnvPrefix marks the modules[].secrets keys passed to the
+	// container from the app's Secret rather than read as settings
+	secretEnvPrefix = "secret_"
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "64Mi", MemoryLimit: "512Mi"}
+
+type CustomAppModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *CustomAppModule {
+	return &CustomAppModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+// Name returns the configured module name so several apps can be deployed
+// as customapp-<suffix>
+func (m *CustomAppModule) Name() string {
+	if m.ModuleConfig.Name == "" {
+		return "customapp"
+	}
+	return m.ModuleConfig.Name
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *CustomAppModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	Ports         string `yaml:"ports" required:"true" doc:"Comma-separated container ports as name=port, e.g. http=8080,metrics=9090; the Service exposes the same ports"`
+	Volumes       string `yaml:"volumes" doc:"Comma-separated PersistentVolumeClaims as name=mountPath[:size], e.g. data=/data:5Gi (default size: modules[].storage, else 1Gi)"`
+	Host          string `yaml:"host" doc:"Hostname routed to the first port through an Ingress; no Ingress is created when empty"`
+	TLS           string `yaml:"tls" doc:"Terminate TLS for host (default: general.ingressTLS.enabled)"`
+	ClusterIssuer string `yaml:"cluster_issuer" doc:"cert-manager ClusterIssuer issuing the TLS certificate, e.g. letsencrypt from the certmanager module (default: general.ingressTLS's when enabled)"`
+	SecretEnv     string `yaml:"secret_<NAME>" doc:"Stored in the <module>-secrets Secret and passed to the container as the environment variable NAME"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *CustomAppModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *CustomAppModule) secretName() string           { return m.Name() + "-secrets" }
+func (m *CustomAppModule) ingressName() string          { return m.Name() }
+func (m *CustomAppModule) tlsSecretName() string        { return m.Name() + "-tls" }
+func (m *CustomAppModule) pvcName(volume string) string { return m.Name() + "-" + volume + "-pvc" }
+
+func (m *CustomAppModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: %s (customapp)\n\n", m.Name())
+	m.log.Info("Description:\n  Deploys any container image described in the config, for small apps without a module of their own.\n  Manages a Secret, PersistentVolumeClaims, Service, Deployment, and Ingress, each only when configured.\n  Multiple apps can be deployed using the 'customapp-<suffix>' naming convention.\n\n")
+	m.log.Info("Required module fields:\n  image   Container image\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  ports            Comma-separated container ports as name=port, e.g. http=8080,metrics=9090\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  volumes          Comma-separated volumes as name=mountPath[:size], e.g. data=/data:5Gi\n                   (default size: modules[].storage, else %s)\n  host             Hostname routed to the first port through an Ingress\n  tls              Terminate TLS for host (default: general.ingressTLS.enabled)\n  cluster_issuer   cert-manager ClusterIssuer issuing the TLS certificate (default: general.ingressTLS's)\n  secret_<NAME>    Stored in the %s Secret and passed to the container as NAME\n\n", defaultVolumeSize, m.secretName())
+	m.log.Info("Optional module fields:\n  envs    Plain environment variables for the container\n\n")
+	m.log.Info("Probes:\n  Liveness and readiness probes connect to the first port. Setting liveness_path or\n  readiness_path turns the probe into an HTTP GET of that path.\n\n")
+	m.log.Info("Subcommands:\n  generate     Write Kubernetes YAML to configs/%s/\n  apply        Create/update resources in the cluster\n  clean        Delete all app resources from the cluster, volumes included\n  status       Print Deployment, Ingress, volume, and Pod status\n  doc          Show this documentation\n", m.Name())
+	return nil
+}
+
+func (m *CustomAppModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", m.Name())
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating %s Kubernetes configurations...\n", m.Name())
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	res, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if res.secret != nil {
+		if err := writeYAML(res.secret, "secret"); err != nil {
+			return err
+		}
+	}
+	for _, pvc := range res.pvcs {
+		if err := writeYAML(pvc, "pvc-"+pvc.Labels[volumeLabel]); err != nil {
+			return err
+		}
+	}
+	if err := writeYAML(res.service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(res.deployment, "deployment"); err != nil {
+		return err
+	}
+	if res.ingress != nil {
+		if err := writeYAML(res.ingress, "ingress"); err != nil {
+			return err
+		}
+	}
+	if res.monitor != nil {
+		if err := writeYAML(res.monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("\nCompleted: %s configurations generated successfully\n", m.Name())
+	return nil
+}
+
+func (m *CustomAppModule) Apply(ctx context.Context) error {
+	// Prepare Kubernetes objects first so invalid settings fail fast
+	res, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Applying %s Kubernetes configurations...\n", m.Name())
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	m.log.Info("Checking for existing resources...\n")
+	if res.secret != nil {
+		if _, err := clientset.CoreV1().Secrets(ns).Get(ctx, res.secret.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("secret '%s' already exists in namespace '%s'", res.secret.Name, ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check secret existence: %w", err)
+		}
+	}
+	for _, pvc := range res.pvcs {
+		if _, err := clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, pvc.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", pvc.Name, ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+		}
+	}
+	if _, err := clientset.CoreV1().Services(ns).Get(ctx, res.service.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", res.service.Name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(ns).Get(ctx, res.deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", res.deployment.Name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	if res.ingress != nil {
+		if _, err := clientset.NetworkingV1().Ingresses(ns).Get(ctx, res.ingress.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("ingress '%s' already exists in namespace '%s'", res.ingress.Name, ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check ingress existence: %w", err)
+		}
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if res.secret != nil {
+		if _, err := clientset.CoreV1().Secrets(ns).Create(ctx, res.secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create secret: %w", err)
+		}
+		m.log.Success("Created Secret: %s\n", res.secret.Name)
+	}
+	for _, pvc := range res.pvcs {
+		if _, err := clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+		}
+		m.log.Success("Created PersistentVolumeClaim: %s\n", pvc.Name)
+	}
+	if _, err := clientset.CoreV1().Services(ns).Create(ctx, res.service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", res.service.Name)
+	if _, err := clientset.AppsV1().Deployments(ns).Create(ctx, res.deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", res.deployment.Name)
+	if res.ingress != nil {
+		if _, err := clientset.NetworkingV1().Ingresses(ns).Create(ctx, res.ingress, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create ingress: %w", err)
+		}
+		m.log.Success("Created Ingress: %s\n", res.ingress.Name)
+	}
+	if res.monitor != nil {
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := res.monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", res.monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: %s configurations applied successfully\n", m.Name())
+	if res.ingress != nil {
+		m.log.Info("💡 Reachable at %s\n", m.url())
+	}
+	return nil
+}
+
+// port is a container port from the ports key
+type port struct {
+	name   string
+	number int32
+}
+
+// volume is a PersistentVolumeClaim from the volumes key
+type volume struct {
+	name      string
+	mountPath string
+	size      resource.Quantity
+}
+
+// volumeLabel records on a PVC which volumes entry it belongs to
+const volumeLabel = "volume"
+
+// ports parses the ports key
+func (m *CustomAppModule) ports() ([]port, error) {
+	var ports []port
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "ports", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid ports entry %q: use name=port", entry)
+		}
+		if errs := validation.IsValidPortName(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid port name %q: %s", name, strings.Join(errs, "; "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("port %q is listed twice", name)
+		}
+		seen[name] = true
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q of %s: must be between 1 and 65535", value, name)
+		}
+		ports = append(ports, port{name: name, number: int32(n)})
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("ports not found in configuration")
+	}
+	return ports, nil
+}
+
+// volumes parses the volumes key
+func (m *CustomAppModule) volumes() ([]volume, error) {
+	var volumes []volume
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "volumes", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		name, target = strings.TrimSpace(name), strings.TrimSpace(target)
+		if !ok || name == "" || target == "" {
+			return nil, fmt.Errorf("invalid volumes entry %q: use name=mountPath[:size]", entry)
+		}
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid volume name %q: %s", name, strings.Join(errs, "; "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("volume %q is listed twice", name)
+		}
+		seen[name] = true
+		mountPath, size, _ := strings.Cut(target, ":")
+		if !strings.HasPrefix(mountPath, "/") {
+			return nil, fmt.Errorf("invalid mount path %q of volume %s: must be absolute", mountPath, name)
+		}
+		// Volumes without a size of their own get the module's storage
+		quantity, err := m.ModuleConfig.StorageSize(defaultVolumeSize)
+		if size != "" {
+			if quantity, err = resource.ParseQuantity(size); err != nil {
+				err = fmt.Errorf("invalid size %q of volume %s: %w", size, name, err)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volume{name: name, mountPath: mountPath, size: quantity})
+	}
+	return volumes, nil
+}
+
+// secretEnv returns the secret_<NAME> keys as NAME -> value
+func (m *CustomAppModule) secretEnv() (map[string]string, error) {
+	env := make(map[string]string)
+	for key, value := range m.ModuleConfig.Secrets {
+		name, ok := strings.CutPrefix(key, secretEnvPrefix)
+		if !ok {
+			continue
+		}
+		if errs := validation.IsEnvVarName(name); name == "" || len(errs) > 0 {
+			return nil, fmt.Errorf("invalid key %s: %s must be a valid environment variable name", key, name)
+		}
+		if _, ok := m.ModuleConfig.Envs[name]; ok {
+			return nil, fmt.Errorf("%s is set both in envs and as %s", name, key)
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// tls reports whether the Ingress serves HTTPS: the tls key, else
+// general.ingressTLS.enabled
+func (m *CustomAppModule) tls() bool {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "tls", strconv.FormatBool(m.GeneralConfig.IngressTLS.Enabled)) == "true"
+}
+
+// clusterIssuer returns the ClusterIssuer of the TLS certificate, by default
+// general.ingressTLS's when that is enabled
+func (m *CustomAppModule) clusterIssuer() string {
+	issuer := ""
+	if m.GeneralConfig.IngressTLS.Enabled {
+		issuer = m.GeneralConfig.IngressTLS.Issuer()
+	}
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "cluster_issuer", issuer)
+}
+
+// url returns the address the Ingress serves the app at
+func (m *CustomAppModule) url() string {
+	scheme := "http"
+	if m.tls() {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "host", ""))
+}
+
+// resources holds the objects managed by the module. secret and ingress are
+// nil when no secret_* key or host is configured, monitor unless the metrics
+// section asks for a ServiceMonitor.
+type resources struct {
+	secret     *corev1.Secret
+	pvcs       []*corev1.PersistentVolumeClaim
+	service    *corev1.Service
+	deployment *appsv1.Deployment
+	ingress    *networkingv1.Ingress
+	monitor    *servicemonitor.Monitor
+}
+
+// prepare creates and returns the Kubernetes objects for the app
+func (m *CustomAppModule) prepare() (*resources, error) {
+	name := m.Name()
+	ns := m.ModuleConfig.Namespace
+	labels := map[string]string{
+		"app":        name,
+		"managed-by": "personal-server",
+	}
+
+	image := m.ModuleConfig.Image
+	if image == "" {
+		return nil, fmt.Errorf("image not found in configuration")
+	}
+	ports, err := m.ports()
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := m.volumes()
+	if err != nil {
+		return nil, err
+	}
+	secretEnv, err := m.secretEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &resources{}
+
+	// Plain variables first, then the secret ones, each sorted for stable output
+	var env []corev1.EnvVar
+	for _, key := range sortedKeys(m.ModuleConfig.Envs) {
+		env = append(env, corev1.EnvVar{Name: key, Value: m.ModuleConfig.Envs[key]})
+	}
+	if len(secretEnv) > 0 {
+		res.secret = &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.secretName(),
+				Namespace: ns,
+				Labels:    labels,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: make(map[string][]byte),
+		}
+		for _, key := range sortedKeys(secretEnv) {
+			res.secret.Data[key] = []byte(secretEnv[key])
+			env = append(env, corev1.EnvVar{
+				Name: key,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: m.secretName()},
+						Key:                  key,
+					},
+				},
+			})
+		}
+	}
+
+	var mounts []corev1.VolumeMount
+	var podVolumes []corev1.Volume
+	for _, v := range volumes {
+		pvcLabels := map[string]string{volumeLabel: v.name}
+		for key, value := range labels {
+			pvcLabels[key] = value
+		}
+		res.pvcs = append(res.pvcs, &corev1.PersistentVolumeClaim{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.pvcName(v.name),
+				Namespace: ns,
+				Labels:    pvcLabels,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: v.size,
+					},
+				},
+			},
+		})
+		k8s.SetStorageClass(&res.pvcs[len(res.pvcs)-1].Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+		mounts = append(mounts, corev1.VolumeMount{Name: v.name, MountPath: v.mountPath})
+		podVolumes = append(podVolumes, corev1.Volume{
+			Name: v.name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: m.pvcName(v.name),
+				},
+			},
+		})
+	}
+
+	var servicePorts []corev1.ServicePort
+	var containerPorts []corev1.ContainerPort
+	for _, p := range ports {
+		servicePorts = append(servicePorts, corev1.ServicePort{
+			Name:       p.name,
+			Port:       p.number,
+			TargetPort: intstr.FromString(p.name),
+			Protocol:   corev1.ProtocolTCP,
+		})
+		containerPorts = append(containerPorts, corev1.ContainerPort{
+			Name:          p.name,
+			ContainerPort: p.number,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+
+	res.service = &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: servicePorts,
+			Selector: map[string]string{
+				"app": name,
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(res.service, m.GeneralConfig.IPFamilyPolicy)
+
+	// Probes only check that the first port accepts connections unless the
+	// app has a health endpoint to ask
+	probe := func(prefix string, initialDelay int32) *corev1.Probe {
+		p := &corev1.Probe{
+			InitialDelaySeconds: initialDelay,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      3,
+		}
+		firstPort := intstr.FromString(ports[0].name)
+		if _, ok := m.ModuleConfig.Secrets[prefix+"_path"]; ok {
+			p.HTTPGet = &corev1.HTTPGetAction{Port: firstPort}
+		} else {
+			p.TCPSocket = &corev1.TCPSocketAction{Port: firstPort}
+		}
+		return p
+	}
+
+	replicas := int32(1)
+	strategy := appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+	if len(volumes) > 0 {
+		// The volumes are ReadWriteOnce
+		strategy.Type = appsv1.RecreateDeploymentStrategyType
+	}
+	res.deployment = &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: strategy,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": name,
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            name,
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
+							Ports:           containerPorts,
+							Env:             env,
+							LivenessProbe:   probe("liveness", 15),
+							ReadinessProbe:  probe("readiness", 5),
+							VolumeMounts:    mounts,
+						},
+					},
+					Volumes: podVolumes,
+				},
+			},
+		},
+	}
+
+	if err := k8s.ApplyProbeOverrides(&res.deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&res.deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+	k8s.SetResources(&res.deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&res.deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+
+	k8s.SetPodEnvironment(&res.deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if res.secret != nil {
+		if err := k8s.SetConfigChecksum(&res.deployment.Spec.Template, res.secret); err != nil {
+			return nil, err
+		}
+	}
+
+	res.ingress = m.ingress(ports[0], labels)
+	metadata := m.ModuleConfig.ObjectMetadata(m.GeneralConfig)
+	k8s.SetObjectMetadata(metadata, res.secret, res.service, res.deployment, res.ingress)
+	for _, pvc := range res.pvcs {
+		k8s.SetObjectMetadata(metadata, pvc)
+	}
+	res.monitor = servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, res.service)
+	return res, nil
+}
+
+// ingress builds the Ingress routing host to the first port, or returns nil
+// when no host is configured
+func (m *CustomAppModule) ingress(target port, labels map[string]string) *networkingv1.Ingress {
+	host := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "host", "")
+	if host == "" {
+		return nil
+	}
+	route := &moduleingress.Route{
+		Name:      m.ingressName(),
+		Namespace: m.ModuleConfig.Namespace,
+		Host:      host,
+		Path:      "/",
+		PathType:  networkingv1.PathTypePrefix,
+		Service:   m.Name(),
+		Port:      networkingv1.ServiceBackendPort{Name: target.name},
+		Labels:    labels,
+	}
+	if m.tls() {
+		route.TLSSecret = m.tlsSecretName()
+		route.ClusterIssuer = m.clusterIssuer()
+	}
+	return route.Ingress()
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *CustomAppModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dyn, err := m.GeneralConfig.Clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.cleanWithClient(ctx, clientset, dyn)
+}
+
+func (m *CustomAppModule) cleanWithClient(ctx context.Context, client k8s.KubernetesClient, dyn dynamic.Interface) error {
+	ns := m.ModuleConfig.Namespace
+	name := m.Name()
+	m.log.Info("Cleaning %s Kubernetes resources...\n", name)
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+	report := func(kind, objectName string, err error) {
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("%s '%s' not found\n", kind, objectName)
+			} else {
+				m.log.Error("Failed to delete %s: %v\n", kind, err)
+			}
+			return
+		}
+		m.log.Success("Deleted %s: %s\n", kind, objectName)
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Ingress: %s\n", m.ingressName())
+	report("Ingress", m.ingressName(), client.NetworkingV1().Ingresses(ns).Delete(ctx, m.ingressName(), deleteOptions))
+
+	if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", name)
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Deployment: %s\n", name)
+	report("Deployment", name, client.AppsV1().Deployments(ns).Delete(ctx, name, deleteOptions))
+
+	m.log.Info("🗑️  Processing Service: %s\n", name)
+	report("Service", name, client.CoreV1().Services(ns).Delete(ctx, name, deleteOptions))
+
+	// Found by label rather than the volumes key, so volumes removed from
+	// the config since the last apply go too
+	claims, err := client.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s,managed-by=personal-server", name),
+	})
+	if err != nil {
+		m.log.Error("Failed to list PersistentVolumeClaims: %v\n", err)
+	} else {
+		for _, pvc := range claims.Items {
+			m.log.Info("🗑️  Processing PersistentVolumeClaim: %s\n", pvc.Name)
+			report("PersistentVolumeClaim", pvc.Name, client.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, pvc.Name, deleteOptions))
+		}
+	}
+
+	m.log.Info("🗑️  Processing Secret: %s\n", m.secretName())
+	report("Secret", m.secretName(), client.CoreV1().Secrets(ns).Delete(ctx, m.secretName(), deleteOptions))
+
+	m.log.Info("\nCompleted: %d %s resources deleted successfully\n", successCount, name)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *CustomAppModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	name := m.Name()
+	m.log.Info("Checking %s resources in namespace '%s'...\n\n", name, ns)
+
+	deployment, err := clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment '%s' not found\n", name)
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Image:           %s\n", deployment.Spec.Template.Spec.Containers[0].Image)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	service, err := clientset.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service '%s' not found\n", name)
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		var ports []string
+		for _, p := range service.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%s=%d", p.Name, p.Port))
+		}
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Info("  Ports:           %s\n", strings.Join(ports, ","))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "host", "") != "" {
+		ingress, err := clientset.NetworkingV1().Ingresses(ns).Get(ctx, m.ingressName(), metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("Ingress '%s' not found\n", m.ingressName())
+			} else {
+				m.log.Error("Error getting Ingress: %v\n", err)
+			}
+		} else {
+			age := time.Since(ingress.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("INGRESS:\n")
+			m.log.Info("  Name:            %s\n", ingress.Name)
+			m.log.Info("  URL:             %s\n", m.url())
+			m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+			m.log.Println()
+		}
+	}
+
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s,managed-by=personal-server", name),
+	})
+	if err != nil {
+		m.log.Error("Error listing PersistentVolumeClaims: %v\n", err)
+	} else if len(claims.Items) > 0 {
+		m.log.Info("PERSISTENT VOLUME CLAIMS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "STATUS", "CAPACITY", "AGE")
+		for _, pvc := range claims.Items {
+			age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pvc.Name,
+				pvc.Status.Phase,
+				pvc.Status.Capacity.Storage().String(),
+				k8s.FormatAge(age))
+		}
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + name,
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No " + name + " pods found")
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package customapp
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCustomAppModule_Name(t *testing.T) {
+	tests := []struct {
+		configName string
+		want       string
+	}{
+		{configName: "", want: "customapp"},
+		{configName: "customapp-blog", want: "customapp-blog"},
+	}
+	for _, tt := range tests {
+		module := &CustomAppModule{ModuleConfig: config.Module{Name: tt.configName}}
+		if got := module.Name(); got != tt.want {
+			t.Errorf("Name() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestCustomAppModule_Doc(t *testing.T) {
+	module := &CustomAppModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func newTestModule(secrets, envs map[string]string) *CustomAppModule {
+	return &CustomAppModule{
+		ModuleConfig: config.Module{
+			Name:      "customapp-blog",
+			Namespace: "hobby",
+			Image:     "ghcr.io/example/blog:1.0",
+			Secrets:   secrets,
+			Envs:      envs,
+		},
+		log: logger.NewNopLogger(),
+	}
+}
+
+func TestCustomAppModule_Prepare(t *testing.T) {
+	module := newTestModule(map[string]string{
+		"ports":            "http=8080,metrics=9090",
+		"volumes":          "data=/var/lib/blog:5Gi, cache=/cache",
+		"host":             "blog.example.com",
+		"tls":              "true",
+		"cluster_issuer":   "letsencrypt",
+		"secret_API_TOKEN": "s3cret",
+		"liveness_path":    "/healthz",
+	}, map[string]string{"LOG_LEVEL": "debug"})
+
+	res, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+
+	if got := string(res.secret.Data["API_TOKEN"]); got != "s3cret" {
+		t.Errorf("API_TOKEN = %q, want s3cret", got)
+	}
+	if len(res.pvcs) != 2 || res.pvcs[0].Name != "customapp-blog-data-pvc" || res.pvcs[1].Name != "customapp-blog-cache-pvc" {
+		t.Fatalf("PVCs = %v, want customapp-blog-data-pvc and customapp-blog-cache-pvc", res.pvcs)
+	}
+	if got := res.pvcs[0].Spec.Resources.Requests.Storage().String(); got != "5Gi" {
+		t.Errorf("data size = %s, want 5Gi", got)
+	}
+	if got := res.pvcs[1].Spec.Resources.Requests.Storage().String(); got != defaultVolumeSize {
+		t.Errorf("cache size = %s, want %s", got, defaultVolumeSize)
+	}
+	if len(res.service.Spec.Ports) != 2 || res.service.Spec.Ports[1].Port != 9090 {
+		t.Errorf("Service ports = %v, want http=8080 and metrics=9090", res.service.Spec.Ports)
+	}
+
+	if res.deployment.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		t.Errorf("strategy = %s, want Recreate for ReadWriteOnce volumes", res.deployment.Spec.Strategy.Type)
+	}
+	container := res.deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != "ghcr.io/example/blog:1.0" {
+		t.Errorf("image = %s, want ghcr.io/example/blog:1.0", container.Image)
+	}
+	if len(container.Env) != 2 || container.Env[0].Name != "LOG_LEVEL" || container.Env[0].Value != "debug" {
+		t.Fatalf("Env = %+v, want LOG_LEVEL then API_TOKEN", container.Env)
+	}
+	if ref := container.Env[1].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "customapp-blog-secrets" || ref.SecretKeyRef.Key != "API_TOKEN" {
+		t.Errorf("API_TOKEN is not read from customapp-blog-secrets")
+	}
+	if container.VolumeMounts[0].MountPath != "/var/lib/blog" || container.VolumeMounts[1].MountPath != "/cache" {
+		t.Errorf("VolumeMounts = %v", container.VolumeMounts)
+	}
+	if probe := container.LivenessProbe; probe.HTTPGet == nil || probe.HTTPGet.Path != "/healthz" || probe.HTTPGet.Port.StrVal != "http" {
+		t.Errorf("liveness probe = %+v, want HTTP GET /healthz on http", probe)
+	}
+	if probe := container.ReadinessProbe; probe.TCPSocket == nil || probe.TCPSocket.Port.StrVal != "http" {
+		t.Errorf("readiness probe = %+v, want TCP on http", probe)
+	}
+	if res.deployment.Spec.Template.Annotations[k8s.ConfigChecksumAnnotation] == "" {
+		t.Error("pod template has no config checksum, pods would keep old secret values")
+	}
+
+	rule := res.ingress.Spec.Rules[0]
+	if rule.Host != "blog.example.com" || rule.HTTP.Paths[0].Backend.Service.Port.Name != "http" {
+		t.Errorf("ingress rule = %+v, want blog.example.com to port http", rule)
+	}
+	if len(res.ingress.Spec.TLS) != 1 || res.ingress.Spec.TLS[0].SecretName != "customapp-blog-tls" {
+		t.Errorf("ingress TLS = %+v, want customapp-blog-tls", res.ingress.Spec.TLS)
+	}
+	if got := res.ingress.Annotations["cert-manager.io/cluster-issuer"]; got != "letsencrypt" {
+		t.Errorf("cluster-issuer annotation = %q, want letsencrypt", got)
+	}
+}
+
+func TestCustomAppModule_PrepareMinimal(t *testing.T) {
+	res, err := newTestModule(map[string]string{"ports": "http=3000"}, nil).prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	if res.secret != nil || res.ingress != nil || len(res.pvcs) != 0 {
+		t.Errorf("got secret %v, ingress %v, PVCs %v; want none", res.secret, res.ingress, res.pvcs)
+	}
+	if res.deployment.Spec.Strategy.Type != appsv1.RollingUpdateDeploymentStrategyType {
+		t.Errorf("strategy = %s, want RollingUpdate without volumes", res.deployment.Spec.Strategy.Type)
+	}
+}
+
+func TestCustomAppModule_PrepareErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		noImage bool
+		secrets map[string]string
+		envs    map[string]string
+		want    string
+	}{
+		{name: "missing image", noImage: true, secrets: map[string]string{"ports": "http=80"}, want: "image not found"},
+		{name: "missing ports", want: "ports not found"},
+		{name: "port without name", secrets: map[string]string{"ports": "8080"}, want: "use name=port"},
+		{name: "invalid port name", secrets: map[string]string{"ports": "HTTP_PORT=80"}, want: "invalid port name"},
+		{name: "port out of range", secrets: map[string]string{"ports": "http=70000"}, want: "between 1 and 65535"},
+		{name: "duplicate port", secrets: map[string]string{"ports": "http=80,http=81"}, want: "listed twice"},
+		{name: "relative mount path", secrets: map[string]string{"ports": "http=80", "volumes": "data=data"}, want: "must be absolute"},
+		{name: "invalid volume size", secrets: map[string]string{"ports": "http=80", "volumes": "data=/data:lots"}, want: "invalid size"},
+		{name: "invalid volume name", secrets: map[string]string{"ports": "http=80", "volumes": "Data=/data"}, want: "invalid volume name"},
+		{name: "invalid secret env name", secrets: map[string]string{"ports": "http=80", "secret_1TOKEN": "x"}, want: "valid environment variable name"},
+		{name: "secret env also in envs", secrets: map[string]string{"ports": "http=80", "secret_TOKEN": "x"}, envs: map[string]string{"TOKEN": "y"}, want: "both in envs"},
+		{name: "invalid probe port", secrets: map[string]string{"ports": "http=80", "liveness_port": "0"}, want: "between 1 and 65535"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets, tt.envs)
+			if tt.noImage {
+				module.ModuleConfig.Image = ""
+			}
+			_, err := module.prepare()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("prepare() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomAppModule_CleanWithClient(t *testing.T) {
+	labels := map[string]string{"app": "customapp-blog", "managed-by": "personal-server"}
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "customapp-blog", Namespace: "hobby"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "customapp-blog", Namespace: "hobby"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "customapp-blog-secrets", Namespace: "hobby"}},
+		// No longer in the volumes key
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "customapp-blog-old-pvc", Namespace: "hobby", Labels: labels}},
+		// Another app's volume
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "gitea-data", Namespace: "hobby", Labels: map[string]string{"app": "gitea", "managed-by": "personal-server"}}},
+	)
+
+	module := newTestModule(map[string]string{"ports": "http=80"}, nil)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		servicemonitor.GVR: "ServiceMonitorList",
+	})
+	if err := module.cleanWithClient(context.Background(), client, dyn); err != nil {
+		t.Fatalf("cleanWithClient() error = %v", err)
+	}
+
+	claims, err := client.CoreV1().PersistentVolumeClaims("hobby").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims.Items) != 1 || claims.Items[0].Name != "gitea-data" {
+		t.Errorf("remaining PVCs = %v, want only gitea-data", claims.Items)
+	}
+	if _, err := client.AppsV1().Deployments("hobby").Get(context.Background(), "customapp-blog", metav1.GetOptions{}); err == nil {
+		t.Error("deployment customapp-blog should have been deleted")
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/pvc-data.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+//go:embed testdata/ingress.yaml
+var expectedIngressYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := newTestModule(map[string]string{
+		"ports":          "http=8080",
+		"volumes":        "data=/data:2Gi",
+		"host":           "blog.example.com",
+		"tls":            "true",
+		"cluster_issuer": "letsencrypt",
+		"secret_TOKEN":   "token",
+	}, map[string]string{"TZ": "UTC"})
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/customapp-blog/secret.yaml", expectedSecretYAML},
+		{"pvc", "configs/customapp-blog/pvc-data.yaml", expectedPvcYAML},
+		{"service", "configs/customapp-blog/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/customapp-blog/deployment.yaml", expectedDeploymentYAML},
+		{"ingress", "configs/customapp-blog/ingress.yaml", expectedIngressYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
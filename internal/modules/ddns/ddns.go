@@ -0,0 +1,660 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultImage    = "alpine:3.20"
+	defaultIPURL    = "https://api.ipify.org"
+	defaultInterval = "5m"
+	defaultSchedule = "*/5 * * * *"
+
+	modeDeployment = "deployment"
+	modeCronJob    = "cronjob"
+)
+
+// recordPattern matches a DNS name; records end up in URLs and JSON bodies
+var recordPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// updateScript defines update, which points the configured records at the
+// current public IPv4 address. The address is only sent when it changed.
+const updateScript = `apk add --no-cache curl jq >/dev/null || exit 1
+
+update() {
+  ip=$(curl -fsS --max-time 10 "$IP_URL") || { echo "failed to detect the public IP from $IP_URL"; return 1; }
+  case "$ip" in
+  "" | *[!0-9.]*) echo "unexpected public IP from $IP_URL: $ip"; return 1 ;;
+  esac
+  case "$DDNS_PROVIDER" in
+  duckdns)
+    result=$(curl -fsS --max-time 10 "https://www.duckdns.org/update?domains=$DDNS_RECORDS&token=$DUCKDNS_TOKEN&ip=$ip") || return 1
+    [ "$result" = OK ] || { echo "duckdns rejected the update: $result"; return 1; }
+    echo "duckdns: $DDNS_RECORDS -> $ip"
+    ;;
+  cloudflare)
+    api=https://api.cloudflare.com/client/v4
+    auth="Authorization: Bearer $CLOUDFLARE_API_TOKEN"
+    zone=$(curl -fsS --max-time 10 -H "$auth" "$api/zones?name=$CLOUDFLARE_ZONE" | jq -r '.result[0].id // empty')
+    [ -n "$zone" ] || { echo "cloudflare zone $CLOUDFLARE_ZONE not found"; return 1; }
+    for name in $(echo "$DDNS_RECORDS" | tr ',' ' '); do
+      record=$(curl -fsS --max-time 10 -H "$auth" "$api/zones/$zone/dns_records?type=A&name=$name" | jq -r '.result[0] // empty | .id + " " + .content') || return 1
+      if [ -z "$record" ]; then
+        curl -fsS --max-time 10 -X POST -H "$auth" -H "Content-Type: application/json" \
+          --data "{\"type\":\"A\",\"name\":\"$name\",\"content\":\"$ip\",\"ttl\":1,\"proxied\":$CLOUDFLARE_PROXIED}" \
+          "$api/zones/$zone/dns_records" >/dev/null || return 1
+        echo "cloudflare: created $name -> $ip"
+      elif [ "${record#* }" != "$ip" ]; then
+        curl -fsS --max-time 10 -X PATCH -H "$auth" -H "Content-Type: application/json" \
+          --data "{\"content\":\"$ip\"}" \
+          "$api/zones/$zone/dns_records/${record%% *}" >/dev/null || return 1
+        echo "cloudflare: updated $name -> $ip"
+      fi
+    done
+    ;;
+  esac
+}
+`
+
+// loopScript keeps the records up to date every $DDNS_INTERVAL seconds
+const loopScript = updateScript + `
+while true; do
+  update || echo "update failed, retrying in ${DDNS_INTERVAL}s"
+  sleep "$DDNS_INTERVAL"
+done
+`
+
+// onceScript updates the records once, failing the Job on error
+const onceScript = updateScript + `
+update
+`
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "16Mi", MemoryLimit: "64Mi"}
+
+type DDNSModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *DDNSModule {
+	return &DDNSModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *DDNSModule) Name() string {
+	return "ddns"
+}
+
+// DeploymentName returns the name of the module's Deployment, or "" in
+// cronjob mode, where no pod runs between checks
+func (m *DDNSModule) DeploymentName() string {
+	if mode, err := m.mode(); err != nil || mode == modeCronJob {
+		return ""
+	}
+	return "ddns"
+}
+
+// AppLabel returns the app label of the module's pods in either mode
+func (m *DDNSModule) AppLabel() string {
+	return "ddns"
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	Provider           string `yaml:"provider" required:"true" doc:"DNS provider to update: cloudflare or duckdns"`
+	CloudflareAPIToken string `yaml:"cloudflare_api_token" doc:"Cloudflare API token with Zone.DNS edit permission (required with cloudflare)"`
+	Zone               string `yaml:"zone" doc:"Cloudflare zone the records belong to (default: general.domain)"`
+	Proxied            string `yaml:"proxied" default:"false" doc:"\"true\" to proxy created Cloudflare records through Cloudflare"`
+	DuckDNSToken       string `yaml:"duckdns_token" doc:"DuckDNS account token (required with duckdns)"`
+	Records            string `yaml:"records" doc:"Comma-separated records to update: DNS names for cloudflare (default: general.domain), subdomains for duckdns (required)"`
+	Mode               string `yaml:"mode" default:"deployment" doc:"deployment to check every interval, or cronjob to run on schedule"`
+	Interval           string `yaml:"interval" default:"5m" doc:"Time between checks in deployment mode"`
+	Schedule           string `yaml:"schedule" default:"*/5 * * * *" doc:"Cron schedule of the checks in cronjob mode"`
+	IPURL              string `yaml:"ip_url" default:"https://api.ipify.org" doc:"URL returning the public IPv4 address as plain text"`
+
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *DDNSModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *DDNSModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: ddns\n\n")
+	m.log.Info("Description:\n  Keeps DNS records pointed at the public IPv4 address of the cluster's network.\n  Updates Cloudflare or DuckDNS records from a Deployment checking every interval,\n  or from a CronJob running on schedule. Records are only changed when the address changed.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  provider               cloudflare or duckdns\n  cloudflare_api_token   API token with Zone.DNS edit permission (cloudflare)\n  duckdns_token          Account token (duckdns)\n  records                Comma-separated DuckDNS subdomains (duckdns)\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  records                Comma-separated Cloudflare DNS names (default: general.domain)\n  zone                   Cloudflare zone of the records (default: general.domain)\n  proxied                \"true\" to proxy created Cloudflare records (default: false)\n  mode                   deployment or cronjob (default: %s)\n  interval               Time between checks in deployment mode (default: %s)\n  schedule               Cron schedule in cronjob mode (default: %s)\n  ip_url                 URL returning the public IPv4 address (default: %s)\n  image                  Alpine-based container image (modules[].image, default: %s)\n\n", modeDeployment, defaultInterval, defaultSchedule, defaultIPURL, defaultImage)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/ddns/\n  apply      Create/update resources in the cluster\n  clean      Delete all DDNS resources from the cluster\n  status     Print Deployment or CronJob status\n  doc        Show this documentation\n")
+	return nil
+}
+
+// mode returns the configured workload kind
+func (m *DDNSModule) mode() (string, error) {
+	mode := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "mode", modeDeployment)
+	if mode != modeDeployment && mode != modeCronJob {
+		return "", fmt.Errorf("invalid mode %q: must be %s or %s", mode, modeDeployment, modeCronJob)
+	}
+	return mode, nil
+}
+
+// records parses the comma-separated records key
+func records(value string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !recordPattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid record %q", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (m *DDNSModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "ddns")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating DDNS Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, deployment, cronJob, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if deployment != nil {
+		if err := writeYAML(deployment, "deployment"); err != nil {
+			return err
+		}
+	} else {
+		if err := writeYAML(cronJob, "cronjob"); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("\nCompleted: 2/2 DDNS configurations generated successfully\n")
+	return nil
+}
+
+func (m *DDNSModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying DDNS Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	secret, deployment, cronJob, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'", secret.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+	if deployment != nil {
+		if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check deployment existence: %w", err)
+		}
+	} else {
+		if _, err := clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Get(ctx, cronJob.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("CronJob '%s' already exists in namespace '%s'", cronJob.Name, m.ModuleConfig.Namespace)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check CronJob existence: %w", err)
+		}
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: %s\n", secret.Name)
+
+	if deployment != nil {
+		if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create deployment: %w", err)
+		}
+		m.log.Success("Created Deployment: %s\n", deployment.Name)
+	} else {
+		if _, err := clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Create(ctx, cronJob, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create CronJob: %w", err)
+		}
+		m.log.Success("Created CronJob: %s\n", cronJob.Name)
+	}
+
+	m.log.Info("\nCompleted: DDNS configurations applied successfully\n")
+	return nil
+}
+
+// prepare creates and returns the Kubernetes objects for the ddns module:
+// the Secret and either the Deployment or the CronJob, depending on mode
+func (m *DDNSModule) prepare() (*corev1.Secret, *appsv1.Deployment, *batchv1.CronJob, error) {
+	mode, err := m.mode()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	provider := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "provider", "")
+	env := []corev1.EnvVar{
+		{Name: "DDNS_PROVIDER", Value: provider},
+		{Name: "IP_URL", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "ip_url", defaultIPURL)},
+	}
+	secretEnv := func(name string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ddns-secrets"},
+					Key:                  name,
+				},
+			},
+		}
+	}
+
+	secretData := map[string][]byte{}
+	switch provider {
+	case "cloudflare":
+		token := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "cloudflare_api_token", "")
+		if token == "" {
+			return nil, nil, nil, fmt.Errorf("cloudflare_api_token not found in configuration")
+		}
+		names, err := records(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "records", m.GeneralConfig.Domain))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(names) == 0 {
+			return nil, nil, nil, fmt.Errorf("records not found in configuration and general.domain is not set")
+		}
+		zone := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "zone", m.GeneralConfig.Domain)
+		if !recordPattern.MatchString(zone) {
+			return nil, nil, nil, fmt.Errorf("invalid zone %q", zone)
+		}
+		proxied := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "proxied", "false")
+		if proxied != "true" && proxied != "false" {
+			return nil, nil, nil, fmt.Errorf("invalid proxied %q: must be true or false", proxied)
+		}
+		secretData["CLOUDFLARE_API_TOKEN"] = []byte(token)
+		env = append(env,
+			corev1.EnvVar{Name: "DDNS_RECORDS", Value: strings.Join(names, ",")},
+			corev1.EnvVar{Name: "CLOUDFLARE_ZONE", Value: zone},
+			corev1.EnvVar{Name: "CLOUDFLARE_PROXIED", Value: proxied},
+			secretEnv("CLOUDFLARE_API_TOKEN"),
+		)
+	case "duckdns":
+		token := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "duckdns_token", "")
+		if token == "" {
+			return nil, nil, nil, fmt.Errorf("duckdns_token not found in configuration")
+		}
+		names, err := records(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "records", ""))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(names) == 0 {
+			return nil, nil, nil, fmt.Errorf("records not found in configuration: list the DuckDNS subdomains to update")
+		}
+		secretData["DUCKDNS_TOKEN"] = []byte(token)
+		env = append(env,
+			corev1.EnvVar{Name: "DDNS_RECORDS", Value: strings.Join(names, ",")},
+			secretEnv("DUCKDNS_TOKEN"),
+		)
+	case "":
+		return nil, nil, nil, fmt.Errorf("provider not found in configuration")
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown provider %q: must be cloudflare or duckdns", provider)
+	}
+
+	labels := map[string]string{
+		"app":        "ddns",
+		"managed-by": "personal-server",
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ddns-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: secretData,
+	}
+
+	image := m.ModuleConfig.ContainerImage(defaultImage)
+
+	if mode == modeCronJob {
+		schedule := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "schedule", defaultSchedule)
+		if err := k8s.ValidateSchedule("schedule", schedule); err != nil {
+			return nil, nil, nil, err
+		}
+		historyLimit := int32(3)
+		backoffLimit := int32(0)
+		cronJob := &batchv1.CronJob{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "batch/v1",
+				Kind:       "CronJob",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ddns",
+				Namespace: m.ModuleConfig.Namespace,
+				Labels:    labels,
+			},
+			Spec: batchv1.CronJobSpec{
+				Schedule:                   schedule,
+				ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+				SuccessfulJobsHistoryLimit: &historyLimit,
+				FailedJobsHistoryLimit:     &historyLimit,
+				JobTemplate: batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						BackoffLimit: &backoffLimit,
+						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{
+								Labels: map[string]string{
+									"app": "ddns",
+								},
+							},
+							Spec: corev1.PodSpec{
+								RestartPolicy: corev1.RestartPolicyNever,
+								Containers: []corev1.Container{
+									{
+										Name:            "ddns",
+										Image:           image,
+										ImagePullPolicy: corev1.PullIfNotPresent,
+										Command:         []string{"sh", "-c", onceScript},
+										Env:             env,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		if m.GeneralConfig.Timezone != "" {
+			// Without a time zone the schedule is read in the controller's zone,
+			// usually UTC
+			cronJob.Spec.TimeZone = &m.GeneralConfig.Timezone
+		}
+		k8s.SetPodEnvironment(&cronJob.Spec.JobTemplate.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+		return secret, nil, cronJob, nil
+	}
+
+	intervalValue := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "interval", defaultInterval)
+	interval, err := time.ParseDuration(intervalValue)
+	if err != nil || interval < time.Minute {
+		return nil, nil, nil, fmt.Errorf("invalid interval %q: want a duration of at least 1m, e.g. 5m", intervalValue)
+	}
+	env = append(env, corev1.EnvVar{Name: "DDNS_INTERVAL", Value: strconv.Itoa(int(interval.Seconds()))})
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ddns",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "ddns",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "ddns",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "ddns",
+							Image:           image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command:         []string{"sh", "-c", loopScript},
+							Env:             env,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, deployment)
+	return secret, deployment, nil, nil
+}
+
+func (m *DDNSModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning DDNS Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	// Both workloads are deleted so switching mode leaves nothing behind
+	m.log.Info("🗑️  Processing Deployment: ddns\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "ddns", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Info("Deployment 'ddns' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: ddns\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing CronJob: ddns\n")
+	if err := clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Delete(ctx, "ddns", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Info("CronJob 'ddns' not found\n")
+		} else {
+			m.log.Error("Failed to delete CronJob: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted CronJob: ddns\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Secret: ddns-secrets\n")
+	if err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Delete(ctx, "ddns-secrets", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'ddns-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: ddns-secrets\n")
+		successCount++
+	}
+
+	m.log.Info("\nCompleted: %d DDNS resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+		m.log.Println("The DNS records keep their last address.")
+	}
+	return nil
+}
+
+func (m *DDNSModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	mode, err := m.mode()
+	if err != nil {
+		return err
+	}
+
+	m.log.Info("Checking DDNS resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	if mode == modeCronJob {
+		cronJob, err := clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Get(ctx, "ddns", metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("CronJob 'ddns' not found\n")
+			} else {
+				m.log.Error("Error getting CronJob: %v\n", err)
+			}
+		} else {
+			m.log.Info("CRONJOB:\n")
+			m.log.Info("  Name:            %s\n", cronJob.Name)
+			m.log.Info("  Schedule:        %s\n", cronJob.Spec.Schedule)
+			m.log.Info("  Active jobs:     %d\n", len(cronJob.Status.Active))
+			if t := cronJob.Status.LastScheduleTime; t != nil {
+				m.log.Info("  Last run:        %s ago\n", k8s.FormatAge(time.Since(t.Time).Round(time.Second)))
+			}
+			if t := cronJob.Status.LastSuccessfulTime; t != nil {
+				m.log.Info("  Last success:    %s ago\n", k8s.FormatAge(time.Since(t.Time).Round(time.Second)))
+			}
+			m.log.Println()
+		}
+	} else {
+		deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "ddns", metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("Deployment 'ddns' not found\n")
+			} else {
+				m.log.Error("Error getting Deployment: %v\n", err)
+			}
+		} else {
+			age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("DEPLOYMENT:\n")
+			m.log.Info("  Name:            %s\n", deployment.Name)
+			m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+			m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+			m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+			m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+			m.log.Println()
+		}
+	}
+
+	secret, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "ddns-secrets", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Secret 'ddns-secrets' not found\n")
+		} else {
+			m.log.Error("Error getting Secret: %v\n", err)
+		}
+	} else {
+		age := time.Since(secret.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SECRET:\n")
+		m.log.Info("  Name:            %s\n", secret.Name)
+		m.log.Info("  Type:            %s\n", secret.Type)
+		m.log.Info("  Data keys:       %d\n", len(secret.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=ddns",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No DDNS pods found")
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package ddns
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newTestModule(secrets map[string]string) *DDNSModule {
+	return &DDNSModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig:  config.Module{Name: "ddns", Namespace: "infra", Secrets: secrets},
+		log:           logger.NewNopLogger(),
+	}
+}
+
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestDDNSModule_Name(t *testing.T) {
+	module := &DDNSModule{}
+	if module.Name() != "ddns" {
+		t.Errorf("Name() = %s, want ddns", module.Name())
+	}
+}
+
+func TestDDNSModule_Doc(t *testing.T) {
+	module := &DDNSModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestDDNSModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name        string
+		secrets     map[string]string
+		wantCronJob bool
+		wantRecords string
+		wantToken   string
+		wantErr     bool
+	}{
+		{
+			name:        "cloudflare defaults to the general domain",
+			secrets:     map[string]string{"provider": "cloudflare", "cloudflare_api_token": "cf-token"},
+			wantRecords: "example.com",
+			wantToken:   "CLOUDFLARE_API_TOKEN",
+		},
+		{
+			name:        "duckdns on a schedule",
+			secrets:     map[string]string{"provider": "duckdns", "duckdns_token": "duck-token", "records": "myhome, myhome-vpn", "mode": "cronjob", "schedule": "@hourly"},
+			wantCronJob: true,
+			wantRecords: "myhome,myhome-vpn",
+			wantToken:   "DUCKDNS_TOKEN",
+		},
+		{name: "missing provider", secrets: map[string]string{}, wantErr: true},
+		{name: "unknown provider", secrets: map[string]string{"provider": "route53"}, wantErr: true},
+		{name: "cloudflare without token", secrets: map[string]string{"provider": "cloudflare"}, wantErr: true},
+		{name: "duckdns without records", secrets: map[string]string{"provider": "duckdns", "duckdns_token": "t"}, wantErr: true},
+		{name: "record with a quote", secrets: map[string]string{"provider": "cloudflare", "cloudflare_api_token": "t", "records": `home"}`}, wantErr: true},
+		{name: "unknown mode", secrets: map[string]string{"provider": "cloudflare", "cloudflare_api_token": "t", "mode": "daemonset"}, wantErr: true},
+		{name: "interval too short", secrets: map[string]string{"provider": "cloudflare", "cloudflare_api_token": "t", "interval": "10s"}, wantErr: true},
+		{name: "bad schedule", secrets: map[string]string{"provider": "cloudflare", "cloudflare_api_token": "t", "mode": "cronjob", "schedule": "hourly"}, wantErr: true},
+		{name: "bad proxied", secrets: map[string]string{"provider": "cloudflare", "cloudflare_api_token": "t", "proxied": "yes"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, deployment, cronJob, err := newTestModule(tt.secrets).prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if (cronJob != nil) != tt.wantCronJob || (deployment != nil) == tt.wantCronJob {
+				t.Fatalf("prepare() deployment = %v, cronJob = %v, want cronJob %v", deployment != nil, cronJob != nil, tt.wantCronJob)
+			}
+			if _, ok := secret.Data[tt.wantToken]; !ok || len(secret.Data) != 1 {
+				t.Errorf("Secret keys = %v, want only %s", secret.Data, tt.wantToken)
+			}
+			var container corev1.Container
+			if cronJob != nil {
+				container = cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+			} else {
+				container = deployment.Spec.Template.Spec.Containers[0]
+				if got := envValue(container.Env, "DDNS_INTERVAL"); got != "300" {
+					t.Errorf("DDNS_INTERVAL = %s, want 300", got)
+				}
+			}
+			if got := envValue(container.Env, "DDNS_RECORDS"); got != tt.wantRecords {
+				t.Errorf("DDNS_RECORDS = %s, want %s", got, tt.wantRecords)
+			}
+		})
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := newTestModule(map[string]string{
+		"provider":             "cloudflare",
+		"cloudflare_api_token": "token",
+		"records":              "example.com,home.example.com",
+	})
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/ddns/secret.yaml", expectedSecretYAML},
+		{"deployment", "configs/ddns/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
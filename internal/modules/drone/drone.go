@@ -11,6 +11,8 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -19,6 +21,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "64Mi", MemoryLimit: "256Mi"}
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "drone/drone:2"
+
 type DroneModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -37,10 +47,40 @@ func (m *DroneModule) Name() string {
 	return "drone"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *DroneModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// Dependencies lists the modules that must be running before drone is applied
+func (m *DroneModule) Dependencies() []string {
+	return []string{"gitea"}
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	GiteaClientID     string `yaml:"drone_gitea_client_id" required:"true" doc:"OAuth2 client ID from Gitea for Drone authentication"`
+	GiteaClientSecret string `yaml:"drone_gitea_client_secret" required:"true" doc:"OAuth2 client secret from Gitea"`
+	RPCSecret         string `yaml:"drone_rpc_secret" required:"true" generate:"true" doc:"Shared RPC secret between Drone server and runner"`
+	ServerProto       string `yaml:"drone_server_proto" required:"true" doc:"Protocol used to access Drone (http or https)"`
+	ServerHost        string `yaml:"drone_server_host" required:"true" doc:"Public hostname of the Drone server"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *DroneModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *DroneModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: drone\n\n")
 	m.log.Info("Description:\n  Deploys Drone CI — a container-native continuous integration server.\n  Integrates with Gitea for source code management.\n  Manages a Secret, Role, RoleBinding, two Deployments (server + runner), and a Service.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  drone_gitea_client_id       OAuth2 client ID from Gitea for Drone authentication\n  drone_gitea_client_secret   OAuth2 client secret from Gitea\n  drone_rpc_secret            Shared RPC secret between Drone server and runner\n  drone_server_proto          Protocol used to access Drone (http or https)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/drone/\n  apply      Create/update resources in the cluster\n  clean      Delete all Drone resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
 	return nil
 }
@@ -58,7 +98,10 @@ func (m *DroneModule) Generate(ctx context.Context) error {
 	m.log.Info("Output directory: %s\n\n", outputDir)
 
 	// Prepare Kubernetes objects
-	secret, role, roleBinding, deployment, runnerDeployment, service := m.prepare()
+	secret, role, roleBinding, deployment, runnerDeployment, service, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -107,14 +150,37 @@ func (m *DroneModule) Generate(ctx context.Context) error {
 	if err := writeYAML(service, "service"); err != nil {
 		return err
 	}
+	total := 6
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
 
-	m.log.Info("\nCompleted: 6/6 Drone configurations generated successfully\n")
+	m.log.Info("\nCompleted: %d/%d Drone configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *DroneModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -169,7 +235,10 @@ func (m *DroneModule) Apply(ctx context.Context) error {
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
-	secret, role, roleBinding, deployment, runnerDeployment, service := m.prepare()
+	secret, role, roleBinding, deployment, runnerDeployment, service, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Apply Secret
 	m.log.Progress("Applying Secret: drone-secrets\n")
@@ -219,13 +288,36 @@ func (m *DroneModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Service: drone\n")
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: Drone configurations applied successfully\n")
 	return nil
 }
 
 // prepare creates and returns the Kubernetes objects for drone module
-func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBinding, *appsv1.Deployment, *appsv1.Deployment, *corev1.Service) {
-	// Prepare Secret
+func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBinding, *appsv1.Deployment, *appsv1.Deployment, *corev1.Service, error) {
+	// Prepare Secret. Keys are the server's environment variable names, as
+	// the server container loads the whole Secret with envFrom.
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "drone-secrets",
@@ -233,12 +325,12 @@ func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBindi
 		},
 		Type: corev1.SecretTypeOpaque,
 		StringData: map[string]string{
-			"drone_gitea_server":        "https://gitea." + m.GeneralConfig.Domain,
-			"drone_gitea_client_id":     m.ModuleConfig.Secrets["drone_gitea_client_id"],
-			"drone_gitea_client_secret": m.ModuleConfig.Secrets["drone_gitea_client_secret"],
-			"drone_rpc_secret":          m.ModuleConfig.Secrets["drone_rpc_secret"],
-			"drone_server_host":         "drone." + m.GeneralConfig.Domain,
-			"drone_server_proto":        m.ModuleConfig.Secrets["drone_server_proto"],
+			"DRONE_GITEA_SERVER":        "https://gitea." + m.GeneralConfig.Domain,
+			"DRONE_GITEA_CLIENT_ID":     m.ModuleConfig.Secrets["drone_gitea_client_id"],
+			"DRONE_GITEA_CLIENT_SECRET": m.ModuleConfig.Secrets["drone_gitea_client_secret"],
+			"DRONE_RPC_SECRET":          m.ModuleConfig.Secrets["drone_rpc_secret"],
+			"DRONE_SERVER_HOST":         "drone." + m.GeneralConfig.Domain,
+			"DRONE_SERVER_PROTO":        m.ModuleConfig.Secrets["drone_server_proto"],
 		},
 	}
 
@@ -309,7 +401,7 @@ func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBindi
 					Containers: []corev1.Container{
 						{
 							Name:            "drone",
-							Image:           "drone/drone:2",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Ports: []corev1.ContainerPort{
 								{
@@ -317,70 +409,11 @@ func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBindi
 									ContainerPort: 80,
 								},
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name: "DRONE_SERVER_HOST",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: "drone-secrets",
-											},
-											Key: "drone_server_host",
-										},
-									},
-								},
+							EnvFrom: []corev1.EnvFromSource{
 								{
-									Name: "DRONE_SERVER_PROTO",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: "drone-secrets",
-											},
-											Key: "drone_server_proto",
-										},
-									},
-								},
-								{
-									Name: "DRONE_GITEA_SERVER",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: "drone-secrets",
-											},
-											Key: "drone_gitea_server",
-										},
-									},
-								},
-								{
-									Name: "DRONE_GITEA_CLIENT_ID",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: "drone-secrets",
-											},
-											Key: "drone_gitea_client_id",
-										},
-									},
-								},
-								{
-									Name: "DRONE_GITEA_CLIENT_SECRET",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: "drone-secrets",
-											},
-											Key: "drone_gitea_client_secret",
-										},
-									},
-								},
-								{
-									Name: "DRONE_RPC_SECRET",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: "drone-secrets",
-											},
-											Key: "drone_rpc_secret",
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "drone-secrets",
 										},
 									},
 								},
@@ -446,6 +479,26 @@ func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBindi
 									ContainerPort: 3000,
 								},
 							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/healthz",
+										Port: intstr.FromInt(3000),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/healthz",
+										Port: intstr.FromInt(3000),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 							Env: []corev1.EnvVar{
 								{
 									Name:  "DRONE_RPC_HOST",
@@ -462,7 +515,7 @@ func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBindi
 											LocalObjectReference: corev1.LocalObjectReference{
 												Name: "drone-secrets",
 											},
-											Key: "drone_rpc_secret",
+											Key: "DRONE_RPC_SECRET",
 										},
 									},
 								},
@@ -498,13 +551,35 @@ func (m *DroneModule) prepare() (*corev1.Secret, *rbacv1.Role, *rbacv1.RoleBindi
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
 
-	return secret, role, roleBinding, deployment, runnerDeployment, service
+	k8s.SetPodEnvironment(&runnerDeployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&runnerDeployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, role, roleBinding, deployment, runnerDeployment, service)
+	return secret, role, roleBinding, deployment, runnerDeployment, service, nil
 }
 
 func (m *DroneModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -603,6 +678,22 @@ func (m *DroneModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d/6 drone resources deleted successfully\n", successCount)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -612,7 +703,7 @@ func (m *DroneModule) Clean(ctx context.Context) error {
 
 func (m *DroneModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
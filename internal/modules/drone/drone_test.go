@@ -58,7 +58,10 @@ func TestDroneModule_Prepare(t *testing.T) {
 				},
 			}
 
-			secret, role, roleBinding, deployment, runnerDeployment, service := module.prepare()
+			secret, role, roleBinding, deployment, runnerDeployment, service, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			// Verify all objects are not nil
 			if secret == nil {
@@ -115,7 +118,10 @@ func TestDroneModule_PrepareSecret(t *testing.T) {
 		},
 	}
 
-	secret, _, _, _, _, _ := module.prepare()
+	secret, _, _, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Secret name
 	if secret.Name != "drone-secrets" {
@@ -129,14 +135,14 @@ func TestDroneModule_PrepareSecret(t *testing.T) {
 
 	// Test Secret data includes gitea server URL with domain
 	expectedGiteaServer := "https://gitea." + domain
-	if secret.StringData["drone_gitea_server"] != expectedGiteaServer {
-		t.Errorf("Secret drone_gitea_server = %s, want %s", secret.StringData["drone_gitea_server"], expectedGiteaServer)
+	if secret.StringData["DRONE_GITEA_SERVER"] != expectedGiteaServer {
+		t.Errorf("Secret DRONE_GITEA_SERVER = %s, want %s", secret.StringData["DRONE_GITEA_SERVER"], expectedGiteaServer)
 	}
 
 	// Test Secret data includes server host with domain
 	expectedServerHost := "drone." + domain
-	if secret.StringData["drone_server_host"] != expectedServerHost {
-		t.Errorf("Secret drone_server_host = %s, want %s", secret.StringData["drone_server_host"], expectedServerHost)
+	if secret.StringData["DRONE_SERVER_HOST"] != expectedServerHost {
+		t.Errorf("Secret DRONE_SERVER_HOST = %s, want %s", secret.StringData["DRONE_SERVER_HOST"], expectedServerHost)
 	}
 }
 
@@ -152,7 +158,10 @@ func TestDroneModule_PrepareRole(t *testing.T) {
 		},
 	}
 
-	_, role, _, _, _, _ := module.prepare()
+	_, role, _, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Role name
 	if role.Name != "drone" {
@@ -206,7 +215,10 @@ func TestDroneModule_PrepareRoleBinding(t *testing.T) {
 		},
 	}
 
-	_, _, roleBinding, _, _, _ := module.prepare()
+	_, _, roleBinding, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test RoleBinding name
 	if roleBinding.Name != "drone" {
@@ -252,7 +264,10 @@ func TestDroneModule_PrepareDeployment(t *testing.T) {
 		},
 	}
 
-	_, _, _, deployment, _, _ := module.prepare()
+	_, _, _, deployment, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Deployment name
 	if deployment.Name != "drone" {
@@ -290,7 +305,10 @@ func TestDroneModule_PrepareDeploymentContainer(t *testing.T) {
 		},
 	}
 
-	_, _, _, deployment, _, _ := module.prepare()
+	_, _, _, deployment, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify container count
 	if len(deployment.Spec.Template.Spec.Containers) != 1 {
@@ -325,18 +343,12 @@ func TestDroneModule_PrepareDeploymentContainer(t *testing.T) {
 		t.Error("Container ReadinessProbe is nil")
 	}
 
-	// Verify env vars use secret references
-	envCount := 0
-	for _, env := range container.Env {
-		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
-			envCount++
-			if env.ValueFrom.SecretKeyRef.Name != "drone-secrets" {
-				t.Errorf("Env %s secret name = %s, want drone-secrets", env.Name, env.ValueFrom.SecretKeyRef.Name)
-			}
-		}
+	// Verify the whole Secret is loaded into the environment
+	if len(container.EnvFrom) != 1 || container.EnvFrom[0].SecretRef == nil || container.EnvFrom[0].SecretRef.Name != "drone-secrets" {
+		t.Errorf("Container EnvFrom = %+v, want drone-secrets", container.EnvFrom)
 	}
-	if envCount < 5 {
-		t.Errorf("Expected at least 5 env vars with secret refs, got %d", envCount)
+	if len(container.Env) != 0 {
+		t.Errorf("Container Env = %+v, want none besides EnvFrom", container.Env)
 	}
 }
 
@@ -352,7 +364,10 @@ func TestDroneModule_PrepareRunnerDeployment(t *testing.T) {
 		},
 	}
 
-	_, _, _, _, runnerDeployment, _ := module.prepare()
+	_, _, _, _, runnerDeployment, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Deployment name
 	if runnerDeployment.Name != "drone-runner" {
@@ -398,7 +413,10 @@ func TestDroneModule_PrepareService(t *testing.T) {
 		},
 	}
 
-	_, _, _, _, _, service := module.prepare()
+	_, _, _, _, _, service, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Service name
 	if service.Name != "drone" {
@@ -1,11 +1,14 @@
 package gitea
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,14 +16,28 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	"github.com/Goalt/personal-server/internal/servicetls"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "1Gi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "10Gi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "gitea/gitea:1.25"
+
 type GiteaModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -39,11 +56,45 @@ func (m *GiteaModule) Name() string {
 	return "gitea"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *GiteaModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// Dependencies lists the modules that must be running before gitea is applied
+func (m *GiteaModule) Dependencies() []string {
+	return []string{"postgres"}
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	DBUser                string `yaml:"gitea_db_user" default:"gitea" doc:"Database username for Gitea's PostgreSQL database"`
+	DBPassword            string `yaml:"gitea_db_password" required:"true" generate:"true" doc:"Database password for Gitea's PostgreSQL database"`
+	DatabaseHost          string `yaml:"database_host" doc:"PostgreSQL host and port (default: the postgres module's host, else postgres:5432)"`
+	LFSPath               string `yaml:"lfs_path" default:"/data/git/lfs" doc:"LFS object storage path"`
+	PackagesPath          string `yaml:"packages_path" default:"/data/gitea/packages" doc:"Package registry storage path"`
+	BackupExcludeLFS      string `yaml:"backup_exclude_lfs" default:"false" doc:"Set to \"true\" to leave LFS objects out of backups"`
+	BackupExcludePackages string `yaml:"backup_exclude_packages" default:"false" doc:"Set to \"true\" to leave package registry data out of backups"`
+	MailFrom              string `yaml:"mail_from" doc:"Sender of notification emails sent through the smtp module (default: gitea@<general.domain>)"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *GiteaModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *GiteaModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: gitea\n\n")
 	m.log.Info("Description:\n  Deploys Gitea — a self-hosted Git service.\n  Manages a Secret, PersistentVolumeClaim, Service, and Deployment.\n  Gitea is connected to the postgres module for its database.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  gitea_db_user       Database username for Gitea's PostgreSQL database\n  gitea_db_password   Database password for Gitea's PostgreSQL database\n\n")
-	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/gitea/\n  apply      Create/update resources in the cluster\n  clean      Delete all Gitea resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive /data volume to the destination directory\n  restore    Restore /data volume from a backup archive\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  lfs_path                  LFS object storage path (default: /data/git/lfs)\n  packages_path             Package registry storage path (default: /data/gitea/packages)\n  backup_exclude_lfs        Set to \"true\" to leave LFS objects out of backups\n  backup_exclude_packages   Set to \"true\" to leave package registry data out of backups\n  mail_from                 Sender of emails sent through the smtp module (default: gitea@<general.domain>)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/gitea/\n  apply      Create/update resources in the cluster\n  clean      Delete all Gitea resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive /data, LFS objects and packages to the destination directory\n  restore    Restore /data, LFS objects and packages from a backup archive\n")
 	return nil
 }
 
@@ -102,14 +153,37 @@ func (m *GiteaModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 4
 
-	m.log.Info("\nCompleted: 4/4 Gitea configurations generated successfully\n")
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Gitea configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *GiteaModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -187,6 +261,28 @@ func (m *GiteaModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: gitea\n")
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: Gitea configurations applied successfully\n")
 	return nil
 }
@@ -214,6 +310,11 @@ func (m *GiteaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 		},
 	}
 
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
 	// Prepare PVC
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
@@ -228,12 +329,12 @@ func (m *GiteaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse("10Gi"),
+					corev1.ResourceStorage: storageSize,
 				},
 			},
-			// StorageClassName: func() *string { s := "microk8s-hostpath"; return &s }(),
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -266,6 +367,7 @@ func (m *GiteaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
 	replicas := int32(1)
@@ -295,7 +397,7 @@ func (m *GiteaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 					Containers: []corev1.Container{
 						{
 							Name:            "gitea",
-							Image:           "gitea/gitea:1.25",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Ports: []corev1.ContainerPort{
 								{
@@ -307,11 +409,11 @@ func (m *GiteaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 									ContainerPort: 22,
 								},
 							},
-							Env: []corev1.EnvVar{
+							Env: append([]corev1.EnvVar{
 								{Name: "USER_UID", Value: "1000"},
 								{Name: "USER_GID", Value: "1000"},
 								{Name: "GITEA__database__DB_TYPE", Value: "postgres"},
-								{Name: "GITEA__database__HOST", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "database_host", "postgres:5432")},
+								{Name: "GITEA__database__HOST", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "database_host", m.GeneralConfig.Endpoint("postgres", "postgres:5432"))},
 								{Name: "GITEA__database__NAME", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "gitea_db_user", "gitea")},
 								{Name: "GITEA__database__USER", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "gitea_db_user", "gitea")},
 								{
@@ -331,7 +433,7 @@ func (m *GiteaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 								{Name: "GITEA__server__HTTP_PORT", Value: "3000"},
 								{Name: "GITEA__server__SSH_PORT", Value: "22"},
 								{Name: "DISABLE_REGISTRATION", Value: "true"},
-							},
+							}, append(m.storageEnv(), m.mailerEnv()...)...),
 							LivenessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
@@ -387,12 +489,51 @@ func (m *GiteaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 		},
 	}
 
+	// Verify postgres' certificate when it serves TLS
+	if caSecret := m.postgresCASecret(); caSecret != "" {
+		caFile := servicetls.MountCA(&deployment.Spec.Template.Spec, "postgres", caSecret)
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "GITEA__database__SSL_MODE", Value: "verify-full"},
+			corev1.EnvVar{Name: "PGSSLROOTCERT", Value: caFile},
+		)
+	}
+
+	// First starts run database migrations that can take minutes on slow disks
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], 60)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, pvc, service, deployment)
 	return secret, pvc, service, deployment, nil
 }
 
+// postgresCASecret returns the Secret verifying the postgres module's TLS
+// certificate, or "" when it serves plain connections or database_host points at
+// another server
+func (m *GiteaModule) postgresCASecret() string {
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "database_host", "") != "" {
+		return ""
+	}
+	return m.GeneralConfig.TLSCASecret("postgres")
+}
+
 func (m *GiteaModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -462,6 +603,22 @@ func (m *GiteaModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d Gitea resources deleted successfully\n", successCount)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -471,7 +628,7 @@ func (m *GiteaModule) Clean(ctx context.Context) error {
 
 func (m *GiteaModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -589,13 +746,156 @@ func (m *GiteaModule) Status(ctx context.Context) error {
 	return nil
 }
 
+const (
+	defaultLFSPath      = "/data/git/lfs"
+	defaultPackagesPath = "/data/gitea/packages"
+)
+
+// backupPath describes one filesystem location covered by the Gitea backup.
+type backupPath struct {
+	Name     string
+	Path     string
+	Excluded bool
+}
+
+// storageEnv returns the environment overrides needed when LFS or package
+// storage has been moved away from its default location.
+func (m *GiteaModule) storageEnv() []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if lfsPath := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "lfs_path", defaultLFSPath); lfsPath != defaultLFSPath {
+		env = append(env, corev1.EnvVar{Name: "GITEA__lfs__PATH", Value: lfsPath})
+	}
+	if packagesPath := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "packages_path", defaultPackagesPath); packagesPath != defaultPackagesPath {
+		env = append(env, corev1.EnvVar{Name: "GITEA__storage_0X2E_packages__PATH", Value: packagesPath})
+	}
+	return env
+}
+
+// mailerEnv enables Gitea's mailer and points it at the smtp module's relay
+// when one is configured
+func (m *GiteaModule) mailerEnv() []corev1.EnvVar {
+	host, port, err := net.SplitHostPort(m.GeneralConfig.Endpoint("smtp", ""))
+	if err != nil {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "GITEA__mailer__ENABLED", Value: "true"},
+		{Name: "GITEA__mailer__PROTOCOL", Value: "smtp"},
+		{Name: "GITEA__mailer__SMTP_ADDR", Value: host},
+		{Name: "GITEA__mailer__SMTP_PORT", Value: port},
+		{Name: "GITEA__mailer__FROM", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "mail_from", "gitea@"+m.GeneralConfig.Domain)},
+	}
+}
+
+// backupPaths returns every location the backup is expected to cover: the /data
+// volume, LFS objects and the packages registry, each flagged if excluded by config.
+func (m *GiteaModule) backupPaths() []backupPath {
+	return []backupPath{
+		{Name: "data", Path: "/data"},
+		{
+			Name:     "lfs",
+			Path:     filepath.Clean(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "lfs_path", defaultLFSPath)),
+			Excluded: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "backup_exclude_lfs", "false") == "true",
+		},
+		{
+			Name:     "packages",
+			Path:     filepath.Clean(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "packages_path", defaultPackagesPath)),
+			Excluded: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "backup_exclude_packages", "false") == "true",
+		},
+	}
+}
+
+// isUnder reports whether path is root itself or nested inside it.
+func isUnder(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+"/")
+}
+
+// tarArgs builds the tar arguments for the given paths: excluded paths become
+// --exclude flags and included paths nested in another included path are skipped.
+func tarArgs(paths []backupPath) []string {
+	args := []string{"czf", "-"}
+	for _, p := range paths {
+		if p.Excluded {
+			args = append(args, "--exclude="+strings.TrimPrefix(p.Path, "/"))
+		}
+	}
+	for i, p := range paths {
+		if p.Excluded {
+			continue
+		}
+		nested := false
+		for j, other := range paths {
+			if i != j && !other.Excluded && other.Path != p.Path && isUnder(p.Path, other.Path) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			args = append(args, p.Path)
+		}
+	}
+	return args
+}
+
+// verifyArchiveCoverage scans a tar.gz archive and reports, for every path, whether
+// at least one entry for it is present in the archive.
+func verifyArchiveCoverage(archivePath string, paths []backupPath) (map[string]bool, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	found := make(map[string]bool, len(paths))
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive entry: %w", err)
+		}
+		entry := "/" + strings.TrimSuffix(strings.TrimPrefix(hdr.Name, "./"), "/")
+		for _, p := range paths {
+			if isUnder(entry, p.Path) {
+				found[p.Path] = true
+			}
+		}
+	}
+	return found, nil
+}
+
+// findPod returns the name of the gitea pod exec commands run in
+func (m *GiteaModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=gitea",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=gitea")
+	}
+	return pods.Items[0].Name, nil
+}
+
 func (m *GiteaModule) Backup(ctx context.Context, destDir string) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
 
+func (m *GiteaModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
 	var backupDir string
 	if destDir != "" {
@@ -612,37 +912,36 @@ func (m *GiteaModule) Backup(ctx context.Context, destDir string) error {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// 1. Backup data volume
-	m.log.Info("💾 Backing up Gitea data (/data)...\n")
-
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=gitea",
-	})
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=gitea")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
-	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("gitea_data_%s.tar.gz", timestamp))
-
-	// Execute tar command in pod and stream to file
-
-	// Let's try to find kubectl or microk8s kubectl
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
+	// 1. Resolve which paths exist in the pod; LFS and packages directories are
+	// only created by Gitea once the feature is first used.
+	paths := m.backupPaths()
+	var present []backupPath
+	for _, p := range paths {
+		if p.Excluded {
+			m.log.Info("⏭️  Skipping %s (%s): excluded by configuration\n", p.Name, p.Path)
+			present = append(present, p)
+			continue
+		}
+		if err := executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: m.ModuleConfig.Namespace,
+			Pod:       podName,
+			Command:   []string{"test", "-d", p.Path},
+		}); err != nil {
+			m.log.Warn("%s path %s not found in pod, it will not be part of the backup\n", p.Name, p.Path)
+			continue
+		}
+		present = append(present, p)
 	}
 
-	// Construct command: kubectl exec -n <ns> <pod> -- tar czf - /data
-	cmdStr := fmt.Sprintf("%s exec -n %s %s -- tar czf - /data", kubectlCmd, m.ModuleConfig.Namespace, podName)
-
-	cmdParts := strings.Fields(cmdStr)
-	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
+	// 2. Archive data, LFS and packages in one stream
+	m.log.Info("💾 Backing up Gitea data, LFS objects and packages...\n")
+	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("gitea_data_%s.tar.gz", timestamp))
 
 	outFile, err := os.Create(dataBackupFile)
 	if err != nil {
@@ -650,10 +949,13 @@ func (m *GiteaModule) Backup(ctx context.Context, destDir string) error {
 	}
 	defer outFile.Close()
 
-	cmd.Stdout = outFile
-	cmd.Stderr = os.Stderr // pipe stderr to see errors
-
-	if err := cmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   append([]string{"tar"}, tarArgs(present)...),
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to archive data: %w", err)
 	}
 
@@ -661,9 +963,35 @@ func (m *GiteaModule) Backup(ctx context.Context, destDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to stat data backup file: %w", err)
 	}
-	m.log.Success("✅ Data archived (%d bytes)\n", fileInfo.Size())
+	m.log.Success("Data archived (%d bytes)\n", fileInfo.Size())
+
+	// 3. Validate coverage
+	m.log.Info("🔍 Validating backup coverage...\n")
+	found, err := verifyArchiveCoverage(dataBackupFile, paths)
+	if err != nil {
+		return fmt.Errorf("failed to validate backup coverage: %w", err)
+	}
+	var coverage strings.Builder
+	missing := 0
+	for _, p := range paths {
+		status := "included"
+		switch {
+		case p.Excluded:
+			status = "excluded by configuration"
+		case found[p.Path]:
+		default:
+			status = "missing"
+			missing++
+		}
+		fmt.Fprintf(&coverage, "  %-9s %-28s %s\n", p.Name, p.Path, status)
+	}
+	if missing > 0 {
+		m.log.Warn("%d path(s) are not covered by the backup, see backup_info.txt\n", missing)
+	} else {
+		m.log.Success("All configured paths are covered\n")
+	}
 
-	// 2. Metadata
+	// 4. Metadata
 	m.log.Info("📋 Writing metadata...\n")
 	metadataFile := filepath.Join(backupDir, "backup_info.txt")
 	metadata := fmt.Sprintf(`Gitea Backup Information
@@ -677,14 +1005,16 @@ Pod: %s
 Data Archive:
 %s
 
+Coverage:
+%s
 Restore Command:
 personal-server gitea restore %s
-`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, filepath.Base(dataBackupFile), timestamp)
+`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, filepath.Base(dataBackupFile), coverage.String(), timestamp)
 
 	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
-	m.log.Success("✅ Metadata written\n")
+	m.log.Success("Metadata written\n")
 
 	m.log.Success("🎉 Backup complete!\n")
 	m.log.Info("💡 To restore: personal-server gitea restore %s\n", timestamp)
@@ -744,77 +1074,84 @@ func (m *GiteaModule) Restore(ctx context.Context, args []string) error {
 	m.log.Info("💾 Data will be restored from %s\n", dataBackupFile)
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	if err := m.restoreDataWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dataBackupFile); err != nil {
+		return err
+	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=gitea",
-	})
-	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+	// Restart deployment
+	m.log.Info("🔄 Restarting deployment 'gitea'...\n")
+	if err := k8s.RestartDeployment(ctx, clientset, m.ModuleConfig.Namespace, "gitea", time.Now()); err != nil {
+		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
+	} else {
+		m.log.Success("Deployment restarted successfully\n")
 	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=gitea")
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreDataWithClient replaces the data, LFS and packages directories in the
+// gitea pod with the contents of a tar archive written by Backup
+func (m *GiteaModule) restoreDataWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dataBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	// Restore data
 	m.log.Info("💾 Restoring data...\n")
 
-	// Let's try to find kubectl or microk8s kubectl
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
-	}
-
-	// 1. Clean existing data
-	// kubectl exec -n <ns> <pod> -- rm -rf /data/*
-	cleanCmdStr := fmt.Sprintf("%s exec -n %s %s -- rm -rf /data/*", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	cleanCmdParts := strings.Fields(cleanCmdStr)
-	cleanCmd := exec.CommandContext(ctx, cleanCmdParts[0], cleanCmdParts[1:]...)
-	if err := cleanCmd.Run(); err != nil {
-		// Ignore error if directory is already empty or other minor issues, but log it
-		m.log.Warn("Warning during clean: %v\n", err)
+	// 1. Clean existing data. Roots that contain an excluded path (e.g. LFS objects
+	// left out of the backup) are not wiped, otherwise that data would be lost.
+	paths := m.backupPaths()
+	for _, root := range paths {
+		if root.Excluded {
+			continue
+		}
+		keep := false
+		for _, p := range paths {
+			if p.Excluded && isUnder(p.Path, root.Path) {
+				keep = true
+				break
+			}
+		}
+		if keep {
+			m.log.Warn("Not cleaning %s because it contains excluded paths; archive contents will be extracted over existing files\n", root.Path)
+			continue
+		}
+		// The path is passed as an argument so the shell only expands the glob
+		if err := executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: m.ModuleConfig.Namespace,
+			Pod:       podName,
+			Command:   []string{"sh", "-c", `rm -rf "$1"/*`, "sh", root.Path},
+		}); err != nil {
+			// Ignore error if directory is already empty or other minor issues, but log it
+			m.log.Warn("Warning during clean of %s: %v\n", root.Path, err)
+		}
 	}
 
 	// 2. Restore from tar
-	// cat <file> | kubectl exec -i -n <ns> <pod> -- tar xzf - -C /
-	restoreCmdStr := fmt.Sprintf("%s exec -i -n %s %s -- tar xzf - -C /", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	restoreCmdParts := strings.Fields(restoreCmdStr)
-	restoreCmd := exec.CommandContext(ctx, restoreCmdParts[0], restoreCmdParts[1:]...)
-
 	inFile, err := os.Open(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to open data backup file: %w", err)
 	}
 	defer inFile.Close()
 
-	restoreCmd.Stdin = inFile
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", "/"},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore data: %w", err)
 	}
-	m.log.Success("✅ Data restored\n")
-
-	// Restart deployment
-	m.log.Info("🔄 Restarting deployment 'gitea'...\n")
-
-	restartCmdStr := fmt.Sprintf("%s rollout restart deployment/gitea -n %s", kubectlCmd, m.ModuleConfig.Namespace)
-	restartCmdParts := strings.Fields(restartCmdStr)
-	restartCmd := exec.CommandContext(ctx, restartCmdParts[0], restartCmdParts[1:]...)
-
-	if err := restartCmd.Run(); err != nil {
-		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
-	} else {
-		m.log.Success("✅ Deployment restarted successfully\n")
-	}
-
-	m.log.Success("🎉 Restore complete!\n")
+	m.log.Success("Data restored\n")
 	return nil
 }
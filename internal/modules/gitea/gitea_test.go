@@ -1,17 +1,24 @@
 package gitea
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
 	_ "embed"
 	"os"
 	"path/filepath"
-
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestGiteaModule_Name(t *testing.T) {
@@ -192,6 +199,20 @@ func TestGiteaModule_PreparePVC(t *testing.T) {
 	if actualStorage.Cmp(expectedStorage) != 0 {
 		t.Errorf("PVC storage request = %s, want %s", actualStorage.String(), expectedStorage.String())
 	}
+
+	// Test storage class: the cluster default unless configured
+	if pvc.Spec.StorageClassName != nil {
+		t.Errorf("PVC storage class = %s, want the cluster default", *pvc.Spec.StorageClassName)
+	}
+	module.GeneralConfig.StorageClass = "longhorn"
+	module.ModuleConfig.StorageClass = "local-path"
+	_, pvc, _, _, err = module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error: %v", err)
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != "local-path" {
+		t.Errorf("PVC storage class = %v, want the module's local-path", pvc.Spec.StorageClassName)
+	}
 }
 
 func TestGiteaModule_PrepareService(t *testing.T) {
@@ -499,6 +520,44 @@ var expectedServiceYAML string
 //go:embed testdata/deployment.yaml
 var expectedDeploymentYAML string
 
+//go:embed testdata/ingress.yaml
+var expectedIngressYAML string
+
+func TestGiteaModule_PrepareProbeOverrides(t *testing.T) {
+	module := &GiteaModule{
+		ModuleConfig: config.Module{
+			Name:      "gitea",
+			Namespace: "infra",
+			Secrets: map[string]string{
+				"gitea_db_password":          "secret123",
+				"liveness_initial_delay":     "120",
+				"liveness_failure_threshold": "6",
+				"readiness_path":             "/api/healthz",
+			},
+		},
+	}
+
+	_, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.LivenessProbe.InitialDelaySeconds != 120 || container.LivenessProbe.FailureThreshold != 6 {
+		t.Errorf("liveness delay/threshold = %d/%d, want 120/6", container.LivenessProbe.InitialDelaySeconds, container.LivenessProbe.FailureThreshold)
+	}
+	if container.ReadinessProbe.HTTPGet.Path != "/api/healthz" {
+		t.Errorf("readiness path = %s, want /api/healthz", container.ReadinessProbe.HTTPGet.Path)
+	}
+	if container.ReadinessProbe.InitialDelaySeconds != 30 {
+		t.Errorf("readiness delay = %d, want the default 30", container.ReadinessProbe.InitialDelaySeconds)
+	}
+
+	module.ModuleConfig.Secrets["readiness_period"] = "often"
+	if _, _, _, _, err := module.prepare(); err == nil {
+		t.Error("prepare() with an invalid readiness_period succeeded, want error")
+	}
+}
+
 func TestGenerate(t *testing.T) {
 	// Create a temporary directory for output
 	tempDir := t.TempDir()
@@ -521,6 +580,7 @@ func TestGenerate(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "gitea",
 			Namespace: "infra",
+			Ingress:   &config.ModuleIngress{},
 			Secrets: map[string]string{
 				"gitea_db_password": "password",
 			},
@@ -544,6 +604,7 @@ func TestGenerate(t *testing.T) {
 		{"pvc", "configs/gitea/pvc.yaml", expectedPvcYAML},
 		{"service", "configs/gitea/service.yaml", expectedServiceYAML},
 		{"deployment", "configs/gitea/deployment.yaml", expectedDeploymentYAML},
+		{"ingress", "configs/gitea/ingress.yaml", expectedIngressYAML},
 	}
 
 	for _, tc := range testCases {
@@ -562,3 +623,329 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestGiteaModule_BackupPaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		secrets     map[string]string
+		wantPaths   map[string]string
+		wantExclude map[string]bool
+		wantTarArgs []string
+	}{
+		{
+			name:        "defaults nest lfs and packages under data",
+			secrets:     map[string]string{},
+			wantPaths:   map[string]string{"data": "/data", "lfs": "/data/git/lfs", "packages": "/data/gitea/packages"},
+			wantExclude: map[string]bool{},
+			wantTarArgs: []string{"czf", "-", "/data"},
+		},
+		{
+			name: "excluded lfs becomes a tar exclude",
+			secrets: map[string]string{
+				"backup_exclude_lfs": "true",
+			},
+			wantPaths:   map[string]string{"data": "/data", "lfs": "/data/git/lfs", "packages": "/data/gitea/packages"},
+			wantExclude: map[string]bool{"lfs": true},
+			wantTarArgs: []string{"czf", "-", "--exclude=data/git/lfs", "/data"},
+		},
+		{
+			name: "separate volumes are archived alongside data",
+			secrets: map[string]string{
+				"lfs_path":                "/lfs",
+				"packages_path":           "/packages/",
+				"backup_exclude_packages": "true",
+			},
+			wantPaths:   map[string]string{"data": "/data", "lfs": "/lfs", "packages": "/packages"},
+			wantExclude: map[string]bool{"packages": true},
+			wantTarArgs: []string{"czf", "-", "--exclude=packages", "/data", "/lfs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &GiteaModule{
+				ModuleConfig: config.Module{Name: "gitea", Namespace: "infra", Secrets: tt.secrets},
+			}
+
+			paths := module.backupPaths()
+			if len(paths) != 3 {
+				t.Fatalf("backupPaths() returned %d paths, want 3", len(paths))
+			}
+			for _, p := range paths {
+				if p.Path != tt.wantPaths[p.Name] {
+					t.Errorf("%s path = %s, want %s", p.Name, p.Path, tt.wantPaths[p.Name])
+				}
+				if p.Excluded != tt.wantExclude[p.Name] {
+					t.Errorf("%s excluded = %v, want %v", p.Name, p.Excluded, tt.wantExclude[p.Name])
+				}
+			}
+
+			if got := tarArgs(paths); !reflect.DeepEqual(got, tt.wantTarArgs) {
+				t.Errorf("tarArgs() = %v, want %v", got, tt.wantTarArgs)
+			}
+		})
+	}
+}
+
+func TestGiteaModule_StorageEnv(t *testing.T) {
+	module := &GiteaModule{ModuleConfig: config.Module{Secrets: map[string]string{}}}
+	if env := module.storageEnv(); len(env) != 0 {
+		t.Errorf("storageEnv() with defaults = %v, want none", env)
+	}
+
+	module.ModuleConfig.Secrets = map[string]string{"lfs_path": "/lfs", "packages_path": "/packages"}
+	env := module.storageEnv()
+	want := map[string]string{"GITEA__lfs__PATH": "/lfs", "GITEA__storage_0X2E_packages__PATH": "/packages"}
+	if len(env) != len(want) {
+		t.Fatalf("storageEnv() returned %d vars, want %d", len(env), len(want))
+	}
+	for _, e := range env {
+		if want[e.Name] != e.Value {
+			t.Errorf("%s = %s, want %s", e.Name, e.Value, want[e.Name])
+		}
+	}
+}
+
+func TestGiteaModule_DatabaseHost(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints map[string]string
+		host      string
+		want      string
+	}{
+		{name: "no postgres module", want: "postgres:5432"},
+		{name: "postgres module endpoint", endpoints: map[string]string{"postgres": "pgbouncer.infra:6432"}, want: "pgbouncer.infra:6432"},
+		{name: "database_host overrides", endpoints: map[string]string{"postgres": "pgbouncer.infra:6432"}, host: "db.example.com:5432", want: "db.example.com:5432"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secrets := map[string]string{"gitea_db_password": "secret123"}
+			if tt.host != "" {
+				secrets["database_host"] = tt.host
+			}
+			module := &GiteaModule{
+				GeneralConfig: config.GeneralConfig{Domain: "example.com", Endpoints: tt.endpoints},
+				ModuleConfig:  config.Module{Name: "gitea", Namespace: "infra", Secrets: secrets},
+			}
+			_, _, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error: %v", err)
+			}
+			for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if env.Name == "GITEA__database__HOST" && env.Value != tt.want {
+					t.Errorf("GITEA__database__HOST = %s, want %s", env.Value, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGiteaModule_Mailer(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints map[string]string
+		from      string
+		wantAddr  string
+		wantFrom  string
+	}{
+		{name: "no smtp module"},
+		{name: "smtp module relay", endpoints: map[string]string{"smtp": "smtp.infra.svc.cluster.local:587"}, wantAddr: "smtp.infra.svc.cluster.local", wantFrom: "gitea@example.com"},
+		{name: "mail_from overrides the sender", endpoints: map[string]string{"smtp": "smtp.infra.svc.cluster.local:587"}, from: "git@example.org", wantAddr: "smtp.infra.svc.cluster.local", wantFrom: "git@example.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secrets := map[string]string{"gitea_db_password": "secret123"}
+			if tt.from != "" {
+				secrets["mail_from"] = tt.from
+			}
+			module := &GiteaModule{
+				GeneralConfig: config.GeneralConfig{Domain: "example.com", Endpoints: tt.endpoints},
+				ModuleConfig:  config.Module{Name: "gitea", Namespace: "infra", Secrets: secrets},
+			}
+			_, _, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error: %v", err)
+			}
+			env := map[string]string{}
+			for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			if env["GITEA__mailer__SMTP_ADDR"] != tt.wantAddr || env["GITEA__mailer__FROM"] != tt.wantFrom {
+				t.Errorf("mailer = %s from %s, want %s from %s", env["GITEA__mailer__SMTP_ADDR"], env["GITEA__mailer__FROM"], tt.wantAddr, tt.wantFrom)
+			}
+			if enabled := env["GITEA__mailer__ENABLED"] == "true"; enabled != (tt.wantAddr != "") {
+				t.Errorf("GITEA__mailer__ENABLED = %q, want enabled %v", env["GITEA__mailer__ENABLED"], tt.wantAddr != "")
+			}
+			if tt.wantAddr != "" && env["GITEA__mailer__SMTP_PORT"] != "587" {
+				t.Errorf("GITEA__mailer__SMTP_PORT = %q, want 587", env["GITEA__mailer__SMTP_PORT"])
+			}
+		})
+	}
+}
+
+func TestGiteaModule_DatabaseTLS(t *testing.T) {
+	tests := []struct {
+		name       string
+		caSecrets  map[string]string
+		host       string
+		wantCAFile string
+	}{
+		{name: "postgres serves plain connections"},
+		{name: "postgres serves TLS", caSecrets: map[string]string{"postgres": "postgres-tls"}, wantCAFile: "/etc/ssl/personal-server/postgres/ca.crt"},
+		{name: "database_host points elsewhere", caSecrets: map[string]string{"postgres": "postgres-tls"}, host: "db.example.com:5432"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secrets := map[string]string{"gitea_db_password": "secret123"}
+			if tt.host != "" {
+				secrets["database_host"] = tt.host
+			}
+			module := &GiteaModule{
+				GeneralConfig: config.GeneralConfig{Domain: "example.com", TLSCASecrets: tt.caSecrets},
+				ModuleConfig:  config.Module{Name: "gitea", Namespace: "infra", Secrets: secrets},
+			}
+			_, _, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error: %v", err)
+			}
+
+			env := map[string]string{}
+			for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			if env["PGSSLROOTCERT"] != tt.wantCAFile {
+				t.Errorf("PGSSLROOTCERT = %q, want %q", env["PGSSLROOTCERT"], tt.wantCAFile)
+			}
+			mounted := false
+			for _, volume := range deployment.Spec.Template.Spec.Volumes {
+				if volume.Secret != nil && volume.Secret.SecretName == "postgres-tls" {
+					mounted = true
+				}
+			}
+			if tt.wantCAFile == "" {
+				if mounted || env["GITEA__database__SSL_MODE"] != "" {
+					t.Errorf("postgres CA mounted or SSL_MODE = %q set without TLS", env["GITEA__database__SSL_MODE"])
+				}
+				return
+			}
+			if !mounted || env["GITEA__database__SSL_MODE"] != "verify-full" {
+				t.Errorf("postgres CA mounted = %v, SSL_MODE = %q, want mounted and verify-full", mounted, env["GITEA__database__SSL_MODE"])
+			}
+		})
+	}
+}
+
+func TestVerifyArchiveCoverage(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "gitea.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{"data/", "data/git/", "data/git/lfs/", "data/git/lfs/ab/cd/object"} {
+		hdr := &tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeDir}
+		if !strings.HasSuffix(name, "/") {
+			hdr = &tar.Header{Name: name, Mode: 0644, Size: 0, Typeflag: tar.TypeReg}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	paths := []backupPath{
+		{Name: "data", Path: "/data"},
+		{Name: "lfs", Path: "/data/git/lfs"},
+		{Name: "packages", Path: "/data/gitea/packages"},
+	}
+	found, err := verifyArchiveCoverage(archive, paths)
+	if err != nil {
+		t.Fatalf("verifyArchiveCoverage() error: %v", err)
+	}
+	if !found["/data"] || !found["/data/git/lfs"] {
+		t.Errorf("expected data and lfs to be covered, got %v", found)
+	}
+	if found["/data/gitea/packages"] {
+		t.Error("packages should not be reported as covered")
+	}
+}
+
+// giteaArchive returns a gzip-compressed tar archive holding names
+func giteaArchive(t *testing.T, names ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeDir}); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.String()
+}
+
+func TestGiteaModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gitea-7d9f", Namespace: "infra", Labels: map[string]string{"app": "gitea"}}}
+	module := &GiteaModule{ModuleConfig: config.Module{Name: "gitea", Namespace: "infra"}, log: logger.NewNopLogger()}
+	archive := giteaArchive(t, "data/", "data/git/", "data/git/lfs/")
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "gitea-7d9f", Command: []string{"test", "-d", "/data"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "gitea-7d9f", Command: []string{"test", "-d", "/data/git/lfs"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "gitea-7d9f", Command: []string{"test", "-d", "/data/gitea/packages"}, Error: "exit status 1"},
+		k8s.ExecRecord{Namespace: "infra", Pod: "gitea-7d9f", Command: []string{"tar", "czf", "-", "/data"}, Stdout: archive},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	info, err := os.ReadFile(filepath.Join(destDir, "gitea", "backup_info.txt"))
+	if err != nil {
+		t.Fatalf("metadata not written: %v", err)
+	}
+	for _, line := range strings.Split(string(info), "\n") {
+		if strings.Contains(line, "/data/gitea/packages") && !strings.HasSuffix(line, "missing") {
+			t.Errorf("packages coverage = %q, want missing", line)
+		}
+		if strings.Contains(line, "/data/git/lfs") && !strings.HasSuffix(line, "included") {
+			t.Errorf("lfs coverage = %q, want included", line)
+		}
+	}
+}
+
+func TestGiteaModule_RestoreDataWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gitea-7d9f", Namespace: "infra", Labels: map[string]string{"app": "gitea"}}}
+	archive := filepath.Join(t.TempDir(), "gitea_data.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The LFS objects are excluded, so /data, which contains them, is not wiped
+	module := &GiteaModule{
+		ModuleConfig: config.Module{Name: "gitea", Namespace: "infra", Secrets: map[string]string{"backup_exclude_lfs": "true"}},
+		log:          logger.NewNopLogger(),
+	}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "gitea-7d9f", Command: []string{"sh", "-c", `rm -rf "$1"/*`, "sh", "/data/gitea/packages"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "gitea-7d9f", Command: []string{"tar", "xzf", "-", "-C", "/"}, Stdin: "archive"},
+	)
+
+	if err := module.restoreDataWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreDataWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,186 @@
+package gotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const createAppUsage = "usage: personal-server gotify create-app <NAME> [--description TEXT] [--secret-namespace NAMESPACE [--secret-name NAME]]"
+
+// createApplicationScript posts the JSON body on stdin to Gotify's
+// application API from inside the pod, authenticating as the admin user the
+// container was started with, so no credential appears on a command line
+const createApplicationScript = `curl -sS --fail-with-body -u "$GOTIFY_DEFAULTUSER_NAME:$GOTIFY_DEFAULTUSER_PASS" -H "Content-Type: application/json" --data-binary @- http://127.0.0.1:80/application`
+
+// createAppOptions are the parsed arguments of gotify create-app
+type createAppOptions struct {
+	name            string
+	description     string
+	secretNamespace string // create a token Secret here when set
+	secretName      string
+}
+
+// application is the part of Gotify's application resource create-app reads
+type application struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+var nonDNSChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+func parseCreateAppArgs(args []string) (createAppOptions, error) {
+	var opts createAppOptions
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--description", "--secret-namespace", "--secret-name":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value\n%s", args[i], createAppUsage)
+			}
+			switch args[i] {
+			case "--description":
+				opts.description = args[i+1]
+			case "--secret-namespace":
+				opts.secretNamespace = args[i+1]
+			default:
+				opts.secretName = args[i+1]
+			}
+			i++
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		return opts, fmt.Errorf(createAppUsage)
+	}
+	if opts.secretName != "" && opts.secretNamespace == "" {
+		return opts, fmt.Errorf("--secret-name requires --secret-namespace\n%s", createAppUsage)
+	}
+	opts.name = positional[0]
+
+	if opts.secretNamespace != "" && opts.secretName == "" {
+		opts.secretName = strings.Trim(nonDNSChars.ReplaceAllString("gotify-"+strings.ToLower(opts.name), "-"), "-")
+	}
+	if opts.secretName != "" {
+		if errs := validation.IsDNS1123Subdomain(opts.secretName); len(errs) > 0 {
+			return opts, fmt.Errorf("invalid secret name %q: %s", opts.secretName, strings.Join(errs, "; "))
+		}
+	}
+	return opts, nil
+}
+
+// CreateApp registers an application in Gotify and prints the token it sends
+// messages with
+func (m *GotifyModule) CreateApp(ctx context.Context, args []string) error {
+	opts, err := parseCreateAppArgs(args)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.createAppWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), opts)
+}
+
+func (m *GotifyModule) createAppWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, opts createAppOptions) error {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=gotify",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no running pod found for app=gotify")
+	}
+	podName := pods.Items[0].Name
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	body, err := json.Marshal(map[string]string{"name": opts.name, "description": opts.description})
+	if err != nil {
+		return fmt.Errorf("failed to encode application: %w", err)
+	}
+
+	m.log.Progress("Creating application '%s'...\n", opts.name)
+	var stdout, stderr bytes.Buffer
+	err = executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", createApplicationScript},
+		Stdin:     bytes.NewReader(body),
+		Stdout:    &stdout,
+		Stderr:    &stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create application: %s\nOutput: %s", err, strings.TrimSpace(stdout.String()+stderr.String()))
+	}
+
+	var app application
+	if err := json.Unmarshal(stdout.Bytes(), &app); err != nil || app.Token == "" {
+		return fmt.Errorf("unexpected response from Gotify: %s", strings.TrimSpace(stdout.String()))
+	}
+	m.log.Success("Created application '%s' (id %d)\n", app.Name, app.ID)
+
+	if opts.secretNamespace != "" {
+		if err := m.applyTokenSecret(ctx, client, opts.secretNamespace, opts.secretName, app.Token); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("Token: %s\n", app.Token)
+	m.log.Info("Send a message: curl \"%s/message?token=<token>\" -F \"title=Hello\" -F \"message=World\"\n", m.serviceURL())
+	return nil
+}
+
+// serviceURL is the in-cluster base URL other workloads reach Gotify at
+func (m *GotifyModule) serviceURL() string {
+	return fmt.Sprintf("http://gotify.%s.svc.cluster.local", m.ModuleConfig.Namespace)
+}
+
+// tokenSecret holds what a workload needs to push messages, for use with
+// envFrom or secretKeyRef
+func (m *GotifyModule) tokenSecret(namespace, name, token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"managed-by": "personal-server",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"token": []byte(token),
+			"url":   []byte(m.serviceURL()),
+		},
+	}
+}
+
+// applyTokenSecret creates the token Secret or replaces its data
+func (m *GotifyModule) applyTokenSecret(ctx context.Context, client k8s.KubernetesClient, namespace, name, token string) error {
+	secret := m.tokenSecret(namespace, name, token)
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret)
+
+	m.log.Progress("Applying Secret: %s (namespace: %s)\n", name, namespace)
+	_, err := client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create or update token secret %s/%s: %w", namespace, name, err)
+	}
+	m.log.Success("Applied Secret: %s in namespace %s\n", name, namespace)
+	return nil
+}
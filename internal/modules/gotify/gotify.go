@@ -0,0 +1,620 @@
+package gotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage       = "gotify/server:2.5.0"
+	defaultStorageSize = "1Gi"
+	defaultAdminUser   = "admin"
+	containerPort      = 80
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "128Mi"}
+
+type GotifyModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *GotifyModule {
+	return &GotifyModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *GotifyModule) Name() string {
+	return "gotify"
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *GotifyModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	AdminPassword string `yaml:"admin_password" required:"true" generate:"true" doc:"Password of the Gotify admin user, set on first start"`
+	AdminUser     string `yaml:"admin_user" default:"admin" doc:"Name of the Gotify admin user, set on first start"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *GotifyModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *GotifyModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: gotify\n\n")
+	m.log.Info("Description:\n  Deploys Gotify — a self-hosted push notification server.\n  Manages a Secret, PersistentVolumeClaim, Service, and Deployment.\n  Other modules send notifications with an application token from create-app.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  admin_password   Password of the Gotify admin user, set on first start\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  admin_user     Name of the Gotify admin user (default: %s)\n  image          Container image (modules[].image, default: %s)\n  ingress        Expose the web interface at <name>.<general.domain> (modules[].ingress)\n  storage        Size of the data volume (modules[].storage, default: %s)\n\n", defaultAdminUser, defaultImage, defaultStorageSize)
+	m.log.Info("Subcommands:\n  generate     Write Kubernetes YAML to configs/gotify/\n  apply        Create/update resources in the cluster\n  clean        Delete all Gotify resources from the cluster\n  status       Print Deployment and Pod status\n  doc          Show this documentation\n  create-app   Create an application and print its token (args: <name> [--description TEXT]\n               [--secret-namespace <ns> [--secret-name <name>]] also writes token/url to a Secret)\n")
+	return nil
+}
+
+func (m *GotifyModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "gotify")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating Gotify Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+	total := 4
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Gotify configurations generated successfully\n", total, total)
+	return nil
+}
+
+func (m *GotifyModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying Gotify Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'", secret.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", pvc.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", service.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: %s\n", secret.Name)
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: %s\n", pvc.Name)
+
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", service.Name)
+
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", deployment.Name)
+
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: Gotify configurations applied successfully\n")
+	return nil
+}
+
+// prepare creates and returns the Kubernetes objects for the gotify module
+func (m *GotifyModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	adminPassword, exists := m.ModuleConfig.Secrets["admin_password"]
+	if !exists {
+		return nil, nil, nil, nil, fmt.Errorf("admin_password not found in configuration")
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	labels := map[string]string{
+		"app":        "gotify",
+		"managed-by": "personal-server",
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gotify-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"GOTIFY_DEFAULTUSER_PASS": []byte(adminPassword),
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gotify-data-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gotify",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       containerPort,
+					TargetPort: intstr.FromInt(containerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "gotify",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gotify",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "gotify",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "gotify",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "gotify",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: containerPort,
+								},
+							},
+							Env: []corev1.EnvVar{
+								{Name: "GOTIFY_DEFAULTUSER_NAME", Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "admin_user", defaultAdminUser)},
+								{
+									Name: "GOTIFY_DEFAULTUSER_PASS",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: "gotify-secrets"},
+											Key:                  "GOTIFY_DEFAULTUSER_PASS",
+										},
+									},
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 15,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/app/data",
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "gotify-data-pvc",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, pvc, service, deployment)
+	return secret, pvc, service, deployment, nil
+}
+
+func (m *GotifyModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning Gotify Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Processing Deployment: gotify\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "gotify", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'gotify' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: gotify\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Service: gotify\n")
+	if err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Delete(ctx, "gotify", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service 'gotify' not found\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: gotify\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: gotify-data-pvc\n")
+	if err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Delete(ctx, "gotify-data-pvc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'gotify-data-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: gotify-data-pvc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Secret: gotify-secrets\n")
+	if err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Delete(ctx, "gotify-secrets", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'gotify-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: gotify-secrets\n")
+		successCount++
+	}
+
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
+	m.log.Info("\nCompleted: %d Gotify resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *GotifyModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking Gotify resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "gotify", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'gotify' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	service, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "gotify", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service 'gotify' not found\n")
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, "gotify-data-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'gotify-data-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	secret, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "gotify-secrets", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Secret 'gotify-secrets' not found\n")
+		} else {
+			m.log.Error("Error getting Secret: %v\n", err)
+		}
+	} else {
+		age := time.Since(secret.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SECRET:\n")
+		m.log.Info("  Name:            %s\n", secret.Name)
+		m.log.Info("  Type:            %s\n", secret.Type)
+		m.log.Info("  Data keys:       %d\n", len(secret.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=gotify",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No Gotify pods found")
+	}
+	return nil
+}
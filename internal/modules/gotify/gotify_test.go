@@ -0,0 +1,323 @@
+package gotify
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGotifyModule_Name(t *testing.T) {
+	module := &GotifyModule{}
+	if module.Name() != "gotify" {
+		t.Errorf("Name() = %s, want gotify", module.Name())
+	}
+}
+
+func TestGotifyModule_Doc(t *testing.T) {
+	module := &GotifyModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestGotifyModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name        string
+		secrets     map[string]string
+		image       string
+		storage     string
+		wantImage   string
+		wantStorage string
+		wantUser    string
+		wantErr     bool
+	}{
+		{
+			name:        "defaults",
+			secrets:     map[string]string{"admin_password": "secret"},
+			wantImage:   defaultImage,
+			wantStorage: defaultStorageSize,
+			wantUser:    defaultAdminUser,
+		},
+		{
+			name:        "custom image, storage size and admin user",
+			secrets:     map[string]string{"admin_password": "secret", "admin_user": "root"},
+			image:       "example/gotify:1.0",
+			storage:     "5Gi",
+			wantImage:   "example/gotify:1.0",
+			wantStorage: "5Gi",
+			wantUser:    "root",
+		},
+		{
+			name:    "invalid storage size",
+			secrets: map[string]string{"admin_password": "secret"},
+			storage: "lots",
+			wantErr: true,
+		},
+		{
+			name:    "missing admin password",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &GotifyModule{
+				ModuleConfig: config.Module{Name: "gotify", Namespace: "test-namespace", Image: tt.image, Storage: tt.storage, Secrets: tt.secrets},
+				log:          logger.NewNopLogger(),
+			}
+
+			secret, pvc, service, deployment, err := module.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := string(secret.Data["GOTIFY_DEFAULTUSER_PASS"]); got != "secret" {
+				t.Errorf("GOTIFY_DEFAULTUSER_PASS = %q, want secret", got)
+			}
+			if got := pvc.Spec.Resources.Requests.Storage().String(); got != tt.wantStorage {
+				t.Errorf("PVC storage = %s, want %s", got, tt.wantStorage)
+			}
+			if service.Spec.Selector["app"] != "gotify" {
+				t.Errorf("Service selector = %v, want app=gotify", service.Spec.Selector)
+			}
+			container := deployment.Spec.Template.Spec.Containers[0]
+			if container.Image != tt.wantImage {
+				t.Errorf("image = %s, want %s", container.Image, tt.wantImage)
+			}
+			if container.Env[0].Name != "GOTIFY_DEFAULTUSER_NAME" || container.Env[0].Value != tt.wantUser {
+				t.Errorf("Env[0] = %+v, want GOTIFY_DEFAULTUSER_NAME=%s", container.Env[0], tt.wantUser)
+			}
+			if ref := container.Env[1].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "gotify-secrets" {
+				t.Errorf("GOTIFY_DEFAULTUSER_PASS is not read from gotify-secrets")
+			}
+			if deployment.Namespace != "test-namespace" {
+				t.Errorf("namespace = %s, want test-namespace", deployment.Namespace)
+			}
+		})
+	}
+}
+
+func TestParseCreateAppArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    createAppOptions
+		wantErr bool
+	}{
+		{
+			name: "name only",
+			args: []string{"Backups"},
+			want: createAppOptions{name: "Backups"},
+		},
+		{
+			name: "description and default secret name",
+			args: []string{"Nightly Backups", "--description", "backup results", "--secret-namespace", "infra"},
+			want: createAppOptions{name: "Nightly Backups", description: "backup results", secretNamespace: "infra", secretName: "gotify-nightly-backups"},
+		},
+		{
+			name: "explicit secret name",
+			args: []string{"drone", "--secret-namespace", "infra", "--secret-name", "drone-gotify"},
+			want: createAppOptions{name: "drone", secretNamespace: "infra", secretName: "drone-gotify"},
+		},
+		{name: "missing name", args: nil, wantErr: true},
+		{name: "two names", args: []string{"a", "b"}, wantErr: true},
+		{name: "missing flag value", args: []string{"a", "--description"}, wantErr: true},
+		{name: "secret name without namespace", args: []string{"a", "--secret-name", "x"}, wantErr: true},
+		{name: "invalid secret name", args: []string{"a", "--secret-namespace", "infra", "--secret-name", "Not_Valid"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCreateAppArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCreateAppArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseCreateAppArgs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newExecTestModule(objects ...runtime.Object) (*GotifyModule, *fake.Clientset) {
+	objects = append(objects, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "gotify-5c8d",
+		Namespace: "infra",
+		Labels:    map[string]string{"app": "gotify"},
+	}})
+	module := &GotifyModule{
+		ModuleConfig: config.Module{Name: "gotify", Namespace: "infra"},
+		log:          logger.NewNopLogger(),
+	}
+	return module, fake.NewSimpleClientset(objects...)
+}
+
+func TestGotifyModule_CreateAppWithClient(t *testing.T) {
+	existing := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "gotify-backups", Namespace: "infra"}, Data: map[string][]byte{"token": []byte("old")}}
+	module, client := newExecTestModule(existing)
+	executor := k8s.NewReplayExecutor(k8s.ExecRecord{
+		Namespace: "infra",
+		Pod:       "gotify-5c8d",
+		Command:   []string{"sh", "-c", createApplicationScript},
+		Stdin:     `{"description":"it's \"quoted\"","name":"backups"}`,
+		Stdout:    `{"id":3,"token":"AbCdEf123","name":"backups","description":"it's \"quoted\"","internal":false}`,
+	})
+
+	opts := createAppOptions{name: "backups", description: `it's "quoted"`, secretNamespace: "infra", secretName: "gotify-backups"}
+	if err := module.createAppWithClient(context.Background(), client, executor, opts); err != nil {
+		t.Fatalf("createAppWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	secret, err := client.CoreV1().Secrets("infra").Get(context.Background(), "gotify-backups", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("token secret not found: %v", err)
+	}
+	if got := string(secret.Data["token"]); got != "AbCdEf123" {
+		t.Errorf("token = %s, want AbCdEf123", got)
+	}
+	if got := string(secret.Data["url"]); got != "http://gotify.infra.svc.cluster.local" {
+		t.Errorf("url = %s, want http://gotify.infra.svc.cluster.local", got)
+	}
+}
+
+func TestGotifyModule_CreateAppWithClientErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		record k8s.ExecRecord
+		want   string
+	}{
+		{
+			name:   "rejected credentials",
+			record: k8s.ExecRecord{Stdout: `{"error":"Unauthorized","errorCode":401}`, Stderr: "curl: (22) The requested URL returned error: 401", Error: "exit status 22"},
+			want:   "Unauthorized",
+		},
+		{
+			name:   "unexpected response",
+			record: k8s.ExecRecord{Stdout: "<html>"},
+			want:   "unexpected response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module, client := newExecTestModule()
+			record := tt.record
+			record.Namespace, record.Pod = "infra", "gotify-5c8d"
+			record.Command = []string{"sh", "-c", createApplicationScript}
+			record.Stdin = `{"description":"","name":"backups"}`
+
+			err := module.createAppWithClient(context.Background(), client, k8s.NewReplayExecutor(record), createAppOptions{name: "backups"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("createAppWithClient() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGotifyModule_CreateAppWithoutPod(t *testing.T) {
+	module := &GotifyModule{ModuleConfig: config.Module{Name: "gotify", Namespace: "infra"}, log: logger.NewNopLogger()}
+	err := module.createAppWithClient(context.Background(), fake.NewSimpleClientset(), k8s.NewReplayExecutor(), createAppOptions{name: "backups"})
+	if err == nil || !strings.Contains(err.Error(), "no running pod") {
+		t.Errorf("createAppWithClient() error = %v, want no running pod", err)
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/pvc.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGotifyModule_PrepareConfigChecksum(t *testing.T) {
+	checksum := func(password string) string {
+		module := &GotifyModule{
+			ModuleConfig: config.Module{Name: "gotify", Namespace: "infra", Secrets: map[string]string{"admin_password": password}},
+			log:          logger.NewNopLogger(),
+		}
+		_, _, _, deployment, err := module.prepare()
+		if err != nil {
+			t.Fatalf("prepare() error = %v", err)
+		}
+		return deployment.Spec.Template.Annotations[k8s.ConfigChecksumAnnotation]
+	}
+
+	if checksum("old") == checksum("new") {
+		t.Error("pod template unchanged after admin_password changed, pods would keep the old value")
+	}
+	if checksum("old") != checksum("old") {
+		t.Error("pod template changed without a config change, pods would restart on every apply")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := &GotifyModule{
+		ModuleConfig: config.Module{
+			Name:      "gotify",
+			Namespace: "infra",
+			Secrets: map[string]string{
+				"admin_password": "password",
+			},
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/gotify/secret.yaml", expectedSecretYAML},
+		{"pvc", "configs/gotify/pvc.yaml", expectedPvcYAML},
+		{"service", "configs/gotify/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/gotify/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// dataDir is the Grafana data directory holding grafana.db
+const dataDir = "/var/lib/grafana"
+
+// findPod returns the name of the grafana pod exec commands run in
+func (m *GrafanaModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=grafana",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=grafana")
+	}
+	return pods.Items[0].Name, nil
+}
+
+func (m *GrafanaModule) Backup(ctx context.Context, destDir string) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
+
+func (m *GrafanaModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
+	timestamp := time.Now().Format("20060102_150405")
+	var backupDir string
+	if destDir != "" {
+		backupDir = filepath.Join(destDir, "grafana")
+	} else {
+		backupDir = filepath.Join("backups", fmt.Sprintf("grafana_backup_%s", timestamp))
+	}
+
+	m.log.Info("🔄 Starting Grafana backup...\n")
+	m.log.Info("Backup directory: %s\n", backupDir)
+
+	// Create backup directory
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	// 1. Archive grafana.db, which holds users, folders, dashboards and alert
+	// rules created in the UI
+	m.log.Info("💾 Backing up grafana.db...\n")
+
+	dbBackupFile := filepath.Join(backupDir, fmt.Sprintf("grafana_db_%s.tar.gz", timestamp))
+
+	outFile, err := os.Create(dbBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to create database backup file: %w", err)
+	}
+	defer outFile.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "-C", dataDir, "grafana.db"},
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to archive grafana.db: %w", err)
+	}
+
+	fileInfo, err := outFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat database backup file: %w", err)
+	}
+	m.log.Success("Database archived (%d bytes)\n", fileInfo.Size())
+
+	// 2. Metadata
+	m.log.Info("📋 Writing metadata...\n")
+	metadataFile := filepath.Join(backupDir, "backup_info.txt")
+	metadata := fmt.Sprintf(`Grafana Backup Information
+===========================
+Backup Date: %s
+Backup Directory: %s
+Namespace: %s
+Deployment: grafana
+Pod: %s
+
+Database Archive:
+%s
+
+Restore Command:
+personal-server grafana restore %s
+`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, filepath.Base(dbBackupFile), timestamp)
+
+	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	m.log.Success("Metadata written\n")
+
+	m.log.Success("🎉 Backup complete!\n")
+	m.log.Info("💡 To restore: personal-server grafana restore %s\n", timestamp)
+
+	return nil
+}
+
+func (m *GrafanaModule) Restore(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: personal-server grafana restore [TIMESTAMP|latest]")
+	}
+
+	timestamp := args[0]
+	backupDir := "backups"
+
+	// Resolve latest
+	if timestamp == "latest" {
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			return fmt.Errorf("failed to read backup directory: %w", err)
+		}
+
+		var latestTime time.Time
+		var latestDir string
+
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "grafana_backup_") {
+				tsStr := strings.TrimPrefix(entry.Name(), "grafana_backup_")
+				ts, err := time.Parse("20060102_150405", tsStr)
+				if err == nil {
+					if ts.After(latestTime) {
+						latestTime = ts
+						latestDir = entry.Name()
+					}
+				}
+			}
+		}
+
+		if latestDir == "" {
+			return fmt.Errorf("no backups found")
+		}
+		timestamp = strings.TrimPrefix(latestDir, "grafana_backup_")
+		m.log.Info("Using latest backup: %s\n", timestamp)
+	}
+
+	targetBackupDir := filepath.Join(backupDir, fmt.Sprintf("grafana_backup_%s", timestamp))
+	if _, err := os.Stat(targetBackupDir); os.IsNotExist(err) {
+		return fmt.Errorf("backup not found: %s", targetBackupDir)
+	}
+
+	dbBackupFile := filepath.Join(targetBackupDir, fmt.Sprintf("grafana_db_%s.tar.gz", timestamp))
+	if _, err := os.Stat(dbBackupFile); os.IsNotExist(err) {
+		return fmt.Errorf("database archive missing: %s", dbBackupFile)
+	}
+
+	m.log.Info("🔄 Starting Grafana restore (timestamp: %s)...\n", timestamp)
+	m.log.Info("💾 grafana.db will be restored from %s\n", dbBackupFile)
+
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if err := m.restoreWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dbBackupFile); err != nil {
+		return err
+	}
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreWithClient replaces grafana.db in the grafana pod with the one in a
+// tar archive written by Backup, then deletes the pod. tar unlinks the old
+// file before extracting, so the running server keeps writing to the
+// replaced copy until its replacement pod opens the restored one.
+func (m *GrafanaModule) restoreWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dbBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	m.log.Info("💾 Restoring grafana.db...\n")
+
+	// 1. Drop the journal of the database being replaced so SQLite does not
+	// roll it back into the restored file
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"rm", "-f", dataDir + "/grafana.db-journal", dataDir + "/grafana.db-wal", dataDir + "/grafana.db-shm"},
+	}); err != nil {
+		m.log.Warn("Warning during clean: %v\n", err)
+	}
+
+	// 2. Restore from tar
+	inFile, err := os.Open(dbBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to open database backup file: %w", err)
+	}
+	defer inFile.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", dataDir},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to restore grafana.db: %w", err)
+	}
+	m.log.Success("grafana.db restored\n")
+
+	// 3. Replace the pod so Grafana opens the restored database
+	m.log.Info("🔄 Restarting pod '%s'...\n", podName)
+	if err := client.CoreV1().Pods(m.ModuleConfig.Namespace).Delete(ctx, podName, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("failed to delete pod %s: %w", podName, err)
+	}
+	m.log.Success("Pod deleted, the Deployment will recreate it\n")
+	return nil
+}
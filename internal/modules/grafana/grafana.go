@@ -11,14 +11,27 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "128Mi", MemoryLimit: "512Mi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "10Gi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "grafana/grafana:11.4.0"
+
 type GrafanaModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -37,11 +50,42 @@ func (m *GrafanaModule) Name() string {
 	return "grafana"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *GrafanaModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	AdminUser     string `yaml:"grafana_admin_user" default:"admin" doc:"Admin username for the Grafana web interface"`
+	AdminPassword string `yaml:"grafana_admin_password" default:"admin" generate:"true" doc:"Admin password for the Grafana web interface"`
+	PrometheusURL string `yaml:"prometheus_url" doc:"URL of the provisioned Prometheus datasource (default: the prometheus module's Service when it is configured)"`
+	DashboardsDir string `yaml:"dashboards_dir" doc:"Local directory whose *.json dashboards are provisioned through the grafana-dashboards ConfigMap"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// Dependencies lists the module whose Service the Prometheus datasource points
+// at, so it is applied first when both are configured
+func (m *GrafanaModule) Dependencies() []string {
+	return []string{"prometheus"}
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *GrafanaModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *GrafanaModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: grafana\n\n")
-	m.log.Info("Description:\n  Deploys Grafana — an open-source observability and analytics platform.\n  Manages a Secret, PersistentVolumeClaim, Service, and Deployment.\n\n")
+	m.log.Info("Description:\n  Deploys Grafana — an open-source observability and analytics platform.\n  Manages a Secret, provisioning and dashboards ConfigMaps, PersistentVolumeClaim, Service, and Deployment.\n  Provisions the prometheus module as the default datasource.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  grafana_admin_user       Admin username for the Grafana web interface\n  grafana_admin_password   Admin password for the Grafana web interface\n\n")
-	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/grafana/\n  apply      Create/update resources in the cluster\n  clean      Delete all Grafana resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  prometheus_url   Datasource URL (default: the prometheus module's Service)\n  dashboards_dir   Local directory of dashboard JSON files to provision\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/grafana/\n  apply      Create/update resources in the cluster\n  clean      Delete all Grafana resources from the cluster\n  status     Print Deployment and Pod status\n  backup     Archive grafana.db to backups/\n  restore    Restore grafana.db from a backup: restore [TIMESTAMP|latest]\n  doc        Show this documentation\n")
 	return nil
 }
 
@@ -58,7 +102,7 @@ func (m *GrafanaModule) Generate(ctx context.Context) error {
 	m.log.Info("Output directory: %s\n\n", outputDir)
 
 	// Prepare Kubernetes objects
-	secret, pvc, service, deployment, err := m.prepare()
+	secret, provisioning, dashboards, pvc, service, deployment, err := m.prepare()
 	if err != nil {
 		return fmt.Errorf("failed to prepare resources: %w", err)
 	}
@@ -86,6 +130,14 @@ func (m *GrafanaModule) Generate(ctx context.Context) error {
 		return err
 	}
 
+	// Write ConfigMaps
+	if err := writeYAML(provisioning, "provisioning"); err != nil {
+		return err
+	}
+	if err := writeYAML(dashboards, "dashboards"); err != nil {
+		return err
+	}
+
 	// Write PVC
 	if err := writeYAML(pvc, "pvc"); err != nil {
 		return err
@@ -100,14 +152,37 @@ func (m *GrafanaModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 6
 
-	m.log.Info("\nCompleted: 4/4 Grafana configurations generated successfully\n")
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Grafana configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *GrafanaModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -124,6 +199,15 @@ func (m *GrafanaModule) Apply(ctx context.Context) error {
 		return fmt.Errorf("failed to check secret existence: %w", err)
 	}
 
+	for _, name := range []string{provisioningConfigName, dashboardsConfigName} {
+		_, err = clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("ConfigMap '%s' already exists in namespace '%s'", name, m.ModuleConfig.Namespace)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check ConfigMap existence: %w", err)
+		}
+	}
+
 	_, err = clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, "grafana-data-pvc", metav1.GetOptions{})
 	if err == nil {
 		return fmt.Errorf("PersistentVolumeClaim 'grafana-data-pvc' already exists in namespace '%s'", m.ModuleConfig.Namespace)
@@ -148,7 +232,7 @@ func (m *GrafanaModule) Apply(ctx context.Context) error {
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
-	secret, pvc, service, deployment, err := m.prepare()
+	secret, provisioning, dashboards, pvc, service, deployment, err := m.prepare()
 	if err != nil {
 		return fmt.Errorf("failed to prepare resources: %w", err)
 	}
@@ -161,6 +245,16 @@ func (m *GrafanaModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Secret: grafana-secrets\n")
 
+	// Apply ConfigMaps
+	for _, configMap := range []*corev1.ConfigMap{provisioning, dashboards} {
+		m.log.Progress("Applying ConfigMap: %s\n", configMap.Name)
+		_, err = clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Create(ctx, configMap, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s: %w", configMap.Name, err)
+		}
+		m.log.Success("Created ConfigMap: %s\n", configMap.Name)
+	}
+
 	// Apply PVC
 	m.log.Progress("Applying PersistentVolumeClaim: grafana-data-pvc\n")
 	_, err = clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Create(ctx, pvc, metav1.CreateOptions{})
@@ -185,12 +279,34 @@ func (m *GrafanaModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: grafana\n")
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: Grafana configurations applied successfully\n")
 	return nil
 }
 
 // prepare creates and returns the Kubernetes objects for grafana module
-func (m *GrafanaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+func (m *GrafanaModule) prepare() (*corev1.Secret, *corev1.ConfigMap, *corev1.ConfigMap, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
 	// Prepare Secret
 	adminPassword := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "grafana_admin_password", "admin")
 
@@ -213,6 +329,21 @@ func (m *GrafanaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim
 		},
 	}
 
+	// Prepare ConfigMaps
+	provisioning, err := m.provisioningConfigMap()
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	dashboards, err := m.dashboardsConfigMap()
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+
 	// Prepare PVC
 	pvc := &corev1.PersistentVolumeClaim{
 		TypeMeta: metav1.TypeMeta{
@@ -231,11 +362,12 @@ func (m *GrafanaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse("10Gi"),
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -266,6 +398,7 @@ func (m *GrafanaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
 	replicas := int32(1)
@@ -307,7 +440,7 @@ func (m *GrafanaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim
 					Containers: []corev1.Container{
 						{
 							Name:            "grafana",
-							Image:           "grafana/grafana:11.4.0",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Ports: []corev1.ContainerPort{
 								{
@@ -395,12 +528,32 @@ func (m *GrafanaModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim
 		},
 	}
 
-	return secret, pvc, service, deployment, nil
+	mountProvisioning(&deployment.Spec.Template.Spec)
+
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret, provisioning, dashboards); err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, provisioning, dashboards, pvc, service, deployment)
+	return secret, provisioning, dashboards, pvc, service, deployment, nil
 }
 
 func (m *GrafanaModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -456,6 +609,22 @@ func (m *GrafanaModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete ConfigMaps
+	for _, name := range []string{provisioningConfigName, dashboardsConfigName} {
+		m.log.Info("🗑️  Processing ConfigMap: %s\n", name)
+		err = clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Delete(ctx, name, deleteOptions)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("ConfigMap '%s' not found\n", name)
+			} else {
+				m.log.Error("Failed to delete ConfigMap: %v\n", err)
+			}
+		} else {
+			m.log.Success("Deleted ConfigMap: %s\n", name)
+			successCount++
+		}
+	}
+
 	// Delete Secret
 	m.log.Info("🗑️  Processing Secret: grafana-secrets\n")
 	err = clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Delete(ctx, "grafana-secrets", deleteOptions)
@@ -470,6 +639,22 @@ func (m *GrafanaModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d Grafana resources deleted successfully\n", successCount)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -479,7 +664,7 @@ func (m *GrafanaModule) Clean(ctx context.Context) error {
 
 func (m *GrafanaModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -549,6 +734,25 @@ func (m *GrafanaModule) Status(ctx context.Context) error {
 		m.log.Println()
 	}
 
+	// Check ConfigMaps
+	for _, name := range []string{provisioningConfigName, dashboardsConfigName} {
+		cm, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("ConfigMap '%s' not found\n", name)
+			} else {
+				m.log.Error("Error getting ConfigMap: %v\n", err)
+			}
+			continue
+		}
+		age := time.Since(cm.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("CONFIG MAP:\n")
+		m.log.Info("  Name:            %s\n", cm.Name)
+		m.log.Info("  Data keys:       %d\n", len(cm.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
 	// Check Secret
 	secret, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "grafana-secrets", metav1.GetOptions{})
 	if err != nil {
@@ -4,10 +4,15 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestGrafanaModule_Name(t *testing.T) {
@@ -62,7 +67,7 @@ func TestGrafanaModule_Prepare(t *testing.T) {
 				},
 			}
 
-			secret, pvc, service, deployment, err := module.prepare()
+			secret, _, _, pvc, service, deployment, err := module.prepare()
 
 			if tt.wantErr {
 				if err == nil {
@@ -177,7 +182,7 @@ func TestGrafanaModule_Generate(t *testing.T) {
 
 	// Verify that config files were generated
 	configsDir := filepath.Join(tmpDir, "configs", "grafana")
-	files := []string{"secret.yaml", "pvc.yaml", "service.yaml", "deployment.yaml"}
+	files := []string{"secret.yaml", "provisioning.yaml", "dashboards.yaml", "pvc.yaml", "service.yaml", "deployment.yaml"}
 
 	for _, file := range files {
 		path := filepath.Join(configsDir, file)
@@ -186,3 +191,140 @@ func TestGrafanaModule_Generate(t *testing.T) {
 		}
 	}
 }
+
+func TestGrafanaModule_PrepareProvisioning(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints map[string]string
+		secrets   map[string]string
+		want      string
+	}{
+		{
+			name:      "prometheus module",
+			endpoints: map[string]string{"prometheus": "prometheus.monitoring.svc.cluster.local:9090"},
+			want:      "url: http://prometheus.monitoring.svc.cluster.local:9090",
+		},
+		{
+			name:      "prometheus_url overrides the module",
+			endpoints: map[string]string{"prometheus": "prometheus.monitoring.svc.cluster.local:9090"},
+			secrets:   map[string]string{"prometheus_url": "https://metrics.example.com"},
+			want:      "url: https://metrics.example.com",
+		},
+		{
+			name: "no prometheus",
+			want: "datasources: []",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &GrafanaModule{
+				GeneralConfig: config.GeneralConfig{Endpoints: tt.endpoints},
+				ModuleConfig:  config.Module{Name: "grafana", Namespace: "infra", Secrets: tt.secrets},
+			}
+			_, provisioning, _, _, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
+			if got := provisioning.Data["datasources.yaml"]; !strings.Contains(got, tt.want) {
+				t.Errorf("datasources.yaml = %s, want it to contain %q", got, tt.want)
+			}
+			if got := provisioning.Data["dashboards.yaml"]; !strings.Contains(got, "path: "+dashboardsPath) {
+				t.Errorf("dashboards.yaml = %s, want the provider reading %s", got, dashboardsPath)
+			}
+
+			mounts := map[string]string{}
+			for _, mount := range deployment.Spec.Template.Spec.Containers[0].VolumeMounts {
+				mounts[mount.MountPath] = mount.Name
+			}
+			for path, volume := range map[string]string{
+				"/etc/grafana/provisioning/datasources/datasources.yaml": "provisioning",
+				"/etc/grafana/provisioning/dashboards/dashboards.yaml":   "provisioning",
+				dashboardsPath: "dashboards",
+			} {
+				if mounts[path] != volume {
+					t.Errorf("mount %s = %q, want volume %q", path, mounts[path], volume)
+				}
+			}
+		})
+	}
+}
+
+func TestGrafanaModule_DashboardsConfigMap(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"node.json":  `{"title": "Node"}`,
+		"notes.txt":  "not a dashboard",
+		"redis.json": `{"title": "Redis"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	module := &GrafanaModule{ModuleConfig: config.Module{Name: "grafana", Namespace: "infra", Secrets: map[string]string{"dashboards_dir": dir}}}
+	cm, err := module.dashboardsConfigMap()
+	if err != nil {
+		t.Fatalf("dashboardsConfigMap() error = %v", err)
+	}
+	if len(cm.Data) != 2 || cm.Data["node.json"] != files["node.json"] || cm.Data["redis.json"] != files["redis.json"] {
+		t.Errorf("Data = %v, want the two JSON dashboards", cm.Data)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"title":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := module.dashboardsConfigMap(); err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Errorf("dashboardsConfigMap() error = %v, want broken.json rejected", err)
+	}
+}
+
+func TestGrafanaModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "grafana-7d9f", Namespace: "infra", Labels: map[string]string{"app": "grafana"}}}
+	module := &GrafanaModule{ModuleConfig: config.Module{Name: "grafana", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "grafana-7d9f", Command: []string{"tar", "czf", "-", "-C", "/var/lib/grafana", "grafana.db"}, Stdout: "archive"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(destDir, "grafana", "grafana_db_*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("expected one archive, got %v", archives)
+	}
+	if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+		t.Errorf("archive = %q, want the tar output", data)
+	}
+}
+
+func TestGrafanaModule_RestoreWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "grafana-7d9f", Namespace: "infra", Labels: map[string]string{"app": "grafana"}}}
+	archive := filepath.Join(t.TempDir(), "grafana_db.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &GrafanaModule{ModuleConfig: config.Module{Name: "grafana", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "grafana-7d9f", Command: []string{"rm", "-f", "/var/lib/grafana/grafana.db-journal", "/var/lib/grafana/grafana.db-wal", "/var/lib/grafana/grafana.db-shm"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "grafana-7d9f", Command: []string{"tar", "xzf", "-", "-C", "/var/lib/grafana"}, Stdin: "archive"},
+	)
+
+	client := fake.NewSimpleClientset(pod)
+	if err := module.restoreWithClient(context.Background(), client, executor, archive); err != nil {
+		t.Fatalf("restoreWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+	if pods, _ := client.CoreV1().Pods("infra").List(context.Background(), metav1.ListOptions{}); len(pods.Items) != 0 {
+		t.Errorf("pods = %d, want the restored pod deleted", len(pods.Items))
+	}
+}
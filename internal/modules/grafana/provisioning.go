@@ -0,0 +1,191 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	provisioningConfigName = "grafana-provisioning"
+	dashboardsConfigName   = "grafana-dashboards"
+	// dashboardsPath is where the dashboards ConfigMap is mounted and the
+	// file provider looks for dashboards
+	dashboardsPath = "/etc/grafana/dashboards"
+	// maxDashboardsContent keeps the dashboards ConfigMap below the 1MiB
+	// object size limit, leaving room for metadata
+	maxDashboardsContent = 900 * 1024
+)
+
+// prometheusURL returns the URL of the provisioned Prometheus datasource: the
+// prometheus_url key, else the configured prometheus module, else "" for no
+// datasource
+func (m *GrafanaModule) prometheusURL() string {
+	if url := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "prometheus_url", ""); url != "" {
+		return url
+	}
+	if endpoint := m.GeneralConfig.Endpoint("prometheus", ""); endpoint != "" {
+		return "http://" + endpoint
+	}
+	return ""
+}
+
+// provisioningConfigMap returns the datasource and dashboard provider files
+// Grafana reads from /etc/grafana/provisioning at start
+func (m *GrafanaModule) provisioningConfigMap() (*corev1.ConfigMap, error) {
+	datasources := []map[string]interface{}{}
+	if url := m.prometheusURL(); url != "" {
+		datasources = append(datasources, map[string]interface{}{
+			"name":      "Prometheus",
+			"type":      "prometheus",
+			"uid":       "prometheus",
+			"access":    "proxy",
+			"url":       url,
+			"isDefault": true,
+			"editable":  false,
+		})
+	}
+	providers := []map[string]interface{}{
+		{
+			"name":                  "personal-server",
+			"type":                  "file",
+			"disableDeletion":       true,
+			"allowUiUpdates":        false,
+			"updateIntervalSeconds": 60,
+			"options": map[string]interface{}{
+				"path": dashboardsPath,
+			},
+		},
+	}
+
+	data := map[string]string{}
+	for name, content := range map[string]interface{}{
+		"datasources.yaml": map[string]interface{}{"apiVersion": 1, "datasources": datasources},
+		"dashboards.yaml":  map[string]interface{}{"apiVersion": 1, "providers": providers},
+	} {
+		jsonBytes, err := json.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		data[name] = yamlContent
+	}
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      provisioningConfigName,
+			Namespace: m.ModuleConfig.Namespace,
+			Labels: map[string]string{
+				"app":        "grafana",
+				"managed-by": "personal-server",
+			},
+		},
+		Data: data,
+	}, nil
+}
+
+// dashboardsConfigMap returns the dashboards ConfigMap holding the *.json
+// files of the dashboards_dir directory; it is empty without one
+func (m *GrafanaModule) dashboardsConfigMap() (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dashboardsConfigName,
+			Namespace: m.ModuleConfig.Namespace,
+			Labels: map[string]string{
+				"app":        "grafana",
+				"managed-by": "personal-server",
+			},
+		},
+		Data: map[string]string{},
+	}
+
+	dir := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "dashboards_dir", "")
+	if dir == "" {
+		return cm, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dashboards directory: %w", err)
+	}
+
+	total := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("dashboard %s is not valid JSON", entry.Name())
+		}
+		total += len(data)
+		if total > maxDashboardsContent {
+			return nil, fmt.Errorf("dashboards in %s exceed %d KiB, the most a ConfigMap can hold", dir, maxDashboardsContent/1024)
+		}
+		cm.Data[entry.Name()] = string(data)
+	}
+	return cm, nil
+}
+
+// mountProvisioning mounts the provisioning files and dashboards into the
+// Grafana container. The provisioning directories are mounted one by one so
+// the image's other ones (plugins, alerting) stay in place.
+func mountProvisioning(spec *corev1.PodSpec) {
+	container := &spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts,
+		corev1.VolumeMount{
+			Name:      "provisioning",
+			MountPath: "/etc/grafana/provisioning/datasources/datasources.yaml",
+			SubPath:   "datasources.yaml",
+			ReadOnly:  true,
+		},
+		corev1.VolumeMount{
+			Name:      "provisioning",
+			MountPath: "/etc/grafana/provisioning/dashboards/dashboards.yaml",
+			SubPath:   "dashboards.yaml",
+			ReadOnly:  true,
+		},
+		corev1.VolumeMount{
+			Name:      "dashboards",
+			MountPath: dashboardsPath,
+			ReadOnly:  true,
+		},
+	)
+	spec.Volumes = append(spec.Volumes,
+		corev1.Volume{
+			Name: "provisioning",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: provisioningConfigName},
+				},
+			},
+		},
+		corev1.Volume{
+			Name: "dashboards",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: dashboardsConfigName},
+				},
+			},
+		},
+	)
+}
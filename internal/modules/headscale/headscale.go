@@ -0,0 +1,766 @@
+package headscale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage       = "headscale/headscale:0.23.0"
+	defaultStorageSize = "1Gi"
+	defaultNameservers = "1.1.1.1,1.0.0.1"
+	containerPort      = 8080
+	metricsPort        = 9090
+	dataPath           = "/var/lib/headscale"
+	// socketPath is where the headscale CLI reaches the server inside the pod
+	socketPath = "/var/run/headscale"
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "64Mi", MemoryLimit: "256Mi"}
+
+type HeadscaleModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *HeadscaleModule {
+	return &HeadscaleModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *HeadscaleModule) Name() string {
+	return "headscale"
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *HeadscaleModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	ServerURL   string `yaml:"server_url" doc:"Public URL Tailscale clients reach the server at (default: https://headscale.<general.domain>)"`
+	BaseDomain  string `yaml:"base_domain" doc:"MagicDNS domain of the tailnet, outside the server_url host (default: tailnet.<general.domain>)"`
+	Nameservers string `yaml:"nameservers" default:"1.1.1.1,1.0.0.1" doc:"Comma-separated DNS servers pushed to the clients"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *HeadscaleModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *HeadscaleModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: headscale\n\n")
+	m.log.Info("Description:\n  Deploys Headscale, a self-hosted Tailscale coordination server.\n  Manages a ConfigMap (config.yaml), PersistentVolumeClaim, Service, and Deployment.\n  Clients connect with tailscale up --login-server <server_url>, so server_url needs an\n  ingress with TLS routing to the headscale Service on port %d.\n\n", containerPort)
+	m.log.Info("Required configuration keys (modules[].secrets):\n  (none)\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  server_url     Public URL of the server (default: https://headscale.<general.domain>)\n  base_domain    MagicDNS domain of the tailnet (default: tailnet.<general.domain>)\n  nameservers    Comma-separated DNS servers for the clients (default: %s)\n  image          Container image (modules[].image, default: %s)\n  ingress        Expose the web interface at <name>.<general.domain> (modules[].ingress)\n  storage        Size of the data volume (modules[].storage, default: %s)\n\n", defaultNameservers, defaultImage, defaultStorageSize)
+	m.log.Info("Subcommands:\n  generate      Write Kubernetes YAML to configs/headscale/\n  apply         Create/update resources in the cluster\n  clean         Delete all Headscale resources from the cluster, including the database\n  status        Print Deployment and Pod status\n  create-user   Create a tailnet user: create-user <NAME>\n  preauth-key   Create a key registering devices of a user:\n                preauth-key <USER> [--reusable] [--ephemeral] [--expiration 1h]\n  doc           Show this documentation\n")
+	return nil
+}
+
+// serverURL returns the public URL of the server
+func (m *HeadscaleModule) serverURL() (*url.URL, error) {
+	defaultURL := ""
+	if m.GeneralConfig.Domain != "" {
+		defaultURL = "https://headscale." + m.GeneralConfig.Domain
+	}
+	value := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "server_url", defaultURL)
+	if value == "" {
+		return nil, fmt.Errorf("server_url not found in configuration and general.domain is not set")
+	}
+	serverURL, err := url.Parse(value)
+	if err != nil || (serverURL.Scheme != "https" && serverURL.Scheme != "http") || serverURL.Hostname() == "" {
+		return nil, fmt.Errorf("invalid server_url %q: use https://host", value)
+	}
+	return serverURL, nil
+}
+
+// baseDomain returns the MagicDNS domain. Headscale refuses a server_url
+// host inside it, since MagicDNS would then shadow the server.
+func (m *HeadscaleModule) baseDomain(serverURL *url.URL) (string, error) {
+	defaultDomain := ""
+	if m.GeneralConfig.Domain != "" {
+		defaultDomain = "tailnet." + m.GeneralConfig.Domain
+	}
+	domain := strings.TrimSuffix(strings.TrimSpace(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "base_domain", defaultDomain)), ".")
+	if domain == "" {
+		return "", fmt.Errorf("base_domain not found in configuration and general.domain is not set")
+	}
+	if host := serverURL.Hostname(); host == domain || strings.HasSuffix(host, "."+domain) {
+		return "", fmt.Errorf("server_url host %s must not be inside base_domain %s", host, domain)
+	}
+	return domain, nil
+}
+
+// nameservers parses the nameservers key
+func (m *HeadscaleModule) nameservers() ([]string, error) {
+	var servers []string
+	for _, server := range strings.Split(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "nameservers", defaultNameservers), ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if net.ParseIP(server) == nil {
+			return nil, fmt.Errorf("invalid nameserver %q: use an IP address", server)
+		}
+		servers = append(servers, server)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("nameservers must list at least one DNS server")
+	}
+	return servers, nil
+}
+
+// configYAML renders config.yaml. Clients relay through Tailscale's public
+// DERP servers; the database and the noise key live on the data volume.
+func configYAML(serverURL, baseDomain string, nameservers []string) string {
+	var servers strings.Builder
+	for _, server := range nameservers {
+		fmt.Fprintf(&servers, "\n      - %s", server)
+	}
+	return fmt.Sprintf(`server_url: %q
+listen_addr: 0.0.0.0:%d
+metrics_listen_addr: 0.0.0.0:%d
+grpc_listen_addr: 127.0.0.1:50443
+grpc_allow_insecure: false
+noise:
+  private_key_path: %s/noise_private.key
+prefixes:
+  v4: 100.64.0.0/10
+  v6: fd7a:115c:a1e0::/48
+  allocation: sequential
+derp:
+  server:
+    enabled: false
+  urls:
+    - https://controlplane.tailscale.com/derpmap/default
+  paths: []
+  auto_update_enabled: true
+  update_frequency: 24h
+disable_check_updates: true
+ephemeral_node_inactivity_timeout: 30m
+database:
+  type: sqlite
+  sqlite:
+    path: %s/db.sqlite
+    write_ahead_log: true
+log:
+  level: info
+  format: text
+policy:
+  mode: file
+  path: ""
+dns:
+  magic_dns: true
+  base_domain: %q
+  nameservers:
+    global:%s
+  search_domains: []
+  extra_records: []
+unix_socket: %s/headscale.sock
+unix_socket_permission: "0770"
+`, serverURL, containerPort, metricsPort, dataPath, dataPath, baseDomain, servers.String(), socketPath)
+}
+
+func (m *HeadscaleModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "headscale")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating Headscale Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	configMap, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(configMap, "configmap"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+	total := 4
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Headscale configurations generated successfully\n", total, total)
+	return nil
+}
+
+func (m *HeadscaleModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying Headscale Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	configMap, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, configMap.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("configmap '%s' already exists in namespace '%s'", configMap.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check configmap existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", pvc.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", service.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create configmap: %w", err)
+	}
+	m.log.Success("Created ConfigMap: %s\n", configMap.Name)
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: %s\n", pvc.Name)
+
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", service.Name)
+
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", deployment.Name)
+
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: Headscale configurations applied successfully\n")
+	m.log.Info("💡 Create a user and a key for its devices: personal-server headscale create-user <name>\n")
+	return nil
+}
+
+// prepare creates and returns the Kubernetes objects for the headscale module
+func (m *HeadscaleModule) prepare() (*corev1.ConfigMap, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	serverURL, err := m.serverURL()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	baseDomain, err := m.baseDomain(serverURL)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	nameservers, err := m.nameservers()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	labels := map[string]string{
+		"app":        "headscale",
+		"managed-by": "personal-server",
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headscale-config",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"config.yaml": configYAML(serverURL.String(), baseDomain, nameservers),
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headscale-data-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headscale",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       containerPort,
+					TargetPort: intstr.FromInt(containerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+				{
+					Name:       "metrics",
+					Port:       metricsPort,
+					TargetPort: intstr.FromInt(metricsPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "headscale",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headscale",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			// The database is a single SQLite file
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "headscale",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "headscale",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "headscale",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Args:            []string{"serve"},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: containerPort,
+								},
+								{
+									Name:          "metrics",
+									ContainerPort: metricsPort,
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "config",
+									MountPath: "/etc/headscale",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "data",
+									MountPath: dataPath,
+								},
+								{
+									Name:      "socket",
+									MountPath: socketPath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "headscale-config"},
+								},
+							},
+						},
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "headscale-data-pvc",
+								},
+							},
+						},
+						{
+							// The image has no writable /var/run for the CLI socket
+							Name: "socket",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, configMap); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), configMap, pvc, service, deployment)
+	return configMap, pvc, service, deployment, nil
+}
+
+func (m *HeadscaleModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning Headscale Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Processing Deployment: headscale\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "headscale", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'headscale' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: headscale\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Service: headscale\n")
+	if err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Delete(ctx, "headscale", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service 'headscale' not found\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: headscale\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: headscale-data-pvc\n")
+	if err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Delete(ctx, "headscale-data-pvc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'headscale-data-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: headscale-data-pvc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing ConfigMap: headscale-config\n")
+	if err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Delete(ctx, "headscale-config", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("ConfigMap 'headscale-config' not found\n")
+		} else {
+			m.log.Error("Failed to delete ConfigMap: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted ConfigMap: headscale-config\n")
+		successCount++
+	}
+
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
+	m.log.Info("\nCompleted: %d Headscale resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+		m.log.Println("The users, keys and devices were deleted with the volume; devices must register again.")
+	}
+	return nil
+}
+
+func (m *HeadscaleModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking Headscale resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "headscale", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'headscale' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	service, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "headscale", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service 'headscale' not found\n")
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		if serverURL, err := m.serverURL(); err == nil {
+			m.log.Info("  Server URL:      %s\n", serverURL)
+		}
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, "headscale-data-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'headscale-data-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, "headscale-config", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("ConfigMap 'headscale-config' not found\n")
+		} else {
+			m.log.Error("Error getting ConfigMap: %v\n", err)
+		}
+	} else {
+		age := time.Since(configMap.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("CONFIGMAP:\n")
+		m.log.Info("  Name:            %s\n", configMap.Name)
+		m.log.Info("  Data keys:       %d\n", len(configMap.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=headscale",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No Headscale pods found")
+	}
+	return nil
+}
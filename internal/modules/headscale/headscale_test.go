@@ -0,0 +1,218 @@
+package headscale
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestModule(secrets map[string]string) *HeadscaleModule {
+	return &HeadscaleModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig:  config.Module{Name: "headscale", Namespace: "infra", Secrets: secrets},
+		log:           logger.NewNopLogger(),
+	}
+}
+
+var testPod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "headscale-7c9d", Namespace: "infra", Labels: map[string]string{"app": "headscale"}}}
+
+func TestHeadscaleModule_Name(t *testing.T) {
+	module := &HeadscaleModule{}
+	if module.Name() != "headscale" {
+		t.Errorf("Name() = %s, want headscale", module.Name())
+	}
+}
+
+func TestHeadscaleModule_Doc(t *testing.T) {
+	module := &HeadscaleModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestHeadscaleModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name       string
+		domain     string
+		secrets    map[string]string
+		storage    string
+		wantConfig []string
+		wantErr    bool
+	}{
+		{
+			name:       "defaults from the general domain",
+			domain:     "example.com",
+			wantConfig: []string{`server_url: "https://headscale.example.com"`, `base_domain: "tailnet.example.com"`, "      - 1.1.1.1\n      - 1.0.0.1\n"},
+		},
+		{
+			name:       "own URL and nameservers",
+			secrets:    map[string]string{"server_url": "https://vpn.example.org", "base_domain": "ts.example.org", "nameservers": "9.9.9.9, 2620:fe::fe"},
+			wantConfig: []string{`server_url: "https://vpn.example.org"`, `base_domain: "ts.example.org"`, "      - 9.9.9.9\n      - 2620:fe::fe\n"},
+		},
+		{name: "no domain", wantErr: true},
+		{name: "server_url without scheme", domain: "example.com", secrets: map[string]string{"server_url": "vpn.example.com"}, wantErr: true},
+		{name: "server inside base_domain", domain: "example.com", secrets: map[string]string{"base_domain": "example.com"}, wantErr: true},
+		{name: "nameserver by name", domain: "example.com", secrets: map[string]string{"nameservers": "dns.quad9.net"}, wantErr: true},
+		{name: "bad storage size", domain: "example.com", storage: "big", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets)
+			module.ModuleConfig.Storage = tt.storage
+			module.GeneralConfig.Domain = tt.domain
+			configMap, _, _, _, err := module.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, want := range tt.wantConfig {
+				if !strings.Contains(configMap.Data["config.yaml"], want) {
+					t.Errorf("config.yaml does not contain %q:\n%s", want, configMap.Data["config.yaml"])
+				}
+			}
+		})
+	}
+}
+
+func TestParsePreauthKeyArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    preauthKeyOptions
+		wantErr bool
+	}{
+		{name: "defaults", args: []string{"alice"}, want: preauthKeyOptions{user: "alice", expiration: "1h"}},
+		{name: "all flags", args: []string{"--reusable", "alice", "--ephemeral", "--expiration", "720h"}, want: preauthKeyOptions{user: "alice", reusable: true, ephemeral: true, expiration: "720h"}},
+		{name: "no user", args: nil, wantErr: true},
+		{name: "two users", args: []string{"alice", "bob"}, wantErr: true},
+		{name: "bad user", args: []string{"Alice Smith"}, wantErr: true},
+		{name: "expiration without value", args: []string{"alice", "--expiration"}, wantErr: true},
+		{name: "bad expiration", args: []string{"alice", "--expiration", "30d"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePreauthKeyArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePreauthKeyArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parsePreauthKeyArgs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadscaleModule_CreateUserWithClient(t *testing.T) {
+	module := newTestModule(nil)
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "headscale-7c9d", Command: []string{"headscale", "users", "create", "alice"}, Stdout: "User created\n"},
+	)
+	if err := module.createUserWithClient(context.Background(), fake.NewSimpleClientset(testPod), executor, "alice"); err != nil {
+		t.Fatalf("createUserWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := parseCreateUserArgs([]string{"alice; rm -rf /"}); err == nil {
+		t.Error("parseCreateUserArgs() accepted a name with spaces")
+	}
+	if err := module.createUserWithClient(context.Background(), fake.NewSimpleClientset(), k8s.NewReplayExecutor(), "alice"); err == nil {
+		t.Error("createUserWithClient() without a pod succeeded, want error")
+	}
+}
+
+func TestHeadscaleModule_PreauthKeyWithClient(t *testing.T) {
+	var buf bytes.Buffer
+	module := newTestModule(nil)
+	module.log = logger.NewStdLogger(&buf)
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{
+			Namespace: "infra",
+			Pod:       "headscale-7c9d",
+			Command:   []string{"headscale", "preauthkeys", "create", "--user", "alice", "--expiration", "24h", "--output", "json", "--reusable"},
+			Stdout:    `{"user":"alice","id":"1","key":"3f1c0b9e8d7a6f5e4d3c2b1a","reusable":true}`,
+		},
+	)
+
+	opts := preauthKeyOptions{user: "alice", reusable: true, expiration: "24h"}
+	if err := module.preauthKeyWithClient(context.Background(), fake.NewSimpleClientset(testPod), executor, opts); err != nil {
+		t.Fatalf("preauthKeyWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+	if want := "tailscale up --login-server https://headscale.example.com --authkey 3f1c0b9e8d7a6f5e4d3c2b1a"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	executor = k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "headscale-7c9d", Command: []string{"headscale", "preauthkeys", "create", "--user", "bob", "--expiration", "1h", "--output", "json"}, Stdout: "Error: user not found"},
+	)
+	err := module.preauthKeyWithClient(context.Background(), fake.NewSimpleClientset(testPod), executor, preauthKeyOptions{user: "bob", expiration: "1h"})
+	if err == nil || !strings.Contains(err.Error(), "user not found") {
+		t.Errorf("preauthKeyWithClient() error = %v, want the command output", err)
+	}
+}
+
+//go:embed testdata/configmap.yaml
+var expectedConfigMapYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := newTestModule(nil)
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"configmap", "configs/headscale/configmap.yaml", expectedConfigMapYAML},
+		{"service", "configs/headscale/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/headscale/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
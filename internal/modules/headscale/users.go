@@ -0,0 +1,177 @@
+package headscale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	createUserUsage = "usage: personal-server headscale create-user <NAME>"
+	preauthKeyUsage = "usage: personal-server headscale preauth-key <USER> [--reusable] [--ephemeral] [--expiration 1h]"
+	// defaultExpiration keeps an unused key from registering devices for long
+	defaultExpiration = "1h"
+)
+
+// userNamePattern matches the user names headscale accepts
+var userNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]*[a-z0-9])?$`)
+
+func parseCreateUserArgs(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf(createUserUsage)
+	}
+	if !userNamePattern.MatchString(args[0]) {
+		return "", fmt.Errorf("invalid user name %q: use lowercase letters, digits, '.', '_' and '-'", args[0])
+	}
+	return args[0], nil
+}
+
+// preauthKeyOptions are the parsed arguments of headscale preauth-key
+type preauthKeyOptions struct {
+	user       string
+	reusable   bool // the key registers any number of devices
+	ephemeral  bool // devices are removed once they go offline
+	expiration string
+}
+
+func parsePreauthKeyArgs(args []string) (preauthKeyOptions, error) {
+	opts := preauthKeyOptions{expiration: defaultExpiration}
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--reusable":
+			opts.reusable = true
+		case "--ephemeral":
+			opts.ephemeral = true
+		case "--expiration":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--expiration requires a value\n%s", preauthKeyUsage)
+			}
+			opts.expiration = args[i+1]
+			i++
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 1 {
+		return opts, fmt.Errorf(preauthKeyUsage)
+	}
+	if !userNamePattern.MatchString(positional[0]) {
+		return opts, fmt.Errorf("invalid user name %q", positional[0])
+	}
+	opts.user = positional[0]
+	if d, err := time.ParseDuration(opts.expiration); err != nil || d <= 0 {
+		return opts, fmt.Errorf("invalid --expiration %q: use a duration like 1h or 720h", opts.expiration)
+	}
+	return opts, nil
+}
+
+// CreateUser creates a tailnet user that devices are registered under
+func (m *HeadscaleModule) CreateUser(ctx context.Context, args []string) error {
+	name, err := parseCreateUserArgs(args)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.createUserWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), name)
+}
+
+func (m *HeadscaleModule) createUserWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, name string) error {
+	podName, err := m.podName(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	m.log.Progress("Creating user '%s'...\n", name)
+	output, err := k8s.ExecCombinedOutput(ctx, executor, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"headscale", "users", "create", name},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create user: %s\nOutput: %s", err, strings.TrimSpace(string(output)))
+	}
+	m.log.Success("Created user '%s'\n", name)
+	m.log.Info("💡 Register a device: personal-server headscale preauth-key %s\n", name)
+	return nil
+}
+
+// PreauthKey creates a key that registers devices of a user without an
+// interactive login and prints the tailscale command using it
+func (m *HeadscaleModule) PreauthKey(ctx context.Context, args []string) error {
+	opts, err := parsePreauthKeyArgs(args)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.preauthKeyWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), opts)
+}
+
+func (m *HeadscaleModule) preauthKeyWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, opts preauthKeyOptions) error {
+	serverURL, err := m.serverURL()
+	if err != nil {
+		return err
+	}
+	podName, err := m.podName(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	command := []string{"headscale", "preauthkeys", "create", "--user", opts.user, "--expiration", opts.expiration, "--output", "json"}
+	if opts.reusable {
+		command = append(command, "--reusable")
+	}
+	if opts.ephemeral {
+		command = append(command, "--ephemeral")
+	}
+
+	m.log.Progress("Creating pre-auth key for user '%s'...\n", opts.user)
+	output, err := k8s.ExecCombinedOutput(ctx, executor, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   command,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pre-auth key: %s\nOutput: %s", err, strings.TrimSpace(string(output)))
+	}
+	var key struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(output, &key); err != nil || key.Key == "" {
+		return fmt.Errorf("unexpected output from headscale preauthkeys create: %s", strings.TrimSpace(string(output)))
+	}
+	m.log.Success("Created pre-auth key for user '%s', valid for %s\n", opts.user, opts.expiration)
+
+	m.log.Info("Pre-auth key: %s\n", key.Key)
+	m.log.Info("Register a device with:\n\n  tailscale up --login-server %s --authkey %s\n", serverURL, key.Key)
+	return nil
+}
+
+// podName returns the running headscale pod the CLI is exec'd in
+func (m *HeadscaleModule) podName(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=headscale",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=headscale")
+	}
+	m.log.Info("📦 Using pod: %s\n", pods.Items[0].Name)
+	return pods.Items[0].Name, nil
+}
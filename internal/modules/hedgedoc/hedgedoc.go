@@ -0,0 +1,679 @@
+package hedgedoc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	"github.com/Goalt/personal-server/internal/servicetls"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage       = "quay.io/hedgedoc/hedgedoc:1.10.0"
+	defaultStorageSize = "5Gi"
+	defaultDBName      = "hedgedoc"
+	defaultDBUser      = "hedgedoc"
+	containerPort      = 3000
+	uploadsPath        = "/hedgedoc/public/uploads"
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "128Mi", MemoryLimit: "512Mi"}
+
+type HedgeDocModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *HedgeDocModule {
+	return &HedgeDocModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *HedgeDocModule) Name() string {
+	return "hedgedoc"
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *HedgeDocModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// Dependencies lists the modules that must be running before hedgedoc is applied
+func (m *HedgeDocModule) Dependencies() []string {
+	return []string{"postgres"}
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	DBPassword    string `yaml:"hedgedoc_db_password" required:"true" generate:"true" doc:"Password of HedgeDoc's PostgreSQL user"`
+	SessionSecret string `yaml:"session_secret" required:"true" generate:"true" doc:"Secret used to sign session cookies"`
+	DBUser        string `yaml:"hedgedoc_db_user" default:"hedgedoc" doc:"HedgeDoc's PostgreSQL user"`
+	DBName        string `yaml:"hedgedoc_db_name" default:"hedgedoc" doc:"HedgeDoc's PostgreSQL database"`
+	DatabaseHost  string `yaml:"database_host" doc:"PostgreSQL host and port (default: the postgres module's host, else postgres:5432)"`
+	Domain        string `yaml:"domain" doc:"Public host name, used for CMD_DOMAIN and links (default: hedgedoc.<general.domain>)"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *HedgeDocModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *HedgeDocModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: hedgedoc\n\n")
+	m.log.Info("Description:\n  Deploys HedgeDoc — a collaborative markdown editor.\n  Manages a Secret, PersistentVolumeClaim (uploads), Service, and Deployment.\n  HedgeDoc is connected to the postgres module for its database; create it first with\n  personal-server postgres add-db hedgedoc hedgedoc\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  hedgedoc_db_password   Password of HedgeDoc's PostgreSQL user\n  session_secret         Secret used to sign session cookies\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  hedgedoc_db_user   HedgeDoc's PostgreSQL user (default: %s)\n  hedgedoc_db_name   HedgeDoc's PostgreSQL database (default: %s)\n  database_host      PostgreSQL host and port (default: the postgres module's host)\n  domain             Public host name (default: hedgedoc.<general.domain>)\n  image              Container image (modules[].image, default: %s)\n  ingress            Expose the web interface at <name>.<general.domain> (modules[].ingress)\n  storage            Size of the uploads volume (modules[].storage, default: %s)\n\n", defaultDBUser, defaultDBName, defaultImage, defaultStorageSize)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/hedgedoc/\n  apply      Create/update resources in the cluster\n  clean      Delete all HedgeDoc resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
+	return nil
+}
+
+func (m *HedgeDocModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "hedgedoc")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating HedgeDoc Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+	total := 4
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d HedgeDoc configurations generated successfully\n", total, total)
+	return nil
+}
+
+func (m *HedgeDocModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying HedgeDoc Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'", secret.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", pvc.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", service.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: %s\n", secret.Name)
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: %s\n", pvc.Name)
+
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", service.Name)
+
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", deployment.Name)
+
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: HedgeDoc configurations applied successfully\n")
+	return nil
+}
+
+// prepare creates and returns the Kubernetes objects for the hedgedoc module
+func (m *HedgeDocModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	dbPassword, exists := m.ModuleConfig.Secrets["hedgedoc_db_password"]
+	if !exists {
+		return nil, nil, nil, nil, fmt.Errorf("hedgedoc_db_password not found in configuration")
+	}
+	sessionSecret, exists := m.ModuleConfig.Secrets["session_secret"]
+	if !exists {
+		return nil, nil, nil, nil, fmt.Errorf("session_secret not found in configuration")
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	labels := map[string]string{
+		"app":        "hedgedoc",
+		"managed-by": "personal-server",
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hedgedoc-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"CMD_DB_URL":         []byte(m.databaseURL(dbPassword)),
+			"CMD_SESSION_SECRET": []byte(sessionSecret),
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hedgedoc-uploads-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hedgedoc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       containerPort,
+					TargetPort: intstr.FromInt(containerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "hedgedoc",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hedgedoc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "hedgedoc",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "hedgedoc",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "hedgedoc",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: containerPort,
+								},
+							},
+							Env: []corev1.EnvVar{
+								{Name: "CMD_DOMAIN", Value: m.domain()},
+								{Name: "CMD_PROTOCOL_USESSL", Value: "true"},
+								{Name: "CMD_URL_ADDPORT", Value: "false"},
+								{Name: "CMD_PORT", Value: fmt.Sprintf("%d", containerPort)},
+							},
+							// CMD_DB_URL and CMD_SESSION_SECRET
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{Name: "hedgedoc-secrets"},
+									},
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/status",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/status",
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "uploads",
+									MountPath: uploadsPath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "uploads",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "hedgedoc-uploads-pvc",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Verify postgres' certificate when it serves TLS. node-postgres turns
+	// PGSSLMODE into a verified connection against Node's CA list, which
+	// NODE_EXTRA_CA_CERTS extends with the mounted CA.
+	if caSecret := m.postgresCASecret(); caSecret != "" {
+		caFile := servicetls.MountCA(&deployment.Spec.Template.Spec, "postgres", caSecret)
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "PGSSLMODE", Value: "verify-full"},
+			corev1.EnvVar{Name: "NODE_EXTRA_CA_CERTS", Value: caFile},
+		)
+	}
+
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, pvc, service, deployment)
+	return secret, pvc, service, deployment, nil
+}
+
+// domain returns the public host name HedgeDoc builds its links from
+func (m *HedgeDocModule) domain() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "domain", "hedgedoc."+m.GeneralConfig.Domain)
+}
+
+// databaseURL returns the CMD_DB_URL connection string for the postgres module
+func (m *HedgeDocModule) databaseURL(dbPassword string) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "hedgedoc_db_user", defaultDBUser), dbPassword),
+		Host:   k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "database_host", m.GeneralConfig.Endpoint("postgres", "postgres:5432")),
+		Path:   "/" + k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "hedgedoc_db_name", defaultDBName),
+	}
+	return u.String()
+}
+
+// postgresCASecret returns the Secret verifying the postgres module's TLS
+// certificate, or "" when it serves plain connections or database_host points at
+// another server
+func (m *HedgeDocModule) postgresCASecret() string {
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "database_host", "") != "" {
+		return ""
+	}
+	return m.GeneralConfig.TLSCASecret("postgres")
+}
+
+func (m *HedgeDocModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning HedgeDoc Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Processing Deployment: hedgedoc\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "hedgedoc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'hedgedoc' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: hedgedoc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Service: hedgedoc\n")
+	if err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Delete(ctx, "hedgedoc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service 'hedgedoc' not found\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: hedgedoc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: hedgedoc-uploads-pvc\n")
+	if err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Delete(ctx, "hedgedoc-uploads-pvc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'hedgedoc-uploads-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: hedgedoc-uploads-pvc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Secret: hedgedoc-secrets\n")
+	if err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Delete(ctx, "hedgedoc-secrets", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'hedgedoc-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: hedgedoc-secrets\n")
+		successCount++
+	}
+
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
+	m.log.Info("\nCompleted: %d HedgeDoc resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *HedgeDocModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking HedgeDoc resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "hedgedoc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'hedgedoc' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	service, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "hedgedoc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service 'hedgedoc' not found\n")
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, "hedgedoc-uploads-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'hedgedoc-uploads-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	secret, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "hedgedoc-secrets", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Secret 'hedgedoc-secrets' not found\n")
+		} else {
+			m.log.Error("Error getting Secret: %v\n", err)
+		}
+	} else {
+		age := time.Since(secret.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SECRET:\n")
+		m.log.Info("  Name:            %s\n", secret.Name)
+		m.log.Info("  Type:            %s\n", secret.Type)
+		m.log.Info("  Data keys:       %d\n", len(secret.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=hedgedoc",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No HedgeDoc pods found")
+	}
+	return nil
+}
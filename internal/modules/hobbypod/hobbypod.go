@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,14 +12,27 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "2Gi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "10Gi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "ghcr.io/goalt/work-config:sha-942241f"
+
 type HobbyPodModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -39,10 +51,28 @@ func (m *HobbyPodModule) Name() string {
 	return "hobby-pod"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *HobbyPodModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *HobbyPodModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *HobbyPodModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: hobby-pod\n\n")
 	m.log.Info("Description:\n  Deploys a personal hobby development pod with a persistent workspace.\n  Manages a PersistentVolumeClaim, Service, and Deployment.\n  Supports VS Code remote tunnels via the code-serve-web subcommand.\n\n")
-	m.log.Info("Optional configuration keys (modules[].secrets):\n  image_tag   Custom container image tag (default: ghcr.io/goalt/work-config:latest)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate        Write Kubernetes YAML to configs/hobbypod/\n  apply           Create/update resources in the cluster\n  clean           Delete all hobby-pod resources from the cluster\n  status          Print Deployment and Pod status\n  doc             Show this documentation\n  backup          Archive the workspace volume to the destination directory\n  restore         Restore the workspace volume from a backup archive\n  code-serve-web  Start a VS Code remote tunnel inside the running pod\n")
 	return nil
 }
@@ -60,7 +90,10 @@ func (m *HobbyPodModule) Generate(ctx context.Context) error {
 	m.log.Info("Output directory: %s\n\n", outputDir)
 
 	// Prepare Kubernetes objects
-	pvc, service, deployment := m.prepare()
+	pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -94,14 +127,37 @@ func (m *HobbyPodModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 3
 
-	m.log.Info("\nCompleted: 3/3 hobby-pod configurations generated successfully\n")
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d hobby-pod configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *HobbyPodModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -135,7 +191,10 @@ func (m *HobbyPodModule) Apply(ctx context.Context) error {
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
-	pvc, service, deployment := m.prepare()
+	pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Apply PVC
 	m.log.Progress("Applying PersistentVolumeClaim: hobby-storage-pvc\n")
@@ -161,11 +220,38 @@ func (m *HobbyPodModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: %s\n", createdDeployment.Name)
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: 3/3 resources applied successfully\n")
 	return nil
 }
 
-func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment) {
+func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	// Prepare PVC
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
@@ -182,11 +268,12 @@ func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Servi
 			},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse("10Gi"),
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Deployment
 	replicas := int32(1)
@@ -195,7 +282,7 @@ func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Servi
 	allowPrivilegeEscalation := true
 
 	// Get custom image tag or use default
-	imageTag := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "image_tag", "ghcr.io/goalt/work-config:sha-942241f")
+	image := m.ModuleConfig.ContainerImage(defaultImage)
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -226,6 +313,7 @@ func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Servi
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -253,8 +341,8 @@ func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Servi
 					Containers: []corev1.Container{
 						{
 							Name:            "hobby",
-							Image:           imageTag,
-							ImagePullPolicy: k8s.DefaultImagePullPolicy(imageTag),
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "http",
@@ -262,6 +350,17 @@ func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Servi
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
+							// No liveness probe: restarting the pod would end interactive
+							// sessions and lose work outside the mounted storage
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 							Env: []corev1.EnvVar{
 								{
 									Name:  "DEBIAN_FRONTEND",
@@ -300,12 +399,26 @@ func (m *HobbyPodModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Servi
 		},
 	}
 
-	return pvc, service, deployment
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), pvc, service, deployment)
+	return pvc, service, deployment, nil
 }
 
 func (m *HobbyPodModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -363,6 +476,22 @@ func (m *HobbyPodModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d/%d resources deleted successfully\n", successCount, totalResources)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -372,7 +501,7 @@ func (m *HobbyPodModule) Clean(ctx context.Context) error {
 
 func (m *HobbyPodModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -479,13 +608,30 @@ func (m *HobbyPodModule) Status(ctx context.Context) error {
 	return nil
 }
 
+// findPod returns the name of the hobby-pod pod exec commands run in
+func (m *HobbyPodModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=hobby-pod",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=hobby-pod")
+	}
+	return pods.Items[0].Name, nil
+}
+
 func (m *HobbyPodModule) Backup(ctx context.Context, destDir string) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
 
+func (m *HobbyPodModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
 	var backupDir string
 	if destDir != "" {
@@ -505,42 +651,27 @@ func (m *HobbyPodModule) Backup(ctx context.Context, destDir string) error {
 	// 1. Backup data volume
 	m.log.Info("💾 Backing up data volume (/data)...\n")
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=hobby-pod",
-	})
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=hobby-pod")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("hobby_data_%s.tar.gz", timestamp))
 
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
-	}
-
-	// Command: tar czf - -C /data .
-	cmdStr := fmt.Sprintf("%s exec -n %s %s -- tar czf - -C /data .", kubectlCmd, m.ModuleConfig.Namespace, podName)
-
-	cmdParts := strings.Fields(cmdStr)
-	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
-
 	outFile, err := os.Create(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to create data backup file: %w", err)
 	}
 	defer outFile.Close()
 
-	cmd.Stdout = outFile
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "-C", "/data", "."},
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to archive data: %w", err)
 	}
 
@@ -548,7 +679,7 @@ func (m *HobbyPodModule) Backup(ctx context.Context, destDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to stat data backup file: %w", err)
 	}
-	m.log.Success("✅ Data archived (%d bytes)\n", fileInfo.Size())
+	m.log.Success("Data archived (%d bytes)\n", fileInfo.Size())
 
 	// 2. Metadata
 	m.log.Info("📋 Writing metadata...\n")
@@ -571,7 +702,7 @@ personal-server hobby-pod restore %s
 	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
-	m.log.Success("✅ Metadata written\n")
+	m.log.Success("Metadata written\n")
 
 	m.log.Success("🎉 Backup complete!\n")
 	m.log.Info("💡 To restore: personal-server hobby-pod restore %s\n", timestamp)
@@ -631,61 +762,57 @@ func (m *HobbyPodModule) Restore(ctx context.Context, args []string) error {
 	m.log.Info("💾 Data will be restored from %s\n", dataBackupFile)
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	if err := m.restoreDataWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dataBackupFile); err != nil {
+		return err
+	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=hobby-pod",
-	})
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreDataWithClient replaces /data in the hobby-pod pod with the contents
+// of a tar archive written by Backup
+func (m *HobbyPodModule) restoreDataWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dataBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=hobby-pod")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	// Restore data
 	m.log.Info("💾 Restoring data...\n")
 
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
-	}
-
 	// 1. Clean existing data
-	cleanCmdStr := fmt.Sprintf("%s exec -n %s %s -- rm -rf /data/*", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	cleanCmdParts := strings.Fields(cleanCmdStr)
-	cleanCmd := exec.CommandContext(ctx, cleanCmdParts[0], cleanCmdParts[1:]...)
-	if err := cleanCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", "rm -rf /data/*"},
+	}); err != nil {
 		m.log.Warn("Warning during clean: %v\n", err)
 	}
 
 	// 2. Restore from tar
-	restoreCmdStr := fmt.Sprintf("%s exec -i -n %s %s -- tar xzf - -C /data", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	restoreCmdParts := strings.Fields(restoreCmdStr)
-	restoreCmd := exec.CommandContext(ctx, restoreCmdParts[0], restoreCmdParts[1:]...)
-
 	inFile, err := os.Open(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to open data backup file: %w", err)
 	}
 	defer inFile.Close()
 
-	restoreCmd.Stdin = inFile
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", "/data"},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore data: %w", err)
 	}
-	m.log.Success("✅ Data restored\n")
-
-	m.log.Success("🎉 Restore complete!\n")
+	m.log.Success("Data restored\n")
 	return nil
 }
 
@@ -695,20 +822,23 @@ func (m *HobbyPodModule) CodeServeWeb(ctx context.Context) error {
 		return fmt.Errorf("failed to generate connection token: %w", err)
 	}
 
-	kubectlBin := "kubectl"
-	var kubectlArgs []string
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlBin = "/snap/bin/microk8s"
-		kubectlArgs = append(kubectlArgs, "kubectl")
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	podName, err := k8s.RunningPod(ctx, clientset, m.ModuleConfig.Namespace, "app=hobby-pod")
+	if err != nil {
+		return err
 	}
 
 	shellCmd := fmt.Sprintf("nohup code serve-web --host 0.0.0.0 --port 20000 --connection-token %s > /dev/null 2>&1 &", token)
-	kubectlArgs = append(kubectlArgs, "exec", "-n", m.ModuleConfig.Namespace, "deployment/hobby-pod", "--", "sh", "-c", shellCmd)
-	cmd := exec.CommandContext(ctx, kubectlBin, kubectlArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := m.GeneralConfig.Clients.Executor().Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", shellCmd},
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to start code serve-web: %w", err)
 	}
 
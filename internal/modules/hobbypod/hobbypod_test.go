@@ -8,10 +8,13 @@ import (
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestHobbyPodModule_Name(t *testing.T) {
@@ -48,7 +51,10 @@ func TestHobbyPodModule_Prepare(t *testing.T) {
 				},
 			}
 
-			pvc, service, deployment := module.prepare()
+			pvc, service, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			// Verify all objects are not nil
 			if pvc == nil {
@@ -86,7 +92,10 @@ func TestHobbyPodModule_PreparePVC(t *testing.T) {
 		},
 	}
 
-	pvc, _, _ := module.prepare()
+	pvc, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test PVC name
 	if pvc.Name != "hobby-storage-pvc" {
@@ -133,7 +142,10 @@ func TestHobbyPodModule_PrepareDeployment(t *testing.T) {
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Deployment name
 	if deployment.Name != "hobby-pod" {
@@ -183,7 +195,10 @@ func TestHobbyPodModule_PrepareDeploymentContainer(t *testing.T) {
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify container count
 	if len(deployment.Spec.Template.Spec.Containers) != 1 {
@@ -247,7 +262,10 @@ func TestHobbyPodModule_PrepareDeploymentContainerSecurityContext(t *testing.T)
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	container := deployment.Spec.Template.Spec.Containers[0]
 
@@ -302,7 +320,10 @@ func TestHobbyPodModule_PrepareDeploymentVolumes(t *testing.T) {
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test volumes
 	if len(deployment.Spec.Template.Spec.Volumes) != 1 {
@@ -332,13 +353,14 @@ func TestHobbyPodModule_PrepareWithCustomImageTag(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "hobby-pod",
 			Namespace: "test-namespace",
-			Secrets: map[string]string{
-				"image_tag": customImageTag,
-			},
+			Image:     customImageTag,
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify deployment is not nil
 	if deployment == nil {
@@ -368,7 +390,10 @@ func TestHobbyPodModule_PrepareService(t *testing.T) {
 		},
 	}
 
-	_, service, _ := module.prepare()
+	_, service, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Service name
 	if service.Name != "hobby-pod" {
@@ -492,3 +517,51 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestHobbyPodModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "hobby-pod-7d9f", Namespace: "hobby", Labels: map[string]string{"app": "hobby-pod"}}}
+	module := &HobbyPodModule{ModuleConfig: config.Module{Name: "hobby-pod", Namespace: "hobby"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "hobby", Pod: "hobby-pod-7d9f", Command: []string{"tar", "czf", "-", "-C", "/data", "."}, Stdout: "archive"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(destDir, "hobby-pod", "hobby_data_*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("expected one archive, got %v", archives)
+	}
+	if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+		t.Errorf("archive = %q, want the tar output", data)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "hobby-pod", "backup_info.txt")); err != nil {
+		t.Errorf("metadata not written: %v", err)
+	}
+}
+
+func TestHobbyPodModule_RestoreDataWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "hobby-pod-7d9f", Namespace: "hobby", Labels: map[string]string{"app": "hobby-pod"}}}
+	archive := filepath.Join(t.TempDir(), "hobby_data.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &HobbyPodModule{ModuleConfig: config.Module{Name: "hobby-pod", Namespace: "hobby"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "hobby", Pod: "hobby-pod-7d9f", Command: []string{"sh", "-c", "rm -rf /data/*"}},
+		k8s.ExecRecord{Namespace: "hobby", Pod: "hobby-pod-7d9f", Command: []string{"tar", "xzf", "-", "-C", "/data"}, Stdin: "archive"},
+	)
+
+	if err := module.restoreDataWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreDataWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/Goalt/personal-server/internal/certs"
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
@@ -21,8 +24,15 @@ type IngressModule struct {
 	GeneralConfig config.GeneralConfig
 	IngressConfig config.IngressConfig
 	log           logger.Logger
+	certsDir      string // overrides certs.DefaultDir in tests
+	// dial overrides net.Dialer for the IPv6 reachability check in tests
+	dial func(ctx context.Context, network, address string) (net.Conn, error)
 }
 
+// ipv6DialTimeout bounds each connection attempt of the IPv6 reachability
+// check in status
+const ipv6DialTimeout = 5 * time.Second
+
 func New(generalConfig config.GeneralConfig, ingressConfig config.IngressConfig, log logger.Logger) *IngressModule {
 	return &IngressModule{
 		GeneralConfig: generalConfig,
@@ -38,7 +48,7 @@ func (m *IngressModule) Name() string {
 func (m *IngressModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: ingress (%s)\n\n", m.IngressConfig.Name)
 	m.log.Info("Description:\n  Manages HTTP/HTTPS ingress routing and TCP/UDP service exposure.\n  Generates an Ingress resource for HTTP rules and optional ConfigMaps for\n  TCP and UDP services. Each named ingress entry in the config becomes its own\n  module instance identified by the ingress name.\n\n")
-	m.log.Info("Configuration (ingresses[] entry):\n  name          Unique name for this ingress (used as the module command name)\n  namespace     Kubernetes namespace\n  rules[]       HTTP routing rules (host, path, pathType, serviceName, servicePort)\n  tls           Enable TLS/HTTPS (boolean)\n  tcpServices[] TCP services to expose (port, serviceName, servicePort, namespace)\n  udpServices[] UDP services to expose (port, serviceName, servicePort)\n\n")
+	m.log.Info("Configuration (ingresses[] entry):\n  name          Unique name for this ingress (used as the module command name)\n  namespace     Kubernetes namespace\n  rules[]       HTTP routing rules (host, path, pathType, serviceName, servicePort)\n  tls           Enable TLS/HTTPS (boolean)\n  clusterIssuer cert-manager ClusterIssuer issuing the certificate (e.g. letsencrypt)\n  basicAuth     Protect HTTP rules with basic-auth (realm, users: {name: password})\n  allowedSourceRanges[]  CIDRs allowed to reach the HTTP rules (ingress-nginx whitelist)\n  clientCertAuth  Require client certificates from the local CA (issue with: certs issue-client <name>)\n  crowdsec      Reject requests from IPs banned by the crowdsec module (boolean)\n  tcpServices[] TCP services to expose (port, serviceName, servicePort, namespace)\n  udpServices[] UDP services to expose (port, serviceName, servicePort)\n\n")
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/ingress/%s/\n  apply      Create/update resources in the cluster\n  clean      Delete all ingress resources from the cluster\n  status     Print Ingress status\n  doc        Show this documentation\n", m.IngressConfig.Name)
 	return nil
 }
@@ -88,10 +98,33 @@ func (m *IngressModule) Generate(ctx context.Context) error {
 
 	// Generate HTTP Ingress if rules are defined
 	if len(m.IngressConfig.Rules) > 0 {
+		if err := m.validateCrowdSec(); err != nil {
+			return err
+		}
 		ingress := m.prepare()
 		if err := writeYAML(ingress, "ingress.yaml"); err != nil {
 			return err
 		}
+
+		authSecret, err := m.prepareBasicAuthSecret()
+		if err != nil {
+			return err
+		}
+		if authSecret != nil {
+			if err := writeYAML(authSecret, "basic-auth-secret.yaml"); err != nil {
+				return err
+			}
+		}
+
+		caSecret, err := m.prepareClientCASecret()
+		if err != nil {
+			return err
+		}
+		if caSecret != nil {
+			if err := writeYAML(caSecret, "client-ca-secret.yaml"); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Generate TCP ConfigMap if TCP services are defined
@@ -119,7 +152,7 @@ func (m *IngressModule) Apply(ctx context.Context) error {
 	}
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -130,6 +163,10 @@ func (m *IngressModule) Apply(ctx context.Context) error {
 
 	// Apply HTTP Ingress if rules are defined
 	if len(m.IngressConfig.Rules) > 0 {
+		if err := m.validateCrowdSec(); err != nil {
+			return err
+		}
+
 		// Check if resource already exists
 		m.log.Info("Checking for existing Ingress...\n")
 		_, err = clientset.NetworkingV1().Ingresses(m.IngressConfig.Namespace).Get(ctx, m.IngressConfig.Name, metav1.GetOptions{})
@@ -141,6 +178,46 @@ func (m *IngressModule) Apply(ctx context.Context) error {
 
 		m.log.Info("No existing Ingress found, proceeding with creation...\n\n")
 
+		// Apply basic-auth Secret before the Ingress that references it
+		authSecret, err := m.prepareBasicAuthSecret()
+		if err != nil {
+			return err
+		}
+		if authSecret != nil {
+			m.log.Progress("Applying Secret: %s\n", authSecret.Name)
+			_, err = clientset.CoreV1().Secrets(m.IngressConfig.Namespace).Create(ctx, authSecret, metav1.CreateOptions{})
+			if err != nil {
+				if !errors.IsAlreadyExists(err) {
+					return fmt.Errorf("failed to create basic-auth Secret: %w", err)
+				}
+				_, err = clientset.CoreV1().Secrets(m.IngressConfig.Namespace).Update(ctx, authSecret, metav1.UpdateOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to update basic-auth Secret: %w", err)
+				}
+			}
+			m.log.Success("Applied Secret: %s\n", authSecret.Name)
+		}
+
+		// Apply client CA Secret used to verify client certificates
+		caSecret, err := m.prepareClientCASecret()
+		if err != nil {
+			return err
+		}
+		if caSecret != nil {
+			m.log.Progress("Applying Secret: %s\n", caSecret.Name)
+			_, err = clientset.CoreV1().Secrets(m.IngressConfig.Namespace).Create(ctx, caSecret, metav1.CreateOptions{})
+			if err != nil {
+				if !errors.IsAlreadyExists(err) {
+					return fmt.Errorf("failed to create client CA Secret: %w", err)
+				}
+				_, err = clientset.CoreV1().Secrets(m.IngressConfig.Namespace).Update(ctx, caSecret, metav1.UpdateOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to update client CA Secret: %w", err)
+				}
+			}
+			m.log.Success("Applied Secret: %s\n", caSecret.Name)
+		}
+
 		// Prepare and apply Ingress
 		ingress := m.prepare()
 		m.log.Progress("Applying Ingress: %s\n", m.IngressConfig.Name)
@@ -277,6 +354,16 @@ func (m *IngressModule) prepare() *networkingv1.Ingress {
 		},
 	}
 
+	annotations := m.accessAnnotations()
+	if m.IngressConfig.TLS && m.IngressConfig.ClusterIssuer != "" {
+		// cert-manager's ingress-shim creates a Certificate for the TLS
+		// section below, storing it in the referenced Secret
+		annotations["cert-manager.io/cluster-issuer"] = m.IngressConfig.ClusterIssuer
+	}
+	if len(annotations) > 0 {
+		ingress.Annotations = annotations
+	}
+
 	// Add TLS configuration if enabled
 	if m.IngressConfig.TLS && len(tlsHosts) > 0 {
 		// Remove duplicates from tlsHosts
@@ -297,9 +384,151 @@ func (m *IngressModule) prepare() *networkingv1.Ingress {
 		}
 	}
 
+	k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), ingress)
 	return ingress
 }
 
+func (m *IngressModule) basicAuthSecretName() string {
+	return fmt.Sprintf("%s-basic-auth", m.IngressConfig.Name)
+}
+
+func (m *IngressModule) basicAuthEnabled() bool {
+	return m.IngressConfig.BasicAuth != nil && len(m.IngressConfig.BasicAuth.Users) > 0
+}
+
+// crowdsecAuthURL returns the bouncer URL ingress-nginx asks about every
+// request, or "" when no crowdsec module is configured
+func (m *IngressModule) crowdsecAuthURL() string {
+	endpoint := m.GeneralConfig.Endpoint("crowdsec", "")
+	if endpoint == "" {
+		return ""
+	}
+	return fmt.Sprintf("http://%s/api/v1/forwardAuth", endpoint)
+}
+
+// validateCrowdSec rejects crowdsec: true without a crowdsec module, which
+// would otherwise leave the ingress silently unprotected
+func (m *IngressModule) validateCrowdSec() error {
+	if m.IngressConfig.CrowdSec && m.crowdsecAuthURL() == "" {
+		return fmt.Errorf("ingress '%s' sets crowdsec: true but no crowdsec module is configured", m.IngressConfig.Name)
+	}
+	return nil
+}
+
+// accessAnnotations returns the ingress-nginx annotations restricting access
+// to the HTTP rules via basic-auth, a source IP allowlist, client
+// certificates and/or the CrowdSec bouncer.
+func (m *IngressModule) accessAnnotations() map[string]string {
+	annotations := make(map[string]string)
+
+	if m.basicAuthEnabled() {
+		realm := m.IngressConfig.BasicAuth.Realm
+		if realm == "" {
+			realm = "Authentication Required"
+		}
+		annotations["nginx.ingress.kubernetes.io/auth-type"] = "basic"
+		annotations["nginx.ingress.kubernetes.io/auth-secret"] = m.basicAuthSecretName()
+		annotations["nginx.ingress.kubernetes.io/auth-realm"] = realm
+	}
+
+	if len(m.IngressConfig.AllowedSourceRanges) > 0 {
+		annotations["nginx.ingress.kubernetes.io/whitelist-source-range"] = strings.Join(m.IngressConfig.AllowedSourceRanges, ",")
+	}
+
+	if m.IngressConfig.ClientCertAuth {
+		annotations["nginx.ingress.kubernetes.io/auth-tls-verify-client"] = "on"
+		annotations["nginx.ingress.kubernetes.io/auth-tls-secret"] = fmt.Sprintf("%s/%s", m.IngressConfig.Namespace, m.clientCASecretName())
+		annotations["nginx.ingress.kubernetes.io/auth-tls-verify-depth"] = "1"
+	}
+
+	// The bouncer answers 403 for banned IPs, which ingress-nginx passes on
+	if m.IngressConfig.CrowdSec {
+		if authURL := m.crowdsecAuthURL(); authURL != "" {
+			annotations["nginx.ingress.kubernetes.io/auth-url"] = authURL
+		}
+	}
+
+	return annotations
+}
+
+func (m *IngressModule) clientCASecretName() string {
+	return fmt.Sprintf("%s-client-ca", m.IngressConfig.Name)
+}
+
+// prepareClientCASecret creates the Secret holding the local CA certificate
+// that ingress-nginx uses to verify client certificates. The CA is generated
+// on first use. Returns nil when client certificate auth is not enabled.
+func (m *IngressModule) prepareClientCASecret() (*corev1.Secret, error) {
+	if !m.IngressConfig.ClientCertAuth {
+		return nil, nil
+	}
+
+	dir := m.certsDir
+	if dir == "" {
+		dir = certs.DefaultDir
+	}
+	ca, created, err := certs.LoadOrCreateCA(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client CA: %w", err)
+	}
+	if created && m.log != nil {
+		m.log.Info("Created new client CA in %s/\n", dir)
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.clientCASecretName(),
+			Namespace: m.IngressConfig.Namespace,
+			Labels: map[string]string{
+				"managed-by": "personal-server",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"ca.crt": ca.CertPEM,
+		},
+	}
+	k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), secret)
+	return secret, nil
+}
+
+// prepareBasicAuthSecret creates the htpasswd Secret referenced by the
+// auth-secret annotation. Returns nil when basic-auth is not configured.
+func (m *IngressModule) prepareBasicAuthSecret() (*corev1.Secret, error) {
+	if !m.basicAuthEnabled() {
+		return nil, nil
+	}
+
+	htpasswd, err := k8s.GenerateHtpasswd(m.IngressConfig.BasicAuth.Users)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate htpasswd for ingress '%s': %w", m.IngressConfig.Name, err)
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.basicAuthSecretName(),
+			Namespace: m.IngressConfig.Namespace,
+			Labels: map[string]string{
+				"managed-by": "personal-server",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"auth": htpasswd,
+		},
+	}
+	k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), secret)
+	return secret, nil
+}
+
 // preparePortConfigMap creates a ConfigMap for TCP or UDP services
 func (m *IngressModule) preparePortConfigMap(services interface{}, suffix string) *corev1.ConfigMap {
 	var data map[string]string
@@ -338,7 +567,7 @@ func (m *IngressModule) preparePortConfigMap(services interface{}, suffix string
 		return nil
 	}
 
-	return &corev1.ConfigMap{
+	configMap := &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "ConfigMap",
@@ -352,6 +581,8 @@ func (m *IngressModule) preparePortConfigMap(services interface{}, suffix string
 		},
 		Data: data,
 	}
+	k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), configMap)
+	return configMap
 }
 
 func (m *IngressModule) prepareTCPConfigMap() *corev1.ConfigMap {
@@ -364,7 +595,7 @@ func (m *IngressModule) prepareUDPConfigMap() *corev1.ConfigMap {
 
 func (m *IngressModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -392,6 +623,36 @@ func (m *IngressModule) Clean(ctx context.Context) error {
 		}
 	}
 
+	// Try to delete the basic-auth Secret
+	if m.basicAuthEnabled() {
+		secretName := m.basicAuthSecretName()
+		err = clientset.CoreV1().Secrets(m.IngressConfig.Namespace).Delete(ctx, secretName, deleteOptions)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("Secret '%s' not found (already deleted or never existed)\n", secretName)
+			} else {
+				return fmt.Errorf("failed to delete Secret '%s': %w", secretName, err)
+			}
+		} else {
+			m.log.Success("Deleted Secret: %s\n", secretName)
+		}
+	}
+
+	// Try to delete the client CA Secret
+	if m.IngressConfig.ClientCertAuth {
+		secretName := m.clientCASecretName()
+		err = clientset.CoreV1().Secrets(m.IngressConfig.Namespace).Delete(ctx, secretName, deleteOptions)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("Secret '%s' not found (already deleted or never existed)\n", secretName)
+			} else {
+				return fmt.Errorf("failed to delete Secret '%s': %w", secretName, err)
+			}
+		} else {
+			m.log.Success("Deleted Secret: %s\n", secretName)
+		}
+	}
+
 	// Try to delete TCP ConfigMap
 	if len(m.IngressConfig.TCPServices) > 0 {
 		tcpConfigMapName := fmt.Sprintf("%s-tcp", m.IngressConfig.Name)
@@ -428,7 +689,7 @@ func (m *IngressModule) Clean(ctx context.Context) error {
 
 func (m *IngressModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -487,6 +748,30 @@ func (m *IngressModule) Status(ctx context.Context) error {
 					m.log.Info("  Secret: %s\n", tls.SecretName)
 					m.log.Info("  Hosts: %v\n", tls.Hosts)
 				}
+				if issuer := ingress.Annotations["cert-manager.io/cluster-issuer"]; issuer != "" {
+					m.log.Info("  Issuer: %s (cert-manager)\n", issuer)
+				}
+			}
+
+			// Display access restrictions
+			authType := ingress.Annotations["nginx.ingress.kubernetes.io/auth-type"]
+			ranges := ingress.Annotations["nginx.ingress.kubernetes.io/whitelist-source-range"]
+			clientCA := ingress.Annotations["nginx.ingress.kubernetes.io/auth-tls-secret"]
+			authURL := ingress.Annotations["nginx.ingress.kubernetes.io/auth-url"]
+			if authType != "" || ranges != "" || clientCA != "" || authURL != "" {
+				m.log.Info("\nACCESS:\n")
+				if authType != "" {
+					m.log.Info("  Auth: %s (secret %s)\n", authType, ingress.Annotations["nginx.ingress.kubernetes.io/auth-secret"])
+				}
+				if ranges != "" {
+					m.log.Info("  Allowed source ranges: %s\n", ranges)
+				}
+				if clientCA != "" {
+					m.log.Info("  Client certificates: required (CA secret %s)\n", clientCA)
+				}
+				if authURL != "" {
+					m.log.Info("  Forward auth: %s\n", authURL)
+				}
 			}
 
 			// Display load balancer ingress
@@ -501,6 +786,7 @@ func (m *IngressModule) Status(ctx context.Context) error {
 					}
 				}
 			}
+			m.printDNSRecords(ctx, ingress)
 			m.log.Println()
 		}
 	}
@@ -555,3 +841,89 @@ func (m *IngressModule) Status(ctx context.Context) error {
 
 	return nil
 }
+
+// dnsRecord is a record pointing a rule host at the ingress controller
+type dnsRecord struct {
+	host  string
+	kind  string
+	value string
+}
+
+// dnsRecords returns the A, AAAA or CNAME records that point each host at the
+// addresses the ingress controller publishes in the Ingress status
+func dnsRecords(hosts []string, addresses []networkingv1.IngressLoadBalancerIngress) []dnsRecord {
+	var records []dnsRecord
+	for _, host := range hosts {
+		for _, address := range addresses {
+			switch ip := net.ParseIP(address.IP); {
+			case ip != nil && ip.To4() != nil:
+				records = append(records, dnsRecord{host, "A", address.IP})
+			case ip != nil:
+				records = append(records, dnsRecord{host, "AAAA", address.IP})
+			case address.Hostname != "":
+				records = append(records, dnsRecord{host, "CNAME", address.Hostname})
+			}
+		}
+	}
+	return records
+}
+
+// printDNSRecords lists the DNS records the rule hosts need, warns when a
+// dual-stack setup publishes no IPv6 address to point AAAA records at and
+// checks that the IPv6 addresses it does publish accept connections
+func (m *IngressModule) printDNSRecords(ctx context.Context, ingress *networkingv1.Ingress) {
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" && !strings.HasPrefix(rule.Host, "*") {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	records := dnsRecords(hosts, ingress.Status.LoadBalancer.Ingress)
+	if len(records) == 0 {
+		return
+	}
+
+	m.log.Info("\nDNS RECORDS:\n")
+	hasAAAA := false
+	for _, record := range records {
+		m.log.Info("  %-40s %-6s %s\n", record.host, record.kind, record.value)
+		hasAAAA = hasAAAA || record.kind == "AAAA"
+	}
+	if policy := m.GeneralConfig.IPFamilyPolicy; !hasAAAA && policy != "" && policy != string(corev1.IPFamilyPolicySingleStack) {
+		m.log.Warn("general.ipFamilyPolicy is %s but the ingress controller publishes no IPv6 address; IPv6 clients cannot reach these hosts\n", policy)
+	}
+	if hasAAAA {
+		m.checkIPv6Reachability(ctx, ingress)
+	}
+}
+
+// checkIPv6Reachability connects to every IPv6 address the ingress
+// controller publishes, on 443 when the Ingress terminates TLS and 80
+// otherwise. It runs from where the CLI runs, so a failure usually means a
+// firewall or missing route between there and the cluster.
+func (m *IngressModule) checkIPv6Reachability(ctx context.Context, ingress *networkingv1.Ingress) {
+	port := "80"
+	if len(ingress.Spec.TLS) > 0 {
+		port = "443"
+	}
+	dial := m.dial
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: ipv6DialTimeout}).DialContext
+	}
+
+	m.log.Info("\nIPV6 REACHABILITY:\n")
+	for _, address := range ingress.Status.LoadBalancer.Ingress {
+		ip := net.ParseIP(address.IP)
+		if ip == nil || ip.To4() != nil {
+			continue
+		}
+		target := net.JoinHostPort(address.IP, port)
+		conn, err := dial(ctx, "tcp6", target)
+		if err != nil {
+			m.log.Warn("  %s: unreachable: %v\n", target, err)
+			continue
+		}
+		conn.Close()
+		m.log.Success("  %s: reachable\n", target)
+	}
+}
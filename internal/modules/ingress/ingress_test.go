@@ -1,14 +1,20 @@
 package ingress
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/logger"
+	networkingv1 "k8s.io/api/networking/v1"
 )
 
 func TestIngressModule_Name(t *testing.T) {
@@ -278,6 +284,190 @@ func TestIngressModule_PrepareTLS(t *testing.T) {
 	}
 }
 
+func TestIngressModule_PrepareClusterIssuer(t *testing.T) {
+	tests := []struct {
+		name string
+		tls  bool
+		want string
+	}{
+		{name: "with TLS", tls: true, want: "letsencrypt"},
+		{name: "without TLS", tls: false, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &IngressModule{
+				IngressConfig: config.IngressConfig{
+					Name:      "app",
+					Namespace: "default",
+					Rules: []config.IngressRule{
+						{Host: "app.example.com", Path: "/", ServiceName: "app", ServicePort: 80},
+					},
+					TLS:           tt.tls,
+					ClusterIssuer: "letsencrypt",
+				},
+			}
+
+			ingress := module.prepare()
+			if got := ingress.Annotations["cert-manager.io/cluster-issuer"]; got != tt.want {
+				t.Errorf("cert-manager.io/cluster-issuer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngressModule_PrepareAccessAnnotations(t *testing.T) {
+	tests := []struct {
+		name            string
+		basicAuth       *config.IngressBasicAuth
+		sourceRanges    []string
+		wantAnnotations map[string]string
+	}{
+		{
+			name:            "no restrictions",
+			wantAnnotations: nil,
+		},
+		{
+			name:      "basic auth with default realm",
+			basicAuth: &config.IngressBasicAuth{Users: map[string]string{"admin": "secret"}},
+			wantAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/auth-type":   "basic",
+				"nginx.ingress.kubernetes.io/auth-secret": "dashboard-basic-auth",
+				"nginx.ingress.kubernetes.io/auth-realm":  "Authentication Required",
+			},
+		},
+		{
+			name:         "basic auth and allowlist",
+			basicAuth:    &config.IngressBasicAuth{Realm: "Dashboard", Users: map[string]string{"admin": "secret"}},
+			sourceRanges: []string{"10.0.0.0/8", "192.168.1.0/24"},
+			wantAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/auth-type":              "basic",
+				"nginx.ingress.kubernetes.io/auth-secret":            "dashboard-basic-auth",
+				"nginx.ingress.kubernetes.io/auth-realm":             "Dashboard",
+				"nginx.ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8,192.168.1.0/24",
+			},
+		},
+		{
+			name:         "allowlist only",
+			sourceRanges: []string{"203.0.113.7/32"},
+			wantAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/whitelist-source-range": "203.0.113.7/32",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &IngressModule{
+				IngressConfig: config.IngressConfig{
+					Name:      "dashboard",
+					Namespace: "infra",
+					Rules: []config.IngressRule{
+						{Host: "dashboard.example.com", ServiceName: "dashboard", ServicePort: 80},
+					},
+					BasicAuth:           tt.basicAuth,
+					AllowedSourceRanges: tt.sourceRanges,
+				},
+			}
+
+			ingress := module.prepare()
+			if !reflect.DeepEqual(ingress.Annotations, tt.wantAnnotations) {
+				t.Errorf("annotations = %v, want %v", ingress.Annotations, tt.wantAnnotations)
+			}
+
+			secret, err := module.prepareBasicAuthSecret()
+			if err != nil {
+				t.Fatalf("prepareBasicAuthSecret() returned error: %v", err)
+			}
+			if tt.basicAuth == nil {
+				if secret != nil {
+					t.Error("expected no basic-auth Secret")
+				}
+				return
+			}
+			if secret == nil {
+				t.Fatal("expected basic-auth Secret")
+			}
+			if secret.Name != "dashboard-basic-auth" || secret.Namespace != "infra" {
+				t.Errorf("secret = %s/%s, want infra/dashboard-basic-auth", secret.Namespace, secret.Name)
+			}
+			if !strings.HasPrefix(secret.StringData["auth"], "admin:$apr1$") {
+				t.Errorf("auth = %q, want admin APR1 htpasswd entry", secret.StringData["auth"])
+			}
+		})
+	}
+}
+
+func TestIngressModule_PrepareClientCertAuth(t *testing.T) {
+	certsDir := t.TempDir()
+	module := &IngressModule{
+		IngressConfig: config.IngressConfig{
+			Name:      "admin",
+			Namespace: "infra",
+			Rules: []config.IngressRule{
+				{Host: "drone.example.com", ServiceName: "drone", ServicePort: 80},
+			},
+			ClientCertAuth: true,
+		},
+		certsDir: certsDir,
+	}
+
+	ingress := module.prepare()
+	want := map[string]string{
+		"nginx.ingress.kubernetes.io/auth-tls-verify-client": "on",
+		"nginx.ingress.kubernetes.io/auth-tls-secret":        "infra/admin-client-ca",
+		"nginx.ingress.kubernetes.io/auth-tls-verify-depth":  "1",
+	}
+	if !reflect.DeepEqual(ingress.Annotations, want) {
+		t.Errorf("annotations = %v, want %v", ingress.Annotations, want)
+	}
+
+	secret, err := module.prepareClientCASecret()
+	if err != nil {
+		t.Fatalf("prepareClientCASecret() returned error: %v", err)
+	}
+	if secret == nil || secret.Name != "admin-client-ca" {
+		t.Fatalf("expected admin-client-ca Secret, got %v", secret)
+	}
+	if !strings.Contains(string(secret.Data["ca.crt"]), "BEGIN CERTIFICATE") {
+		t.Error("ca.crt should contain the PEM encoded CA certificate")
+	}
+	if _, err := os.Stat(filepath.Join(certsDir, "ca.key")); err != nil {
+		t.Errorf("CA key should be created on first use: %v", err)
+	}
+}
+
+func TestIngressModule_PrepareCrowdSec(t *testing.T) {
+	module := &IngressModule{
+		IngressConfig: config.IngressConfig{
+			Name:      "vault",
+			Namespace: "infra",
+			Rules: []config.IngressRule{
+				{Host: "vault.example.com", ServiceName: "bitwarden", ServicePort: 80},
+			},
+			CrowdSec: true,
+		},
+	}
+
+	if err := module.validateCrowdSec(); err == nil {
+		t.Error("validateCrowdSec() without a crowdsec module succeeded, want error")
+	}
+	if got := module.prepare().Annotations; got != nil {
+		t.Errorf("annotations without a crowdsec module = %v, want none", got)
+	}
+
+	module.GeneralConfig.Endpoints = map[string]string{"crowdsec": "crowdsec-bouncer.security.svc.cluster.local:8080"}
+	if err := module.validateCrowdSec(); err != nil {
+		t.Errorf("validateCrowdSec() returned error: %v", err)
+	}
+	want := map[string]string{
+		"nginx.ingress.kubernetes.io/auth-url": "http://crowdsec-bouncer.security.svc.cluster.local:8080/api/v1/forwardAuth",
+	}
+	if got := module.prepare().Annotations; !reflect.DeepEqual(got, want) {
+		t.Errorf("annotations = %v, want %v", got, want)
+	}
+}
+
 func TestIngressModule_PrepareMultiplePathsSameHost(t *testing.T) {
 	module := &IngressModule{
 		GeneralConfig: config.GeneralConfig{
@@ -686,3 +876,85 @@ func TestGenerate_TCPUDPServices(t *testing.T) {
 		t.Errorf("Generated UDP ConfigMap YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(udpContent), expectedUDPConfigMapYAML)
 	}
 }
+
+func TestDNSRecords(t *testing.T) {
+	addresses := []networkingv1.IngressLoadBalancerIngress{
+		{IP: "203.0.113.10"},
+		{IP: "2001:db8::10"},
+		{Hostname: "lb.example.net"},
+	}
+	got := dnsRecords([]string{"app.example.com"}, addresses)
+	want := []dnsRecord{
+		{"app.example.com", "A", "203.0.113.10"},
+		{"app.example.com", "AAAA", "2001:db8::10"},
+		{"app.example.com", "CNAME", "lb.example.net"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dnsRecords() = %v, want %v", got, want)
+	}
+}
+
+func TestPrintDNSRecordsWarnsWithoutIPv6(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "app.example.com"}, {Host: "*.example.com"}}},
+	}
+	ingress.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}}
+
+	for _, tt := range []struct {
+		policy   string
+		wantWarn bool
+	}{
+		{policy: "", wantWarn: false},
+		{policy: "SingleStack", wantWarn: false},
+		{policy: "PreferDualStack", wantWarn: true},
+	} {
+		var buf bytes.Buffer
+		module := &IngressModule{GeneralConfig: config.GeneralConfig{IPFamilyPolicy: tt.policy}, log: logger.NewStdLogger(&buf)}
+		module.printDNSRecords(context.Background(), ingress)
+
+		out := buf.String()
+		if !strings.Contains(out, "app.example.com") || strings.Contains(out, "*.example.com") {
+			t.Errorf("policy %q: output = %q, want a record for app.example.com only", tt.policy, out)
+		}
+		if got := strings.Contains(out, "no IPv6 address"); got != tt.wantWarn {
+			t.Errorf("policy %q: IPv6 warning = %v, want %v", tt.policy, got, tt.wantWarn)
+		}
+	}
+}
+
+func TestCheckIPv6Reachability(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		Spec: networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{{Hosts: []string{"app.example.com"}}}},
+	}
+	ingress.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{
+		{IP: "203.0.113.10"},
+		{IP: "2001:db8::10"},
+		{IP: "2001:db8::20"},
+	}
+
+	var dialed []string
+	var buf bytes.Buffer
+	module := &IngressModule{log: logger.NewStdLogger(&buf)}
+	module.dial = func(_ context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, network+" "+address)
+		if address == "[2001:db8::20]:443" {
+			return nil, fmt.Errorf("connection refused")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	module.checkIPv6Reachability(context.Background(), ingress)
+
+	want := []string{"tcp6 [2001:db8::10]:443", "tcp6 [2001:db8::20]:443"}
+	if !reflect.DeepEqual(dialed, want) {
+		t.Errorf("dialed %v, want %v", dialed, want)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "[2001:db8::10]:443: reachable") {
+		t.Errorf("missing reachable address in output:\n%s", out)
+	}
+	if !strings.Contains(out, "[2001:db8::20]:443: unreachable: connection refused") {
+		t.Errorf("missing unreachable address in output:\n%s", out)
+	}
+}
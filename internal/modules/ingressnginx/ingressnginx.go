@@ -0,0 +1,455 @@
+package ingressnginx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// namespace is where the upstream manifest installs the controller; its
+	// admission webhook certificate names the Service in this namespace, so
+	// it cannot be moved
+	namespace = "ingress-nginx"
+
+	controllerName  = "ingress-nginx-controller"
+	defaultVersion  = "v1.11.2"
+	defaultProvider = "baremetal"
+	manifestURL     = "https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-%s/deploy/static/provider/%s/deploy.yaml"
+
+	// defaultClassAnnotation makes Ingresses without ingressClassName, such
+	// as those of the ingresses[] entries, use the class
+	defaultClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+
+	readyTimeout = 5 * time.Minute
+)
+
+type IngressNginxModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *IngressNginxModule {
+	return &IngressNginxModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *IngressNginxModule) Name() string {
+	return "ingress-nginx"
+}
+
+// DeploymentName returns the controller Deployment, or nothing when the
+// controller is installed by other means
+func (m *IngressNginxModule) DeploymentName() string {
+	if !m.install() {
+		return ""
+	}
+	return controllerName
+}
+
+// AppLabel returns the app label added to the controller pods
+func (m *IngressNginxModule) AppLabel() string {
+	return controllerName
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	Install      string `yaml:"install" default:"true" doc:"\"false\" to only verify a controller installed by other means, e.g. microk8s enable ingress"`
+	Version      string `yaml:"version" default:"v1.11.2" doc:"ingress-nginx controller release installed from GitHub"`
+	Provider     string `yaml:"provider" default:"baremetal" doc:"Upstream manifest variant: baremetal (NodePort Service) or cloud (LoadBalancer Service)"`
+	DefaultClass string `yaml:"default_class" default:"true" doc:"\"false\" to not make the nginx IngressClass the cluster default"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *IngressNginxModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *IngressNginxModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: ingress-nginx\n\n")
+	m.log.Info("Description:\n  Installs the ingress-nginx controller into the ingress-nginx namespace for clusters without one,\n  or verifies the existing controller (e.g. the microk8s ingress addon) with install: \"false\".\n  The nginx IngressClass becomes the cluster default, so ingresses[] entries are served without\n  naming a class. status lists the controller and every Ingress route in the cluster.\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  install         \"false\" to only verify an existing controller (default: true)\n  version         Controller release to install (default: %s)\n  provider        baremetal (NodePort Service) or cloud (LoadBalancer Service) (default: %s)\n  default_class   \"false\" to keep the nginx IngressClass from becoming the default (default: true)\n\n", defaultVersion, defaultProvider)
+	m.log.Info("Subcommands:\n  generate   Write the controller manifest to configs/ingress-nginx/\n  apply      Install the controller and wait until it is ready, or verify the existing one\n  clean      Uninstall the controller\n  status     Print the controller, IngressClasses and all Ingress routes\n  doc        Show this documentation\n")
+	return nil
+}
+
+func (m *IngressNginxModule) install() bool {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "install", "true") != "false"
+}
+
+func (m *IngressNginxModule) version() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "version", defaultVersion)
+}
+
+// manifestURL returns the upstream manifest of the configured release and
+// provider
+func (m *IngressNginxModule) manifestURL() (string, error) {
+	if m.ModuleConfig.Namespace != "" && m.ModuleConfig.Namespace != namespace {
+		return "", fmt.Errorf("ingress-nginx must use namespace %s, where the upstream manifest installs it, not %s", namespace, m.ModuleConfig.Namespace)
+	}
+	provider := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "provider", defaultProvider)
+	if provider != "baremetal" && provider != "cloud" {
+		return "", fmt.Errorf("invalid provider %q: must be baremetal or cloud", provider)
+	}
+	return fmt.Sprintf(manifestURL, m.version(), provider), nil
+}
+
+// prepare adapts the upstream manifest: the controller pods get the app
+// label the tool's status and apply --all look for, the IngressClass is made
+// the default unless default_class is "false", and the controller Service
+// gets general.ipFamilyPolicy so it can accept IPv6 traffic
+func (m *IngressNginxModule) prepare(objects []*unstructured.Unstructured) error {
+	defaultClass := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "default_class", "true") != "false"
+	foundController := false
+	for _, object := range objects {
+		switch {
+		case object.GetKind() == "Deployment" && object.GetName() == controllerName:
+			labels, _, _ := unstructured.NestedStringMap(object.Object, "spec", "template", "metadata", "labels")
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels["app"] = controllerName
+			if err := unstructured.SetNestedStringMap(object.Object, labels, "spec", "template", "metadata", "labels"); err != nil {
+				return fmt.Errorf("failed to label controller pods: %w", err)
+			}
+			foundController = true
+		case object.GetKind() == "Service" && object.GetName() == controllerName && m.GeneralConfig.IPFamilyPolicy != "":
+			if err := unstructured.SetNestedField(object.Object, m.GeneralConfig.IPFamilyPolicy, "spec", "ipFamilyPolicy"); err != nil {
+				return fmt.Errorf("failed to set the controller Service IP family policy: %w", err)
+			}
+		case object.GetKind() == "IngressClass" && defaultClass:
+			annotations := object.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[defaultClassAnnotation] = "true"
+			object.SetAnnotations(annotations)
+		}
+	}
+	if !foundController {
+		return fmt.Errorf("manifest has no Deployment %s", controllerName)
+	}
+	return nil
+}
+
+// manifestObjects downloads, decodes and adapts the controller manifest
+func (m *IngressNginxModule) manifestObjects(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	url, err := m.manifestURL()
+	if err != nil {
+		return nil, err
+	}
+	m.log.Progress("Downloading ingress-nginx %s manifest...\n", m.version())
+	data, err := k8s.FetchManifest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := k8s.DecodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.prepare(objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (m *IngressNginxModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "ingress-nginx")
+
+	m.log.Info("Generating ingress-nginx Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	if !m.install() {
+		m.log.Info("install is \"false\": the controller is managed outside personal-server, nothing to generate\n")
+		return nil
+	}
+
+	objects, err := m.manifestObjects(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	var documents []string
+	for _, object := range objects {
+		jsonBytes, err := json.Marshal(object.Object)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s %s to JSON: %w", object.GetKind(), object.GetName(), err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s %s to YAML: %w", object.GetKind(), object.GetName(), err)
+		}
+		documents = append(documents, yamlContent)
+	}
+	filename := filepath.Join(outputDir, "deploy.yaml")
+	if err := os.WriteFile(filename, []byte(strings.Join(documents, "---\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest to file: %w", err)
+	}
+	m.log.Success("Generated: %s\n", filename)
+
+	m.log.Info("\nCompleted: ingress-nginx %s manifest generated successfully (%d objects)\n", m.version(), len(objects))
+	return nil
+}
+
+func (m *IngressNginxModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying ingress-nginx Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", namespace)
+
+	if !m.install() {
+		return m.verifyWithClient(ctx, clientset)
+	}
+
+	dyn, err := m.GeneralConfig.Clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	mapper, err := m.GeneralConfig.Clients.RESTMapper()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	objects, err := m.manifestObjects(ctx)
+	if err != nil {
+		return err
+	}
+	return m.applyWithClient(ctx, clientset, dyn, mapper, objects, 5*time.Second)
+}
+
+func (m *IngressNginxModule) applyWithClient(ctx context.Context, client k8s.KubernetesClient, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured, interval time.Duration) error {
+	m.log.Progress("Applying ingress-nginx %s (%d objects)\n", m.version(), len(objects))
+	if err := k8s.ApplyObjects(ctx, dyn, mapper, objects, nil); err != nil {
+		return err
+	}
+	m.log.Success("Applied ingress-nginx %s\n", m.version())
+
+	waitCtx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	m.log.Progress("Waiting for Deployment: %s\n", controllerName)
+	if err := k8s.WaitForDeploymentReady(waitCtx, client, namespace, controllerName, interval); err != nil {
+		return err
+	}
+	m.log.Success("Deployment ready: %s\n", controllerName)
+
+	m.log.Info("\nCompleted: ingress-nginx configurations applied successfully\n")
+	return nil
+}
+
+// verifyWithClient checks that some controller serves Ingresses when the
+// module does not install one
+func (m *IngressNginxModule) verifyWithClient(ctx context.Context, client k8s.KubernetesClient) error {
+	m.log.Info("install is \"false\": verifying the existing ingress controller...\n\n")
+	classes, err := client.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list IngressClasses: %w", err)
+	}
+	if len(classes.Items) == 0 {
+		return fmt.Errorf("no IngressClass found: install a controller (e.g. microk8s enable ingress) or set install: \"true\"")
+	}
+	for _, class := range classes.Items {
+		m.log.Success("Found IngressClass: %s (controller %s)\n", class.Name, class.Spec.Controller)
+	}
+	m.log.Info("\nCompleted: ingress controller verified\n")
+	return nil
+}
+
+func (m *IngressNginxModule) Clean(ctx context.Context) error {
+	m.log.Info("Cleaning ingress-nginx Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", namespace)
+
+	if !m.install() {
+		m.log.Info("install is \"false\": the controller is managed outside personal-server, nothing to clean\n")
+		return nil
+	}
+
+	dyn, err := m.GeneralConfig.Clients.Dynamic()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	mapper, err := m.GeneralConfig.Clients.RESTMapper()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	objects, err := m.manifestObjects(ctx)
+	if err != nil {
+		return err
+	}
+	return m.cleanWithClient(ctx, dyn, mapper, objects)
+}
+
+func (m *IngressNginxModule) cleanWithClient(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured) error {
+	m.log.Info("🗑️  Processing ingress-nginx %s (%d objects)\n", m.version(), len(objects))
+	deleted, err := k8s.DeleteObjects(ctx, dyn, mapper, objects)
+	if err != nil {
+		m.log.Error("Failed to delete ingress-nginx: %v\n", err)
+	} else {
+		m.log.Success("Deleted ingress-nginx (%d objects)\n", deleted)
+	}
+
+	m.log.Info("\nCompleted: %d ingress-nginx resources deleted successfully\n", deleted)
+	if deleted > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+		m.log.Println("Ingresses stay in place but are not served until a controller is installed again.")
+	}
+	return nil
+}
+
+func (m *IngressNginxModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.statusWithClient(ctx, clientset)
+}
+
+func (m *IngressNginxModule) statusWithClient(ctx context.Context, client k8s.KubernetesClient) error {
+	// Check the controller
+	if m.install() {
+		m.log.Info("Checking ingress-nginx resources in namespace '%s'...\n\n", namespace)
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, controllerName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("Deployment '%s' not found\n\n", controllerName)
+			} else {
+				m.log.Error("Error getting Deployment: %v\n\n", err)
+			}
+		} else {
+			m.log.Info("CONTROLLER:\n")
+			m.log.Info("  Deployment:      %s\n", deployment.Name)
+			m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+			if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+				m.log.Info("  Image:           %s\n", containers[0].Image)
+			}
+			m.log.Info("  Age:             %s\n", k8s.FormatAge(time.Since(deployment.CreationTimestamp.Time).Round(time.Second)))
+			m.log.Println()
+		}
+
+		service, err := client.CoreV1().Services(namespace).Get(ctx, controllerName, metav1.GetOptions{})
+		if err == nil {
+			var ports []string
+			for _, port := range service.Spec.Ports {
+				if port.NodePort != 0 {
+					ports = append(ports, fmt.Sprintf("%d:%d", port.Port, port.NodePort))
+				} else {
+					ports = append(ports, fmt.Sprintf("%d", port.Port))
+				}
+			}
+			m.log.Info("SERVICE:\n")
+			m.log.Info("  Type:            %s\n", service.Spec.Type)
+			m.log.Info("  Ports:           %s\n", strings.Join(ports, ", "))
+			for _, lb := range service.Status.LoadBalancer.Ingress {
+				if lb.IP != "" {
+					m.log.Info("  External IP:     %s\n", lb.IP)
+				}
+				if lb.Hostname != "" {
+					m.log.Info("  External Host:   %s\n", lb.Hostname)
+				}
+			}
+			m.log.Println()
+		}
+	}
+
+	// Check IngressClasses
+	classes, err := client.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list IngressClasses: %w", err)
+	}
+	if len(classes.Items) == 0 {
+		m.log.Error("No IngressClass found: Ingresses are not served\n\n")
+	} else {
+		m.log.Info("INGRESS CLASSES:\n")
+		m.log.Info("%-20s %-35s %-8s\n", "NAME", "CONTROLLER", "DEFAULT")
+		for _, class := range classes.Items {
+			isDefault := "no"
+			if class.Annotations[defaultClassAnnotation] == "true" {
+				isDefault = "yes"
+			}
+			m.log.Info("%-20s %-35s %-8s\n", class.Name, class.Spec.Controller, isDefault)
+		}
+		m.log.Println()
+	}
+
+	// List the routes of all Ingresses
+	ingresses, err := client.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list Ingresses: %w", err)
+	}
+	if len(ingresses.Items) == 0 {
+		m.log.Println("No Ingresses found")
+		return nil
+	}
+
+	type route struct {
+		namespace, ingress, url, backend, tls string
+	}
+	var routes []route
+	for _, ingress := range ingresses.Items {
+		tlsHosts := map[string]bool{}
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				tlsHosts[host] = true
+			}
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			tls := "no"
+			if tlsHosts[rule.Host] {
+				tls = "yes"
+			}
+			for _, path := range rule.HTTP.Paths {
+				backend := "-"
+				if svc := path.Backend.Service; svc != nil {
+					port := svc.Port.Name
+					if port == "" {
+						port = fmt.Sprintf("%d", svc.Port.Number)
+					}
+					backend = fmt.Sprintf("%s:%s", svc.Name, port)
+				}
+				routes = append(routes, route{ingress.Namespace, ingress.Name, host + path.Path, backend, tls})
+			}
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].url != routes[j].url {
+			return routes[i].url < routes[j].url
+		}
+		return routes[i].namespace < routes[j].namespace
+	})
+
+	m.log.Info("ROUTES:\n")
+	m.log.Info("%-40s %-25s %-5s %-15s %-20s\n", "HOST/PATH", "BACKEND", "TLS", "NAMESPACE", "INGRESS")
+	for _, r := range routes {
+		m.log.Info("%-40s %-25s %-5s %-15s %-20s\n", r.url, r.backend, r.tls, r.namespace, r.ingress)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package ingressnginx
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: ingress-nginx
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ingress-nginx-controller
+  namespace: ingress-nginx
+spec:
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: ingress-nginx
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: ingress-nginx-admission-create
+  namespace: ingress-nginx
+---
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: nginx
+spec:
+  controller: k8s.io/ingress-nginx
+`
+
+var (
+	namespaceGVR    = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	deploymentGVR   = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	jobGVR          = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	ingressClassGVR = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingressclasses"}
+)
+
+func newModule(secrets map[string]string) *IngressNginxModule {
+	return &IngressNginxModule{
+		ModuleConfig: config.Module{Name: "ingress-nginx", Namespace: namespace, Secrets: secrets},
+		log:          logger.NewNopLogger(),
+	}
+}
+
+func decodeTestManifest(t *testing.T) []*unstructured.Unstructured {
+	objects, err := k8s.DecodeManifest([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("DecodeManifest() error = %v", err)
+	}
+	return objects
+}
+
+func TestIngressNginxModule_Name(t *testing.T) {
+	module := &IngressNginxModule{}
+	if module.Name() != "ingress-nginx" {
+		t.Errorf("Name() = %s, want ingress-nginx", module.Name())
+	}
+}
+
+func TestIngressNginxModule_Doc(t *testing.T) {
+	module := &IngressNginxModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestIngressNginxModule_ManifestURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		secrets   map[string]string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "defaults",
+			want: "https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-v1.11.2/deploy/static/provider/baremetal/deploy.yaml",
+		},
+		{
+			name:    "cloud provider and version",
+			secrets: map[string]string{"provider": "cloud", "version": "v1.10.0"},
+			want:    "https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-v1.10.0/deploy/static/provider/cloud/deploy.yaml",
+		},
+		{name: "unknown provider", secrets: map[string]string{"provider": "aws"}, wantErr: true},
+		{name: "wrong namespace", namespace: "infra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newModule(tt.secrets)
+			if tt.namespace != "" {
+				module.ModuleConfig.Namespace = tt.namespace
+			}
+			got, err := module.manifestURL()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("manifestURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("manifestURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngressNginxModule_Prepare(t *testing.T) {
+	for _, defaultClass := range []string{"true", "false"} {
+		t.Run("default_class "+defaultClass, func(t *testing.T) {
+			objects := decodeTestManifest(t)
+			if err := newModule(map[string]string{"default_class": defaultClass}).prepare(objects); err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
+
+			labels, _, _ := unstructured.NestedStringMap(objects[1].Object, "spec", "template", "metadata", "labels")
+			if labels["app"] != controllerName || labels["app.kubernetes.io/name"] != "ingress-nginx" {
+				t.Errorf("controller pod labels = %v, want app=%s added to the upstream labels", labels, controllerName)
+			}
+			if got := objects[3].GetAnnotations()[defaultClassAnnotation]; (got == "true") != (defaultClass == "true") {
+				t.Errorf("IngressClass %s = %q with default_class %s", defaultClassAnnotation, got, defaultClass)
+			}
+		})
+	}
+
+	if err := newModule(nil).prepare(decodeTestManifest(t)[:1]); err == nil {
+		t.Error("prepare() of a manifest without the controller succeeded, want error")
+	}
+}
+
+func TestIngressNginxModule_PrepareIPFamilyPolicy(t *testing.T) {
+	service := &unstructured.Unstructured{}
+	service.SetAPIVersion("v1")
+	service.SetKind("Service")
+	service.SetName(controllerName)
+	objects := append(decodeTestManifest(t), service)
+
+	module := newModule(nil)
+	module.GeneralConfig.IPFamilyPolicy = "PreferDualStack"
+	if err := module.prepare(objects); err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	if got, _, _ := unstructured.NestedString(service.Object, "spec", "ipFamilyPolicy"); got != "PreferDualStack" {
+		t.Errorf("controller Service ipFamilyPolicy = %q, want PreferDualStack", got)
+	}
+}
+
+func TestApplyAndClean(t *testing.T) {
+	ctx := context.Background()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "IngressClass"}, meta.RESTScopeRoot)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		namespaceGVR:    "NamespaceList",
+		deploymentGVR:   "DeploymentList",
+		jobGVR:          "JobList",
+		ingressClassGVR: "IngressClassList",
+	})
+	dyn.PrependReactor("update", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		t.Error("existing Job was updated, but its pod template is immutable")
+		return true, nil, nil
+	})
+
+	module := newModule(nil)
+	objects := decodeTestManifest(t)
+	if err := module.prepare(objects); err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := module.applyWithClient(ctx, fake.NewSimpleClientset(), dyn, mapper, objects, time.Millisecond); err != nil {
+			t.Fatalf("applyWithClient() #%d error = %v", i+1, err)
+		}
+	}
+	class, err := dyn.Resource(ingressClassGVR).Get(ctx, "nginx", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("IngressClass not created: %v", err)
+	}
+	if class.GetAnnotations()[defaultClassAnnotation] != "true" {
+		t.Error("IngressClass was not made the default")
+	}
+
+	if err := module.cleanWithClient(ctx, dyn, mapper, objects); err != nil {
+		t.Fatalf("cleanWithClient() error = %v", err)
+	}
+	if _, err := dyn.Resource(deploymentGVR).Namespace(namespace).Get(ctx, controllerName, metav1.GetOptions{}); err == nil {
+		t.Error("controller Deployment still exists after clean")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	module := newModule(map[string]string{"install": "false"})
+	if module.DeploymentName() != "" {
+		t.Errorf("DeploymentName() = %s with install false, want none", module.DeploymentName())
+	}
+	if err := module.verifyWithClient(context.Background(), fake.NewSimpleClientset()); err == nil {
+		t.Error("verifyWithClient() without an IngressClass succeeded, want error")
+	}
+
+	client := fake.NewSimpleClientset(&networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "public"},
+		Spec:       networkingv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"},
+	})
+	if err := module.verifyWithClient(context.Background(), client); err != nil {
+		t.Errorf("verifyWithClient() error = %v", err)
+	}
+}
+
+func TestStatusRoutes(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	client := fake.NewSimpleClientset(&networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-ingress", Namespace: "infra"},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"gitea.example.com"}}},
+			Rules: []networkingv1.IngressRule{{
+				Host: "gitea.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: "gitea",
+							Port: networkingv1.ServiceBackendPort{Number: 3000},
+						}},
+					}},
+				}},
+			}},
+		},
+	})
+
+	var out bytes.Buffer
+	module := newModule(map[string]string{"install": "false"})
+	module.log = logger.NewStdLogger(&out)
+	if err := module.statusWithClient(context.Background(), client); err != nil {
+		t.Fatalf("statusWithClient() error = %v", err)
+	}
+	for _, want := range []string{"No IngressClass found", "gitea.example.com/", "gitea:3000", "yes", "web-ingress"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("status output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package jupyter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// backupCommand returns the command writing a gzipped tar of the workspace
+// to stdout, limited to the notebooks unless backup_scope is workspace.
+// Checkpoints are left out either way; JupyterLab recreates them.
+func (m *JupyterModule) backupCommand() ([]string, error) {
+	switch scope := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "backup_scope", "notebooks"); scope {
+	case "notebooks":
+		script := fmt.Sprintf(`cd %s && find . -name '*.ipynb' -not -path '*/.ipynb_checkpoints/*' -print0 | tar czf - --null -T -`, workspacePath)
+		return []string{"sh", "-c", script}, nil
+	case "workspace":
+		return []string{"tar", "czf", "-", "--exclude=.ipynb_checkpoints", "-C", workspacePath, "."}, nil
+	default:
+		return nil, fmt.Errorf("invalid backup_scope %q: use notebooks or workspace", scope)
+	}
+}
+
+// findPod returns the name of the jupyter pod exec commands run in
+func (m *JupyterModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=jupyter",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=jupyter")
+	}
+	return pods.Items[0].Name, nil
+}
+
+func (m *JupyterModule) Backup(ctx context.Context, destDir string) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
+
+func (m *JupyterModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
+	command, err := m.backupCommand()
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	var backupDir string
+	if destDir != "" {
+		backupDir = filepath.Join(destDir, "jupyter")
+	} else {
+		backupDir = filepath.Join("backups", fmt.Sprintf("jupyter_backup_%s", timestamp))
+	}
+
+	m.log.Info("🔄 Starting JupyterLab backup...\n")
+	m.log.Info("Backup directory: %s\n", backupDir)
+
+	// Create backup directory
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	// 1. Archive the workspace
+	m.log.Info("💾 Backing up notebooks...\n")
+
+	workspaceBackupFile := filepath.Join(backupDir, fmt.Sprintf("jupyter_workspace_%s.tar.gz", timestamp))
+
+	outFile, err := os.Create(workspaceBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace backup file: %w", err)
+	}
+	defer outFile.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   command,
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to archive notebooks: %w", err)
+	}
+
+	fileInfo, err := outFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat workspace backup file: %w", err)
+	}
+	m.log.Success("Notebooks archived (%d bytes)\n", fileInfo.Size())
+
+	// 2. Metadata
+	m.log.Info("📋 Writing metadata...\n")
+	metadataFile := filepath.Join(backupDir, "backup_info.txt")
+	metadata := fmt.Sprintf(`JupyterLab Backup Information
+=============================
+Backup Date: %s
+Backup Directory: %s
+Namespace: %s
+Deployment: jupyter
+Pod: %s
+Scope: %s
+
+Workspace Archive:
+%s
+
+Restore Command:
+personal-server jupyter restore %s
+`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "backup_scope", "notebooks"), filepath.Base(workspaceBackupFile), timestamp)
+
+	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	m.log.Success("Metadata written\n")
+
+	m.log.Success("🎉 Backup complete!\n")
+	m.log.Info("💡 To restore: personal-server jupyter restore %s\n", timestamp)
+
+	return nil
+}
+
+func (m *JupyterModule) Restore(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: personal-server jupyter restore [TIMESTAMP|latest]")
+	}
+
+	timestamp := args[0]
+	backupDir := "backups"
+
+	// Resolve latest
+	if timestamp == "latest" {
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			return fmt.Errorf("failed to read backup directory: %w", err)
+		}
+
+		var latestTime time.Time
+		var latestDir string
+
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "jupyter_backup_") {
+				tsStr := strings.TrimPrefix(entry.Name(), "jupyter_backup_")
+				ts, err := time.Parse("20060102_150405", tsStr)
+				if err == nil {
+					if ts.After(latestTime) {
+						latestTime = ts
+						latestDir = entry.Name()
+					}
+				}
+			}
+		}
+
+		if latestDir == "" {
+			return fmt.Errorf("no backups found")
+		}
+		timestamp = strings.TrimPrefix(latestDir, "jupyter_backup_")
+		m.log.Info("Using latest backup: %s\n", timestamp)
+	}
+
+	targetBackupDir := filepath.Join(backupDir, fmt.Sprintf("jupyter_backup_%s", timestamp))
+	if _, err := os.Stat(targetBackupDir); os.IsNotExist(err) {
+		return fmt.Errorf("backup not found: %s", targetBackupDir)
+	}
+
+	workspaceBackupFile := filepath.Join(targetBackupDir, fmt.Sprintf("jupyter_workspace_%s.tar.gz", timestamp))
+	if _, err := os.Stat(workspaceBackupFile); os.IsNotExist(err) {
+		return fmt.Errorf("workspace archive missing: %s", workspaceBackupFile)
+	}
+
+	m.log.Info("🔄 Starting JupyterLab restore (timestamp: %s)...\n", timestamp)
+	m.log.Info("💾 Notebooks will be restored from %s\n", workspaceBackupFile)
+
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if err := m.restoreWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), workspaceBackupFile); err != nil {
+		return err
+	}
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreWithClient extracts a workspace archive written by Backup into the
+// jupyter pod, overwriting notebooks of the same name. Other files in the
+// workspace are kept.
+func (m *JupyterModule) restoreWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, workspaceBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	inFile, err := os.Open(workspaceBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to open workspace backup file: %w", err)
+	}
+	defer inFile.Close()
+
+	m.log.Info("💾 Restoring notebooks...\n")
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", workspacePath},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to restore notebooks: %w", err)
+	}
+	m.log.Success("Notebooks restored\n")
+	return nil
+}
@@ -0,0 +1,190 @@
+package jupyter
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestModule(secrets map[string]string) *JupyterModule {
+	return &JupyterModule{
+		ModuleConfig: config.Module{Name: "jupyter", Namespace: "infra", Secrets: secrets},
+		log:          logger.NewNopLogger(),
+	}
+}
+
+var testPod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "jupyter-5f8b", Namespace: "infra", Labels: map[string]string{"app": "jupyter"}}}
+
+func TestJupyterModule_Name(t *testing.T) {
+	module := &JupyterModule{}
+	if module.Name() != "jupyter" {
+		t.Errorf("Name() = %s, want jupyter", module.Name())
+	}
+}
+
+func TestJupyterModule_Doc(t *testing.T) {
+	module := &JupyterModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestJupyterModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name             string
+		secrets          map[string]string
+		storage          string
+		wantGPUs         map[string]string
+		wantRuntimeClass string
+		wantErr          bool
+	}{
+		{name: "no GPU", secrets: map[string]string{"token": "t"}},
+		{name: "GPU count", secrets: map[string]string{"token": "t", "gpu": "2"}, wantGPUs: map[string]string{"nvidia.com/gpu": "2"}},
+		{name: "other GPU vendor", secrets: map[string]string{"token": "t", "gpu": "1", "gpu_resource": "amd.com/gpu"}, wantGPUs: map[string]string{"amd.com/gpu": "1"}},
+		{name: "runtime class", secrets: map[string]string{"token": "t", "gpu": "1", "runtime_class": "nvidia"}, wantGPUs: map[string]string{"nvidia.com/gpu": "1"}, wantRuntimeClass: "nvidia"},
+		{name: "missing token", secrets: map[string]string{}, wantErr: true},
+		{name: "bad gpu", secrets: map[string]string{"token": "t", "gpu": "one"}, wantErr: true},
+		{name: "bad gpu_resource", secrets: map[string]string{"token": "t", "gpu": "1", "gpu_resource": "gpu"}, wantErr: true},
+		{name: "bad backup_scope", secrets: map[string]string{"token": "t", "backup_scope": "everything"}, wantErr: true},
+		{name: "bad storage size", secrets: map[string]string{"token": "t"}, storage: "big", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets)
+			module.ModuleConfig.Storage = tt.storage
+			_, _, _, deployment, err := module.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			limits := deployment.Spec.Template.Spec.Containers[0].Resources.Limits
+			// Besides the GPUs, only the default memory limit
+			if len(limits) != len(tt.wantGPUs)+1 || limits.Memory().String() != "2Gi" {
+				t.Errorf("limits = %v, want %v and memory 2Gi", limits, tt.wantGPUs)
+			}
+			for name, want := range tt.wantGPUs {
+				if got := limits[corev1.ResourceName(name)]; got.String() != want {
+					t.Errorf("limits[%s] = %s, want %s", name, got.String(), want)
+				}
+			}
+			var runtimeClass string
+			if deployment.Spec.Template.Spec.RuntimeClassName != nil {
+				runtimeClass = *deployment.Spec.Template.Spec.RuntimeClassName
+			}
+			if runtimeClass != tt.wantRuntimeClass {
+				t.Errorf("runtimeClassName = %q, want %q", runtimeClass, tt.wantRuntimeClass)
+			}
+		})
+	}
+}
+
+func TestJupyterModule_BackupWithClient(t *testing.T) {
+	module := newTestModule(map[string]string{"token": "t", "backup_scope": "workspace"})
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{
+			Namespace: "infra",
+			Pod:       "jupyter-5f8b",
+			Command:   []string{"tar", "czf", "-", "--exclude=.ipynb_checkpoints", "-C", "/home/jovyan/work", "."},
+			Stdout:    "archive",
+		},
+	)
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(testPod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, err := filepath.Glob(filepath.Join(destDir, "jupyter", "jupyter_workspace_*.tar.gz"))
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("archives = %v, err = %v, want one", archives, err)
+	}
+	if content, _ := os.ReadFile(archives[0]); string(content) != "archive" {
+		t.Errorf("archive content = %q, want %q", content, "archive")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "jupyter", "backup_info.txt")); err != nil {
+		t.Errorf("backup_info.txt not written: %v", err)
+	}
+}
+
+func TestJupyterModule_RestoreWithClient(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "jupyter_workspace_20240101_000000.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "jupyter-5f8b", Command: []string{"tar", "xzf", "-", "-C", "/home/jovyan/work"}, Stdin: "archive"},
+	)
+	module := newTestModule(map[string]string{"token": "t"})
+	if err := module.restoreWithClient(context.Background(), fake.NewSimpleClientset(testPod), executor, archive); err != nil {
+		t.Fatalf("restoreWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	if err := module.Restore(context.Background(), nil); err == nil {
+		t.Error("Restore() without a timestamp succeeded, want usage error")
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := newTestModule(map[string]string{"token": "s3cr3t", "gpu": "1", "runtime_class": "nvidia"})
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/jupyter/secret.yaml", expectedSecretYAML},
+		{"service", "configs/jupyter/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/jupyter/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
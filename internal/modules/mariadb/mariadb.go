@@ -0,0 +1,1037 @@
+package mariadb
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage       = "mariadb:11.4"
+	defaultStorageSize = "10Gi"
+	containerPort      = 3306
+)
+
+// shutdownGracePeriod leaves room for InnoDB to flush its buffer pool; mariadbd
+// shuts down cleanly on SIGTERM, so no pre-stop hook is needed
+const shutdownGracePeriod = 60
+
+// clientAuth passes the root password to the mariadb client tools through
+// the environment, keeping it out of their command lines
+const clientAuth = `MYSQL_PWD="$MARIADB_ROOT_PASSWORD"`
+
+// pingCommand checks that the server accepts connections
+const pingCommand = clientAuth + ` mariadb-admin -u root ping`
+
+// identifierPattern matches the database and user names add-db and remove-db
+// accept, which are then safe to quote with backticks
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "1Gi"}
+
+type MariaDBModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *MariaDBModule {
+	return &MariaDBModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *MariaDBModule) Name() string {
+	return "mariadb"
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	RootPassword string `yaml:"root_password" required:"true" generate:"true" doc:"Password of the MariaDB root user"`
+	Host         string `yaml:"host" doc:"host:port add-db puts in DSNs, e.g. an external server (default: mariadb.<namespace>.svc.cluster.local:3306)"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// Endpoint returns the host:port apps use to reach the database
+func (m *MariaDBModule) Endpoint() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "host", fmt.Sprintf("mariadb.%s.svc.cluster.local:%d", m.ModuleConfig.Namespace, containerPort))
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *MariaDBModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *MariaDBModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: mariadb\n\n")
+	m.log.Info("Description:\n  Deploys MariaDB — a MySQL-compatible relational database.\n  Manages a Secret, PersistentVolumeClaim, Service, and Deployment.\n  For apps that only support MySQL; they connect with their MySQL driver.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  root_password   Password of the MariaDB root user\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  host            host:port add-db puts in DSNs (default: mariadb.<namespace>.svc.cluster.local:3306)\n  image           Container image (modules[].image, default: %s)\n  storage         Size of the data volume (modules[].storage, default: %s)\n\n", defaultImage, defaultStorageSize)
+	m.log.Info("Subcommands:\n  generate    Write Kubernetes YAML to configs/mariadb/\n  apply       Create/update resources in the cluster\n  clean       Delete all MariaDB resources from the cluster\n  status      Print Deployment and Pod status\n  doc         Show this documentation\n  backup      Dump all databases using mariadb-dump (mysqldump) and archive to the destination directory\n  restore     Restore databases from a mariadb-dump backup archive\n  add-db      Create a new database and user and print its DSN (args: <dbname> <username> [password | --generate]; prompts when the password is omitted;\n              --secret-namespace <ns> [--secret-name <name>] also writes host/port/database/username/password/dsn to a Secret)\n  remove-db   Drop a database and its user (args: <dbname> <username>)\n")
+	return nil
+}
+
+func (m *MariaDBModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "mariadb")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating MariaDB Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+
+	m.log.Info("\nCompleted: 4/4 MariaDB configurations generated successfully\n")
+	return nil
+}
+
+func (m *MariaDBModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying MariaDB Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'", secret.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", pvc.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", service.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	m.log.Progress("Applying Secret: %s\n", secret.Name)
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create Secret: %w", err)
+	}
+	m.log.Success("Created Secret: %s\n", secret.Name)
+
+	m.log.Progress("Applying PVC: %s\n", pvc.Name)
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PVC: %w", err)
+	}
+	m.log.Success("Created PVC: %s\n", pvc.Name)
+
+	m.log.Progress("Applying Service: %s\n", service.Name)
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create Service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", service.Name)
+
+	m.log.Progress("Applying Deployment: %s\n", deployment.Name)
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create Deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", deployment.Name)
+
+	m.log.Info("\nCompleted: 4/4 resources applied successfully\n")
+	return nil
+}
+
+// prepare creates and returns the Kubernetes objects for the mariadb module
+func (m *MariaDBModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	rootPassword, exists := m.ModuleConfig.Secrets["root_password"]
+	if !exists {
+		return nil, nil, nil, nil, fmt.Errorf("root_password not found in configuration")
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	labels := map[string]string{
+		"app": "mariadb",
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mariadb-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"root_password": []byte(rootPassword),
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mariadb-data-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mariadb",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "mysql",
+					Port:       containerPort,
+					TargetPort: intstr.FromInt(containerPort),
+				},
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mariadb",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			// two servers must never open the same data directory
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "mariadb",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "mysql",
+									ContainerPort: containerPort,
+								},
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name: "MARIADB_ROOT_PASSWORD",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{
+												Name: "mariadb-secrets",
+											},
+											Key: "root_password",
+										},
+									},
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", pingCommand},
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       10,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", clientAuth + ` mariadb -u root -e "SELECT 1"`},
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       5,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/var/lib/mysql",
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "mariadb-data-pvc",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	k8s.SetGracefulShutdown(&deployment.Spec.Template.Spec, shutdownGracePeriod, "")
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, pvc, service, deployment)
+	return secret, pvc, service, deployment, nil
+}
+
+func (m *MariaDBModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning MariaDB Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	totalResources := 4 // Deployment, Service, Secret, PVC
+
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Deleting Deployment: mariadb\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "mariadb", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment not found (already deleted or never existed)\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: mariadb\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Deleting Service: mariadb\n")
+	if err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Delete(ctx, "mariadb", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service not found (already deleted or never existed)\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: mariadb\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Deleting Secret: mariadb-secrets\n")
+	if err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Delete(ctx, "mariadb-secrets", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret not found (already deleted or never existed)\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: mariadb-secrets\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Deleting PVC: mariadb-data-pvc\n")
+	if err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Delete(ctx, "mariadb-data-pvc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PVC not found (already deleted or never existed)\n")
+		} else {
+			m.log.Error("Failed to delete PVC: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PVC: mariadb-data-pvc\n")
+		successCount++
+	}
+
+	m.log.Info("\nCompleted: %d/%d resources deleted successfully\n", successCount, totalResources)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *MariaDBModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking MariaDB resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	m.log.Println("DEPLOYMENT:")
+	deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "mariadb", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Println("  Status: Not Found")
+		} else {
+			m.log.Error("  Error: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("  Name: %s\n", deployment.Name)
+		m.log.Info("  Ready: %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date: %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available: %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Age: %s\n", k8s.FormatAge(age))
+	}
+
+	m.log.Println("\nSERVICE:")
+	service, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "mariadb", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Println("  Status: Not Found")
+		} else {
+			m.log.Error("  Error: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("  Name: %s\n", service.Name)
+		m.log.Info("  Type: %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP: %s\n", service.Spec.ClusterIP)
+		m.log.Print("  Ports: ")
+		for i, port := range service.Spec.Ports {
+			if i > 0 {
+				m.log.Print(", ")
+			}
+			m.log.Print("%d:%d/%s", port.Port, port.TargetPort.IntVal, port.Protocol)
+		}
+		m.log.Println()
+		m.log.Info("  Age: %s\n", k8s.FormatAge(age))
+	}
+
+	m.log.Println("\nSECRET:")
+	secret, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "mariadb-secrets", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Println("  Status: Not Found")
+		} else {
+			m.log.Error("  Error: %v\n", err)
+		}
+	} else {
+		age := time.Since(secret.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("  Name: %s\n", secret.Name)
+		m.log.Info("  Type: %s\n", secret.Type)
+		m.log.Info("  Data keys: %d\n", len(secret.Data))
+		m.log.Info("  Age: %s\n", k8s.FormatAge(age))
+	}
+
+	m.log.Println("\nPERSISTENT VOLUME CLAIM:")
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, "mariadb-data-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Println("  Status: Not Found")
+		} else {
+			m.log.Error("  Error: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("  Name: %s\n", pvc.Name)
+		m.log.Info("  Status: %s\n", pvc.Status.Phase)
+		m.log.Info("  Volume: %s\n", pvc.Spec.VolumeName)
+		m.log.Info("  Capacity: %s\n", pvc.Status.Capacity.Storage())
+		m.log.Info("  Access Modes: %v\n", pvc.Spec.AccessModes)
+		m.log.Info("  Age: %s\n", k8s.FormatAge(age))
+	}
+
+	m.log.Println("\nPODS:")
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=mariadb",
+	})
+	if err != nil {
+		m.log.Error("  Error listing pods: %v\n", err)
+	} else if len(pods.Items) == 0 {
+		m.log.Println("  No pods found")
+	} else {
+		m.log.Info("  %-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("  %-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	}
+	return nil
+}
+
+// findPod returns the name of the mariadb pod exec commands run in
+func (m *MariaDBModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=mariadb",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=mariadb")
+	}
+	return pods.Items[0].Name, nil
+}
+
+// query runs sql as root against db in the mariadb pod and returns the
+// combined output. The statements go to the client's stdin, so they need no
+// shell quoting. The client has no bind variables: identifiers must be
+// validated with identifierPattern before they are quoted into sql.
+func (m *MariaDBModule) query(ctx context.Context, executor k8s.Executor, podName, db, sql string) ([]byte, error) {
+	return k8s.ExecCombinedOutput(ctx, executor, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"bash", "-c", clientAuth + ` mariadb -u root --batch --skip-column-names "$1"`, "mariadb", db},
+		Stdin:     strings.NewReader(sql),
+	})
+}
+
+func (m *MariaDBModule) Backup(ctx context.Context, destDir string) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
+
+func (m *MariaDBModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
+	timestamp := time.Now().Format("20060102_150405")
+	var backupDir string
+	if destDir != "" {
+		backupDir = filepath.Join(destDir, "mariadb")
+	} else {
+		backupDir = filepath.Join("backups", fmt.Sprintf("mariadb_backup_%s", timestamp))
+	}
+
+	m.log.Info("🔄 Starting MariaDB backup...\n")
+	m.log.Info("Backup directory: %s\n", backupDir)
+
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	dumpFile := filepath.Join(backupDir, fmt.Sprintf("mariadb_dump_%s.sql.gz", timestamp))
+
+	m.log.Info("💾 Creating full database dump (mariadb-dump)...\n")
+	if err := m.dumpAll(ctx, executor, podName, dumpFile); err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(dumpFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat dump file: %w", err)
+	}
+	m.log.Success("Database dump created (%d bytes)\n", fileInfo.Size())
+
+	m.log.Info("📋 Writing metadata...\n")
+	metadataFile := filepath.Join(backupDir, "backup_info.txt")
+	metadata := fmt.Sprintf(`MariaDB Backup Information
+==========================
+Backup Date: %s
+Backup Directory: %s
+Namespace: %s
+Deployment: mariadb
+Pod: %s
+
+Dump File:
+%s
+
+Restore Command:
+personal-server mariadb restore %s
+`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, filepath.Base(dumpFile), timestamp)
+
+	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	m.log.Success("Metadata written\n")
+
+	m.log.Success("🎉 Backup complete!\n")
+	m.log.Info("💡 To restore: personal-server mariadb restore %s\n", timestamp)
+
+	return nil
+}
+
+// dumpCommand dumps every database, with stored routines and events, from
+// one consistent InnoDB snapshot without locking tables
+const dumpCommand = clientAuth + ` mariadb-dump -u root --all-databases --single-transaction --routines --events`
+
+// dumpAll streams mariadb-dump from the pod into a gzip-compressed dumpFile
+func (m *MariaDBModule) dumpAll(ctx context.Context, executor k8s.Executor, podName, dumpFile string) error {
+	outFile, err := os.Create(dumpFile)
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %w", err)
+	}
+	defer outFile.Close()
+
+	gz := gzip.NewWriter(outFile)
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"bash", "-c", dumpCommand},
+		Stdout:    gz,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to create dump: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress dump: %w", err)
+	}
+	return outFile.Close()
+}
+
+func (m *MariaDBModule) Restore(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: personal-server mariadb restore [TIMESTAMP|latest]")
+	}
+
+	timestamp := args[0]
+	backupDir := "backups"
+
+	if timestamp == "latest" {
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			return fmt.Errorf("failed to read backup directory: %w", err)
+		}
+
+		var latestTime time.Time
+		var latestDir string
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "mariadb_backup_") {
+				ts, err := time.Parse("20060102_150405", strings.TrimPrefix(entry.Name(), "mariadb_backup_"))
+				if err == nil && ts.After(latestTime) {
+					latestTime = ts
+					latestDir = entry.Name()
+				}
+			}
+		}
+
+		if latestDir == "" {
+			return fmt.Errorf("no backups found")
+		}
+		timestamp = strings.TrimPrefix(latestDir, "mariadb_backup_")
+		m.log.Info("Using latest backup: %s\n", timestamp)
+	}
+
+	targetBackupDir := filepath.Join(backupDir, fmt.Sprintf("mariadb_backup_%s", timestamp))
+	if _, err := os.Stat(targetBackupDir); os.IsNotExist(err) {
+		return fmt.Errorf("backup not found: %s", targetBackupDir)
+	}
+
+	dumpFile := filepath.Join(targetBackupDir, fmt.Sprintf("mariadb_dump_%s.sql.gz", timestamp))
+	if _, err := os.Stat(dumpFile); os.IsNotExist(err) {
+		return fmt.Errorf("dump file missing: %s", dumpFile)
+	}
+
+	m.log.Info("🔄 Starting MariaDB restore (timestamp: %s)...\n", timestamp)
+	m.log.Info("💾 Database will be restored from %s\n", dumpFile)
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.restoreDumpWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dumpFile)
+}
+
+// restoreDumpWithClient streams a gzip-compressed mariadb-dump file into the
+// mariadb client in the pod
+func (m *MariaDBModule) restoreDumpWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dumpFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	m.log.Info("💾 Restoring database (this may take a while)...\n")
+
+	inFile, err := os.Open(dumpFile)
+	if err != nil {
+		return fmt.Errorf("failed to open dump file: %w", err)
+	}
+	defer inFile.Close()
+
+	gz, err := gzip.NewReader(inFile)
+	if err != nil {
+		return fmt.Errorf("failed to read dump file: %w", err)
+	}
+	defer gz.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"bash", "-c", clientAuth + ` mariadb -u root`},
+		Stdin:     gz,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	m.log.Success("Database restored\n")
+	m.log.Success("🎉 Restore complete!\n")
+
+	return nil
+}
+
+// addDBOptions are the parsed arguments of mariadb add-db
+type addDBOptions struct {
+	dbName          string
+	dbUser          string
+	dbPass          string // set when passed as an argument
+	generate        bool
+	secretNamespace string // create a connection Secret here when set
+	secretName      string
+}
+
+const addDBUsage = "usage: personal-server mariadb add-db <DB_NAME> <DB_USER> [DB_PASS | --generate] [--secret-namespace NAMESPACE [--secret-name NAME]]"
+
+func parseAddDBArgs(args []string) (addDBOptions, error) {
+	var opts addDBOptions
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--generate":
+			opts.generate = true
+		case "--secret-namespace", "--secret-name":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value\n%s", args[i], addDBUsage)
+			}
+			if args[i] == "--secret-namespace" {
+				opts.secretNamespace = args[i+1]
+			} else {
+				opts.secretName = args[i+1]
+			}
+			i++
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) < 2 || len(positional) > 3 || (opts.generate && len(positional) == 3) {
+		return opts, fmt.Errorf(addDBUsage)
+	}
+	if opts.secretName != "" && opts.secretNamespace == "" {
+		return opts, fmt.Errorf("--secret-name requires --secret-namespace\n%s", addDBUsage)
+	}
+
+	opts.dbName = positional[0]
+	opts.dbUser = positional[1]
+	if len(positional) == 3 {
+		opts.dbPass = positional[2]
+	}
+
+	if err := validateNames(opts.dbName, opts.dbUser); err != nil {
+		return opts, err
+	}
+	// MariaDB limits user names to 80 characters and database names to 64
+	if len(opts.dbName) > 64 {
+		return opts, fmt.Errorf("invalid DB_NAME: at most 64 characters")
+	}
+	if len(opts.dbUser) > 80 {
+		return opts, fmt.Errorf("invalid DB_USER: at most 80 characters")
+	}
+	if opts.secretNamespace != "" && opts.secretName == "" {
+		opts.secretName = "mariadb-" + strings.ReplaceAll(strings.ToLower(opts.dbName), "_", "-")
+	}
+	return opts, nil
+}
+
+// validateNames checks the names add-db and remove-db quote into statements
+func validateNames(dbName, dbUser string) error {
+	if !identifierPattern.MatchString(dbName) {
+		return fmt.Errorf("invalid DB_NAME: must match ^[a-zA-Z0-9_]+$")
+	}
+	if !identifierPattern.MatchString(dbUser) {
+		return fmt.Errorf("invalid DB_USER: must match ^[a-zA-Z0-9_]+$")
+	}
+	return nil
+}
+
+func (m *MariaDBModule) AddDB(ctx context.Context, args []string) error {
+	opts, err := parseAddDBArgs(args)
+	if err != nil {
+		return err
+	}
+
+	dbPass := opts.dbPass
+	switch {
+	case opts.generate:
+		if dbPass, err = generatePassword(); err != nil {
+			return err
+		}
+	case dbPass != "":
+		m.log.Warn("DB_PASS given as an argument is visible in shell history and the process list; omit it to be prompted instead\n")
+	default:
+		if dbPass, err = readPassword(os.Stdin, os.Stderr, opts.dbUser); err != nil {
+			return err
+		}
+	}
+	if !opts.generate {
+		if err := validatePassword(dbPass); err != nil {
+			return err
+		}
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if err := m.addDBWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), opts.dbName, opts.dbUser, dbPass); err != nil {
+		return err
+	}
+
+	if opts.secretNamespace != "" {
+		if err := m.applyConnectionSecret(ctx, clientset, opts.secretNamespace, opts.secretName, opts.dbName, opts.dbUser, dbPass); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("DSN: %s\n", m.dsn(opts.dbName, opts.dbUser, dbPass))
+	return nil
+}
+
+// connectionSecret holds what an app needs to reach dbName, for use with
+// envFrom or secretKeyRef
+func (m *MariaDBModule) connectionSecret(namespace, name, dbName, dbUser, dbPass string) *corev1.Secret {
+	host, port, err := net.SplitHostPort(m.Endpoint())
+	if err != nil {
+		host, port = m.Endpoint(), fmt.Sprint(containerPort)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"managed-by": "personal-server",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"host":     []byte(host),
+			"port":     []byte(port),
+			"database": []byte(dbName),
+			"username": []byte(dbUser),
+			"password": []byte(dbPass),
+			"dsn":      []byte(m.dsn(dbName, dbUser, dbPass)),
+		},
+	}
+}
+
+// applyConnectionSecret creates the connection Secret or replaces its data
+func (m *MariaDBModule) applyConnectionSecret(ctx context.Context, client k8s.KubernetesClient, namespace, name, dbName, dbUser, dbPass string) error {
+	secret := m.connectionSecret(namespace, name, dbName, dbUser, dbPass)
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret)
+
+	m.log.Progress("Applying Secret: %s (namespace: %s)\n", name, namespace)
+	_, err := client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create or update connection secret %s/%s: %w", namespace, name, err)
+	}
+	m.log.Success("Applied Secret: %s in namespace %s\n", name, namespace)
+	return nil
+}
+
+// createUserSQL creates the user or, when it exists, resets its password.
+// The password is set by its mysql_native_password hash, so the plain text
+// never reaches the pod.
+func createUserSQL(dbUser, dbPass string) string {
+	hash := nativePasswordHash(dbPass)
+	return fmt.Sprintf("CREATE USER IF NOT EXISTS `%s`@'%%' IDENTIFIED BY PASSWORD '%s';\nALTER USER `%s`@'%%' IDENTIFIED BY PASSWORD '%s';\n", dbUser, hash, dbUser, hash)
+}
+
+func (m *MariaDBModule) addDBWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dbName, dbUser, dbPass string) error {
+	if err := validateNames(dbName, dbUser); err != nil {
+		return err
+	}
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	m.log.Info("Waiting for MariaDB to be ready in pod %s...\n", podName)
+	ready := false
+	for i := 0; i < 30; i++ {
+		if err := executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: m.ModuleConfig.Namespace,
+			Pod:       podName,
+			Command:   []string{"sh", "-c", pingCommand},
+		}); err == nil {
+			ready = true
+			break
+		}
+		time.Sleep(1 * time.Second)
+	}
+	if !ready {
+		return fmt.Errorf("mariadb is not ready")
+	}
+
+	m.log.Info("Creating/Altering user '%s'...\n", dbUser)
+	if out, err := m.query(ctx, executor, podName, "mysql", createUserSQL(dbUser, dbPass)); err != nil {
+		return fmt.Errorf("failed to create user: %s\nOutput: %s", err, string(out))
+	}
+
+	m.log.Info("Ensuring database '%s' exists...\n", dbName)
+	createDB := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;\n", dbName)
+	if out, err := m.query(ctx, executor, podName, "mysql", createDB); err != nil {
+		return fmt.Errorf("failed to create database: %s\nOutput: %s", err, string(out))
+	}
+
+	m.log.Info("Granting privileges on database '%s' to '%s'...\n", dbName, dbUser)
+	grant := fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.* TO `%s`@'%%';\n", dbName, dbUser)
+	if out, err := m.query(ctx, executor, podName, "mysql", grant); err != nil {
+		return fmt.Errorf("failed to grant privileges: %s\nOutput: %s", err, string(out))
+	}
+
+	m.log.Success("Database and user setup complete for %s / %s\n", dbName, dbUser)
+	return nil
+}
+
+func (m *MariaDBModule) RemoveDB(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: personal-server mariadb remove-db <DB_NAME> <DB_USER>")
+	}
+	if err := validateNames(args[0], args[1]); err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.removeDBWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), args[0], args[1])
+}
+
+func (m *MariaDBModule) removeDBWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dbName, dbUser string) error {
+	if err := validateNames(dbName, dbUser); err != nil {
+		return err
+	}
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	m.log.Info("Dropping database '%s'...\n", dbName)
+	if out, err := m.query(ctx, executor, podName, "mysql", fmt.Sprintf("DROP DATABASE IF EXISTS `%s`;\n", dbName)); err != nil {
+		return fmt.Errorf("failed to drop database: %s\nOutput: %s", err, string(out))
+	}
+
+	m.log.Info("Dropping user '%s'...\n", dbUser)
+	if out, err := m.query(ctx, executor, podName, "mysql", fmt.Sprintf("DROP USER IF EXISTS `%s`@'%%';\n", dbUser)); err != nil {
+		return fmt.Errorf("failed to drop user: %s\nOutput: %s", err, string(out))
+	}
+
+	m.log.Success("Database '%s' and user '%s' removed\n", dbName, dbUser)
+	return nil
+}
@@ -0,0 +1,132 @@
+package mariadb
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+const (
+	// minPasswordEntropy is the lowest estimated strength, in bits, add-db
+	// accepts for a supplied password; 12 random letters and digits pass
+	minPasswordEntropy = 60
+
+	generatedPasswordLength = 24
+	// passwordAlphabet leaves out symbols so generated passwords need no
+	// escaping in DSNs, shells or YAML
+	passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// generatePassword returns a random password of generatedPasswordLength
+// characters from passwordAlphabet
+func generatePassword() (string, error) {
+	size := big.NewInt(int64(len(passwordAlphabet)))
+	password := make([]byte, generatedPasswordLength)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// passwordEntropy estimates the strength of password in bits as its length
+// times log2 of the size of the character classes it draws from
+func passwordEntropy(password string) float64 {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(len([]rune(password))) * math.Log2(float64(pool))
+}
+
+// validatePassword rejects passwords that are too weak or that cannot be
+// entered on a single line
+func validatePassword(password string) error {
+	if strings.ContainsAny(password, "\r\n\x00") {
+		return fmt.Errorf("invalid DB_PASS: must not contain line breaks or NUL characters")
+	}
+	if bits := passwordEntropy(password); bits < minPasswordEntropy {
+		return fmt.Errorf("DB_PASS is too weak (about %.0f bits, need %d): use a longer password mixing upper and lower case, digits and symbols, or pass --generate", bits, minPasswordEntropy)
+	}
+	return nil
+}
+
+// readPassword prompts for the password on a terminal without echoing it, or
+// reads the first line of in when it is piped
+func readPassword(in *os.File, prompt io.Writer, dbUser string) (string, error) {
+	if term.IsTerminal(int(in.Fd())) {
+		fmt.Fprintf(prompt, "DB_PASS for %s: ", dbUser)
+		password, err := term.ReadPassword(int(in.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// dsn returns a mysql:// connection string for dbName on the module's
+// endpoint
+func (m *MariaDBModule) dsn(dbName, dbUser, dbPass string) string {
+	u := url.URL{
+		Scheme: "mysql",
+		User:   url.UserPassword(dbUser, dbPass),
+		Host:   m.Endpoint(),
+		Path:   "/" + dbName,
+	}
+	return u.String()
+}
+
+// nativePasswordHash returns the mysql_native_password hash of password, the
+// form CREATE USER ... IDENTIFIED BY PASSWORD accepts
+func nativePasswordHash(password string) string {
+	first := sha1.Sum([]byte(password))
+	second := sha1.Sum(first[:])
+	return "*" + strings.ToUpper(hex.EncodeToString(second[:]))
+}
@@ -0,0 +1,218 @@
+package mealie
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recipesDir holds the images and assets uploaded with recipes, relative to
+// dataPath. Recipes themselves live in PostgreSQL.
+const recipesDir = "recipes"
+
+// findPod returns the name of the mealie pod exec commands run in
+func (m *MealieModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=mealie",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=mealie")
+	}
+	return pods.Items[0].Name, nil
+}
+
+func (m *MealieModule) Backup(ctx context.Context, destDir string) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
+
+func (m *MealieModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
+	timestamp := time.Now().Format("20060102_150405")
+	var backupDir string
+	if destDir != "" {
+		backupDir = filepath.Join(destDir, "mealie")
+	} else {
+		backupDir = filepath.Join("backups", fmt.Sprintf("mealie_backup_%s", timestamp))
+	}
+
+	m.log.Info("🔄 Starting Mealie backup...\n")
+	m.log.Info("Backup directory: %s\n", backupDir)
+
+	// Create backup directory
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	// 1. Archive the uploaded recipe images; the recipes are in the
+	// database, which the postgres module backs up
+	m.log.Info("💾 Backing up recipe images...\n")
+
+	imagesBackupFile := filepath.Join(backupDir, fmt.Sprintf("mealie_images_%s.tar.gz", timestamp))
+
+	outFile, err := os.Create(imagesBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to create images backup file: %w", err)
+	}
+	defer outFile.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "-C", dataPath, recipesDir},
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to archive recipe images: %w", err)
+	}
+
+	fileInfo, err := outFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat images backup file: %w", err)
+	}
+	m.log.Success("Recipe images archived (%d bytes)\n", fileInfo.Size())
+
+	// 2. Metadata
+	m.log.Info("📋 Writing metadata...\n")
+	metadataFile := filepath.Join(backupDir, "backup_info.txt")
+	metadata := fmt.Sprintf(`Mealie Backup Information
+==========================
+Backup Date: %s
+Backup Directory: %s
+Namespace: %s
+Deployment: mealie
+Pod: %s
+
+Images Archive:
+%s
+
+The database is backed up by the postgres module.
+
+Restore Command:
+personal-server mealie restore %s
+`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, filepath.Base(imagesBackupFile), timestamp)
+
+	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	m.log.Success("Metadata written\n")
+
+	m.log.Success("🎉 Backup complete!\n")
+	m.log.Info("💡 To restore: personal-server mealie restore %s\n", timestamp)
+
+	return nil
+}
+
+func (m *MealieModule) Restore(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: personal-server mealie restore [TIMESTAMP|latest]")
+	}
+
+	timestamp := args[0]
+	backupDir := "backups"
+
+	// Resolve latest
+	if timestamp == "latest" {
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			return fmt.Errorf("failed to read backup directory: %w", err)
+		}
+
+		var latestTime time.Time
+		var latestDir string
+
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "mealie_backup_") {
+				tsStr := strings.TrimPrefix(entry.Name(), "mealie_backup_")
+				ts, err := time.Parse("20060102_150405", tsStr)
+				if err == nil {
+					if ts.After(latestTime) {
+						latestTime = ts
+						latestDir = entry.Name()
+					}
+				}
+			}
+		}
+
+		if latestDir == "" {
+			return fmt.Errorf("no backups found")
+		}
+		timestamp = strings.TrimPrefix(latestDir, "mealie_backup_")
+		m.log.Info("Using latest backup: %s\n", timestamp)
+	}
+
+	targetBackupDir := filepath.Join(backupDir, fmt.Sprintf("mealie_backup_%s", timestamp))
+	if _, err := os.Stat(targetBackupDir); os.IsNotExist(err) {
+		return fmt.Errorf("backup not found: %s", targetBackupDir)
+	}
+
+	imagesBackupFile := filepath.Join(targetBackupDir, fmt.Sprintf("mealie_images_%s.tar.gz", timestamp))
+	if _, err := os.Stat(imagesBackupFile); os.IsNotExist(err) {
+		return fmt.Errorf("images archive missing: %s", imagesBackupFile)
+	}
+
+	m.log.Info("🔄 Starting Mealie restore (timestamp: %s)...\n", timestamp)
+	m.log.Info("💾 Recipe images will be restored from %s\n", imagesBackupFile)
+
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if err := m.restoreWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), imagesBackupFile); err != nil {
+		return err
+	}
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreWithClient extracts a recipe image archive written by Backup into
+// the mealie pod. Images are served from disk, so no restart is needed.
+// Restore the database with the postgres module for the images to be linked
+// to their recipes.
+func (m *MealieModule) restoreWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, imagesBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	inFile, err := os.Open(imagesBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to open images backup file: %w", err)
+	}
+	defer inFile.Close()
+
+	m.log.Info("💾 Restoring recipe images...\n")
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", dataPath},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to restore recipe images: %w", err)
+	}
+	m.log.Success("Recipe images restored\n")
+	return nil
+}
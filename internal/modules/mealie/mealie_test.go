@@ -0,0 +1,306 @@
+package mealie
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestModule(secrets map[string]string) *MealieModule {
+	base := map[string]string{
+		"mealie_db_password": "db-password",
+	}
+	for k, v := range secrets {
+		base[k] = v
+	}
+	return &MealieModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig:  config.Module{Name: "mealie", Namespace: "test-namespace", Secrets: base},
+		log:           logger.NewNopLogger(),
+	}
+}
+
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestMealieModule_Name(t *testing.T) {
+	module := &MealieModule{}
+	if module.Name() != "mealie" {
+		t.Errorf("Name() = %s, want mealie", module.Name())
+	}
+}
+
+func TestMealieModule_Dependencies(t *testing.T) {
+	deps := (&MealieModule{}).Dependencies()
+	if len(deps) != 1 || deps[0] != "postgres" {
+		t.Errorf("Dependencies() = %v, want [postgres]", deps)
+	}
+}
+
+func TestMealieModule_Doc(t *testing.T) {
+	module := &MealieModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestMealieModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name        string
+		secrets     map[string]string
+		image       string
+		storage     string
+		wantImage   string
+		wantStorage string
+		wantErr     bool
+	}{
+		{
+			name:        "defaults",
+			wantImage:   defaultImage,
+			wantStorage: defaultStorageSize,
+		},
+		{
+			name:        "custom image and storage size",
+			image:       "example/mealie:1.0",
+			storage:     "10Gi",
+			wantImage:   "example/mealie:1.0",
+			wantStorage: "10Gi",
+		},
+		{
+			name:    "invalid storage size",
+			secrets: map[string]string{},
+			storage: "lots",
+			wantErr: true,
+		},
+		{
+			name:    "database_host without a port",
+			secrets: map[string]string{"database_host": "pg.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid allow_signup",
+			secrets: map[string]string{"allow_signup": "yes"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets)
+			module.ModuleConfig.Image = tt.image
+			module.ModuleConfig.Storage = tt.storage
+			_, pvc, service, deployment, err := module.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := pvc.Spec.Resources.Requests.Storage().String(); got != tt.wantStorage {
+				t.Errorf("PVC storage = %s, want %s", got, tt.wantStorage)
+			}
+			if service.Spec.Selector["app"] != "mealie" {
+				t.Errorf("Service selector = %v, want app=mealie", service.Spec.Selector)
+			}
+			if got := deployment.Spec.Template.Spec.Containers[0].Image; got != tt.wantImage {
+				t.Errorf("image = %s, want %s", got, tt.wantImage)
+			}
+		})
+	}
+}
+
+func TestMealieModule_PrepareMissingPassword(t *testing.T) {
+	module := newTestModule(nil)
+	delete(module.ModuleConfig.Secrets, "mealie_db_password")
+	if _, _, _, _, err := module.prepare(); err == nil {
+		t.Error("prepare() without mealie_db_password succeeded, want error")
+	}
+}
+
+func TestMealieModule_PrepareDatabase(t *testing.T) {
+	tests := []struct {
+		name     string
+		general  config.GeneralConfig
+		secrets  map[string]string
+		wantHost string
+		wantPort string
+		wantUser string
+		wantDB   string
+		wantTLS  bool
+	}{
+		{
+			name:     "default",
+			wantHost: "postgres", wantPort: "5432", wantUser: "mealie", wantDB: "mealie",
+		},
+		{
+			name:     "postgres module endpoint with TLS",
+			general:  config.GeneralConfig{Endpoints: map[string]string{"postgres": "postgres.infra.svc.cluster.local:5432"}, TLSCASecrets: map[string]string{"postgres": "postgres-tls"}},
+			wantHost: "postgres.infra.svc.cluster.local", wantPort: "5432", wantUser: "mealie", wantDB: "mealie",
+			wantTLS: true,
+		},
+		{
+			name:     "database_host overrides the endpoint",
+			general:  config.GeneralConfig{Endpoints: map[string]string{"postgres": "db.internal:5433"}, TLSCASecrets: map[string]string{"postgres": "postgres-tls"}},
+			secrets:  map[string]string{"database_host": "pg.example.com:6432", "mealie_db_user": "recipes", "mealie_db_name": "recipes_db"},
+			wantHost: "pg.example.com", wantPort: "6432", wantUser: "recipes", wantDB: "recipes_db",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets)
+			module.GeneralConfig.Endpoints = tt.general.Endpoints
+			module.GeneralConfig.TLSCASecrets = tt.general.TLSCASecrets
+
+			secret, _, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() failed: %v", err)
+			}
+			env := deployment.Spec.Template.Spec.Containers[0].Env
+			if envValue(env, "DB_ENGINE") != "postgres" || envValue(env, "POSTGRES_SERVER") != tt.wantHost || envValue(env, "POSTGRES_PORT") != tt.wantPort ||
+				envValue(env, "POSTGRES_USER") != tt.wantUser || envValue(env, "POSTGRES_DB") != tt.wantDB {
+				t.Errorf("database env = %v, want %s:%s as %s on %s", env, tt.wantHost, tt.wantPort, tt.wantUser, tt.wantDB)
+			}
+			if string(secret.Data["POSTGRES_PASSWORD"]) != "db-password" {
+				t.Errorf("POSTGRES_PASSWORD = %q, want the configured password", secret.Data["POSTGRES_PASSWORD"])
+			}
+			if got := envValue(env, "PGSSLMODE") == "verify-full"; got != tt.wantTLS {
+				t.Errorf("verify-full = %v, want %v", got, tt.wantTLS)
+			}
+			if got := envValue(env, "BASE_URL"); got != "https://mealie.example.com" {
+				t.Errorf("BASE_URL = %s, want https://mealie.example.com", got)
+			}
+		})
+	}
+}
+
+func TestMealieModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "mealie-5c8d", Namespace: "infra", Labels: map[string]string{"app": "mealie"}}}
+	module := &MealieModule{ModuleConfig: config.Module{Name: "mealie", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "mealie-5c8d", Command: []string{"tar", "czf", "-", "-C", "/app/data", "recipes"}, Stdout: "archive"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(destDir, "mealie", "mealie_images_*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("expected one archive, got %v", archives)
+	}
+	if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+		t.Errorf("archive = %q, want the tar output", data)
+	}
+}
+
+func TestMealieModule_BackupWithoutPod(t *testing.T) {
+	module := &MealieModule{ModuleConfig: config.Module{Name: "mealie", Namespace: "infra"}, log: logger.NewNopLogger()}
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(), k8s.NewReplayExecutor(), t.TempDir()); err == nil {
+		t.Error("backupWithClient() without a pod succeeded, want error")
+	}
+}
+
+func TestMealieModule_RestoreWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "mealie-5c8d", Namespace: "infra", Labels: map[string]string{"app": "mealie"}}}
+	archive := filepath.Join(t.TempDir(), "mealie_images.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &MealieModule{ModuleConfig: config.Module{Name: "mealie", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "mealie-5c8d", Command: []string{"tar", "xzf", "-", "-C", "/app/data"}, Stdin: "archive"},
+	)
+	if err := module.restoreWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/pvc.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := &MealieModule{
+		GeneralConfig: config.GeneralConfig{
+			Domain: "example.com",
+		},
+		ModuleConfig: config.Module{
+			Name:      "mealie",
+			Namespace: "infra",
+			Secrets: map[string]string{
+				"mealie_db_password": "password",
+			},
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/mealie/secret.yaml", expectedSecretYAML},
+		{"pvc", "configs/mealie/pvc.yaml", expectedPvcYAML},
+		{"service", "configs/mealie/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/mealie/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
@@ -41,6 +41,21 @@ type DatabaseManager interface {
 	RemoveDB(ctx context.Context, args []string) error
 }
 
+// Reporter defines the interface for modules that print a usage report
+type Reporter interface {
+	Report(ctx context.Context, args []string) error
+}
+
+// Maintainer defines the interface for modules that run routine maintenance
+type Maintainer interface {
+	Maintain(ctx context.Context, args []string) error
+}
+
+// Promoter defines the interface for modules that fail over to a standby
+type Promoter interface {
+	Promote(ctx context.Context, args []string) error
+}
+
 // Tester defines the interface for modules that support testing
 type Tester interface {
 	Test(ctx context.Context) error
@@ -56,7 +71,117 @@ type Rollouter interface {
 	Rollout(ctx context.Context, args []string) error
 }
 
+// Uploader defines the interface for modules that sync local files into the cluster
+type Uploader interface {
+	Upload(ctx context.Context, args []string) error
+}
+
+// AppCreator defines the interface for modules that register client
+// applications and hand out their tokens
+type AppCreator interface {
+	CreateApp(ctx context.Context, args []string) error
+}
+
+// APIKeyGenerator defines the interface for modules that generate keys for
+// their own API
+type APIKeyGenerator interface {
+	APIKey(ctx context.Context, args []string) error
+}
+
+// DKIMKeyGenerator defines the interface for modules that create DKIM keys
+// and print the DNS records publishing them
+type DKIMKeyGenerator interface {
+	DKIMKey(ctx context.Context, args []string) error
+}
+
+// UserCreator defines the interface for modules that manage their own users
+type UserCreator interface {
+	CreateUser(ctx context.Context, args []string) error
+}
+
+// PreauthKeyGenerator defines the interface for modules that create keys
+// registering devices without an interactive login
+type PreauthKeyGenerator interface {
+	PreauthKey(ctx context.Context, args []string) error
+}
+
 // CodeServeWebRunner defines the interface for modules that support starting code serve-web
 type CodeServeWebRunner interface {
 	CodeServeWeb(ctx context.Context) error
 }
+
+// ConfigSchemaProvider defines the interface for modules that describe their
+// modules[].secrets keys. ConfigSchema returns a struct whose yaml, doc,
+// default and required tags document each key.
+type ConfigSchemaProvider interface {
+	ConfigSchema() interface{}
+}
+
+// EndpointProvider is implemented by modules other modules connect to, such as
+// postgres. Endpoint returns the host:port dependents should use; the registry
+// passes it to every module as GeneralConfig.Endpoints[<kind>].
+type EndpointProvider interface {
+	Endpoint() string
+}
+
+// TLSProvider is implemented by EndpointProviders that can serve TLS.
+// TLSCASecret returns the Secret in the module's namespace whose ca.crt
+// verifies the certificate at Endpoint(), or "" when TLS is off; the registry
+// passes it to every module as GeneralConfig.TLSCASecrets[<kind>].
+type TLSProvider interface {
+	TLSCASecret() string
+}
+
+// ServiceMonitorProvider is implemented by modules that create the
+// prometheus-operator ServiceMonitor of a metrics section with
+// serviceMonitor: true. The registry refuses serviceMonitor for other modules,
+// which only get the scrape annotations.
+type ServiceMonitorProvider interface {
+	SupportsServiceMonitor() bool
+}
+
+// Dependent defines the interface for modules that must be applied after
+// other modules are running. Dependencies returns registered module names
+// (e.g. "postgres"), which also match prefixed entries such as "postgres-infra".
+type Dependent interface {
+	Dependencies() []string
+}
+
+// Workload defines the interface for modules whose Deployment or pod app
+// label differ from Name(). An empty DeploymentName means the module runs no
+// workload in the cluster.
+type Workload interface {
+	DeploymentName() string
+	AppLabel() string
+}
+
+// Sheller defines the interface for modules whose shell is not sh in the
+// pod's default container, e.g. psql for postgres. An empty container selects
+// the pod's default container.
+type Sheller interface {
+	Shell() (container string, command []string)
+}
+
+// DeploymentName returns the name of the Deployment managed by module
+func DeploymentName(module Module) string {
+	if w, ok := module.(Workload); ok {
+		return w.DeploymentName()
+	}
+	return module.Name()
+}
+
+// AppLabel returns the value of the app label on the module's pods
+func AppLabel(module Module) string {
+	if w, ok := module.(Workload); ok {
+		return w.AppLabel()
+	}
+	return module.Name()
+}
+
+// ShellCommand returns the container and command <module> shell opens
+func ShellCommand(module Module) (string, []string) {
+	if s, ok := module.(Sheller); ok {
+		return s.Shell()
+	}
+	return "", []string{"sh"}
+}
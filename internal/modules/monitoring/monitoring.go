@@ -18,6 +18,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "128Mi"}
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "ghcr.io/goalt/sentry-kubernetes:0b536b48eee946b00cac35e161561f3f31fb1a79"
+
 type MonitoringModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -36,10 +44,34 @@ func (m *MonitoringModule) Name() string {
 	return "monitoring"
 }
 
+// DeploymentName returns the name of the module's Deployment
+func (m *MonitoringModule) DeploymentName() string {
+	return "monitor-sentry-kubernetes"
+}
+
+// AppLabel returns the app label of the module's pods
+func (m *MonitoringModule) AppLabel() string {
+	return "sentry-kubernetes"
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	SentryDSN string `yaml:"sentry_dsn" required:"true" doc:"Sentry DSN URL for error reporting and alerting"`
+
+	k8s.ResourceSettings `yaml:",inline"`
+	k8s.ProbeSettings    `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *MonitoringModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *MonitoringModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: monitoring\n\n")
 	m.log.Info("Description:\n  Deploys a monitoring agent (personal-server-monitoring) that reports errors\n  to Sentry. Manages a ServiceAccount, ClusterRole, ClusterRoleBinding, Secret,\n  and Deployment.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  sentry_dsn   Sentry DSN URL for error reporting and alerting\n\n")
+	m.log.Info("Optional module fields:\n  image   Container image (default: %s)\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/monitoring/\n  apply      Create/update resources in the cluster\n  clean      Delete all monitoring resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
 	return nil
 }
@@ -111,7 +143,7 @@ func (m *MonitoringModule) Generate(ctx context.Context) error {
 
 func (m *MonitoringModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -317,16 +349,13 @@ func (m *MonitoringModule) prepare() (*corev1.ServiceAccount, *rbacv1.ClusterRol
 						"app":     "sentry-kubernetes",
 						"release": "monitor",
 					},
-					Annotations: map[string]string{
-						"checksum/secrets": "static-place-holder",
-					},
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: "monitor-sentry-kubernetes",
 					Containers: []corev1.Container{
 						{
 							Name:            "sentry-kubernetes",
-							Image:           "ghcr.io/goalt/sentry-kubernetes:0b536b48eee946b00cac35e161561f3f31fb1a79",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
 							ImagePullPolicy: corev1.PullAlways,
 							Env: []corev1.EnvVar{
 								{
@@ -349,6 +378,26 @@ func (m *MonitoringModule) prepare() (*corev1.ServiceAccount, *rbacv1.ClusterRol
 									Value: "__all__",
 								},
 							},
+							// The agent serves no HTTP endpoint, so the probes check that
+							// its process is still running
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", "kill -0 1"},
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       30,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", "kill -0 1"},
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 						},
 					},
 				},
@@ -356,12 +405,25 @@ func (m *MonitoringModule) prepare() (*corev1.ServiceAccount, *rbacv1.ClusterRol
 		},
 	}
 
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), serviceAccount, clusterRole, clusterRoleBinding, secret, deployment)
 	return serviceAccount, clusterRole, clusterRoleBinding, secret, deployment, nil
 }
 
 func (m *MonitoringModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -456,7 +518,7 @@ func (m *MonitoringModule) Clean(ctx context.Context) error {
 
 func (m *MonitoringModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
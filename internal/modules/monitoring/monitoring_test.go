@@ -405,6 +405,43 @@ func TestMonitoringModule_PrepareDeploymentContainer(t *testing.T) {
 	}
 }
 
+func TestMonitoringModule_PrepareProbes(t *testing.T) {
+	module := &MonitoringModule{
+		ModuleConfig: config.Module{
+			Name:      "monitoring",
+			Namespace: "test-namespace",
+			Secrets: map[string]string{
+				"sentry_dsn":                  "https://test@sentry.io/123",
+				"liveness_initial_delay":      "60",
+				"readiness_failure_threshold": "5",
+			},
+		},
+	}
+
+	_, _, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error: %v", err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.LivenessProbe == nil || container.LivenessProbe.Exec == nil {
+		t.Fatalf("LivenessProbe = %+v, want a command probe", container.LivenessProbe)
+	}
+	if container.ReadinessProbe == nil || container.ReadinessProbe.Exec == nil {
+		t.Fatalf("ReadinessProbe = %+v, want a command probe", container.ReadinessProbe)
+	}
+	if got := container.LivenessProbe.InitialDelaySeconds; got != 60 {
+		t.Errorf("LivenessProbe InitialDelaySeconds = %d, want 60", got)
+	}
+	if got := container.ReadinessProbe.FailureThreshold; got != 5 {
+		t.Errorf("ReadinessProbe FailureThreshold = %d, want 5", got)
+	}
+
+	module.ModuleConfig.Secrets["liveness_path"] = "/healthz"
+	if _, _, _, _, _, err := module.prepare(); err == nil {
+		t.Error("prepare() expected error for liveness_path on a command probe, got nil")
+	}
+}
+
 func TestMonitoringModule_PrepareMissingSentryDSN(t *testing.T) {
 	module := &MonitoringModule{
 		GeneralConfig: config.GeneralConfig{
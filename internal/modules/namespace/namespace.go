@@ -32,6 +32,16 @@ func (m *NamespaceModule) Name() string {
 	return "namespace"
 }
 
+// DeploymentName is empty: the module only creates namespaces
+func (m *NamespaceModule) DeploymentName() string {
+	return ""
+}
+
+// AppLabel is empty because the module runs no pods
+func (m *NamespaceModule) AppLabel() string {
+	return ""
+}
+
 func (m *NamespaceModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: namespace\n\n")
 	m.log.Info("Description:\n  Creates Kubernetes Namespace resources for every name listed in\n  general.namespaces in the configuration. Deploy this module first before\n  deploying any other module.\n\n")
@@ -95,7 +105,7 @@ func (m *NamespaceModule) Apply(ctx context.Context) error {
 	}
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -145,6 +155,7 @@ func (m *NamespaceModule) prepare() []*corev1.Namespace {
 				},
 			},
 		}
+		k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), namespace)
 		namespaces = append(namespaces, namespace)
 	}
 
@@ -158,7 +169,7 @@ func (m *NamespaceModule) Clean(ctx context.Context) error {
 	}
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -205,7 +216,7 @@ func (m *NamespaceModule) Status(ctx context.Context) error {
 	}
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -14,6 +13,8 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -22,6 +23,18 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "1Gi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "1Gi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "ghcr.io/openclaw/openclaw:2026.4.2"
+
 type OpenClawModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -40,10 +53,31 @@ func (m *OpenClawModule) Name() string {
 	return "openclaw"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *OpenClawModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	DashboardToken string `yaml:"dashboard_token" required:"true" generate:"true" doc:"Gateway token for OpenClaw (OPENCLAW_GATEWAY_TOKEN)"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *OpenClawModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *OpenClawModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: openclaw\n\n")
 	m.log.Info("Description:\n  Deploys the OpenClaw application.\n  Manages two PersistentVolumeClaims (data and assets), a Service, and a Deployment.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  dashboard_token   Gateway token for OpenClaw (OPENCLAW_GATEWAY_TOKEN)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/openclaw/\n  apply      Create/update resources in the cluster\n  clean      Delete all OpenClaw resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive data and assets volumes to the destination directory\n  restore    Restore volumes from a backup archive\n")
 	return nil
 }
@@ -61,7 +95,10 @@ func (m *OpenClawModule) Generate(ctx context.Context) error {
 	m.log.Info("Output directory: %s\n\n", outputDir)
 
 	// Prepare Kubernetes objects
-	configPVC, dataPVC, service, deployment := m.prepare()
+	configPVC, dataPVC, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -100,14 +137,37 @@ func (m *OpenClawModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 4
 
-	m.log.Info("\nCompleted: 4/4 OpenClaw configurations generated successfully\n")
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d OpenClaw configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *OpenClawModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -148,7 +208,10 @@ func (m *OpenClawModule) Apply(ctx context.Context) error {
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
-	configPVC, dataPVC, service, deployment := m.prepare()
+	configPVC, dataPVC, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Apply Config PVC
 	m.log.Progress("Applying PersistentVolumeClaim: openclaw-config-pvc\n")
@@ -182,12 +245,34 @@ func (m *OpenClawModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: %s\n", createdDeployment.Name)
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, true); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: OpenClaw configurations applied successfully\n")
 	return nil
 }
 
 // prepare creates and returns the Kubernetes objects for openclaw module
-func (m *OpenClawModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment) {
+func (m *OpenClawModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
 	// Prepare Config PersistentVolumeClaim
 	configStorageQuantity := resource.MustParse("100Mi")
 	configPVC := &corev1.PersistentVolumeClaim{
@@ -212,7 +297,10 @@ func (m *OpenClawModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Persi
 	}
 
 	// Prepare Data PersistentVolumeClaim
-	dataStorageQuantity := resource.MustParse("1Gi")
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
 	dataPVC := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "openclaw-data-pvc",
@@ -228,11 +316,14 @@ func (m *OpenClawModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Persi
 			},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: dataStorageQuantity,
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	storageClass := m.ModuleConfig.StorageClassName(m.GeneralConfig)
+	k8s.SetStorageClass(&configPVC.Spec, storageClass)
+	k8s.SetStorageClass(&dataPVC.Spec, storageClass)
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -257,12 +348,10 @@ func (m *OpenClawModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Persi
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
-	image := m.ModuleConfig.Image
-	if image == "" {
-		image = "ghcr.io/openclaw/openclaw:2026.4.2"
-	}
+	image := m.ModuleConfig.ContainerImage(defaultImage)
 
 	gatewayToken := m.ModuleConfig.Secrets["dashboard_token"]
 
@@ -323,6 +412,24 @@ func (m *OpenClawModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Persi
 									ContainerPort: 18789,
 								},
 							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromInt(18789),
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromInt(18789),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "openclaw-config",
@@ -358,12 +465,27 @@ func (m *OpenClawModule) prepare() (*corev1.PersistentVolumeClaim, *corev1.Persi
 		},
 	}
 
-	return configPVC, dataPVC, service, deployment
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), configPVC, dataPVC, service, deployment)
+	return configPVC, dataPVC, service, deployment, nil
 }
 
 func (m *OpenClawModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -434,6 +556,22 @@ func (m *OpenClawModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d/4 OpenClaw resources deleted successfully\n", successCount)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -444,7 +582,7 @@ func (m *OpenClawModule) Clean(ctx context.Context) error {
 
 func (m *OpenClawModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -576,21 +714,30 @@ func (m *OpenClawModule) Status(ctx context.Context) error {
 	return nil
 }
 
-// detectKubectl returns the kubectl command path, preferring microk8s if available
-func detectKubectl() string {
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		return "/snap/bin/microk8s kubectl"
+// findPod returns the name of the openclaw pod exec commands run in
+func (m *OpenClawModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=openclaw",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=openclaw")
 	}
-	return "kubectl"
+	return pods.Items[0].Name, nil
 }
 
 func (m *OpenClawModule) Backup(ctx context.Context, destDir string) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
 
+func (m *OpenClawModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
 	var backupDir string
 	if destDir != "" {
@@ -607,39 +754,29 @@ func (m *OpenClawModule) Backup(ctx context.Context, destDir string) error {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=openclaw",
-	})
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=openclaw")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
-	kubectlCmd := detectKubectl()
-
 	// 1. Backup config volume
 	m.log.Info("💾 Backing up OpenClaw config (/config)...\n")
 	configBackupFile := filepath.Join(backupDir, fmt.Sprintf("openclaw_config_%s.tar.gz", timestamp))
 
-	cmdStr := fmt.Sprintf("%s exec -n %s %s -- tar czf - /config", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	cmdParts := strings.Fields(cmdStr)
-	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
-
 	configOutFile, err := os.Create(configBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to create config backup file: %w", err)
 	}
 	defer configOutFile.Close()
 
-	cmd.Stdout = configOutFile
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "/config"},
+		Stdout:    configOutFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to archive config: %w", err)
 	}
 
@@ -647,26 +784,25 @@ func (m *OpenClawModule) Backup(ctx context.Context, destDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to stat config backup file: %w", err)
 	}
-	m.log.Success("✅ Config archived (%d bytes)\n", configFileInfo.Size())
+	m.log.Success("Config archived (%d bytes)\n", configFileInfo.Size())
 
 	// 2. Backup data volume
 	m.log.Info("💾 Backing up OpenClaw data (/data)...\n")
 	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("openclaw_data_%s.tar.gz", timestamp))
 
-	cmdStr = fmt.Sprintf("%s exec -n %s %s -- tar czf - /data", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	cmdParts = strings.Fields(cmdStr)
-	cmd = exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
-
 	dataOutFile, err := os.Create(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to create data backup file: %w", err)
 	}
 	defer dataOutFile.Close()
 
-	cmd.Stdout = dataOutFile
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "/data"},
+		Stdout:    dataOutFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to archive data: %w", err)
 	}
 
@@ -674,7 +810,7 @@ func (m *OpenClawModule) Backup(ctx context.Context, destDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to stat data backup file: %w", err)
 	}
-	m.log.Success("✅ Data archived (%d bytes)\n", dataFileInfo.Size())
+	m.log.Success("Data archived (%d bytes)\n", dataFileInfo.Size())
 
 	// 3. Metadata
 	m.log.Info("📋 Writing metadata...\n")
@@ -700,7 +836,7 @@ personal-server openclaw restore %s
 	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
-	m.log.Success("✅ Metadata written\n")
+	m.log.Success("Metadata written\n")
 
 	m.log.Success("🎉 Backup complete!\n")
 	m.log.Info("💡 To restore: personal-server openclaw restore %s\n", timestamp)
@@ -766,100 +902,95 @@ func (m *OpenClawModule) Restore(ctx context.Context, args []string) error {
 	m.log.Info("💾 Data will be restored from %s\n", dataBackupFile)
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	if err := m.restoreVolumesWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), configBackupFile, dataBackupFile); err != nil {
+		return err
+	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=openclaw",
-	})
-	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+	// Restart deployment
+	m.log.Info("🔄 Restarting deployment 'openclaw'...\n")
+	if err := k8s.RestartDeployment(ctx, clientset, m.ModuleConfig.Namespace, "openclaw", time.Now()); err != nil {
+		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
+	} else {
+		m.log.Success("Deployment restarted successfully\n")
 	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=openclaw")
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreVolumesWithClient replaces /config and /data in the openclaw pod with
+// the contents of the tar archives written by Backup
+func (m *OpenClawModule) restoreVolumesWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, configBackupFile, dataBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
-	kubectlCmd := detectKubectl()
-
 	// 1. Restore config volume
 	m.log.Info("💾 Restoring config...\n")
 
 	// Clean existing config
-	cleanCmdStr := fmt.Sprintf("%s exec -n %s %s -- rm -rf /config/*", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	cleanCmdParts := strings.Fields(cleanCmdStr)
-	cleanCmd := exec.CommandContext(ctx, cleanCmdParts[0], cleanCmdParts[1:]...)
-	if err := cleanCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", "rm -rf /config/*"},
+	}); err != nil {
 		m.log.Warn("Warning during config clean: %v\n", err)
 	}
 
 	// Restore config from tar
-	restoreCmdStr := fmt.Sprintf("%s exec -i -n %s %s -- tar xzf - -C /", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	restoreCmdParts := strings.Fields(restoreCmdStr)
-	restoreCmd := exec.CommandContext(ctx, restoreCmdParts[0], restoreCmdParts[1:]...)
-
 	configInFile, err := os.Open(configBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to open config backup file: %w", err)
 	}
 	defer configInFile.Close()
 
-	restoreCmd.Stdin = configInFile
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", "/"},
+		Stdin:     configInFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore config: %w", err)
 	}
-	m.log.Success("✅ Config restored\n")
+	m.log.Success("Config restored\n")
 
 	// 2. Restore data volume
 	m.log.Info("💾 Restoring data...\n")
 
 	// Clean existing data
-	cleanCmdStr = fmt.Sprintf("%s exec -n %s %s -- rm -rf /data/*", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	cleanCmdParts = strings.Fields(cleanCmdStr)
-	cleanCmd = exec.CommandContext(ctx, cleanCmdParts[0], cleanCmdParts[1:]...)
-	if err := cleanCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", "rm -rf /data/*"},
+	}); err != nil {
 		m.log.Warn("Warning during data clean: %v\n", err)
 	}
 
 	// Restore data from tar
-	restoreCmdStr = fmt.Sprintf("%s exec -i -n %s %s -- tar xzf - -C /", kubectlCmd, m.ModuleConfig.Namespace, podName)
-	restoreCmdParts = strings.Fields(restoreCmdStr)
-	restoreCmd = exec.CommandContext(ctx, restoreCmdParts[0], restoreCmdParts[1:]...)
-
 	dataInFile, err := os.Open(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to open data backup file: %w", err)
 	}
 	defer dataInFile.Close()
 
-	restoreCmd.Stdin = dataInFile
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", "/"},
+		Stdin:     dataInFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore data: %w", err)
 	}
-	m.log.Success("✅ Data restored\n")
-
-	// Restart deployment
-	m.log.Info("🔄 Restarting deployment 'openclaw'...\n")
-	restartCmdStr := fmt.Sprintf("%s rollout restart deployment/openclaw -n %s", kubectlCmd, m.ModuleConfig.Namespace)
-	restartCmdParts := strings.Fields(restartCmdStr)
-	restartCmd := exec.CommandContext(ctx, restartCmdParts[0], restartCmdParts[1:]...)
-
-	if err := restartCmd.Run(); err != nil {
-		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
-	} else {
-		m.log.Success("✅ Deployment restarted successfully\n")
-	}
-
-	m.log.Success("🎉 Restore complete!\n")
+	m.log.Success("Data restored\n")
 	return nil
 }
@@ -8,9 +8,12 @@ import (
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestOpenClawModule_Name(t *testing.T) {
@@ -51,7 +54,10 @@ func TestOpenClawModule_Prepare(t *testing.T) {
 				},
 			}
 
-			configPVC, dataPVC, service, deployment := module.prepare()
+			configPVC, dataPVC, service, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			// Verify all objects are not nil
 			if configPVC == nil {
@@ -95,7 +101,10 @@ func TestOpenClawModule_PrepareConfigPVC(t *testing.T) {
 		},
 	}
 
-	configPVC, _, _, _ := module.prepare()
+	configPVC, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test PVC name
 	if configPVC.Name != "openclaw-config-pvc" {
@@ -142,7 +151,10 @@ func TestOpenClawModule_PrepareDataPVC(t *testing.T) {
 		},
 	}
 
-	_, dataPVC, _, _ := module.prepare()
+	_, dataPVC, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test PVC name
 	if dataPVC.Name != "openclaw-data-pvc" {
@@ -189,7 +201,10 @@ func TestOpenClawModule_PrepareService(t *testing.T) {
 		},
 	}
 
-	_, _, service, _ := module.prepare()
+	_, _, service, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Service name
 	if service.Name != "openclaw" {
@@ -248,7 +263,10 @@ func TestOpenClawModule_PrepareDeployment(t *testing.T) {
 		},
 	}
 
-	_, _, _, deployment := module.prepare()
+	_, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Deployment name
 	if deployment.Name != "openclaw" {
@@ -314,7 +332,10 @@ func TestOpenClawModule_PrepareDeploymentContainer(t *testing.T) {
 		},
 	}
 
-	_, _, _, deployment := module.prepare()
+	_, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify container count
 	if len(deployment.Spec.Template.Spec.Containers) != 1 {
@@ -419,7 +440,10 @@ func TestOpenClawModule_PrepareWithCustomImage(t *testing.T) {
 				},
 			}
 
-			_, _, _, deployment := module.prepare()
+			_, _, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			if len(deployment.Spec.Template.Spec.Containers) != 1 {
 				t.Fatalf("Container count = %d, want 1", len(deployment.Spec.Template.Spec.Containers))
@@ -447,7 +471,10 @@ func TestOpenClawModule_PrepareGatewayToken(t *testing.T) {
 		},
 	}
 
-	_, _, _, deployment := module.prepare()
+	_, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	if len(deployment.Spec.Template.Spec.Containers) != 1 {
 		t.Fatalf("Container count = %d, want 1", len(deployment.Spec.Template.Spec.Containers))
@@ -487,7 +514,10 @@ func TestOpenClawModule_PrepareWithExtraEnvs(t *testing.T) {
 		},
 	}
 
-	_, _, _, deployment := module.prepare()
+	_, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	if len(deployment.Spec.Template.Spec.Containers) != 1 {
 		t.Fatalf("Container count = %d, want 1", len(deployment.Spec.Template.Spec.Containers))
@@ -536,7 +566,10 @@ func TestOpenClawModule_PrepareDeploymentVolumes(t *testing.T) {
 		},
 	}
 
-	_, _, _, deployment := module.prepare()
+	_, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test volumes
 	if len(deployment.Spec.Template.Spec.Volumes) != 2 {
@@ -645,3 +678,58 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenClawModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "openclaw-7d9f", Namespace: "hobby", Labels: map[string]string{"app": "openclaw"}}}
+	module := &OpenClawModule{ModuleConfig: config.Module{Name: "openclaw", Namespace: "hobby"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "hobby", Pod: "openclaw-7d9f", Command: []string{"tar", "czf", "-", "/config"}, Stdout: "config"},
+		k8s.ExecRecord{Namespace: "hobby", Pod: "openclaw-7d9f", Command: []string{"tar", "czf", "-", "/data"}, Stdout: "data"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	for prefix, want := range map[string]string{"openclaw_config": "config", "openclaw_data": "data"} {
+		archives, _ := filepath.Glob(filepath.Join(destDir, "openclaw", prefix+"_*.tar.gz"))
+		if len(archives) != 1 {
+			t.Fatalf("expected one %s archive, got %v", prefix, archives)
+		}
+		if data, _ := os.ReadFile(archives[0]); string(data) != want {
+			t.Errorf("%s archive = %q, want %q", prefix, data, want)
+		}
+	}
+}
+
+func TestOpenClawModule_RestoreVolumesWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "openclaw-7d9f", Namespace: "hobby", Labels: map[string]string{"app": "openclaw"}}}
+	dir := t.TempDir()
+	configArchive := filepath.Join(dir, "openclaw_config.tar.gz")
+	dataArchive := filepath.Join(dir, "openclaw_data.tar.gz")
+	if err := os.WriteFile(configArchive, []byte("config"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dataArchive, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &OpenClawModule{ModuleConfig: config.Module{Name: "openclaw", Namespace: "hobby"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "hobby", Pod: "openclaw-7d9f", Command: []string{"sh", "-c", "rm -rf /config/*"}},
+		k8s.ExecRecord{Namespace: "hobby", Pod: "openclaw-7d9f", Command: []string{"tar", "xzf", "-", "-C", "/"}, Stdin: "config"},
+		k8s.ExecRecord{Namespace: "hobby", Pod: "openclaw-7d9f", Command: []string{"sh", "-c", "rm -rf /data/*"}},
+		k8s.ExecRecord{Namespace: "hobby", Pod: "openclaw-7d9f", Command: []string{"tar", "xzf", "-", "-C", "/"}, Stdin: "data"},
+	)
+
+	if err := module.restoreVolumesWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, configArchive, dataArchive); err != nil {
+		t.Fatalf("restoreVolumesWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
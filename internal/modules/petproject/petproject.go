@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -39,6 +38,16 @@ func (m *PetProjectModule) Name() string {
 	return m.ProjectConfig.Name
 }
 
+// DeploymentName returns the name of the module's Deployment
+func (m *PetProjectModule) DeploymentName() string {
+	return fmt.Sprintf("pet-%s", m.ProjectConfig.Name)
+}
+
+// AppLabel returns the app label of the module's pods
+func (m *PetProjectModule) AppLabel() string {
+	return fmt.Sprintf("pet-%s", m.ProjectConfig.Name)
+}
+
 func (m *PetProjectModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: %s (pet-project)\n\n", m.ProjectConfig.Name)
 	m.log.Info("Description:\n  Deploys a custom containerized application defined in the pet-projects[]\n  section of the configuration. Manages a Deployment and optionally a Service.\n\n")
@@ -110,7 +119,7 @@ func (m *PetProjectModule) Generate(ctx context.Context) error {
 
 func (m *PetProjectModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -248,6 +257,9 @@ func (m *PetProjectModule) prepareDeployment() *appsv1.Deployment {
 		},
 	}
 
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), deployment)
+
 	return deployment
 }
 
@@ -302,6 +314,7 @@ func (m *PetProjectModule) prepareImagePullSecret() (*corev1.Secret, string, err
 			".dockerconfigjson": jsonBytes,
 		},
 	}
+	k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), secret)
 
 	return secret, secretName, nil
 }
@@ -357,13 +370,15 @@ func (m *PetProjectModule) prepareService() *corev1.Service {
 			Ports: ports,
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+	k8s.SetObjectMetadata(m.GeneralConfig.ObjectMetadata(), service)
 
 	return service
 }
 
 func (m *PetProjectModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -433,7 +448,7 @@ func (m *PetProjectModule) Clean(ctx context.Context) error {
 
 func (m *PetProjectModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -531,10 +546,18 @@ func (m *PetProjectModule) Status(ctx context.Context) error {
 	return nil
 }
 
+const (
+	// rolloutStatusTimeout bounds how long "rollout status" waits for the
+	// Deployment to become ready
+	rolloutStatusTimeout  = 5 * time.Minute
+	rolloutStatusInterval = 2 * time.Second
+)
+
 // Rollout performs rollout operations on the pet project deployment.
 // The "restart" operation updates the deployment image, environment variables, and
 // image pull secret from the current configuration before triggering a pod restart.
-// Other operations (status, history, undo) are delegated to kubectl.
+// "status" waits for the Deployment to finish rolling out, "history" lists its
+// revisions and "undo" rolls it back to the previous revision.
 func (m *PetProjectModule) Rollout(ctx context.Context, args []string) error {
 	deploymentName := fmt.Sprintf("pet-%s", m.ProjectConfig.Name)
 
@@ -561,23 +584,52 @@ func (m *PetProjectModule) Rollout(ctx context.Context, args []string) error {
 		return m.rolloutRestart(ctx, deploymentName)
 	}
 
-	// For status, history, undo delegate to kubectl
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
-	cmdStr := fmt.Sprintf("%s rollout %s deployment/%s -n %s", kubectlCmd, operation, deploymentName, m.ProjectConfig.Namespace)
-	cmdParts := strings.Fields(cmdStr)
-	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
+	return m.rolloutWithClient(ctx, clientset, operation, deploymentName)
+}
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("rollout %s failed: %w\nOutput: %s", operation, err, string(output))
+// rolloutWithClient runs the status, history and undo rollout operations
+// against an injectable Kubernetes client.
+func (m *PetProjectModule) rolloutWithClient(ctx context.Context, clientset k8s.KubernetesClient, operation, deploymentName string) error {
+	namespace := m.ProjectConfig.Namespace
+	switch operation {
+	case "status":
+		if _, err := clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				return fmt.Errorf("deployment '%s' not found in namespace '%s'", deploymentName, namespace)
+			}
+			return fmt.Errorf("failed to get deployment '%s': %w", deploymentName, err)
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, rolloutStatusTimeout)
+		defer cancel()
+		if err := k8s.WaitForDeploymentReady(waitCtx, clientset, namespace, deploymentName, rolloutStatusInterval); err != nil {
+			return fmt.Errorf("rollout status failed: %w", err)
+		}
+		m.log.Success("deployment %q successfully rolled out\n", deploymentName)
+	case "history":
+		history, err := k8s.DeploymentHistory(ctx, clientset, namespace, deploymentName)
+		if err != nil {
+			return fmt.Errorf("rollout history failed: %w", err)
+		}
+		m.log.Info("REVISION  IMAGES  CHANGE-CAUSE\n")
+		for _, revision := range history {
+			changeCause := revision.ChangeCause
+			if changeCause == "" {
+				changeCause = "<none>"
+			}
+			m.log.Info("%-8d  %s  %s\n", revision.Revision, strings.Join(revision.Images, ","), changeCause)
+		}
+	case "undo":
+		revision, err := k8s.UndoDeployment(ctx, clientset, namespace, deploymentName)
+		if err != nil {
+			return fmt.Errorf("rollout undo failed: %w", err)
+		}
+		m.log.Success("deployment %q rolled back to revision %d\n", deploymentName, revision)
 	}
-	m.log.Info("%s", string(output))
-	m.log.Success("✅ Rollout %s completed successfully\n", operation)
-
 	return nil
 }
 
@@ -585,7 +637,7 @@ func (m *PetProjectModule) Rollout(ctx context.Context, args []string) error {
 // secret from the current configuration, then triggers a rollout by patching the
 // pod template's restart annotation.
 func (m *PetProjectModule) rolloutRestart(ctx context.Context, deploymentName string) error {
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -673,7 +725,7 @@ func (m *PetProjectModule) rolloutRestartWithClient(ctx context.Context, clients
 			return fmt.Errorf("failed to update deployment '%s': %w", deploymentName, updateErr)
 		}
 
-		m.log.Success("✅ Rollout restart completed successfully\n")
+		m.log.Success("Rollout restart completed successfully\n")
 		return nil
 	}
 	return lastErr
@@ -9,7 +9,9 @@ import (
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -445,6 +447,58 @@ func TestRolloutValidation(t *testing.T) {
 	}
 }
 
+// TestRolloutStatusHistoryUndo verifies that the non-restart rollout operations
+// run against the cluster client: status on a ready deployment, history over
+// the owned ReplicaSets and undo back to the previous revision.
+func TestRolloutStatusHistoryUndo(t *testing.T) {
+	projectConfig := config.PetProject{Name: "testapp", Namespace: "hobby", Image: "nginx:1.25"}
+	module := New(config.GeneralConfig{Domain: "example.com"}, projectConfig, logger.NewNopLogger())
+
+	deployment := module.prepareDeployment()
+	deployment.UID = "testapp-uid"
+	deployment.Annotations = map[string]string{k8s.RevisionAnnotation: "2"}
+	deployment.Status.ObservedGeneration = deployment.Generation
+	deployment.Status.UpdatedReplicas = 1
+	deployment.Status.AvailableReplicas = 1
+
+	owner := *metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	var objects []runtime.Object
+	for revision, image := range map[string]string{"1": "nginx:1.24", "2": "nginx:1.25"} {
+		template := deployment.Spec.Template.DeepCopy()
+		template.Spec.Containers[0].Image = image
+		objects = append(objects, &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "pet-testapp-" + revision,
+				Namespace:       "hobby",
+				Labels:          template.Labels,
+				Annotations:     map[string]string{k8s.RevisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: *template},
+		})
+	}
+	fakeClient := newFakeClient(append(objects, deployment)...)
+	ctx := context.Background()
+
+	for _, operation := range []string{"status", "history", "undo"} {
+		if err := module.rolloutWithClient(ctx, fakeClient, operation, "pet-testapp"); err != nil {
+			t.Fatalf("rollout %s returned unexpected error: %v", operation, err)
+		}
+	}
+
+	live, err := fakeClient.AppsV1().Deployments("hobby").Get(ctx, "pet-testapp", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get deployment after undo: %v", err)
+	}
+	if got := live.Spec.Template.Spec.Containers[0].Image; got != "nginx:1.24" {
+		t.Errorf("container image after undo = %q, want %q", got, "nginx:1.24")
+	}
+
+	if err := module.rolloutWithClient(ctx, newFakeClient(), "status", "pet-testapp"); err == nil {
+		t.Error("expected an error for rollout status on a missing deployment")
+	}
+}
+
 // TestRolloutRestartUpdatesImage verifies that rollout restart updates the
 // container image in the deployment to match the module configuration.
 func TestRolloutRestartUpdatesImage(t *testing.T) {
@@ -11,6 +11,8 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -18,6 +20,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "128Mi", MemoryLimit: "512Mi"}
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "dpage/pgadmin4:9.10.0"
+
 type PgadminModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -36,10 +46,37 @@ func (m *PgadminModule) Name() string {
 	return "pgadmin"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *PgadminModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// Dependencies lists the modules that must be running before pgadmin is applied
+func (m *PgadminModule) Dependencies() []string {
+	return []string{"postgres"}
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	DefaultEmail  string `yaml:"pgadmin_default_email" required:"true" doc:"Admin e-mail address for the pgAdmin login"`
+	AdminPassword string `yaml:"pgadmin_admin_password" required:"true" generate:"true" doc:"Admin password for the pgAdmin login"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *PgadminModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *PgadminModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: pgadmin\n\n")
 	m.log.Info("Description:\n  Deploys pgAdmin 4 — a web-based PostgreSQL administration tool.\n  Manages a Secret, Service, and Deployment.\n  Connects to the postgres module for database administration.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  pgadmin_default_email    Admin e-mail address for the pgAdmin login\n  pgadmin_admin_password   Admin password for the pgAdmin login\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/pgadmin/\n  apply      Create/update resources in the cluster\n  clean      Delete all pgAdmin resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
 	return nil
 }
@@ -94,14 +131,37 @@ func (m *PgadminModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 3
 
-	m.log.Info("\nCompleted: 3/3 pgadmin configurations generated successfully\n")
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d pgadmin configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *PgadminModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -164,6 +224,28 @@ func (m *PgadminModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: %s\n", createdDeployment.Name)
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: 3/3 pgadmin resources applied successfully\n")
 	return nil
 }
@@ -221,6 +303,7 @@ func (m *PgadminModule) prepare() (*corev1.Secret, *corev1.Service, *appsv1.Depl
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
 	replicas := int32(1)
@@ -254,7 +337,7 @@ func (m *PgadminModule) prepare() (*corev1.Secret, *corev1.Service, *appsv1.Depl
 					Containers: []corev1.Container{
 						{
 							Name:            "pgadmin",
-							Image:           "dpage/pgadmin4:9.10.0",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
 							ImagePullPolicy: corev1.PullAlways,
 							Env: []corev1.EnvVar{
 								{
@@ -293,6 +376,26 @@ func (m *PgadminModule) prepare() (*corev1.Secret, *corev1.Service, *appsv1.Depl
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/misc/ping",
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 60,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/misc/ping",
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+							},
 						},
 					},
 					RestartPolicy:                 corev1.RestartPolicyAlways,
@@ -302,12 +405,30 @@ func (m *PgadminModule) prepare() (*corev1.Secret, *corev1.Service, *appsv1.Depl
 		},
 	}
 
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, service, deployment)
 	return secret, service, deployment, nil
 }
 
 func (m *PgadminModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -363,13 +484,29 @@ func (m *PgadminModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d/3 pgadmin resources deleted successfully\n", successCount)
 	return nil
 }
 
 func (m *PgadminModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
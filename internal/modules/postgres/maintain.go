@@ -0,0 +1,301 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/sentry"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	maintenanceCronJobName = "postgres-maintenance"
+
+	maintainUsage = "usage: personal-server postgres maintain [--reindex]"
+
+	// maintenanceNotifyImage runs maintenanceNotifyScript in the CronJob
+	maintenanceNotifyImage = "alpine:3.20"
+	// maintenanceReportDir is where the CronJob's maintenance container leaves
+	// its output, exit status and duration for the notify container
+	maintenanceReportDir = "/report"
+)
+
+// maintenanceScript vacuums and analyzes every database, then optionally
+// rebuilds their indexes without blocking writes. It connects as the admin
+// user from $POSTGRES_USER and $POSTGRES_PASSWORD, over the local socket in
+// the postgres pod or to $PGHOST in the CronJob.
+func maintenanceScript(reindex bool) string {
+	script := `set -e
+export PGPASSWORD="$POSTGRES_PASSWORD"
+vacuumdb -U "$POSTGRES_USER" --all --analyze
+`
+	if reindex {
+		script += `reindexdb -U "$POSTGRES_USER" --all --concurrently
+`
+	}
+	return script
+}
+
+// reportedMaintenanceScript runs maintenanceScript and writes its output, exit
+// status and duration to maintenanceReportDir. It always succeeds so the notify
+// container runs, which then fails the Job on the recorded status.
+func reportedMaintenanceScript(reindex bool) string {
+	return `start=$(date +%s)
+(
+` + maintenanceScript(reindex) + `) 2>&1 | tee ` + maintenanceReportDir + `/output
+echo "${PIPESTATUS[0]}" > ` + maintenanceReportDir + `/status
+echo "$(($(date +%s) - start))s" > ` + maintenanceReportDir + `/duration
+`
+}
+
+// maintenanceNotifyScript sends the outcome recorded by
+// reportedMaintenanceScript to the Sentry DSN in $SENTRY_DSN, the same event
+// postgres maintain sends, and exits with the maintenance exit status. A failed
+// notification is logged but does not fail the Job.
+const maintenanceNotifyScript = `status=$(cat ` + maintenanceReportDir + `/status)
+apk add --no-cache curl jq >/dev/null || { echo "failed to install curl and jq"; exit "$status"; }
+
+level=info
+message="PostgreSQL $OPERATION finished in namespace $NAMESPACE"
+if [ "$status" != 0 ]; then
+  level=error
+  message="PostgreSQL $OPERATION failed in namespace $NAMESPACE: exit status $status"
+fi
+
+case "$SENTRY_DSN" in
+https://*@*/*) ;;
+*) echo "invalid Sentry DSN: must look like https://<key>@<host>/<project>"; exit "$status" ;;
+esac
+key=${SENTRY_DSN#https://}
+key=${key%%@*}
+target=${SENTRY_DSN#*@}
+
+event_id=$(od -An -N16 -tx1 /dev/urandom | tr -d ' \n')
+event=$(jq -nc --arg id "$event_id" --arg level "$level" --arg message "$message" \
+  --arg namespace "$NAMESPACE" --arg reindex "$REINDEX" --arg pod "$HOSTNAME" \
+  --arg duration "$(cat ` + maintenanceReportDir + `/duration)" --rawfile output ` + maintenanceReportDir + `/output \
+  '{event_id: $id, timestamp: (now | floor), platform: "other", level: $level, logger: "postgres-maintenance",
+    message: $message, server_name: $pod, tags: {namespace: $namespace, reindex: $reindex},
+    extra: {pod: $pod, duration: $duration, output: ($output | rtrimstr("\n"))}}')
+if printf '{"event_id":"%s"}\n{"type":"event"}\n%s' "$event_id" "$event" |
+  curl -fsS --max-time 10 -H "Content-Type: application/x-sentry-envelope" \
+    -H "X-Sentry-Auth: Sentry sentry_version=7, sentry_key=$key, sentry_client=personal-server/1.0" \
+    --data-binary @- "https://${target%%/*}/api/${target#*/}/envelope/" >/dev/null; then
+  echo "Maintenance result sent to Sentry"
+else
+  echo "failed to send maintenance notification"
+fi
+exit "$status"
+`
+
+func parseMaintainArgs(args []string) (bool, error) {
+	reindex := false
+	for _, arg := range args {
+		switch arg {
+		case "--reindex":
+			reindex = true
+		default:
+			return false, fmt.Errorf("unexpected argument %q\n%s", arg, maintainUsage)
+		}
+	}
+	return reindex, nil
+}
+
+// Maintain runs VACUUM ANALYZE, and REINDEX with --reindex, on every database
+// and reports the result to notify_sentry_dsn when it is configured
+func (m *PostgresModule) Maintain(ctx context.Context, args []string) error {
+	reindex, err := parseMaintainArgs(args)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	var notify func(ctx context.Context, event sentry.Event) error
+	if dsn := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "notify_sentry_dsn", ""); dsn != "" {
+		notify = func(ctx context.Context, event sentry.Event) error {
+			return sentry.SendEvent(ctx, dsn, event)
+		}
+	}
+	return m.maintainWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), reindex, notify)
+}
+
+// maintainWithClient runs the maintenance script in the postgres pod. notify,
+// when set, receives the outcome whether or not maintenance succeeded; a
+// failed notification is logged but does not fail the command.
+func (m *PostgresModule) maintainWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, reindex bool, notify func(ctx context.Context, event sentry.Event) error) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	operation := "VACUUM ANALYZE"
+	if reindex {
+		operation = "VACUUM ANALYZE and REINDEX"
+	}
+	m.log.Progress("Running %s on all databases...\n", operation)
+
+	start := time.Now()
+	out, runErr := k8s.ExecCombinedOutput(ctx, executor, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"bash", "-c", maintenanceScript(reindex)},
+	})
+	duration := time.Since(start).Round(time.Second)
+	output := strings.TrimSpace(string(out))
+	if output != "" {
+		m.log.Println(output)
+	}
+
+	event := sentry.Event{
+		Message: fmt.Sprintf("PostgreSQL %s finished in namespace %s", operation, m.ModuleConfig.Namespace),
+		Logger:  "postgres-maintenance",
+		Tags:    map[string]string{"namespace": m.ModuleConfig.Namespace, "reindex": fmt.Sprintf("%t", reindex)},
+		Extra:   map[string]interface{}{"pod": podName, "duration": duration.String(), "output": output},
+	}
+	if runErr != nil {
+		event.Level = "error"
+		event.Message = fmt.Sprintf("PostgreSQL %s failed in namespace %s: %v", operation, m.ModuleConfig.Namespace, runErr)
+	}
+	if notify != nil {
+		if err := notify(ctx, event); err != nil {
+			m.log.Warn("Failed to send maintenance notification: %v\n", err)
+		} else {
+			m.log.Info("Maintenance result sent to Sentry\n")
+		}
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("maintenance failed after %s: %w", duration, runErr)
+	}
+	m.log.Success("%s completed in %s\n", operation, duration)
+	return nil
+}
+
+// prepareMaintenanceCronJob returns the CronJob running the maintenance script
+// on maintenance_schedule, or nil when no schedule is configured. With
+// notify_sentry_dsn the outcome is reported like postgres maintain does.
+func (m *PostgresModule) prepareMaintenanceCronJob() (*batchv1.CronJob, error) {
+	schedule := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "maintenance_schedule", "")
+	if schedule == "" {
+		return nil, nil
+	}
+	if err := k8s.ValidateSchedule("maintenance_schedule", schedule); err != nil {
+		return nil, err
+	}
+	reindex := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "maintenance_reindex", "false") == "true"
+
+	labels := map[string]string{
+		"app": maintenanceCronJobName,
+	}
+	secretEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "postgres-secrets",
+					},
+					Key: key,
+				},
+			},
+		}
+	}
+	historyLimit := int32(3)
+	backoffLimit := int32(0)
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      maintenanceCronJobName,
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &historyLimit,
+			FailedJobsHistoryLimit:     &historyLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{
+								{
+									Name:            "maintenance",
+									Image:           m.image(),
+									ImagePullPolicy: corev1.PullIfNotPresent,
+									Command:         []string{"bash", "-c", maintenanceScript(reindex)},
+									Env: []corev1.EnvVar{
+										secretEnv("POSTGRES_USER", "admin_postgres_user"),
+										secretEnv("POSTGRES_PASSWORD", "admin_postgres_password"),
+										{Name: "PGHOST", Value: "postgres"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "notify_sentry_dsn", "") != "" {
+		m.addMaintenanceNotifier(&cronJob.Spec.JobTemplate.Spec.Template.Spec, reindex, secretEnv("SENTRY_DSN", "notify_sentry_dsn"))
+	}
+	if m.GeneralConfig.Timezone != "" {
+		// Without a time zone the schedule is read in the controller's zone,
+		// usually UTC
+		cronJob.Spec.TimeZone = &m.GeneralConfig.Timezone
+	}
+	k8s.SetPodEnvironment(&cronJob.Spec.JobTemplate.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), cronJob)
+	return cronJob, nil
+}
+
+// addMaintenanceNotifier turns the maintenance container into an init
+// container recording its outcome and adds a container reporting it to the
+// DSN in dsnEnv
+func (m *PostgresModule) addMaintenanceNotifier(spec *corev1.PodSpec, reindex bool, dsnEnv corev1.EnvVar) {
+	operation := "VACUUM ANALYZE"
+	if reindex {
+		operation = "VACUUM ANALYZE and REINDEX"
+	}
+	report := corev1.VolumeMount{Name: "report", MountPath: maintenanceReportDir}
+
+	maintenance := spec.Containers[0]
+	maintenance.Command = []string{"bash", "-c", reportedMaintenanceScript(reindex)}
+	maintenance.VolumeMounts = append(maintenance.VolumeMounts, report)
+	spec.InitContainers = []corev1.Container{maintenance}
+	spec.Containers = []corev1.Container{
+		{
+			Name:            "notify",
+			Image:           maintenanceNotifyImage,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"sh", "-c", maintenanceNotifyScript},
+			Env: []corev1.EnvVar{
+				dsnEnv,
+				{Name: "OPERATION", Value: operation},
+				{Name: "NAMESPACE", Value: m.ModuleConfig.Namespace},
+				{Name: "REINDEX", Value: fmt.Sprintf("%t", reindex)},
+			},
+			VolumeMounts: []corev1.VolumeMount{report},
+		},
+	}
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name:         "report",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+}
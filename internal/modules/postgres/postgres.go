@@ -1,27 +1,57 @@
 package postgres
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/servicetls"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// postgresImage is the image of the primary, the replica and the maintenance
+// job unless set with the module's image field. They share it, since a
+// replica must run the primary's major version.
+const postgresImage = "postgres:16"
+
+// image returns the image of the postgres containers
+func (m *PostgresModule) image() string {
+	return m.ModuleConfig.ContainerImage(postgresImage)
+}
+
+// shutdownCommand stops postgres with a fast shutdown before the pod is
+// terminated. SIGTERM alone is a smart shutdown, which waits for clients to
+// disconnect and can run out the grace period; a pod killed mid-write needs
+// crash recovery on its next start.
+const shutdownCommand = `gosu postgres pg_ctl stop -D "$PGDATA" -m fast -w -t 50`
+
+// shutdownGracePeriod leaves room for the checkpoint pg_ctl waits for
+const shutdownGracePeriod = 60
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "1Gi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "10Gi"
+
 type PostgresModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -40,11 +70,50 @@ func (m *PostgresModule) Name() string {
 	return "postgres"
 }
 
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	AdminPostgresUser     string `yaml:"admin_postgres_user" required:"true" doc:"PostgreSQL superuser username"`
+	AdminPostgresPassword string `yaml:"admin_postgres_password" required:"true" generate:"true" doc:"PostgreSQL superuser password"`
+	Host                  string `yaml:"host" doc:"host:port dependent modules connect to, e.g. a pgbouncer Service or an external database (default: postgres.<namespace>.svc.cluster.local:5432)"`
+	MaintenanceSchedule   string `yaml:"maintenance_schedule" doc:"Cron schedule of the postgres-maintenance CronJob running vacuumdb on all databases, e.g. \"30 3 * * 0\" (default: no CronJob)"`
+	MaintenanceReindex    string `yaml:"maintenance_reindex" default:"false" doc:"Also run reindexdb --concurrently in the scheduled maintenance"`
+	NotifySentryDSN       string `yaml:"notify_sentry_dsn" doc:"Sentry DSN postgres maintain and the maintenance CronJob report their results to"`
+	Replica               string `yaml:"replica" default:"false" doc:"Run a read-only streaming replica as the postgres-replica StatefulSet; postgres promote fails over to it"`
+	ReplicationUser       string `yaml:"replication_user" default:"replicator" doc:"Role the replica streams WAL as"`
+	ReplicationPassword   string `yaml:"replication_password" generate:"true" doc:"Password of the replication role (required with replica)"`
+	TLS                   string `yaml:"tls" doc:"Serve TLS with a certificate that is self-signed (by the CA in certs/service-ca) or from cert-manager; dependent modules then verify it (default: off)"`
+	TLSIssuer             string `yaml:"tls_issuer" doc:"cert-manager issuer with tls: cert-manager, as <name> for an Issuer or ClusterIssuer/<name>"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// Shell opens psql as the admin user, whose credentials the container reads
+// from its environment
+func (m *PostgresModule) Shell() (string, []string) {
+	return "postgres", []string{"bash", "-c", `PGPASSWORD="$POSTGRES_PASSWORD" exec psql -U "$POSTGRES_USER"`}
+}
+
+// Endpoint returns the host:port modules such as gitea and synapse use to
+// reach the database. Setting the host key moves every dependent at once,
+// e.g. to pgbouncer or an external server.
+func (m *PostgresModule) Endpoint() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "host", fmt.Sprintf("postgres.%s.svc.cluster.local:5432", m.ModuleConfig.Namespace))
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *PostgresModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *PostgresModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: postgres\n\n")
 	m.log.Info("Description:\n  Deploys PostgreSQL — a powerful open-source relational database.\n  Manages a Secret, PersistentVolumeClaim, Service, and Deployment.\n  Used as the database backend for Gitea, pgAdmin, and other modules.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  admin_postgres_user       PostgreSQL superuser username\n  admin_postgres_password   PostgreSQL superuser password\n\n")
-	m.log.Info("Subcommands:\n  generate    Write Kubernetes YAML to configs/postgres/\n  apply       Create/update resources in the cluster\n  clean       Delete all PostgreSQL resources from the cluster\n  status      Print Deployment and Pod status\n  doc         Show this documentation\n  backup      Dump all databases using pg_dumpall and archive to the destination directory\n  restore     Restore databases from a pg_dumpall backup archive\n  add-db      Create a new database and user (args: <dbname> [username] [password])\n  remove-db   Drop a database and its owner role (args: <dbname>)\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  host                      host:port dependent modules connect to (default: postgres.<namespace>.svc.cluster.local:5432)\n  maintenance_schedule      Cron schedule of the postgres-maintenance CronJob running vacuumdb (default: none)\n  maintenance_reindex       \"true\" to also run reindexdb --concurrently on schedule (default: false)\n  notify_sentry_dsn         Sentry DSN maintain and the maintenance CronJob report their results to\n  replica                   \"true\" to run a read-only streaming replica, postgres-replica (default: false)\n  replication_user          Role the replica streams WAL as (default: replicator)\n  replication_password      Password of the replication role (required with replica)\n  tls                       self-signed or cert-manager to serve TLS; dependent modules then verify it (default: off)\n  tls_issuer                cert-manager issuer with tls: cert-manager (<name> or ClusterIssuer/<name>)\n\n")
+	m.log.Info("Optional module fields:\n  image   Container image (default: %s)\n\n", postgresImage)
+	m.log.Info("Subcommands:\n  generate    Write Kubernetes YAML to configs/postgres/\n  apply       Create/update resources in the cluster\n  clean       Delete all PostgreSQL resources from the cluster\n  status      Print Deployment and Pod status\n  doc         Show this documentation\n  backup      Dump all databases using pg_dumpall and archive to the destination directory\n  restore     Restore databases from a pg_dumpall backup archive\n  add-db      Create a new database and user and print its DSN (args: <dbname> <username> [password | --generate]; prompts when the password is omitted;\n              --secret-namespace <ns> [--secret-name <name>] also writes host/port/database/username/password/dsn to a Secret)\n  remove-db   Drop a database and its owner role (args: <dbname>)\n  maintain    Run VACUUM ANALYZE on all databases now and report the result to Sentry (args: [--reindex] also rebuilds indexes concurrently)\n  promote     Fail over to the replica: promote it, scale the primary Deployment to 0 and point the postgres Service at it\n  report      Print database sizes, largest tables, estimated index bloat, connection counts and cache hit ratios (args: [--limit N])\n")
 	return nil
 }
 
@@ -65,6 +134,18 @@ func (m *PostgresModule) Generate(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
 	}
+	cronJob, err := m.prepareMaintenanceCronJob()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
+	hbaConfigMap, replicaService, replicaStatefulSet, err := m.prepareReplica()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -104,13 +185,52 @@ func (m *PostgresModule) Generate(ctx context.Context) error {
 		return err
 	}
 
-	m.log.Info("\nCompleted: 4/4 Postgres configurations generated successfully\n")
+	// Write maintenance CronJob when a schedule is configured
+	total := 4
+	if cronJob != nil {
+		if err := writeYAML(cronJob, "cronjob"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	// Write the self-signed certificate Secret or the cert-manager Certificate
+	if tlsServer != nil {
+		tlsObject, err := tlsServer.Object()
+		if err != nil {
+			return err
+		}
+		name := "tls-secret"
+		if tlsServer.Mode == servicetls.ModeCertManager {
+			name = "certificate"
+		}
+		if err := writeYAML(tlsObject, name); err != nil {
+			return err
+		}
+		total++
+	}
+
+	// Write replica resources when the replica is enabled
+	if replicaStatefulSet != nil {
+		if err := writeYAML(hbaConfigMap, "hba-configmap"); err != nil {
+			return err
+		}
+		if err := writeYAML(replicaService, "replica-service"); err != nil {
+			return err
+		}
+		if err := writeYAML(replicaStatefulSet, "replica-statefulset"); err != nil {
+			return err
+		}
+		total += 3
+	}
+
+	m.log.Info("\nCompleted: %d/%d Postgres configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *PostgresModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -118,6 +238,19 @@ func (m *PostgresModule) Apply(ctx context.Context) error {
 	m.log.Info("Applying Postgres Kubernetes configurations...\n")
 	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
 
+	cronJob, err := m.prepareMaintenanceCronJob()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
+	hbaConfigMap, replicaService, replicaStatefulSet, err := m.prepareReplica()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return fmt.Errorf("failed to prepare Kubernetes objects: %w", err)
+	}
+
 	// Check if resources already exist
 	m.log.Info("Checking for existing resources...\n")
 	_, err = clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "postgres-secrets", metav1.GetOptions{})
@@ -148,6 +281,38 @@ func (m *PostgresModule) Apply(ctx context.Context) error {
 		return fmt.Errorf("failed to check deployment existence: %w", err)
 	}
 
+	if cronJob != nil {
+		_, err = clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Get(ctx, cronJob.Name, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("CronJob '%s' already exists in namespace '%s'", cronJob.Name, m.ModuleConfig.Namespace)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check CronJob existence: %w", err)
+		}
+	}
+
+	if replicaStatefulSet != nil {
+		_, err = clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, hbaConfigMapName, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("ConfigMap '%s' already exists in namespace '%s'", hbaConfigMapName, m.ModuleConfig.Namespace)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check ConfigMap existence: %w", err)
+		}
+
+		_, err = clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, replicaName, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("service '%s' already exists in namespace '%s'", replicaName, m.ModuleConfig.Namespace)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check service existence: %w", err)
+		}
+
+		_, err = clientset.AppsV1().StatefulSets(m.ModuleConfig.Namespace).Get(ctx, replicaName, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("StatefulSet '%s' already exists in namespace '%s'", replicaName, m.ModuleConfig.Namespace)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check StatefulSet existence: %w", err)
+		}
+	}
+
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
@@ -180,6 +345,31 @@ func (m *PostgresModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Service: %s\n", createdService.Name)
 
+	// Apply the certificate and the pg_hba ConfigMap before the Deployment
+	// that mounts them
+	total := 4
+	if tlsServer != nil {
+		m.log.Progress("Applying TLS certificate: %s (%s)\n", tlsSecretName, tlsServer.Mode)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := tlsServer.Apply(ctx, clientset, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied TLS certificate: %s\n", tlsSecretName)
+		total++
+	}
+	if hbaConfigMap != nil {
+		m.log.Progress("Applying ConfigMap: %s\n", hbaConfigMapName)
+		createdConfigMap, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Create(ctx, hbaConfigMap, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create ConfigMap: %w", err)
+		}
+		m.log.Success("Created ConfigMap: %s\n", createdConfigMap.Name)
+		total++
+	}
+
 	// Apply Deployment
 	m.log.Progress("Applying Deployment: postgres\n")
 	createdDeployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
@@ -188,7 +378,37 @@ func (m *PostgresModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: %s\n", createdDeployment.Name)
 
-	m.log.Info("\nCompleted: 4/4 resources applied successfully\n")
+	// Apply the replica; its init container waits for the primary to accept
+	// connections before cloning it
+	if replicaStatefulSet != nil {
+		m.log.Progress("Applying Service: %s\n", replicaName)
+		createdService, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Create(ctx, replicaService, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create Service: %w", err)
+		}
+		m.log.Success("Created Service: %s\n", createdService.Name)
+
+		m.log.Progress("Applying StatefulSet: %s\n", replicaName)
+		createdStatefulSet, err := clientset.AppsV1().StatefulSets(m.ModuleConfig.Namespace).Create(ctx, replicaStatefulSet, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create StatefulSet: %w", err)
+		}
+		m.log.Success("Created StatefulSet: %s\n", createdStatefulSet.Name)
+		total += 2
+	}
+
+	// Apply maintenance CronJob
+	if cronJob != nil {
+		m.log.Progress("Applying CronJob: %s\n", cronJob.Name)
+		createdCronJob, err := clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Create(ctx, cronJob, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create CronJob: %w", err)
+		}
+		m.log.Success("Created CronJob: %s\n", createdCronJob.Name)
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d resources applied successfully\n", total, total)
 	return nil
 }
 
@@ -208,6 +428,19 @@ func (m *PostgresModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClai
 	}
 	secretData["admin_postgres_password"] = []byte(password)
 
+	if m.replicaEnabled() {
+		replicationPassword, exists := m.ModuleConfig.Secrets["replication_password"]
+		if !exists {
+			return nil, nil, nil, nil, fmt.Errorf("replication_password not found in configuration (required with replica: \"true\")")
+		}
+		secretData["replication_password"] = []byte(replicationPassword)
+	}
+
+	// The maintenance CronJob reports to notify_sentry_dsn
+	if dsn, ok := m.ModuleConfig.Secrets["notify_sentry_dsn"]; ok && dsn != "" {
+		secretData["notify_sentry_dsn"] = []byte(dsn)
+	}
+
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "postgres-secrets",
@@ -217,6 +450,11 @@ func (m *PostgresModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClai
 		Data: secretData,
 	}
 
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
 	// Prepare PVC
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
@@ -232,11 +470,12 @@ func (m *PostgresModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClai
 			},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse("10Gi"),
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -261,6 +500,7 @@ func (m *PostgresModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClai
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
 	replicas := int32(1)
@@ -289,7 +529,7 @@ func (m *PostgresModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClai
 					Containers: []corev1.Container{
 						{
 							Name:            "postgres",
-							Image:           "postgres:16",
+							Image:           m.image(),
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Ports: []corev1.ContainerPort{
 								{
@@ -372,13 +612,42 @@ func (m *PostgresModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClai
 			},
 		},
 	}
+	if m.replicaEnabled() {
+		useHBAConfig(&deployment.Spec.Template.Spec)
+	}
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if tlsServer != nil {
+		useTLS(&deployment.Spec.Template.Spec, tlsServer)
+	}
 
+	k8s.SetGracefulShutdown(&deployment.Spec.Template.Spec, shutdownGracePeriod, shutdownCommand)
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, pvc, service, deployment)
 	return secret, pvc, service, deployment, nil
 }
 
 func (m *PostgresModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -394,6 +663,57 @@ func (m *PostgresModule) Clean(ctx context.Context) error {
 		PropagationPolicy: &deletePolicy,
 	}
 
+	// Delete the maintenance CronJob first so no job starts against a
+	// database that is going away. It only exists when a schedule was set.
+	m.log.Info("🗑️  Deleting CronJob: %s\n", maintenanceCronJobName)
+	err = clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Delete(ctx, maintenanceCronJobName, deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Info("CronJob not found (maintenance not scheduled)\n")
+		} else {
+			m.log.Error("Failed to delete CronJob: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted CronJob: %s\n", maintenanceCronJobName)
+		successCount++
+		totalResources++
+	}
+
+	// Delete the replica before the primary it streams from. Like the
+	// CronJob, its resources only exist when replica was enabled.
+	replicaResources := []struct {
+		kind   string
+		name   string
+		delete func() error
+	}{
+		{"StatefulSet", replicaName, func() error {
+			return clientset.AppsV1().StatefulSets(m.ModuleConfig.Namespace).Delete(ctx, replicaName, deleteOptions)
+		}},
+		{"Service", replicaName, func() error {
+			return clientset.CoreV1().Services(m.ModuleConfig.Namespace).Delete(ctx, replicaName, deleteOptions)
+		}},
+		{"PVC", replicaPVCName, func() error {
+			return clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Delete(ctx, replicaPVCName, deleteOptions)
+		}},
+		{"ConfigMap", hbaConfigMapName, func() error {
+			return clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Delete(ctx, hbaConfigMapName, deleteOptions)
+		}},
+	}
+	for _, r := range replicaResources {
+		m.log.Info("🗑️  Deleting %s: %s\n", r.kind, r.name)
+		if err := r.delete(); err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Info("%s not found (replica not enabled)\n", r.kind)
+			} else {
+				m.log.Error("Failed to delete %s: %v\n", r.kind, err)
+			}
+		} else {
+			m.log.Success("Deleted %s: %s\n", r.kind, r.name)
+			successCount++
+			totalResources++
+		}
+	}
+
 	// 1. Delete Deployment
 	m.log.Info("🗑️  Deleting Deployment: postgres\n")
 	err = clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "postgres", deleteOptions)
@@ -450,6 +770,22 @@ func (m *PostgresModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the TLS certificate when tls is configured
+	if tlsServer, err := m.tlsServer(); err == nil && tlsServer != nil {
+		m.log.Info("🗑️  Deleting TLS certificate: %s\n", tlsSecretName)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err == nil {
+			err = tlsServer.Delete(ctx, clientset, dyn)
+		}
+		if err != nil {
+			m.log.Error("Failed to delete TLS certificate: %v\n", err)
+		} else {
+			m.log.Success("Deleted TLS certificate: %s\n", tlsSecretName)
+			successCount++
+		}
+		totalResources++
+	}
+
 	m.log.Info("\nCompleted: %d/%d resources deleted successfully\n", successCount, totalResources)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -459,7 +795,7 @@ func (m *PostgresModule) Clean(ctx context.Context) error {
 
 func (m *PostgresModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -554,10 +890,43 @@ func (m *PostgresModule) Status(ctx context.Context) error {
 		m.log.Info("  Age: %s\n", k8s.FormatAge(age))
 	}
 
+	// Check maintenance CronJob
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "maintenance_schedule", "") != "" {
+		m.log.Println("\nMAINTENANCE CRONJOB:")
+		cronJob, err := clientset.BatchV1().CronJobs(m.ModuleConfig.Namespace).Get(ctx, maintenanceCronJobName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Println("  Status: Not Found")
+			} else {
+				m.log.Error("  Error: %v\n", err)
+			}
+		} else {
+			m.log.Info("  Name: %s\n", cronJob.Name)
+			m.log.Info("  Schedule: %s\n", cronJob.Spec.Schedule)
+			m.log.Info("  Active jobs: %d\n", len(cronJob.Status.Active))
+			if t := cronJob.Status.LastScheduleTime; t != nil {
+				m.log.Info("  Last run: %s ago\n", k8s.FormatAge(time.Since(t.Time).Round(time.Second)))
+			}
+			if t := cronJob.Status.LastSuccessfulTime; t != nil {
+				m.log.Info("  Last success: %s ago\n", k8s.FormatAge(time.Since(t.Time).Round(time.Second)))
+			}
+		}
+	}
+
+	// Check TLS certificate
+	if tlsServer, err := m.tlsServer(); err == nil && tlsServer != nil {
+		m.printTLSStatus(ctx, clientset, tlsServer)
+	}
+
+	// Check replica
+	if m.replicaEnabled() {
+		m.printReplicaStatus(ctx, clientset, m.GeneralConfig.Clients.Executor())
+	}
+
 	// Check Pods
 	m.log.Println("\nPODS:")
 	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=postgres",
+		LabelSelector: "app in (postgres, " + replicaName + ")",
 	})
 	if err != nil {
 		m.log.Error("  Error listing pods: %v\n", err)
@@ -584,13 +953,74 @@ func (m *PostgresModule) Status(ctx context.Context) error {
 	return nil
 }
 
+// findPod returns the name of the postgres pod exec commands run in: the one
+// the postgres Service routes to, so commands follow a promoted replica
+func (m *PostgresModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	selector := "app=postgres"
+	if service, err := client.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "postgres", metav1.GetOptions{}); err == nil && len(service.Spec.Selector) > 0 {
+		selector = labels.SelectorFromSet(service.Spec.Selector).String()
+	}
+
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for %s", selector)
+	}
+	return pods.Items[0].Name, nil
+}
+
+// psql runs sql as the admin user against db in the postgres pod and returns
+// the combined output. The statements go to psql's stdin, so they need no
+// shell quoting. vars are bound with psql -v and must be referenced as
+// :'name' (string literal) or :"name" (identifier), never spliced into sql,
+// so quotes or backticks in user input cannot change the statement.
+func (m *PostgresModule) psql(ctx context.Context, executor k8s.Executor, podName, db, sql string, vars map[string]string) ([]byte, error) {
+	return m.psqlWithPassword(ctx, executor, podName, db, sql, vars, "")
+}
+
+// psqlWithPassword is psql with a password that stays out of every command
+// line: it is sent as the first line of stdin, read into $DB_PASS by the
+// shell, and loaded by sql with \getenv db_pass DB_PASS
+func (m *PostgresModule) psqlWithPassword(ctx context.Context, executor k8s.Executor, podName, db, sql string, vars map[string]string, password string) ([]byte, error) {
+	script := `PGPASSWORD="$POSTGRES_PASSWORD" psql -U "$POSTGRES_USER" -d "$1" -v ON_ERROR_STOP=1 -Atq "${@:2}"`
+	stdin := sql
+	if password != "" {
+		script = `IFS= read -r DB_PASS && export DB_PASS && ` + script
+		stdin = password + "\n" + sql
+	}
+
+	command := []string{"bash", "-c", script, "psql", db}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		command = append(command, "-v", name+"="+vars[name])
+	}
+
+	return k8s.ExecCombinedOutput(ctx, executor, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   command,
+		Stdin:     strings.NewReader(stdin),
+	})
+}
+
 func (m *PostgresModule) Backup(ctx context.Context, destDir string) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
 
+func (m *PostgresModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
 	var backupDir string
 	if destDir != "" {
@@ -607,45 +1037,25 @@ func (m *PostgresModule) Backup(ctx context.Context, destDir string) error {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=postgres",
-	})
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=postgres")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	dumpFile := filepath.Join(backupDir, fmt.Sprintf("postgres_dump_%s.sql.gz", timestamp))
 
 	// Execute pg_dumpall in pod and stream to file
 	m.log.Info("💾 Creating full database dump (pg_dumpall)...\n")
-
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
-	}
-
-	// Use sh -c to simple pipe
-	finalCmdStr := fmt.Sprintf(`%s exec -n %s %s -- bash -c "pg_dumpall -U \"\$POSTGRES_USER\" --clean --if-exists" | gzip > %s`, kubectlCmd, m.ModuleConfig.Namespace, podName, dumpFile)
-
-	cmd := exec.CommandContext(ctx, "sh", "-c", finalCmdStr)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create dump: %w", err)
+	if err := m.dumpAll(ctx, executor, podName, dumpFile); err != nil {
+		return err
 	}
 
 	fileInfo, err := os.Stat(dumpFile)
 	if err != nil {
 		return fmt.Errorf("failed to stat dump file: %w", err)
 	}
-	m.log.Success("✅ Database dump created (%d bytes)\n", fileInfo.Size())
+	m.log.Success("Database dump created (%d bytes)\n", fileInfo.Size())
 
 	// Metadata
 	m.log.Info("📋 Writing metadata...\n")
@@ -668,7 +1078,7 @@ personal-server postgres restore %s
 	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
-	m.log.Success("✅ Metadata written\n")
+	m.log.Success("Metadata written\n")
 
 	m.log.Success("🎉 Backup complete!\n")
 	m.log.Info("💡 To restore: personal-server postgres restore %s\n", timestamp)
@@ -676,6 +1086,30 @@ personal-server postgres restore %s
 	return nil
 }
 
+// dumpAll streams pg_dumpall from the pod into a gzip-compressed dumpFile
+func (m *PostgresModule) dumpAll(ctx context.Context, executor k8s.Executor, podName, dumpFile string) error {
+	outFile, err := os.Create(dumpFile)
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %w", err)
+	}
+	defer outFile.Close()
+
+	gz := gzip.NewWriter(outFile)
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"bash", "-c", `pg_dumpall -U "$POSTGRES_USER" --clean --if-exists`},
+		Stdout:    gz,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to create dump: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress dump: %w", err)
+	}
+	return outFile.Close()
+}
+
 func (m *PostgresModule) Restore(ctx context.Context, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("usage: personal-server postgres restore [TIMESTAMP|latest]")
@@ -728,112 +1162,238 @@ func (m *PostgresModule) Restore(ctx context.Context, args []string) error {
 	m.log.Info("💾 Database will be restored from %s\n", dumpFile)
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.restoreDumpWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dumpFile)
+}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=postgres",
-	})
+// restoreDumpWithClient streams a gzip-compressed pg_dumpall file into psql
+// in the postgres pod
+func (m *PostgresModule) restoreDumpWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dumpFile string) error {
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=postgres")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	m.log.Info("💾 Restoring database (this may take a while)...\n")
 
-	// Restore command: gunzip -c file | kubectl exec ... -- psql -U $POSTGRES_USER postgres
-
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
+	inFile, err := os.Open(dumpFile)
+	if err != nil {
+		return fmt.Errorf("failed to open dump file: %w", err)
 	}
+	defer inFile.Close()
 
-	finalCmdStr := fmt.Sprintf(`gunzip -c %s | %s exec -i -n %s %s -- bash -c "psql -U \"\$POSTGRES_USER\" postgres"`, dumpFile, kubectlCmd, m.ModuleConfig.Namespace, podName)
-
-	restoreCmd := exec.CommandContext(ctx, "sh", "-c", finalCmdStr)
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	gz, err := gzip.NewReader(inFile)
+	if err != nil {
+		return fmt.Errorf("failed to read dump file: %w", err)
+	}
+	defer gz.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"bash", "-c", `psql -U "$POSTGRES_USER" postgres`},
+		Stdin:     gz,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore database: %w", err)
 	}
 
-	m.log.Success("✅ Database restored\n")
+	m.log.Success("Database restored\n")
 	m.log.Success("🎉 Restore complete!\n")
 
 	return nil
 }
 
-func (m *PostgresModule) AddDB(ctx context.Context, args []string) error {
-	if len(args) != 3 {
-		return fmt.Errorf("usage: personal-server postgres add-db <DB_NAME> <DB_USER> <DB_PASS>")
+// addDBOptions are the parsed arguments of postgres add-db
+type addDBOptions struct {
+	dbName          string
+	dbUser          string
+	dbPass          string // set when passed as an argument
+	generate        bool
+	secretNamespace string // create a connection Secret here when set
+	secretName      string
+}
+
+const addDBUsage = "usage: personal-server postgres add-db <DB_NAME> <DB_USER> [DB_PASS | --generate] [--secret-namespace NAMESPACE [--secret-name NAME]]"
+
+func parseAddDBArgs(args []string) (addDBOptions, error) {
+	var opts addDBOptions
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--generate":
+			opts.generate = true
+		case "--secret-namespace", "--secret-name":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value\n%s", args[i], addDBUsage)
+			}
+			if args[i] == "--secret-namespace" {
+				opts.secretNamespace = args[i+1]
+			} else {
+				opts.secretName = args[i+1]
+			}
+			i++
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) < 2 || len(positional) > 3 || (opts.generate && len(positional) == 3) {
+		return opts, fmt.Errorf(addDBUsage)
+	}
+	if opts.secretName != "" && opts.secretNamespace == "" {
+		return opts, fmt.Errorf("--secret-name requires --secret-namespace\n%s", addDBUsage)
 	}
 
-	dbName := args[0]
-	dbUser := args[1]
-	dbPass := args[2]
+	opts.dbName = positional[0]
+	opts.dbUser = positional[1]
+	if len(positional) == 3 {
+		opts.dbPass = positional[2]
+	}
 
 	// Validation
 	validator := regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
-	if !validator.MatchString(dbName) {
-		return fmt.Errorf("invalid DB_NAME: must match ^[a-zA-Z0-9_]+$")
+	if !validator.MatchString(opts.dbName) {
+		return opts, fmt.Errorf("invalid DB_NAME: must match ^[a-zA-Z0-9_]+$")
 	}
-	if !validator.MatchString(dbUser) {
-		return fmt.Errorf("invalid DB_USER: must match ^[a-zA-Z0-9_]+$")
+	if !validator.MatchString(opts.dbUser) {
+		return opts, fmt.Errorf("invalid DB_USER: must match ^[a-zA-Z0-9_]+$")
 	}
+	if opts.secretNamespace != "" && opts.secretName == "" {
+		opts.secretName = "postgres-" + strings.ReplaceAll(strings.ToLower(opts.dbName), "_", "-")
+	}
+	return opts, nil
+}
 
-	// Escape single quotes for SQL
-	dbPassEsc := strings.ReplaceAll(dbPass, "'", "''")
+func (m *PostgresModule) AddDB(ctx context.Context, args []string) error {
+	opts, err := parseAddDBArgs(args)
+	if err != nil {
+		return err
+	}
 
-	clientset, err := k8s.CreateKubernetesClient()
+	dbPass := opts.dbPass
+	switch {
+	case opts.generate:
+		if dbPass, err = generatePassword(); err != nil {
+			return err
+		}
+	case dbPass != "":
+		m.log.Warn("DB_PASS given as an argument is visible in shell history and the process list; omit it to be prompted instead\n")
+	default:
+		if dbPass, err = readPassword(os.Stdin, os.Stderr, opts.dbUser); err != nil {
+			return err
+		}
+	}
+	if !opts.generate {
+		if err := validatePassword(dbPass); err != nil {
+			return err
+		}
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	if err := m.addDBWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), opts.dbName, opts.dbUser, dbPass); err != nil {
+		return err
+	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=postgres",
-	})
+	if opts.secretNamespace != "" {
+		if err := m.applyConnectionSecret(ctx, clientset, opts.secretNamespace, opts.secretName, opts.dbName, opts.dbUser, dbPass); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("DSN: %s\n", m.dsn(opts.dbName, opts.dbUser, dbPass))
+	return nil
+}
+
+// connectionSecret holds what an app needs to reach dbName, for use with
+// envFrom or secretKeyRef
+func (m *PostgresModule) connectionSecret(namespace, name, dbName, dbUser, dbPass string) *corev1.Secret {
+	host, port, err := net.SplitHostPort(m.Endpoint())
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+		host, port = m.Endpoint(), "5432"
 	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=postgres")
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"managed-by": "personal-server",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"host":     []byte(host),
+			"port":     []byte(port),
+			"database": []byte(dbName),
+			"username": []byte(dbUser),
+			"password": []byte(dbPass),
+			"dsn":      []byte(m.dsn(dbName, dbUser, dbPass)),
+		},
 	}
-	podName := pods.Items[0].Name
-	m.log.Info("📦 Using pod: %s\n", podName)
+}
 
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
+// applyConnectionSecret creates the connection Secret or replaces its data
+func (m *PostgresModule) applyConnectionSecret(ctx context.Context, client k8s.KubernetesClient, namespace, name, dbName, dbUser, dbPass string) error {
+	secret := m.connectionSecret(namespace, name, dbName, dbUser, dbPass)
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret)
+
+	m.log.Progress("Applying Secret: %s (namespace: %s)\n", name, namespace)
+	_, err := client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
 	}
+	if err != nil {
+		return fmt.Errorf("failed to create or update connection secret %s/%s: %w", namespace, name, err)
+	}
+	m.log.Success("Applied Secret: %s in namespace %s\n", name, namespace)
+	return nil
+}
 
-	// Helper to exec command
-	execPSQL := func(sql string, db string) error {
-		// Use heredoc with EOF to avoid escaping issues
-		cmdStr := fmt.Sprintf(`%s exec -i -n %s %s -- bash -c 'PGPASSWORD="$POSTGRES_PASSWORD" psql -U "$POSTGRES_USER" -d "%s" -v ON_ERROR_STOP=1' <<'EOF'
-%s
-EOF`, kubectlCmd, m.ModuleConfig.Namespace, podName, db, sql)
-		cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("command failed: %s\nOutput: %s", err, string(output))
-		}
-		return nil
+// createRoleSQL creates db_user or, when it exists, resets its password.
+// psql does not expand variables inside DO blocks, so the branch uses \if.
+const createRoleSQL = `\getenv db_pass DB_PASS
+SELECT NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = :'db_user') AS create_role \gset
+\if :create_role
+CREATE ROLE :"db_user" LOGIN PASSWORD :'db_pass';
+\else
+ALTER ROLE :"db_user" WITH LOGIN PASSWORD :'db_pass';
+\endif
+`
+
+const grantsSQL = `GRANT CONNECT ON DATABASE :"db_name" TO :"db_user";
+GRANT USAGE ON SCHEMA public TO :"db_user";
+GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO :"db_user";
+GRANT ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public TO :"db_user";
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON TABLES TO :"db_user";
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON SEQUENCES TO :"db_user";
+ALTER SCHEMA public OWNER TO :"db_user";
+`
+
+func (m *PostgresModule) addDBWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dbName, dbUser, dbPass string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
 	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	vars := map[string]string{"db_name": dbName, "db_user": dbUser}
 
 	// Check readiness
 	m.log.Info("Waiting for Postgres to be ready in pod %s...\n", podName)
-	checkCmd := fmt.Sprintf(`%s exec -n %s %s -- sh -c 'pg_isready -U "$POSTGRES_USER" -h 127.0.0.1 -p 5432'`, kubectlCmd, m.ModuleConfig.Namespace, podName)
 	ready := false
 	for i := 0; i < 30; i++ {
-		if err := exec.CommandContext(ctx, "sh", "-c", checkCmd).Run(); err == nil {
+		if err := executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: m.ModuleConfig.Namespace,
+			Pod:       podName,
+			Command:   []string{"sh", "-c", `pg_isready -U "$POSTGRES_USER" -h 127.0.0.1 -p 5432`},
+		}); err == nil {
 			ready = true
 			break
 		}
@@ -844,46 +1404,24 @@ EOF`, kubectlCmd, m.ModuleConfig.Namespace, podName, db, sql)
 	}
 
 	m.log.Info("Creating/Altering role '%s'...\n", dbUser)
-	createRoleSQL := fmt.Sprintf(`
-DO $$
-BEGIN
-   IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '%s') THEN
-      CREATE ROLE "%s" LOGIN PASSWORD '%s';
-   ELSE
-      ALTER ROLE "%s" WITH LOGIN PASSWORD '%s';
-   END IF;
-END
-$$;`, dbUser, dbUser, dbPassEsc, dbUser, dbPassEsc)
-	if err := execPSQL(createRoleSQL, "postgres"); err != nil {
-		return err
+	if out, err := m.psqlWithPassword(ctx, executor, podName, "postgres", createRoleSQL, map[string]string{"db_user": dbUser}, dbPass); err != nil {
+		return fmt.Errorf("failed to create role: %s\nOutput: %s", err, string(out))
 	}
 
 	m.log.Info("Ensuring database '%s' exists...\n", dbName)
-	checkDBAuthCmd := fmt.Sprintf(`%s exec -n %s %s -- bash -c 'PGPASSWORD="$POSTGRES_PASSWORD" psql -U "$POSTGRES_USER" -d postgres -Atqc "SELECT 1 FROM pg_database WHERE datname = ''%s'';"'`, kubectlCmd, m.ModuleConfig.Namespace, podName, dbName)
-	out, _ := exec.CommandContext(ctx, "sh", "-c", checkDBAuthCmd).Output()
-	if !strings.Contains(string(out), "1") {
-		createDBCmd := fmt.Sprintf(`%s exec -n %s %s -- bash -c 'PGPASSWORD="$POSTGRES_PASSWORD" psql -U "$POSTGRES_USER" -d postgres -v ON_ERROR_STOP=1 -c "CREATE DATABASE \"%s\" OWNER \"%s\";"'`, kubectlCmd, m.ModuleConfig.Namespace, podName, dbName, dbUser)
-		if out, err := exec.CommandContext(ctx, "sh", "-c", createDBCmd).CombinedOutput(); err != nil {
+	out, _ := m.psql(ctx, executor, podName, "postgres", "SELECT 1 FROM pg_database WHERE datname = :'db_name';\n", vars)
+	if strings.TrimSpace(string(out)) != "1" {
+		if out, err := m.psql(ctx, executor, podName, "postgres", "CREATE DATABASE :\"db_name\" OWNER :\"db_user\";\n", vars); err != nil {
 			return fmt.Errorf("failed to create database: %s\nOutput: %s", err, string(out))
 		}
 	}
 
 	m.log.Info("Granting privileges on database '%s' to '%s'...\n", dbName, dbUser)
-	grantsSQL := fmt.Sprintf(`
-GRANT CONNECT ON DATABASE "%s" TO "%s";
-GRANT USAGE ON SCHEMA public TO "%s";
-GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO "%s";
-GRANT ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public TO "%s";
-ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON TABLES TO "%s";
-ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON SEQUENCES TO "%s";
-ALTER SCHEMA public OWNER TO "%s";
-`, dbName, dbUser, dbUser, dbUser, dbUser, dbUser, dbUser, dbUser)
-
-	if err := execPSQL(grantsSQL, dbName); err != nil {
-		m.log.Warn("Some grants might have failed or been redundant: %v\n", err)
+	if out, err := m.psql(ctx, executor, podName, dbName, grantsSQL, vars); err != nil {
+		m.log.Warn("Some grants might have failed or been redundant: command failed: %s\nOutput: %s\n", err, string(out))
 	}
 
-	m.log.Success("✅ Database and user setup complete for %s / %s\n", dbName, dbUser)
+	m.log.Success("Database and user setup complete for %s / %s\n", dbName, dbUser)
 	return nil
 }
 
@@ -904,55 +1442,41 @@ func (m *PostgresModule) RemoveDB(ctx context.Context, args []string) error {
 		return fmt.Errorf("invalid DB_USER: must match ^[a-zA-Z0-9_]+$")
 	}
 
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.removeDBWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dbName, dbUser)
+}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=postgres",
-	})
+func (m *PostgresModule) removeDBWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dbName, dbUser string) error {
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=postgres")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
-	kubectlCmd := "kubectl"
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s kubectl"
-	}
+	vars := map[string]string{"db_name": dbName, "db_user": dbUser}
 
 	m.log.Info("Terminating active connections to database '%s'...\n", dbName)
-	terminateSQL := fmt.Sprintf(`
-SELECT pg_terminate_backend(pid)
+	terminateSQL := `SELECT pg_terminate_backend(pid)
 FROM pg_stat_activity
-WHERE datname = '%s'
+WHERE datname = :'db_name'
 AND pid <> pg_backend_pid();
-`, dbName)
-
-	cmdStr := fmt.Sprintf(`%s exec -i -n %s %s -- bash -c 'PGPASSWORD="$POSTGRES_PASSWORD" psql -U "$POSTGRES_USER" -d "postgres" -v ON_ERROR_STOP=1' <<'EOF'
-%s
-EOF`, kubectlCmd, m.ModuleConfig.Namespace, podName, terminateSQL)
+`
 	// Ignore errors during termination
-	_ = exec.CommandContext(ctx, "sh", "-c", cmdStr).Run()
+	_, _ = m.psql(ctx, executor, podName, "postgres", terminateSQL, vars)
 
 	m.log.Info("Dropping database '%s'...\n", dbName)
-	dropDBCmd := fmt.Sprintf(`%s exec -n %s %s -- bash -c 'PGPASSWORD="$POSTGRES_PASSWORD" psql -U "$POSTGRES_USER" -d postgres -v ON_ERROR_STOP=1 -c "DROP DATABASE IF EXISTS \"%s\";"'`, kubectlCmd, m.ModuleConfig.Namespace, podName, dbName)
-	if out, err := exec.CommandContext(ctx, "sh", "-c", dropDBCmd).CombinedOutput(); err != nil {
+	if out, err := m.psql(ctx, executor, podName, "postgres", "DROP DATABASE IF EXISTS :\"db_name\";\n", vars); err != nil {
 		return fmt.Errorf("failed to drop database: %s\nOutput: %s", err, string(out))
 	}
 
 	m.log.Info("Dropping role '%s'...\n", dbUser)
-	dropRoleCmd := fmt.Sprintf(`%s exec -n %s %s -- bash -c 'PGPASSWORD="$POSTGRES_PASSWORD" psql -U "$POSTGRES_USER" -d postgres -v ON_ERROR_STOP=1 -c "DROP ROLE IF EXISTS \"%s\";"'`, kubectlCmd, m.ModuleConfig.Namespace, podName, dbUser)
-	if out, err := exec.CommandContext(ctx, "sh", "-c", dropRoleCmd).CombinedOutput(); err != nil {
+	if out, err := m.psql(ctx, executor, podName, "postgres", "DROP ROLE IF EXISTS :\"db_user\";\n", vars); err != nil {
 		return fmt.Errorf("failed to drop role: %s\nOutput: %s", err, string(out))
 	}
 
-	m.log.Success("✅ Database '%s' and user '%s' removed\n", dbName, dbUser)
+	m.log.Success("Database '%s' and user '%s' removed\n", dbName, dbUser)
 	return nil
 }
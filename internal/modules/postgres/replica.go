@@ -0,0 +1,418 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	replicaName            = "postgres-replica"
+	replicaPVCName         = "data-postgres-replica-0" // from the StatefulSet's volumeClaimTemplate
+	hbaConfigMapName       = "postgres-hba"
+	hbaFile                = "/etc/postgresql/pg_hba.conf"
+	replicationSlot        = "postgres_replica"
+	defaultReplicationUser = "replicator"
+
+	promoteUsage = "usage: personal-server postgres promote"
+)
+
+// replicaInitScript clones the primary into an empty data directory. It runs
+// as root so it can create $PGDATA on a fresh volume; the postgres entrypoint
+// hands the files to the postgres user on start. Using the admin credentials
+// it first creates or updates the replication role and the physical
+// replication slot, so the primary keeps the WAL the replica has not received.
+// pg_basebackup -R then writes standby.signal and primary_conninfo, which
+// makes the main container start as a read-only hot standby.
+const replicaInitScript = `set -e
+if [ -s "$PGDATA/PG_VERSION" ]; then
+  echo "Replica data present, resuming streaming replication"
+  exit 0
+fi
+until pg_isready -h "$PGHOST" -p 5432; do
+  echo "Waiting for primary $PGHOST..."
+  sleep 2
+done
+export PGPASSWORD="$POSTGRES_PASSWORD"
+psql -U "$POSTGRES_USER" -d postgres -v ON_ERROR_STOP=1 -Atq -v user="$REPLICATION_USER" -v password="$REPLICATION_PASSWORD" -v slot="$REPLICATION_SLOT" <<'SQL'
+SELECT format(CASE WHEN EXISTS (SELECT 1 FROM pg_roles WHERE rolname = :'user') THEN 'ALTER' ELSE 'CREATE' END || ' ROLE %I WITH REPLICATION LOGIN PASSWORD %L', :'user', :'password') \gexec
+SELECT 1 FROM pg_create_physical_replication_slot(:'slot') WHERE NOT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = :'slot');
+SQL
+export PGPASSWORD="$REPLICATION_PASSWORD"
+pg_basebackup -U "$REPLICATION_USER" -D "$PGDATA" -S "$REPLICATION_SLOT" -R -X stream
+echo "Base backup complete"
+`
+
+const (
+	promoteSQL      = "SELECT pg_promote(true, 60);"
+	inRecoverySQL   = "SELECT pg_is_in_recovery();"
+	replicaLagSQL   = "SELECT COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())::int, 0);"
+	replicaSelector = "app=" + replicaName
+)
+
+// replicaEnabled reports whether the streaming replica is configured
+func (m *PostgresModule) replicaEnabled() bool {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "replica", "false") == "true"
+}
+
+func (m *PostgresModule) replicationUser() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "replication_user", defaultReplicationUser)
+}
+
+// hbaConfig is the pg_hba.conf of the postgres image with one addition: the
+// replication user may stream WAL over the network with its password
+func hbaConfig(replicationUser string) string {
+	return fmt.Sprintf(`# TYPE  DATABASE        USER            ADDRESS                 METHOD
+local   all             all                                     trust
+host    all             all             127.0.0.1/32            trust
+host    all             all             ::1/128                 trust
+local   replication     all                                     trust
+host    replication     all             127.0.0.1/32            trust
+host    replication     all             ::1/128                 trust
+host    replication     %s          all                     scram-sha-256
+host    all             all             all                     scram-sha-256
+`, replicationUser)
+}
+
+// useHBAConfig points a postgres container at the pg_hba.conf from the
+// postgres-hba ConfigMap. Both the primary and the replica use it, so a
+// promoted replica accepts replication connections too.
+func useHBAConfig(spec *corev1.PodSpec) {
+	container := &spec.Containers[0]
+	container.Args = append(container.Args, "-c", "hba_file="+hbaFile)
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "hba",
+		MountPath: "/etc/postgresql",
+		ReadOnly:  true,
+	})
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: "hba",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: hbaConfigMapName,
+				},
+			},
+		},
+	})
+}
+
+// prepareReplica returns the pg_hba ConfigMap, the read-only Service and the
+// replica StatefulSet, or nils when replica is not enabled
+func (m *PostgresModule) prepareReplica() (*corev1.ConfigMap, *corev1.Service, *appsv1.StatefulSet, error) {
+	if !m.replicaEnabled() {
+		return nil, nil, nil, nil
+	}
+	if _, exists := m.ModuleConfig.Secrets["replication_password"]; !exists {
+		return nil, nil, nil, fmt.Errorf("replication_password not found in configuration (required with replica: \"true\")")
+	}
+
+	labels := map[string]string{
+		"app": replicaName,
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hbaConfigMapName,
+			Namespace: m.ModuleConfig.Namespace,
+			Labels: map[string]string{
+				"app": "postgres",
+			},
+		},
+		Data: map[string]string{
+			"pg_hba.conf": hbaConfig(m.replicationUser()),
+		},
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      replicaName,
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "postgres",
+					Port:       5432,
+					TargetPort: intstr.FromInt(5432),
+				},
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	secretEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "postgres-secrets",
+					},
+					Key: key,
+				},
+			},
+		}
+	}
+	env := []corev1.EnvVar{
+		secretEnv("POSTGRES_USER", "admin_postgres_user"),
+		secretEnv("POSTGRES_PASSWORD", "admin_postgres_password"),
+		{Name: "PGDATA", Value: "/var/lib/postgresql/data/pgdata"},
+	}
+	readyCommand := []string{"sh", "-c", "pg_isready -U \"$POSTGRES_USER\" -h 127.0.0.1 -p 5432"}
+	dataMount := []corev1.VolumeMount{
+		{
+			Name:      "data",
+			MountPath: "/var/lib/postgresql/data",
+		},
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	replicas := int32(1)
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      replicaName,
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: replicaName,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{
+							Name:            "basebackup",
+							Image:           m.image(),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command:         []string{"bash", "-c", replicaInitScript},
+							Env: append(append([]corev1.EnvVar{}, env...),
+								corev1.EnvVar{Name: "PGHOST", Value: "postgres"},
+								corev1.EnvVar{Name: "REPLICATION_USER", Value: m.replicationUser()},
+								secretEnv("REPLICATION_PASSWORD", "replication_password"),
+								corev1.EnvVar{Name: "REPLICATION_SLOT", Value: replicationSlot},
+							),
+							VolumeMounts: dataMount,
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            "postgres",
+							Image:           m.image(),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{
+								{
+									ContainerPort: 5432,
+								},
+							},
+							Env: env,
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{Command: readyCommand},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       10,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{Command: readyCommand},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       5,
+							},
+							VolumeMounts: dataMount,
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "data",
+						Labels: map[string]string{
+							"app": replicaName,
+						},
+					},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{
+							corev1.ReadWriteOnce,
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: storageSize,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&statefulSet.Spec.VolumeClaimTemplates[0].Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+	useHBAConfig(&statefulSet.Spec.Template.Spec)
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if tlsServer != nil {
+		useTLS(&statefulSet.Spec.Template.Spec, tlsServer)
+	}
+
+	k8s.SetGracefulShutdown(&statefulSet.Spec.Template.Spec, shutdownGracePeriod, shutdownCommand)
+	if err := k8s.ApplyShutdownOverrides(&statefulSet.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	k8s.SetResources(&statefulSet.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&statefulSet.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&statefulSet.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&statefulSet.Spec.Template, configMap); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), configMap, service, statefulSet)
+	return configMap, service, statefulSet, nil
+}
+
+func parsePromoteArgs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q\n%s", args[0], promoteUsage)
+	}
+	return nil
+}
+
+// Promote turns the streaming replica into the primary after the primary's
+// volume is lost: it promotes the replica, scales the old primary Deployment
+// to zero so it cannot come back as a second primary, and points the postgres
+// Service at the replica so dependent modules reconnect without changes
+func (m *PostgresModule) Promote(ctx context.Context, args []string) error {
+	if err := parsePromoteArgs(args); err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.promoteWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor())
+}
+
+func (m *PostgresModule) promoteWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor) error {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: replicaSelector,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no running pod found for %s; is replica enabled and applied?", replicaSelector)
+	}
+	podName := pods.Items[0].Name
+	m.log.Info("📦 Using replica pod: %s\n", podName)
+
+	out, err := m.psql(ctx, executor, podName, "postgres", inRecoverySQL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check replica state: %s\nOutput: %s", err, out)
+	}
+	switch strings.TrimSpace(string(out)) {
+	case "t":
+		m.log.Progress("Promoting %s to primary...\n", podName)
+		out, err := m.psql(ctx, executor, podName, "postgres", promoteSQL, nil)
+		if err != nil {
+			return fmt.Errorf("failed to promote replica: %s\nOutput: %s", err, out)
+		}
+		if strings.TrimSpace(string(out)) != "t" {
+			return fmt.Errorf("replica did not finish promotion within 60s: %s", strings.TrimSpace(string(out)))
+		}
+		m.log.Success("Promoted %s\n", podName)
+	case "f":
+		m.log.Info("%s is already a primary\n", podName)
+	default:
+		return fmt.Errorf("unexpected output checking replica state: %s", strings.TrimSpace(string(out)))
+	}
+
+	// Stop the old primary before any client can reach it again
+	deployment, err := client.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "postgres", metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get Deployment: %w", err)
+	}
+	if err == nil && (deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 0) {
+		m.log.Progress("Scaling Deployment postgres to 0\n")
+		replicas := int32(0)
+		deployment.Spec.Replicas = &replicas
+		if _, err := client.AppsV1().Deployments(m.ModuleConfig.Namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to scale down old primary: %w", err)
+		}
+		m.log.Success("Scaled Deployment postgres to 0\n")
+	}
+
+	service, err := client.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "postgres", metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get Service postgres: %w", err)
+	}
+	if service.Spec.Selector["app"] != replicaName {
+		m.log.Progress("Pointing Service postgres at %s\n", replicaName)
+		service.Spec.Selector = map[string]string{"app": replicaName}
+		if _, err := client.CoreV1().Services(m.ModuleConfig.Namespace).Update(ctx, service, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update Service postgres: %w", err)
+		}
+		m.log.Success("Service postgres now routes to %s\n", replicaName)
+	}
+
+	m.log.Info("\nFailover complete. Dependent modules reach the new primary through the postgres Service.\n")
+	m.log.Info("The cluster now runs without a replica; take a backup and rebuild with clean and apply to restore one.\n")
+	return nil
+}
+
+// printReplicaStatus reports the replica StatefulSet, its replay lag and
+// which pod the postgres Service routes writes to
+func (m *PostgresModule) printReplicaStatus(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor) {
+	m.log.Println("\nREPLICA:")
+	statefulSet, err := client.AppsV1().StatefulSets(m.ModuleConfig.Namespace).Get(ctx, replicaName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Println("  Status: Not Found")
+		} else {
+			m.log.Error("  Error: %v\n", err)
+		}
+		return
+	}
+	m.log.Info("  Name: %s\n", statefulSet.Name)
+	m.log.Info("  Ready: %d/%d\n", statefulSet.Status.ReadyReplicas, statefulSet.Status.Replicas)
+
+	if service, err := client.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "postgres", metav1.GetOptions{}); err == nil && service.Spec.Selector["app"] == replicaName {
+		m.log.Warn("  Role: primary (promoted); Deployment postgres is no longer used\n")
+		return
+	}
+	if statefulSet.Status.ReadyReplicas == 0 {
+		return
+	}
+	out, err := m.psql(ctx, executor, replicaName+"-0", "postgres", replicaLagSQL, nil)
+	if err != nil {
+		m.log.Warn("  Lag: unknown (%v)\n", err)
+		return
+	}
+	m.log.Info("  Role: standby\n")
+	m.log.Info("  Lag: %ss since last replayed transaction\n", strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+)
+
+const (
+	defaultReportLimit = 10
+
+	reportUsage = "usage: personal-server postgres report [--limit N]"
+
+	// lowCacheHitRatio is the ratio below which a database is reported as
+	// reading too much from disk; healthy OLTP databases stay above 99%
+	lowCacheHitRatio = 0.99
+	// highConnectionUsage is the share of max_connections above which the
+	// report warns before clients start being refused
+	highConnectionUsage = 0.8
+)
+
+// reportDatabasesSQL lists connectable databases with their size, open
+// connections and buffer cache counters, largest first
+const reportDatabasesSQL = `SELECT d.datname, pg_database_size(d.oid), s.numbackends, s.blks_hit, s.blks_read
+FROM pg_database d
+JOIN pg_stat_database s ON s.datid = d.oid
+WHERE NOT d.datistemplate AND d.datallowconn
+ORDER BY 2 DESC, 1;
+`
+
+// reportConnectionsSQL counts client connections by state against
+// max_connections
+const reportConnectionsSQL = `SELECT current_setting('max_connections'),
+count(*),
+count(*) FILTER (WHERE state = 'active'),
+count(*) FILTER (WHERE state = 'idle'),
+count(*) FILTER (WHERE state LIKE 'idle in transaction%')
+FROM pg_stat_activity
+WHERE backend_type = 'client backend';
+`
+
+// reportTablesSQL lists the :limit largest tables of the current database
+// including their indexes and TOAST data
+const reportTablesSQL = `SELECT schemaname || '.' || relname, pg_total_relation_size(relid), pg_relation_size(relid), n_live_tup, n_dead_tup
+FROM pg_stat_user_tables
+ORDER BY 2 DESC, 1
+LIMIT :limit;
+`
+
+// reportIndexBloatSQL estimates the wasted space of btree indexes in the
+// current database. The expected size is derived from the row count and the
+// average width of the indexed columns in pg_stats, so it is only as good as
+// the last ANALYZE; indexes on expressions or unanalyzed columns are skipped.
+const reportIndexBloatSQL = `WITH btree AS (
+  SELECT n.nspname || '.' || c.relname AS name,
+    c.relpages::numeric AS pages,
+    c.reltuples::numeric AS tuples,
+    current_setting('block_size')::numeric AS bs,
+    coalesce(substring(array_to_string(c.reloptions, ',') FROM 'fillfactor=([0-9]+)')::numeric, 90) AS fillfactor,
+    (SELECT sum(s.avg_width)
+      FROM pg_attribute a
+      JOIN pg_stats s ON s.schemaname = n.nspname AND s.tablename = t.relname AND s.attname = a.attname
+      WHERE a.attrelid = i.indrelid AND a.attnum = ANY (i.indkey)) AS key_width
+  FROM pg_index i
+  JOIN pg_class c ON c.oid = i.indexrelid
+  JOIN pg_class t ON t.oid = i.indrelid
+  JOIN pg_namespace n ON n.oid = c.relnamespace
+  JOIN pg_am am ON am.oid = c.relam
+  WHERE am.amname = 'btree' AND c.relpages > 1 AND c.reltuples >= 0
+    AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%'
+), estimate AS (
+  SELECT name, pages, bs,
+    ceil(tuples * (12 + ceil(key_width / 8) * 8) / (bs * fillfactor / 100 - 40)) + 1 AS expected_pages
+  FROM btree
+  WHERE key_width > 0
+)
+SELECT name, (pages * bs)::bigint, (greatest(pages - expected_pages, 0) * bs)::bigint
+FROM estimate
+ORDER BY 3 DESC, 2 DESC, 1
+LIMIT :limit;
+`
+
+// report is the data printed by postgres report
+type report struct {
+	Connections connectionStats
+	Databases   []databaseStats
+}
+
+type connectionStats struct {
+	Max, Total, Active, Idle, IdleInTransaction int64
+}
+
+type databaseStats struct {
+	Name        string
+	Size        int64
+	Connections int64
+	BlocksHit   int64
+	BlocksRead  int64
+	Tables      []tableStats
+	Indexes     []indexStats
+	// TablesErr and IndexesErr record why a database could not be inspected
+	TablesErr  error
+	IndexesErr error
+}
+
+type tableStats struct {
+	Name                 string
+	TotalSize, TableSize int64
+	LiveRows, DeadRows   int64
+}
+
+type indexStats struct {
+	Name        string
+	Size, Bloat int64
+}
+
+// CacheHitRatio returns the share of block reads served from shared buffers,
+// or -1 when the database has not read any blocks yet
+func (d databaseStats) CacheHitRatio() float64 {
+	total := d.BlocksHit + d.BlocksRead
+	if total == 0 {
+		return -1
+	}
+	return float64(d.BlocksHit) / float64(total)
+}
+
+func parseReportArgs(args []string) (int, error) {
+	limit := defaultReportLimit
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--limit":
+			if i+1 >= len(args) {
+				return 0, fmt.Errorf("--limit requires a value\n%s", reportUsage)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid --limit %q: must be a positive integer", args[i])
+			}
+			limit = n
+		default:
+			return 0, fmt.Errorf("unexpected argument %q\n%s", args[i], reportUsage)
+		}
+	}
+	return limit, nil
+}
+
+// Report prints database sizes, the largest tables, estimated index bloat,
+// connection counts and cache hit ratios
+func (m *PostgresModule) Report(ctx context.Context, args []string) error {
+	limit, err := parseReportArgs(args)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.reportWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), limit)
+}
+
+func (m *PostgresModule) reportWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, limit int) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n\n", podName)
+
+	r, err := m.collectReport(ctx, executor, podName, limit)
+	if err != nil {
+		return err
+	}
+	m.printReport(r)
+	return nil
+}
+
+// collectReport queries the cluster-wide statistics from the postgres
+// database, then the table and index statistics from each database. A
+// database that cannot be inspected is reported without failing the rest.
+func (m *PostgresModule) collectReport(ctx context.Context, executor k8s.Executor, podName string, limit int) (report, error) {
+	var r report
+
+	rows, err := m.queryRows(ctx, executor, podName, "postgres", reportDatabasesSQL, nil, 5)
+	if err != nil {
+		return r, fmt.Errorf("failed to list databases: %w", err)
+	}
+	for _, row := range rows {
+		d := databaseStats{Name: row[0]}
+		if err := parseInts(row[1:], &d.Size, &d.Connections, &d.BlocksHit, &d.BlocksRead); err != nil {
+			return r, fmt.Errorf("failed to parse statistics of database %s: %w", d.Name, err)
+		}
+		r.Databases = append(r.Databases, d)
+	}
+
+	rows, err = m.queryRows(ctx, executor, podName, "postgres", reportConnectionsSQL, nil, 5)
+	if err != nil {
+		return r, fmt.Errorf("failed to count connections: %w", err)
+	}
+	if len(rows) == 1 {
+		c := &r.Connections
+		if err := parseInts(rows[0], &c.Max, &c.Total, &c.Active, &c.Idle, &c.IdleInTransaction); err != nil {
+			return r, fmt.Errorf("failed to parse connection counts: %w", err)
+		}
+	}
+
+	vars := map[string]string{"limit": strconv.Itoa(limit)}
+	for i := range r.Databases {
+		d := &r.Databases[i]
+
+		if rows, err := m.queryRows(ctx, executor, podName, d.Name, reportTablesSQL, vars, 5); err != nil {
+			d.TablesErr = err
+		} else {
+			for _, row := range rows {
+				t := tableStats{Name: row[0]}
+				if err := parseInts(row[1:], &t.TotalSize, &t.TableSize, &t.LiveRows, &t.DeadRows); err != nil {
+					d.TablesErr = err
+					break
+				}
+				d.Tables = append(d.Tables, t)
+			}
+		}
+
+		if rows, err := m.queryRows(ctx, executor, podName, d.Name, reportIndexBloatSQL, vars, 3); err != nil {
+			d.IndexesErr = err
+		} else {
+			for _, row := range rows {
+				idx := indexStats{Name: row[0]}
+				if err := parseInts(row[1:], &idx.Size, &idx.Bloat); err != nil {
+					d.IndexesErr = err
+					break
+				}
+				d.Indexes = append(d.Indexes, idx)
+			}
+		}
+	}
+	return r, nil
+}
+
+// queryRows runs sql with psql's unaligned output and splits each line into
+// exactly fields columns
+func (m *PostgresModule) queryRows(ctx context.Context, executor k8s.Executor, podName, db, sql string, vars map[string]string, fields int) ([][]string, error) {
+	out, err := m.psql(ctx, executor, podName, db, sql, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s\nOutput: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		row := strings.Split(line, "|")
+		if len(row) != fields {
+			return nil, fmt.Errorf("unexpected psql output line %q: want %d columns", line, fields)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseInts(values []string, dst ...*int64) error {
+	for i, v := range values {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*dst[i] = n
+	}
+	return nil
+}
+
+func (m *PostgresModule) printReport(r report) {
+	m.log.Info("DATABASES:\n")
+	m.log.Info("  %-30s %-10s %-12s %-10s\n", "NAME", "SIZE", "CONNECTIONS", "CACHE HIT")
+	var total int64
+	for _, d := range r.Databases {
+		total += d.Size
+		m.log.Info("  %-30s %-10s %-12d %-10s\n", d.Name, formatBytes(d.Size), d.Connections, formatRatio(d.CacheHitRatio()))
+	}
+	m.log.Info("  %-30s %-10s\n\n", "TOTAL", formatBytes(total))
+
+	c := r.Connections
+	m.log.Info("CONNECTIONS:\n")
+	m.log.Info("  In use:              %d/%d\n", c.Total, c.Max)
+	m.log.Info("  Active:              %d\n", c.Active)
+	m.log.Info("  Idle:                %d\n", c.Idle)
+	m.log.Info("  Idle in transaction: %d\n\n", c.IdleInTransaction)
+
+	for _, d := range r.Databases {
+		m.log.Info("DATABASE %s:\n", d.Name)
+
+		m.log.Info("  Largest tables:\n")
+		switch {
+		case d.TablesErr != nil:
+			m.log.Warn("  Failed to list tables: %v\n", d.TablesErr)
+		case len(d.Tables) == 0:
+			m.log.Info("    (no tables)\n")
+		default:
+			m.log.Info("    %-40s %-10s %-10s %-12s %-12s\n", "NAME", "TOTAL", "TABLE", "ROWS", "DEAD ROWS")
+			for _, t := range d.Tables {
+				m.log.Info("    %-40s %-10s %-10s %-12d %-12d\n", t.Name, formatBytes(t.TotalSize), formatBytes(t.TableSize), t.LiveRows, t.DeadRows)
+			}
+		}
+
+		m.log.Info("  Index bloat (estimated):\n")
+		switch {
+		case d.IndexesErr != nil:
+			m.log.Warn("  Failed to estimate index bloat: %v\n", d.IndexesErr)
+		case len(d.Indexes) == 0:
+			m.log.Info("    (no analyzed btree indexes)\n")
+		default:
+			m.log.Info("    %-40s %-10s %-10s %-6s\n", "NAME", "SIZE", "BLOAT", "%")
+			for _, idx := range d.Indexes {
+				m.log.Info("    %-40s %-10s %-10s %-6s\n", idx.Name, formatBytes(idx.Size), formatBytes(idx.Bloat), formatRatio(float64(idx.Bloat)/float64(idx.Size)))
+			}
+		}
+		m.log.Println()
+	}
+
+	for _, d := range r.Databases {
+		if ratio := d.CacheHitRatio(); ratio >= 0 && ratio < lowCacheHitRatio {
+			m.log.Warn("Cache hit ratio of %s is %s: consider raising shared_buffers or the pod's memory\n", d.Name, formatRatio(ratio))
+		}
+	}
+	if c.Max > 0 && float64(c.Total) >= highConnectionUsage*float64(c.Max) {
+		m.log.Warn("%d of %d connections in use: consider a connection pooler such as pgbouncer\n", c.Total, c.Max)
+	}
+	if c.IdleInTransaction > 0 {
+		m.log.Warn("%d connections are idle in transaction and may block vacuum\n", c.IdleInTransaction)
+	}
+}
+
+// formatBytes renders n with a binary unit, e.g. 1.5 GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatRatio renders ratio as a percentage, or n/a for a negative ratio
+func formatRatio(ratio float64) string {
+	if ratio < 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", ratio*100)
+}
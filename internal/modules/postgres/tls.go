@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/servicetls"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const tlsSecretName = "postgres-tls"
+
+// tlsServer returns the certificate served by postgres and the replica, or
+// nil when tls is not configured
+func (m *PostgresModule) tlsServer() (*servicetls.Server, error) {
+	hosts := servicetls.ServiceHosts("postgres", m.ModuleConfig.Namespace)
+	if m.replicaEnabled() {
+		hosts = append(hosts, servicetls.ServiceHosts(replicaName, m.ModuleConfig.Namespace)...)
+	}
+	server, err := servicetls.Config(m.ModuleConfig.Secrets, tlsSecretName, m.ModuleConfig.Namespace, hosts)
+	if server != nil {
+		server.Metadata = m.ModuleConfig.ObjectMetadata(m.GeneralConfig)
+	}
+	return server, err
+}
+
+// TLSCASecret returns the Secret whose ca.crt verifies the server, so gitea,
+// hedgedoc, mealie, shlink, synapse and postgres-exporter connect with
+// sslmode=verify-full.
+// When host points dependents at another server, such as pgbouncer, they
+// cannot verify it with this CA and the result is empty.
+func (m *PostgresModule) TLSCASecret() string {
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "host", "") != "" {
+		return ""
+	}
+	if server, err := m.tlsServer(); err != nil || server == nil {
+		return ""
+	}
+	return tlsSecretName
+}
+
+// useTLS mounts the certificate into a postgres pod and turns on ssl. Clients
+// choose whether to use it; plain connections keep working.
+func useTLS(spec *corev1.PodSpec, server *servicetls.Server) {
+	server.Mount(spec, spec.Containers[0].Image, "postgres:postgres")
+	container := &spec.Containers[0]
+	container.Args = append(container.Args,
+		"-c", "ssl=on",
+		"-c", "ssl_cert_file="+servicetls.Dir+"/"+servicetls.CertKey,
+		"-c", "ssl_key_file="+servicetls.Dir+"/"+servicetls.KeyKey,
+	)
+}
+
+// printTLSStatus reports the TLS mode and when the served certificate expires
+func (m *PostgresModule) printTLSStatus(ctx context.Context, client k8s.KubernetesClient, server *servicetls.Server) {
+	m.log.Println("\nTLS:")
+	m.log.Info("  Mode: %s\n", server.Mode)
+	secret, err := client.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Info("  Secret %s: Not Found\n", tlsSecretName)
+		} else {
+			m.log.Error("  Error: %v\n", err)
+		}
+		return
+	}
+	expiry, err := servicetls.Expiry(secret)
+	if err != nil {
+		m.log.Error("  Error: %v\n", err)
+		return
+	}
+	m.log.Info("  Expires: %s (in %s)\n", expiry.Format("2006-01-02"), k8s.FormatAge(time.Until(expiry).Round(time.Hour)))
+}
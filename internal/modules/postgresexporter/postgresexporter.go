@@ -11,12 +11,22 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/servicetls"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "128Mi"}
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "quay.io/prometheuscommunity/postgres-exporter:latest"
+
 type PostgresExporterModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -35,10 +45,34 @@ func (m *PostgresExporterModule) Name() string {
 	return "postgres-exporter"
 }
 
+// Dependencies lists the modules that must be running before postgres-exporter is applied
+func (m *PostgresExporterModule) Dependencies() []string {
+	return []string{"postgres"}
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	DataSourceURI    string `yaml:"data_source_uri" doc:"PostgreSQL connection URI (default: <postgres module host>/postgres?sslmode=disable, verify-full when postgres serves TLS, else postgres:5432/...)"`
+	DataSourceUser   string `yaml:"data_source_user" default:"postgres" doc:"PostgreSQL username"`
+	DataSourcePass   string `yaml:"data_source_pass" default:"postgres" doc:"PostgreSQL password"`
+	ExtendQueryPath  string `yaml:"extend_query_path" doc:"Path to custom queries YAML file"`
+	IncludeDatabases string `yaml:"include_databases" default:"postgres" doc:"Comma-separated list of databases to include"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *PostgresExporterModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *PostgresExporterModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: postgres-exporter\n\n")
 	m.log.Info("Description:\n  Deploys postgres_exporter — a Prometheus exporter for PostgreSQL metrics.\n  Manages a Deployment that scrapes metrics from a PostgreSQL instance and\n  exposes them on port 9187 for Prometheus to collect.\n\n")
-	m.log.Info("Optional configuration keys (modules[].secrets):\n  data_source_uri     PostgreSQL connection URI (default: postgres:5432/postgres?sslmode=disable)\n  data_source_user    PostgreSQL username (default: postgres)\n  data_source_pass    PostgreSQL password (default: postgres)\n  extend_query_path   Path to custom queries YAML file (default: \"\")\n  include_databases   Comma-separated list of databases to include (default: postgres)\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  data_source_uri     PostgreSQL connection URI (default: <postgres module host>/postgres?sslmode=disable, or sslmode=verify-full when postgres serves TLS)\n  data_source_user    PostgreSQL username (default: postgres)\n  data_source_pass    PostgreSQL password (default: postgres)\n  extend_query_path   Path to custom queries YAML file (default: \"\")\n  include_databases   Comma-separated list of databases to include (default: postgres)\n\n")
+	m.log.Info("Optional module fields:\n  image   Container image (default: %s)\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/postgres-exporter/\n  apply      Create/update resources in the cluster\n  clean      Delete all postgres-exporter resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n")
 	return nil
 }
@@ -90,7 +124,7 @@ func (m *PostgresExporterModule) Generate(ctx context.Context) error {
 
 func (m *PostgresExporterModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -129,7 +163,16 @@ func (m *PostgresExporterModule) Apply(ctx context.Context) error {
 
 func (m *PostgresExporterModule) prepare() (*appsv1.Deployment, error) {
 	// Get configuration values with defaults
-	dataSourceURI := m.getSecretOrDefault("data_source_uri", "postgres:5432/postgres?sslmode=disable")
+	// Verify postgres' certificate when it serves TLS and the URI is not overridden
+	caSecret := ""
+	if m.getSecretOrDefault("data_source_uri", "") == "" {
+		caSecret = m.GeneralConfig.TLSCASecret("postgres")
+	}
+	sslParams := "sslmode=disable"
+	if caSecret != "" {
+		sslParams = "sslmode=verify-full&sslrootcert=" + servicetls.CAPath("postgres")
+	}
+	dataSourceURI := m.getSecretOrDefault("data_source_uri", m.GeneralConfig.Endpoint("postgres", "postgres:5432")+"/postgres?"+sslParams)
 	dataSourceUser := m.getSecretOrDefault("data_source_user", "postgres")
 	dataSourcePass := m.getSecretOrDefault("data_source_pass", "postgres")
 	extendQueryPath := m.getSecretOrDefault("extend_query_path", "")
@@ -171,13 +214,33 @@ func (m *PostgresExporterModule) prepare() (*appsv1.Deployment, error) {
 					Containers: []corev1.Container{
 						{
 							Name:            "postgres-exporter",
-							Image:           "quay.io/prometheuscommunity/postgres-exporter:latest",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
 							ImagePullPolicy: corev1.PullAlways,
 							Ports: []corev1.ContainerPort{
 								{
 									ContainerPort: 9187,
 								},
 							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/",
+										Port: intstr.FromInt(9187),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/",
+										Port: intstr.FromInt(9187),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 							Env: []corev1.EnvVar{
 								{
 									Name:  "DATA_SOURCE_URI",
@@ -205,6 +268,10 @@ func (m *PostgresExporterModule) prepare() (*appsv1.Deployment, error) {
 		},
 	}
 
+	if caSecret != "" {
+		servicetls.MountCA(&deployment.Spec.Template.Spec, "postgres", caSecret)
+	}
+
 	// Add optional environment variables if they are set
 	if extendQueryPath != "" {
 		deployment.Spec.Template.Spec.Containers[0].Env = append(
@@ -216,6 +283,20 @@ func (m *PostgresExporterModule) prepare() (*appsv1.Deployment, error) {
 		)
 	}
 
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), deployment)
 	return deployment, nil
 }
 
@@ -228,7 +309,7 @@ func (m *PostgresExporterModule) getSecretOrDefault(key, defaultValue string) st
 
 func (m *PostgresExporterModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -267,7 +348,7 @@ func (m *PostgresExporterModule) Clean(ctx context.Context) error {
 
 func (m *PostgresExporterModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
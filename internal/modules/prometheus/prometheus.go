@@ -11,15 +11,28 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "2Gi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "10Gi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "prom/prometheus:v2.48.0"
+
 type PrometheusModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -38,10 +51,37 @@ func (m *PrometheusModule) Name() string {
 	return m.ModuleConfig.Name
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *PrometheusModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	AlertRulesFile string `yaml:"alert_rules_file" doc:"Local file of Prometheus rule groups loaded next to the built-in alerts"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// Endpoint returns the host:port of the Prometheus HTTP API, which grafana
+// provisions as its default datasource
+func (m *PrometheusModule) Endpoint() string {
+	return fmt.Sprintf("prometheus.%s.svc.cluster.local:9090", m.ModuleConfig.Namespace)
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *PrometheusModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *PrometheusModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: %s (prometheus)\n\n", m.ModuleConfig.Name)
-	m.log.Info("Description:\n  Deploys Prometheus — an open-source monitoring and alerting system.\n  Manages a ServiceAccount, ClusterRole, ClusterRoleBinding, ConfigMap,\n  PersistentVolumeClaim, Service, and Deployment.\n  Automatically scrapes metrics from Kubernetes pods and services.\n  Multiple Prometheus instances can be deployed using the 'prometheus-<suffix>'\n  naming convention in the modules list.\n\n")
-	m.log.Info("Optional configuration keys (modules[].secrets):\n  prometheus_image   Custom Prometheus image (default: prom/prometheus:v2.48.0)\n  storage_size       PersistentVolumeClaim size (default: 10Gi)\n\n")
+	m.log.Info("Description:\n  Deploys Prometheus — an open-source monitoring and alerting system.\n  Manages a ServiceAccount, ClusterRole, ClusterRoleBinding, ConfigMap,\n  PersistentVolumeClaim, Service, and Deployment.\n  Automatically scrapes metrics from Kubernetes pods and services annotated\n  prometheus.io/scrape, such as modules with a metrics section.\n  Ships alerting rules for down targets, memory limits and full volumes,\n  sent to the alertmanager module when it is configured.\n  Multiple Prometheus instances can be deployed using the 'prometheus-<suffix>'\n  naming convention in the modules list.\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  storage            PersistentVolumeClaim size (modules[].storage, default: 10Gi)\n  alert_rules_file   Local file of rule groups loaded next to the built-in alerts\n\n")
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/%s/\n  apply      Create/update resources in the cluster\n  clean      Delete all Prometheus resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  rollout    Manage rollouts (restart, status, history, undo)\n", m.ModuleConfig.Name)
 	return nil
 }
@@ -116,14 +156,37 @@ func (m *PrometheusModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 7
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
 
-	m.log.Info("\nCompleted: 7/7 Prometheus configurations generated successfully\n")
+	m.log.Info("\nCompleted: %d/%d Prometheus configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *PrometheusModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -246,6 +309,28 @@ func (m *PrometheusModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: %s\n", createdDeployment.Name)
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: 7/7 Prometheus configurations applied successfully\n")
 	return nil
 }
@@ -318,6 +403,9 @@ func (m *PrometheusModule) prepare() (*corev1.ServiceAccount, *rbacv1.ClusterRol
   scrape_interval: 15s
   evaluation_interval: 15s
 
+rule_files:
+  - ` + rulesPath + `
+` + m.alertingConfig() + `
 scrape_configs:
   - job_name: 'prometheus'
     static_configs:
@@ -352,6 +440,23 @@ scrape_configs:
         target_label: __metrics_path__
         replacement: /api/v1/nodes/${1}/proxy/metrics
 
+  - job_name: 'kubernetes-cadvisor'
+    kubernetes_sd_configs:
+      - role: node
+    scheme: https
+    tls_config:
+      ca_file: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+    bearer_token_file: /var/run/secrets/kubernetes.io/serviceaccount/token
+    relabel_configs:
+      - action: labelmap
+        regex: __meta_kubernetes_node_label_(.+)
+      - target_label: __address__
+        replacement: kubernetes.default.svc:443
+      - source_labels: [__meta_kubernetes_node_name]
+        regex: (.+)
+        target_label: __metrics_path__
+        replacement: /api/v1/nodes/${1}/proxy/metrics/cadvisor
+
   - job_name: 'kubernetes-pods'
     kubernetes_sd_configs:
       - role: pod
@@ -420,9 +525,19 @@ scrape_configs:
 			"prometheus.yml": prometheusConfig,
 		},
 	}
+	rules, err := m.rulesData()
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, nil, err
+	}
+	for name, content := range rules {
+		configMap.Data[name] = content
+	}
 
 	// Prepare PVC
-	storageSize := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "storage_size", "10Gi")
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, nil, err
+	}
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "prometheus-data-pvc",
@@ -436,11 +551,12 @@ scrape_configs:
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse(storageSize),
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -467,10 +583,11 @@ scrape_configs:
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
 	replicas := int32(1)
-	prometheusImage := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "prometheus_image", "prom/prometheus:v2.48.0")
+	prometheusImage := m.ModuleConfig.ContainerImage(defaultImage)
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "prometheus",
@@ -572,12 +689,33 @@ scrape_configs:
 		},
 	}
 
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	// Prometheus closes its TSDB on SIGTERM, which for a large one can take
+	// longer than the default 30 seconds
+	k8s.SetGracefulShutdown(&deployment.Spec.Template.Spec, 60, "")
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, configMap); err != nil {
+		return nil, nil, nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), serviceAccount, clusterRole, clusterRoleBinding, configMap, pvc, service, deployment)
 	return serviceAccount, clusterRole, clusterRoleBinding, configMap, pvc, service, deployment, nil
 }
 
 func (m *PrometheusModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -691,6 +829,22 @@ func (m *PrometheusModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d/%d Prometheus resources deleted successfully\n", successCount, totalSteps)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -700,7 +854,7 @@ func (m *PrometheusModule) Clean(ctx context.Context) error {
 
 func (m *PrometheusModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -865,7 +1019,7 @@ func (m *PrometheusModule) Rollout(ctx context.Context, args []string) error {
 	}
 
 	action := args[0]
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
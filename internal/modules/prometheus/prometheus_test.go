@@ -3,6 +3,7 @@ package prometheus
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -632,3 +633,74 @@ func TestGenerate_CustomName(t *testing.T) {
 		}
 	}
 }
+
+func TestPrometheusModule_PrepareAlerting(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "custom.yml")
+	custom := "groups:\n  - name: backups\n    rules:\n      - alert: BackupMissing\n        expr: time() - backup_last_success_timestamp > 86400\n"
+	if err := os.WriteFile(rulesFile, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &PrometheusModule{
+		GeneralConfig: config.GeneralConfig{
+			Endpoints: map[string]string{"alertmanager": "alertmanager.monitoring.svc.cluster.local:9093"},
+		},
+		ModuleConfig: config.Module{
+			Name:      "prometheus",
+			Namespace: "monitoring",
+			Secrets:   map[string]string{"alert_rules_file": rulesFile},
+		},
+	}
+
+	_, _, _, cm, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error: %v", err)
+	}
+
+	prometheusConfig := cm.Data["prometheus.yml"]
+	for _, expected := range []string{
+		"rule_files:\n  - /etc/prometheus/*.rules.yml\n",
+		"- targets: ['alertmanager.monitoring.svc.cluster.local:9093']",
+	} {
+		if !strings.Contains(prometheusConfig, expected) {
+			t.Errorf("prometheus.yml missing %q:\n%s", expected, prometheusConfig)
+		}
+	}
+	if !strings.Contains(cm.Data["alerting.rules.yml"], "alert: TargetDown") {
+		t.Error("ConfigMap missing built-in alerting rules")
+	}
+	if cm.Data["custom.rules.yml"] != custom {
+		t.Errorf("custom.rules.yml = %q, want the alert_rules_file content", cm.Data["custom.rules.yml"])
+	}
+}
+
+func TestPrometheusModule_PrepareWithoutAlertmanager(t *testing.T) {
+	module := &PrometheusModule{ModuleConfig: config.Module{Name: "prometheus", Namespace: "monitoring"}}
+
+	_, _, _, cm, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error: %v", err)
+	}
+	if strings.Contains(cm.Data["prometheus.yml"], "alerting:") {
+		t.Error("prometheus.yml has an alerting section without an alertmanager module")
+	}
+	if _, ok := cm.Data["custom.rules.yml"]; ok {
+		t.Error("ConfigMap has custom rules without alert_rules_file")
+	}
+}
+
+func TestPrometheusModule_PrepareInvalidRulesFile(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "custom.yml")
+	if err := os.WriteFile(rulesFile, []byte("rules: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	module := &PrometheusModule{ModuleConfig: config.Module{
+		Name:      "prometheus",
+		Namespace: "monitoring",
+		Secrets:   map[string]string{"alert_rules_file": rulesFile},
+	}}
+
+	if _, _, _, _, _, _, _, err := module.prepare(); err == nil || !strings.Contains(err.Error(), "no rule groups") {
+		t.Errorf("prepare() error = %v, want no rule groups", err)
+	}
+}
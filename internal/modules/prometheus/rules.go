@@ -0,0 +1,82 @@
+package prometheus
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"gopkg.in/yaml.v3"
+)
+
+// rulesPath is where Prometheus loads alerting rules from; every
+// *.rules.yml key of the prometheus-config ConfigMap lands there
+const rulesPath = "/etc/prometheus/*.rules.yml"
+
+// alertingRules are the built-in alerts shipped with every instance. They only
+// rely on metrics from the scrape jobs in prometheus.yml
+const alertingRules = `groups:
+  - name: personal-server
+    rules:
+      - alert: TargetDown
+        expr: up == 0
+        for: 5m
+        labels:
+          severity: warning
+        annotations:
+          summary: '{{ $labels.job }} target {{ $labels.instance }} is down'
+      - alert: ContainerMemoryNearLimit
+        expr: max by (namespace, pod, container) (container_memory_working_set_bytes{container!=""} / (container_spec_memory_limit_bytes{container!=""} > 0)) > 0.9
+        for: 10m
+        labels:
+          severity: warning
+        annotations:
+          summary: '{{ $labels.namespace }}/{{ $labels.pod }} ({{ $labels.container }}) uses {{ $value | humanizePercentage }} of its memory limit'
+      - alert: VolumeAlmostFull
+        expr: kubelet_volume_stats_available_bytes / kubelet_volume_stats_capacity_bytes < 0.1
+        for: 15m
+        labels:
+          severity: critical
+        annotations:
+          summary: 'Volume {{ $labels.namespace }}/{{ $labels.persistentvolumeclaim }} has {{ $value | humanizePercentage }} space left'
+`
+
+// alertingConfig returns the alerting section of prometheus.yml pointing at
+// the configured alertmanager module, or "" when there is none
+func (m *PrometheusModule) alertingConfig() string {
+	endpoint := m.GeneralConfig.Endpoint("alertmanager", "")
+	if endpoint == "" {
+		return ""
+	}
+	return fmt.Sprintf(`
+alerting:
+  alertmanagers:
+    - static_configs:
+        - targets: ['%s']
+`, endpoint)
+}
+
+// rulesData returns the rule files stored in the prometheus-config ConfigMap:
+// the built-in alerts plus the local file named by alert_rules_file
+func (m *PrometheusModule) rulesData() (map[string]string, error) {
+	data := map[string]string{"alerting.rules.yml": alertingRules}
+
+	path := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "alert_rules_file", "")
+	if path == "" {
+		return data, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert_rules_file: %w", err)
+	}
+	var rules struct {
+		Groups []interface{} `yaml:"groups"`
+	}
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("alert_rules_file %s is not valid YAML: %w", path, err)
+	}
+	if len(rules.Groups) == 0 {
+		return nil, fmt.Errorf("alert_rules_file %s has no rule groups", path)
+	}
+	data["custom.rules.yml"] = string(content)
+	return data, nil
+}
@@ -0,0 +1,435 @@
+package quotas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// quotaName and limitRangeName name the objects created in every
+	// namespace with a budget
+	quotaName      = "personal-server-quota"
+	limitRangeName = "personal-server-limits"
+	// warnPercent is the consumption from which status flags a resource
+	warnPercent = 90
+)
+
+// QuotasModule manages a ResourceQuota and LimitRange per namespace from the
+// top-level quotas section of the configuration, so one namespace, such as CI
+// jobs, cannot starve another, such as the databases.
+type QuotasModule struct {
+	Quotas map[string]config.NamespaceQuota
+	// Metadata holds the general labels and annotations stamped on the
+	// quotas and limit ranges
+	Metadata k8s.ObjectMetadata
+	// Clients reach the cluster
+	Clients *k8s.Clients
+	log     logger.Logger
+}
+
+// New creates a new QuotasModule.
+func New(quotas map[string]config.NamespaceQuota, log logger.Logger) *QuotasModule {
+	return &QuotasModule{
+		Quotas: quotas,
+		log:    log,
+	}
+}
+
+func (m *QuotasModule) Name() string {
+	return "quotas"
+}
+
+// DeploymentName is empty: the module only creates quotas and limit ranges
+func (m *QuotasModule) DeploymentName() string {
+	return ""
+}
+
+// AppLabel is empty because the module runs no pods
+func (m *QuotasModule) AppLabel() string {
+	return ""
+}
+
+func (m *QuotasModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: quotas\n\n")
+	m.log.Info("Description:\n  Creates a ResourceQuota and LimitRange in each namespace listed in the\n  top-level quotas section of the configuration, capping what the namespace's\n  workloads may claim in total and giving containers without their own\n  requests and limits defaults that count against the budget.\n\n")
+	m.log.Info("Configuration (top-level quotas map, keyed by namespace):\n  <namespace>:\n    hard             ResourceQuota totals, e.g. requests.cpu, limits.memory, pods\n    default          Limits for containers that set none, e.g. cpu, memory\n    defaultRequest   Requests for containers that set none (default: the limits)\n    max              Largest limits any one container may set\n\n")
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/quotas/\n  apply      Create/update quotas and limit ranges in the cluster\n  clean      Delete all quotas and limit ranges from the cluster\n  status     Print consumption against each namespace's budget\n  doc        Show this documentation\n")
+	return nil
+}
+
+func (m *QuotasModule) Generate(ctx context.Context) error {
+	if len(m.Quotas) == 0 {
+		m.log.Info("No quotas configured\n")
+		return nil
+	}
+
+	outputDir := filepath.Join("configs", "quotas")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating quota configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	quotas, limitRanges, err := m.prepare()
+	if err != nil {
+		return err
+	}
+	m.warnMissingDefaults()
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	for _, quota := range quotas {
+		if err := writeYAML(quota, quota.Namespace+"-quota"); err != nil {
+			return err
+		}
+	}
+	for _, limitRange := range limitRanges {
+		if err := writeYAML(limitRange, limitRange.Namespace+"-limits"); err != nil {
+			return err
+		}
+	}
+
+	total := len(quotas) + len(limitRanges)
+	m.log.Info("\nCompleted: %d/%d quota configurations generated successfully\n", total, total)
+	return nil
+}
+
+// Apply creates or updates the ResourceQuota and LimitRange of each
+// configured namespace.
+func (m *QuotasModule) Apply(ctx context.Context) error {
+	if len(m.Quotas) == 0 {
+		m.log.Info("No quotas configured\n")
+		return nil
+	}
+
+	quotas, limitRanges, err := m.prepare()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := m.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying quota configurations...\n\n")
+	m.warnMissingDefaults()
+
+	// Limit ranges go first so pods created once the quota exists already
+	// receive their defaults
+	for _, limitRange := range limitRanges {
+		m.log.Progress("Applying LimitRange: %s (namespace: %s)\n", limitRange.Name, limitRange.Namespace)
+		_, err = clientset.CoreV1().LimitRanges(limitRange.Namespace).Create(ctx, limitRange, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			_, err = clientset.CoreV1().LimitRanges(limitRange.Namespace).Update(ctx, limitRange, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create or update LimitRange in namespace %q: %w", limitRange.Namespace, err)
+		}
+		m.log.Success("Applied LimitRange: %s in namespace %s\n", limitRange.Name, limitRange.Namespace)
+	}
+
+	for _, quota := range quotas {
+		m.log.Progress("Applying ResourceQuota: %s (namespace: %s)\n", quota.Name, quota.Namespace)
+		_, err = clientset.CoreV1().ResourceQuotas(quota.Namespace).Create(ctx, quota, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			_, err = clientset.CoreV1().ResourceQuotas(quota.Namespace).Update(ctx, quota, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create or update ResourceQuota in namespace %q: %w", quota.Namespace, err)
+		}
+		m.log.Success("Applied ResourceQuota: %s in namespace %s\n", quota.Name, quota.Namespace)
+	}
+
+	m.log.Info("\nCompleted: quotas applied successfully\n")
+	return nil
+}
+
+// Clean deletes the ResourceQuota and LimitRange of each configured namespace.
+func (m *QuotasModule) Clean(ctx context.Context) error {
+	if len(m.Quotas) == 0 {
+		m.log.Info("No quotas configured\n")
+		return nil
+	}
+
+	clientset, err := m.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning quotas...\n\n")
+
+	successCount := 0
+	for _, namespace := range m.namespaces() {
+		m.log.Info("🗑️  Processing ResourceQuota: %s (namespace: %s)\n", quotaName, namespace)
+		err := clientset.CoreV1().ResourceQuotas(namespace).Delete(ctx, quotaName, metav1.DeleteOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("ResourceQuota '%s' not found in namespace %s\n", quotaName, namespace)
+			} else {
+				m.log.Error("Failed to delete ResourceQuota: %v\n", err)
+			}
+		} else {
+			m.log.Success("Deleted ResourceQuota: %s\n", quotaName)
+			successCount++
+		}
+
+		m.log.Info("🗑️  Processing LimitRange: %s (namespace: %s)\n", limitRangeName, namespace)
+		err = clientset.CoreV1().LimitRanges(namespace).Delete(ctx, limitRangeName, metav1.DeleteOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("LimitRange '%s' not found in namespace %s\n", limitRangeName, namespace)
+			} else {
+				m.log.Error("Failed to delete LimitRange: %v\n", err)
+			}
+		} else {
+			m.log.Success("Deleted LimitRange: %s\n", limitRangeName)
+			successCount++
+		}
+	}
+
+	m.log.Info("\nCompleted: %d quota resources deleted successfully\n", successCount)
+	return nil
+}
+
+// Status prints, per configured namespace, how much of each budgeted
+// resource its workloads consume.
+func (m *QuotasModule) Status(ctx context.Context) error {
+	if len(m.Quotas) == 0 {
+		m.log.Info("No quotas configured\n")
+		return nil
+	}
+
+	clientset, err := m.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.statusWithClient(ctx, clientset)
+}
+
+func (m *QuotasModule) statusWithClient(ctx context.Context, client k8s.KubernetesClient) error {
+	m.log.Info("Checking quotas...\n\n")
+
+	for _, namespace := range m.namespaces() {
+		quota, err := client.CoreV1().ResourceQuotas(namespace).Get(ctx, quotaName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("ResourceQuota '%s' not found in namespace %s\n\n", quotaName, namespace)
+			} else {
+				m.log.Error("Error getting ResourceQuota in namespace %s: %v\n\n", namespace, err)
+			}
+			continue
+		}
+
+		m.log.Info("NAMESPACE: %s\n", namespace)
+		m.log.Info("  %-28s %-12s %-12s %s\n", "RESOURCE", "USED", "HARD", "USAGE")
+		names := make([]string, 0, len(quota.Spec.Hard))
+		for name := range quota.Spec.Hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			hard := quota.Spec.Hard[corev1.ResourceName(name)]
+			// The quota controller fills in usage shortly after creation
+			used, ok := quota.Status.Used[corev1.ResourceName(name)]
+			if !ok {
+				m.log.Info("  %-28s %-12s %-12s %s\n", name, "-", hard.String(), "-")
+				continue
+			}
+			line := fmt.Sprintf("  %-28s %-12s %-12s %s\n", name, used.String(), hard.String(), usage(used, hard))
+			if percent(used, hard) >= warnPercent {
+				m.log.Warn("%s", line)
+			} else {
+				m.log.Info("%s", line)
+			}
+		}
+
+		if _, err := client.CoreV1().LimitRanges(namespace).Get(ctx, limitRangeName, metav1.GetOptions{}); err == nil {
+			m.log.Info("  LimitRange:      %s\n", limitRangeName)
+		} else if errors.IsNotFound(err) && hasLimits(m.Quotas[namespace]) {
+			m.log.Error("  LimitRange '%s' not found\n", limitRangeName)
+		}
+		m.log.Println()
+	}
+	return nil
+}
+
+// prepare builds the ResourceQuota and, when container defaults or bounds are
+// configured, the LimitRange of every namespace, in namespace order
+func (m *QuotasModule) prepare() ([]*corev1.ResourceQuota, []*corev1.LimitRange, error) {
+	var quotas []*corev1.ResourceQuota
+	var limitRanges []*corev1.LimitRange
+
+	for _, namespace := range m.namespaces() {
+		budget := m.Quotas[namespace]
+		labels := map[string]string{
+			"managed-by": "personal-server",
+		}
+
+		hard, err := resourceList(namespace, "hard", budget.Hard)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(hard) > 0 {
+			quotas = append(quotas, &corev1.ResourceQuota{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ResourceQuota",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      quotaName,
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: corev1.ResourceQuotaSpec{Hard: hard},
+			})
+		}
+
+		if !hasLimits(budget) {
+			continue
+		}
+		item := corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}
+		if item.Default, err = resourceList(namespace, "default", budget.Default); err != nil {
+			return nil, nil, err
+		}
+		if item.DefaultRequest, err = resourceList(namespace, "defaultRequest", budget.DefaultRequest); err != nil {
+			return nil, nil, err
+		}
+		if item.Max, err = resourceList(namespace, "max", budget.Max); err != nil {
+			return nil, nil, err
+		}
+		limitRanges = append(limitRanges, &corev1.LimitRange{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "LimitRange",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      limitRangeName,
+				Namespace: namespace,
+				Labels:    labels,
+			},
+			Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{item}},
+		})
+	}
+	for _, quota := range quotas {
+		k8s.SetObjectMetadata(m.Metadata, quota)
+	}
+	for _, limitRange := range limitRanges {
+		k8s.SetObjectMetadata(m.Metadata, limitRange)
+	}
+	return quotas, limitRanges, nil
+}
+
+// warnMissingDefaults warns about budgets that make the API server reject
+// pods: once a quota covers cpu or memory requests or limits, every container
+// must set them, and most modules' containers set none
+func (m *QuotasModule) warnMissingDefaults() {
+	for _, namespace := range m.namespaces() {
+		for _, missing := range missingDefaults(m.Quotas[namespace]) {
+			m.log.Warn("quotas.%s: hard sets %s but no default covers it; containers without their own %s are rejected\n", namespace, missing, missing)
+		}
+	}
+}
+
+// missingDefaults returns the compute quotas of budget that no LimitRange
+// default fills in for containers that do not set them
+func missingDefaults(budget config.NamespaceQuota) []string {
+	var missing []string
+	for _, res := range []string{"cpu", "memory"} {
+		_, hasDefault := budget.Default[res]
+		_, hasDefaultRequest := budget.DefaultRequest[res]
+		// A default limit doubles as the default request
+		if _, ok := budget.Hard["requests."+res]; ok && !hasDefault && !hasDefaultRequest {
+			missing = append(missing, "requests."+res)
+		}
+		if _, ok := budget.Hard[res]; ok && !hasDefault && !hasDefaultRequest {
+			missing = append(missing, res)
+		}
+		if _, ok := budget.Hard["limits."+res]; ok && !hasDefault {
+			missing = append(missing, "limits."+res)
+		}
+	}
+	return missing
+}
+
+// hasLimits reports whether budget needs a LimitRange
+func hasLimits(budget config.NamespaceQuota) bool {
+	return len(budget.Default) > 0 || len(budget.DefaultRequest) > 0 || len(budget.Max) > 0
+}
+
+// namespaces returns the configured namespaces in sorted order
+func (m *QuotasModule) namespaces() []string {
+	namespaces := make([]string, 0, len(m.Quotas))
+	for namespace := range m.Quotas {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// resourceList parses a name -> quantity map of the quotas.<namespace>.<field>
+// config into a ResourceList
+func resourceList(namespace, field string, values map[string]string) (corev1.ResourceList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	list := corev1.ResourceList{}
+	for name, value := range values {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quotas.%s.%s.%s %q: %w", namespace, field, name, value, err)
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+// percent returns used as a percentage of hard
+func percent(used, hard resource.Quantity) float64 {
+	if hard.IsZero() {
+		if used.IsZero() {
+			return 0
+		}
+		return 100
+	}
+	return used.AsApproximateFloat64() / hard.AsApproximateFloat64() * 100
+}
+
+// usage renders used against hard as a percentage with a ten-step bar
+func usage(used, hard resource.Quantity) string {
+	p := percent(used, hard)
+	filled := int(p / 10)
+	if filled > 10 {
+		filled = 10
+	}
+	return fmt.Sprintf("[%s%s] %3.0f%%", strings.Repeat("#", filled), strings.Repeat(".", 10-filled), p)
+}
@@ -0,0 +1,171 @@
+package quotas
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestQuotasModule_Prepare(t *testing.T) {
+	m := New(map[string]config.NamespaceQuota{
+		"ci": {
+			Hard:    map[string]string{"requests.cpu": "2", "limits.memory": "4Gi", "pods": "20"},
+			Default: map[string]string{"cpu": "500m", "memory": "512Mi"},
+			Max:     map[string]string{"memory": "2Gi"},
+		},
+		"infra": {
+			Hard: map[string]string{"requests.storage": "100Gi"},
+		},
+	}, logger.NewNopLogger())
+
+	quotas, limitRanges, err := m.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+
+	if len(quotas) != 2 || quotas[0].Namespace != "ci" || quotas[1].Namespace != "infra" {
+		t.Fatalf("quotas = %+v, want ci and infra in order", quotas)
+	}
+	hard := quotas[0].Spec.Hard
+	if got := hard[corev1.ResourceRequestsCPU]; got.Cmp(resource.MustParse("2")) != 0 {
+		t.Errorf("requests.cpu = %s, want 2", got.String())
+	}
+	if got := hard[corev1.ResourcePods]; got.Cmp(resource.MustParse("20")) != 0 {
+		t.Errorf("pods = %s, want 20", got.String())
+	}
+	if quotas[0].Name != quotaName || quotas[0].Labels["managed-by"] != "personal-server" {
+		t.Errorf("quota metadata = %+v", quotas[0].ObjectMeta)
+	}
+
+	// infra sets no container defaults, so it gets no LimitRange
+	if len(limitRanges) != 1 || limitRanges[0].Namespace != "ci" {
+		t.Fatalf("limitRanges = %+v, want only ci", limitRanges)
+	}
+	item := limitRanges[0].Spec.Limits[0]
+	if item.Type != corev1.LimitTypeContainer {
+		t.Errorf("Type = %s, want Container", item.Type)
+	}
+	if got := item.Default[corev1.ResourceMemory]; got.Cmp(resource.MustParse("512Mi")) != 0 {
+		t.Errorf("default memory = %s, want 512Mi", got.String())
+	}
+	if got := item.Max[corev1.ResourceMemory]; got.Cmp(resource.MustParse("2Gi")) != 0 {
+		t.Errorf("max memory = %s, want 2Gi", got.String())
+	}
+	if item.DefaultRequest != nil {
+		t.Errorf("DefaultRequest = %v, want nil", item.DefaultRequest)
+	}
+}
+
+func TestQuotasModule_PrepareInvalidQuantity(t *testing.T) {
+	m := New(map[string]config.NamespaceQuota{
+		"ci": {Hard: map[string]string{"limits.memory": "four gigs"}},
+	}, logger.NewNopLogger())
+
+	_, _, err := m.prepare()
+	if err == nil || !strings.Contains(err.Error(), "quotas.ci.hard.limits.memory") {
+		t.Errorf("prepare() error = %v, want the invalid key named", err)
+	}
+}
+
+func TestMissingDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget config.NamespaceQuota
+		want   []string
+	}{
+		{
+			name:   "no compute quota",
+			budget: config.NamespaceQuota{Hard: map[string]string{"pods": "10", "requests.storage": "50Gi"}},
+		},
+		{
+			name:   "compute quota without defaults",
+			budget: config.NamespaceQuota{Hard: map[string]string{"requests.cpu": "2", "limits.memory": "4Gi"}},
+			want:   []string{"requests.cpu", "limits.memory"},
+		},
+		{
+			name: "default limits cover requests",
+			budget: config.NamespaceQuota{
+				Hard:    map[string]string{"requests.cpu": "2", "limits.cpu": "4"},
+				Default: map[string]string{"cpu": "500m"},
+			},
+		},
+		{
+			name: "default requests do not cover limits",
+			budget: config.NamespaceQuota{
+				Hard:           map[string]string{"requests.memory": "2Gi", "limits.memory": "4Gi"},
+				DefaultRequest: map[string]string{"memory": "128Mi"},
+			},
+			want: []string{"limits.memory"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingDefaults(tt.budget); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingDefaults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotasModule_StatusWithClient(t *testing.T) {
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: quotaName, Namespace: "ci"},
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse("2"),
+			corev1.ResourcePods:        resource.MustParse("10"),
+		}},
+		Status: corev1.ResourceQuotaStatus{Used: corev1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse("1900m"),
+			corev1.ResourcePods:        resource.MustParse("3"),
+		}},
+	}
+
+	var out bytes.Buffer
+	m := New(map[string]config.NamespaceQuota{
+		"ci":    {Hard: map[string]string{"requests.cpu": "2", "pods": "10"}},
+		"infra": {Hard: map[string]string{"pods": "50"}},
+	}, logger.NewStdLogger(&out))
+
+	if err := m.statusWithClient(context.Background(), fake.NewSimpleClientset(quota)); err != nil {
+		t.Fatalf("statusWithClient() error = %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"NAMESPACE: ci",
+		"[###.......]  30%",
+		"[#########.]  95%",
+		"ResourceQuota 'personal-server-quota' not found in namespace infra",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("status output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	tests := []struct {
+		used, hard string
+		want       string
+	}{
+		{"0", "10", "[..........]   0%"},
+		{"512Mi", "1Gi", "[#####.....]  50%"},
+		{"3", "2", "[##########] 150%"},
+		{"1", "0", "[##########] 100%"},
+	}
+	for _, tt := range tests {
+		if got := usage(resource.MustParse(tt.used), resource.MustParse(tt.hard)); got != tt.want {
+			t.Errorf("usage(%s, %s) = %q, want %q", tt.used, tt.hard, got, tt.want)
+		}
+	}
+}
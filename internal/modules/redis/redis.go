@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,14 +12,26 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/servicetls"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "64Mi", MemoryLimit: "256Mi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "5Gi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "redis:7.2-alpine"
+
 type RedisModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -39,10 +50,28 @@ func (m *RedisModule) Name() string {
 	return "redis"
 }
 
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	RedisPassword string `yaml:"redis_password" required:"true" generate:"true" doc:"Password for Redis authentication"`
+	TLS           string `yaml:"tls" doc:"Serve TLS instead of plain TCP on port 6379 with a certificate that is self-signed (by the CA in certs/service-ca) or from cert-manager (default: off)"`
+	TLSIssuer     string `yaml:"tls_issuer" doc:"cert-manager issuer with tls: cert-manager, as <name> for an Issuer or ClusterIssuer/<name>"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *RedisModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *RedisModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: redis\n\n")
 	m.log.Info("Description:\n  Deploys Redis — an in-memory data structure store used as a cache and message broker.\n  Manages a Secret, PersistentVolumeClaim, Service, and Deployment.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  redis_password   Password for Redis authentication\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  tls              self-signed or cert-manager to serve TLS on port 6379 instead of plain TCP (default: off)\n  tls_issuer       cert-manager issuer with tls: cert-manager (<name> or ClusterIssuer/<name>)\n\n")
+	m.log.Info("Optional module fields:\n  image   Container image (default: %s)\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/redis/\n  apply      Create/update resources in the cluster\n  clean      Delete all Redis resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive the Redis data volume to the destination directory\n  restore    Restore the Redis data volume from a backup archive\n")
 	return nil
 }
@@ -64,6 +93,10 @@ func (m *RedisModule) Generate(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to prepare resources: %w", err)
 	}
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -102,14 +135,31 @@ func (m *RedisModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 4
+
+	// Write the self-signed certificate Secret or the cert-manager Certificate
+	if tlsServer != nil {
+		tlsObject, err := tlsServer.Object()
+		if err != nil {
+			return err
+		}
+		name := "tls-secret"
+		if tlsServer.Mode == servicetls.ModeCertManager {
+			name = "certificate"
+		}
+		if err := writeYAML(tlsObject, name); err != nil {
+			return err
+		}
+		total++
+	}
 
-	m.log.Info("\nCompleted: 4/4 Redis configurations generated successfully\n")
+	m.log.Info("\nCompleted: %d/%d Redis configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *RedisModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -154,6 +204,10 @@ func (m *RedisModule) Apply(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to prepare resources: %w", err)
 	}
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Apply Secret
 	m.log.Progress("Applying Secret: redis-secrets\n")
@@ -179,6 +233,19 @@ func (m *RedisModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Service: redis\n")
 
+	// Apply the certificate before the Deployment that mounts it
+	if tlsServer != nil {
+		m.log.Progress("Applying TLS certificate: %s (%s)\n", tlsSecretName, tlsServer.Mode)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := tlsServer.Apply(ctx, clientset, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied TLS certificate: %s\n", tlsSecretName)
+	}
+
 	// Apply Deployment
 	m.log.Progress("Applying Deployment: redis\n")
 	_, err = clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
@@ -215,6 +282,11 @@ func (m *RedisModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 		},
 	}
 
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
 	// Prepare PVC
 	pvc := &corev1.PersistentVolumeClaim{
 		TypeMeta: metav1.TypeMeta{
@@ -233,11 +305,12 @@ func (m *RedisModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse("5Gi"),
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -268,6 +341,7 @@ func (m *RedisModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
 	replicas := int32(1)
@@ -301,7 +375,7 @@ func (m *RedisModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 					Containers: []corev1.Container{
 						{
 							Name:            "redis",
-							Image:           "redis:7.2-alpine",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Ports: []corev1.ContainerPort{
 								{
@@ -386,12 +460,49 @@ func (m *RedisModule) prepare() (*corev1.Secret, *corev1.PersistentVolumeClaim,
 		},
 	}
 
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if tlsServer != nil {
+		useTLS(&deployment.Spec.Template.Spec, tlsServer)
+	}
+
+	// SHUTDOWN SAVE writes the dataset to /data/dump.rdb and stops redis, so a
+	// node drain does not lose the writes since the last snapshot
+	cli := "redis-cli"
+	if tlsServer != nil {
+		cli += " " + strings.Join(tlsCLIFlags, " ")
+	}
+	if redisPassword != "" {
+		cli = `REDISCLI_AUTH="$REDIS_PASSWORD" ` + cli
+	}
+	k8s.SetGracefulShutdown(&deployment.Spec.Template.Spec, 60, cli+" shutdown save")
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	// The alpine image has no tzdata
+	k8s.MountZoneinfo(&deployment.Spec.Template.Spec, m.GeneralConfig.Timezone)
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, pvc, service, deployment)
 	return secret, pvc, service, deployment, nil
 }
 
 func (m *RedisModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -461,6 +572,21 @@ func (m *RedisModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the TLS certificate when tls is configured
+	if tlsServer, err := m.tlsServer(); err == nil && tlsServer != nil {
+		m.log.Info("🗑️  Processing TLS certificate: %s\n", tlsSecretName)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err == nil {
+			err = tlsServer.Delete(ctx, clientset, dyn)
+		}
+		if err != nil {
+			m.log.Error("Failed to delete TLS certificate: %v\n", err)
+		} else {
+			m.log.Success("Deleted TLS certificate: %s\n", tlsSecretName)
+			successCount++
+		}
+	}
+
 	m.log.Info("\nCompleted: %d Redis resources deleted successfully\n", successCount)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -470,7 +596,7 @@ func (m *RedisModule) Clean(ctx context.Context) error {
 
 func (m *RedisModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -558,6 +684,11 @@ func (m *RedisModule) Status(ctx context.Context) error {
 		m.log.Println()
 	}
 
+	// Check TLS certificate
+	if tlsServer, err := m.tlsServer(); err == nil && tlsServer != nil {
+		m.printTLSStatus(ctx, clientset, tlsServer)
+	}
+
 	// Check Pods
 	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: "app=redis",
@@ -588,21 +719,30 @@ func (m *RedisModule) Status(ctx context.Context) error {
 	return nil
 }
 
-// getKubectlCommand returns the kubectl command and args array for the environment
-func (m *RedisModule) getKubectlCommand() (string, []string) {
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		return "/snap/bin/microk8s", []string{"/snap/bin/microk8s", "kubectl"}
+// findPod returns the name of the redis pod exec commands run in
+func (m *RedisModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=redis",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=redis")
 	}
-	return "kubectl", []string{"kubectl"}
+	return pods.Items[0].Name, nil
 }
 
 func (m *RedisModule) Backup(ctx context.Context, destDir string) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
 
+func (m *RedisModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
 	var backupDir string
 	if destDir != "" {
@@ -619,42 +759,37 @@ func (m *RedisModule) Backup(ctx context.Context, destDir string) error {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=redis",
-	})
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=redis")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	// 1. Trigger Redis SAVE command to ensure data is persisted to disk
 	m.log.Info("💾 Triggering Redis SAVE...\n")
-	kubectlCmd, kubectlArgs := m.getKubectlCommand()
 
 	redisPassword := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "redis_password", "")
-	var saveCmd *exec.Cmd
+	tlsServer, err := m.tlsServer()
+	if err != nil {
+		return err
+	}
+	cli := "redis-cli"
+	if tlsServer != nil {
+		cli += " " + strings.Join(tlsCLIFlags, " ")
+	}
+	saveCmd := append(strings.Fields(cli), "SAVE")
 	if redisPassword != "" {
 		// Use REDISCLI_AUTH environment variable to pass password securely.
 		// The password is set as an env var within the pod's shell context (not as a command arg),
 		// so it won't appear in the host's process list - only "sh -c" is visible externally.
 		// This is the most secure approach as kubectl exec doesn't support --env flag.
-		shellCmd := fmt.Sprintf("REDISCLI_AUTH='%s' redis-cli SAVE", redisPassword)
-		args := append(kubectlArgs[1:], "exec", "-n", m.ModuleConfig.Namespace, podName, "--", "sh", "-c", shellCmd)
-		saveCmd = exec.CommandContext(ctx, kubectlArgs[0], args...)
-	} else {
-		args := append(kubectlArgs[1:], "exec", "-n", m.ModuleConfig.Namespace, podName, "--", "redis-cli", "SAVE")
-		saveCmd = exec.CommandContext(ctx, kubectlArgs[0], args...)
+		saveCmd = []string{"sh", "-c", fmt.Sprintf("REDISCLI_AUTH='%s' %s SAVE", redisPassword, cli)}
 	}
 
-	if err := saveCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{Namespace: m.ModuleConfig.Namespace, Pod: podName, Command: saveCmd}); err != nil {
 		m.log.Warn("Warning: Failed to trigger SAVE command: %v\n", err)
 	} else {
-		m.log.Success("✅ Redis SAVE completed\n")
+		m.log.Success("Redis SAVE completed\n")
 	}
 
 	// 2. Backup data volume
@@ -662,20 +797,20 @@ func (m *RedisModule) Backup(ctx context.Context, destDir string) error {
 
 	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("redis_data_%s.tar.gz", timestamp))
 
-	// Execute tar command in pod and stream to file
-	args := append(kubectlArgs[1:], "exec", "-n", m.ModuleConfig.Namespace, podName, "--", "tar", "czf", "-", "/data")
-	cmd := exec.CommandContext(ctx, kubectlCmd, args...)
-
 	outFile, err := os.Create(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to create data backup file: %w", err)
 	}
 	defer outFile.Close()
 
-	cmd.Stdout = outFile
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	// Execute tar command in pod and stream to file
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "/data"},
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to archive data: %w", err)
 	}
 
@@ -683,7 +818,7 @@ func (m *RedisModule) Backup(ctx context.Context, destDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to stat data backup file: %w", err)
 	}
-	m.log.Success("✅ Data archived (%d bytes)\n", fileInfo.Size())
+	m.log.Success("Data archived (%d bytes)\n", fileInfo.Size())
 
 	// 3. Metadata
 	m.log.Info("📋 Writing metadata...\n")
@@ -706,7 +841,7 @@ personal-server redis restore %s
 	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
-	m.log.Success("✅ Metadata written\n")
+	m.log.Success("Metadata written\n")
 
 	m.log.Success("🎉 Backup complete!\n")
 	m.log.Info("💡 To restore: personal-server redis restore %s\n", timestamp)
@@ -766,67 +901,65 @@ func (m *RedisModule) Restore(ctx context.Context, args []string) error {
 	m.log.Info("💾 Data will be restored from %s\n", dataBackupFile)
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	if err := m.restoreDataWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dataBackupFile); err != nil {
+		return err
+	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=redis",
-	})
-	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+	// Restart deployment
+	m.log.Info("🔄 Restarting deployment 'redis'...\n")
+
+	if err := k8s.RestartDeployment(ctx, clientset, m.ModuleConfig.Namespace, "redis", time.Now()); err != nil {
+		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
+	} else {
+		m.log.Success("Deployment restarted successfully\n")
 	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=redis")
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreDataWithClient replaces /data in the redis pod with the contents of
+// a tar archive written by Backup
+func (m *RedisModule) restoreDataWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dataBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	// Restore data
 	m.log.Info("💾 Restoring data...\n")
 
-	_, kubectlArgs := m.getKubectlCommand()
-
 	// 1. Clean existing data using find command for robust deletion
-	cleanArgs := append(kubectlArgs[1:], "exec", "-n", m.ModuleConfig.Namespace, podName, "--", "sh", "-c", "find /data -mindepth 1 -delete")
-	cleanCmd := exec.CommandContext(ctx, kubectlArgs[0], cleanArgs...)
-	if err := cleanCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", "find /data -mindepth 1 -delete"},
+	}); err != nil {
 		m.log.Warn("Warning during clean: %v\n", err)
 	}
 
 	// 2. Restore from tar
-	restoreArgs := append(kubectlArgs[1:], "exec", "-i", "-n", m.ModuleConfig.Namespace, podName, "--", "tar", "xzf", "-", "-C", "/")
-	restoreCmd := exec.CommandContext(ctx, kubectlArgs[0], restoreArgs...)
-
 	inFile, err := os.Open(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to open data backup file: %w", err)
 	}
 	defer inFile.Close()
 
-	restoreCmd.Stdin = inFile
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", "/"},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore data: %w", err)
 	}
-	m.log.Success("✅ Data restored\n")
-
-	// Restart deployment
-	m.log.Info("🔄 Restarting deployment 'redis'...\n")
-
-	restartArgs := append(kubectlArgs[1:], "rollout", "restart", "deployment/redis", "-n", m.ModuleConfig.Namespace)
-	restartCmd := exec.CommandContext(ctx, kubectlArgs[0], restartArgs...)
-
-	if err := restartCmd.Run(); err != nil {
-		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
-	} else {
-		m.log.Success("✅ Deployment restarted successfully\n")
-	}
-
-	m.log.Success("🎉 Restore complete!\n")
+	m.log.Success("Data restored\n")
 	return nil
 }
@@ -5,10 +5,15 @@ import (
 	_ "embed"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestRedisModule_Name(t *testing.T) {
@@ -159,3 +164,127 @@ func TestRedisModule_Generate(t *testing.T) {
 		}
 	}
 }
+
+func TestRedisModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "redis-5c6b", Namespace: "infra", Labels: map[string]string{"app": "redis"}}}
+	save := func(password string, tls bool) []string {
+		cli := "redis-cli"
+		if tls {
+			cli = "redis-cli --tls --insecure"
+		}
+		if password == "" {
+			return append(strings.Fields(cli), "SAVE")
+		}
+		return []string{"sh", "-c", "REDISCLI_AUTH='" + password + "' " + cli + " SAVE"}
+	}
+
+	tests := []struct {
+		name      string
+		password  string
+		tls       bool
+		saveError string
+	}{
+		{name: "without password"},
+		{name: "with password", password: "hunter2"},
+		{name: "with password over TLS", password: "hunter2", tls: true},
+		{name: "without password over TLS", tls: true},
+		{name: "failed SAVE still archives", saveError: "exit status 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &RedisModule{
+				ModuleConfig: config.Module{Name: "redis", Namespace: "infra", Secrets: map[string]string{"redis_password": tt.password}},
+				log:          logger.NewNopLogger(),
+			}
+			if tt.password == "" {
+				delete(module.ModuleConfig.Secrets, "redis_password")
+			}
+			if tt.tls {
+				module.ModuleConfig.Secrets["tls"] = "self-signed"
+			}
+			executor := k8s.NewReplayExecutor(
+				k8s.ExecRecord{Namespace: "infra", Pod: "redis-5c6b", Command: save(tt.password, tt.tls), Error: tt.saveError},
+				k8s.ExecRecord{Namespace: "infra", Pod: "redis-5c6b", Command: []string{"tar", "czf", "-", "/data"}, Stdout: "archive"},
+			)
+
+			destDir := t.TempDir()
+			if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+				t.Fatalf("backupWithClient() error = %v", err)
+			}
+			if err := executor.Verify(); err != nil {
+				t.Error(err)
+			}
+
+			archives, _ := filepath.Glob(filepath.Join(destDir, "redis", "redis_data_*.tar.gz"))
+			if len(archives) != 1 {
+				t.Fatalf("expected one archive, got %v", archives)
+			}
+			if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+				t.Errorf("archive = %q, want the tar output", data)
+			}
+		})
+	}
+}
+
+func TestRedisModule_RestoreDataWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "redis-5c6b", Namespace: "infra", Labels: map[string]string{"app": "redis"}}}
+	archive := filepath.Join(t.TempDir(), "redis_data.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &RedisModule{ModuleConfig: config.Module{Name: "redis", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "redis-5c6b", Command: []string{"sh", "-c", "find /data -mindepth 1 -delete"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "redis-5c6b", Command: []string{"tar", "xzf", "-", "-C", "/"}, Stdin: "archive"},
+	)
+
+	if err := module.restoreDataWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreDataWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRedisModule_PrepareTLS(t *testing.T) {
+	module := &RedisModule{
+		ModuleConfig: config.Module{Name: "redis", Namespace: "infra", Secrets: map[string]string{
+			"redis_password": "secret",
+			"tls":            "self-signed",
+		}},
+		log: logger.NewNopLogger(),
+	}
+
+	_, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	podSpec := deployment.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 1 || podSpec.InitContainers[0].Image != "redis:7.2-alpine" {
+		t.Fatalf("InitContainers = %+v, want tls-certs running the redis image", podSpec.InitContainers)
+	}
+	container := podSpec.Containers[0]
+	if got := strings.Join(container.Args, " "); !strings.Contains(got, "--port 0 --tls-port 6379 --tls-cert-file /etc/ssl/personal-server/tls.crt") {
+		t.Errorf("Args = %s, want the TLS port replacing the plain one", got)
+	}
+	want := []string{"redis-cli", "--tls", "--insecure", "-a", "$(REDIS_PASSWORD)", "ping"}
+	for name, probe := range map[string]*corev1.Probe{"liveness": container.LivenessProbe, "readiness": container.ReadinessProbe} {
+		if got := probe.Exec.Command; strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s probe = %v, want %v", name, got, want)
+		}
+	}
+	preStop := `REDISCLI_AUTH="$REDIS_PASSWORD" redis-cli --tls --insecure shutdown save`
+	if container.Lifecycle == nil || strings.Join(container.Lifecycle.PreStop.Exec.Command, " ") != "sh -c "+preStop {
+		t.Errorf("Lifecycle = %+v, want a %s pre-stop hook", container.Lifecycle, preStop)
+	}
+
+	server, err := module.tlsServer()
+	if err != nil {
+		t.Fatalf("tlsServer() error = %v", err)
+	}
+	if hosts := strings.Join(server.Hosts, ","); !strings.Contains(hosts, "redis.infra.svc.cluster.local,localhost,127.0.0.1") {
+		t.Errorf("Hosts = %s, want the Service names and loopback", hosts)
+	}
+}
@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/servicetls"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const tlsSecretName = "redis-tls"
+
+// tlsCLIFlags make redis-cli inside the pod talk TLS to the local server. The
+// pod only has the certificate and key, not ca.crt, and the connection never
+// leaves the loopback interface, so the certificate is not verified.
+var tlsCLIFlags = []string{"--tls", "--insecure"}
+
+// tlsServer returns the certificate served by redis, or nil when tls is not
+// configured
+func (m *RedisModule) tlsServer() (*servicetls.Server, error) {
+	hosts := append(servicetls.ServiceHosts("redis", m.ModuleConfig.Namespace), "localhost", "127.0.0.1")
+	server, err := servicetls.Config(m.ModuleConfig.Secrets, tlsSecretName, m.ModuleConfig.Namespace, hosts)
+	if server != nil {
+		server.Metadata = m.ModuleConfig.ObjectMetadata(m.GeneralConfig)
+	}
+	return server, err
+}
+
+// useTLS mounts the certificate into the redis pod and replaces the plain
+// port with a TLS one on the same number, so clients must switch to rediss://.
+// Client certificates are not requested; clients still authenticate with the
+// password.
+func useTLS(spec *corev1.PodSpec, server *servicetls.Server) {
+	container := &spec.Containers[0]
+	server.Mount(spec, container.Image, "redis:redis")
+	container.Args = append(container.Args,
+		"--port", "0",
+		"--tls-port", "6379",
+		"--tls-cert-file", servicetls.Dir+"/"+servicetls.CertKey,
+		"--tls-key-file", servicetls.Dir+"/"+servicetls.KeyKey,
+		"--tls-auth-clients", "no",
+	)
+	for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe} {
+		command := probe.Exec.Command
+		probe.Exec.Command = append(append([]string{command[0]}, tlsCLIFlags...), command[1:]...)
+	}
+}
+
+// printTLSStatus reports the TLS mode and when the served certificate expires
+func (m *RedisModule) printTLSStatus(ctx context.Context, client k8s.KubernetesClient, server *servicetls.Server) {
+	m.log.Println("TLS:")
+	m.log.Info("  Mode:            %s\n", server.Mode)
+	secret, err := client.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("  Secret '%s' not found\n", tlsSecretName)
+		} else {
+			m.log.Error("  Error getting Secret: %v\n", err)
+		}
+		m.log.Println()
+		return
+	}
+	expiry, err := servicetls.Expiry(secret)
+	if err != nil {
+		m.log.Error("  Error: %v\n", err)
+		m.log.Println()
+		return
+	}
+	m.log.Info("  Expires:         %s (in %s)\n", expiry.Format("2006-01-02"), k8s.FormatAge(time.Until(expiry).Round(time.Hour)))
+	m.log.Println()
+}
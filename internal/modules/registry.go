@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
 )
 
@@ -29,6 +30,7 @@ type Registry struct {
 	// requiresModuleConfig tracks which modules need module-specific config
 	requiresModuleConfig map[string]bool
 	logger               logger.Logger
+	clients              *k8s.Clients
 }
 
 // NewRegistry creates a new module registry with a logger
@@ -43,6 +45,22 @@ func NewRegistry(log logger.Logger) *Registry {
 	}
 }
 
+// WithLogger returns a copy of the registry whose modules log to log. The
+// copy shares the registered factories.
+func (r *Registry) WithLogger(log logger.Logger) *Registry {
+	clone := *r
+	clone.logger = log
+	return &clone
+}
+
+// WithClients returns a copy of the registry whose modules use clients to
+// reach the cluster. The copy shares the registered factories.
+func (r *Registry) WithClients(clients *k8s.Clients) *Registry {
+	clone := *r
+	clone.clients = clients
+	return &clone
+}
+
 // Register adds a module factory that requires module config
 func (r *Registry) Register(name string, factory ModuleFactory) {
 	r.factories[name] = factory
@@ -89,11 +107,20 @@ func (r *Registry) findFactory(name string) (ModuleFactory, string, bool) {
 	return nil, "", false
 }
 
+// Kind returns the registered module name a configured module name resolves
+// to, e.g. "postgres" for "postgres-infra".
+func (r *Registry) Kind(name string) (string, bool) {
+	_, key, ok := r.findFactory(name)
+	return key, ok
+}
+
 // Get creates a module by name
 func (r *Registry) Get(name string, cfg *config.Config) (Module, error) {
 	// Check config-level factories first (they receive the full config)
 	if factory, ok := r.configFactories[name]; ok {
-		return factory(cfg, r.logger), nil
+		withClients := *cfg
+		withClients.General.Clients = r.clients
+		return factory(&withClients, r.logger), nil
 	}
 
 	factory, factoryKey, ok := r.findFactory(name)
@@ -118,7 +145,54 @@ func (r *Registry) Get(name string, cfg *config.Config) (Module, error) {
 		}
 	}
 
-	return factory(cfg.General, modCfg, r.logger), nil
+	module := factory(r.general(cfg), modCfg, r.logger)
+	if err := checkServiceMonitor(module, cfg, name); err != nil {
+		return nil, err
+	}
+	return module, nil
+}
+
+// checkServiceMonitor rejects metrics.serviceMonitor on a module that would
+// silently create no ServiceMonitor
+func checkServiceMonitor(module Module, cfg *config.Config, name string) error {
+	modCfg, err := cfg.GetModule(name)
+	if err != nil || modCfg.Metrics == nil || !modCfg.Metrics.ServiceMonitor {
+		return nil
+	}
+	if provider, ok := module.(ServiceMonitorProvider); ok && provider.SupportsServiceMonitor() {
+		return nil
+	}
+	return fmt.Errorf("module %s does not support metrics.serviceMonitor; remove it to use the scrape annotations", name)
+}
+
+// general returns cfg.General with the registry's clients, and with Endpoints
+// and TLSCASecrets filled in from every configured module implementing
+// EndpointProvider and TLSProvider, keyed by module kind. When several modules of one kind are configured, the first
+// one wins.
+func (r *Registry) general(cfg *config.Config) config.GeneralConfig {
+	general := cfg.General
+	general.Clients = r.clients
+	general.Endpoints = make(map[string]string)
+	general.TLSCASecrets = make(map[string]string)
+	for _, m := range cfg.Modules {
+		factory, kind, ok := r.findFactory(m.Name)
+		if !ok {
+			continue
+		}
+		if _, seen := general.Endpoints[kind]; seen {
+			continue
+		}
+		module := factory(cfg.General, m, logger.NewNopLogger())
+		if provider, ok := module.(EndpointProvider); ok {
+			general.Endpoints[kind] = provider.Endpoint()
+		}
+		if provider, ok := module.(TLSProvider); ok {
+			if secret := provider.TLSCASecret(); secret != "" {
+				general.TLSCASecrets[kind] = secret
+			}
+		}
+	}
+	return general
 }
 
 // GetPetProject creates a pet project module by name
@@ -140,12 +214,12 @@ func (r *Registry) GetPetProject(name string, cfg *config.Config) (Module, error
 
 	// Check if there's a specific factory registered for this pet project
 	if factory, ok := r.petProjectFactories[name]; ok {
-		return factory(cfg.General, projectCfg, r.logger), nil
+		return factory(r.general(cfg), projectCfg, r.logger), nil
 	}
 
 	// Use the default pet project factory if no specific factory is registered
 	if defaultFactory, ok := r.petProjectFactories["_default"]; ok {
-		return defaultFactory(cfg.General, projectCfg, r.logger), nil
+		return defaultFactory(r.general(cfg), projectCfg, r.logger), nil
 	}
 
 	return nil, fmt.Errorf("no pet project factory registered")
@@ -161,12 +235,12 @@ func (r *Registry) GetIngress(name string, cfg *config.Config) (Module, error) {
 
 	// Check if there's a specific factory registered for this ingress
 	if factory, ok := r.ingressFactories[name]; ok {
-		return factory(cfg.General, ingressCfg, r.logger), nil
+		return factory(r.general(cfg), ingressCfg, r.logger), nil
 	}
 
 	// Use the default ingress factory if no specific factory is registered
 	if defaultFactory, ok := r.ingressFactories["_default"]; ok {
-		return defaultFactory(cfg.General, ingressCfg, r.logger), nil
+		return defaultFactory(r.general(cfg), ingressCfg, r.logger), nil
 	}
 
 	return nil, fmt.Errorf("no ingress factory registered")
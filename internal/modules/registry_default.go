@@ -3,13 +3,26 @@ package modules
 import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/modules/alertmanager"
 	"github.com/Goalt/personal-server/internal/modules/bitwarden"
+	"github.com/Goalt/personal-server/internal/modules/certmanager"
 	"github.com/Goalt/personal-server/internal/modules/cloudflare"
+	"github.com/Goalt/personal-server/internal/modules/cronjob"
+	"github.com/Goalt/personal-server/internal/modules/crowdsec"
+	"github.com/Goalt/personal-server/internal/modules/customapp"
+	"github.com/Goalt/personal-server/internal/modules/ddns"
 	"github.com/Goalt/personal-server/internal/modules/drone"
 	"github.com/Goalt/personal-server/internal/modules/gitea"
+	"github.com/Goalt/personal-server/internal/modules/gotify"
 	"github.com/Goalt/personal-server/internal/modules/grafana"
+	"github.com/Goalt/personal-server/internal/modules/headscale"
+	"github.com/Goalt/personal-server/internal/modules/hedgedoc"
 	"github.com/Goalt/personal-server/internal/modules/hobbypod"
 	"github.com/Goalt/personal-server/internal/modules/ingress"
+	"github.com/Goalt/personal-server/internal/modules/ingressnginx"
+	"github.com/Goalt/personal-server/internal/modules/jupyter"
+	"github.com/Goalt/personal-server/internal/modules/mariadb"
+	"github.com/Goalt/personal-server/internal/modules/mealie"
 	"github.com/Goalt/personal-server/internal/modules/monitoring"
 	"github.com/Goalt/personal-server/internal/modules/namespace"
 	"github.com/Goalt/personal-server/internal/modules/openclaw"
@@ -18,9 +31,16 @@ import (
 	"github.com/Goalt/personal-server/internal/modules/postgres"
 	"github.com/Goalt/personal-server/internal/modules/postgresexporter"
 	"github.com/Goalt/personal-server/internal/modules/prometheus"
+	"github.com/Goalt/personal-server/internal/modules/quotas"
 	"github.com/Goalt/personal-server/internal/modules/redis"
 	"github.com/Goalt/personal-server/internal/modules/registrysecret"
+	"github.com/Goalt/personal-server/internal/modules/shlink"
+	"github.com/Goalt/personal-server/internal/modules/smtp"
 	"github.com/Goalt/personal-server/internal/modules/sshlogin"
+	"github.com/Goalt/personal-server/internal/modules/staticsite"
+	"github.com/Goalt/personal-server/internal/modules/synapse"
+	"github.com/Goalt/personal-server/internal/modules/tor"
+	"github.com/Goalt/personal-server/internal/modules/verdaccio"
 	"github.com/Goalt/personal-server/internal/modules/webdav"
 	"github.com/Goalt/personal-server/internal/modules/workpod"
 )
@@ -38,6 +58,9 @@ func DefaultRegistry(log logger.Logger) *Registry {
 	r.Register("cloudflare", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
 		return cloudflare.New(g, m, log)
 	})
+	r.Register("ddns", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return ddns.New(g, m, log)
+	})
 	r.Register("bitwarden", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
 		return bitwarden.New(g, m, log)
 	})
@@ -71,18 +94,72 @@ func DefaultRegistry(log logger.Logger) *Registry {
 	r.Register("pgadmin", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
 		return pgadmin.New(g, m, log)
 	})
+	r.Register("mariadb", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return mariadb.New(g, m, log)
+	})
 	r.Register("redis", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
 		return redis.New(g, m, log)
 	})
 	r.Register("prometheus", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
 		return prometheus.New(g, m, log)
 	})
+	r.Register("alertmanager", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return alertmanager.New(g, m, log)
+	})
 	r.Register("ssh-login-notifier", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
 		return sshlogin.New(g, m, log)
 	})
 	r.Register("openclaw", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
 		return openclaw.New(g, m, log)
 	})
+	r.Register("verdaccio", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return verdaccio.New(g, m, log)
+	})
+	r.Register("synapse", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return synapse.New(g, m, log)
+	})
+	r.Register("staticsite", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return staticsite.New(g, m, log)
+	})
+	r.Register("customapp", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return customapp.New(g, m, log)
+	})
+	r.Register("cronjob", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return cronjob.New(g, m, log)
+	})
+	r.Register("hedgedoc", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return hedgedoc.New(g, m, log)
+	})
+	r.Register("mealie", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return mealie.New(g, m, log)
+	})
+	r.Register("shlink", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return shlink.New(g, m, log)
+	})
+	r.Register("tor", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return tor.New(g, m, log)
+	})
+	r.Register("smtp", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return smtp.New(g, m, log)
+	})
+	r.Register("headscale", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return headscale.New(g, m, log)
+	})
+	r.Register("jupyter", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return jupyter.New(g, m, log)
+	})
+	r.Register("crowdsec", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return crowdsec.New(g, m, log)
+	})
+	r.Register("gotify", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return gotify.New(g, m, log)
+	})
+	r.Register("certmanager", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return certmanager.New(g, m, log)
+	})
+	r.Register("ingress-nginx", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return ingressnginx.New(g, m, log)
+	})
 
 	// Register default pet project factory
 	r.RegisterPetProject("_default", func(g config.GeneralConfig, p config.PetProject, log logger.Logger) Module {
@@ -96,7 +173,18 @@ func DefaultRegistry(log logger.Logger) *Registry {
 
 	// Register registry secrets command (receives the full config)
 	r.RegisterConfigModule("registry", func(cfg *config.Config, log logger.Logger) Module {
-		return registrysecret.New(cfg.Registries, log)
+		m := registrysecret.New(cfg.Registries, log)
+		m.Metadata = cfg.General.ObjectMetadata()
+		m.Clients = cfg.General.Clients
+		return m
+	})
+
+	// Register namespace quotas command (receives the full config)
+	r.RegisterConfigModule("quotas", func(cfg *config.Config, log logger.Logger) Module {
+		m := quotas.New(cfg.Quotas, log)
+		m.Metadata = cfg.General.ObjectMetadata()
+		m.Clients = cfg.General.Clients
+		return m
 	})
 
 	return r
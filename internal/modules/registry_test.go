@@ -0,0 +1,137 @@
+package modules
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/logger"
+)
+
+type endpointTestModule struct {
+	name     string
+	endpoint string
+	general  config.GeneralConfig
+}
+
+func (m endpointTestModule) Name() string                   { return m.name }
+func (m endpointTestModule) Doc(context.Context) error      { return nil }
+func (m endpointTestModule) Generate(context.Context) error { return nil }
+func (m endpointTestModule) Apply(context.Context) error    { return nil }
+func (m endpointTestModule) Clean(context.Context) error    { return nil }
+func (m endpointTestModule) Status(context.Context) error   { return nil }
+
+type endpointProviderTestModule struct {
+	endpointTestModule
+	caSecret string
+}
+
+func (m endpointProviderTestModule) Endpoint() string    { return m.endpoint }
+func (m endpointProviderTestModule) TLSCASecret() string { return m.caSecret }
+
+func TestRegistryGetFillsEndpoints(t *testing.T) {
+	registry := NewRegistry(logger.NewNopLogger())
+	registry.Register("postgres", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return endpointProviderTestModule{endpointTestModule: endpointTestModule{name: "postgres", endpoint: m.Secrets["host"]}}
+	})
+	registry.Register("gitea", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return endpointTestModule{name: "gitea", general: g}
+	})
+
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "gitea", Namespace: "infra"},
+		{Name: "postgres-infra", Namespace: "infra", Secrets: map[string]string{"host": "pgbouncer.infra:6432"}},
+		{Name: "postgres-other", Namespace: "other", Secrets: map[string]string{"host": "postgres.other:5432"}},
+	}}
+
+	module, err := registry.Get("gitea", cfg)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	general := module.(endpointTestModule).general
+	if got := general.Endpoint("postgres", "postgres:5432"); got != "pgbouncer.infra:6432" {
+		t.Errorf("Endpoint(postgres) = %s, want pgbouncer.infra:6432", got)
+	}
+	if got := general.Endpoint("redis", "redis:6379"); got != "redis:6379" {
+		t.Errorf("Endpoint(redis) = %s, want fallback redis:6379", got)
+	}
+	if cfg.General.Endpoints != nil {
+		t.Error("Get() should not modify the loaded config")
+	}
+	if got := general.TLSCASecret("postgres"); got != "" {
+		t.Errorf("TLSCASecret(postgres) = %q, want none for a plain endpoint", got)
+	}
+}
+
+func TestRegistryGetFillsTLSCASecrets(t *testing.T) {
+	registry := NewRegistry(logger.NewNopLogger())
+	registry.Register("postgres", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return endpointProviderTestModule{endpointTestModule: endpointTestModule{name: "postgres"}, caSecret: m.Secrets["ca_secret"]}
+	})
+	registry.Register("gitea", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return endpointTestModule{name: "gitea", general: g}
+	})
+
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "gitea", Namespace: "infra"},
+		{Name: "postgres", Namespace: "infra", Secrets: map[string]string{"ca_secret": "postgres-tls"}},
+	}}
+
+	module, err := registry.Get("gitea", cfg)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if got := module.(endpointTestModule).general.TLSCASecret("postgres"); got != "postgres-tls" {
+		t.Errorf("TLSCASecret(postgres) = %q, want postgres-tls", got)
+	}
+}
+
+type serviceMonitorTestModule struct {
+	endpointTestModule
+}
+
+func (m serviceMonitorTestModule) SupportsServiceMonitor() bool { return true }
+
+func TestRegistryGetRejectsUnsupportedServiceMonitor(t *testing.T) {
+	registry := NewRegistry(logger.NewNopLogger())
+	registry.Register("postgres", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return endpointTestModule{name: "postgres"}
+	})
+	registry.Register("gitea", func(g config.GeneralConfig, m config.Module, log logger.Logger) Module {
+		return serviceMonitorTestModule{endpointTestModule{name: "gitea"}}
+	})
+
+	monitored := &config.ModuleMetrics{ServiceMonitor: true}
+	cfg := &config.Config{Modules: []config.Module{
+		{Name: "gitea", Namespace: "infra", Metrics: monitored},
+		{Name: "postgres-infra", Namespace: "infra", Metrics: monitored},
+		{Name: "postgres-annotated", Namespace: "infra", Metrics: &config.ModuleMetrics{}},
+	}}
+
+	if _, err := registry.Get("gitea", cfg); err != nil {
+		t.Errorf("Get(gitea) error: %v", err)
+	}
+	if _, err := registry.Get("postgres-annotated", cfg); err != nil {
+		t.Errorf("Get(postgres-annotated) error: %v", err)
+	}
+	_, err := registry.Get("postgres-infra", cfg)
+	if err == nil || !strings.Contains(err.Error(), "does not support metrics.serviceMonitor") {
+		t.Errorf("Get(postgres-infra) error = %v, want serviceMonitor rejected", err)
+	}
+}
+
+type shellTestModule struct {
+	endpointTestModule
+}
+
+func (m shellTestModule) Shell() (string, []string) { return "sidecar", []string{"bash"} }
+
+func TestShellCommand(t *testing.T) {
+	if container, command := ShellCommand(endpointTestModule{name: "redis"}); container != "" || len(command) != 1 || command[0] != "sh" {
+		t.Errorf("ShellCommand() = %q %q, want sh in the default container", container, command)
+	}
+	if container, command := ShellCommand(shellTestModule{endpointTestModule{name: "webdav"}}); container != "sidecar" || len(command) != 1 || command[0] != "bash" {
+		t.Errorf("ShellCommand() = %q %q, want the module's shell", container, command)
+	}
+}
@@ -21,7 +21,12 @@ import (
 // top-level registries defined in the configuration.
 type RegistrySecretModule struct {
 	Registries map[string]config.RegistryCredentials
-	log        logger.Logger
+	// Metadata holds the general labels and annotations stamped on the
+	// secrets
+	Metadata k8s.ObjectMetadata
+	// Clients reach the cluster
+	Clients *k8s.Clients
+	log     logger.Logger
 }
 
 // New creates a new RegistrySecretModule.
@@ -36,6 +41,16 @@ func (m *RegistrySecretModule) Name() string {
 	return "registry"
 }
 
+// DeploymentName is empty: the module only creates image pull Secrets
+func (m *RegistrySecretModule) DeploymentName() string {
+	return ""
+}
+
+// AppLabel is empty because the module runs no pods
+func (m *RegistrySecretModule) AppLabel() string {
+	return ""
+}
+
 func (m *RegistrySecretModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: registry\n\n")
 	m.log.Info("Description:\n  Creates Kubernetes docker-registry Secrets for each registry entry defined\n  in the top-level registries section of the configuration.\n  These secrets are referenced by pet-project Deployments to pull private images.\n\n")
@@ -94,7 +109,7 @@ func (m *RegistrySecretModule) Apply(ctx context.Context) error {
 		return nil
 	}
 
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -134,7 +149,7 @@ func (m *RegistrySecretModule) Clean(ctx context.Context) error {
 		return nil
 	}
 
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -177,7 +192,7 @@ func (m *RegistrySecretModule) Status(ctx context.Context) error {
 		return nil
 	}
 
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -249,5 +264,6 @@ func (m *RegistrySecretModule) prepareSecret(name string, creds config.RegistryC
 		},
 	}
 
+	k8s.SetObjectMetadata(m.Metadata, secret)
 	return secret, nil
 }
@@ -0,0 +1,104 @@
+package shlink
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const apiKeyUsage = "usage: personal-server shlink api-key <NAME> [--expires 2006-01-02]"
+
+// generatedKey matches the key in the output of shlink api-key:generate
+var generatedKey = regexp.MustCompile(`Generated API key: "([^"]+)"`)
+
+// apiKeyOptions are the parsed arguments of shlink api-key
+type apiKeyOptions struct {
+	name    string
+	expires string // date the key stops working, empty for never
+}
+
+func parseAPIKeyArgs(args []string) (apiKeyOptions, error) {
+	var opts apiKeyOptions
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--expires":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--expires requires a value\n%s", apiKeyUsage)
+			}
+			opts.expires = args[i+1]
+			i++
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		return opts, fmt.Errorf(apiKeyUsage)
+	}
+	opts.name = positional[0]
+	if opts.expires != "" {
+		if _, err := time.Parse("2006-01-02", opts.expires); err != nil {
+			return opts, fmt.Errorf("invalid --expires %q: use a date like 2006-01-02", opts.expires)
+		}
+	}
+	return opts, nil
+}
+
+// APIKey generates a key for Shlink's REST API, used by the web client and
+// other integrations, and prints it
+func (m *ShlinkModule) APIKey(ctx context.Context, args []string) error {
+	opts, err := parseAPIKeyArgs(args)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.apiKeyWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), opts)
+}
+
+func (m *ShlinkModule) apiKeyWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, opts apiKeyOptions) error {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=shlink",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no running pod found for app=shlink")
+	}
+	podName := pods.Items[0].Name
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	// The name is passed as a single argument, never through a shell
+	command := []string{"shlink", "api-key:generate", "--no-interaction", "--name=" + opts.name}
+	if opts.expires != "" {
+		command = append(command, "--expiration-date="+opts.expires)
+	}
+
+	m.log.Progress("Generating API key '%s'...\n", opts.name)
+	output, err := k8s.ExecCombinedOutput(ctx, executor, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   command,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate API key: %s\nOutput: %s", err, strings.TrimSpace(string(output)))
+	}
+	match := generatedKey.FindSubmatch(output)
+	if match == nil {
+		return fmt.Errorf("unexpected output from shlink api-key:generate: %s", strings.TrimSpace(string(output)))
+	}
+	m.log.Success("Generated API key '%s'\n", opts.name)
+
+	m.log.Info("API key: %s\n", match[1])
+	m.log.Info("Server URL: https://%s\n", m.domain())
+	return nil
+}
@@ -0,0 +1,265 @@
+package shlink
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestModule(secrets map[string]string) *ShlinkModule {
+	base := map[string]string{
+		"shlink_db_password": "db-password",
+	}
+	for k, v := range secrets {
+		base[k] = v
+	}
+	return &ShlinkModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig:  config.Module{Name: "shlink", Namespace: "test-namespace", Secrets: base},
+		log:           logger.NewNopLogger(),
+	}
+}
+
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestShlinkModule_Name(t *testing.T) {
+	module := &ShlinkModule{}
+	if module.Name() != "shlink" {
+		t.Errorf("Name() = %s, want shlink", module.Name())
+	}
+}
+
+func TestShlinkModule_Dependencies(t *testing.T) {
+	deps := (&ShlinkModule{}).Dependencies()
+	if len(deps) != 1 || deps[0] != "postgres" {
+		t.Errorf("Dependencies() = %v, want [postgres]", deps)
+	}
+}
+
+func TestShlinkModule_Doc(t *testing.T) {
+	module := &ShlinkModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestShlinkModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name       string
+		general    config.GeneralConfig
+		secrets    map[string]string
+		wantDomain string
+		wantHost   string
+		wantPort   string
+		wantGeo    bool
+		wantTLS    bool
+		wantErr    bool
+	}{
+		{
+			name:       "defaults",
+			wantDomain: "shlink.example.com", wantHost: "postgres", wantPort: "5432",
+		},
+		{
+			name:       "domain, GeoLite key and postgres module with TLS",
+			general:    config.GeneralConfig{Endpoints: map[string]string{"postgres": "postgres.infra.svc.cluster.local:5432"}, TLSCASecrets: map[string]string{"postgres": "postgres-tls"}},
+			secrets:    map[string]string{"domain": "go.example.com", "geolite_license_key": "maxmind-key"},
+			wantDomain: "go.example.com", wantHost: "postgres.infra.svc.cluster.local", wantPort: "5432",
+			wantGeo: true, wantTLS: true,
+		},
+		{
+			name:       "database_host overrides the endpoint",
+			general:    config.GeneralConfig{Endpoints: map[string]string{"postgres": "db.internal:5433"}, TLSCASecrets: map[string]string{"postgres": "postgres-tls"}},
+			secrets:    map[string]string{"database_host": "pg.example.com:6432"},
+			wantDomain: "shlink.example.com", wantHost: "pg.example.com", wantPort: "6432",
+		},
+		{
+			name:    "database_host without a port",
+			secrets: map[string]string{"database_host": "pg.example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := newTestModule(tt.secrets)
+			module.GeneralConfig.Endpoints = tt.general.Endpoints
+			module.GeneralConfig.TLSCASecrets = tt.general.TLSCASecrets
+
+			secret, service, deployment, err := module.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if service.Spec.Selector["app"] != "shlink" {
+				t.Errorf("Service selector = %v, want app=shlink", service.Spec.Selector)
+			}
+			env := deployment.Spec.Template.Spec.Containers[0].Env
+			if got := envValue(env, "DEFAULT_DOMAIN"); got != tt.wantDomain {
+				t.Errorf("DEFAULT_DOMAIN = %s, want %s", got, tt.wantDomain)
+			}
+			if envValue(env, "DB_DRIVER") != "postgres" || envValue(env, "DB_HOST") != tt.wantHost || envValue(env, "DB_PORT") != tt.wantPort {
+				t.Errorf("database env = %v, want %s:%s", env, tt.wantHost, tt.wantPort)
+			}
+			if string(secret.Data["DB_PASSWORD"]) != "db-password" {
+				t.Errorf("DB_PASSWORD = %q, want the configured password", secret.Data["DB_PASSWORD"])
+			}
+			if _, ok := secret.Data["GEOLITE_LICENSE_KEY"]; ok != tt.wantGeo {
+				t.Errorf("GEOLITE_LICENSE_KEY set = %v, want %v", ok, tt.wantGeo)
+			}
+			if got := envValue(env, "PGSSLMODE") == "verify-full"; got != tt.wantTLS {
+				t.Errorf("verify-full = %v, want %v", got, tt.wantTLS)
+			}
+		})
+	}
+}
+
+func TestShlinkModule_PrepareMissingPassword(t *testing.T) {
+	module := newTestModule(nil)
+	delete(module.ModuleConfig.Secrets, "shlink_db_password")
+	if _, _, _, err := module.prepare(); err == nil {
+		t.Error("prepare() without shlink_db_password succeeded, want error")
+	}
+}
+
+func TestParseAPIKeyArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    apiKeyOptions
+		wantErr bool
+	}{
+		{args: []string{"web-client"}, want: apiKeyOptions{name: "web-client"}},
+		{args: []string{"ci", "--expires", "2030-01-31"}, want: apiKeyOptions{name: "ci", expires: "2030-01-31"}},
+		{args: nil, wantErr: true},
+		{args: []string{"a", "b"}, wantErr: true},
+		{args: []string{"ci", "--expires"}, wantErr: true},
+		{args: []string{"ci", "--expires", "next year"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAPIKeyArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAPIKeyArgs(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseAPIKeyArgs(%v) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestShlinkModule_APIKeyWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "shlink-6f7b", Namespace: "infra", Labels: map[string]string{"app": "shlink"}}}
+	module := &ShlinkModule{GeneralConfig: config.GeneralConfig{Domain: "example.com"}, ModuleConfig: config.Module{Name: "shlink", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{
+			Namespace: "infra",
+			Pod:       "shlink-6f7b",
+			Command:   []string{"shlink", "api-key:generate", "--no-interaction", "--name=web client; rm -rf /", "--expiration-date=2030-01-31"},
+			Stdout:    "\n [OK] Generated API key: \"3c8f4f1e-9b0a-4d2e-a1f7-6b2c9d0e5a11\"\n\n",
+		},
+	)
+
+	err := module.apiKeyWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, apiKeyOptions{name: "web client; rm -rf /", expires: "2030-01-31"})
+	if err != nil {
+		t.Fatalf("apiKeyWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShlinkModule_APIKeyUnexpectedOutput(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "shlink-6f7b", Namespace: "infra", Labels: map[string]string{"app": "shlink"}}}
+	module := &ShlinkModule{ModuleConfig: config.Module{Name: "shlink", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "shlink-6f7b", Command: []string{"shlink", "api-key:generate", "--no-interaction", "--name=ci"}, Stdout: "Database not ready"},
+	)
+	err := module.apiKeyWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, apiKeyOptions{name: "ci"})
+	if err == nil || !strings.Contains(err.Error(), "Database not ready") {
+		t.Errorf("apiKeyWithClient() error = %v, want the command output", err)
+	}
+	if err := module.apiKeyWithClient(context.Background(), fake.NewSimpleClientset(), k8s.NewReplayExecutor(), apiKeyOptions{name: "ci"}); err == nil {
+		t.Error("apiKeyWithClient() without a pod succeeded, want error")
+	}
+}
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := &ShlinkModule{
+		GeneralConfig: config.GeneralConfig{
+			Domain: "example.com",
+		},
+		ModuleConfig: config.Module{
+			Name:      "shlink",
+			Namespace: "infra",
+			Secrets: map[string]string{
+				"shlink_db_password":  "password",
+				"geolite_license_key": "license",
+			},
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"secret", "configs/shlink/secret.yaml", expectedSecretYAML},
+		{"service", "configs/shlink/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/shlink/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package smtp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+const (
+	dkimUsage = "usage: personal-server smtp dkim-key [--out FILE]"
+	// dkimKeyBits is the key size DKIM verifiers expect; 1024-bit keys are
+	// rejected by some of them
+	dkimKeyBits    = 2048
+	defaultDKIMOut = "dkim.pem"
+)
+
+func parseDKIMArgs(args []string) (string, error) {
+	out := defaultDKIMOut
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--out":
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("--out requires a value\n%s", dkimUsage)
+			}
+			out = args[i+1]
+			i++
+		default:
+			return "", fmt.Errorf("unexpected argument %q\n%s", args[i], dkimUsage)
+		}
+	}
+	return out, nil
+}
+
+// generateDKIMKey returns a new RSA private key as PKCS#1 PEM, the format
+// opendkim-genkey writes
+func generateDKIMKey() ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, dkimKeyBits)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+}
+
+// parseDKIMKey returns the public half of a PKCS#1 or PKCS#8 RSA private key
+func parseDKIMKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return &key.PublicKey, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("not an RSA private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA private key")
+	}
+	return &rsaKey.PublicKey, nil
+}
+
+// dkimRecord returns the TXT record value publishing pub
+func dkimRecord(pub *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der), nil
+}
+
+// DKIMKey prints the DNS records publishing the configured DKIM key. Without
+// one it first creates a key in a local file for dkim_private_key to point at.
+func (m *SMTPModule) DKIMKey(ctx context.Context, args []string) error {
+	out, err := parseDKIMArgs(args)
+	if err != nil {
+		return err
+	}
+	domains, err := m.senderDomains()
+	if err != nil {
+		return err
+	}
+
+	keyPEM := []byte(m.ModuleConfig.Secrets["dkim_private_key"])
+	if len(keyPEM) == 0 {
+		keyPEM, err = generateDKIMKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate DKIM key: %w", err)
+		}
+		// Never overwrite a key whose public half may already be published
+		f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", out, err)
+		}
+		if _, err := f.Write(keyPEM); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write %s: %w", out, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", out, err)
+		}
+		m.log.Success("Generated DKIM key: %s\n", out)
+		m.log.Info("Reference it from the smtp module and apply again:\n\n  dkim_private_key: file:%s\n\n", out)
+	}
+
+	pub, err := parseDKIMKey(keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid dkim_private_key: %w", err)
+	}
+	record, err := dkimRecord(pub)
+	if err != nil {
+		return err
+	}
+
+	m.log.Info("Publish these TXT records:\n\n")
+	for _, domain := range domains {
+		m.log.Info("%s._domainkey.%s  TXT  \"%s\"\n", m.dkimSelector(), domain, record)
+	}
+	return nil
+}
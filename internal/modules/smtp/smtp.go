@@ -0,0 +1,563 @@
+package smtp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage        = "boky/postfix:v4.3.0"
+	defaultDKIMSelector = "mail"
+	containerPort       = 587
+	// dkimKeysPath is where the image's opendkim looks for <domain>.private
+	dkimKeysPath = "/etc/opendkim/keys"
+	// dkimSecretKey holds the DKIM private key in smtp-secrets
+	dkimSecretKey = "dkim.private"
+)
+
+var domainPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "128Mi"}
+
+type SMTPModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *SMTPModule {
+	return &SMTPModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *SMTPModule) Name() string {
+	return "smtp"
+}
+
+// Endpoint returns the host:port modules such as gitea and bitwarden send
+// email through. The relay accepts mail from the cluster without a login.
+func (m *SMTPModule) Endpoint() string {
+	return fmt.Sprintf("smtp.%s.svc.cluster.local:%d", m.ModuleConfig.Namespace, containerPort)
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	RelayHost      string `yaml:"relay_host" doc:"Upstream SMTP server as host:port, e.g. smtp.sendgrid.net:587 (default: deliver directly to the recipients' MX)"`
+	RelayUsername  string `yaml:"relay_username" doc:"Login at relay_host"`
+	RelayPassword  string `yaml:"relay_password" doc:"Password at relay_host"`
+	SenderDomains  string `yaml:"sender_domains" doc:"Comma-separated domains the relay sends mail from (default: general.domain)"`
+	DKIMPrivateKey string `yaml:"dkim_private_key" doc:"PEM RSA key signing outgoing mail, e.g. file:./dkim.pem from smtp dkim-key"`
+	DKIMSelector   string `yaml:"dkim_selector" default:"mail" doc:"DKIM selector the public key is published under"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *SMTPModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *SMTPModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: smtp\n\n")
+	m.log.Info("Description:\n  Deploys a Postfix relay other modules send email through.\n  Manages a Secret, Service, and Deployment.\n  Gitea and Bitwarden send through it when it is configured. Mail from the cluster\n  is accepted without a login and forwarded to relay_host, DKIM-signed when a key is set.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  (none)\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  relay_host         Upstream SMTP server as host:port (default: direct delivery)\n  relay_username     Login at relay_host\n  relay_password     Password at relay_host\n  sender_domains     Comma-separated sender domains (default: general.domain)\n  dkim_private_key   PEM RSA key signing outgoing mail, e.g. file:./dkim.pem\n  dkim_selector      DKIM selector (default: %s)\n  image              Container image (modules[].image, default: %s)\n\n", defaultDKIMSelector, defaultImage)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/smtp/\n  apply      Create/update resources in the cluster\n  clean      Delete all SMTP resources from the cluster\n  status     Print Deployment and Pod status\n  dkim-key   Create a DKIM key and print its DNS records: dkim-key [--out FILE]\n  doc        Show this documentation\n")
+	return nil
+}
+
+// senderDomains parses the sender_domains key
+func (m *SMTPModule) senderDomains() ([]string, error) {
+	var domains []string
+	for _, domain := range strings.Split(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "sender_domains", m.GeneralConfig.Domain), ",") {
+		domain = strings.TrimSpace(domain)
+		if domain == "" {
+			continue
+		}
+		if !domainPattern.MatchString(domain) {
+			return nil, fmt.Errorf("invalid sender domain %q", domain)
+		}
+		domains = append(domains, domain)
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("sender_domains not found in configuration and general.domain is not set")
+	}
+	return domains, nil
+}
+
+func (m *SMTPModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "smtp")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating SMTP Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	secret, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+
+	m.log.Info("\nCompleted: 3/3 SMTP configurations generated successfully\n")
+	return nil
+}
+
+func (m *SMTPModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying SMTP Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	secret, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, secret.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'", secret.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", service.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: %s\n", secret.Name)
+
+	if _, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", service.Name)
+
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", deployment.Name)
+
+	m.log.Info("\nCompleted: SMTP configurations applied successfully\n")
+	if _, ok := m.ModuleConfig.Secrets["dkim_private_key"]; !ok {
+		m.log.Info("💡 Sign outgoing mail with DKIM: personal-server smtp dkim-key\n")
+	}
+	return nil
+}
+
+// prepare creates and returns the Kubernetes objects for the smtp module
+func (m *SMTPModule) prepare() (*corev1.Secret, *corev1.Service, *appsv1.Deployment, error) {
+	domains, err := m.senderDomains()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "ALLOWED_SENDER_DOMAINS", Value: strings.Join(domains, " ")},
+		{Name: "POSTFIX_myhostname", Value: "smtp." + domains[0]},
+	}
+	secretData := map[string][]byte{}
+
+	if relayHost := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "relay_host", ""); relayHost != "" {
+		host, port, err := net.SplitHostPort(relayHost)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid relay_host: %w", err)
+		}
+		if _, err := strconv.Atoi(port); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid relay_host port %q", port)
+		}
+		// Brackets skip the MX lookup of the relay's host name
+		env = append(env, corev1.EnvVar{Name: "RELAYHOST", Value: fmt.Sprintf("[%s]:%s", host, port)})
+		if username := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "relay_username", ""); username != "" {
+			env = append(env, corev1.EnvVar{Name: "RELAYHOST_USERNAME", Value: username})
+		}
+		if password := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "relay_password", ""); password != "" {
+			secretData["RELAYHOST_PASSWORD"] = []byte(password)
+			env = append(env, corev1.EnvVar{
+				Name: "RELAYHOST_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "smtp-secrets"},
+						Key:                  "RELAYHOST_PASSWORD",
+					},
+				},
+			})
+		}
+	}
+
+	dkimKey := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "dkim_private_key", "")
+	if dkimKey != "" {
+		if _, err := parseDKIMKey([]byte(dkimKey)); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid dkim_private_key: %w", err)
+		}
+		secretData[dkimSecretKey] = []byte(dkimKey)
+		env = append(env, corev1.EnvVar{Name: "DKIM_SELECTOR", Value: m.dkimSelector()})
+	}
+
+	labels := map[string]string{
+		"app":        "smtp",
+		"managed-by": "personal-server",
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "smtp-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: secretData,
+	}
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "smtp",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "submission",
+					Port:       containerPort,
+					TargetPort: intstr.FromInt(containerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "smtp",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "smtp",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "smtp",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "smtp",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "smtp",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "submission",
+									ContainerPort: containerPort,
+								},
+							},
+							Env: env,
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromInt(containerPort),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// The image's opendkim signs mail from each domain with the key in
+	// <domain>.private, so the one key is mounted under every sender domain
+	if dkimKey != "" {
+		items := make([]corev1.KeyToPath, 0, len(domains))
+		for _, domain := range domains {
+			items = append(items, corev1.KeyToPath{Key: dkimSecretKey, Path: domain + ".private"})
+		}
+		spec := &deployment.Spec.Template.Spec
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: "dkim-keys",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "smtp-secrets",
+					Items:      items,
+				},
+			},
+		})
+		spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      "dkim-keys",
+			MountPath: dkimKeysPath,
+			ReadOnly:  true,
+		})
+	}
+
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, secret); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), secret, service, deployment)
+	return secret, service, deployment, nil
+}
+
+// dkimSelector returns the selector the DKIM public key is published under
+func (m *SMTPModule) dkimSelector() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "dkim_selector", defaultDKIMSelector)
+}
+
+func (m *SMTPModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning SMTP Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Processing Deployment: smtp\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "smtp", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'smtp' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: smtp\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Service: smtp\n")
+	if err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Delete(ctx, "smtp", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service 'smtp' not found\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: smtp\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing Secret: smtp-secrets\n")
+	if err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Delete(ctx, "smtp-secrets", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'smtp-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: smtp-secrets\n")
+		successCount++
+	}
+
+	m.log.Info("\nCompleted: %d SMTP resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+		m.log.Println("Mail still queued in the relay is lost.")
+	}
+	return nil
+}
+
+func (m *SMTPModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking SMTP resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "smtp", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'smtp' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	service, err := clientset.CoreV1().Services(m.ModuleConfig.Namespace).Get(ctx, "smtp", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service 'smtp' not found\n")
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Info("  Endpoint:        %s\n", m.Endpoint())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	secret, err := clientset.CoreV1().Secrets(m.ModuleConfig.Namespace).Get(ctx, "smtp-secrets", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Secret 'smtp-secrets' not found\n")
+		} else {
+			m.log.Error("Error getting Secret: %v\n", err)
+		}
+	} else {
+		age := time.Since(secret.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SECRET:\n")
+		m.log.Info("  Name:            %s\n", secret.Name)
+		m.log.Info("  Type:            %s\n", secret.Type)
+		m.log.Info("  Data keys:       %d\n", len(secret.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=smtp",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No SMTP pods found")
+	}
+	return nil
+}
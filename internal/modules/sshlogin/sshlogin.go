@@ -1,18 +1,15 @@
 package sshlogin
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/sentry"
 )
 
 const (
@@ -38,6 +35,26 @@ func (m *SSHLoginModule) Name() string {
 	return "ssh-login-notifier"
 }
 
+// DeploymentName is empty: the notifier runs on the host, not in the cluster
+func (m *SSHLoginModule) DeploymentName() string {
+	return ""
+}
+
+// AppLabel is empty because the module runs no pods
+func (m *SSHLoginModule) AppLabel() string {
+	return ""
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	SentryDSN string `yaml:"sentry_dsn" required:"true" doc:"Sentry DSN URL for SSH login event reporting"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *SSHLoginModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *SSHLoginModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: ssh-login-notifier\n\n")
 	m.log.Info("Description:\n  Installs an SSH login notification script on the host system.\n  When a user logs in via SSH, the script sends an alert to Sentry.\n  Unlike other modules, this does not manage Kubernetes resources —\n  it writes a shell hook to ~/.ssh/rc and installs the personal-server binary.\n\n")
@@ -276,95 +293,10 @@ echo "$(date '+%%Y-%%m-%%d %%H:%%M:%%S') - SSH login: $USER from $IP" >> /var/lo
 }
 
 func (m *SSHLoginModule) sendSentryEvent(dsn, message string, extra map[string]interface{}) error {
-	// Parse DSN to extract components
-	// DSN format: https://{key}@{org}.ingest.sentry.io/{project}
-
-	// Remove https:// prefix
-	if !strings.HasPrefix(dsn, "https://") {
-		return fmt.Errorf("invalid Sentry DSN: must start with https://")
-	}
-	dsn = strings.TrimPrefix(dsn, "https://")
-
-	// Split by @ to get key and rest
-	parts := strings.SplitN(dsn, "@", 2)
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid Sentry DSN format: missing @ separator")
-	}
-	sentryKey := parts[0]
-
-	// Split the rest by / to get host and project
-	hostAndProject := strings.SplitN(parts[1], "/", 2)
-	if len(hostAndProject) != 2 {
-		return fmt.Errorf("invalid Sentry DSN format: missing project ID")
-	}
-	sentryHost := hostAndProject[0]
-	sentryProject := hostAndProject[1]
-
-	// Get hostname
-	hostname, _ := os.Hostname()
-
-	// Generate proper UUID for event_id (32-char hex, no dashes)
-	eventID := strings.Replace(fmt.Sprintf("%032x", time.Now().UnixNano()), "-", "", -1)
-	if len(eventID) > 32 {
-		eventID = eventID[:32]
-	}
-
-	// Create event with Sentry v7 API format
-	now := time.Now().UTC()
-	event := map[string]interface{}{
-		"event_id":    eventID,
-		"timestamp":   now.Unix(), // Unix timestamp
-		"platform":    "other",
-		"level":       "info",
-		"logger":      "ssh-login",
-		"message":     message,
-		"server_name": hostname,
-		"tags":        map[string]string{"event_type": "test"},
-		"extra":       extra,
-	}
-
-	// Marshal to JSON
-	eventJSON, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
-	}
-
-	// Send to Sentry using envelope format
-	// Envelope format: {headers}\n{item_header}\n{payload}
-	envelopeHeaders := map[string]interface{}{
-		"event_id": eventID,
-		"sent_at":  now.Format(time.RFC3339),
-	}
-	envelopeHeadersJSON, _ := json.Marshal(envelopeHeaders)
-
-	itemHeader := map[string]interface{}{
-		"type": "event",
-	}
-	itemHeaderJSON, _ := json.Marshal(itemHeader)
-
-	// Build envelope: line1=envelope headers, line2=item header, line3=event
-	envelope := fmt.Sprintf("%s\n%s\n%s", string(envelopeHeadersJSON), string(itemHeaderJSON), string(eventJSON))
-
-	// Send to Sentry envelope endpoint
-	apiURL := fmt.Sprintf("https://%s/api/%s/envelope/", sentryHost, sentryProject)
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBufferString(envelope))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/x-sentry-envelope")
-	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=ssh-login-notifier/1.0", sentryKey))
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("Sentry API returned status %d", resp.StatusCode)
-	}
-
-	return nil
+	return sentry.SendEvent(context.Background(), dsn, sentry.Event{
+		Message: message,
+		Logger:  "ssh-login",
+		Tags:    map[string]string{"event_type": "test"},
+		Extra:   extra,
+	})
 }
@@ -0,0 +1,1060 @@
+package staticsite
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage       = "nginx:1.25-alpine"
+	defaultStorageSize = "1Gi"
+	htmlPath           = "/usr/share/nginx/html"
+	// uploadStagingPath is where uploads are extracted before they replace
+	// the content, outside htmlPath so nginx never serves a partial upload
+	uploadStagingPath = "/tmp/staticsite-upload"
+
+	sourcePVC       = "pvc"
+	sourceConfigMap = "configmap"
+
+	// maxConfigMapContent leaves headroom below the 1MiB object size limit
+	maxConfigMapContent = 900 * 1024
+)
+
+// nginxConf serves the site root with SPA-friendly fallbacks and caching
+const nginxConf = `server {
+    listen 80;
+    server_name _;
+    root /usr/share/nginx/html;
+    index index.html;
+
+    gzip on;
+    gzip_types text/plain text/css application/javascript application/json image/svg+xml;
+
+    location / {
+        try_files $uri $uri/ $uri.html =404;
+    }
+
+    location ~* \.(css|js|png|jpg|jpeg|gif|svg|ico|webp|woff2?)$ {
+        expires 7d;
+        add_header Cache-Control "public";
+    }
+
+    location = /healthz {
+        access_log off;
+        return 200 "ok";
+    }
+}
+`
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "16Mi", MemoryLimit: "64Mi"}
+
+type StaticSiteModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *StaticSiteModule {
+	return &StaticSiteModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+// Name returns the configured module name so several sites can be deployed
+// as staticsite-<suffix>
+func (m *StaticSiteModule) Name() string {
+	if m.ModuleConfig.Name == "" {
+		return "staticsite"
+	}
+	return m.ModuleConfig.Name
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *StaticSiteModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	Source     string `yaml:"source" default:"pvc" doc:"Where content is stored: pvc (synced with upload) or configmap"`
+	ContentDir string `yaml:"content_dir" doc:"Local directory loaded into the ConfigMap on generate/apply (configmap source only)"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *StaticSiteModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *StaticSiteModule) source() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "source", sourcePVC)
+}
+
+func (m *StaticSiteModule) nginxConfigName() string   { return m.Name() + "-nginx" }
+func (m *StaticSiteModule) contentConfigName() string { return m.Name() + "-content" }
+func (m *StaticSiteModule) contentPVCName() string    { return m.Name() + "-content-pvc" }
+
+func (m *StaticSiteModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: %s (staticsite)\n\n", m.Name())
+	m.log.Info("Description:\n  Deploys nginx serving a static website, e.g. a personal homepage.\n  Content lives on a PersistentVolumeClaim (default) or in a ConfigMap.\n  Manages a ConfigMap (nginx.conf), the content volume, Service, and Deployment.\n  Multiple sites can be deployed using the 'staticsite-<suffix>' naming convention.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  (none — no secrets required)\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  source         pvc or configmap (default: pvc)\n  content_dir    Local directory loaded into the ConfigMap on generate/apply (configmap source only)\n  storage        Size of the content volume (modules[].storage, default: %s, pvc source only)\n\n", defaultStorageSize)
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
+	m.log.Info("ConfigMap source:\n  Only top-level files are supported and the total size must stay below 900KiB.\n  Use the pvc source for nested directories or larger sites.\n\n")
+	m.log.Info("Subcommands:\n  generate       Write Kubernetes YAML to configs/%s/\n  apply          Create/update resources in the cluster\n  clean          Delete all site resources from the cluster\n  status         Print Deployment and Pod status\n  doc            Show this documentation\n  upload <dir>   Replace the site content with the files in <dir>\n", m.Name())
+	return nil
+}
+
+func (m *StaticSiteModule) Generate(ctx context.Context) error {
+	// Define output directory
+	outputDir := filepath.Join("configs", m.Name())
+
+	// Check and create output directory if it doesn't exist
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating static site Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	// Prepare Kubernetes objects
+	res, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	// Helper function to write object to YAML file
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(res.nginxConfig, "configmap"); err != nil {
+		return err
+	}
+	if res.content != nil {
+		if err := writeYAML(res.content, "content"); err != nil {
+			return err
+		}
+	}
+	if res.pvc != nil {
+		if err := writeYAML(res.pvc, "pvc"); err != nil {
+			return err
+		}
+	}
+	if err := writeYAML(res.service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(res.deployment, "deployment"); err != nil {
+		return err
+	}
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, res.service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, res.service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("\nCompleted: static site configurations generated successfully\n")
+	return nil
+}
+
+func (m *StaticSiteModule) Apply(ctx context.Context) error {
+	// Prepare Kubernetes objects first so invalid settings fail fast
+	res, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	name := m.Name()
+	m.log.Info("Applying static site Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	// Check if resources already exist
+	m.log.Info("Checking for existing resources...\n")
+	_, err = clientset.CoreV1().ConfigMaps(ns).Get(ctx, m.nginxConfigName(), metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("configmap '%s' already exists in namespace '%s'", m.nginxConfigName(), ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check configmap existence: %w", err)
+	}
+
+	if res.content != nil {
+		_, err = clientset.CoreV1().ConfigMaps(ns).Get(ctx, m.contentConfigName(), metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("configmap '%s' already exists in namespace '%s'", m.contentConfigName(), ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check configmap existence: %w", err)
+		}
+	}
+
+	if res.pvc != nil {
+		_, err = clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, m.contentPVCName(), metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", m.contentPVCName(), ns)
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+		}
+	}
+
+	_, err = clientset.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("service '%s' already exists in namespace '%s'", name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+
+	_, err = clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", name, ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	// Apply nginx ConfigMap
+	m.log.Progress("Applying ConfigMap: %s\n", m.nginxConfigName())
+	if _, err := clientset.CoreV1().ConfigMaps(ns).Create(ctx, res.nginxConfig, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create configmap: %w", err)
+	}
+	m.log.Success("Created ConfigMap: %s\n", m.nginxConfigName())
+
+	// Apply content ConfigMap or PVC
+	if res.content != nil {
+		m.log.Progress("Applying ConfigMap: %s\n", m.contentConfigName())
+		if _, err := clientset.CoreV1().ConfigMaps(ns).Create(ctx, res.content, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create content configmap: %w", err)
+		}
+		m.log.Success("Created ConfigMap: %s\n", m.contentConfigName())
+	}
+	if res.pvc != nil {
+		m.log.Progress("Applying PersistentVolumeClaim: %s\n", m.contentPVCName())
+		if _, err := clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, res.pvc, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+		}
+		m.log.Success("Created PersistentVolumeClaim: %s\n", m.contentPVCName())
+	}
+
+	// Apply Service
+	m.log.Progress("Applying Service: %s\n", name)
+	if _, err := clientset.CoreV1().Services(ns).Create(ctx, res.service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: %s\n", name)
+
+	// Apply Deployment
+	m.log.Progress("Applying Deployment: %s\n", name)
+	if _, err := clientset.AppsV1().Deployments(ns).Create(ctx, res.deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", name)
+
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, res.service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, res.service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: static site configurations applied successfully\n")
+	if res.pvc != nil {
+		m.log.Info("💡 Upload your site: personal-server %s upload ./public\n", name)
+	}
+	return nil
+}
+
+// resources holds the objects managed by the module. Exactly one of content
+// and pvc is set, depending on the source.
+type resources struct {
+	nginxConfig *corev1.ConfigMap
+	content     *corev1.ConfigMap
+	pvc         *corev1.PersistentVolumeClaim
+	service     *corev1.Service
+	deployment  *appsv1.Deployment
+}
+
+// prepare creates and returns the Kubernetes objects for the static site
+func (m *StaticSiteModule) prepare() (*resources, error) {
+	name := m.Name()
+	ns := m.ModuleConfig.Namespace
+	labels := map[string]string{
+		"app":        name,
+		"managed-by": "personal-server",
+	}
+
+	image := m.ModuleConfig.ContainerImage(defaultImage)
+
+	res := &resources{}
+
+	// Prepare nginx ConfigMap
+	res.nginxConfig = &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.nginxConfigName(),
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"default.conf": nginxConf,
+		},
+	}
+
+	// Prepare the content volume
+	var contentVolume corev1.VolumeSource
+	switch m.source() {
+	case sourcePVC:
+		storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+		if err != nil {
+			return nil, err
+		}
+		res.pvc = &corev1.PersistentVolumeClaim{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.contentPVCName(),
+				Namespace: ns,
+				Labels:    labels,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: storageSize,
+					},
+				},
+			},
+		}
+		k8s.SetStorageClass(&res.pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+		contentVolume = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: m.contentPVCName(),
+			},
+		}
+	case sourceConfigMap:
+		content, err := m.contentConfigMap(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "content_dir", ""))
+		if err != nil {
+			return nil, err
+		}
+		res.content = content
+		contentVolume = corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: m.contentConfigName(),
+				},
+			},
+		}
+	default:
+		return nil, fmt.Errorf("invalid source %q: must be %q or %q", m.source(), sourcePVC, sourceConfigMap)
+	}
+
+	// Prepare Service
+	res.service = &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromInt(80),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": name,
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(res.service, m.GeneralConfig.IPFamilyPolicy)
+
+	// Prepare Deployment
+	replicas := int32(1)
+	healthProbe := func(initialDelay int32) *corev1.Probe {
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/healthz",
+					Port: intstr.FromString("http"),
+				},
+			},
+			InitialDelaySeconds: initialDelay,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      3,
+		}
+	}
+	res.deployment = &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			// The content PVC is ReadWriteOnce
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": name,
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "nginx",
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: 80,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							LivenessProbe:  healthProbe(10),
+							ReadinessProbe: healthProbe(2),
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "nginx-config",
+									MountPath: "/etc/nginx/conf.d",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "content",
+									MountPath: htmlPath,
+									ReadOnly:  res.content != nil,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "nginx-config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: m.nginxConfigName(),
+									},
+								},
+							},
+						},
+						{
+							Name:         "content",
+							VolumeSource: contentVolume,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Content ConfigMap updates reach the mounted volume without a restart;
+	// nginx only reads its config at start
+	if err := k8s.ApplyProbeOverrides(&res.deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&res.deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+	k8s.SetResources(&res.deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&res.deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, err
+	}
+
+	k8s.SetPodEnvironment(&res.deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&res.deployment.Spec.Template, res.nginxConfig); err != nil {
+		return nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), res.nginxConfig, res.content, res.pvc, res.service, res.deployment)
+	return res, nil
+}
+
+// contentConfigMap builds the content ConfigMap from the top-level files of
+// dir. Text files go to Data and binary files to BinaryData. An empty dir
+// yields a placeholder index.html.
+func (m *StaticSiteModule) contentConfigMap(dir string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.contentConfigName(),
+			Namespace: m.ModuleConfig.Namespace,
+			Labels: map[string]string{
+				"app":        m.Name(),
+				"managed-by": "personal-server",
+			},
+		},
+		Data: map[string]string{},
+	}
+
+	if dir == "" {
+		cm.Data["index.html"] = fmt.Sprintf("<!DOCTYPE html>\n<html><body><h1>%s</h1></body></html>\n", m.Name())
+		return cm, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content directory: %w", err)
+	}
+
+	total := 0
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return nil, fmt.Errorf("content directory %s contains subdirectory %q; the configmap source only supports top-level files, use source: pvc", dir, entry.Name())
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		total += len(data)
+		if total > maxConfigMapContent {
+			return nil, fmt.Errorf("content directory %s exceeds %d bytes; use source: pvc for larger sites", dir, maxConfigMapContent)
+		}
+		if utf8.Valid(data) {
+			cm.Data[entry.Name()] = string(data)
+		} else {
+			if cm.BinaryData == nil {
+				cm.BinaryData = map[string][]byte{}
+			}
+			cm.BinaryData[entry.Name()] = data
+		}
+	}
+	return cm, nil
+}
+
+func (m *StaticSiteModule) Clean(ctx context.Context) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	name := m.Name()
+	m.log.Info("Cleaning static site Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	// Delete Deployment
+	m.log.Info("🗑️  Processing Deployment: %s\n", name)
+	err = clientset.AppsV1().Deployments(ns).Delete(ctx, name, deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment '%s' not found\n", name)
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: %s\n", name)
+		successCount++
+	}
+
+	// Delete Service
+	m.log.Info("🗑️  Processing Service: %s\n", name)
+	err = clientset.CoreV1().Services(ns).Delete(ctx, name, deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service '%s' not found\n", name)
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: %s\n", name)
+		successCount++
+	}
+
+	// Delete content PVC (left over from the pvc source even after switching)
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: %s\n", m.contentPVCName())
+	err = clientset.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, m.contentPVCName(), deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim '%s' not found\n", m.contentPVCName())
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: %s\n", m.contentPVCName())
+		successCount++
+	}
+
+	// Delete ConfigMaps
+	for _, cm := range []string{m.contentConfigName(), m.nginxConfigName()} {
+		m.log.Info("🗑️  Processing ConfigMap: %s\n", cm)
+		err = clientset.CoreV1().ConfigMaps(ns).Delete(ctx, cm, deleteOptions)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Warn("ConfigMap '%s' not found\n", cm)
+			} else {
+				m.log.Error("Failed to delete ConfigMap: %v\n", err)
+			}
+		} else {
+			m.log.Success("Deleted ConfigMap: %s\n", cm)
+			successCount++
+		}
+	}
+
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
+	m.log.Info("\nCompleted: %d static site resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *StaticSiteModule) Status(ctx context.Context) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	name := m.Name()
+	m.log.Info("Checking static site resources in namespace '%s'...\n\n", ns)
+
+	// Check Deployment
+	deployment, err := clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment '%s' not found\n", name)
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Image:           %s\n", deployment.Spec.Template.Spec.Containers[0].Image)
+		m.log.Info("  Source:          %s\n", m.source())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check Service
+	service, err := clientset.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service '%s' not found\n", name)
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check content PVC
+	if m.source() == sourcePVC {
+		pvc, err := clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, m.contentPVCName(), metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				m.log.Error("PersistentVolumeClaim '%s' not found\n", m.contentPVCName())
+			} else {
+				m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+			}
+		} else {
+			age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+			m.log.Info("  Name:            %s\n", pvc.Name)
+			m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+			m.log.Info("  Volume:          %s\n", pvc.Spec.VolumeName)
+			m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+			m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+			m.log.Println()
+		}
+	}
+
+	// Check Pods
+	pods, err := clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + name,
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			total := len(pod.Spec.Containers)
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, total),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No static site pods found")
+	}
+	return nil
+}
+
+// Upload replaces the site content with the files in a local directory. With
+// the pvc source the files are streamed into the running pod; with the
+// configmap source the content ConfigMap is rewritten and the Deployment
+// restarted.
+func (m *StaticSiteModule) Upload(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: personal-server %s upload <dir>", m.Name())
+	}
+	dir := args[0]
+	if info, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	switch m.source() {
+	case sourceConfigMap:
+		return m.uploadConfigMapWithClient(ctx, clientset, dir)
+	case sourcePVC:
+		return m.uploadPVCWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dir)
+	default:
+		return fmt.Errorf("invalid source %q: must be %q or %q", m.source(), sourcePVC, sourceConfigMap)
+	}
+}
+
+func (m *StaticSiteModule) uploadPVCWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dir string) error {
+	ns := m.ModuleConfig.Namespace
+	pods, err := client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + m.Name(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	podName := ""
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			podName = pod.Name
+			break
+		}
+	}
+	if podName == "" {
+		return fmt.Errorf("no running pod found for app=%s; run '%s apply' first", m.Name(), m.Name())
+	}
+
+	m.log.Info("🔄 Uploading %s to %s...\n", dir, m.Name())
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	// 1. Prepare an empty staging directory
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: ns,
+		Pod:       podName,
+		Container: "nginx",
+		Command:   []string{"sh", "-c", `rm -rf "$1" && mkdir -p "$1"`, "sh", uploadStagingPath},
+	}); err != nil {
+		return fmt.Errorf("failed to prepare staging directory: %w", err)
+	}
+
+	// 2. Stream a tar archive of dir into the staging directory, so a failed
+	// upload leaves the current content in place
+	stdin, archive := io.Pipe()
+	var count int
+	archived := make(chan error, 1)
+	go func() {
+		n, err := archiveDir(dir, archive)
+		archive.CloseWithError(err)
+		count = n
+		archived <- err
+	}()
+	extractErr := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: ns,
+		Pod:       podName,
+		Container: "nginx",
+		Command:   []string{"tar", "xzf", "-", "-C", uploadStagingPath},
+		Stdin:     stdin,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	})
+	// Unblock the archiver if the upload ended before reading everything
+	stdin.Close()
+	archiveErr := <-archived
+	var uploadErr error
+	switch {
+	case extractErr != nil:
+		uploadErr = fmt.Errorf("failed to upload content: %w", extractErr)
+	case archiveErr != nil:
+		uploadErr = fmt.Errorf("failed to archive %s: %w", dir, archiveErr)
+	}
+	if uploadErr != nil {
+		if err := executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: ns,
+			Pod:       podName,
+			Container: "nginx",
+			Command:   []string{"rm", "-rf", uploadStagingPath},
+		}); err != nil {
+			m.log.Warn("Failed to remove staging directory: %v\n", err)
+		}
+		return uploadErr
+	}
+
+	// 3. Replace the content, including dotfiles, with the staged files
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: ns,
+		Pod:       podName,
+		Container: "nginx",
+		Command: []string{"sh", "-c", `find "$1" -mindepth 1 -delete && cp -a "$2"/. "$1"/ && rm -rf "$2"`,
+			"sh", htmlPath, uploadStagingPath},
+	}); err != nil {
+		return fmt.Errorf("failed to replace content: %w", err)
+	}
+
+	m.log.Success("Uploaded %d files to %s\n", count, m.Name())
+	return nil
+}
+
+func (m *StaticSiteModule) uploadConfigMapWithClient(ctx context.Context, client k8s.KubernetesClient, dir string) error {
+	ns := m.ModuleConfig.Namespace
+	content, err := m.contentConfigMap(dir)
+	if err != nil {
+		return err
+	}
+
+	m.log.Info("🔄 Uploading %s to ConfigMap %s...\n", dir, m.contentConfigName())
+	existing, err := client.CoreV1().ConfigMaps(ns).Get(ctx, m.contentConfigName(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("configmap '%s' not found in namespace '%s'; run '%s apply' first", m.contentConfigName(), ns, m.Name())
+		}
+		return fmt.Errorf("failed to get configmap: %w", err)
+	}
+	existing.Data = content.Data
+	existing.BinaryData = content.BinaryData
+	if _, err := client.CoreV1().ConfigMaps(ns).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update configmap: %w", err)
+	}
+	m.log.Success("Updated ConfigMap: %s (%d files)\n", m.contentConfigName(), len(content.Data)+len(content.BinaryData))
+
+	// ConfigMap volumes refresh lazily; restart so the new content is served now
+	deployment, err := client.AppsV1().Deployments(ns).Get(ctx, m.Name(), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment '%s': %w", m.Name(), err)
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = make(map[string]string)
+	}
+	deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+	if _, err := client.AppsV1().Deployments(ns).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to restart deployment '%s': %w", m.Name(), err)
+	}
+	m.log.Success("Deployment restarted successfully\n")
+	return nil
+}
+
+// archiveDir writes a gzipped tar of the regular files and directories under
+// dir to w, with paths relative to dir. Symlinks and other special files are
+// skipped. It returns the number of files written.
+func archiveDir(dir string, w io.Writer) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && (d.IsDir() || d.Type().IsRegular()) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(paths)
+
+	count := 0
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return count, err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return count, err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return count, err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		// Files must be readable by the nginx worker regardless of local modes
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if info.IsDir() {
+			header.Mode = 0755
+		} else {
+			header.Mode = 0644
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return count, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return count, err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := tw.Close(); err != nil {
+		return count, err
+	}
+	return count, gz.Close()
+}
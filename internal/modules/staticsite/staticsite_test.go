@@ -0,0 +1,406 @@
+package staticsite
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "embed"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStaticSiteModule_Name(t *testing.T) {
+	if name := (&StaticSiteModule{}).Name(); name != "staticsite" {
+		t.Errorf("Name() = %s, want staticsite", name)
+	}
+	module := &StaticSiteModule{ModuleConfig: config.Module{Name: "staticsite-blog"}}
+	if module.Name() != "staticsite-blog" {
+		t.Errorf("Name() = %s, want staticsite-blog", module.Name())
+	}
+	if _, ok := interface{}(module).(interface {
+		Upload(context.Context, []string) error
+	}); !ok {
+		t.Error("StaticSiteModule should support upload")
+	}
+}
+
+func TestStaticSiteModule_Doc(t *testing.T) {
+	module := &StaticSiteModule{
+		ModuleConfig: config.Module{Name: "staticsite", Namespace: "hobby"},
+		log:          logger.NewNopLogger(),
+	}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestStaticSiteModule_Prepare(t *testing.T) {
+	contentDir := t.TempDir()
+	os.WriteFile(filepath.Join(contentDir, "index.html"), []byte("<h1>hi</h1>"), 0644)
+	os.WriteFile(filepath.Join(contentDir, "favicon.ico"), []byte{0x00, 0xff, 0xfe}, 0644)
+
+	nestedDir := t.TempDir()
+	os.Mkdir(filepath.Join(nestedDir, "css"), 0755)
+
+	tests := []struct {
+		name        string
+		moduleName  string
+		secrets     map[string]string
+		storage     string
+		wantErr     string
+		wantPVC     bool
+		wantStorage string
+		wantText    []string
+		wantBinary  []string
+	}{
+		{
+			name:        "pvc source by default",
+			moduleName:  "staticsite",
+			wantPVC:     true,
+			wantStorage: "1Gi",
+		},
+		{
+			name:        "custom storage size and instance name",
+			moduleName:  "staticsite-blog",
+			storage:     "5Gi",
+			wantPVC:     true,
+			wantStorage: "5Gi",
+		},
+		{
+			name:       "configmap source without content",
+			moduleName: "staticsite",
+			secrets:    map[string]string{"source": "configmap"},
+			wantText:   []string{"index.html"},
+		},
+		{
+			name:       "configmap source from directory",
+			moduleName: "staticsite",
+			secrets:    map[string]string{"source": "configmap", "content_dir": contentDir},
+			wantText:   []string{"index.html"},
+			wantBinary: []string{"favicon.ico"},
+		},
+		{
+			name:       "configmap source rejects subdirectories",
+			moduleName: "staticsite",
+			secrets:    map[string]string{"source": "configmap", "content_dir": nestedDir},
+			wantErr:    "subdirectory",
+		},
+		{
+			name:       "invalid source",
+			moduleName: "staticsite",
+			secrets:    map[string]string{"source": "s3"},
+			wantErr:    "invalid source",
+		},
+		{
+			name:       "invalid storage size",
+			moduleName: "staticsite",
+			storage:    "lots",
+			wantErr:    "invalid storage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &StaticSiteModule{
+				ModuleConfig: config.Module{Name: tt.moduleName, Namespace: "hobby", Storage: tt.storage, Secrets: tt.secrets},
+				log:          logger.NewNopLogger(),
+			}
+
+			res, err := module.prepare()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("prepare() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepare() unexpected error: %v", err)
+			}
+
+			if res.deployment.Name != tt.moduleName || res.service.Name != tt.moduleName {
+				t.Errorf("deployment/service names = %s/%s, want %s", res.deployment.Name, res.service.Name, tt.moduleName)
+			}
+			if res.service.Spec.Selector["app"] != tt.moduleName {
+				t.Errorf("service selector = %v", res.service.Spec.Selector)
+			}
+
+			volumes := res.deployment.Spec.Template.Spec.Volumes
+			content := volumes[len(volumes)-1]
+			if tt.wantPVC {
+				if res.pvc == nil || res.content != nil {
+					t.Fatal("expected a content PVC and no content ConfigMap")
+				}
+				if res.pvc.Name != tt.moduleName+"-content-pvc" {
+					t.Errorf("PVC name = %s", res.pvc.Name)
+				}
+				if got := res.pvc.Spec.Resources.Requests.Storage().String(); got != tt.wantStorage {
+					t.Errorf("PVC storage = %s, want %s", got, tt.wantStorage)
+				}
+				if content.PersistentVolumeClaim == nil || content.PersistentVolumeClaim.ClaimName != res.pvc.Name {
+					t.Errorf("content volume = %+v", content.VolumeSource)
+				}
+				return
+			}
+
+			if res.content == nil || res.pvc != nil {
+				t.Fatal("expected a content ConfigMap and no PVC")
+			}
+			if content.ConfigMap == nil || content.ConfigMap.Name != tt.moduleName+"-content" {
+				t.Errorf("content volume = %+v", content.VolumeSource)
+			}
+			for _, key := range tt.wantText {
+				if _, ok := res.content.Data[key]; !ok {
+					t.Errorf("content ConfigMap missing text key %s", key)
+				}
+			}
+			for _, key := range tt.wantBinary {
+				if _, ok := res.content.BinaryData[key]; !ok {
+					t.Errorf("content ConfigMap missing binary key %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestArchiveDir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "css"), 0755)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>home</h1>"), 0600)
+	os.WriteFile(filepath.Join(dir, ".well-known"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "css", "site.css"), []byte("body{}"), 0644)
+	os.Symlink("index.html", filepath.Join(dir, "link.html"))
+
+	var buf bytes.Buffer
+	count, err := archiveDir(dir, &buf)
+	if err != nil {
+		t.Fatalf("archiveDir() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("archiveDir() count = %d, want 3", count)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	contents := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		names = append(names, header.Name)
+		if header.Typeflag == tar.TypeReg {
+			if header.Mode != 0644 {
+				t.Errorf("%s mode = %o, want 644", header.Name, header.Mode)
+			}
+			b, _ := io.ReadAll(tr)
+			contents[header.Name] = string(b)
+		}
+	}
+
+	sort.Strings(names)
+	want := []string{".well-known", "css/", "css/site.css", "index.html"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("archive entries = %v, want %v", names, want)
+	}
+	if contents["index.html"] != "<h1>home</h1>" {
+		t.Errorf("index.html content = %q", contents["index.html"])
+	}
+}
+
+func TestUploadConfigMapWithClient(t *testing.T) {
+	module := &StaticSiteModule{
+		ModuleConfig: config.Module{Name: "staticsite", Namespace: "hobby", Secrets: map[string]string{"source": "configmap"}},
+		log:          logger.NewNopLogger(),
+	}
+	res, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	client := fake.NewSimpleClientset(res.content, res.deployment)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>new</h1>"), 0644)
+
+	ctx := context.Background()
+	if err := module.uploadConfigMapWithClient(ctx, client, dir); err != nil {
+		t.Fatalf("uploadConfigMapWithClient() error = %v", err)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("hobby").Get(ctx, "staticsite-content", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get configmap: %v", err)
+	}
+	if cm.Data["index.html"] != "<h1>new</h1>" {
+		t.Errorf("index.html = %q", cm.Data["index.html"])
+	}
+
+	deployment, err := client.AppsV1().Deployments("hobby").Get(ctx, "staticsite", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	if deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] == "" {
+		t.Error("deployment was not restarted after upload")
+	}
+}
+
+func TestUploadConfigMapRequiresApply(t *testing.T) {
+	module := &StaticSiteModule{
+		ModuleConfig: config.Module{Name: "staticsite", Namespace: "hobby", Secrets: map[string]string{"source": "configmap"}},
+		log:          logger.NewNopLogger(),
+	}
+	client := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "staticsite", Namespace: "hobby"}})
+
+	err := module.uploadConfigMapWithClient(context.Background(), client, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "apply") {
+		t.Fatalf("expected error suggesting apply, got %v", err)
+	}
+}
+
+func TestUploadPVCRequiresRunningPod(t *testing.T) {
+	module := &StaticSiteModule{
+		ModuleConfig: config.Module{Name: "staticsite", Namespace: "hobby"},
+		log:          logger.NewNopLogger(),
+	}
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "staticsite-0", Namespace: "hobby", Labels: map[string]string{"app": "staticsite"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	})
+
+	err := module.uploadPVCWithClient(context.Background(), client, k8s.NewReplayExecutor(), t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "no running pod") {
+		t.Fatalf("expected no running pod error, got %v", err)
+	}
+}
+
+func TestUploadPVCWithClient(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>hi</h1>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if _, err := archiveDir(dir, &archive); err != nil {
+		t.Fatal(err)
+	}
+	exec := func(command ...string) k8s.ExecRecord {
+		return k8s.ExecRecord{Namespace: "hobby", Pod: "staticsite-0", Container: "nginx", Command: command}
+	}
+	prepare := exec("sh", "-c", `rm -rf "$1" && mkdir -p "$1"`, "sh", uploadStagingPath)
+	extract := exec("tar", "xzf", "-", "-C", uploadStagingPath)
+	extract.Stdin = archive.String()
+	failedExtract := extract
+	failedExtract.Error = "exit status 2"
+
+	tests := []struct {
+		name    string
+		records []k8s.ExecRecord
+		wantErr string
+	}{
+		{
+			name: "replaces the content after extracting",
+			records: []k8s.ExecRecord{prepare, extract,
+				exec("sh", "-c", `find "$1" -mindepth 1 -delete && cp -a "$2"/. "$1"/ && rm -rf "$2"`, "sh", htmlPath, uploadStagingPath)},
+		},
+		{
+			name:    "keeps the content when the extract fails",
+			records: []k8s.ExecRecord{prepare, failedExtract, exec("rm", "-rf", uploadStagingPath)},
+			wantErr: "failed to upload content",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &StaticSiteModule{
+				ModuleConfig: config.Module{Name: "staticsite", Namespace: "hobby"},
+				log:          logger.NewNopLogger(),
+			}
+			client := fake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "staticsite-0", Namespace: "hobby", Labels: map[string]string{"app": "staticsite"}},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			})
+			executor := k8s.NewReplayExecutor(tt.records...)
+
+			err := module.uploadPVCWithClient(context.Background(), client, executor, dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected %q error, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("uploadPVCWithClient() error = %v", err)
+			}
+			if err := executor.Verify(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+//go:embed testdata/configmap.yaml
+var expectedConfigMapYAML string
+
+//go:embed testdata/pvc.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	origWd, _ := os.Getwd()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	module := &StaticSiteModule{
+		ModuleConfig: config.Module{
+			Name:      "staticsite",
+			Namespace: "hobby",
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	cases := []struct{ name, file, want string }{
+		{"configmap", "configs/staticsite/configmap.yaml", expectedConfigMapYAML},
+		{"pvc", "configs/staticsite/pvc.yaml", expectedPvcYAML},
+		{"service", "configs/staticsite/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/staticsite/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(tempDir, tc.file))
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tc.file, err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Generated YAML does not match expected.\nGot:\n%s\n\nWant:\n%s", string(got), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1121 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	"github.com/Goalt/personal-server/internal/servicetls"
+	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage        = "matrixdotorg/synapse:v1.98.0"
+	defaultStorageSize  = "10Gi"
+	defaultDatabaseHost = "postgres:5432"
+	defaultDBUser       = "synapse"
+	dataPath            = "/data"
+	mediaStorePath      = "/data/media_store"
+	keysPath            = "/data/keys"
+	// synapseUID is the uid/gid the synapse image expects to own /data
+	synapseUID = int64(991)
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "100m", MemoryRequest: "256Mi", MemoryLimit: "2Gi"}
+
+type SynapseModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *SynapseModule {
+	return &SynapseModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *SynapseModule) Name() string {
+	return "synapse"
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *SynapseModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// Dependencies lists the modules that must be running before synapse is applied
+func (m *SynapseModule) Dependencies() []string {
+	return []string{"postgres"}
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	DBPassword               string `yaml:"synapse_db_password" required:"true" generate:"true" doc:"Password of the Synapse PostgreSQL user"`
+	RegistrationSharedSecret string `yaml:"registration_shared_secret" required:"true" generate:"true" doc:"Shared secret for registering users with register_new_matrix_user"`
+	MacaroonSecretKey        string `yaml:"macaroon_secret_key" required:"true" generate:"true" doc:"Secret used to sign access tokens"`
+	DBUser                   string `yaml:"synapse_db_user" default:"synapse" doc:"PostgreSQL user"`
+	DBName                   string `yaml:"synapse_db_name" doc:"PostgreSQL database (defaults to synapse_db_user)"`
+	DatabaseHost             string `yaml:"database_host" doc:"PostgreSQL host and port (default: the postgres module's host, else postgres:5432)"`
+	ServerName               string `yaml:"server_name" doc:"Matrix server name (defaults to general.domain)"`
+	PublicBaseURL            string `yaml:"public_baseurl" doc:"Public client URL (defaults to https://matrix.<domain>/)"`
+	EnableRegistration       string `yaml:"enable_registration" default:"false" doc:"Set to \"true\" to allow open registration"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *SynapseModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *SynapseModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: synapse\n\n")
+	m.log.Info("Description:\n  Deploys Matrix Synapse — a Matrix homeserver backed by the postgres module.\n  Manages a ConfigMap (homeserver.yaml), Secret (database credentials and signing secrets),\n  PersistentVolumeClaim (media store and signing keys), Service, and Deployment.\n  The signing key is generated on first start by an init container.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  synapse_db_password          Password of the Synapse PostgreSQL user\n  registration_shared_secret   Shared secret for registering users with register_new_matrix_user\n  macaroon_secret_key          Secret used to sign access tokens\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  synapse_db_user       PostgreSQL user (default: synapse)\n  synapse_db_name       PostgreSQL database (default: synapse_db_user)\n  database_host         PostgreSQL host and port (default: the postgres module's host, else postgres:5432)\n  server_name           Matrix server name (default: general.domain)\n  public_baseurl        Public client URL (default: https://matrix.<domain>/)\n  enable_registration   Set to \"true\" to allow open registration\n  storage               Size of the media store and signing key volume (modules[].storage, default: 10Gi)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
+	m.log.Info("Database:\n  Create it before the first apply: personal-server postgres add-db synapse synapse <password>\n\n")
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/synapse/\n  apply      Create/update resources in the cluster\n  clean      Delete all Synapse resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive the media store and signing keys to the destination directory\n  restore    Restore the media store and signing keys from a backup archive\n")
+	return nil
+}
+
+func (m *SynapseModule) Generate(ctx context.Context) error {
+	// Define output directory
+	outputDir := filepath.Join("configs", "synapse")
+
+	// Check and create output directory if it doesn't exist
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating Synapse Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	// Prepare Kubernetes objects
+	configMap, secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	// Helper function to write object to YAML file
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(configMap, "configmap"); err != nil {
+		return err
+	}
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+	total := 5
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Synapse configurations generated successfully\n", total, total)
+	return nil
+}
+
+func (m *SynapseModule) Apply(ctx context.Context) error {
+	// Prepare Kubernetes objects first so missing secrets fail fast
+	configMap, secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Applying Synapse Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	// Check if resources already exist
+	m.log.Info("Checking for existing resources...\n")
+	_, err = clientset.CoreV1().ConfigMaps(ns).Get(ctx, "synapse-config", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("configmap 'synapse-config' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check configmap existence: %w", err)
+	}
+
+	_, err = clientset.CoreV1().Secrets(ns).Get(ctx, "synapse-secrets", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("secret 'synapse-secrets' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+
+	_, err = clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, "synapse-data-pvc", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("PersistentVolumeClaim 'synapse-data-pvc' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+
+	_, err = clientset.CoreV1().Services(ns).Get(ctx, "synapse", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("service 'synapse' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+
+	_, err = clientset.AppsV1().Deployments(ns).Get(ctx, "synapse", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("deployment 'synapse' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	// Apply ConfigMap
+	m.log.Progress("Applying ConfigMap: synapse-config\n")
+	_, err = clientset.CoreV1().ConfigMaps(ns).Create(ctx, configMap, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create configmap: %w", err)
+	}
+	m.log.Success("Created ConfigMap: synapse-config\n")
+
+	// Apply Secret
+	m.log.Progress("Applying Secret: synapse-secrets\n")
+	_, err = clientset.CoreV1().Secrets(ns).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: synapse-secrets\n")
+
+	// Apply PVC
+	m.log.Progress("Applying PersistentVolumeClaim: synapse-data-pvc\n")
+	_, err = clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: synapse-data-pvc\n")
+
+	// Apply Service
+	m.log.Progress("Applying Service: synapse\n")
+	_, err = clientset.CoreV1().Services(ns).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: synapse\n")
+
+	// Apply Deployment
+	m.log.Progress("Applying Deployment: synapse\n")
+	_, err = clientset.AppsV1().Deployments(ns).Create(ctx, deployment, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: synapse\n")
+
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: Synapse configurations applied successfully\n")
+	m.log.Info("💡 Register an admin: kubectl exec -n %s deploy/synapse -- register_new_matrix_user -c /secrets/secrets.yaml -a http://localhost:8008\n", ns)
+	return nil
+}
+
+// serverName returns the Matrix server name, defaulting to the general domain
+func (m *SynapseModule) serverName() string {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "server_name", m.GeneralConfig.Domain)
+}
+
+// homeserverYAML renders the non-secret part of homeserver.yaml. Database
+// credentials and signing secrets live in secrets.yaml, which Synapse merges
+// on top of this file.
+func (m *SynapseModule) homeserverYAML() string {
+	serverName := m.serverName()
+	publicBaseURL := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "public_baseurl", fmt.Sprintf("https://matrix.%s/", m.GeneralConfig.Domain))
+	enableRegistration := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "enable_registration", "false") == "true"
+
+	return fmt.Sprintf(`server_name: %q
+public_baseurl: %q
+pid_file: /data/homeserver.pid
+report_stats: false
+listeners:
+  - port: 8008
+    type: http
+    tls: false
+    x_forwarded: true
+    bind_addresses: ['0.0.0.0']
+    resources:
+      - names: [client, federation]
+        compress: false
+media_store_path: %s
+signing_key_path: %s/%s.signing.key
+enable_registration: %t
+trusted_key_servers:
+  - server_name: matrix.org
+`, serverName, publicBaseURL, mediaStorePath, keysPath, serverName, enableRegistration)
+}
+
+// secretsYAML renders the secret part of the Synapse configuration
+func (m *SynapseModule) secretsYAML(dbPassword, registrationSecret, macaroonKey string) (string, error) {
+	dbUser := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "synapse_db_user", defaultDBUser)
+	dbName := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "synapse_db_name", dbUser)
+
+	host, port, err := net.SplitHostPort(k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "database_host", m.GeneralConfig.Endpoint("postgres", defaultDatabaseHost)))
+	if err != nil {
+		return "", fmt.Errorf("invalid database_host: %w", err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("invalid database_host port %q", port)
+	}
+
+	args := map[string]interface{}{
+		"user":     dbUser,
+		"password": dbPassword,
+		"database": dbName,
+		"host":     host,
+		"port":     portNumber,
+		"cp_min":   5,
+		"cp_max":   10,
+		// Databases created by the postgres module use the default locale
+		"allow_unsafe_locale": true,
+	}
+	if m.postgresCASecret() != "" {
+		args["sslmode"] = "verify-full"
+		args["sslrootcert"] = servicetls.CAPath("postgres")
+	}
+
+	doc := map[string]interface{}{
+		"database": map[string]interface{}{
+			"name": "psycopg2",
+			"args": args,
+		},
+		"registration_shared_secret": registrationSecret,
+		"macaroon_secret_key":        macaroonKey,
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("failed to render secrets.yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to render secrets.yaml: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// prepare creates and returns the Kubernetes objects for synapse module
+func (m *SynapseModule) prepare() (*corev1.ConfigMap, *corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	dbPassword := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "synapse_db_password", "")
+	registrationSecret := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "registration_shared_secret", "")
+	macaroonKey := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "macaroon_secret_key", "")
+	if dbPassword == "" || registrationSecret == "" || macaroonKey == "" {
+		return nil, nil, nil, nil, nil, fmt.Errorf("synapse_db_password, registration_shared_secret and macaroon_secret_key are required in modules[].secrets")
+	}
+	if m.serverName() == "" {
+		return nil, nil, nil, nil, nil, fmt.Errorf("server_name is required when general.domain is empty")
+	}
+
+	secretsYAML, err := m.secretsYAML(dbPassword, registrationSecret, macaroonKey)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	image := m.ModuleConfig.ContainerImage(defaultImage)
+
+	labels := map[string]string{
+		"app":        "synapse",
+		"managed-by": "personal-server",
+	}
+
+	// Prepare ConfigMap
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "synapse-config",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"homeserver.yaml": m.homeserverYAML(),
+		},
+	}
+
+	// Prepare Secret
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "synapse-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"secrets.yaml": secretsYAML,
+		},
+	}
+
+	// Prepare PVC
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "synapse-data-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	// Prepare Service
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "synapse",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       8008,
+					TargetPort: intstr.FromInt(8008),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "synapse",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	// Both containers load homeserver.yaml and the secrets overlay, projected
+	// side by side into /config
+	configArgs := []string{
+		"--config-path", "/config/homeserver.yaml",
+		"--config-path", "/config/secrets.yaml",
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "synapse-config",
+			MountPath: "/config",
+			ReadOnly:  true,
+		},
+		{
+			Name:      "synapse-data",
+			MountPath: dataPath,
+		},
+	}
+
+	// Prepare Deployment
+	replicas := int32(1)
+	uid := synapseUID
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "synapse",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "synapse",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "synapse",
+					},
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsUser:  &uid,
+						RunAsGroup: &uid,
+						FSGroup:    &uid,
+					},
+					InitContainers: []corev1.Container{
+						{
+							// Creates the media store and signing key on first start
+							Name:            "generate-keys",
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
+							Command: []string{"sh", "-c",
+								fmt.Sprintf("mkdir -p %s %s && exec python -m synapse.app.homeserver %s --generate-keys", mediaStorePath, keysPath, strings.Join(configArgs, " "))},
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            "synapse",
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
+							Command:         append([]string{"python", "-m", "synapse.app.homeserver"}, configArgs...),
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: 8008,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 60,
+								PeriodSeconds:       15,
+								TimeoutSeconds:      5,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/health",
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       5,
+								TimeoutSeconds:      3,
+							},
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "synapse-config",
+							VolumeSource: corev1.VolumeSource{
+								Projected: &corev1.ProjectedVolumeSource{
+									Sources: []corev1.VolumeProjection{
+										{
+											ConfigMap: &corev1.ConfigMapProjection{
+												LocalObjectReference: corev1.LocalObjectReference{
+													Name: "synapse-config",
+												},
+											},
+										},
+										{
+											Secret: &corev1.SecretProjection{
+												LocalObjectReference: corev1.LocalObjectReference{
+													Name: "synapse-secrets",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						{
+							Name: "synapse-data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "synapse-data-pvc",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// secretsYAML points psycopg2 at the CA mounted here
+	if caSecret := m.postgresCASecret(); caSecret != "" {
+		servicetls.MountCA(&deployment.Spec.Template.Spec, "postgres", caSecret)
+	}
+
+	// First starts run database migrations that can take minutes on slow disks
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], 60)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, configMap, secret); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), configMap, secret, pvc, service, deployment)
+	return configMap, secret, pvc, service, deployment, nil
+}
+
+// postgresCASecret returns the Secret verifying the postgres module's TLS
+// certificate, or "" when it serves plain connections or database_host points at
+// another server
+func (m *SynapseModule) postgresCASecret() string {
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "database_host", "") != "" {
+		return ""
+	}
+	return m.GeneralConfig.TLSCASecret("postgres")
+}
+
+func (m *SynapseModule) Clean(ctx context.Context) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Cleaning Synapse Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	// Delete Deployment
+	m.log.Info("🗑️  Processing Deployment: synapse\n")
+	err = clientset.AppsV1().Deployments(ns).Delete(ctx, "synapse", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'synapse' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: synapse\n")
+		successCount++
+	}
+
+	// Delete Service
+	m.log.Info("🗑️  Processing Service: synapse\n")
+	err = clientset.CoreV1().Services(ns).Delete(ctx, "synapse", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service 'synapse' not found\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: synapse\n")
+		successCount++
+	}
+
+	// Delete PVC
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: synapse-data-pvc\n")
+	err = clientset.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, "synapse-data-pvc", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'synapse-data-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: synapse-data-pvc\n")
+		successCount++
+	}
+
+	// Delete Secret
+	m.log.Info("🗑️  Processing Secret: synapse-secrets\n")
+	err = clientset.CoreV1().Secrets(ns).Delete(ctx, "synapse-secrets", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'synapse-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: synapse-secrets\n")
+		successCount++
+	}
+
+	// Delete ConfigMap
+	m.log.Info("🗑️  Processing ConfigMap: synapse-config\n")
+	err = clientset.CoreV1().ConfigMaps(ns).Delete(ctx, "synapse-config", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("ConfigMap 'synapse-config' not found\n")
+		} else {
+			m.log.Error("Failed to delete ConfigMap: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted ConfigMap: synapse-config\n")
+		successCount++
+	}
+
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
+	m.log.Info("\nCompleted: %d Synapse resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+		m.log.Println("The synapse database in the postgres module is left untouched.")
+	}
+	return nil
+}
+
+func (m *SynapseModule) Status(ctx context.Context) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Checking Synapse resources in namespace '%s'...\n\n", ns)
+
+	// Check Deployment
+	deployment, err := clientset.AppsV1().Deployments(ns).Get(ctx, "synapse", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'synapse' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Image:           %s\n", deployment.Spec.Template.Spec.Containers[0].Image)
+		m.log.Info("  Server name:     %s\n", m.serverName())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check Service
+	service, err := clientset.CoreV1().Services(ns).Get(ctx, "synapse", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service 'synapse' not found\n")
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Print("  Ports:           ")
+		for i, port := range service.Spec.Ports {
+			if i > 0 {
+				m.log.Print(", ")
+			}
+			m.log.Print("%d/%s", port.Port, port.Protocol)
+		}
+		m.log.Info("\n  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check PVC
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, "synapse-data-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'synapse-data-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Volume:          %s\n", pvc.Spec.VolumeName)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check Pods
+	pods, err := clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=synapse",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			total := len(pod.Spec.Containers)
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, total),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No Synapse pods found")
+	}
+	return nil
+}
+
+// findPod returns the name of the synapse pod exec commands run in
+func (m *SynapseModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=synapse",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=synapse")
+	}
+	return pods.Items[0].Name, nil
+}
+
+// backupArchives maps the archive prefix to the directory it covers
+var backupArchives = []struct{ prefix, path, label string }{
+	{"synapse_media", mediaStorePath, "media store"},
+	{"synapse_keys", keysPath, "signing keys"},
+}
+
+func (m *SynapseModule) Backup(ctx context.Context, destDir string) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
+
+func (m *SynapseModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
+	timestamp := time.Now().Format("20060102_150405")
+	var backupDir string
+	if destDir != "" {
+		backupDir = filepath.Join(destDir, "synapse")
+	} else {
+		backupDir = filepath.Join("backups", fmt.Sprintf("synapse_backup_%s", timestamp))
+	}
+
+	m.log.Info("🔄 Starting Synapse backup...\n")
+	m.log.Info("Backup directory: %s\n", backupDir)
+
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	var archives []string
+	for _, a := range backupArchives {
+		m.log.Info("💾 Backing up %s (%s)...\n", a.label, a.path)
+		archive := filepath.Join(backupDir, fmt.Sprintf("%s_%s.tar.gz", a.prefix, timestamp))
+
+		outFile, err := os.Create(archive)
+		if err != nil {
+			return fmt.Errorf("failed to create %s backup file: %w", a.label, err)
+		}
+		err = executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: m.ModuleConfig.Namespace,
+			Pod:       podName,
+			Container: "synapse",
+			Command:   []string{"tar", "czf", "-", "-C", a.path, "."},
+			Stdout:    outFile,
+			Stderr:    os.Stderr,
+		})
+		outFile.Close()
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", a.label, err)
+		}
+
+		fileInfo, err := os.Stat(archive)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s backup file: %w", a.label, err)
+		}
+		m.log.Success("%s archived (%d bytes)\n", strings.ToUpper(a.label[:1])+a.label[1:], fileInfo.Size())
+		archives = append(archives, filepath.Base(archive))
+	}
+
+	m.log.Info("📋 Writing metadata...\n")
+	metadataFile := filepath.Join(backupDir, "backup_info.txt")
+	metadata := fmt.Sprintf(`Synapse Backup Information
+==========================
+Backup Date: %s
+Backup Directory: %s
+Namespace: %s
+Deployment: synapse
+Pod: %s
+Server Name: %s
+
+Archives:
+%s
+
+The database is not included; back it up with: personal-server postgres backup
+
+Restore Command:
+personal-server synapse restore %s
+`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, m.serverName(), strings.Join(archives, "\n"), timestamp)
+
+	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	m.log.Success("Metadata written\n")
+
+	m.log.Success("🎉 Backup complete!\n")
+	m.log.Info("💡 To restore: personal-server synapse restore %s\n", timestamp)
+	return nil
+}
+
+func (m *SynapseModule) Restore(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: personal-server synapse restore [TIMESTAMP|latest]")
+	}
+
+	timestamp := args[0]
+	backupDir := "backups"
+
+	// Resolve latest
+	if timestamp == "latest" {
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			return fmt.Errorf("failed to read backup directory: %w", err)
+		}
+
+		var latestTime time.Time
+		var latestDir string
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "synapse_backup_") {
+				ts, err := time.Parse("20060102_150405", strings.TrimPrefix(entry.Name(), "synapse_backup_"))
+				if err == nil && ts.After(latestTime) {
+					latestTime = ts
+					latestDir = entry.Name()
+				}
+			}
+		}
+
+		if latestDir == "" {
+			return fmt.Errorf("no backups found")
+		}
+		timestamp = strings.TrimPrefix(latestDir, "synapse_backup_")
+		m.log.Info("Using latest backup: %s\n", timestamp)
+	}
+
+	targetBackupDir := filepath.Join(backupDir, fmt.Sprintf("synapse_backup_%s", timestamp))
+	if _, err := os.Stat(targetBackupDir); os.IsNotExist(err) {
+		return fmt.Errorf("backup not found: %s", targetBackupDir)
+	}
+
+	for _, a := range backupArchives {
+		archive := filepath.Join(targetBackupDir, fmt.Sprintf("%s_%s.tar.gz", a.prefix, timestamp))
+		if _, err := os.Stat(archive); os.IsNotExist(err) {
+			return fmt.Errorf("%s archive missing: %s", a.label, archive)
+		}
+	}
+
+	m.log.Info("🔄 Starting Synapse restore (timestamp: %s)...\n", timestamp)
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if err := m.restoreArchivesWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), targetBackupDir, timestamp); err != nil {
+		return err
+	}
+
+	// Restart deployment so Synapse reloads its signing key
+	m.log.Info("🔄 Restarting deployment 'synapse'...\n")
+	if err := k8s.RestartDeployment(ctx, clientset, m.ModuleConfig.Namespace, "synapse", time.Now()); err != nil {
+		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
+	} else {
+		m.log.Success("Deployment restarted successfully\n")
+	}
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreArchivesWithClient replaces the media store and signing keys in the
+// synapse pod with the archives of the backup in targetBackupDir
+func (m *SynapseModule) restoreArchivesWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, targetBackupDir, timestamp string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	for _, a := range backupArchives {
+		archive := filepath.Join(targetBackupDir, fmt.Sprintf("%s_%s.tar.gz", a.prefix, timestamp))
+		m.log.Info("💾 Restoring %s from %s\n", a.label, archive)
+
+		// 1. Clean existing data
+		if err := executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: m.ModuleConfig.Namespace,
+			Pod:       podName,
+			Container: "synapse",
+			Command:   []string{"sh", "-c", fmt.Sprintf("mkdir -p %[1]s && find %[1]s -mindepth 1 -delete", a.path)},
+		}); err != nil {
+			m.log.Warn("Warning during clean: %v\n", err)
+		}
+
+		// 2. Restore from tar
+		inFile, err := os.Open(archive)
+		if err != nil {
+			return fmt.Errorf("failed to open %s backup file: %w", a.label, err)
+		}
+		err = executor.Exec(ctx, k8s.ExecRequest{
+			Namespace: m.ModuleConfig.Namespace,
+			Pod:       podName,
+			Container: "synapse",
+			Command:   []string{"tar", "xzf", "-", "-C", a.path},
+			Stdin:     inFile,
+			Stdout:    os.Stdout,
+			Stderr:    os.Stderr,
+		})
+		inFile.Close()
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", a.label, err)
+		}
+		m.log.Success("%s restored\n", strings.ToUpper(a.label[:1])+a.label[1:])
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package synapse
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSynapseModule_Name(t *testing.T) {
+	module := &SynapseModule{}
+	if module.Name() != "synapse" {
+		t.Errorf("Name() = %s, want synapse", module.Name())
+	}
+}
+
+func TestSynapseModule_Doc(t *testing.T) {
+	module := &SynapseModule{
+		ModuleConfig: config.Module{Name: "synapse", Namespace: "infra"},
+		log:          logger.NewNopLogger(),
+	}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func requiredSecrets() map[string]string {
+	return map[string]string{
+		"synapse_db_password":        "dbpass",
+		"registration_shared_secret": "regsecret",
+		"macaroon_secret_key":        "macaroon",
+	}
+}
+
+func TestSynapseModule_Prepare(t *testing.T) {
+	withSecrets := func(extra map[string]string) map[string]string {
+		s := requiredSecrets()
+		for k, v := range extra {
+			s[k] = v
+		}
+		return s
+	}
+
+	tests := []struct {
+		name        string
+		domain      string
+		image       string
+		secrets     map[string]string
+		storage     string
+		wantErr     bool
+		wantImage   string
+		wantStorage string
+		wantConfig  []string
+		wantSecrets []string
+	}{
+		{
+			name:        "defaults",
+			domain:      "example.com",
+			secrets:     requiredSecrets(),
+			wantImage:   defaultImage,
+			wantStorage: "10Gi",
+			wantConfig: []string{
+				`server_name: "example.com"`,
+				`public_baseurl: "https://matrix.example.com/"`,
+				"signing_key_path: /data/keys/example.com.signing.key",
+				"enable_registration: false",
+			},
+			wantSecrets: []string{"user: synapse", "database: synapse", "host: postgres", "port: 5432", "password: dbpass"},
+		},
+		{
+			name:   "overrides",
+			domain: "example.com",
+			image:  "matrixdotorg/synapse:latest",
+			secrets: withSecrets(map[string]string{
+				"server_name":         "chat.example.org",
+				"public_baseurl":      "https://chat.example.org/",
+				"synapse_db_user":     "matrix",
+				"database_host":       "db.infra:6432",
+				"enable_registration": "true",
+			}),
+			storage:     "50Gi",
+			wantImage:   "matrixdotorg/synapse:latest",
+			wantStorage: "50Gi",
+			wantConfig:  []string{`server_name: "chat.example.org"`, "enable_registration: true"},
+			wantSecrets: []string{"user: matrix", "database: matrix", "host: db.infra", "port: 6432"},
+		},
+		{
+			name:    "missing macaroon key",
+			domain:  "example.com",
+			secrets: map[string]string{"synapse_db_password": "x", "registration_shared_secret": "y"},
+			wantErr: true,
+		},
+		{
+			name:    "missing server name",
+			secrets: requiredSecrets(),
+			wantErr: true,
+		},
+		{
+			name:    "invalid database host",
+			domain:  "example.com",
+			secrets: withSecrets(map[string]string{"database_host": "postgres"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid storage size",
+			domain:  "example.com",
+			secrets: withSecrets(nil), storage: "lots",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &SynapseModule{
+				GeneralConfig: config.GeneralConfig{Domain: tt.domain},
+				ModuleConfig: config.Module{
+					Name:      "synapse",
+					Namespace: "infra",
+					Image:     tt.image,
+					Storage:   tt.storage,
+					Secrets:   tt.secrets,
+				},
+			}
+
+			configMap, secret, pvc, _, deployment, err := module.prepare()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("prepare() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepare() unexpected error: %v", err)
+			}
+
+			if got := deployment.Spec.Template.Spec.Containers[0].Image; got != tt.wantImage {
+				t.Errorf("image = %s, want %s", got, tt.wantImage)
+			}
+			if got := deployment.Spec.Template.Spec.InitContainers[0].Image; got != tt.wantImage {
+				t.Errorf("init container image = %s, want %s", got, tt.wantImage)
+			}
+			if got := pvc.Spec.Resources.Requests.Storage().String(); got != tt.wantStorage {
+				t.Errorf("storage = %s, want %s", got, tt.wantStorage)
+			}
+			for _, want := range tt.wantConfig {
+				if !strings.Contains(configMap.Data["homeserver.yaml"], want) {
+					t.Errorf("homeserver.yaml should contain %q, got:\n%s", want, configMap.Data["homeserver.yaml"])
+				}
+			}
+			if strings.Contains(configMap.Data["homeserver.yaml"], "dbpass") {
+				t.Error("homeserver.yaml must not contain the database password")
+			}
+			for _, want := range tt.wantSecrets {
+				if !strings.Contains(secret.StringData["secrets.yaml"], want) {
+					t.Errorf("secrets.yaml should contain %q, got:\n%s", want, secret.StringData["secrets.yaml"])
+				}
+			}
+		})
+	}
+}
+
+//go:embed testdata/configmap.yaml
+var expectedConfigMapYAML string
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/pvc.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	origWd, _ := os.Getwd()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	module := &SynapseModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig: config.Module{
+			Name:      "synapse",
+			Namespace: "infra",
+			Secrets:   requiredSecrets(),
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	cases := []struct{ name, file, want string }{
+		{"configmap", "configs/synapse/configmap.yaml", expectedConfigMapYAML},
+		{"secret", "configs/synapse/secret.yaml", expectedSecretYAML},
+		{"pvc", "configs/synapse/pvc.yaml", expectedPvcYAML},
+		{"service", "configs/synapse/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/synapse/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(tempDir, tc.file))
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tc.file, err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Generated YAML does not match expected.\nGot:\n%s\n\nWant:\n%s", string(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestSynapseModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "synapse-7d9f", Namespace: "matrix", Labels: map[string]string{"app": "synapse"}}}
+	module := &SynapseModule{ModuleConfig: config.Module{Name: "synapse", Namespace: "matrix"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "matrix", Pod: "synapse-7d9f", Container: "synapse", Command: []string{"tar", "czf", "-", "-C", mediaStorePath, "."}, Stdout: "media"},
+		k8s.ExecRecord{Namespace: "matrix", Pod: "synapse-7d9f", Container: "synapse", Command: []string{"tar", "czf", "-", "-C", keysPath, "."}, Stdout: "keys"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	for prefix, want := range map[string]string{"synapse_media": "media", "synapse_keys": "keys"} {
+		archives, _ := filepath.Glob(filepath.Join(destDir, "synapse", prefix+"_*.tar.gz"))
+		if len(archives) != 1 {
+			t.Fatalf("expected one %s archive, got %v", prefix, archives)
+		}
+		if data, _ := os.ReadFile(archives[0]); string(data) != want {
+			t.Errorf("%s archive = %q, want %q", prefix, data, want)
+		}
+	}
+}
+
+func TestSynapseModule_RestoreArchivesWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "synapse-7d9f", Namespace: "matrix", Labels: map[string]string{"app": "synapse"}}}
+	dir := t.TempDir()
+	for prefix, content := range map[string]string{"synapse_media": "media", "synapse_keys": "keys"} {
+		if err := os.WriteFile(filepath.Join(dir, prefix+"_20260301_030000.tar.gz"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	module := &SynapseModule{ModuleConfig: config.Module{Name: "synapse", Namespace: "matrix"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "matrix", Pod: "synapse-7d9f", Container: "synapse", Command: []string{"sh", "-c", "mkdir -p /data/media_store && find /data/media_store -mindepth 1 -delete"}},
+		k8s.ExecRecord{Namespace: "matrix", Pod: "synapse-7d9f", Container: "synapse", Command: []string{"tar", "xzf", "-", "-C", mediaStorePath}, Stdin: "media"},
+		k8s.ExecRecord{Namespace: "matrix", Pod: "synapse-7d9f", Container: "synapse", Command: []string{"sh", "-c", "mkdir -p /data/keys && find /data/keys -mindepth 1 -delete"}},
+		k8s.ExecRecord{Namespace: "matrix", Pod: "synapse-7d9f", Container: "synapse", Command: []string{"tar", "xzf", "-", "-C", keysPath}, Stdin: "keys"},
+	)
+
+	if err := module.restoreArchivesWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, dir, "20260301_030000"); err != nil {
+		t.Fatalf("restoreArchivesWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,609 @@
+package tor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultImage       = "alpine:3.20"
+	defaultStorageSize = "100Mi"
+	dataPath           = "/var/lib/tor"
+	// hiddenServicesPath holds one directory per onion service with its
+	// keys and hostname file
+	hiddenServicesPath = dataPath + "/hidden_services"
+	// onionPort is the port each onion service is reachable on
+	onionPort = 80
+)
+
+// entrypoint installs tor, hands the data directory to the tor user, which
+// tor requires to own the hidden service keys with mode 0700, and runs it
+const entrypoint = `apk add --no-cache tor >/dev/null && mkdir -p ` + hiddenServicesPath + ` && chown -R tor ` + dataPath + ` && chmod -R go-rwx ` + dataPath + ` && exec tor -f /etc/tor/torrc`
+
+var serviceNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "128Mi"}
+
+type TorModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *TorModule {
+	return &TorModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *TorModule) Name() string {
+	return "tor"
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	Services string `yaml:"services" required:"true" doc:"Comma-separated onion services as name=host:port, e.g. blog=staticsite.hobby.svc.cluster.local:80"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *TorModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *TorModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: tor\n\n")
+	m.log.Info("Description:\n  Runs a Tor daemon that publishes in-cluster Services as onion services on port %d.\n  Manages a ConfigMap (torrc), PersistentVolumeClaim (onion service keys), and Deployment.\n  The keys determine the .onion addresses, so they survive pod restarts and re-applies;\n  status prints the addresses.\n\n", onionPort)
+	m.log.Info("Required configuration keys (modules[].secrets):\n  services       Comma-separated onion services as name=host:port,\n                 e.g. blog=staticsite.hobby.svc.cluster.local:80,git=gitea:3000\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  image          Alpine-based container image (modules[].image, default: %s)\n  storage        Size of the keys volume (modules[].storage, default: %s)\n\n", defaultImage, defaultStorageSize)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/tor/\n  apply      Create/update resources in the cluster\n  clean      Delete all Tor resources from the cluster, including the keys\n  status     Print Deployment and Pod status and the .onion addresses\n  doc        Show this documentation\n")
+	return nil
+}
+
+// onionService publishes target on an onion address
+type onionService struct {
+	name   string
+	target string // host:port
+}
+
+// services parses the services key
+func (m *TorModule) services() ([]onionService, error) {
+	value := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "services", "")
+	var services []onionService
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		name, target = strings.TrimSpace(name), strings.TrimSpace(target)
+		if !ok || name == "" || target == "" {
+			return nil, fmt.Errorf("invalid services entry %q: use name=host:port", entry)
+		}
+		if !serviceNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid onion service name %q: use lowercase letters, digits and '-'", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("onion service %q is listed twice", name)
+		}
+		seen[name] = true
+		host, port, err := net.SplitHostPort(target)
+		if err != nil || host == "" {
+			return nil, fmt.Errorf("invalid target %q of onion service %s: use host:port", target, name)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q of onion service %s", port, name)
+		}
+		services = append(services, onionService{name: name, target: target})
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("services not found in configuration")
+	}
+	return services, nil
+}
+
+// torrc renders the Tor configuration. Tor resolves the target host names
+// once, when it starts.
+func torrc(services []onionService) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "DataDirectory %s\n", dataPath)
+	b.WriteString("User tor\n")
+	// Only onion services are offered; no SOCKS proxy for the cluster
+	b.WriteString("SocksPort 0\n")
+	b.WriteString("Log notice stdout\n")
+	for _, service := range services {
+		fmt.Fprintf(&b, "\nHiddenServiceDir %s/%s\n", hiddenServicesPath, service.name)
+		fmt.Fprintf(&b, "HiddenServicePort %d %s\n", onionPort, service.target)
+	}
+	return b.String()
+}
+
+func (m *TorModule) Generate(ctx context.Context) error {
+	outputDir := filepath.Join("configs", "tor")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating Tor Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	configMap, pvc, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(configMap, "configmap"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+
+	m.log.Info("\nCompleted: 3/3 Tor configurations generated successfully\n")
+	return nil
+}
+
+func (m *TorModule) Apply(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Applying Tor Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	configMap, pvc, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	m.log.Info("Checking for existing resources...\n")
+	if _, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, configMap.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("configmap '%s' already exists in namespace '%s'", configMap.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check configmap existence: %w", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("PersistentVolumeClaim '%s' already exists in namespace '%s'", pvc.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		return fmt.Errorf("deployment '%s' already exists in namespace '%s'", deployment.Name, m.ModuleConfig.Namespace)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	if _, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create configmap: %w", err)
+	}
+	m.log.Success("Created ConfigMap: %s\n", configMap.Name)
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: %s\n", pvc.Name)
+
+	if _, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: %s\n", deployment.Name)
+
+	m.log.Info("\nCompleted: Tor configurations applied successfully\n")
+	m.log.Info("💡 Once the pod is ready, personal-server tor status prints the .onion addresses\n")
+	return nil
+}
+
+// prepare creates and returns the Kubernetes objects for the tor module
+func (m *TorModule) prepare() (*corev1.ConfigMap, *corev1.PersistentVolumeClaim, *appsv1.Deployment, error) {
+	services, err := m.services()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	labels := map[string]string{
+		"app":        "tor",
+		"managed-by": "personal-server",
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tor-config",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"torrc": torrc(services),
+		},
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tor-data-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	// Ready once tor has created the keys and hostname of every service
+	var ready []string
+	for _, service := range services {
+		ready = append(ready, fmt.Sprintf("test -s %s/%s/hostname", hiddenServicesPath, service.name))
+	}
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tor",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			// Two daemons publishing the same keys would compete for the
+			// onion addresses
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "tor",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "tor",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "tor",
+							Image:           m.ModuleConfig.ContainerImage(defaultImage),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command:         []string{"sh", "-c", entrypoint},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", strings.Join(ready, " && ")},
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "config",
+									MountPath: "/etc/tor/torrc",
+									SubPath:   "torrc",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "data",
+									MountPath: dataPath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "tor-config"},
+								},
+							},
+						},
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "tor-data-pvc",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, configMap); err != nil {
+		return nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), configMap, pvc, deployment)
+	return configMap, pvc, deployment, nil
+}
+
+// onionAddresses reads the .onion address of each onion service from the
+// hostname files tor writes into the tor pod
+func (m *TorModule) onionAddresses(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor) (map[string]string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=tor",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no running pod found for app=tor")
+	}
+
+	script := fmt.Sprintf(`cd %s && for s in *; do [ -s "$s/hostname" ] && printf '%%s %%s\n' "$s" "$(cat "$s/hostname")"; done; true`, hiddenServicesPath)
+	output, err := k8s.ExecCombinedOutput(ctx, executor, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       pods.Items[0].Name,
+		Command:   []string{"sh", "-c", script},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read onion addresses: %s: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	addresses := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		if name, address, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " "); ok && strings.HasSuffix(address, ".onion") {
+			addresses[name] = address
+		}
+	}
+	return addresses, nil
+}
+
+func (m *TorModule) Clean(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Cleaning Tor Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", m.ModuleConfig.Namespace)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	m.log.Info("🗑️  Processing Deployment: tor\n")
+	if err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Delete(ctx, "tor", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'tor' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: tor\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: tor-data-pvc\n")
+	if err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Delete(ctx, "tor-data-pvc", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'tor-data-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: tor-data-pvc\n")
+		successCount++
+	}
+
+	m.log.Info("🗑️  Processing ConfigMap: tor-config\n")
+	if err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Delete(ctx, "tor-config", deleteOptions); err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("ConfigMap 'tor-config' not found\n")
+		} else {
+			m.log.Error("Failed to delete ConfigMap: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted ConfigMap: tor-config\n")
+		successCount++
+	}
+
+	m.log.Info("\nCompleted: %d Tor resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+		m.log.Println("The onion service keys were deleted with the volume; a new apply publishes new .onion addresses.")
+	}
+	return nil
+}
+
+func (m *TorModule) Status(ctx context.Context) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	m.log.Info("Checking Tor resources in namespace '%s'...\n\n", m.ModuleConfig.Namespace)
+
+	deployment, err := clientset.AppsV1().Deployments(m.ModuleConfig.Namespace).Get(ctx, "tor", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'tor' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(m.ModuleConfig.Namespace).Get(ctx, "tor-data-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'tor-data-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps(m.ModuleConfig.Namespace).Get(ctx, "tor-config", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("ConfigMap 'tor-config' not found\n")
+		} else {
+			m.log.Error("Error getting ConfigMap: %v\n", err)
+		}
+	} else {
+		age := time.Since(configMap.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("CONFIGMAP:\n")
+		m.log.Info("  Name:            %s\n", configMap.Name)
+		m.log.Info("  Data keys:       %d\n", len(configMap.Data))
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=tor",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+		m.log.Println()
+	} else {
+		m.log.Println("No Tor pods found")
+		return nil
+	}
+
+	services, err := m.services()
+	if err != nil {
+		return err
+	}
+	addresses, err := m.onionAddresses(ctx, clientset, m.GeneralConfig.Clients.Executor())
+	if err != nil {
+		m.log.Error("%v\n", err)
+		return nil
+	}
+	m.printOnionServices(services, addresses)
+	return nil
+}
+
+// printOnionServices prints the address of each configured onion service
+func (m *TorModule) printOnionServices(services []onionService, addresses map[string]string) {
+	m.log.Info("ONION SERVICES:\n")
+	m.log.Info("%-20s %-64s %s\n", "NAME", "ADDRESS", "TARGET")
+	for _, service := range services {
+		address := addresses[service.name]
+		if address == "" {
+			address = "(not published yet)"
+		} else {
+			address = "http://" + address
+		}
+		m.log.Info("%-20s %-64s %s\n", service.name, address, service.target)
+	}
+}
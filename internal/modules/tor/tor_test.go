@@ -0,0 +1,194 @@
+package tor
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestModule(secrets map[string]string) *TorModule {
+	return &TorModule{
+		ModuleConfig: config.Module{Name: "tor", Namespace: "infra", Secrets: secrets},
+		log:          logger.NewNopLogger(),
+	}
+}
+
+func TestTorModule_Name(t *testing.T) {
+	module := &TorModule{}
+	if module.Name() != "tor" {
+		t.Errorf("Name() = %s, want tor", module.Name())
+	}
+}
+
+func TestTorModule_Doc(t *testing.T) {
+	module := &TorModule{log: logger.NewNopLogger()}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestTorModule_Services(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []onionService
+		wantErr bool
+	}{
+		{
+			value: "blog=staticsite.hobby.svc.cluster.local:80, git=gitea:3000,",
+			want:  []onionService{{name: "blog", target: "staticsite.hobby.svc.cluster.local:80"}, {name: "git", target: "gitea:3000"}},
+		},
+		{value: "", wantErr: true},
+		{value: "blog", wantErr: true},
+		{value: "Blog=staticsite:80", wantErr: true},
+		{value: "../keys=staticsite:80", wantErr: true},
+		{value: "blog=staticsite", wantErr: true},
+		{value: "blog=staticsite:http", wantErr: true},
+		{value: "blog=staticsite:80,blog=gitea:3000", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := newTestModule(map[string]string{"services": tt.value}).services()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("services(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("services(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("services(%q)[%d] = %v, want %v", tt.value, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestTorModule_Prepare(t *testing.T) {
+	module := newTestModule(map[string]string{
+		"services": "blog=staticsite.hobby.svc.cluster.local:80,git=gitea:3000",
+	})
+	module.ModuleConfig.Storage = "1Gi"
+	configMap, pvc, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+
+	torrc := configMap.Data["torrc"]
+	for _, line := range []string{
+		"SocksPort 0",
+		"HiddenServiceDir /var/lib/tor/hidden_services/blog\nHiddenServicePort 80 staticsite.hobby.svc.cluster.local:80",
+		"HiddenServiceDir /var/lib/tor/hidden_services/git\nHiddenServicePort 80 gitea:3000",
+	} {
+		if !strings.Contains(torrc, line) {
+			t.Errorf("torrc does not contain %q:\n%s", line, torrc)
+		}
+	}
+	if got := pvc.Spec.Resources.Requests.Storage().String(); got != "1Gi" {
+		t.Errorf("PVC storage = %s, want 1Gi", got)
+	}
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if probe := strings.Join(container.ReadinessProbe.Exec.Command, " "); !strings.Contains(probe, "blog/hostname") || !strings.Contains(probe, "git/hostname") {
+		t.Errorf("readiness probe = %q, want every hostname file checked", probe)
+	}
+	if claim := deployment.Spec.Template.Spec.Volumes[1].PersistentVolumeClaim; claim == nil || claim.ClaimName != "tor-data-pvc" {
+		t.Errorf("keys volume does not use tor-data-pvc")
+	}
+}
+
+func TestTorModule_OnionAddresses(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "tor-5d6c", Namespace: "infra", Labels: map[string]string{"app": "tor"}}}
+	module := newTestModule(map[string]string{"services": "blog=staticsite:80,git=gitea:3000"})
+	executor := k8s.NewReplayExecutor(k8s.ExecRecord{
+		Namespace: "infra",
+		Pod:       "tor-5d6c",
+		Command:   []string{"sh", "-c", `cd /var/lib/tor/hidden_services && for s in *; do [ -s "$s/hostname" ] && printf '%s %s\n' "$s" "$(cat "$s/hostname")"; done; true`},
+		Stdout:    "blog abcdefghijklmnopqrstuvwxyz234567abcdefghijklmnopqrstuvwx.onion\n",
+	})
+
+	addresses, err := module.onionAddresses(context.Background(), fake.NewSimpleClientset(pod), executor)
+	if err != nil {
+		t.Fatalf("onionAddresses() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+	if len(addresses) != 1 || addresses["blog"] != "abcdefghijklmnopqrstuvwxyz234567abcdefghijklmnopqrstuvwx.onion" {
+		t.Errorf("addresses = %v, want the blog address", addresses)
+	}
+
+	var buf bytes.Buffer
+	module.log = logger.NewStdLogger(&buf)
+	services, _ := module.services()
+	module.printOnionServices(services, addresses)
+	if !strings.Contains(buf.String(), "http://abcdefghijklmnopqrstuvwxyz234567abcdefghijklmnopqrstuvwx.onion") || !strings.Contains(buf.String(), "not published yet") {
+		t.Errorf("output = %q, want the blog address and git pending", buf.String())
+	}
+
+	if _, err := module.onionAddresses(context.Background(), fake.NewSimpleClientset(), k8s.NewReplayExecutor()); err == nil {
+		t.Error("onionAddresses() without a pod succeeded, want error")
+	}
+}
+
+//go:embed testdata/configmap.yaml
+var expectedConfigMapYAML string
+
+//go:embed testdata/pvc.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	module := newTestModule(map[string]string{
+		"services": "blog=staticsite.hobby.svc.cluster.local:80,git=gitea.infra.svc.cluster.local:3000",
+	})
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"configmap", "configs/tor/configmap.yaml", expectedConfigMapYAML},
+		{"pvc", "configs/tor/pvc.yaml", expectedPvcYAML},
+		{"deployment", "configs/tor/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generatedContent, err := os.ReadFile(filepath.Join(tempDir, tc.filename))
+			if err != nil {
+				t.Fatalf("failed to read generated file %s: %v", tc.filename, err)
+			}
+			if string(generatedContent) != tc.expected {
+				t.Errorf("Generated YAML does not match expected.\nGenerated:\n%s\n\nExpected:\n%s", string(generatedContent), tc.expected)
+			}
+		})
+	}
+}
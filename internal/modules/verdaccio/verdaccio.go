@@ -0,0 +1,999 @@
+package verdaccio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultImage       = "verdaccio/verdaccio:5"
+	defaultStorageSize = "10Gi"
+	storagePath        = "/verdaccio/storage"
+)
+
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "50m", MemoryRequest: "128Mi", MemoryLimit: "512Mi"}
+
+type VerdaccioModule struct {
+	GeneralConfig config.GeneralConfig
+	ModuleConfig  config.Module
+	log           logger.Logger
+}
+
+func New(generalConfig config.GeneralConfig, moduleConfig config.Module, log logger.Logger) *VerdaccioModule {
+	return &VerdaccioModule{
+		GeneralConfig: generalConfig,
+		ModuleConfig:  moduleConfig,
+		log:           log,
+	}
+}
+
+func (m *VerdaccioModule) Name() string {
+	return "verdaccio"
+}
+
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *VerdaccioModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	VerdaccioUsername string `yaml:"verdaccio_username" required:"true" doc:"Username allowed to read and publish packages"`
+	VerdaccioPassword string `yaml:"verdaccio_password" required:"true" generate:"true" doc:"Password for that user"`
+	PublicAccess      string `yaml:"public_access" default:"false" doc:"Set to \"true\" to allow anonymous installs"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *VerdaccioModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
+func (m *VerdaccioModule) Doc(ctx context.Context) error {
+	m.log.Info("Module: verdaccio\n\n")
+	m.log.Info("Description:\n  Deploys Verdaccio — a private npm registry that proxies registry.npmjs.org.\n  Manages a ConfigMap, Secret (htpasswd), PersistentVolumeClaim, Service, and Deployment.\n  Self-registration is disabled; users are managed through the config file.\n\n")
+	m.log.Info("Required configuration keys (modules[].secrets):\n  verdaccio_username   Username allowed to read and publish packages\n  verdaccio_password   Password for that user\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  storage         Size of the package storage volume (modules[].storage, default: 10Gi)\n  public_access   Set to \"true\" to allow anonymous installs (publishing still requires login)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
+	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/verdaccio/\n  apply      Create/update resources in the cluster\n  clean      Delete all Verdaccio resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive the package storage volume to the destination directory\n  restore    Restore the package storage volume from a backup archive\n")
+	return nil
+}
+
+func (m *VerdaccioModule) Generate(ctx context.Context) error {
+	// Define output directory
+	outputDir := filepath.Join("configs", "verdaccio")
+
+	// Check and create output directory if it doesn't exist
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory '%s': %w", outputDir, err)
+	}
+
+	m.log.Info("Generating Verdaccio Kubernetes configurations...\n")
+	m.log.Info("Output directory: %s\n\n", outputDir)
+
+	// Prepare Kubernetes objects
+	configMap, secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	// Helper function to write object to YAML file
+	writeYAML := func(obj interface{}, name string) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to JSON: %w", name, err)
+		}
+		yamlContent, err := k8s.JSONToYAML(string(jsonBytes))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to YAML: %w", name, err)
+		}
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if err := os.WriteFile(filename, []byte(yamlContent), 0644); err != nil {
+			return fmt.Errorf("failed to write %s to file: %w", name, err)
+		}
+		m.log.Success("Generated: %s\n", filename)
+		return nil
+	}
+
+	if err := writeYAML(configMap, "configmap"); err != nil {
+		return err
+	}
+	if err := writeYAML(secret, "secret"); err != nil {
+		return err
+	}
+	if err := writeYAML(pvc, "pvc"); err != nil {
+		return err
+	}
+	if err := writeYAML(service, "service"); err != nil {
+		return err
+	}
+	if err := writeYAML(deployment, "deployment"); err != nil {
+		return err
+	}
+	total := 5
+
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d Verdaccio configurations generated successfully\n", total, total)
+	return nil
+}
+
+func (m *VerdaccioModule) Apply(ctx context.Context) error {
+	// Prepare Kubernetes objects first so missing secrets fail fast
+	configMap, secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
+
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Applying Verdaccio Kubernetes configurations...\n")
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	// Check if resources already exist
+	m.log.Info("Checking for existing resources...\n")
+	_, err = clientset.CoreV1().ConfigMaps(ns).Get(ctx, "verdaccio-config", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("configmap 'verdaccio-config' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check configmap existence: %w", err)
+	}
+
+	_, err = clientset.CoreV1().Secrets(ns).Get(ctx, "verdaccio-secrets", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("secret 'verdaccio-secrets' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check secret existence: %w", err)
+	}
+
+	_, err = clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, "verdaccio-storage-pvc", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("PersistentVolumeClaim 'verdaccio-storage-pvc' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check PersistentVolumeClaim existence: %w", err)
+	}
+
+	_, err = clientset.CoreV1().Services(ns).Get(ctx, "verdaccio", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("service 'verdaccio' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check service existence: %w", err)
+	}
+
+	_, err = clientset.AppsV1().Deployments(ns).Get(ctx, "verdaccio", metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("deployment 'verdaccio' already exists in namespace '%s'", ns)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check deployment existence: %w", err)
+	}
+
+	m.log.Info("No existing resources found, proceeding with creation...\n\n")
+
+	// Apply ConfigMap
+	m.log.Progress("Applying ConfigMap: verdaccio-config\n")
+	_, err = clientset.CoreV1().ConfigMaps(ns).Create(ctx, configMap, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create configmap: %w", err)
+	}
+	m.log.Success("Created ConfigMap: verdaccio-config\n")
+
+	// Apply Secret
+	m.log.Progress("Applying Secret: verdaccio-secrets\n")
+	_, err = clientset.CoreV1().Secrets(ns).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	m.log.Success("Created Secret: verdaccio-secrets\n")
+
+	// Apply PVC
+	m.log.Progress("Applying PersistentVolumeClaim: verdaccio-storage-pvc\n")
+	_, err = clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim: %w", err)
+	}
+	m.log.Success("Created PersistentVolumeClaim: verdaccio-storage-pvc\n")
+
+	// Apply Service
+	m.log.Progress("Applying Service: verdaccio\n")
+	_, err = clientset.CoreV1().Services(ns).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	m.log.Success("Created Service: verdaccio\n")
+
+	// Apply Deployment
+	m.log.Progress("Applying Deployment: verdaccio\n")
+	_, err = clientset.AppsV1().Deployments(ns).Create(ctx, deployment, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+	m.log.Success("Created Deployment: verdaccio\n")
+
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
+	m.log.Info("\nCompleted: Verdaccio configurations applied successfully\n")
+	m.log.Info("💡 Point npm at the registry: npm set registry http://verdaccio.%s.svc.cluster.local:4873/\n", ns)
+	return nil
+}
+
+// htpasswdEntry returns an htpasswd line for the user. The salt is derived
+// from the credentials so that repeated generate runs produce identical output.
+func htpasswdEntry(username, password string) string {
+	const alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	sum := sha256.Sum256([]byte(username + ":" + password))
+	salt := make([]byte, 8)
+	for i := range salt {
+		salt[i] = alphabet[int(sum[i])%len(alphabet)]
+	}
+	return fmt.Sprintf("%s:%s\n", username, k8s.APR1Hash(password, string(salt)))
+}
+
+// configYAML renders the Verdaccio config.yaml
+func (m *VerdaccioModule) configYAML() string {
+	access := "$authenticated"
+	if k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "public_access", "false") == "true" {
+		access = "$all"
+	}
+
+	return fmt.Sprintf(`storage: %s/data
+plugins: /verdaccio/plugins
+web:
+  title: Verdaccio
+auth:
+  htpasswd:
+    file: /verdaccio/conf/htpasswd
+    max_users: -1
+uplinks:
+  npmjs:
+    url: https://registry.npmjs.org/
+packages:
+  '@*/*':
+    access: %[2]s
+    publish: $authenticated
+    unpublish: $authenticated
+    proxy: npmjs
+  '**':
+    access: %[2]s
+    publish: $authenticated
+    unpublish: $authenticated
+    proxy: npmjs
+server:
+  keepAliveTimeout: 60
+log:
+  type: stdout
+  format: pretty
+  level: http
+`, storagePath, access)
+}
+
+// prepare creates and returns the Kubernetes objects for verdaccio module
+func (m *VerdaccioModule) prepare() (*corev1.ConfigMap, *corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	username := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "verdaccio_username", "")
+	password := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "verdaccio_password", "")
+	if username == "" || password == "" {
+		return nil, nil, nil, nil, nil, fmt.Errorf("verdaccio_username and verdaccio_password are required in modules[].secrets")
+	}
+	if strings.Contains(username, ":") {
+		return nil, nil, nil, nil, nil, fmt.Errorf("verdaccio_username must not contain ':'")
+	}
+
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	image := m.ModuleConfig.ContainerImage(defaultImage)
+
+	labels := map[string]string{
+		"app":        "verdaccio",
+		"managed-by": "personal-server",
+	}
+
+	// Prepare ConfigMap
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "verdaccio-config",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"config.yaml": m.configYAML(),
+		},
+	}
+
+	// Prepare Secret
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "verdaccio-secrets",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"htpasswd": []byte(htpasswdEntry(username, password)),
+		},
+	}
+
+	// Prepare PVC
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "verdaccio-storage-pvc",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storageSize,
+				},
+			},
+		},
+	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
+
+	// Prepare Service
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "verdaccio",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       4873,
+					TargetPort: intstr.FromInt(4873),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"app": "verdaccio",
+			},
+		},
+	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
+
+	// Prepare Deployment
+	replicas := int32(1)
+	// The verdaccio image runs as uid 10001 with gid 65533
+	fsGroup := int64(65533)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "verdaccio",
+			Namespace: m.ModuleConfig.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "verdaccio",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "verdaccio",
+					},
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						FSGroup: &fsGroup,
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            "verdaccio",
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: 4873,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/-/ping",
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       10,
+								TimeoutSeconds:      5,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/-/ping",
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       5,
+								TimeoutSeconds:      3,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "verdaccio-config",
+									MountPath: "/verdaccio/conf/config.yaml",
+									SubPath:   "config.yaml",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "verdaccio-htpasswd",
+									MountPath: "/verdaccio/conf/htpasswd",
+									SubPath:   "htpasswd",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "verdaccio-storage",
+									MountPath: storagePath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "verdaccio-config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: "verdaccio-config",
+									},
+								},
+							},
+						},
+						{
+							Name: "verdaccio-htpasswd",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: "verdaccio-secrets",
+								},
+							},
+						},
+						{
+							Name: "verdaccio-storage",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: "verdaccio-storage-pvc",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	k8s.SetStartupProbe(&deployment.Spec.Template.Spec.Containers[0], k8s.DefaultStartupFailureThreshold)
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, configMap, secret); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), configMap, secret, pvc, service, deployment)
+	return configMap, secret, pvc, service, deployment, nil
+}
+
+func (m *VerdaccioModule) Clean(ctx context.Context) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Cleaning Verdaccio Kubernetes resources...\n")
+	m.log.Info("Target namespace: %s\n\n", ns)
+
+	successCount := 0
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	// Delete Deployment
+	m.log.Info("🗑️  Processing Deployment: verdaccio\n")
+	err = clientset.AppsV1().Deployments(ns).Delete(ctx, "verdaccio", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Deployment 'verdaccio' not found\n")
+		} else {
+			m.log.Error("Failed to delete Deployment: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Deployment: verdaccio\n")
+		successCount++
+	}
+
+	// Delete Service
+	m.log.Info("🗑️  Processing Service: verdaccio\n")
+	err = clientset.CoreV1().Services(ns).Delete(ctx, "verdaccio", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Service 'verdaccio' not found\n")
+		} else {
+			m.log.Error("Failed to delete Service: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Service: verdaccio\n")
+		successCount++
+	}
+
+	// Delete PVC
+	m.log.Info("🗑️  Processing PersistentVolumeClaim: verdaccio-storage-pvc\n")
+	err = clientset.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, "verdaccio-storage-pvc", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("PersistentVolumeClaim 'verdaccio-storage-pvc' not found\n")
+		} else {
+			m.log.Error("Failed to delete PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted PersistentVolumeClaim: verdaccio-storage-pvc\n")
+		successCount++
+	}
+
+	// Delete Secret
+	m.log.Info("🗑️  Processing Secret: verdaccio-secrets\n")
+	err = clientset.CoreV1().Secrets(ns).Delete(ctx, "verdaccio-secrets", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("Secret 'verdaccio-secrets' not found\n")
+		} else {
+			m.log.Error("Failed to delete Secret: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted Secret: verdaccio-secrets\n")
+		successCount++
+	}
+
+	// Delete ConfigMap
+	m.log.Info("🗑️  Processing ConfigMap: verdaccio-config\n")
+	err = clientset.CoreV1().ConfigMaps(ns).Delete(ctx, "verdaccio-config", deleteOptions)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Warn("ConfigMap 'verdaccio-config' not found\n")
+		} else {
+			m.log.Error("Failed to delete ConfigMap: %v\n", err)
+		}
+	} else {
+		m.log.Success("Deleted ConfigMap: verdaccio-config\n")
+		successCount++
+	}
+
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
+	m.log.Info("\nCompleted: %d Verdaccio resources deleted successfully\n", successCount)
+	if successCount > 0 {
+		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
+	}
+	return nil
+}
+
+func (m *VerdaccioModule) Status(ctx context.Context) error {
+	// Create Kubernetes client
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ns := m.ModuleConfig.Namespace
+	m.log.Info("Checking Verdaccio resources in namespace '%s'...\n\n", ns)
+
+	// Check Deployment
+	deployment, err := clientset.AppsV1().Deployments(ns).Get(ctx, "verdaccio", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Deployment 'verdaccio' not found\n")
+		} else {
+			m.log.Error("Error getting Deployment: %v\n", err)
+		}
+	} else {
+		age := time.Since(deployment.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("DEPLOYMENT:\n")
+		m.log.Info("  Name:            %s\n", deployment.Name)
+		m.log.Info("  Ready:           %d/%d\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+		m.log.Info("  Up-to-date:      %d\n", deployment.Status.UpdatedReplicas)
+		m.log.Info("  Available:       %d\n", deployment.Status.AvailableReplicas)
+		m.log.Info("  Image:           %s\n", deployment.Spec.Template.Spec.Containers[0].Image)
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check Service
+	service, err := clientset.CoreV1().Services(ns).Get(ctx, "verdaccio", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("Service 'verdaccio' not found\n")
+		} else {
+			m.log.Error("Error getting Service: %v\n", err)
+		}
+	} else {
+		age := time.Since(service.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("SERVICE:\n")
+		m.log.Info("  Name:            %s\n", service.Name)
+		m.log.Info("  Type:            %s\n", service.Spec.Type)
+		m.log.Info("  Cluster-IP:      %s\n", service.Spec.ClusterIP)
+		m.log.Print("  Ports:           ")
+		for i, port := range service.Spec.Ports {
+			if i > 0 {
+				m.log.Print(", ")
+			}
+			m.log.Print("%d/%s", port.Port, port.Protocol)
+		}
+		m.log.Info("\n  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check PVC
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(ns).Get(ctx, "verdaccio-storage-pvc", metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			m.log.Error("PersistentVolumeClaim 'verdaccio-storage-pvc' not found\n")
+		} else {
+			m.log.Error("Error getting PersistentVolumeClaim: %v\n", err)
+		}
+	} else {
+		age := time.Since(pvc.CreationTimestamp.Time).Round(time.Second)
+		m.log.Info("PERSISTENT VOLUME CLAIM:\n")
+		m.log.Info("  Name:            %s\n", pvc.Name)
+		m.log.Info("  Status:          %s\n", pvc.Status.Phase)
+		m.log.Info("  Volume:          %s\n", pvc.Spec.VolumeName)
+		m.log.Info("  Capacity:        %s\n", pvc.Status.Capacity.Storage().String())
+		m.log.Info("  Age:             %s\n", k8s.FormatAge(age))
+		m.log.Println()
+	}
+
+	// Check Pods
+	pods, err := clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=verdaccio",
+	})
+	if err != nil {
+		m.log.Error("Error listing pods: %v\n", err)
+	} else if len(pods.Items) > 0 {
+		m.log.Info("PODS:\n")
+		m.log.Info("%-40s %-10s %-10s %-10s\n", "NAME", "READY", "STATUS", "AGE")
+		for _, pod := range pods.Items {
+			ready := 0
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.Ready {
+					ready++
+				}
+			}
+			total := len(pod.Spec.Containers)
+			age := time.Since(pod.CreationTimestamp.Time).Round(time.Second)
+			m.log.Info("%-40s %-10s %-10s %-10s\n",
+				pod.Name,
+				fmt.Sprintf("%d/%d", ready, total),
+				pod.Status.Phase,
+				k8s.FormatAge(age))
+		}
+	} else {
+		m.log.Println("No Verdaccio pods found")
+	}
+	return nil
+}
+
+// findPod returns the name of the verdaccio pod exec commands run in
+func (m *VerdaccioModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=verdaccio",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=verdaccio")
+	}
+	return pods.Items[0].Name, nil
+}
+
+func (m *VerdaccioModule) Backup(ctx context.Context, destDir string) error {
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
+
+func (m *VerdaccioModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
+	timestamp := time.Now().Format("20060102_150405")
+	var backupDir string
+	if destDir != "" {
+		backupDir = filepath.Join(destDir, "verdaccio")
+	} else {
+		backupDir = filepath.Join("backups", fmt.Sprintf("verdaccio_backup_%s", timestamp))
+	}
+
+	m.log.Info("🔄 Starting Verdaccio backup...\n")
+	m.log.Info("Backup directory: %s\n", backupDir)
+
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	m.log.Info("💾 Backing up package storage (%s)...\n", storagePath)
+	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("verdaccio_storage_%s.tar.gz", timestamp))
+
+	outFile, err := os.Create(dataBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to create storage backup file: %w", err)
+	}
+	defer outFile.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "czf", "-", "-C", storagePath, "."},
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to archive storage: %w", err)
+	}
+
+	fileInfo, err := outFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat storage backup file: %w", err)
+	}
+	m.log.Success("Storage archived (%d bytes)\n", fileInfo.Size())
+
+	m.log.Info("📋 Writing metadata...\n")
+	metadataFile := filepath.Join(backupDir, "backup_info.txt")
+	metadata := fmt.Sprintf(`Verdaccio Backup Information
+===========================
+Backup Date: %s
+Backup Directory: %s
+Namespace: %s
+Deployment: verdaccio
+Pod: %s
+
+Storage Archive:
+%s
+
+Restore Command:
+personal-server verdaccio restore %s
+`, time.Now().Format(time.RFC1123), backupDir, m.ModuleConfig.Namespace, podName, filepath.Base(dataBackupFile), timestamp)
+
+	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	m.log.Success("Metadata written\n")
+
+	m.log.Success("🎉 Backup complete!\n")
+	m.log.Info("💡 To restore: personal-server verdaccio restore %s\n", timestamp)
+	return nil
+}
+
+func (m *VerdaccioModule) Restore(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: personal-server verdaccio restore [TIMESTAMP|latest]")
+	}
+
+	timestamp := args[0]
+	backupDir := "backups"
+
+	// Resolve latest
+	if timestamp == "latest" {
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			return fmt.Errorf("failed to read backup directory: %w", err)
+		}
+
+		var latestTime time.Time
+		var latestDir string
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "verdaccio_backup_") {
+				ts, err := time.Parse("20060102_150405", strings.TrimPrefix(entry.Name(), "verdaccio_backup_"))
+				if err == nil && ts.After(latestTime) {
+					latestTime = ts
+					latestDir = entry.Name()
+				}
+			}
+		}
+
+		if latestDir == "" {
+			return fmt.Errorf("no backups found")
+		}
+		timestamp = strings.TrimPrefix(latestDir, "verdaccio_backup_")
+		m.log.Info("Using latest backup: %s\n", timestamp)
+	}
+
+	targetBackupDir := filepath.Join(backupDir, fmt.Sprintf("verdaccio_backup_%s", timestamp))
+	if _, err := os.Stat(targetBackupDir); os.IsNotExist(err) {
+		return fmt.Errorf("backup not found: %s", targetBackupDir)
+	}
+
+	dataBackupFile := filepath.Join(targetBackupDir, fmt.Sprintf("verdaccio_storage_%s.tar.gz", timestamp))
+	if _, err := os.Stat(dataBackupFile); os.IsNotExist(err) {
+		return fmt.Errorf("storage archive missing: %s", dataBackupFile)
+	}
+
+	m.log.Info("🔄 Starting Verdaccio restore (timestamp: %s)...\n", timestamp)
+	m.log.Info("💾 Storage will be restored from %s\n", dataBackupFile)
+
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	if err := m.restoreStorageWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dataBackupFile); err != nil {
+		return err
+	}
+
+	// Restart deployment so Verdaccio reloads its package index
+	m.log.Info("🔄 Restarting deployment 'verdaccio'...\n")
+	if err := k8s.RestartDeployment(ctx, clientset, m.ModuleConfig.Namespace, "verdaccio", time.Now()); err != nil {
+		m.log.Warn("Failed to trigger rollout restart: %v\n", err)
+	} else {
+		m.log.Success("Deployment restarted successfully\n")
+	}
+
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreStorageWithClient replaces the package storage in the verdaccio pod
+// with the contents of a tar archive written by Backup
+func (m *VerdaccioModule) restoreStorageWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dataBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
+	if err != nil {
+		return err
+	}
+	m.log.Info("📦 Using pod: %s\n", podName)
+
+	// 1. Clean existing storage
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"sh", "-c", fmt.Sprintf("find %s -mindepth 1 -delete", storagePath)},
+	}); err != nil {
+		m.log.Warn("Warning during clean: %v\n", err)
+	}
+
+	// 2. Restore from tar
+	inFile, err := os.Open(dataBackupFile)
+	if err != nil {
+		return fmt.Errorf("failed to open storage backup file: %w", err)
+	}
+	defer inFile.Close()
+
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Command:   []string{"tar", "xzf", "-", "-C", storagePath},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to restore storage: %w", err)
+	}
+	m.log.Success("Storage restored\n")
+	return nil
+}
@@ -0,0 +1,265 @@
+package verdaccio
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	"github.com/Goalt/personal-server/internal/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerdaccioModule_Name(t *testing.T) {
+	module := &VerdaccioModule{}
+	if module.Name() != "verdaccio" {
+		t.Errorf("Name() = %s, want verdaccio", module.Name())
+	}
+}
+
+func TestVerdaccioModule_Doc(t *testing.T) {
+	module := &VerdaccioModule{
+		ModuleConfig: config.Module{Name: "verdaccio", Namespace: "infra"},
+		log:          logger.NewNopLogger(),
+	}
+	if err := module.Doc(context.Background()); err != nil {
+		t.Errorf("Doc() returned unexpected error: %v", err)
+	}
+}
+
+func TestVerdaccioModule_Prepare(t *testing.T) {
+	tests := []struct {
+		name        string
+		namespace   string
+		image       string
+		secrets     map[string]string
+		storage     string
+		wantErr     bool
+		wantImage   string
+		wantStorage string
+		wantAccess  string
+	}{
+		{
+			name:      "defaults",
+			namespace: "infra",
+			secrets: map[string]string{
+				"verdaccio_username": "npm",
+				"verdaccio_password": "secret",
+			},
+			wantImage:   "verdaccio/verdaccio:5",
+			wantStorage: "10Gi",
+			wantAccess:  "access: $authenticated",
+		},
+		{
+			name:      "overrides",
+			namespace: "hobby",
+			image:     "verdaccio/verdaccio:6",
+			secrets: map[string]string{
+				"verdaccio_username": "npm",
+				"verdaccio_password": "secret",
+				"public_access":      "true",
+			},
+			storage:     "50Gi",
+			wantImage:   "verdaccio/verdaccio:6",
+			wantStorage: "50Gi",
+			wantAccess:  "access: $all",
+		},
+		{
+			name:      "missing password",
+			namespace: "infra",
+			secrets:   map[string]string{"verdaccio_username": "npm"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid storage size",
+			namespace: "infra",
+			secrets: map[string]string{
+				"verdaccio_username": "npm",
+				"verdaccio_password": "secret",
+			},
+			storage: "lots",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &VerdaccioModule{
+				ModuleConfig: config.Module{
+					Name:      "verdaccio",
+					Namespace: tt.namespace,
+					Image:     tt.image,
+					Storage:   tt.storage,
+					Secrets:   tt.secrets,
+				},
+			}
+
+			configMap, secret, pvc, service, deployment, err := module.prepare()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("prepare() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepare() unexpected error: %v", err)
+			}
+
+			for kind, ns := range map[string]string{
+				"ConfigMap":  configMap.Namespace,
+				"Secret":     secret.Namespace,
+				"PVC":        pvc.Namespace,
+				"Service":    service.Namespace,
+				"Deployment": deployment.Namespace,
+			} {
+				if ns != tt.namespace {
+					t.Errorf("%s namespace = %s, want %s", kind, ns, tt.namespace)
+				}
+			}
+
+			if got := deployment.Spec.Template.Spec.Containers[0].Image; got != tt.wantImage {
+				t.Errorf("image = %s, want %s", got, tt.wantImage)
+			}
+			if got := pvc.Spec.Resources.Requests.Storage().String(); got != tt.wantStorage {
+				t.Errorf("storage = %s, want %s", got, tt.wantStorage)
+			}
+			if !strings.Contains(configMap.Data["config.yaml"], tt.wantAccess) {
+				t.Errorf("config.yaml should contain %q, got:\n%s", tt.wantAccess, configMap.Data["config.yaml"])
+			}
+			if !strings.Contains(configMap.Data["config.yaml"], "max_users: -1") {
+				t.Error("self-registration should be disabled")
+			}
+		})
+	}
+}
+
+func TestHtpasswdEntry(t *testing.T) {
+	entry := htpasswdEntry("npm", "secret")
+	if entry != htpasswdEntry("npm", "secret") {
+		t.Error("htpasswdEntry() should be deterministic")
+	}
+
+	name, hash, ok := strings.Cut(strings.TrimSpace(entry), ":")
+	if !ok || name != "npm" {
+		t.Fatalf("unexpected entry %q", entry)
+	}
+	salt := strings.Split(hash, "$")[2]
+	if k8s.APR1Hash("secret", salt) != hash {
+		t.Error("htpasswd hash does not verify")
+	}
+}
+
+//go:embed testdata/configmap.yaml
+var expectedConfigMapYAML string
+
+//go:embed testdata/secret.yaml
+var expectedSecretYAML string
+
+//go:embed testdata/pvc.yaml
+var expectedPvcYAML string
+
+//go:embed testdata/service.yaml
+var expectedServiceYAML string
+
+//go:embed testdata/deployment.yaml
+var expectedDeploymentYAML string
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	origWd, _ := os.Getwd()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	module := &VerdaccioModule{
+		GeneralConfig: config.GeneralConfig{Domain: "example.com"},
+		ModuleConfig: config.Module{
+			Name:      "verdaccio",
+			Namespace: "infra",
+			Secrets: map[string]string{
+				"verdaccio_username": "npm",
+				"verdaccio_password": "secret",
+			},
+		},
+		log: logger.NewNopLogger(),
+	}
+
+	if err := module.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	cases := []struct{ name, file, want string }{
+		{"configmap", "configs/verdaccio/configmap.yaml", expectedConfigMapYAML},
+		{"secret", "configs/verdaccio/secret.yaml", expectedSecretYAML},
+		{"pvc", "configs/verdaccio/pvc.yaml", expectedPvcYAML},
+		{"service", "configs/verdaccio/service.yaml", expectedServiceYAML},
+		{"deployment", "configs/verdaccio/deployment.yaml", expectedDeploymentYAML},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(tempDir, tc.file))
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tc.file, err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Generated YAML does not match expected.\nGot:\n%s\n\nWant:\n%s", string(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestVerdaccioModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "verdaccio-7d9f", Namespace: "infra", Labels: map[string]string{"app": "verdaccio"}}}
+	module := &VerdaccioModule{ModuleConfig: config.Module{Name: "verdaccio", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "verdaccio-7d9f", Command: []string{"tar", "czf", "-", "-C", storagePath, "."}, Stdout: "archive"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(destDir, "verdaccio", "verdaccio_storage_*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("expected one archive, got %v", archives)
+	}
+	if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+		t.Errorf("archive = %q, want the tar output", data)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "verdaccio", "backup_info.txt")); err != nil {
+		t.Errorf("metadata not written: %v", err)
+	}
+}
+
+func TestVerdaccioModule_RestoreStorageWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "verdaccio-7d9f", Namespace: "infra", Labels: map[string]string{"app": "verdaccio"}}}
+	archive := filepath.Join(t.TempDir(), "verdaccio_storage.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &VerdaccioModule{ModuleConfig: config.Module{Name: "verdaccio", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "verdaccio-7d9f", Command: []string{"sh", "-c", "find " + storagePath + " -mindepth 1 -delete"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "verdaccio-7d9f", Command: []string{"tar", "xzf", "-", "-C", storagePath}, Stdin: "archive"},
+	)
+
+	if err := module.restoreStorageWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreStorageWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
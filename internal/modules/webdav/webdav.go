@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,14 +12,27 @@ import (
 	"github.com/Goalt/personal-server/internal/config"
 	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
+	"github.com/Goalt/personal-server/internal/moduleingress"
+	"github.com/Goalt/personal-server/internal/servicemonitor"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// defaultResources are the main container's requests and limits unless
+// overridden with the cpu_* and memory_* keys
+var defaultResources = k8s.Resources{CPURequest: "10m", MemoryRequest: "32Mi", MemoryLimit: "256Mi"}
+
+// defaultStorageSize is the size of the data volume unless set with the
+// module's storage field
+const defaultStorageSize = "20Gi"
+
+// defaultImage is the main container's image unless set with the module's
+// image field
+const defaultImage = "ghcr.io/hacdias/webdav:latest"
+
 type WebdavModule struct {
 	GeneralConfig config.GeneralConfig
 	ModuleConfig  config.Module
@@ -39,10 +51,42 @@ func (m *WebdavModule) Name() string {
 	return "webdav"
 }
 
+// SupportsServiceMonitor reports that the module creates the ServiceMonitor
+// its metrics section asks for
+func (m *WebdavModule) SupportsServiceMonitor() bool {
+	return true
+}
+
+// Shell opens sh in the backup-helper sidecar, which has the tools the
+// webdav image lacks
+func (m *WebdavModule) Shell() (string, []string) {
+	return "backup-helper", []string{"sh"}
+}
+
+// settings documents the modules[].secrets keys read by this module
+type settings struct {
+	WebdavUsername          string `yaml:"webdav_username" default:"admin" doc:"Username for WebDAV authentication"`
+	WebdavPassword          string `yaml:"webdav_password" required:"true" generate:"true" doc:"Password for WebDAV authentication"`
+	VersioningEnabled       string `yaml:"versioning_enabled" default:"false" doc:"Set to \"true\" to snapshot changed files into /data/.versions"`
+	VersioningInterval      string `yaml:"versioning_interval" default:"3600" doc:"Seconds between snapshots"`
+	VersioningRetentionDays string `yaml:"versioning_retention_days" default:"7" doc:"Days to keep snapshots before pruning"`
+
+	k8s.ProbeSettings    `yaml:",inline"`
+	k8s.ShutdownSettings `yaml:",inline"`
+	k8s.ResourceSettings `yaml:",inline"`
+}
+
+// ConfigSchema describes the module's modules[].secrets keys for config explain
+func (m *WebdavModule) ConfigSchema() interface{} {
+	return settings{}
+}
+
 func (m *WebdavModule) Doc(ctx context.Context) error {
 	m.log.Info("Module: webdav\n\n")
 	m.log.Info("Description:\n  Deploys a WebDAV server used as backup storage for personal-server.\n  Manages a ConfigMap, Secret, PersistentVolumeClaim, Service, and Deployment.\n  The backup system uses WebDAV to store and retrieve encrypted backup archives.\n\n")
 	m.log.Info("Required configuration keys (modules[].secrets):\n  webdav_username   Username for WebDAV authentication\n  webdav_password   Password for WebDAV authentication\n\n")
+	m.log.Info("Optional configuration keys (modules[].secrets):\n  versioning_enabled          Set to \"true\" to run a sidecar that snapshots changed files into /data/.versions\n  versioning_interval         Seconds between snapshots (default: 3600)\n  versioning_retention_days   Days to keep snapshots before pruning (default: 7)\n\n")
+	m.log.Info("Optional module fields:\n  image     Container image (default: %s)\n  ingress   Expose the web interface at <name>.<general.domain>, e.g. ingress: {}\n\n", defaultImage)
 	m.log.Info("Subcommands:\n  generate   Write Kubernetes YAML to configs/webdav/\n  apply      Create/update resources in the cluster\n  clean      Delete all WebDAV resources from the cluster\n  status     Print Deployment and Pod status\n  doc        Show this documentation\n  backup     Archive the WebDAV data volume to the destination directory\n  restore    Restore the WebDAV data volume from a backup archive\n")
 	return nil
 }
@@ -60,7 +104,10 @@ func (m *WebdavModule) Generate(ctx context.Context) error {
 	m.log.Info("Output directory: %s\n\n", outputDir)
 
 	// Prepare Kubernetes objects
-	configMap, secret, pvc, service, deployment := m.prepare()
+	configMap, secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Helper function to write object to YAML file
 	writeYAML := func(obj interface{}, name string) error {
@@ -104,14 +151,37 @@ func (m *WebdavModule) Generate(ctx context.Context) error {
 	if err := writeYAML(deployment, "deployment"); err != nil {
 		return err
 	}
+	total := 5
 
-	m.log.Info("\nCompleted: 5/5 WebDAV configurations generated successfully\n")
+	// Write the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		objects, err := route.Objects()
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := writeYAML(object.Object, object.File); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+
+	// Write the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		if err := writeYAML(monitor.Object(), "servicemonitor"); err != nil {
+			return err
+		}
+		total++
+	}
+
+	m.log.Info("\nCompleted: %d/%d WebDAV configurations generated successfully\n", total, total)
 	return nil
 }
 
 func (m *WebdavModule) Apply(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -159,7 +229,10 @@ func (m *WebdavModule) Apply(ctx context.Context) error {
 	m.log.Info("No existing resources found, proceeding with creation...\n\n")
 
 	// Prepare Kubernetes objects
-	configMap, secret, pvc, service, deployment := m.prepare()
+	configMap, secret, pvc, service, deployment, err := m.prepare()
+	if err != nil {
+		return fmt.Errorf("failed to prepare resources: %w", err)
+	}
 
 	// Apply ConfigMap
 	m.log.Progress("Applying ConfigMap: webdav-config\n")
@@ -201,11 +274,38 @@ func (m *WebdavModule) Apply(ctx context.Context) error {
 	}
 	m.log.Success("Created Deployment: %s\n", createdDeployment.Name)
 
+	// Apply the Ingress and basic-auth Secret when the module is exposed
+	if route := moduleingress.Config(m.ModuleConfig, m.GeneralConfig, service, false); route != nil {
+		m.log.Progress("Applying Ingress: %s\n", route.Name)
+		if err := route.Apply(ctx, clientset); err != nil {
+			return err
+		}
+		m.log.Success("Applied Ingress: %s\n", route.Name)
+	}
+
+	// Apply the ServiceMonitor when the metrics are scraped by prometheus-operator
+	if monitor := servicemonitor.Config(m.ModuleConfig, m.GeneralConfig, service); monitor != nil {
+		m.log.Progress("Applying ServiceMonitor: %s\n", monitor.Name)
+		dyn, err := m.GeneralConfig.Clients.Dynamic()
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			return err
+		}
+		m.log.Success("Applied ServiceMonitor: %s\n", monitor.Name)
+	}
+
 	m.log.Info("\nCompleted: 5/5 resources applied successfully\n")
 	return nil
 }
 
-func (m *WebdavModule) prepare() (*corev1.ConfigMap, *corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment) {
+func (m *WebdavModule) prepare() (*corev1.ConfigMap, *corev1.Secret, *corev1.PersistentVolumeClaim, *corev1.Service, *appsv1.Deployment, error) {
+	password := k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "webdav_password", "")
+	if password == "" {
+		return nil, nil, nil, nil, nil, fmt.Errorf("webdav_password not found in configuration")
+	}
+
 	// Prepare ConfigMap
 	configMapData := `# WebDAV Server Configuration
 address: 0.0.0.0
@@ -308,12 +408,15 @@ users:
 		Type: corev1.SecretTypeOpaque,
 		StringData: map[string]string{
 			"webdav_username": k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "webdav_username", "admin"),
-			"webdav_password": k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "webdav_password", "abc"),
+			"webdav_password": password,
 		},
 	}
 
 	// Prepare PVC
-	storageQuantity := resource.MustParse("20Gi")
+	storageSize, err := m.ModuleConfig.StorageSize(defaultStorageSize)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
 	pvc := &corev1.PersistentVolumeClaim{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -333,11 +436,12 @@ users:
 			},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: storageQuantity,
+					corev1.ResourceStorage: storageSize,
 				},
 			},
 		},
 	}
+	k8s.SetStorageClass(&pvc.Spec, m.ModuleConfig.StorageClassName(m.GeneralConfig))
 
 	// Prepare Service
 	service := &corev1.Service{
@@ -368,8 +472,10 @@ users:
 			},
 		},
 	}
+	k8s.SetIPFamilyPolicy(service, m.GeneralConfig.IPFamilyPolicy)
 
 	// Prepare Deployment
+	image := m.ModuleConfig.ContainerImage(defaultImage)
 	replicas := int32(1)
 	runAsUser := int64(1000)
 	runAsGroup := int64(1000)
@@ -412,8 +518,8 @@ users:
 					Containers: []corev1.Container{
 						{
 							Name:            "webdav",
-							Image:           "ghcr.io/hacdias/webdav:latest",
-							ImagePullPolicy: k8s.DefaultImagePullPolicy("ghcr.io/hacdias/webdav:latest"),
+							Image:           image,
+							ImagePullPolicy: k8s.DefaultImagePullPolicy(image),
 							Args: []string{
 								"-c",
 								"/config/config.yaml",
@@ -425,6 +531,24 @@ users:
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       20,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromString("http"),
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+							},
 							Env: []corev1.EnvVar{
 								{
 									Name: "WEBDAV_USERNAME",
@@ -524,12 +648,120 @@ users:
 		},
 	}
 
-	return configMap, secret, pvc, service, deployment
+	if m.versioningEnabled() {
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, m.versioningContainer())
+	}
+
+	if err := k8s.ApplyProbeOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	if err := k8s.ApplyShutdownOverrides(&deployment.Spec.Template.Spec, m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	k8s.SetResources(&deployment.Spec.Template.Spec.Containers[0], defaultResources)
+	if err := k8s.ApplyResourceOverrides(&deployment.Spec.Template.Spec.Containers[0], m.ModuleConfig.Secrets); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetPodEnvironment(&deployment.Spec.Template.Spec, m.GeneralConfig.PodEnvironment())
+	if err := k8s.SetConfigChecksum(&deployment.Spec.Template, configMap, secret); err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	k8s.SetObjectMetadata(m.ModuleConfig.ObjectMetadata(m.GeneralConfig), configMap, secret, pvc, service, deployment)
+	return configMap, secret, pvc, service, deployment, nil
+}
+
+const (
+	defaultVersioningInterval      = "3600"
+	defaultVersioningRetentionDays = "7"
+)
+
+// versioningScript snapshots files changed since the previous run into
+// /data/.versions/<timestamp>/ and prunes snapshots older than the retention
+// period. The first run takes a full snapshot so that files which are never
+// modified afterwards can still be recovered after an accidental delete.
+const versioningScript = `VERSIONS=/data/.versions
+MARKER="$VERSIONS/.last-snapshot"
+mkdir -p "$VERSIONS"
+snapshot() {
+  STAMP=$(date +%Y%m%d_%H%M%S)
+  touch "$VERSIONS/.next-snapshot"
+  cd /data || return
+  if [ -f "$MARKER" ]; then
+    FILES=$(find . -path ./.versions -prune -o -type f -newer "$MARKER" -print)
+  else
+    FILES=$(find . -path ./.versions -prune -o -type f -print)
+  fi
+  echo "$FILES" | while IFS= read -r f; do
+    [ -n "$f" ] || continue
+    mkdir -p "$VERSIONS/$STAMP/$(dirname "$f")" && cp -p "$f" "$VERSIONS/$STAMP/$f"
+  done
+  mv "$VERSIONS/.next-snapshot" "$MARKER"
+  find "$VERSIONS" -mindepth 1 -maxdepth 1 -type d -mtime +"$VERSIONING_RETENTION_DAYS" -exec rm -rf {} +
+}
+[ -f "$MARKER" ] || snapshot
+while true; do
+  sleep "$VERSIONING_INTERVAL"
+  snapshot
+done
+`
+
+func (m *WebdavModule) versioningEnabled() bool {
+	return k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "versioning_enabled", "false") == "true"
+}
+
+// versioningContainer returns a sidecar that keeps point-in-time copies of
+// changed files under /data/.versions, protecting against accidental deletes
+// between backups.
+func (m *WebdavModule) versioningContainer() corev1.Container {
+	runAsNonRoot := true
+	runAsUser := int64(1000)
+	runAsGroup := int64(1000)
+	allowPrivilegeEscalation := false
+	readOnlyRootFilesystem := true
+
+	return corev1.Container{
+		Name:            "versioning",
+		Image:           "busybox:latest",
+		ImagePullPolicy: k8s.DefaultImagePullPolicy("busybox:latest"),
+		Command: []string{
+			"sh",
+			"-c",
+			versioningScript,
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "VERSIONING_INTERVAL",
+				Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "versioning_interval", defaultVersioningInterval),
+			},
+			{
+				Name:  "VERSIONING_RETENTION_DAYS",
+				Value: k8s.GetSecretOrDefault(m.ModuleConfig.Secrets, "versioning_retention_days", defaultVersioningRetentionDays),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "webdav-data",
+				MountPath: "/data",
+			},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsNonRoot:             &runAsNonRoot,
+			RunAsUser:                &runAsUser,
+			RunAsGroup:               &runAsGroup,
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+	}
 }
 
 func (m *WebdavModule) Clean(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -615,6 +847,22 @@ func (m *WebdavModule) Clean(ctx context.Context) error {
 		successCount++
 	}
 
+	// Delete the Ingress and basic-auth Secret, if the module was exposed
+	if deleted, err := moduleingress.Delete(ctx, clientset, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted Ingress: %s\n", m.ModuleConfig.Name)
+	}
+
+	// Delete the ServiceMonitor, if the module had one
+	if dyn, err := m.GeneralConfig.Clients.Dynamic(); err != nil {
+		m.log.Error("Failed to create Kubernetes client: %v\n", err)
+	} else if deleted, err := servicemonitor.Delete(ctx, dyn, m.ModuleConfig); err != nil {
+		m.log.Error("%v\n", err)
+	} else if deleted {
+		m.log.Success("Deleted ServiceMonitor: %s\n", m.ModuleConfig.Name)
+	}
+
 	m.log.Info("\nCompleted: %d/%d resources deleted successfully\n", successCount, totalResources)
 	if successCount > 0 {
 		m.log.Println("\nNote: Resource deletion is asynchronous and may take some time to complete.")
@@ -624,7 +872,7 @@ func (m *WebdavModule) Clean(ctx context.Context) error {
 
 func (m *WebdavModule) Status(ctx context.Context) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
@@ -758,13 +1006,30 @@ func (m *WebdavModule) Status(ctx context.Context) error {
 	return nil
 }
 
+// findPod returns the name of the webdav pod exec commands run in
+func (m *WebdavModule) findPod(ctx context.Context, client k8s.KubernetesClient) (string, error) {
+	pods, err := client.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=webdav",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no running pod found for app=webdav")
+	}
+	return pods.Items[0].Name, nil
+}
+
 func (m *WebdavModule) Backup(ctx context.Context, destDir string) error {
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return m.backupWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), destDir)
+}
 
+func (m *WebdavModule) backupWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, destDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
 	var backupDir string
 	if destDir != "" {
@@ -784,49 +1049,31 @@ func (m *WebdavModule) Backup(ctx context.Context, destDir string) error {
 	// 1. Backup data volume
 	m.log.Info("💾 Backing up data volume (/data)...\n")
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=webdav",
-	})
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=webdav")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	dataBackupFile := filepath.Join(backupDir, fmt.Sprintf("webdav_data_%s.tar.gz", timestamp))
 
-	kubectlCmd := "kubectl"
-	kubectlArgs := []string{}
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s"
-		kubectlArgs = append(kubectlArgs, "kubectl")
-	}
-
 	// Use the backup-helper sidecar container which has tar
 	m.log.Info("📦 Creating archive using backup-helper container...\n")
 
-	// kubectl exec -n <namespace> <pod> -c backup-helper -- tar czf - -C /data .
-	execArgs := append(kubectlArgs, "exec", "-n", m.ModuleConfig.Namespace, podName,
-		"-c", "backup-helper",
-		"--",
-		"tar", "czf", "-", "-C", "/data", ".")
-
-	execCmd := exec.CommandContext(ctx, kubectlCmd, execArgs...)
-
 	outFile, err := os.Create(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to create data backup file: %w", err)
 	}
 	defer outFile.Close()
 
-	execCmd.Stdout = outFile
-	execCmd.Stderr = os.Stderr
-
-	if err := execCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Container: "backup-helper",
+		Command:   []string{"tar", "czf", "-", "-C", "/data", "."},
+		Stdout:    outFile,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to create archive: %w", err)
 	}
 
@@ -834,7 +1081,7 @@ func (m *WebdavModule) Backup(ctx context.Context, destDir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to stat data backup file: %w", err)
 	}
-	m.log.Success("✅ Data archived (%d bytes)\n", fileInfo.Size())
+	m.log.Success("Data archived (%d bytes)\n", fileInfo.Size())
 
 	// 2. Metadata
 	m.log.Info("📋 Writing metadata...\n")
@@ -857,7 +1104,7 @@ personal-server webdav restore %s
 	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
-	m.log.Success("✅ Metadata written\n")
+	m.log.Success("Metadata written\n")
 
 	m.log.Success("🎉 Backup complete!\n")
 	m.log.Info("💡 To restore: personal-server webdav restore %s\n", timestamp)
@@ -917,69 +1164,61 @@ func (m *WebdavModule) Restore(ctx context.Context, args []string) error {
 	m.log.Info("💾 Data will be restored from %s\n", dataBackupFile)
 
 	// Create Kubernetes client
-	clientset, err := k8s.CreateKubernetesClient()
+	clientset, err := m.GeneralConfig.Clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	if err := m.restoreDataWithClient(ctx, clientset, m.GeneralConfig.Clients.Executor(), dataBackupFile); err != nil {
+		return err
+	}
 
-	// Find Pod
-	pods, err := clientset.CoreV1().Pods(m.ModuleConfig.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=webdav",
-	})
+	m.log.Success("🎉 Restore complete!\n")
+	return nil
+}
+
+// restoreDataWithClient replaces /data in the webdav pod with the contents of
+// a tar archive written by Backup
+func (m *WebdavModule) restoreDataWithClient(ctx context.Context, client k8s.KubernetesClient, executor k8s.Executor, dataBackupFile string) error {
+	podName, err := m.findPod(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running pod found for app=webdav")
+		return err
 	}
-	podName := pods.Items[0].Name
 	m.log.Info("📦 Using pod: %s\n", podName)
 
 	// Restore data
 	m.log.Info("💾 Restoring data...\n")
 
-	kubectlCmd := "kubectl"
-	kubectlArgs := []string{}
-	if _, err := os.Stat("/snap/bin/microk8s"); err == nil {
-		kubectlCmd = "/snap/bin/microk8s"
-		kubectlArgs = append(kubectlArgs, "kubectl")
-	}
-
 	// 1. Clean existing data using backup-helper container
 	m.log.Info("🗑️  Cleaning existing data...\n")
-	cleanArgs := append(kubectlArgs, "exec", "-n", m.ModuleConfig.Namespace, podName,
-		"-c", "backup-helper",
-		"--",
-		"sh", "-c", "rm -rf /data/*")
-	cleanCmd := exec.CommandContext(ctx, kubectlCmd, cleanArgs...)
-	cleanCmd.Stderr = os.Stderr
-	if err := cleanCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Container: "backup-helper",
+		Command:   []string{"sh", "-c", "rm -rf /data/*"},
+		Stderr:    os.Stderr,
+	}); err != nil {
 		m.log.Warn("Warning during clean: %v\n", err)
 	}
 
 	// 2. Restore from tar using backup-helper container (it has tar and write access)
 	m.log.Info("📦 Restoring from archive...\n")
-	restoreArgs := append(kubectlArgs, "exec", "-i", "-n", m.ModuleConfig.Namespace, podName,
-		"-c", "backup-helper",
-		"--",
-		"tar", "xzf", "-", "-C", "/data")
-	restoreCmd := exec.CommandContext(ctx, kubectlCmd, restoreArgs...)
-
 	inFile, err := os.Open(dataBackupFile)
 	if err != nil {
 		return fmt.Errorf("failed to open data backup file: %w", err)
 	}
 	defer inFile.Close()
 
-	restoreCmd.Stdin = inFile
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-
-	if err := restoreCmd.Run(); err != nil {
+	if err := executor.Exec(ctx, k8s.ExecRequest{
+		Namespace: m.ModuleConfig.Namespace,
+		Pod:       podName,
+		Container: "backup-helper",
+		Command:   []string{"tar", "xzf", "-", "-C", "/data"},
+		Stdin:     inFile,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}); err != nil {
 		return fmt.Errorf("failed to restore data: %w", err)
 	}
-	m.log.Success("✅ Data restored\n")
-
-	m.log.Success("🎉 Restore complete!\n")
+	m.log.Success("Data restored\n")
 	return nil
 }
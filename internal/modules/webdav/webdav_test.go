@@ -9,9 +9,12 @@ import (
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestWebdavModule_Name(t *testing.T) {
@@ -45,10 +48,14 @@ func TestWebdavModule_Prepare(t *testing.T) {
 				ModuleConfig: config.Module{
 					Name:      "webdav",
 					Namespace: tt.namespace,
+					Secrets:   map[string]string{"webdav_password": "secret"},
 				},
 			}
 
-			configMap, secret, pvc, service, deployment := module.prepare()
+			configMap, secret, pvc, service, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			// Verify all objects are not nil
 			if configMap == nil {
@@ -95,10 +102,14 @@ func TestWebdavModule_PrepareConfigMap(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	configMap, _, _, _, _ := module.prepare()
+	configMap, _, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test ConfigMap name
 	if configMap.Name != "webdav-config" {
@@ -154,7 +165,10 @@ func TestWebdavModule_PrepareSecret(t *testing.T) {
 		},
 	}
 
-	_, secret, _, _, _ := module.prepare()
+	_, secret, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Secret name
 	if secret.Name != "webdav-secrets" {
@@ -196,18 +210,38 @@ func TestWebdavModule_PrepareSecretDefaults(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
-			Secrets:   map[string]string{}, // Empty secrets to test defaults
+			Secrets:   map[string]string{"webdav_password": "secret"}, // Only the required key, to test defaults
 		},
 	}
 
-	_, secret, _, _, _ := module.prepare()
+	_, secret, _, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test default values are used
 	if secret.StringData["webdav_username"] != "admin" {
 		t.Errorf("Secret webdav_username default = %s, want admin", secret.StringData["webdav_username"])
 	}
-	if secret.StringData["webdav_password"] != "abc" {
-		t.Errorf("Secret webdav_password default = %s, want abc", secret.StringData["webdav_password"])
+}
+
+func TestWebdavModule_PrepareMissingPassword(t *testing.T) {
+	module := &WebdavModule{
+		ModuleConfig: config.Module{
+			Name:      "webdav",
+			Namespace: "test-namespace",
+			Secrets:   map[string]string{},
+		},
+	}
+
+	_, _, _, _, _, err := module.prepare()
+	if err == nil {
+		t.Fatal("prepare() expected error for missing webdav_password, got nil")
+	}
+
+	expectedErr := "webdav_password not found in configuration"
+	if err.Error() != expectedErr {
+		t.Errorf("prepare() error = %s, want %s", err.Error(), expectedErr)
 	}
 }
 
@@ -219,10 +253,14 @@ func TestWebdavModule_PreparePVC(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	_, _, pvc, _, _ := module.prepare()
+	_, _, pvc, _, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test PVC name
 	if pvc.Name != "webdav-data-pvc" {
@@ -266,10 +304,14 @@ func TestWebdavModule_PrepareService(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	_, _, _, service, _ := module.prepare()
+	_, _, _, service, _, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Service name
 	if service.Name != "webdav-service" {
@@ -327,10 +369,14 @@ func TestWebdavModule_PrepareDeployment(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	_, _, _, _, deployment := module.prepare()
+	_, _, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test Deployment name
 	if deployment.Name != "webdav" {
@@ -386,10 +432,14 @@ func TestWebdavModule_PrepareDeploymentContainer(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	_, _, _, _, deployment := module.prepare()
+	_, _, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify container count - should have webdav and backup-helper
 	if len(deployment.Spec.Template.Spec.Containers) != 2 {
@@ -493,10 +543,14 @@ func TestWebdavModule_PrepareDeploymentBackupHelperContainer(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	_, _, _, _, deployment := module.prepare()
+	_, _, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Find the backup-helper container
 	var backupHelper *corev1.Container
@@ -556,10 +610,14 @@ func TestWebdavModule_PrepareDeploymentContainerSecurityContext(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	_, _, _, _, deployment := module.prepare()
+	_, _, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Find the webdav container
 	var container *corev1.Container
@@ -625,10 +683,14 @@ func TestWebdavModule_PrepareDeploymentVolumes(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "test-namespace",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 	}
 
-	_, _, _, _, deployment := module.prepare()
+	_, _, _, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Test volumes
 	if len(deployment.Spec.Template.Spec.Volumes) != 2 {
@@ -697,6 +759,7 @@ func TestGenerate(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "webdav",
 			Namespace: "infra",
+			Secrets:   map[string]string{"webdav_password": "secret"},
 		},
 		log: logger.Default(),
 	}
@@ -735,3 +798,140 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestWebdavModule_PrepareVersioningSidecar(t *testing.T) {
+	tests := []struct {
+		name          string
+		secrets       map[string]string
+		wantSidecar   bool
+		wantInterval  string
+		wantRetention string
+	}{
+		{
+			name:        "disabled by default",
+			secrets:     nil,
+			wantSidecar: false,
+		},
+		{
+			name:          "enabled with defaults",
+			secrets:       map[string]string{"versioning_enabled": "true"},
+			wantSidecar:   true,
+			wantInterval:  "3600",
+			wantRetention: "7",
+		},
+		{
+			name: "enabled with overrides",
+			secrets: map[string]string{
+				"versioning_enabled":        "true",
+				"versioning_interval":       "600",
+				"versioning_retention_days": "30",
+			},
+			wantSidecar:   true,
+			wantInterval:  "600",
+			wantRetention: "30",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secrets := map[string]string{"webdav_password": "secret"}
+			for k, v := range tt.secrets {
+				secrets[k] = v
+			}
+			module := &WebdavModule{
+				ModuleConfig: config.Module{
+					Name:      "webdav",
+					Namespace: "test-namespace",
+					Secrets:   secrets,
+				},
+			}
+
+			_, _, _, _, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
+
+			var sidecar *corev1.Container
+			for i := range deployment.Spec.Template.Spec.Containers {
+				if deployment.Spec.Template.Spec.Containers[i].Name == "versioning" {
+					sidecar = &deployment.Spec.Template.Spec.Containers[i]
+				}
+			}
+
+			if !tt.wantSidecar {
+				if sidecar != nil {
+					t.Fatal("versioning container should not be present")
+				}
+				return
+			}
+			if sidecar == nil {
+				t.Fatal("versioning container not found")
+			}
+
+			env := map[string]string{}
+			for _, e := range sidecar.Env {
+				env[e.Name] = e.Value
+			}
+			if env["VERSIONING_INTERVAL"] != tt.wantInterval {
+				t.Errorf("VERSIONING_INTERVAL = %q, want %q", env["VERSIONING_INTERVAL"], tt.wantInterval)
+			}
+			if env["VERSIONING_RETENTION_DAYS"] != tt.wantRetention {
+				t.Errorf("VERSIONING_RETENTION_DAYS = %q, want %q", env["VERSIONING_RETENTION_DAYS"], tt.wantRetention)
+			}
+			if len(sidecar.Command) != 3 || !strings.Contains(sidecar.Command[2], "/data/.versions") {
+				t.Errorf("versioning command should snapshot into /data/.versions, got %v", sidecar.Command)
+			}
+			if len(sidecar.VolumeMounts) != 1 || sidecar.VolumeMounts[0].MountPath != "/data" {
+				t.Errorf("versioning container should mount /data, got %v", sidecar.VolumeMounts)
+			}
+		})
+	}
+}
+
+func TestWebdavModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "webdav-7d9f", Namespace: "infra", Labels: map[string]string{"app": "webdav"}}}
+	module := &WebdavModule{ModuleConfig: config.Module{Name: "webdav", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "webdav-7d9f", Container: "backup-helper", Command: []string{"tar", "czf", "-", "-C", "/data", "."}, Stdout: "archive"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(destDir, "webdav", "webdav_data_*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("expected one archive, got %v", archives)
+	}
+	if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+		t.Errorf("archive = %q, want the tar output", data)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "webdav", "backup_info.txt")); err != nil {
+		t.Errorf("metadata not written: %v", err)
+	}
+}
+
+func TestWebdavModule_RestoreDataWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "webdav-7d9f", Namespace: "infra", Labels: map[string]string{"app": "webdav"}}}
+	archive := filepath.Join(t.TempDir(), "webdav_data.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &WebdavModule{ModuleConfig: config.Module{Name: "webdav", Namespace: "infra"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "infra", Pod: "webdav-7d9f", Container: "backup-helper", Command: []string{"sh", "-c", "rm -rf /data/*"}},
+		k8s.ExecRecord{Namespace: "infra", Pod: "webdav-7d9f", Container: "backup-helper", Command: []string{"tar", "xzf", "-", "-C", "/data"}, Stdin: "archive"},
+	)
+
+	if err := module.restoreDataWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreDataWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"testing"
 
 	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
 	"github.com/Goalt/personal-server/internal/logger"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestWorkPodModule_Name(t *testing.T) {
@@ -52,7 +55,10 @@ func TestWorkPodModule_Prepare(t *testing.T) {
 				},
 			}
 
-			pvc, service, deployment := module.prepare()
+			pvc, service, deployment, err := module.prepare()
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
 
 			// Verify PVC is not nil
 			if pvc == nil {
@@ -259,13 +265,14 @@ func TestWorkPodModule_PrepareWithCustomImageTag(t *testing.T) {
 		ModuleConfig: config.Module{
 			Name:      "workpod",
 			Namespace: "default",
-			Secrets: map[string]string{
-				"image_tag": customImageTag,
-			},
+			Image:     customImageTag,
 		},
 	}
 
-	_, _, deployment := module.prepare()
+	_, _, deployment, err := module.prepare()
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
 
 	// Verify deployment is not nil
 	if deployment == nil {
@@ -357,3 +364,51 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkPodModule_BackupWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "work-pod-7d9f", Namespace: "work", Labels: map[string]string{"app": "work-pod"}}}
+	module := &WorkPodModule{ModuleConfig: config.Module{Name: "workpod", Namespace: "work"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "work", Pod: "work-pod-7d9f", Command: []string{"tar", "czf", "-", "-C", "/data", "."}, Stdout: "archive"},
+	)
+
+	destDir := t.TempDir()
+	if err := module.backupWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, destDir); err != nil {
+		t.Fatalf("backupWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(destDir, "workpod", "workpod_data_*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("expected one archive, got %v", archives)
+	}
+	if data, _ := os.ReadFile(archives[0]); string(data) != "archive" {
+		t.Errorf("archive = %q, want the tar output", data)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "workpod", "backup_info.txt")); err != nil {
+		t.Errorf("metadata not written: %v", err)
+	}
+}
+
+func TestWorkPodModule_RestoreDataWithClient(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "work-pod-7d9f", Namespace: "work", Labels: map[string]string{"app": "work-pod"}}}
+	archive := filepath.Join(t.TempDir(), "workpod_data.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := &WorkPodModule{ModuleConfig: config.Module{Name: "workpod", Namespace: "work"}, log: logger.NewNopLogger()}
+	executor := k8s.NewReplayExecutor(
+		k8s.ExecRecord{Namespace: "work", Pod: "work-pod-7d9f", Command: []string{"sh", "-c", "rm -rf /data/*"}},
+		k8s.ExecRecord{Namespace: "work", Pod: "work-pod-7d9f", Command: []string{"tar", "xzf", "-", "-C", "/data"}, Stdin: "archive"},
+	)
+
+	if err := module.restoreDataWithClient(context.Background(), fake.NewSimpleClientset(pod), executor, archive); err != nil {
+		t.Fatalf("restoreDataWithClient() error = %v", err)
+	}
+	if err := executor.Verify(); err != nil {
+		t.Error(err)
+	}
+}
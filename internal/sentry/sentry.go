@@ -0,0 +1,126 @@
+// Package sentry sends events to Sentry, the notification channel used by the
+// ssh-login-notifier and postgres maintenance.
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Event is a message reported to Sentry
+type Event struct {
+	Message string
+	Level   string // info, warning or error; empty means info
+	Logger  string // the component reporting the event
+	Tags    map[string]string
+	Extra   map[string]interface{}
+}
+
+// SendEvent posts event to the project identified by dsn
+func SendEvent(ctx context.Context, dsn string, event Event) error {
+	return send(ctx, &http.Client{Timeout: 10 * time.Second}, dsn, event)
+}
+
+func send(ctx context.Context, client *http.Client, dsn string, event Event) error {
+	// Parse DSN to extract components
+	// DSN format: https://{key}@{org}.ingest.sentry.io/{project}
+
+	// Remove https:// prefix
+	if !strings.HasPrefix(dsn, "https://") {
+		return fmt.Errorf("invalid Sentry DSN: must start with https://")
+	}
+	dsn = strings.TrimPrefix(dsn, "https://")
+
+	// Split by @ to get key and rest
+	parts := strings.SplitN(dsn, "@", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid Sentry DSN format: missing @ separator")
+	}
+	sentryKey := parts[0]
+
+	// Split the rest by / to get host and project
+	hostAndProject := strings.SplitN(parts[1], "/", 2)
+	if len(hostAndProject) != 2 {
+		return fmt.Errorf("invalid Sentry DSN format: missing project ID")
+	}
+	sentryHost := hostAndProject[0]
+	sentryProject := hostAndProject[1]
+
+	// Get hostname
+	hostname, _ := os.Hostname()
+
+	// Generate proper UUID for event_id (32-char hex, no dashes)
+	eventID := strings.Replace(fmt.Sprintf("%032x", time.Now().UnixNano()), "-", "", -1)
+	if len(eventID) > 32 {
+		eventID = eventID[:32]
+	}
+
+	level := event.Level
+	if level == "" {
+		level = "info"
+	}
+
+	// Create event with Sentry v7 API format
+	now := time.Now().UTC()
+	payload := map[string]interface{}{
+		"event_id":    eventID,
+		"timestamp":   now.Unix(), // Unix timestamp
+		"platform":    "other",
+		"level":       level,
+		"logger":      event.Logger,
+		"message":     event.Message,
+		"server_name": hostname,
+		"tags":        event.Tags,
+		"extra":       event.Extra,
+	}
+
+	// Marshal to JSON
+	eventJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	// Send to Sentry using envelope format
+	// Envelope format: {headers}\n{item_header}\n{payload}
+	envelopeHeaders := map[string]interface{}{
+		"event_id": eventID,
+		"sent_at":  now.Format(time.RFC3339),
+	}
+	envelopeHeadersJSON, _ := json.Marshal(envelopeHeaders)
+
+	itemHeader := map[string]interface{}{
+		"type": "event",
+	}
+	itemHeaderJSON, _ := json.Marshal(itemHeader)
+
+	// Build envelope: line1=envelope headers, line2=item header, line3=event
+	envelope := fmt.Sprintf("%s\n%s\n%s", string(envelopeHeadersJSON), string(itemHeaderJSON), string(eventJSON))
+
+	// Send to Sentry envelope endpoint
+	apiURL := fmt.Sprintf("https://%s/api/%s/envelope/", sentryHost, sentryProject)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBufferString(envelope))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=personal-server/1.0", sentryKey))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Sentry API returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotEvent map[string]interface{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("X-Sentry-Auth")
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(string(body), "\n")
+		if len(lines) != 3 {
+			t.Errorf("envelope has %d lines, want 3", len(lines))
+			return
+		}
+		if err := json.Unmarshal([]byte(lines[2]), &gotEvent); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	dsn := "https://public-key@" + strings.TrimPrefix(server.URL, "https://") + "/42"
+	err := send(context.Background(), server.Client(), dsn, Event{
+		Message: "maintenance done",
+		Logger:  "postgres",
+		Tags:    map[string]string{"namespace": "infra"},
+		Extra:   map[string]interface{}{"duration": "3s"},
+	})
+	if err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	if gotPath != "/api/42/envelope/" {
+		t.Errorf("path = %s, want /api/42/envelope/", gotPath)
+	}
+	if !strings.Contains(gotAuth, "sentry_key=public-key") {
+		t.Errorf("X-Sentry-Auth = %s, want sentry_key=public-key", gotAuth)
+	}
+	if gotEvent["message"] != "maintenance done" || gotEvent["level"] != "info" || gotEvent["logger"] != "postgres" {
+		t.Errorf("event = %v, want message, default level and logger", gotEvent)
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"plain http", "http://key@sentry.io/1", "must start with https://"},
+		{"missing key", "https://sentry.io/1", "missing @ separator"},
+		{"missing project", "https://key@sentry.io", "missing project ID"},
+		{"rejected", "https://key@" + strings.TrimPrefix(server.URL, "https://") + "/1", "status 403"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := send(context.Background(), server.Client(), tt.dsn, Event{Message: "test"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("send() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package servicemonitor builds the prometheus-operator ServiceMonitor that
+// has a Prometheus run by the operator scrape a module's metrics, for modules
+// whose metrics section sets serviceMonitor: true. Without it the prometheus
+// module finds the module's Services by their prometheus.io annotations.
+package servicemonitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Goalt/personal-server/internal/config"
+	"github.com/Goalt/personal-server/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// GVR identifies prometheus-operator ServiceMonitors for the dynamic client
+var GVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+
+// Monitor is the ServiceMonitor of one module
+type Monitor struct {
+	Name      string
+	Namespace string
+	Selector  map[string]string // labels of the module's Service
+	// Port names the Service port serving the metrics; TargetPort is the
+	// container port instead when that port has no name
+	Port       string
+	TargetPort int32
+	Path       string
+	Scheme     string
+	Interval   string
+	Metadata   k8s.ObjectMetadata
+}
+
+// Config returns the Monitor of the module's metrics on service, or nil
+// unless its metrics section asks for a ServiceMonitor. The metrics port is
+// found among the Service's ports by container port, else the Service's http
+// or first port is used.
+func Config(module config.Module, general config.GeneralConfig, service *corev1.Service) *Monitor {
+	if module.Metrics == nil || !module.Metrics.ServiceMonitor || service == nil {
+		return nil
+	}
+	monitor := &Monitor{
+		Name:      module.Name,
+		Namespace: module.Namespace,
+		Selector:  service.Labels,
+		Path:      module.Metrics.Path,
+		Scheme:    module.Metrics.Scheme,
+		Interval:  module.Metrics.Interval,
+		Metadata:  module.ObjectMetadata(general),
+	}
+	if monitor.Path == "" {
+		monitor.Path = "/metrics"
+	}
+	if monitor.Scheme == "" {
+		monitor.Scheme = "http"
+	}
+
+	port := k8s.WebPort(service)
+	if module.Metrics.Port != 0 {
+		port = nil
+		for i, p := range service.Spec.Ports {
+			if p.TargetPort.IntVal == module.Metrics.Port || (p.TargetPort.IntVal == 0 && p.Port == module.Metrics.Port) {
+				port = &service.Spec.Ports[i]
+				break
+			}
+		}
+	}
+	if port != nil && port.Name != "" {
+		monitor.Port = port.Name
+	} else if module.Metrics.Port != 0 {
+		monitor.TargetPort = module.Metrics.Port
+	} else {
+		monitor.TargetPort = k8s.MetricsPort(service)
+	}
+	return monitor
+}
+
+// Object returns the ServiceMonitor selecting the module's Service
+func (m *Monitor) Object() *unstructured.Unstructured {
+	selector := map[string]interface{}{}
+	for key, value := range m.Selector {
+		selector[key] = value
+	}
+	endpoint := map[string]interface{}{
+		"path":   m.Path,
+		"scheme": m.Scheme,
+	}
+	if m.Port != "" {
+		endpoint["port"] = m.Port
+	} else {
+		endpoint["targetPort"] = int64(m.TargetPort)
+	}
+	if m.Interval != "" {
+		endpoint["interval"] = m.Interval
+	}
+
+	monitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "ServiceMonitor",
+		"metadata": map[string]interface{}{
+			"name":      m.Name,
+			"namespace": m.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"selector":  map[string]interface{}{"matchLabels": selector},
+			"endpoints": []interface{}{endpoint},
+		},
+	}}
+	k8s.SetObjectMetadata(m.Metadata, monitor)
+	return monitor
+}
+
+// Apply creates the ServiceMonitor, replacing an existing one. It fails when
+// prometheus-operator's CRDs are not installed.
+func (m *Monitor) Apply(ctx context.Context, dyn dynamic.Interface) error {
+	monitor := m.Object()
+	monitors := dyn.Resource(GVR).Namespace(m.Namespace)
+	existing, err := monitors.Get(ctx, m.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = monitors.Create(ctx, monitor, metav1.CreateOptions{})
+	} else if err == nil {
+		monitor.SetResourceVersion(existing.GetResourceVersion())
+		_, err = monitors.Update(ctx, monitor, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply ServiceMonitor %s (is prometheus-operator installed?): %w", m.Name, err)
+	}
+	return nil
+}
+
+// Delete removes the module's ServiceMonitor and reports whether there was
+// one. ServiceMonitors the tool did not create, and clusters without
+// prometheus-operator, are left alone.
+func Delete(ctx context.Context, dyn dynamic.Interface, module config.Module) (bool, error) {
+	monitors := dyn.Resource(GVR).Namespace(module.Namespace)
+	existing, err := monitors.Get(ctx, module.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err == nil && existing.GetLabels()[k8s.ManagedByLabel] != k8s.ManagedByValue {
+		return false, nil
+	}
+	if err == nil {
+		err = monitors.Delete(ctx, module.Name, metav1.DeleteOptions{})
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete ServiceMonitor %s: %w", module.Name, err)
+	}
+	return true, nil
+}
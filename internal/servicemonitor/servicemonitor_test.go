@@ -0,0 +1,88 @@
+package servicemonitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Goalt/personal-server/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "infra", Labels: map[string]string{"app": "gitea"}},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{
+			{Name: "ssh", Port: 22},
+			{Name: "http", Port: 80, TargetPort: intstr.FromInt(3000)},
+			{Port: 9100},
+		}},
+	}
+}
+
+func TestConfig(t *testing.T) {
+	general := config.GeneralConfig{Domain: "example.com"}
+	module := config.Module{Name: "gitea", Namespace: "infra", Metrics: &config.ModuleMetrics{}}
+	if monitor := Config(module, general, testService()); monitor != nil {
+		t.Fatalf("Config() = %+v without serviceMonitor, want nil", monitor)
+	}
+
+	module.Metrics.ServiceMonitor = true
+	monitor := Config(module, general, testService())
+	if monitor == nil {
+		t.Fatal("Config() = nil, want a monitor")
+	}
+	if monitor.Port != "http" || monitor.Path != "/metrics" || monitor.Scheme != "http" || monitor.Selector["app"] != "gitea" {
+		t.Errorf("monitor = %+v, want the http port, /metrics and http", monitor)
+	}
+	if monitor.Metadata.Scrape != nil {
+		t.Error("ServiceMonitor metadata annotates Services for scraping as well")
+	}
+
+	module.Metrics.Port = 3000
+	if monitor := Config(module, general, testService()); monitor.Port != "http" {
+		t.Errorf("Port = %q for container port 3000, want http", monitor.Port)
+	}
+	module.Metrics.Port = 9100
+	if monitor := Config(module, general, testService()); monitor.Port != "" || monitor.TargetPort != 9100 {
+		t.Errorf("port = %q/%d for the unnamed port, want targetPort 9100", monitor.Port, monitor.TargetPort)
+	}
+}
+
+func TestMonitor_ApplyAndDelete(t *testing.T) {
+	ctx := context.Background()
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		GVR: "ServiceMonitorList",
+	})
+	module := config.Module{Name: "gitea", Namespace: "infra", Metrics: &config.ModuleMetrics{ServiceMonitor: true, Interval: "30s"}}
+	monitor := Config(module, config.GeneralConfig{}, testService())
+
+	for i := 0; i < 2; i++ {
+		if err := monitor.Apply(ctx, dyn); err != nil {
+			t.Fatalf("Apply() #%d error = %v", i+1, err)
+		}
+	}
+	created, err := dyn.Resource(GVR).Namespace("infra").Get(ctx, "gitea", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("ServiceMonitor not found: %v", err)
+	}
+	endpoints, _, _ := unstructured.NestedSlice(created.Object, "spec", "endpoints")
+	if len(endpoints) != 1 || endpoints[0].(map[string]interface{})["port"] != "http" || endpoints[0].(map[string]interface{})["interval"] != "30s" {
+		t.Errorf("endpoints = %v, want the http port every 30s", endpoints)
+	}
+	if app, _, _ := unstructured.NestedString(created.Object, "spec", "selector", "matchLabels", "app"); app != "gitea" {
+		t.Errorf("selector app = %q, want gitea", app)
+	}
+
+	if deleted, err := Delete(ctx, dyn, module); err != nil || !deleted {
+		t.Fatalf("Delete() = %v, %v, want true", deleted, err)
+	}
+	if deleted, err := Delete(ctx, dyn, module); err != nil || deleted {
+		t.Errorf("Delete() of a missing ServiceMonitor = %v, %v, want false", deleted, err)
+	}
+}
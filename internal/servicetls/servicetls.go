@@ -0,0 +1,312 @@
+// Package servicetls provisions the server certificates of in-cluster TLS
+// endpoints such as postgres and redis, either signed by the local service CA
+// or requested from cert-manager, and mounts them into pods.
+package servicetls
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Goalt/personal-server/internal/certs"
+	"github.com/Goalt/personal-server/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ModeSelfSigned signs the certificate with the CA in certs.ServiceCADir
+	ModeSelfSigned = "self-signed"
+	// ModeCertManager asks cert-manager for the certificate
+	ModeCertManager = "cert-manager"
+
+	// Keys of the TLS Secret, the same cert-manager writes
+	CertKey = "tls.crt"
+	KeyKey  = "tls.key"
+	CAKey   = "ca.crt"
+
+	// Dir is where Mount and MountCA place certificate files in containers
+	Dir = "/etc/ssl/personal-server"
+)
+
+// CertificateGVR identifies cert-manager Certificates for the dynamic client
+var CertificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// Server is the certificate of one TLS endpoint
+type Server struct {
+	SecretName string
+	Namespace  string
+	Mode       string
+	Issuer     string             // cert-manager issuer: "<name>" for an Issuer, "ClusterIssuer/<name>" for a ClusterIssuer
+	Hosts      []string           // DNS names and IP addresses the certificate is valid for
+	CADir      string             // local directory of the self-signing CA
+	Metadata   k8s.ObjectMetadata // labels and annotations of the Secret or Certificate
+}
+
+// Config reads the tls and tls_issuer keys of a module's secrets. It returns
+// nil when tls is unset, meaning the endpoint serves plain connections.
+func Config(secrets map[string]string, secretName, namespace string, hosts []string) (*Server, error) {
+	server := &Server{
+		SecretName: secretName,
+		Namespace:  namespace,
+		Mode:       secrets["tls"],
+		Issuer:     secrets["tls_issuer"],
+		Hosts:      hosts,
+		CADir:      certs.ServiceCADir,
+	}
+	switch server.Mode {
+	case "", "false":
+		return nil, nil
+	case ModeSelfSigned:
+	case ModeCertManager:
+		if server.Issuer == "" {
+			return nil, fmt.Errorf("tls_issuer is required with tls: %s", ModeCertManager)
+		}
+	default:
+		return nil, fmt.Errorf("invalid tls %q: want %s or %s", server.Mode, ModeSelfSigned, ModeCertManager)
+	}
+	return server, nil
+}
+
+// ServiceHosts returns the names a Service is reached at from inside the
+// cluster, from the short name to the fully qualified one
+func ServiceHosts(service, namespace string) []string {
+	return []string{
+		service,
+		service + "." + namespace,
+		service + "." + namespace + ".svc",
+		service + "." + namespace + ".svc.cluster.local",
+	}
+}
+
+// Secret issues a certificate from the service CA, creating the CA on first
+// use, and returns it as a kubernetes.io/tls Secret that also carries ca.crt
+func (s *Server) Secret() (*corev1.Secret, error) {
+	ca, _, err := certs.LoadOrCreateServiceCA(s.CADir)
+	if err != nil {
+		return nil, fmt.Errorf("loading service CA: %w", err)
+	}
+	certPEM, keyPEM, err := ca.IssueServerCert(s.Hosts)
+	if err != nil {
+		return nil, fmt.Errorf("issuing server certificate: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.SecretName,
+			Namespace: s.Namespace,
+			Labels: map[string]string{
+				"managed-by": "personal-server",
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			CertKey: certPEM,
+			KeyKey:  keyPEM,
+			CAKey:   ca.CertPEM,
+		},
+	}
+	k8s.SetObjectMetadata(s.Metadata, secret)
+	return secret, nil
+}
+
+// Certificate returns the cert-manager Certificate that writes the Secret
+func (s *Server) Certificate() *unstructured.Unstructured {
+	issuerKind, issuerName := "Issuer", s.Issuer
+	if kind, name, found := strings.Cut(s.Issuer, "/"); found {
+		issuerKind, issuerName = kind, name
+	}
+
+	var dnsNames, ipAddresses []interface{}
+	for _, host := range s.Hosts {
+		if net.ParseIP(host) != nil {
+			ipAddresses = append(ipAddresses, host)
+		} else {
+			dnsNames = append(dnsNames, host)
+		}
+	}
+
+	certificate := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"name":      s.SecretName,
+			"namespace": s.Namespace,
+			"labels": map[string]interface{}{
+				"managed-by": "personal-server",
+			},
+		},
+		"spec": map[string]interface{}{
+			"secretName": s.SecretName,
+			"commonName": s.Hosts[0],
+			"dnsNames":   dnsNames,
+			"usages":     []interface{}{"server auth", "digital signature", "key encipherment"},
+			"issuerRef": map[string]interface{}{
+				"group": "cert-manager.io",
+				"kind":  issuerKind,
+				"name":  issuerName,
+			},
+		},
+	}}
+	if len(ipAddresses) > 0 {
+		certificate.Object["spec"].(map[string]interface{})["ipAddresses"] = ipAddresses
+	}
+	k8s.SetObjectMetadata(s.Metadata, certificate)
+	return certificate
+}
+
+// Object returns what Generate writes for the endpoint: the self-signed
+// Secret or the cert-manager Certificate
+func (s *Server) Object() (interface{}, error) {
+	if s.Mode == ModeCertManager {
+		return s.Certificate(), nil
+	}
+	return s.Secret()
+}
+
+// Apply creates the self-signed Secret or the cert-manager Certificate,
+// replacing an existing one. dyn is only used in cert-manager mode.
+func (s *Server) Apply(ctx context.Context, client kubernetes.Interface, dyn dynamic.Interface) error {
+	if s.Mode == ModeCertManager {
+		certificate := s.Certificate()
+		certificates := dyn.Resource(CertificateGVR).Namespace(s.Namespace)
+		existing, err := certificates.Get(ctx, s.SecretName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err = certificates.Create(ctx, certificate, metav1.CreateOptions{})
+		} else if err == nil {
+			certificate.SetResourceVersion(existing.GetResourceVersion())
+			_, err = certificates.Update(ctx, certificate, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply Certificate %s: %w", s.SecretName, err)
+		}
+		return nil
+	}
+
+	secret, err := s.Secret()
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().Secrets(s.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = client.CoreV1().Secrets(s.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply Secret %s: %w", s.SecretName, err)
+	}
+	return nil
+}
+
+// Delete removes the Secret and, in cert-manager mode, the Certificate that
+// would otherwise recreate it. Missing objects are not an error.
+func (s *Server) Delete(ctx context.Context, client kubernetes.Interface, dyn dynamic.Interface) error {
+	if s.Mode == ModeCertManager {
+		err := dyn.Resource(CertificateGVR).Namespace(s.Namespace).Delete(ctx, s.SecretName, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Certificate %s: %w", s.SecretName, err)
+		}
+	}
+	err := client.CoreV1().Secrets(s.Namespace).Delete(ctx, s.SecretName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Secret %s: %w", s.SecretName, err)
+	}
+	return nil
+}
+
+// Expiry returns when the certificate in secret's tls.crt expires
+func Expiry(secret *corev1.Secret) (time.Time, error) {
+	block, _ := pem.Decode(secret.Data[CertKey])
+	if block == nil {
+		return time.Time{}, fmt.Errorf("secret %s has no PEM certificate in %s", secret.Name, CertKey)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse certificate in %s: %w", secret.Name, err)
+	}
+	return cert.NotAfter, nil
+}
+
+// Mount makes the certificate available to the first container of spec in
+// Dir. Servers such as postgres refuse a key they do not own, and Secret
+// volumes are owned by root, so an init container running image copies the
+// certificate and key into an emptyDir owned by owner (user:group) with the
+// key at 0600.
+func (s *Server) Mount(spec *corev1.PodSpec, image, owner string) {
+	spec.InitContainers = append(spec.InitContainers, corev1.Container{
+		Name:            "tls-certs",
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command: []string{"sh", "-c", `install -o "${1%:*}" -g "${1#*:}" -m 0644 /tls-secret/tls.crt "$2" && install -o "${1%:*}" -g "${1#*:}" -m 0600 /tls-secret/tls.key "$2"`,
+			"tls-certs", owner, Dir},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "tls-secret", MountPath: "/tls-secret", ReadOnly: true},
+			{Name: "tls", MountPath: Dir},
+		},
+	})
+
+	container := &spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "tls",
+		MountPath: Dir,
+		ReadOnly:  true,
+	})
+	spec.Volumes = append(spec.Volumes,
+		corev1.Volume{
+			Name: "tls-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: s.SecretName},
+			},
+		},
+		corev1.Volume{
+			Name: "tls",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	)
+}
+
+// CAPath is where MountCA places the CA named name
+func CAPath(name string) string {
+	return Dir + "/" + name + "/" + CAKey
+}
+
+// MountCA mounts only ca.crt of secretName into the first container of spec
+// at CAPath(name), for clients verifying a server, and returns the path. The
+// private key in the same Secret stays out of the pod.
+func MountCA(spec *corev1.PodSpec, name, secretName string) string {
+	volume := name + "-ca"
+	dir := path.Dir(CAPath(name))
+	container := &spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      volume,
+		MountPath: dir,
+		ReadOnly:  true,
+	})
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: volume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+				Items: []corev1.KeyToPath{
+					{Key: CAKey, Path: CAKey},
+				},
+			},
+		},
+	})
+	return CAPath(name)
+}
@@ -0,0 +1,233 @@
+package servicetls
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfig(t *testing.T) {
+	hosts := ServiceHosts("postgres", "infra")
+	tests := []struct {
+		name     string
+		secrets  map[string]string
+		wantNil  bool
+		wantMode string
+		wantErr  bool
+	}{
+		{name: "unset", secrets: nil, wantNil: true},
+		{name: "false", secrets: map[string]string{"tls": "false"}, wantNil: true},
+		{name: "self-signed", secrets: map[string]string{"tls": "self-signed"}, wantMode: ModeSelfSigned},
+		{name: "cert-manager", secrets: map[string]string{"tls": "cert-manager", "tls_issuer": "ClusterIssuer/internal"}, wantMode: ModeCertManager},
+		{name: "cert-manager without issuer", secrets: map[string]string{"tls": "cert-manager"}, wantErr: true},
+		{name: "unknown mode", secrets: map[string]string{"tls": "true"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := Config(tt.secrets, "postgres-tls", "infra", hosts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (server == nil) != tt.wantNil {
+				t.Fatalf("Config() = %+v, want nil %v", server, tt.wantNil)
+			}
+			if server != nil && server.Mode != tt.wantMode {
+				t.Errorf("Mode = %s, want %s", server.Mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestServer_Secret(t *testing.T) {
+	server := &Server{
+		SecretName: "redis-tls",
+		Namespace:  "infra",
+		Mode:       ModeSelfSigned,
+		Hosts:      append(ServiceHosts("redis", "infra"), "127.0.0.1"),
+		CADir:      t.TempDir(),
+	}
+
+	secret, err := server.Secret()
+	if err != nil {
+		t.Fatalf("Secret() error = %v", err)
+	}
+	if secret.Type != corev1.SecretTypeTLS || secret.Name != "redis-tls" || secret.Namespace != "infra" {
+		t.Errorf("Secret() = %s %s/%s, want kubernetes.io/tls infra/redis-tls", secret.Type, secret.Namespace, secret.Name)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(secret.Data[CAKey]) {
+		t.Fatal("ca.crt holds no certificate")
+	}
+	block, _ := pem.Decode(secret.Data[CertKey])
+	if block == nil {
+		t.Fatal("tls.crt holds no certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse tls.crt: %v", err)
+	}
+	for _, host := range []string{"redis.infra.svc.cluster.local", "127.0.0.1"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: host, Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err != nil {
+			t.Errorf("certificate does not verify for %s: %v", host, err)
+		}
+	}
+
+	// The CA is reused, so certificates issued later verify against the
+	// ca.crt dependents already mounted
+	again, err := server.Secret()
+	if err != nil {
+		t.Fatalf("Secret() error = %v", err)
+	}
+	if string(again.Data[CAKey]) != string(secret.Data[CAKey]) {
+		t.Error("second Secret() created a new CA")
+	}
+
+	expiry, err := Expiry(secret)
+	if err != nil {
+		t.Fatalf("Expiry() error = %v", err)
+	}
+	if !expiry.Equal(cert.NotAfter) || expiry.Before(time.Now().AddDate(1, 0, 0)) {
+		t.Errorf("Expiry() = %s, want NotAfter %s more than a year ahead", expiry, cert.NotAfter)
+	}
+}
+
+func TestServer_Certificate(t *testing.T) {
+	tests := []struct {
+		issuer   string
+		wantKind string
+		wantName string
+	}{
+		{issuer: "internal-ca", wantKind: "Issuer", wantName: "internal-ca"},
+		{issuer: "ClusterIssuer/letsencrypt", wantKind: "ClusterIssuer", wantName: "letsencrypt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.issuer, func(t *testing.T) {
+			server := &Server{SecretName: "redis-tls", Namespace: "infra", Mode: ModeCertManager, Issuer: tt.issuer, Hosts: []string{"redis", "127.0.0.1"}}
+			certificate := server.Certificate()
+
+			if certificate.GetKind() != "Certificate" || certificate.GetNamespace() != "infra" {
+				t.Errorf("Certificate() = %s in %s, want Certificate in infra", certificate.GetKind(), certificate.GetNamespace())
+			}
+			if got, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName"); got != "redis-tls" {
+				t.Errorf("secretName = %s, want redis-tls", got)
+			}
+			if got, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "kind"); got != tt.wantKind {
+				t.Errorf("issuerRef.kind = %s, want %s", got, tt.wantKind)
+			}
+			if got, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "name"); got != tt.wantName {
+				t.Errorf("issuerRef.name = %s, want %s", got, tt.wantName)
+			}
+			if got, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames"); len(got) != 1 || got[0] != "redis" {
+				t.Errorf("dnsNames = %v, want [redis]", got)
+			}
+			if got, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "ipAddresses"); len(got) != 1 || got[0] != "127.0.0.1" {
+				t.Errorf("ipAddresses = %v, want [127.0.0.1]", got)
+			}
+		})
+	}
+}
+
+func TestServer_ApplyAndDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("self-signed", func(t *testing.T) {
+		client := fake.NewSimpleClientset(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "postgres-tls", Namespace: "infra"}})
+		server := &Server{SecretName: "postgres-tls", Namespace: "infra", Mode: ModeSelfSigned, Hosts: []string{"postgres"}, CADir: t.TempDir()}
+
+		if err := server.Apply(ctx, client, nil); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		secret, err := client.CoreV1().Secrets("infra").Get(ctx, "postgres-tls", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Secret not found: %v", err)
+		}
+		if len(secret.Data[CertKey]) == 0 {
+			t.Error("existing Secret was not updated with a certificate")
+		}
+
+		if err := server.Delete(ctx, client, nil); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if err := server.Delete(ctx, client, nil); err != nil {
+			t.Errorf("Delete() of a missing Secret error = %v", err)
+		}
+	})
+
+	t.Run("cert-manager", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{CertificateGVR: "CertificateList"})
+		server := &Server{SecretName: "postgres-tls", Namespace: "infra", Mode: ModeCertManager, Issuer: "internal-ca", Hosts: []string{"postgres"}}
+
+		for i := 0; i < 2; i++ {
+			if err := server.Apply(ctx, client, dyn); err != nil {
+				t.Fatalf("Apply() #%d error = %v", i+1, err)
+			}
+		}
+		if _, err := dyn.Resource(CertificateGVR).Namespace("infra").Get(ctx, "postgres-tls", metav1.GetOptions{}); err != nil {
+			t.Fatalf("Certificate not found: %v", err)
+		}
+		if _, err := client.CoreV1().Secrets("infra").Get(ctx, "postgres-tls", metav1.GetOptions{}); err == nil {
+			t.Error("cert-manager mode created the Secret itself")
+		}
+
+		if err := server.Delete(ctx, client, dyn); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := dyn.Resource(CertificateGVR).Namespace("infra").Get(ctx, "postgres-tls", metav1.GetOptions{}); err == nil {
+			t.Error("Certificate still exists after Delete()")
+		}
+	})
+}
+
+func TestServer_Mount(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "redis"}}}
+	server := &Server{SecretName: "redis-tls"}
+	server.Mount(spec, "redis:7.2-alpine", "redis:redis")
+
+	if len(spec.InitContainers) != 1 || spec.InitContainers[0].Image != "redis:7.2-alpine" {
+		t.Fatalf("InitContainers = %+v, want one tls-certs container running redis:7.2-alpine", spec.InitContainers)
+	}
+	if got := spec.InitContainers[0].Command[4]; got != "redis:redis" {
+		t.Errorf("owner argument = %s, want redis:redis", got)
+	}
+	mounts := spec.Containers[0].VolumeMounts
+	if len(mounts) != 1 || mounts[0].Name != "tls" || mounts[0].MountPath != Dir || !mounts[0].ReadOnly {
+		t.Errorf("VolumeMounts = %+v, want tls read-only at %s", mounts, Dir)
+	}
+	if len(spec.Volumes) != 2 || spec.Volumes[0].Secret == nil || spec.Volumes[0].Secret.SecretName != "redis-tls" || spec.Volumes[1].EmptyDir == nil {
+		t.Errorf("Volumes = %+v, want the redis-tls Secret and an emptyDir", spec.Volumes)
+	}
+}
+
+func TestMountCA(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "gitea"}}}
+	path := MountCA(spec, "postgres", "postgres-tls")
+
+	if path != "/etc/ssl/personal-server/postgres/ca.crt" || path != CAPath("postgres") {
+		t.Errorf("MountCA() = %s, want /etc/ssl/personal-server/postgres/ca.crt", path)
+	}
+	mounts := spec.Containers[0].VolumeMounts
+	if len(mounts) != 1 || mounts[0].Name != "postgres-ca" || mounts[0].MountPath != "/etc/ssl/personal-server/postgres" {
+		t.Errorf("VolumeMounts = %+v, want postgres-ca at /etc/ssl/personal-server/postgres", mounts)
+	}
+	volume := spec.Volumes[0]
+	if volume.Secret == nil || volume.Secret.SecretName != "postgres-tls" || len(volume.Secret.Items) != 1 || volume.Secret.Items[0].Key != CAKey {
+		t.Errorf("Volume = %+v, want only ca.crt of postgres-tls", volume)
+	}
+}
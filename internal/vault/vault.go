@@ -0,0 +1,115 @@
+// Package vault reads module secrets from the KV version 2 secrets engine of
+// HashiCorp Vault.
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultMount is the path the KV secrets engine is mounted at by default
+const DefaultMount = "secret"
+
+// Client fetches secrets over the Vault HTTP API. Each path is read once and
+// cached, so several keys of one secret cost a single request.
+type Client struct {
+	Address   string
+	Token     string
+	Mount     string
+	Namespace string // Vault Enterprise namespace, if any
+
+	httpClient *http.Client
+	cache      map[string]map[string]interface{}
+}
+
+// New returns a client for the Vault server at address. An empty mount means
+// DefaultMount.
+func New(address, token, mount, namespace string) *Client {
+	if mount == "" {
+		mount = DefaultMount
+	}
+	return &Client{
+		Address:    strings.TrimRight(address, "/"),
+		Token:      token,
+		Mount:      strings.Trim(mount, "/"),
+		Namespace:  namespace,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		cache:      make(map[string]map[string]interface{}),
+	}
+}
+
+// Secret returns the value of key in the secret at path, relative to the
+// mount
+func (c *Client) Secret(path, key string) (string, error) {
+	path = strings.Trim(path, "/")
+	data, ok := c.cache[path]
+	if !ok {
+		var err error
+		if data, err = c.read(path); err != nil {
+			return "", err
+		}
+		c.cache[path] = data
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %s", path, key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("encoding vault secret %s key %s: %w", path, key, err)
+		}
+		return string(encoded), nil
+	}
+}
+
+// read fetches the latest version of the secret at path
+func (c *Client) read(path string) (map[string]interface{}, error) {
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", c.Address, c.Mount, (&url.URL{Path: path}).EscapedPath())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", c.Token)
+	if c.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Namespace)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault secret %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("vault secret %s not found in mount %s", path, c.Mount)
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("vault denied access to secret %s: check the token and its policies", path)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("reading vault secret %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding vault secret %s: %w", path, err)
+	}
+	return result.Data.Data, nil
+}
@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Secret(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "s.token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/infra/postgres":
+			w.Write([]byte(`{"data":{"data":{"password":"s3cret","port":5432},"metadata":{"version":2}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL+"/", "s.token", "kv", "team")
+	if value, err := client.Secret("infra/postgres", "password"); err != nil || value != "s3cret" {
+		t.Errorf("Secret(password) = %q, %v, want s3cret", value, err)
+	}
+	if value, err := client.Secret("/infra/postgres", "port"); err != nil || value != "5432" {
+		t.Errorf("Secret(port) = %q, %v, want 5432", value, err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want the secret read once", requests)
+	}
+
+	if _, err := client.Secret("infra/postgres", "user"); err == nil || !strings.Contains(err.Error(), "has no key user") {
+		t.Errorf("Secret(user) error = %v, want a missing key", err)
+	}
+	if _, err := client.Secret("infra/redis", "password"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Secret(infra/redis) error = %v, want not found", err)
+	}
+	if _, err := New(server.URL, "wrong", "kv", "team").Secret("infra/postgres", "password"); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Secret() with a wrong token error = %v, want access denied", err)
+	}
+}
@@ -1,8 +1,9 @@
+//go:build e2e
+
 package e2e
 
 import (
 	"os"
-	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -16,14 +17,10 @@ func TestBackupDecryptWithoutConfig(t *testing.T) {
 		t.Skip("Skipping e2e test in short mode")
 	}
 
-	relBinaryPath := filepath.Join("..", "..", binaryPath)
-	absBinaryPath, err := filepath.Abs(relBinaryPath)
-	if err != nil {
-		t.Fatalf("failed to resolve binary path: %v", err)
-	}
+	absBinaryPath := binaryPath
 
 	if _, err := os.Stat(absBinaryPath); os.IsNotExist(err) {
-		t.Fatalf("binary not found at %s. Run 'make build' first", absBinaryPath)
+		t.Fatalf("binary not found at %s", absBinaryPath)
 	}
 
 	// Run from a temporary directory that has no config.yaml, ensuring we test
@@ -67,14 +64,10 @@ func TestBackupDecryptMissingPassphrase(t *testing.T) {
 		t.Skip("Skipping e2e test in short mode")
 	}
 
-	relBinaryPath := filepath.Join("..", "..", binaryPath)
-	absBinaryPath, err := filepath.Abs(relBinaryPath)
-	if err != nil {
-		t.Fatalf("failed to resolve binary path: %v", err)
-	}
+	absBinaryPath := binaryPath
 
 	if _, err := os.Stat(absBinaryPath); os.IsNotExist(err) {
-		t.Fatalf("binary not found at %s. Run 'make build' first", absBinaryPath)
+		t.Fatalf("binary not found at %s", absBinaryPath)
 	}
 
 	// Run from a temporary directory without config.yaml.
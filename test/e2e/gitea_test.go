@@ -1,3 +1,5 @@
+//go:build e2e
+
 package e2e
 
 import (
@@ -19,13 +21,13 @@ func TestGiteaE2E(t *testing.T) {
 	}
 
 	// Construct full path to binary from test directory
-	fullBinaryPath := filepath.Join("..", "..", binaryPath)
+	fullBinaryPath := binaryPath
 	// Construct full path to config from test directory
 	fullConfigPath := filepath.Join("..", "..", testConfigPath)
 
 	// Verify binary exists
 	if _, err := os.Stat(fullBinaryPath); os.IsNotExist(err) {
-		t.Fatalf("binary not found at %s. Run 'make build' first", fullBinaryPath)
+		t.Fatalf("binary not found at %s", fullBinaryPath)
 	}
 
 	// Create Kubernetes client
@@ -0,0 +1,221 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// defaultKindCluster is the kind cluster created when E2E_KIND_CLUSTER is unset
+	defaultKindCluster = "personal-server-e2e"
+	kindWaitTimeout    = "120s"
+)
+
+// testNamespaces are created before the tests run; they match test-config.yaml
+var testNamespaces = []string{"e2e-test-infra", "e2e-test-hobby"}
+
+// TestMain prepares the cluster the tests run against. By default it creates a
+// kind cluster (reusing one with the same name if it exists) and deletes it
+// afterwards. Environment variables:
+//
+//	E2E_EXISTING_CLUSTER=1  use the current KUBECONFIG instead of kind
+//	E2E_KIND_CLUSTER=name   kind cluster name (default: personal-server-e2e)
+//	E2E_KEEP_CLUSTER=1      keep the kind cluster after the run
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	binDir, err := os.MkdirTemp("", "personal-server-e2e-bin-*")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to create binary directory: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(binDir)
+	binaryPath = filepath.Join(binDir, "personal-server")
+	if err := buildBinary(binaryPath); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+		return 1
+	}
+
+	if os.Getenv("E2E_EXISTING_CLUSTER") != "1" {
+		teardown, err := setupKindCluster()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+			return 1
+		}
+		defer teardown()
+	}
+
+	if err := createNamespaces(testNamespaces); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// buildBinary builds the CLI from the working tree into path, on every run so
+// a binary left over from an older checkout is never tested
+func buildBinary(path string) error {
+	cmd := exec.Command("go", "build", "-o", path, "./cmd")
+	cmd.Dir = filepath.Join("..", "..")
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to build %s: %w", path, err)
+	}
+	return nil
+}
+
+// setupKindCluster creates (or reuses) a kind cluster, points KUBECONFIG at a
+// kubeconfig for it and returns a function that removes what was created
+func setupKindCluster() (func(), error) {
+	if _, err := exec.LookPath("kind"); err != nil {
+		return nil, fmt.Errorf("kind not found in PATH; install it or set E2E_EXISTING_CLUSTER=1 to use the current cluster")
+	}
+
+	name := os.Getenv("E2E_KIND_CLUSTER")
+	if name == "" {
+		name = defaultKindCluster
+	}
+
+	kubeconfigDir, err := os.MkdirTemp("", "personal-server-e2e-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubeconfig directory: %w", err)
+	}
+	kubeconfig := filepath.Join(kubeconfigDir, "kubeconfig")
+
+	out, err := exec.Command("kind", "get", "clusters").Output()
+	if err != nil {
+		os.RemoveAll(kubeconfigDir)
+		return nil, fmt.Errorf("failed to list kind clusters: %w", err)
+	}
+	created := false
+	if !containsLine(string(out), name) {
+		fmt.Fprintf(os.Stderr, "e2e: creating kind cluster %s\n", name)
+		if err := runKind("create", "cluster", "--name", name, "--wait", kindWaitTimeout, "--kubeconfig", kubeconfig); err != nil {
+			os.RemoveAll(kubeconfigDir)
+			return nil, err
+		}
+		created = true
+	} else {
+		fmt.Fprintf(os.Stderr, "e2e: reusing kind cluster %s\n", name)
+		if err := runKind("export", "kubeconfig", "--name", name, "--kubeconfig", kubeconfig); err != nil {
+			os.RemoveAll(kubeconfigDir)
+			return nil, err
+		}
+	}
+	os.Setenv("KUBECONFIG", kubeconfig)
+
+	return func() {
+		if created && os.Getenv("E2E_KEEP_CLUSTER") != "1" {
+			fmt.Fprintf(os.Stderr, "e2e: deleting kind cluster %s\n", name)
+			if err := runKind("delete", "cluster", "--name", name); err != nil {
+				fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+			}
+		}
+		os.RemoveAll(kubeconfigDir)
+	}, nil
+}
+
+func runKind(args ...string) error {
+	cmd := exec.Command("kind", args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("kind %s failed: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func containsLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) == line {
+			return true
+		}
+	}
+	return false
+}
+
+// createNamespaces creates the test namespaces if they do not exist yet
+func createNamespaces(names []string) error {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, name := range names {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"managed-by": "personal-server"},
+		}}
+		if _, err := client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create namespace %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// kubeconfigPath returns KUBECONFIG or the default ~/.kube/config
+func kubeconfigPath() string {
+	if path := os.Getenv("KUBECONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// waitForDeploymentReady polls until the deployment has all replicas ready
+func waitForDeploymentReady(t *testing.T, client *kubernetes.Clientset, namespace, name string, timeout time.Duration) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			desired := int32(1)
+			if deployment.Spec.Replicas != nil {
+				desired = *deployment.Spec.Replicas
+			}
+			if deployment.Status.ObservedGeneration >= deployment.Generation &&
+				deployment.Status.UpdatedReplicas >= desired &&
+				deployment.Status.ReadyReplicas >= desired {
+				t.Logf("Deployment %s/%s is ready", namespace, name)
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("deployment %s/%s not ready after %s (last error: %v)", namespace, name, timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
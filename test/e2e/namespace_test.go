@@ -1,3 +1,5 @@
+//go:build e2e
+
 package e2e
 
 import (
@@ -13,9 +15,11 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// binaryPath is the CLI the tests run, built by TestMain
+var binaryPath string
+
 const (
 	testConfigPath = "test/e2e/test-config.yaml"
-	binaryPath     = "bin/personal-server"
 	timeout        = 5 * time.Minute
 
 	// Test namespace used for deploying test resources
@@ -58,8 +62,12 @@ func runCommand(t *testing.T, name string, args ...string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	// Pass the kubeconfig explicitly so the commands can only reach the
+	// cluster the harness set up
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		args = append([]string{"--kubeconfig", kubeconfig}, args...)
+	}
 	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", os.Getenv("KUBECONFIG")))
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -0,0 +1,116 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const readyTimeout = 3 * time.Minute
+
+// TestModulesBecomeReady applies each module, waits until its Deployment is
+// ready and cleans it up again
+func TestModulesBecomeReady(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping e2e test in short mode")
+	}
+
+	fullBinaryPath := binaryPath
+	fullConfigPath := filepath.Join("..", "..", testConfigPath)
+	client := createKubeClient(t)
+
+	modules := []struct {
+		module     string
+		deployment string
+	}{
+		{module: "postgres", deployment: "postgres"},
+		{module: "redis", deployment: "redis"},
+		{module: "webdav", deployment: "webdav"},
+	}
+
+	for _, tt := range modules {
+		t.Run(tt.module, func(t *testing.T) {
+			defer func() {
+				if output, err := runCommand(t, fullBinaryPath, "-config", fullConfigPath, tt.module, "clean"); err != nil {
+					t.Logf("Warning: clean failed: %v\n%s", err, output)
+				}
+				os.RemoveAll(filepath.Join("configs", tt.module))
+			}()
+
+			if output, err := runCommand(t, fullBinaryPath, "-config", fullConfigPath, tt.module, "apply"); err != nil {
+				t.Fatalf("failed to apply %s: %v", tt.module, err)
+			} else {
+				t.Logf("Apply output:\n%s", output)
+			}
+			waitForDeploymentReady(t, client, testNamespace, tt.deployment, readyTimeout)
+
+			if output, err := runCommand(t, fullBinaryPath, "-config", fullConfigPath, tt.module, "status"); err != nil {
+				t.Errorf("failed to get %s status: %v", tt.module, err)
+			} else {
+				t.Logf("Status output:\n%s", output)
+			}
+		})
+	}
+}
+
+// TestPostgresBackupRestoreRoundTrip writes a row, backs the database up,
+// drops the table and checks that restore brings the row back
+func TestPostgresBackupRestoreRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping e2e test in short mode")
+	}
+
+	fullBinaryPath := binaryPath
+	fullConfigPath := filepath.Join("..", "..", testConfigPath)
+	client := createKubeClient(t)
+
+	defer func() {
+		if output, err := runCommand(t, fullBinaryPath, "-config", fullConfigPath, "postgres", "clean"); err != nil {
+			t.Logf("Warning: clean failed: %v\n%s", err, output)
+		}
+		os.RemoveAll(filepath.Join("configs", "postgres"))
+		os.RemoveAll("backups")
+	}()
+
+	if output, err := runCommand(t, fullBinaryPath, "-config", fullConfigPath, "postgres", "apply"); err != nil {
+		t.Fatalf("failed to apply postgres: %v", err)
+	} else {
+		t.Logf("Apply output:\n%s", output)
+	}
+	waitForDeploymentReady(t, client, testNamespace, "postgres", readyTimeout)
+
+	// psql runs a statement in the postgres pod and returns its unaligned output
+	psql := func(sql string) string {
+		t.Helper()
+		output, err := runCommand(t, "kubectl", "exec", "-n", testNamespace, "deploy/postgres", "--",
+			"psql", "-U", "e2e-admin", "-d", "postgres", "-v", "ON_ERROR_STOP=1", "-tA", "-c", sql)
+		if err != nil {
+			t.Fatalf("psql %q failed: %v", sql, err)
+		}
+		return strings.TrimSpace(output)
+	}
+
+	psql("CREATE TABLE e2e_roundtrip (value text); INSERT INTO e2e_roundtrip VALUES ('before-backup');")
+
+	if output, err := runCommand(t, fullBinaryPath, "-config", fullConfigPath, "postgres", "backup"); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	} else {
+		t.Logf("Backup output:\n%s", output)
+	}
+
+	psql("DROP TABLE e2e_roundtrip;")
+
+	if output, err := runCommand(t, fullBinaryPath, "-config", fullConfigPath, "postgres", "restore", "latest"); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	} else {
+		t.Logf("Restore output:\n%s", output)
+	}
+
+	if got := psql("SELECT value FROM e2e_roundtrip;"); got != "before-backup" {
+		t.Errorf("restored value = %q, want before-backup", got)
+	}
+}